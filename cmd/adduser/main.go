@@ -18,12 +18,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
+	"time"
 
+	"prime-send-receive-go/internal/addressing"
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/common"
 	"prime-send-receive-go/internal/config"
 	"prime-send-receive-go/internal/models"
@@ -33,8 +36,6 @@ import (
 	"go.uber.org/zap"
 )
 
-var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
-
 type addressGenerationResult struct {
 	success      bool
 	assetSymbol  string
@@ -47,26 +48,6 @@ type generationStats struct {
 	failedAssets []string
 }
 
-func validateEmail(email string) error {
-	if email == "" {
-		return fmt.Errorf("email cannot be empty")
-	}
-	if !emailRegex.MatchString(email) {
-		return fmt.Errorf("invalid email format: %s", email)
-	}
-	return nil
-}
-
-func validateName(name string) error {
-	if name == "" {
-		return fmt.Errorf("name cannot be empty")
-	}
-	if len(name) < 2 {
-		return fmt.Errorf("name must be at least 2 characters")
-	}
-	return nil
-}
-
 func checkExistingAddress(ctx context.Context, services *common.Services, userId string, assetConfig common.AssetConfig) (bool, error) {
 	existingAddresses, err := services.DbService.GetAddresses(ctx, userId, assetConfig.Symbol, assetConfig.Network)
 	if err != nil {
@@ -113,14 +94,19 @@ func generateAndStoreAddress(ctx context.Context, services *common.Services, use
 		return "", fmt.Errorf("error creating deposit address: %w", err)
 	}
 
-	storedAddress, err := services.DbService.StoreAddress(ctx, store.StoreAddressParams{
+	params := store.StoreAddressParams{
 		UserId:            userId,
 		Asset:             assetConfig.Symbol,
 		Network:           assetConfig.Network,
 		Address:           depositAddress.Address,
 		WalletId:          walletId,
 		AccountIdentifier: depositAddress.Id,
-	})
+		Tag:               depositAddress.Tag,
+	}
+	if err := params.Normalize(); err != nil {
+		return "", fmt.Errorf("Prime returned an unexpected address: %w", err)
+	}
+	storedAddress, err := services.DbService.StoreAddress(ctx, params)
 	if err != nil {
 		return "", fmt.Errorf("error storing address to database: %w", err)
 	}
@@ -162,14 +148,20 @@ func processAsset(ctx context.Context, services *common.Services, userId string,
 	if primeErr == nil && len(primeAddresses) > 0 {
 		var lastAddr string
 		for _, addr := range primeAddresses {
-			_, storeErr := services.DbService.StoreAddress(ctx, store.StoreAddressParams{
+			params := store.StoreAddressParams{
 				UserId:            userId,
 				Asset:             assetConfig.Symbol,
 				Network:           assetConfig.Network,
 				Address:           addr.Address,
 				WalletId:          walletId,
 				AccountIdentifier: addr.Id,
-			})
+				Tag:               addr.Tag,
+			}
+			if err := params.Normalize(); err != nil {
+				zap.L().Warn("Skipping malformed Prime address", zap.Error(err))
+				continue
+			}
+			_, storeErr := services.DbService.StoreAddress(ctx, params)
 			if storeErr != nil {
 				zap.L().Warn("Failed to store synced address",
 					zap.String("address", addr.Address), zap.Error(storeErr))
@@ -237,7 +229,7 @@ func assignExistingAddress(ctx context.Context, services *common.Services, user
 	fmt.Printf("\n🔍 Verifying deposit address: %s\n", depositAddr)
 
 	// 1. Check that no one already owns this address in the local store.
-	existingUser, _, err := services.DbService.FindUserByAddress(ctx, depositAddr)
+	existingUser, _, err := services.DbService.FindUserByAddress(ctx, depositAddr, "")
 	if err == nil && existingUser != nil {
 		if existingUser.Id == user.Id {
 			fmt.Printf("✓ Address already assigned to this user\n")
@@ -257,8 +249,8 @@ func assignExistingAddress(ctx context.Context, services *common.Services, user
 		zap.L().Fatal("Failed to list Prime wallets", zap.Error(err))
 	}
 
-	// Known networks to search across.
-	networks := []string{"ethereum-mainnet", "base-mainnet", "bitcoin-mainnet", "solana-mainnet", "polygon-mainnet", "arbitrum-mainnet", "avalanche-mainnet"}
+	// Search every network in the asset registry.
+	networks := assets.Current().Networks()
 
 	var foundWallet *models.Wallet
 	var foundNetwork string
@@ -297,14 +289,20 @@ func assignExistingAddress(ctx context.Context, services *common.Services, user
 	fmt.Printf("✓ Found on Prime: %s wallet (%s) on %s\n", foundWallet.Symbol, foundWallet.Id[:12]+"...", foundNetwork)
 
 	// 3. Store the address mapping.
-	_, err = services.DbService.StoreAddress(ctx, store.StoreAddressParams{
+	params := store.StoreAddressParams{
 		UserId:            user.Id,
 		Asset:             foundWallet.Symbol,
 		Network:           foundNetwork,
 		Address:           foundAddr.Address,
 		WalletId:          foundWallet.Id,
 		AccountIdentifier: foundAddr.Id,
-	})
+		Tag:               foundAddr.Tag,
+	}
+	if err := params.Normalize(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		zap.L().Fatal("Invalid deposit address", zap.Error(err))
+	}
+	_, err = services.DbService.StoreAddress(ctx, params)
 	if err != nil {
 		zap.L().Fatal("Failed to store address", zap.Error(err))
 	}
@@ -317,22 +315,21 @@ func assignExistingAddress(ctx context.Context, services *common.Services, user
 		zap.String("network", foundNetwork))
 }
 
-// registerDestinationAddress stores an external withdrawal address. Looks up the
-// Prime address book to determine the asset, then stores with the correct symbol.
-func registerDestinationAddress(ctx context.Context, services *common.Services, user *models.User, destAddr string) {
+// registerDestinationAddress stores an external withdrawal address and puts it on the
+// user's withdrawal allowlist. Looks up the Prime address book to determine the asset,
+// then stores with the correct symbol.
+func registerDestinationAddress(ctx context.Context, services *common.Services, user *models.User, destAddr string, cooldown time.Duration) {
 	// Check if already assigned to someone.
-	existingUser, _, err := services.DbService.FindUserByAddress(ctx, destAddr)
-	if err == nil && existingUser != nil {
-		if existingUser.Id == user.Id {
-			fmt.Printf("  ✓ %s (already registered)\n", destAddr)
-			return
-		}
+	existingUser, _, err := services.DbService.FindUserByAddress(ctx, destAddr, "")
+	if err == nil && existingUser != nil && existingUser.Id != user.Id {
 		fmt.Printf("  ❌ %s -- already belongs to %s (%s)\n", destAddr, existingUser.Name, existingUser.Email)
 		return
 	}
+	registered := err == nil && existingUser != nil
 
 	// Look up the address in Prime's address book to get the asset symbol.
 	asset := "WITHDRAWAL" // default if not found in address book
+	state := ""
 	fmt.Printf("  🔍 Looking up %s in Prime address book...\n", destAddr)
 
 	entry, lookupErr := services.PrimeService.LookupAddressBook(ctx, services.DefaultPortfolio.Id, destAddr)
@@ -340,28 +337,73 @@ func registerDestinationAddress(ctx context.Context, services *common.Services,
 		zap.L().Debug("Address book lookup failed, using generic type",
 			zap.String("address", destAddr), zap.Error(lookupErr))
 	} else if entry != nil {
-		asset = entry.Symbol
+		asset, state = entry.Symbol, entry.State
 		fmt.Printf("  ✓ Found in address book: %s (%s, state: %s)\n", entry.Name, entry.Symbol, entry.State)
 	} else {
 		fmt.Printf("  ⚠ Not found in Prime address book, registering as generic withdrawal address\n")
 	}
 
-	_, err = services.DbService.StoreAddress(ctx, store.StoreAddressParams{
-		UserId:  user.Id,
-		Asset:   asset,
-		Network: "external",
-		Address: destAddr,
+	if registered {
+		fmt.Printf("  ✓ %s (already registered)\n", destAddr)
+	} else {
+		_, err = services.DbService.StoreAddress(ctx, store.StoreAddressParams{
+			UserId:  user.Id,
+			Asset:   asset,
+			Network: "external",
+			Address: destAddr,
+		})
+		if err != nil {
+			fmt.Printf("  ❌ %s -- failed to register: %v\n", destAddr, err)
+			return
+		}
+		fmt.Printf("  ✓ %s registered (%s)\n", destAddr, asset)
+		zap.L().Info("Withdrawal address registered",
+			zap.String("user_id", user.Id),
+			zap.String("address", destAddr),
+			zap.String("asset", asset))
+	}
+
+	// The generic type is not an asset, so without an address book entry the
+	// allowlist entry covers any asset
+	allowAsset := asset
+	if entry == nil {
+		allowAsset = ""
+	}
+	allowed, err := services.DbService.AddAllowlistAddress(ctx, store.AllowlistParams{
+		UserId:           user.Id,
+		Address:          destAddr,
+		Asset:            allowAsset,
+		AddressBookState: state,
+		Actor:            "adduser",
+		Cooldown:         cooldown,
 	})
-	if err != nil {
-		fmt.Printf("  ❌ %s -- failed to register: %v\n", destAddr, err)
-		return
+	switch {
+	case errors.Is(err, store.ErrAddressAllowlisted):
+		fmt.Printf("  ✓ %s (already allowlisted)\n", destAddr)
+	case err != nil:
+		fmt.Printf("  ❌ %s -- failed to allowlist: %v\n", destAddr, err)
+	default:
+		fmt.Printf("  ✓ %s allowlisted for withdrawals from %s\n", destAddr, allowed.ActiveAt.Format(time.RFC3339))
 	}
+}
 
-	fmt.Printf("  ✓ %s registered (%s)\n", destAddr, asset)
-	zap.L().Info("Withdrawal address registered",
-		zap.String("user_id", user.Id),
-		zap.String("address", destAddr),
-		zap.String("asset", asset))
+// parseAddressList splits a comma-separated flag value and checks each address's
+// format. The network is not known yet, so only formats recognisable from the
+// address itself are checked here; deposit addresses are checked again against
+// the network Prime reports for them.
+func parseAddressList(value string) ([]string, error) {
+	var addresses []string
+	for _, addr := range strings.Split(value, ",") {
+		if strings.TrimSpace(addr) == "" {
+			continue
+		}
+		normalized, err := addressing.NormalizeAny(addr)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, normalized)
+	}
+	return addresses, nil
 }
 
 func main() {
@@ -374,7 +416,7 @@ func main() {
 	nameFlag := flag.String("name", "", "User's full name (required)")
 	emailFlag := flag.String("email", "", "User's email address (required)")
 	depositAddrsFlag := flag.String("deposit-addresses", "", "Comma-separated existing Prime deposit addresses to assign (optional)")
-	destAddrsFlag := flag.String("withdrawal-addresses", "", "Comma-separated external withdrawal addresses for matching outgoing transactions (optional)")
+	destAddrsFlag := flag.String("withdrawal-addresses", "", "Comma-separated external withdrawal addresses for matching outgoing transactions; also added to the withdrawal allowlist (optional)")
 	flag.Parse()
 
 	// Check for stray positional args (common when comma-separated values have spaces).
@@ -398,15 +440,25 @@ func main() {
 	}
 
 	// Validate name
-	if err := validateName(*nameFlag); err != nil {
+	if err := common.ValidateName(*nameFlag); err != nil {
 		zap.L().Fatal("Invalid name", zap.Error(err))
 	}
 
 	// Validate email
-	if err := validateEmail(*emailFlag); err != nil {
+	if err := common.ValidateEmail(*emailFlag); err != nil {
 		zap.L().Fatal("Invalid email", zap.Error(err))
 	}
 
+	// Validate address formats before touching Prime or the ledger
+	depositAddrs, err := parseAddressList(*depositAddrsFlag)
+	if err != nil {
+		zap.L().Fatal("Invalid deposit address", zap.Error(err))
+	}
+	destAddrs, err := parseAddressList(*destAddrsFlag)
+	if err != nil {
+		zap.L().Fatal("Invalid withdrawal address", zap.Error(err))
+	}
+
 	zap.L().Info("Starting user creation process",
 		zap.String("name", *nameFlag),
 		zap.String("email", *emailFlag))
@@ -464,24 +516,16 @@ func main() {
 	}
 
 	// If --deposit-addresses was provided, assign each one to this user.
-	hasExplicitDeposits := *depositAddrsFlag != ""
-	if hasExplicitDeposits {
-		for _, addr := range strings.Split(*depositAddrsFlag, ",") {
-			addr = strings.TrimSpace(addr)
-			if addr != "" {
-				assignExistingAddress(ctx, services, user, addr)
-			}
-		}
+	hasExplicitDeposits := len(depositAddrs) > 0
+	for _, addr := range depositAddrs {
+		assignExistingAddress(ctx, services, user, addr)
 	}
 
-	// If --withdrawal-addresses was provided, register them for withdrawal matching.
-	if *destAddrsFlag != "" {
+	// If --withdrawal-addresses was provided, register them for withdrawal matching and the allowlist.
+	if len(destAddrs) > 0 {
 		fmt.Println("Registering withdrawal addresses...")
-		for _, addr := range strings.Split(*destAddrsFlag, ",") {
-			addr = strings.TrimSpace(addr)
-			if addr != "" {
-				registerDestinationAddress(ctx, services, user, addr)
-			}
+		for _, addr := range destAddrs {
+			registerDestinationAddress(ctx, services, user, addr, cfg.Withdrawal.AllowlistCooldown)
 		}
 	}
 
@@ -0,0 +1,278 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/addressing"
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: allowlist <command> [flags]
+
+Commands:
+  add     Allow withdrawals to an address once its cooldown has passed
+  remove  Stop allowing withdrawals to an address
+  list    Show a user's allowlisted addresses and the allowlist audit log
+
+Run "allowlist <command> --help" for the command's flags.
+`)
+}
+
+// userFlags selects the user a command acts on
+type userFlags struct {
+	email  *string
+	userId *string
+}
+
+func addUserFlags(fs *flag.FlagSet) userFlags {
+	return userFlags{
+		email:  fs.String("email", "", "User email"),
+		userId: fs.String("user-id", "", "User id"),
+	}
+}
+
+func (f userFlags) resolve(ctx context.Context, db store.LedgerStore) (*models.User, error) {
+	switch {
+	case *f.userId != "":
+		return db.GetUserById(ctx, *f.userId)
+	case *f.email != "":
+		return db.GetUserByEmail(ctx, *f.email)
+	default:
+		return nil, fmt.Errorf("--email or --user-id is required")
+	}
+}
+
+func runAdd(ctx context.Context, cfg *models.Config, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	user := addUserFlags(fs)
+	address := fs.String("address", "", "Withdrawal destination address (required)")
+	tag := fs.String("tag", "", "Destination tag or memo (required with a tag-based --network such as xrp-mainnet)")
+	asset := fs.String("asset", "", "Only allow withdrawals of this asset (default: the address book entry's asset, else any)")
+	network := fs.String("network", "", "Only allow withdrawals on this network (e.g. base-mainnet), and check the address format strictly")
+	cooldown := fs.Duration("cooldown", cfg.Withdrawal.AllowlistCooldown, "How long the address stays locked (default: WITHDRAWAL_ALLOWLIST_COOLDOWN)")
+	actor := fs.String("actor", os.Getenv("USER"), "Operator making the change (default: $USER)")
+	note := fs.String("note", "", "Free-text note for the audit log")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *address == "" {
+		return fmt.Errorf("--address is required")
+	}
+	if *network != "" {
+		normalized, err := addressing.Normalize(*network, *address)
+		if err != nil {
+			return err
+		}
+		*address = normalized
+	}
+
+	services, err := common.InitializeServices(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize services: %w", err)
+	}
+	defer services.Close()
+
+	target, err := user.resolve(ctx, services.DbService)
+	if err != nil {
+		return err
+	}
+
+	// Record what Prime knows about the address; with verification on it must be there
+	params := store.AllowlistParams{
+		UserId: target.Id, Address: *address, Tag: *tag, Asset: *asset, Network: *network, Actor: *actor, Note: *note, Cooldown: *cooldown,
+	}
+	entry, err := services.PrimeService.LookupAddressBook(ctx, services.DefaultPortfolio.Id, *address)
+	switch {
+	case err != nil && cfg.Withdrawal.VerifyAddressBook:
+		return fmt.Errorf("failed to look up address book: %w", err)
+	case err != nil:
+		zap.L().Warn("Address book lookup failed", zap.String("address", *address), zap.Error(err))
+	case entry != nil && params.Asset != "" && entry.Symbol != "" && !strings.EqualFold(entry.Symbol, params.Asset):
+		return fmt.Errorf("address book entry %q is for %s, not %s", entry.Name, entry.Symbol, params.Asset)
+	case entry != nil:
+		if params.Asset == "" {
+			params.Asset = entry.Symbol
+		}
+		params.AddressBookState = entry.State
+		fmt.Printf("\n🔍 Found in address book: %s (%s, state: %s)\n", entry.Name, entry.Symbol, entry.State)
+	case cfg.Withdrawal.VerifyAddressBook:
+		return fmt.Errorf("%s is not in the Prime address book; add it there first", *address)
+	default:
+		fmt.Printf("\n⚠ %s is not in the Prime address book\n", *address)
+	}
+
+	added, err := api.NewLedgerService(services.DbService).AddAllowlistAddress(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ %s allowlisted for %s (%s) by %s\n", models.AllowlistDestination(added.Address, added.Tag), target.Name, target.Email, added.AddedBy)
+	fmt.Printf("   Withdrawals to it are allowed from %s\n\n", added.ActiveAt.Format(time.RFC3339))
+	return nil
+}
+
+func runRemove(ctx context.Context, cfg *models.Config, args []string) error {
+	fs := flag.NewFlagSet("remove", flag.ExitOnError)
+	user := addUserFlags(fs)
+	address := fs.String("address", "", "Withdrawal destination address (required)")
+	tag := fs.String("tag", "", "Destination tag or memo the address was allowlisted with")
+	actor := fs.String("actor", os.Getenv("USER"), "Operator making the change (default: $USER)")
+	note := fs.String("note", "", "Free-text note for the audit log")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *address == "" {
+		return fmt.Errorf("--address is required")
+	}
+
+	db, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	target, err := user.resolve(ctx, db)
+	if err != nil {
+		return err
+	}
+	err = api.NewLedgerService(db).RemoveAllowlistAddress(ctx, store.AllowlistParams{
+		UserId: target.Id, Address: *address, Tag: *tag, Actor: *actor, Note: *note,
+	})
+	destination := models.AllowlistDestination(*address, *tag)
+	if err != nil {
+		if errors.Is(err, store.ErrAddressNotAllowlisted) {
+			fmt.Printf("\n❌ %s is not on the allowlist for %s\n", destination, target.Email)
+		}
+		return err
+	}
+
+	fmt.Printf("\n🗑  %s removed from the allowlist for %s (%s) by %s\n\n", destination, target.Name, target.Email, *actor)
+	return nil
+}
+
+func runList(ctx context.Context, cfg *models.Config, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	user := addUserFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	target, err := user.resolve(ctx, db)
+	if err != nil {
+		return err
+	}
+	service := api.NewLedgerService(db)
+	entries, err := service.GetAllowlist(ctx, target.Id)
+	if err != nil {
+		return fmt.Errorf("failed to get allowlist: %w", err)
+	}
+	events, err := service.GetAllowlistEvents(ctx, target.Id)
+	if err != nil {
+		return fmt.Errorf("failed to get allowlist events: %w", err)
+	}
+
+	now := time.Now().UTC()
+	common.PrintHeader(fmt.Sprintf("WITHDRAWAL ALLOWLIST %s (%s)", target.Name, target.Email), common.DefaultWidth)
+	if !cfg.Withdrawal.EnforceAllowlist {
+		fmt.Println("⚠ Not enforced (WITHDRAWAL_ALLOWLIST_ENFORCE=false)")
+	}
+
+	fmt.Printf("\n┌─ Addresses (%d)\n", len(entries))
+	for i, e := range entries {
+		status := "active"
+		if !e.Usable(now) {
+			status = "cooling down until " + e.ActiveAt.Format(time.RFC3339)
+		}
+		details := []string{"added " + e.AddedAt.Format(time.RFC3339) + " by " + e.AddedBy}
+		if e.Asset != "" {
+			details = append(details, e.Asset)
+		}
+		if e.Network != "" {
+			details = append(details, e.Network)
+		}
+		if e.AddressBookState != "" {
+			details = append(details, "address book: "+e.AddressBookState)
+		}
+		fmt.Printf("%s%s  %s  (%s)\n", common.BoxPrefix(i == len(entries)-1), models.AllowlistDestination(e.Address, e.Tag), status, strings.Join(details, ", "))
+	}
+
+	fmt.Printf("\n┌─ Audit log (%d events)\n", len(events))
+	for i, e := range events {
+		fmt.Printf("%s%s  %-6s %s  by %s", common.BoxPrefix(i == len(events)-1),
+			e.CreatedAt.Format(time.RFC3339), e.Action, models.AllowlistDestination(e.Address, e.Tag), e.Actor)
+		if e.Note != "" {
+			fmt.Printf("  (%s)", e.Note)
+		}
+		fmt.Println()
+	}
+	common.PrintFooter("", common.DefaultWidth)
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "add":
+		err = runAdd(ctx, cfg, args)
+	case "remove":
+		err = runRemove(ctx, cfg, args)
+	case "list":
+		err = runList(ctx, cfg, args)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		logger.Fatal("allowlist "+command+" failed", zap.Error(err))
+	}
+}
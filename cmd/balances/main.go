@@ -20,10 +20,14 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"prime-send-receive-go/internal/common"
 	"prime-send-receive-go/internal/config"
 	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
 	"prime-send-receive-go/internal/store"
 
 	"github.com/shopspring/decimal"
@@ -126,7 +130,64 @@ func hasNetworkDetail(balances []models.AccountBalance) bool {
 	return false
 }
 
-func printUserHeader(user common.UserInfo, balances []models.AccountBalance) {
+// valuer prices balances in USD and accumulates per-asset totals across users.
+// A nil valuer disables valuation.
+type valuer struct {
+	prices pricing.PriceSource
+	at     time.Time
+	totals map[string]decimal.Decimal
+}
+
+func newValuer(prices pricing.PriceSource, at time.Time) *valuer {
+	if prices == nil {
+		return nil
+	}
+	return &valuer{prices: prices, at: at, totals: make(map[string]decimal.Decimal)}
+}
+
+// add records a user's balances and returns their valuation
+func (v *valuer) add(ctx context.Context, balances []models.AccountBalance) (*models.Valuation, error) {
+	amounts := make(map[string]decimal.Decimal)
+	for _, b := range balances {
+		amounts[b.Asset] = amounts[b.Asset].Add(b.Balance)
+		v.totals[b.Asset] = v.totals[b.Asset].Add(b.Balance)
+	}
+	return pricing.Value(ctx, v.prices, v.at, amounts)
+}
+
+func printUserValuation(valuation *models.Valuation) {
+	if valuation == nil {
+		return
+	}
+	line := fmt.Sprintf("│  USD value: $%s", valuation.TotalUSD.StringFixed(2))
+	if len(valuation.Unpriced) > 0 {
+		line += fmt.Sprintf(" (unpriced: %s)", strings.Join(valuation.Unpriced, ", "))
+	}
+	fmt.Println(line)
+}
+
+// printSummary prints the USD value of all reported balances per asset and in total
+func (v *valuer) printSummary(ctx context.Context) error {
+	valuation, err := pricing.Value(ctx, v.prices, v.at, v.totals)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n┌─ USD valuation of all balances\n")
+	fmt.Printf("│  %-15s %22s %16s %18s\n", "ASSET", "AMOUNT", "PRICE", "USD VALUE")
+	common.PrintBoxSeparator(78)
+	for _, a := range valuation.Assets {
+		price, value := "n/a", "n/a"
+		if a.Priced {
+			price = a.Price.String()
+			value = a.USDValue.StringFixed(2)
+		}
+		fmt.Printf("│  %-15s %22s %16s %18s\n", a.Asset, a.Amount.String(), price, value)
+	}
+	fmt.Printf("└  %-15s %22s %16s %18s\n", "TOTAL", "", "", valuation.TotalUSD.StringFixed(2))
+	return nil
+}
+
+func printUserHeader(user common.UserInfo, balances []models.AccountBalance, valuation *models.Valuation) {
 	uniqueAssets := countUniqueAssets(balances)
 	fmt.Printf("\n┌─ User: %s (%s)\n", user.Name, user.Email)
 	fmt.Printf("│  ID: %s\n", user.Id)
@@ -135,10 +196,11 @@ func printUserHeader(user common.UserInfo, balances []models.AccountBalance) {
 	} else {
 		fmt.Printf("│  Assets: %d\n", uniqueAssets)
 	}
+	printUserValuation(valuation)
 	common.PrintBoxSeparator(78)
 }
 
-func processUser(ctx context.Context, user common.UserInfo, dbService store.LedgerStore, logger *zap.Logger) (int, error) {
+func processUser(ctx context.Context, user common.UserInfo, dbService store.LedgerStore, v *valuer, logger *zap.Logger) (int, error) {
 	balances, err := dbService.GetAllUserBalances(ctx, user.Id)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get balances: %w", err)
@@ -148,19 +210,127 @@ func processUser(ctx context.Context, user common.UserInfo, dbService store.Ledg
 		return 0, nil
 	}
 
-	printUserHeader(user, balances)
+	var valuation *models.Valuation
+	if v != nil {
+		if valuation, err = v.add(ctx, balances); err != nil {
+			return 0, fmt.Errorf("failed to value balances: %w", err)
+		}
+	}
+
+	printUserHeader(user, balances, valuation)
 	printBalances(balances)
+	if err := printHolds(ctx, dbService, user.Id); err != nil {
+		return 0, err
+	}
+
+	return len(balances), nil
+}
+
+// printHolds shows total, held and available for each asset with active holds
+func printHolds(ctx context.Context, dbService store.LedgerStore, userId string) error {
+	holds, err := dbService.GetActiveHolds(ctx, userId, "")
+	if err != nil {
+		return fmt.Errorf("failed to get holds: %w", err)
+	}
+	if len(holds) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var assets []string
+	for _, h := range holds {
+		if !seen[h.Asset] {
+			seen[h.Asset] = true
+			assets = append(assets, h.Asset)
+		}
+	}
+	sort.Strings(assets)
+
+	fmt.Printf("\n┌─ %d active holds\n", len(holds))
+	fmt.Printf("│  %-15s %20s %20s %20s\n", "ASSET", "TOTAL", "HELD", "AVAILABLE")
+	for i, asset := range assets {
+		detail, err := dbService.GetUserBalanceDetail(ctx, userId, asset)
+		if err != nil {
+			return fmt.Errorf("failed to get balance detail: %w", err)
+		}
+		fmt.Printf("%s%-15s %20s %20s %20s\n", common.BoxPrefix(i == len(assets)-1), asset,
+			detail.Total.String(), detail.Held.String(), detail.Available.String())
+	}
+	return nil
+}
+
+// processUserAsOf prints a user's balances as of a point in time. Candidate assets are
+// the configured assets plus anything the user currently holds, so assets that have
+// since been fully withdrawn are still reported.
+func processUserAsOf(ctx context.Context, user common.UserInfo, dbService store.LedgerStore, configuredAssets []string, asOf time.Time, v *valuer) (int, error) {
+	current, err := dbService.GetAllUserBalances(ctx, user.Id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var assets []string
+	for _, a := range configuredAssets {
+		if !seen[a] {
+			seen[a] = true
+			assets = append(assets, a)
+		}
+	}
+	for _, b := range current {
+		if !seen[b.Asset] {
+			seen[b.Asset] = true
+			assets = append(assets, b.Asset)
+		}
+	}
+	sort.Strings(assets)
+
+	var balances []models.AccountBalance
+	for _, asset := range assets {
+		bal, err := dbService.GetUserBalanceAt(ctx, user.Id, asset, asOf)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get %s balance as of %s: %w", asset, asOf.Format(time.RFC3339), err)
+		}
+		if bal.IsZero() {
+			continue
+		}
+		balances = append(balances, models.AccountBalance{UserId: user.Id, Asset: asset, Balance: bal})
+	}
+
+	if len(balances) == 0 {
+		return 0, nil
+	}
+
+	var valuation *models.Valuation
+	if v != nil {
+		if valuation, err = v.add(ctx, balances); err != nil {
+			return 0, fmt.Errorf("failed to value balances: %w", err)
+		}
+	}
+
+	fmt.Printf("\n┌─ User: %s (%s)\n", user.Name, user.Email)
+	fmt.Printf("│  ID: %s\n", user.Id)
+	fmt.Printf("│  Assets: %d\n", len(balances))
+	printUserValuation(valuation)
+	common.PrintBoxSeparator(78)
+	for i, b := range balances {
+		fmt.Printf("%s %-15s: %20s\n", common.BoxPrefix(i == len(balances)-1), b.Asset, b.Balance.String())
+	}
 
 	return len(balances), nil
 }
 
-func processUsersAndGenerateReport(ctx context.Context, users []common.UserInfo, dbService store.LedgerStore, logger *zap.Logger) balanceStats {
+func processUsersAndGenerateReport(ctx context.Context, users []common.UserInfo, dbService store.LedgerStore, configuredAssets []string, asOf time.Time, v *valuer, logger *zap.Logger) balanceStats {
 	stats := balanceStats{}
 
 	for _, user := range users {
 		stats.totalUsers++
 
-		balanceCount, err := processUser(ctx, user, dbService, logger)
+		var balanceCount int
+		var err error
+		if asOf.IsZero() {
+			balanceCount, err = processUser(ctx, user, dbService, v, logger)
+		} else {
+			balanceCount, err = processUserAsOf(ctx, user, dbService, configuredAssets, asOf, v)
+		}
 		if err != nil {
 			logger.Error("Failed to process user",
 				zap.String("user_id", user.Id),
@@ -186,8 +356,18 @@ func main() {
 
 	// Parse command line flags
 	emailFlag := flag.String("email", "", "Filter by specific user email (optional)")
+	asOfFlag := flag.String("as-of", "", "Show balances as of a point in time: RFC3339 or YYYY-MM-DD (end of day UTC)")
 	flag.Parse()
 
+	var asOf time.Time
+	if *asOfFlag != "" {
+		var err error
+		asOf, err = common.ParseAsOf(*asOfFlag)
+		if err != nil {
+			logger.Fatal("Invalid --as-of value", zap.Error(err))
+		}
+	}
+
 	logger.Info("Starting balance query")
 
 	// Load configuration
@@ -204,17 +384,45 @@ func main() {
 	}
 	defer dbService.Close()
 
+	// USD valuation is shown when a price source is configured
+	prices, err := pricing.New(cfg.Pricing)
+	if err != nil {
+		logger.Fatal("Failed to initialize price source", zap.Error(err))
+	}
+
 	// Initialize users based on filter
 	users, err := common.InitializeUsers(ctx, dbService, *emailFlag, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize users", zap.Error(err))
 	}
 
+	// Candidate assets for point-in-time queries come from the assets config.
+	var configuredAssets []string
+	if !asOf.IsZero() {
+		assetConfigs, err := common.LoadAssetConfig(cfg.Listener.AssetsFile)
+		if err != nil {
+			logger.Warn("Failed to load assets config, using current balances only", zap.Error(err))
+		}
+		for _, a := range assetConfigs {
+			configuredAssets = append(configuredAssets, a.Symbol)
+		}
+	}
+
 	// Print header
-	common.PrintHeader("USER BALANCE REPORT", common.DefaultWidth)
+	if asOf.IsZero() {
+		common.PrintHeader("USER BALANCE REPORT", common.DefaultWidth)
+	} else {
+		common.PrintHeader(fmt.Sprintf("USER BALANCE REPORT AS OF %s", asOf.UTC().Format(time.RFC3339)), common.DefaultWidth)
+	}
 
 	// Process users and generate report
-	stats := processUsersAndGenerateReport(ctx, users, dbService, logger)
+	v := newValuer(prices, asOf)
+	stats := processUsersAndGenerateReport(ctx, users, dbService, configuredAssets, asOf, v, logger)
+	if v != nil {
+		if err := v.printSummary(ctx); err != nil {
+			logger.Error("Failed to value balances", zap.Error(err))
+		}
+	}
 
 	// Print footer summary
 	summary := fmt.Sprintf("SUMMARY: %d users with balances (%d total balances across %d users queried)",
@@ -0,0 +1,224 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/costbasis"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
+
+	"go.uber.org/zap"
+)
+
+const (
+	reportGains = "gains"
+	reportLots  = "lots"
+)
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ---------------------------------------------------------------------------
+// Realized gains
+// ---------------------------------------------------------------------------
+
+func printGains(r *models.RealizedGainsReport) {
+	common.PrintHeader(fmt.Sprintf("REALIZED GAINS %d (%s)", r.Year, r.Method), common.WideWidth)
+	fmt.Printf("%-36s %-8s %-20s %-20s %18s %14s %14s %14s %-5s\n",
+		"USER", "ASSET", "ACQUIRED", "DISPOSED", "QUANTITY", "COST", "PROCEEDS", "GAIN", "TERM")
+	common.PrintSeparator("-", common.WideWidth)
+	for _, d := range r.Disposals {
+		note := ""
+		if d.Uncovered {
+			note = " (no lot)"
+		} else if !d.Priced {
+			note = " (unpriced)"
+		}
+		fmt.Printf("%-36s %-8s %-20s %-20s %18s %14s %14s %14s %-5s%s\n",
+			d.UserId, d.Asset,
+			d.AcquiredAt.UTC().Format("2006-01-02 15:04:05"),
+			d.DisposedAt.UTC().Format("2006-01-02 15:04:05"),
+			d.Quantity.String(), d.CostBasis.StringFixed(2), d.Proceeds.StringFixed(2), d.Gain.StringFixed(2), d.Term, note)
+	}
+	common.PrintSeparator("-", common.WideWidth)
+	for _, t := range r.Totals {
+		fmt.Printf("%-36s %-8s proceeds %s  cost %s  short-term %s  long-term %s  total %s\n",
+			t.UserId, t.Asset, t.Proceeds.StringFixed(2), t.CostBasis.StringFixed(2),
+			t.ShortTermGain.StringFixed(2), t.LongTermGain.StringFixed(2), t.Gain.StringFixed(2))
+	}
+	common.PrintFooter(fmt.Sprintf("%d disposals", len(r.Disposals)), common.WideWidth)
+}
+
+func gainsCSV(r *models.RealizedGainsReport) [][]string {
+	rows := [][]string{{"user_id", "asset", "lot_id", "transaction_id", "acquired_at", "disposed_at", "quantity", "cost_basis", "proceeds", "gain", "term", "priced", "uncovered"}}
+	for _, d := range r.Disposals {
+		rows = append(rows, []string{d.UserId, d.Asset, d.LotId, d.TransactionId, formatTime(d.AcquiredAt), formatTime(d.DisposedAt),
+			d.Quantity.String(), d.CostBasis.StringFixed(2), d.Proceeds.StringFixed(2), d.Gain.StringFixed(2), d.Term, strconv.FormatBool(d.Priced), strconv.FormatBool(d.Uncovered)})
+	}
+	return rows
+}
+
+// ---------------------------------------------------------------------------
+// Open lots
+// ---------------------------------------------------------------------------
+
+func printLots(lots []models.TaxLot) {
+	common.PrintHeader("OPEN TAX LOTS", common.WideWidth)
+	fmt.Printf("%-24s %-36s %-8s %-20s %18s %18s %14s\n", "LOT", "USER", "ASSET", "ACQUIRED", "QUANTITY", "REMAINING", "UNIT COST")
+	common.PrintSeparator("-", common.WideWidth)
+	for _, l := range lots {
+		cost := l.UnitCost.String()
+		if !l.Priced {
+			cost = "n/a"
+		}
+		fmt.Printf("%-24s %-36s %-8s %-20s %18s %18s %14s\n", l.Id, l.UserId, l.Asset,
+			l.AcquiredAt.UTC().Format("2006-01-02 15:04:05"), l.Quantity.String(), l.Remaining.String(), cost)
+	}
+	common.PrintFooter(fmt.Sprintf("%d open lots", len(lots)), common.WideWidth)
+}
+
+func lotsCSV(lots []models.TaxLot) [][]string {
+	rows := [][]string{{"lot_id", "user_id", "asset", "transaction_id", "acquired_at", "quantity", "remaining", "unit_cost", "priced"}}
+	for _, l := range lots {
+		rows = append(rows, []string{l.Id, l.UserId, l.Asset, l.TransactionId, formatTime(l.AcquiredAt),
+			l.Quantity.String(), l.Remaining.String(), l.UnitCost.String(), strconv.FormatBool(l.Priced)})
+	}
+	return rows
+}
+
+func write(w io.Writer, format string, v any, rows [][]string, table func()) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(rows); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	default:
+		table()
+	}
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	reportFlag := flag.String("report", reportGains, "Report: gains (realized gains for --year) or lots (open lots at the end of --year)")
+	yearFlag := flag.Int("year", time.Now().UTC().Year(), "Tax year (UTC calendar year)")
+	emailFlag := flag.String("email", "", "Only this user (by email)")
+	userIdFlag := flag.String("user-id", "", "Only this user (by id)")
+	methodFlag := flag.String("method", costbasis.MethodFIFO, "Lot selection: fifo, lifo or specific-id")
+	selectionsFlag := flag.String("selections", "", "specific-id: CSV of disposal_tx_id,lot_id,quantity")
+	formatFlag := flag.String("format", "table", "Output format: table, csv or json")
+	outputFlag := flag.String("output", "", "Write csv/json output to this file instead of stdout")
+	flag.Parse()
+
+	if *formatFlag != "table" && *formatFlag != "csv" && *formatFlag != "json" {
+		logger.Fatal("Invalid --format, expected table, csv or json", zap.String("format", *formatFlag))
+	}
+
+	opts := costbasis.Options{Method: *methodFlag}
+	if *selectionsFlag != "" {
+		if *methodFlag != costbasis.MethodSpecificID {
+			logger.Fatal("--selections requires --method specific-id")
+		}
+		selections, err := costbasis.LoadSelections(*selectionsFlag)
+		if err != nil {
+			logger.Fatal("Failed to load lot selections", zap.Error(err))
+		}
+		opts.Selections = selections
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+	prices, err := pricing.New(cfg.Pricing)
+	if err != nil {
+		logger.Fatal("Failed to initialize price source", zap.Error(err))
+	}
+	if prices == nil {
+		logger.Fatal("Cost basis needs a price source: set PRICE_SOURCE (historical recommended)")
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	userId := *userIdFlag
+	if userId == "" && *emailFlag != "" {
+		user, err := dbService.GetUserByEmail(ctx, *emailFlag)
+		if err != nil {
+			logger.Fatal("Failed to find user", zap.String("email", *emailFlag), zap.Error(err))
+		}
+		userId = user.Id
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outputFlag != "" && *formatFlag != "table" {
+		f, err := os.Create(*outputFlag)
+		if err != nil {
+			logger.Fatal("Failed to create output file", zap.Error(err))
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ledger := api.NewLedgerService(dbService).WithPriceSource(prices)
+	switch *reportFlag {
+	case reportGains:
+		var report *models.RealizedGainsReport
+		if report, err = ledger.RealizedGainsReport(ctx, *yearFlag, userId, opts); err == nil {
+			err = write(out, *formatFlag, report, gainsCSV(report), func() { printGains(report) })
+		}
+	case reportLots:
+		var lots []models.TaxLot
+		if lots, err = ledger.GetOpenLots(ctx, userId, costbasis.YearEnd(*yearFlag), opts); err == nil {
+			err = write(out, *formatFlag, lots, lotsCSV(lots), func() { printLots(lots) })
+		}
+	default:
+		logger.Fatal("Unknown --report, expected gains or lots", zap.String("report", *reportFlag))
+	}
+	if err != nil {
+		logger.Fatal("Cost basis report failed", zap.Error(err))
+	}
+	logger.Info("Cost basis report completed", zap.String("report", *reportFlag), zap.Int("year", *yearFlag), zap.String("method", opts.Method))
+}
@@ -0,0 +1,196 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/fees"
+	"prime-send-receive-go/internal/models"
+
+	"go.uber.org/zap"
+)
+
+func printReport(r *models.CustodyFeeReport) {
+	common.PrintHeader(fmt.Sprintf("CUSTODY FEES %s TO %s", r.From.Format(time.DateOnly), r.To.AddDate(0, 0, -1).Format(time.DateOnly)), common.WideWidth)
+	fmt.Printf("Rate: %d bps a year, %d days, fees rounded down to %d decimals\n", r.AnnualBps, r.Days, r.Precision)
+	common.PrintSeparator("-", common.WideWidth)
+	fmt.Printf("%-36s %-8s %26s %20s %-22s\n", "USER", "ASSET", "AVG DAILY BALANCE", "FEE", "STATUS")
+	for _, l := range r.Lines {
+		fmt.Printf("%-36s %-8s %26s %20s %-22s\n", l.UserId, l.Asset,
+			l.AverageDailyBalance.RoundFloor(r.Precision).String(), l.Fee.String(), l.Status)
+		if l.Error != "" {
+			fmt.Printf("%s%s\n", common.BoxPrefix(true), l.Error)
+		}
+	}
+	common.PrintSeparator("-", common.WideWidth)
+	for _, t := range r.Totals {
+		fmt.Printf("%-8s fees %s, charged %s, %d users\n", t.Asset, t.Fees.String(), t.Charged.String(), t.Users)
+	}
+	common.PrintFooter(fmt.Sprintf("%d fee lines", len(r.Lines)), common.WideWidth)
+}
+
+func reportCSV(r *models.CustodyFeeReport) [][]string {
+	rows := [][]string{{"user_id", "asset", "average_daily_balance", "fee", "reference", "status", "description", "error"}}
+	for _, l := range r.Lines {
+		rows = append(rows, []string{l.UserId, l.Asset, l.AverageDailyBalance.String(), l.Fee.String(),
+			l.Reference, l.Status, l.Description, l.Error})
+	}
+	return rows
+}
+
+func write(w io.Writer, format string, r *models.CustodyFeeReport) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(reportCSV(r)); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	default:
+		printReport(r)
+	}
+	return nil
+}
+
+// parsePeriod resolves --month or --from/--to; neither means the previous month
+func parsePeriod(month, from, to string) (fees.Period, error) {
+	if month != "" {
+		return fees.MonthPeriod(month)
+	}
+	if from == "" && to == "" {
+		return fees.PreviousMonth(time.Now()), nil
+	}
+	start, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return fees.Period{}, fmt.Errorf("invalid --from %q, expected YYYY-MM-DD", from)
+	}
+	end, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return fees.Period{}, fmt.Errorf("invalid --to %q, expected YYYY-MM-DD", to)
+	}
+	return fees.NewPeriod(start, end)
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	monthFlag := flag.String("month", "", "Calendar month to charge, YYYY-MM (default: previous month)")
+	fromFlag := flag.String("from", "", "Period start date, YYYY-MM-DD")
+	toFlag := flag.String("to", "", "Period end date, YYYY-MM-DD (exclusive)")
+	bpsFlag := flag.Int64("bps", cfg.CustodyFee.AnnualBps, "Annual custody fee in basis points (default: CUSTODY_FEE_BPS)")
+	precisionFlag := flag.Int("precision", int(cfg.CustodyFee.Precision), "Decimals fees are rounded down to (default: CUSTODY_FEE_PRECISION)")
+	emailFlag := flag.String("email", "", "Only this user (by email)")
+	userIdFlag := flag.String("user-id", "", "Only this user (by id)")
+	assetFlag := flag.String("asset", "", "Only this asset")
+	previewFlag := flag.Bool("preview", false, "Compute the fees without charging them")
+	scheduleFlag := flag.Duration("schedule", 0, "Keep running and charge the previous month on this interval (e.g. 24h)")
+	formatFlag := flag.String("format", "table", "Output format: table, csv or json")
+	outputFlag := flag.String("output", "", "Write csv/json output to this file instead of stdout")
+	flag.Parse()
+
+	if *formatFlag != "table" && *formatFlag != "csv" && *formatFlag != "json" {
+		logger.Fatal("Invalid --format, expected table, csv or json", zap.String("format", *formatFlag))
+	}
+	if *bpsFlag <= 0 {
+		logger.Fatal("A custody fee rate is required: set --bps or CUSTODY_FEE_BPS")
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	opts := fees.Options{
+		AnnualBps: *bpsFlag,
+		Precision: int32(*precisionFlag),
+		UserId:    *userIdFlag,
+		Asset:     *assetFlag,
+	}
+	if opts.UserId == "" && *emailFlag != "" {
+		user, err := dbService.GetUserByEmail(ctx, *emailFlag)
+		if err != nil {
+			logger.Fatal("Failed to find user", zap.String("email", *emailFlag), zap.Error(err))
+		}
+		opts.UserId = user.Id
+	}
+
+	if *scheduleFlag > 0 {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		logger.Info("Charging custody fees on a schedule", zap.Duration("interval", *scheduleFlag), zap.Int64("annual_bps", opts.AnnualBps))
+		fees.RunSchedule(ctx, dbService, opts, *scheduleFlag)
+		return
+	}
+
+	period, err := parsePeriod(*monthFlag, *fromFlag, *toFlag)
+	if err != nil {
+		logger.Fatal("Invalid fee period", zap.Error(err))
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outputFlag != "" && *formatFlag != "table" {
+		f, err := os.Create(*outputFlag)
+		if err != nil {
+			logger.Fatal("Failed to create output file", zap.Error(err))
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ledger := api.NewLedgerService(dbService)
+	var report *models.CustodyFeeReport
+	if *previewFlag {
+		report, err = ledger.PreviewCustodyFees(ctx, period, opts)
+	} else {
+		report, err = ledger.ChargeCustodyFees(ctx, period, opts)
+	}
+	if report != nil {
+		if werr := write(out, *formatFlag, report); werr != nil {
+			logger.Fatal("Failed to write report", zap.Error(werr))
+		}
+	}
+	if err != nil {
+		logger.Fatal("Custody fee run failed", zap.Error(err))
+	}
+}
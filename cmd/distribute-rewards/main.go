@@ -0,0 +1,157 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/rewards"
+
+	"go.uber.org/zap"
+)
+
+func printReport(r *models.RewardDistributionReport) {
+	status := "PLAN (dry run)"
+	if r.Posted {
+		status = "POSTED " + r.PostedAt.Format(time.RFC3339)
+	}
+	common.PrintHeader("REWARD DISTRIBUTION "+r.RewardTxId, common.WideWidth)
+	fmt.Printf("Status:       %s\n", status)
+	fmt.Printf("Reward:       %s %s\n", r.RewardAmount.String(), r.Asset)
+	if r.Method == rewards.MethodSnapshot {
+		fmt.Printf("Weighting:    balance at %s\n", r.SnapshotAt.UTC().Format(time.RFC3339))
+	} else {
+		fmt.Printf("Weighting:    average balance %s to %s\n", r.From.UTC().Format(time.RFC3339), r.To.UTC().Format(time.RFC3339))
+	}
+	fmt.Printf("Commission:   %s %s (%d bps, includes rounding)\n", r.Commission.String(), r.Asset, r.CommissionBps)
+	fmt.Printf("Distributed:  %s %s to %d users\n", r.Distributed.String(), r.Asset, len(r.Allocations))
+	fmt.Printf("Report hash:  %s\n", r.Hash)
+	common.PrintSeparator("-", common.WideWidth)
+	fmt.Printf("%-40s %28s %22s %22s\n", "USER", "WEIGHT", "SHARE", "AMOUNT")
+	for _, a := range r.Allocations {
+		fmt.Printf("%-40s %28s %22s %22s\n", a.UserId, a.Weight.String(), a.Share.StringFixed(10), a.Amount.String())
+	}
+	common.PrintFooter(fmt.Sprintf("Distribution id: %s", r.DistributionId), common.WideWidth)
+}
+
+func writeReport(path string, r *models.RewardDistributionReport) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	rewardTxFlag := flag.String("reward-tx", "", "Prime transaction id of the REWARD to distribute (required)")
+	methodFlag := flag.String("method", rewards.MethodSnapshot, "Weighting: snapshot or time-weighted")
+	snapshotFlag := flag.String("snapshot-at", "", "snapshot: balance time, RFC3339 or YYYY-MM-DD (default: reward time)")
+	fromFlag := flag.String("from", "", "time-weighted: period start, RFC3339 or YYYY-MM-DD")
+	toFlag := flag.String("to", "", "time-weighted: period end, RFC3339 (exclusive) or YYYY-MM-DD (default: reward time)")
+	commissionFlag := flag.Int64("commission-bps", 0, "Platform commission in basis points of the reward")
+	precisionFlag := flag.Int("precision", 0, "Decimals to round allocations to (default: the reward amount's decimals)")
+	dryRunFlag := flag.Bool("dry-run", false, "Show the distribution without posting it")
+	reportFlag := flag.String("report", "", "Write the distribution report as JSON to this file")
+	formatFlag := flag.String("format", "table", "Output format: table or json")
+	flag.Parse()
+
+	if *rewardTxFlag == "" {
+		logger.Fatal("--reward-tx is required")
+	}
+
+	opts := rewards.Options{
+		Method:        *methodFlag,
+		CommissionBps: *commissionFlag,
+		Precision:     int32(*precisionFlag),
+	}
+	if *snapshotFlag != "" {
+		at, err := common.ParseAsOf(*snapshotFlag)
+		if err != nil {
+			logger.Fatal("Invalid --snapshot-at value", zap.Error(err))
+		}
+		opts.SnapshotAt = at
+	}
+	from, to, err := common.ParseTimeRange(*fromFlag, *toFlag)
+	if err != nil {
+		logger.Fatal("Invalid time range", zap.Error(err))
+	}
+	opts.From, opts.To = from, to
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	ledger := api.NewLedgerService(dbService)
+	reward, err := ledger.FindReward(ctx, *rewardTxFlag)
+	if err != nil {
+		logger.Fatal("Failed to find reward", zap.Error(err))
+	}
+
+	var report *models.RewardDistributionReport
+	if *dryRunFlag {
+		report, err = ledger.PlanRewardDistribution(ctx, *reward, opts)
+	} else {
+		report, err = ledger.DistributeReward(ctx, *reward, opts)
+	}
+	if errors.Is(err, rewards.ErrAlreadyDistributed) {
+		logger.Info("Reward already distributed, nothing posted", zap.String("reward_tx_id", *rewardTxFlag))
+		return
+	}
+	if err != nil {
+		logger.Fatal("Reward distribution failed", zap.Error(err))
+	}
+
+	if *reportFlag != "" {
+		if err := writeReport(*reportFlag, report); err != nil {
+			logger.Fatal("Failed to save report", zap.Error(err))
+		}
+	}
+	if *formatFlag == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			logger.Fatal("Failed to write JSON", zap.Error(err))
+		}
+		return
+	}
+	printReport(report)
+}
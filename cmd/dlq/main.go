@@ -0,0 +1,231 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: dlq <command> [flags]
+
+Commands:
+  list     Show failed listener transactions (default: the dead-letter queue)
+  show     Show a failed transaction's last error and full Prime payload
+  retry    Queue a failed transaction for an immediate attempt by the listener
+  discard  Drop a transaction from the dead-letter queue without processing it
+
+Run "dlq <command> --help" for the command's flags.
+`)
+}
+
+// parseWithId parses a command's flags and its single transaction id
+// argument, which may come before or after the flags
+func parseWithId(fs *flag.FlagSet, args []string) (string, error) {
+	var id string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if id == "" && fs.NArg() > 0 {
+		id = fs.Arg(0)
+	}
+	if id == "" {
+		return "", fmt.Errorf("a Prime transaction id is required")
+	}
+	return id, nil
+}
+
+func runList(ctx context.Context, db store.LedgerStore, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	state := fs.String("state", models.RetryStateDead, "Entries to show: dead, queued, discarded or all")
+	portfolio := fs.String("portfolio", "", "Only show entries for this portfolio id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	query := store.FailedTxQuery{PortfolioId: *portfolio}
+	switch *state {
+	case models.RetryStateDead, models.RetryStateQueued, models.RetryStateDiscarded:
+		query.State = *state
+	case "all":
+	default:
+		return fmt.Errorf("unknown state %q", *state)
+	}
+
+	entries, err := db.GetFailedTxs(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	common.PrintHeader("FAILED LISTENER TRANSACTIONS ("+*state+")", common.WideWidth)
+	fmt.Printf("\n┌─ Transactions (%d)\n", len(entries))
+	for i, e := range entries {
+		fmt.Printf("%s%s  %-9s %-10s %-27s %s %s  attempts %d, last failed %s\n", common.BoxPrefix(i == len(entries)-1),
+			e.PrimeTxId, e.State, e.Transaction.Type, e.Transaction.Status, e.Transaction.Amount, e.Wallet.AssetSymbol,
+			e.Attempts, e.LastFailedAt.Format(time.RFC3339))
+		fmt.Printf("   %s\n", e.LastError)
+	}
+	common.PrintFooter("Run dlq show <id> for a transaction's payload", common.WideWidth)
+	return nil
+}
+
+func runShow(ctx context.Context, db store.LedgerStore, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	entry, err := db.GetFailedTx(ctx, id)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("transaction %s is not in the retry queue", id)
+	}
+	payload, err := json.MarshalIndent(entry.Transaction, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	common.PrintHeader("FAILED TRANSACTION "+entry.PrimeTxId, common.WideWidth)
+	fmt.Printf("State:         %s\n", entry.State)
+	fmt.Printf("Portfolio:     %s\n", entry.PortfolioId)
+	fmt.Printf("Wallet:        %s (%s)\n", entry.Wallet.Id, entry.Wallet.AssetSymbol)
+	fmt.Printf("Attempts:      %d\n", entry.Attempts)
+	fmt.Printf("First failed:  %s\n", entry.FirstFailedAt.Format(time.RFC3339))
+	fmt.Printf("Last failed:   %s\n", entry.LastFailedAt.Format(time.RFC3339))
+	if entry.State == models.RetryStateQueued {
+		fmt.Printf("Next attempt:  %s\n", entry.NextAttemptAt.Format(time.RFC3339))
+	}
+	if entry.UpdatedBy != "" {
+		fmt.Printf("Updated by:    %s\n", entry.UpdatedBy)
+	}
+	if entry.Note != "" {
+		fmt.Printf("Note:          %s\n", entry.Note)
+	}
+	fmt.Printf("Last error:    %s\n", entry.LastError)
+	fmt.Printf("\n┌─ Prime payload\n%s\n", payload)
+	common.PrintFooter("", common.WideWidth)
+	return nil
+}
+
+func runRetry(ctx context.Context, db store.LedgerStore, args []string) error {
+	fs := flag.NewFlagSet("retry", flag.ExitOnError)
+	actor := fs.String("actor", os.Getenv("USER"), "Operator making the change (default: $USER)")
+	note := fs.String("note", "", "What was fixed before retrying")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	entry, err := db.RequeueFailedTx(ctx, store.UpdateFailedTxParams{PrimeTxId: id, Actor: *actor, Note: *note})
+	if err != nil {
+		if errors.Is(err, store.ErrFailedTxNotFound) {
+			fmt.Printf("\n❌ %s is not in the retry queue\n", id)
+		}
+		return err
+	}
+
+	fmt.Printf("\n🔁 %s queued for retry by %s\n", entry.PrimeTxId, *actor)
+	fmt.Printf("   The listener for portfolio %s replays it on its next retry pass\n\n", entry.PortfolioId)
+	return nil
+}
+
+func runDiscard(ctx context.Context, db store.LedgerStore, args []string) error {
+	fs := flag.NewFlagSet("discard", flag.ExitOnError)
+	actor := fs.String("actor", os.Getenv("USER"), "Operator making the change (default: $USER)")
+	note := fs.String("note", "", "Why the transaction is being dropped (required)")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+	if *note == "" {
+		return fmt.Errorf("--note is required")
+	}
+
+	err = db.DiscardFailedTx(ctx, store.UpdateFailedTxParams{PrimeTxId: id, Actor: *actor, Note: *note})
+	if err != nil {
+		if errors.Is(err, store.ErrFailedTxNotFound) {
+			fmt.Printf("\n❌ %s is not in the retry queue\n", id)
+		}
+		return err
+	}
+
+	fmt.Printf("\n🗑  %s discarded by %s; the listener will not process it again\n\n", id, *actor)
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var run func(context.Context, store.LedgerStore, []string) error
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "list":
+		run = runList
+	case "show":
+		run = runShow
+	case "retry":
+		run = runRetry
+	case "discard":
+		run = runDiscard
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	if err := run(ctx, dbService, args); err != nil {
+		dbService.Close()
+		logger.Fatal("dlq "+command+" failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,96 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	fromFlag := flag.String("from", "", "Effective time from, inclusive: RFC3339 or YYYY-MM-DD")
+	toFlag := flag.String("to", "", "Effective time to: RFC3339 (exclusive) or YYYY-MM-DD (whole day included)")
+	formatFlag := flag.String("format", api.ExportFormatCSV, "Output format: csv or jsonl")
+	outputFlag := flag.String("output", "", "Output file (default stdout)")
+	chunkFlag := flag.Int("chunk-size", 0, "Transactions fetched per round trip (default: backend default)")
+	flag.Parse()
+
+	from, to, err := common.ParseTimeRange(*fromFlag, *toFlag)
+	if err != nil {
+		logger.Fatal("Invalid time range", zap.Error(err))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	var out io.Writer = os.Stdout
+	if *outputFlag != "" {
+		f, err := os.Create(*outputFlag)
+		if err != nil {
+			logger.Fatal("Failed to create output file", zap.String("path", *outputFlag), zap.Error(err))
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				logger.Error("Failed to close output file", zap.Error(err))
+			}
+		}()
+		out = f
+	}
+	buffered := bufio.NewWriter(out)
+
+	logger.Info("Starting ledger export",
+		zap.String("from", *fromFlag),
+		zap.String("to", *toFlag),
+		zap.String("format", *formatFlag))
+
+	rows, err := api.NewLedgerService(dbService).ExportPostings(ctx, store.ExportQuery{
+		From:      from,
+		To:        to,
+		ChunkSize: *chunkFlag,
+	}, *formatFlag, buffered)
+	if flushErr := buffered.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		logger.Fatal("Export failed", zap.Int("rows_written", rows), zap.Error(err))
+	}
+
+	logger.Info("Ledger export completed", zap.Int("rows", rows))
+}
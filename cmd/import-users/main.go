@@ -0,0 +1,190 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/onboarding"
+
+	"go.uber.org/zap"
+)
+
+func printReport(r *models.UserImportReport) {
+	common.PrintHeader("USER IMPORT "+r.Source, common.WideWidth)
+	fmt.Printf("%-6s %-36s %-36s %-9s %8s %8s\n", "LINE", "EMAIL", "USER ID", "STATUS", "DEPOSIT", "WITHDRAW")
+	for _, res := range r.Results {
+		fmt.Printf("%-6d %-36s %-36s %-9s %8d %8d\n", res.Line, res.Email, res.UserId, res.Status,
+			res.DepositAddresses, res.WithdrawalAddresses)
+		if res.Error != "" {
+			fmt.Printf("%s%s\n", common.BoxPrefix(true), res.Error)
+		}
+	}
+	common.PrintFooter(fmt.Sprintf("%d created, %d existing, %d skipped, %d failed in %s",
+		r.Created, r.Existing, r.Skipped, r.Failed, r.EndedAt.Sub(r.StartedAt).Round(time.Millisecond)), common.WideWidth)
+}
+
+func reportCSV(r *models.UserImportReport) [][]string {
+	rows := [][]string{{"line", "name", "email", "user_id", "status", "deposit_addresses", "withdrawal_addresses", "failed_assets", "error"}}
+	for _, res := range r.Results {
+		rows = append(rows, []string{strconv.Itoa(res.Line), res.Name, res.Email, res.UserId, res.Status,
+			strconv.Itoa(res.DepositAddresses), strconv.Itoa(res.WithdrawalAddresses), strings.Join(res.FailedAssets, ";"), res.Error})
+	}
+	return rows
+}
+
+func write(w io.Writer, format string, r *models.UserImportReport) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(reportCSV(r)); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	default:
+		printReport(r)
+	}
+	return nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	fileFlag := flag.String("file", "", "CSV or JSONL file of users to import (required)")
+	inputFormatFlag := flag.String("input-format", "", "Input format: csv or jsonl (default: from the file extension)")
+	concurrencyFlag := flag.Int("concurrency", 4, "Rows provisioned in parallel against Prime")
+	progressFlag := flag.String("progress", "", "Progress file used to resume an interrupted import (default: <file>.progress)")
+	assetsFlag := flag.String("assets", "assets.yaml", "Assets to provision for rows without deposit addresses")
+	dryRunFlag := flag.Bool("dry-run", false, "Validate the file and exit without importing")
+	formatFlag := flag.String("format", "table", "Report format: table, csv or json")
+	outputFlag := flag.String("output", "", "Write the csv/json report to this file instead of stdout")
+	flag.Parse()
+
+	if *fileFlag == "" {
+		fmt.Println("Usage: import-users --file users.csv [--concurrency 4] [--progress users.csv.progress] [--format table|csv|json --output report.csv]")
+		os.Exit(2)
+	}
+	if *formatFlag != "table" && *formatFlag != "csv" && *formatFlag != "json" {
+		logger.Fatal("Invalid --format, expected table, csv or json", zap.String("format", *formatFlag))
+	}
+	inputFormat := *inputFormatFlag
+	if inputFormat == "" {
+		var err error
+		if inputFormat, err = onboarding.FormatFromPath(*fileFlag); err != nil {
+			logger.Fatal("Unknown input format", zap.Error(err))
+		}
+	}
+
+	rows, err := onboarding.LoadRows(*fileFlag, inputFormat)
+	if err != nil {
+		logger.Fatal("Failed to read users file", zap.Error(err))
+	}
+	if errs := onboarding.Validate(rows); len(errs) > 0 {
+		fmt.Printf("\n❌ %d problems in %s, nothing was imported:\n", len(errs), *fileFlag)
+		for _, e := range errs {
+			fmt.Printf("   %v\n", e)
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("✓ %d rows in %s are valid\n", len(rows), *fileFlag)
+	if *dryRunFlag {
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+	assets, err := common.LoadAssetConfig(*assetsFlag)
+	if err != nil {
+		logger.Fatal("Failed to load asset config", zap.Error(err))
+	}
+	services, err := common.InitializeServices(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize services", zap.Error(err))
+	}
+	defer services.Close()
+
+	progressPath := *progressFlag
+	if progressPath == "" {
+		progressPath = *fileFlag + ".progress"
+	}
+	progress, err := onboarding.OpenProgress(progressPath)
+	if err != nil {
+		logger.Fatal("Failed to open progress file", zap.Error(err))
+	}
+	defer progress.Close()
+
+	importer := &onboarding.Importer{
+		Store:             services.DbService,
+		Provisioner:       onboarding.NewPrimeProvisioner(services.PrimeService, services.DefaultPortfolio.Id, assets),
+		Assets:            assets,
+		Concurrency:       *concurrencyFlag,
+		Progress:          progress,
+		AllowlistCooldown: cfg.Withdrawal.AllowlistCooldown,
+	}
+	report, err := importer.Run(ctx, *fileFlag, rows)
+	if report != nil {
+		out := io.Writer(os.Stdout)
+		if *outputFlag != "" && *formatFlag != "table" {
+			f, ferr := os.Create(*outputFlag)
+			if ferr != nil {
+				logger.Fatal("Failed to create output file", zap.Error(ferr))
+			}
+			defer f.Close()
+			out = f
+		}
+		if werr := write(out, *formatFlag, report); werr != nil {
+			logger.Fatal("Failed to write report", zap.Error(werr))
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		fmt.Printf("\nImport interrupted; run the same command again to resume from %s\n", progressPath)
+		os.Exit(1)
+	}
+	if err != nil {
+		logger.Fatal("User import failed", zap.Error(err))
+	}
+	if report.Failed > 0 {
+		fmt.Printf("\n%d rows failed; fix them and run the same command again to retry only those rows\n", report.Failed)
+		os.Exit(1)
+	}
+}
@@ -17,18 +17,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sync"
 	"syscall"
 	"time"
 
 	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/common"
 	"prime-send-receive-go/internal/config"
 	"prime-send-receive-go/internal/formance"
+	"prime-send-receive-go/internal/leader"
 	"prime-send-receive-go/internal/listener"
 	"prime-send-receive-go/internal/models"
 
@@ -76,6 +83,14 @@ func main() {
 		portfolios = []models.Portfolio{*services.DefaultPortfolio}
 	}
 
+	// Electors outlive the listeners so leases are released after the last write.
+	electionCtx, stopElection := context.WithCancel(context.Background())
+	var electors sync.WaitGroup
+	defer func() {
+		stopElection()
+		electors.Wait()
+	}()
+
 	// Start one listener per portfolio.
 	listeners := make([]*listener.SendReceiveListener, 0, len(portfolios))
 	for _, p := range portfolios {
@@ -86,6 +101,28 @@ func main() {
 			dbSvc = fSvc.WithPortfolioID(p.Id)
 		}
 
+		var elector *leader.Elector
+		stopElector := func() {}
+		if cfg.Listener.LeaderElection {
+			elector, err = leader.NewElector(leader.Config{
+				Store:         dbSvc,
+				Name:          "listener:" + p.Id,
+				Holder:        cfg.Listener.InstanceId,
+				TTL:           cfg.Listener.LeaseTTL,
+				RenewInterval: cfg.Listener.LeaseRenewInterval,
+			})
+			if err != nil {
+				zap.L().Fatal("Invalid leader election configuration", zap.Error(err))
+			}
+			var electorCtx context.Context
+			electorCtx, stopElector = context.WithCancel(electionCtx)
+			electors.Add(1)
+			go func(e *leader.Elector) {
+				defer electors.Done()
+				e.Run(electorCtx)
+			}(elector)
+		}
+
 		apiSvc := api.NewLedgerService(dbSvc)
 		l := listener.NewSendReceiveListener(listener.SendReceiveListenerConfig{
 			PrimeService:    services.PrimeService,
@@ -95,6 +132,16 @@ func main() {
 			LookbackWindow:  cfg.Listener.LookbackWindow,
 			PollingInterval: cfg.Listener.PollingInterval,
 			CleanupInterval: cfg.Listener.CleanupInterval,
+
+			DiscoveryInterval: cfg.Listener.DiscoveryInterval,
+			OnWalletEvent:     printWalletEvent,
+			PollConcurrency:   cfg.Listener.PollConcurrency,
+			MaxPollInterval:   cfg.Listener.MaxPollInterval,
+			Elector:           elector,
+
+			RetryPolicy:              cfg.Listener.RetryPolicy,
+			DeadLetterAlertThreshold: cfg.Listener.DeadLetterAlertThreshold,
+			OnDeadLetter:             deadLetterAlerter(cfg.Listener.DeadLetterWebhook),
 		})
 
 		zap.L().Info("Starting listener for portfolio",
@@ -106,6 +153,8 @@ func main() {
 				zap.String("portfolio_id", p.Id),
 				zap.String("portfolio_name", p.Name),
 				zap.Error(err))
+			// Don't hold a lease for a portfolio nothing is processing
+			stopElector()
 			continue
 		}
 		listeners = append(listeners, l)
@@ -120,6 +169,14 @@ func main() {
 		zap.Int("portfolios", len(portfolios)))
 	zap.L().Info("Press Ctrl+C to stop")
 
+	if cfg.Listener.StatusAddr != "" {
+		go serveStatus(cfg.Listener.StatusAddr, listeners)
+	}
+
+	if cfg.Listener.AssetsReloadInterval > 0 {
+		go watchAssetsFiles(ctx, cfg, services, assetsFile, listeners)
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
@@ -150,3 +207,88 @@ func main() {
 		zap.L().Warn("Forced shutdown after timeout")
 	}
 }
+
+// watchAssetsFiles reloads the asset registry when the assets file changes and
+// rediscovers wallets on every listener when it or the --assets filter changes.
+func watchAssetsFiles(ctx context.Context, cfg *models.Config, services *common.Services, filterFile string, listeners []*listener.SendReceiveListener) {
+	rediscover := func(reason string) {
+		for _, l := range listeners {
+			if err := l.Rediscover(ctx, reason); err != nil {
+				zap.L().Warn("Wallet rediscovery failed, keeping current wallets",
+					zap.String("reason", reason),
+					zap.Error(err))
+			}
+		}
+	}
+	onError := func(err error) {
+		zap.L().Warn("Failed to check assets file", zap.Error(err))
+	}
+
+	if filterFile != "" && filepath.Clean(filterFile) != filepath.Clean(cfg.Assets.File) {
+		go assets.WatchFile(ctx, filterFile, cfg.Listener.AssetsReloadInterval, func() {
+			zap.L().Info("Assets filter file changed", zap.String("file", filterFile))
+			rediscover("assets filter changed")
+		}, onError)
+	}
+
+	assets.WatchFile(ctx, cfg.Assets.File, cfg.Listener.AssetsReloadInterval, func() {
+		zap.L().Info("Assets file changed", zap.String("file", cfg.Assets.File))
+		if err := common.ReloadAssetRegistry(ctx, cfg, services); err != nil {
+			zap.L().Error("Keeping current asset registry", zap.Error(err))
+			return
+		}
+		rediscover("assets file changed")
+	}, onError)
+}
+
+// serveStatus exposes the listeners' scheduler stats at /status
+func serveStatus(addr string, listeners []*listener.SendReceiveListener) {
+	mux := http.NewServeMux()
+	mux.Handle("/status", listener.StatusHandler(listeners))
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	zap.L().Info("Serving listener status", zap.String("addr", addr))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		zap.L().Error("Listener status server failed", zap.Error(err))
+	}
+}
+
+// printWalletEvent prints monitored wallet membership changes to the console
+func printWalletEvent(event models.WalletEvent) {
+	marker := "+"
+	if event.Type == models.WalletEventRemoved {
+		marker = "-"
+	}
+	fmt.Printf("%s Wallet %s: %s (%s) in portfolio %s [%s]\n",
+		marker, event.Type, event.Wallet.AssetSymbol, event.Wallet.Id, event.PortfolioId, event.Reason)
+}
+
+// deadLetterAlerter prints dead-letter queue alerts and, if a webhook is
+// configured, posts each alert to it as JSON
+func deadLetterAlerter(webhook string) func(models.DeadLetterAlert) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return func(alert models.DeadLetterAlert) {
+		fmt.Printf("! Dead-letter queue for portfolio %s holds %d transactions; %s failed %d times: %s\n",
+			alert.PortfolioId, alert.Depth, alert.Transaction.PrimeTxId, alert.Transaction.Attempts, alert.Transaction.LastError)
+		if webhook == "" {
+			return
+		}
+		body, err := json.Marshal(alert)
+		if err != nil {
+			zap.L().Error("Failed to encode dead-letter alert", zap.Error(err))
+			return
+		}
+		// Post in the background so a slow webhook doesn't hold up retries
+		go func() {
+			resp, err := client.Post(webhook, "application/json", bytes.NewReader(body))
+			if err != nil {
+				zap.L().Error("Failed to post dead-letter alert", zap.Error(err))
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				zap.L().Error("Dead-letter alert webhook rejected alert", zap.Int("status", resp.StatusCode))
+			}
+		}()
+	}
+}
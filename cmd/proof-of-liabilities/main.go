@@ -0,0 +1,221 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/liabilities"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/pkg/liabilityproof"
+
+	"go.uber.org/zap"
+)
+
+const (
+	modeBuild  = "build"
+	modeProof  = "proof"
+	modeVerify = "verify"
+
+	// secretEnv holds the nonce secret when --secret is not given
+	secretEnv = "LIABILITY_PROOF_SECRET"
+
+	snapshotFile = "snapshot.json"
+	rootFile     = "root.json"
+)
+
+func writeJSONFile(path string, v any, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func runBuild(ctx context.Context, cfg *models.Config, asOf time.Time, secret, outDir string) error {
+	if secret == "" {
+		return fmt.Errorf("a nonce secret is required: pass --secret or set %s", secretEnv)
+	}
+	if err := os.MkdirAll(outDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var configuredAssets []string
+	assetConfigs, err := common.LoadAssetConfig(cfg.Listener.AssetsFile)
+	if err != nil {
+		zap.L().Warn("Failed to load assets config, using ledger assets only", zap.Error(err))
+	}
+	for _, a := range assetConfigs {
+		configuredAssets = append(configuredAssets, a.Symbol)
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer dbService.Close()
+
+	snapshot, err := api.NewLedgerService(dbService).BuildLiabilitySnapshot(ctx, asOf, configuredAssets, []byte(secret))
+	if err != nil {
+		return err
+	}
+	if err := snapshot.Save(filepath.Join(outDir, snapshotFile)); err != nil {
+		return err
+	}
+	summary := snapshot.Summary()
+	if err := writeJSONFile(filepath.Join(outDir, rootFile), summary, 0o644); err != nil {
+		return err
+	}
+
+	common.PrintHeader("PROOF OF LIABILITIES", common.DefaultWidth)
+	fmt.Printf("As of:      %s\n", summary.AsOf.Format(time.RFC3339))
+	fmt.Printf("Root hash:  %s\n", summary.RootHash)
+	fmt.Printf("Users:      %d\n", summary.LeafCount)
+	for _, asset := range snapshot.Assets {
+		fmt.Printf("  %-10s %s\n", asset, summary.Totals[asset].String())
+	}
+	if snapshot.Clamped > 0 {
+		fmt.Printf("Warning:    %d negative balances committed as zero\n", snapshot.Clamped)
+	}
+	common.PrintFooter(fmt.Sprintf("Publish %s; keep %s private", filepath.Join(outDir, rootFile), filepath.Join(outDir, snapshotFile)), common.DefaultWidth)
+	return nil
+}
+
+func runProof(ctx context.Context, cfg *models.Config, snapshotPath, email, userId, output string) error {
+	snapshot, err := liabilities.Load(snapshotPath)
+	if err != nil {
+		return err
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer dbService.Close()
+
+	if userId == "" {
+		if email == "" {
+			return fmt.Errorf("--email or --user-id is required")
+		}
+		user, err := dbService.GetUserByEmail(ctx, email)
+		if err != nil {
+			return fmt.Errorf("failed to find user %s: %w", email, err)
+		}
+		userId = user.Id
+	}
+
+	proof, err := api.NewLedgerService(dbService).GetLiabilityProof(snapshot, userId)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(proof)
+	}
+	return writeJSONFile(output, proof, 0o600)
+}
+
+func runVerify(proofPath, rootHash string) error {
+	data, err := os.ReadFile(proofPath)
+	if err != nil {
+		return fmt.Errorf("failed to read proof: %w", err)
+	}
+	var proof liabilityproof.Proof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return fmt.Errorf("failed to decode proof: %w", err)
+	}
+	if rootHash != "" && rootHash != proof.Root.Hash {
+		return fmt.Errorf("proof root %s does not match published root %s", proof.Root.Hash, rootHash)
+	}
+	if err := liabilityproof.Verify(proof); err != nil {
+		return fmt.Errorf("proof is invalid: %w", err)
+	}
+
+	fmt.Printf("Proof valid for user %s under root %s\n", proof.UserId, proof.Root.Hash)
+	for i, asset := range proof.Assets {
+		fmt.Printf("  %-10s balance %s of total %s\n", asset, proof.Balances[i].String(), proof.Root.Sums[i].String())
+	}
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	modeFlag := flag.String("mode", modeBuild, "Mode: build, proof or verify")
+	asOfFlag := flag.String("as-of", "", "build: snapshot time, RFC3339 or YYYY-MM-DD (end of day UTC); defaults to now")
+	secretFlag := flag.String("secret", "", "build: nonce secret (defaults to $"+secretEnv+")")
+	outFlag := flag.String("out", "liabilities", "build: output directory for snapshot.json and root.json")
+	snapshotFlag := flag.String("snapshot", filepath.Join("liabilities", snapshotFile), "proof: snapshot file written by build")
+	emailFlag := flag.String("email", "", "proof: user email")
+	userIdFlag := flag.String("user-id", "", "proof: user id")
+	outputFlag := flag.String("output", "", "proof: write the proof to this file instead of stdout")
+	proofFlag := flag.String("proof", "", "verify: proof file")
+	rootFlag := flag.String("root", "", "verify: published root hash the proof must match")
+	flag.Parse()
+
+	var err error
+	switch *modeFlag {
+	case modeBuild:
+		asOf := time.Now().UTC()
+		if *asOfFlag != "" {
+			if asOf, err = common.ParseAsOf(*asOfFlag); err != nil {
+				logger.Fatal("Invalid --as-of value", zap.Error(err))
+			}
+		}
+		secret := *secretFlag
+		if secret == "" {
+			secret = os.Getenv(secretEnv)
+		}
+		cfg, cfgErr := config.Load()
+		if cfgErr != nil {
+			logger.Fatal("Failed to load config", zap.Error(cfgErr))
+		}
+		err = runBuild(ctx, cfg, asOf, secret, *outFlag)
+	case modeProof:
+		cfg, cfgErr := config.Load()
+		if cfgErr != nil {
+			logger.Fatal("Failed to load config", zap.Error(cfgErr))
+		}
+		err = runProof(ctx, cfg, *snapshotFlag, *emailFlag, *userIdFlag, *outputFlag)
+	case modeVerify:
+		if *proofFlag == "" {
+			logger.Fatal("--proof is required")
+		}
+		err = runVerify(*proofFlag, *rootFlag)
+	default:
+		logger.Fatal("Unknown --mode, expected build, proof or verify", zap.String("mode", *modeFlag))
+	}
+	if err != nil {
+		logger.Fatal("Proof of liabilities failed", zap.Error(err))
+	}
+}
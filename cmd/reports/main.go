@@ -0,0 +1,352 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
+
+	"go.uber.org/zap"
+)
+
+const (
+	reportTrialBalance  = "trial-balance"
+	reportGeneralLedger = "general-ledger"
+	reportMovements     = "movements"
+	reportSolvency      = "solvency"
+)
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func periodTitle(from, to time.Time) string {
+	start, end := "beginning", "now"
+	if !from.IsZero() {
+		start = formatTime(from)
+	}
+	if !to.IsZero() {
+		end = formatTime(to)
+	}
+	return fmt.Sprintf("%s to %s", start, end)
+}
+
+// ---------------------------------------------------------------------------
+// Table output
+// ---------------------------------------------------------------------------
+
+func printTrialBalance(r *models.TrialBalanceReport) {
+	title := "TRIAL BALANCE"
+	if !r.AsOf.IsZero() {
+		title += " AS OF " + formatTime(r.AsOf)
+	}
+	common.PrintHeader(title, common.WideWidth)
+	fmt.Printf("%-8s %-50s %20s %20s %20s\n", "ASSET", "ACCOUNT", "DEBITS", "CREDITS", "NET")
+	common.PrintSeparator("-", common.WideWidth)
+	for _, l := range r.Lines {
+		fmt.Printf("%-8s %-50s %20s %20s %20s\n", l.Asset, l.Account, l.Debits.String(), l.Credits.String(), l.Net().String())
+	}
+	common.PrintSeparator("-", common.WideWidth)
+	for _, t := range r.Totals {
+		status := "balanced"
+		if !t.Balanced {
+			status = "OUT OF BALANCE"
+		}
+		fmt.Printf("%-8s %-50s %20s %20s\n", t.Asset, "TOTAL ("+status+")", t.Debits.String(), t.Credits.String())
+	}
+	printValuation("USER LIABILITIES (USD)", r.Liabilities)
+	common.PrintFooter(fmt.Sprintf("%d account lines across %d assets", len(r.Lines), len(r.Totals)), common.WideWidth)
+}
+
+// printValuation prints per-asset USD values and the total; nil means no price source
+func printValuation(title string, v *models.Valuation) {
+	if v == nil {
+		return
+	}
+	fmt.Printf("\n%s\n", title)
+	for _, a := range v.Assets {
+		if !a.Priced {
+			fmt.Printf("%-8s %24s %20s %20s\n", a.Asset, a.Amount.String(), "n/a", "n/a")
+			continue
+		}
+		fmt.Printf("%-8s %24s %20s %20s\n", a.Asset, a.Amount.String(), a.Price.String(), a.USDValue.StringFixed(2))
+	}
+	fmt.Printf("%-8s %24s %20s %20s\n", "TOTAL", "", "", v.TotalUSD.StringFixed(2))
+}
+
+func printGeneralLedger(r *models.GeneralLedgerReport) {
+	common.PrintHeader("GENERAL LEDGER "+periodTitle(r.From, r.To), common.WideWidth)
+	for _, a := range r.Accounts {
+		fmt.Printf("\n┌─ %s (%s)\n", a.Account, a.Asset)
+		fmt.Printf("│  Opening balance: %s\n", a.Opening.String())
+		common.PrintBoxSeparator(98)
+		for _, e := range a.Entries {
+			fmt.Printf("│  %-20s %-40s %16s %16s %16s\n",
+				e.EffectiveAt.UTC().Format("2006-01-02 15:04:05"),
+				e.Counterparty,
+				e.Debit.String(),
+				e.Credit.String(),
+				e.Balance.String())
+		}
+		fmt.Printf("└  Closing balance: %s (debits %s, credits %s, %d entries)\n",
+			a.Closing.String(), a.Debits.String(), a.Credits.String(), len(a.Entries))
+	}
+	common.PrintFooter(fmt.Sprintf("%d account ledgers", len(r.Accounts)), common.WideWidth)
+}
+
+func printMovements(r *models.MovementSummaryReport) {
+	common.PrintHeader("MOVEMENT SUMMARY "+periodTitle(r.From, r.To), common.WideWidth)
+	fmt.Printf("%-8s %-22s %20s %20s %20s %8s\n", "ASSET", "ACCOUNT TYPE", "IN", "OUT", "NET", "POSTINGS")
+	common.PrintSeparator("-", common.WideWidth)
+	for _, l := range r.Lines {
+		fmt.Printf("%-8s %-22s %20s %20s %20s %8d\n", l.Asset, l.AccountType, l.In.String(), l.Out.String(), l.Net.String(), l.Postings)
+	}
+	common.PrintFooter(fmt.Sprintf("%d movement lines", len(r.Lines)), common.WideWidth)
+}
+
+func printSolvency(r *models.SolvencyReport) {
+	title := "SOLVENCY"
+	if !r.AsOf.IsZero() {
+		title += " AS OF " + formatTime(r.AsOf)
+	}
+	common.PrintHeader(title, common.WideWidth)
+	fmt.Printf("%-8s %20s %20s %20s %14s %18s %18s\n", "ASSET", "LIABILITIES", "CUSTODY", "SURPLUS", "PRICE", "LIABILITIES USD", "SURPLUS USD")
+	common.PrintSeparator("-", common.WideWidth)
+	for _, l := range r.Lines {
+		price, liabilitiesUSD, surplusUSD := "n/a", "n/a", "n/a"
+		if l.Priced {
+			price, liabilitiesUSD, surplusUSD = l.Price.String(), l.LiabilitiesUSD.StringFixed(2), l.SurplusUSD.StringFixed(2)
+		}
+		fmt.Printf("%-8s %20s %20s %20s %14s %18s %18s\n", l.Asset, l.Liabilities.String(), l.Custody.String(), l.Surplus.String(), price, liabilitiesUSD, surplusUSD)
+	}
+	common.PrintSeparator("-", common.WideWidth)
+	fmt.Printf("Total liabilities: $%s  custody: $%s  surplus: $%s\n",
+		r.TotalLiabilitiesUSD.StringFixed(2), r.TotalCustodyUSD.StringFixed(2), r.SurplusUSD.StringFixed(2))
+	summary := fmt.Sprintf("%d assets", len(r.Lines))
+	if len(r.Unpriced) > 0 {
+		summary += fmt.Sprintf(", unpriced: %v", r.Unpriced)
+	}
+	common.PrintFooter(summary, common.WideWidth)
+}
+
+// ---------------------------------------------------------------------------
+// CSV output
+// ---------------------------------------------------------------------------
+
+func trialBalanceCSV(r *models.TrialBalanceReport) [][]string {
+	rows := [][]string{{"as_of", "asset", "account", "debits", "credits", "net"}}
+	for _, l := range r.Lines {
+		rows = append(rows, []string{formatTime(r.AsOf), l.Asset, l.Account, l.Debits.String(), l.Credits.String(), l.Net().String()})
+	}
+	return rows
+}
+
+func generalLedgerCSV(r *models.GeneralLedgerReport) [][]string {
+	rows := [][]string{{"account", "asset", "row_type", "effective_at", "transaction_id", "external_tx_id", "counterparty", "debit", "credit", "balance"}}
+	for _, a := range r.Accounts {
+		rows = append(rows, []string{a.Account, a.Asset, "opening", formatTime(r.From), "", "", "", "", "", a.Opening.String()})
+		for _, e := range a.Entries {
+			rows = append(rows, []string{a.Account, a.Asset, "entry", e.EffectiveAt.UTC().Format(time.RFC3339Nano),
+				e.TransactionId, e.ExternalTxId, e.Counterparty, e.Debit.String(), e.Credit.String(), e.Balance.String()})
+		}
+		rows = append(rows, []string{a.Account, a.Asset, "closing", formatTime(r.To), "", "", "", a.Debits.String(), a.Credits.String(), a.Closing.String()})
+	}
+	return rows
+}
+
+func movementsCSV(r *models.MovementSummaryReport) [][]string {
+	rows := [][]string{{"from", "to", "asset", "account_type", "in", "out", "net", "postings"}}
+	for _, l := range r.Lines {
+		rows = append(rows, []string{formatTime(r.From), formatTime(r.To), l.Asset, l.AccountType,
+			l.In.String(), l.Out.String(), l.Net.String(), strconv.Itoa(l.Postings)})
+	}
+	return rows
+}
+
+func solvencyCSV(r *models.SolvencyReport) [][]string {
+	rows := [][]string{{"as_of", "asset", "liabilities", "custody", "surplus", "price", "liabilities_usd", "custody_usd", "surplus_usd"}}
+	for _, l := range r.Lines {
+		row := []string{formatTime(r.AsOf), l.Asset, l.Liabilities.String(), l.Custody.String(), l.Surplus.String(), "", "", "", ""}
+		if l.Priced {
+			row[5], row[6], row[7], row[8] = l.Price.String(), l.LiabilitiesUSD.StringFixed(2), l.CustodyUSD.StringFixed(2), l.SurplusUSD.StringFixed(2)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeCSV(rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+func runTrialBalance(ctx context.Context, ledger *api.LedgerService, asOf time.Time, format string) error {
+	report, err := ledger.TrialBalanceReport(ctx, asOf)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		return writeJSON(report)
+	case "csv":
+		return writeCSV(trialBalanceCSV(report))
+	default:
+		printTrialBalance(report)
+		return nil
+	}
+}
+
+func runGeneralLedger(ctx context.Context, ledger *api.LedgerService, from, to time.Time, accountPrefix, format string) error {
+	report, err := ledger.GeneralLedgerReport(ctx, from, to, accountPrefix)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		return writeJSON(report)
+	case "csv":
+		return writeCSV(generalLedgerCSV(report))
+	default:
+		printGeneralLedger(report)
+		return nil
+	}
+}
+
+func runMovements(ctx context.Context, ledger *api.LedgerService, from, to time.Time, format string) error {
+	report, err := ledger.MovementSummaryReport(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		return writeJSON(report)
+	case "csv":
+		return writeCSV(movementsCSV(report))
+	default:
+		printMovements(report)
+		return nil
+	}
+}
+
+func runSolvency(ctx context.Context, ledger *api.LedgerService, asOf time.Time, format string) error {
+	report, err := ledger.SolvencyReport(ctx, asOf)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		return writeJSON(report)
+	case "csv":
+		return writeCSV(solvencyCSV(report))
+	default:
+		printSolvency(report)
+		return nil
+	}
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	reportFlag := flag.String("report", reportTrialBalance, "Report: trial-balance, general-ledger, movements or solvency")
+	asOfFlag := flag.String("as-of", "", "trial-balance/solvency: as of RFC3339 or YYYY-MM-DD (end of day UTC)")
+	fromFlag := flag.String("from", "", "general-ledger/movements: period start, RFC3339 or YYYY-MM-DD")
+	toFlag := flag.String("to", "", "general-ledger/movements: period end, RFC3339 (exclusive) or YYYY-MM-DD (whole day included)")
+	accountFlag := flag.String("account", "", "general-ledger: only accounts starting with this prefix (e.g. users:)")
+	formatFlag := flag.String("format", "table", "Output format: table, csv or json")
+	flag.Parse()
+
+	if *formatFlag != "table" && *formatFlag != "csv" && *formatFlag != "json" {
+		logger.Fatal("Invalid --format, expected table, csv or json", zap.String("format", *formatFlag))
+	}
+
+	var asOf time.Time
+	if *asOfFlag != "" {
+		var err error
+		if asOf, err = common.ParseAsOf(*asOfFlag); err != nil {
+			logger.Fatal("Invalid --as-of value", zap.Error(err))
+		}
+	}
+	from, to, err := common.ParseTimeRange(*fromFlag, *toFlag)
+	if err != nil {
+		logger.Fatal("Invalid time range", zap.Error(err))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	// USD valuations (and historical prices for --as-of) when a price source is configured
+	prices, err := pricing.New(cfg.Pricing)
+	if err != nil {
+		logger.Fatal("Failed to initialize price source", zap.Error(err))
+	}
+
+	ledger := api.NewLedgerService(dbService).WithPriceSource(prices)
+
+	switch *reportFlag {
+	case reportTrialBalance:
+		err = runTrialBalance(ctx, ledger, asOf, *formatFlag)
+	case reportGeneralLedger:
+		err = runGeneralLedger(ctx, ledger, from, to, *accountFlag, *formatFlag)
+	case reportMovements:
+		err = runMovements(ctx, ledger, from, to, *formatFlag)
+	case reportSolvency:
+		err = runSolvency(ctx, ledger, asOf, *formatFlag)
+	default:
+		logger.Fatal("Unknown --report, expected trial-balance, general-ledger, movements or solvency", zap.String("report", *reportFlag))
+	}
+	if err != nil {
+		logger.Fatal("Report failed", zap.Error(err))
+	}
+}
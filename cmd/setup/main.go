@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"strings"
 
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/common"
 	"prime-send-receive-go/internal/config"
 	"prime-send-receive-go/internal/models"
@@ -95,6 +96,15 @@ func getOrCreateWallet(ctx context.Context, services *common.Services, assetSymb
 	return wallet, nil
 }
 
+// storeAddress checks the address against its network's format before storing it,
+// so a malformed address from Prime never reaches the ledger.
+func storeAddress(ctx context.Context, services *common.Services, params store.StoreAddressParams) (*models.Address, error) {
+	if err := params.Normalize(); err != nil {
+		return nil, err
+	}
+	return services.DbService.StoreAddress(ctx, params)
+}
+
 // createAndStoreAddress creates a deposit address via Prime API and stores it in the database
 func createAndStoreAddress(ctx context.Context, services *common.Services, user models.User, assetConfig common.AssetConfig, wallet *models.Wallet) error {
 	zap.L().Info("Creating deposit address",
@@ -117,13 +127,14 @@ func createAndStoreAddress(ctx context.Context, services *common.Services, user
 		zap.String("address", depositAddress.Address))
 
 	// Store with separate asset and network columns
-	storedAddress, err := services.DbService.StoreAddress(ctx, store.StoreAddressParams{
+	storedAddress, err := storeAddress(ctx, services, store.StoreAddressParams{
 		UserId:            user.Id,
 		Asset:             assetConfig.Symbol,
 		Network:           assetConfig.Network,
 		Address:           depositAddress.Address,
 		WalletId:          wallet.Id,
 		AccountIdentifier: depositAddress.Id,
+		Tag:               depositAddress.Tag,
 	})
 	if err != nil {
 		zap.L().Error("Error storing address to database",
@@ -168,13 +179,14 @@ func syncAddressesFromPrime(ctx context.Context, services *common.Services, user
 		zap.Int("count", len(primeAddresses)))
 
 	for _, addr := range primeAddresses {
-		_, err = services.DbService.StoreAddress(ctx, store.StoreAddressParams{
+		_, err = storeAddress(ctx, services, store.StoreAddressParams{
 			UserId:            user.Id,
 			Asset:             assetConfig.Symbol,
 			Network:           assetConfig.Network,
 			Address:           addr.Address,
 			WalletId:          wallet.Id,
 			AccountIdentifier: addr.Id,
+			Tag:               addr.Tag,
 		})
 		if err != nil {
 			zap.L().Warn("Failed to sync address",
@@ -279,13 +291,14 @@ func syncWalletAddressesToUser(ctx context.Context, services *common.Services, u
 
 	count := 0
 	for _, addr := range primeAddresses {
-		_, err := services.DbService.StoreAddress(ctx, store.StoreAddressParams{
+		_, err := storeAddress(ctx, services, store.StoreAddressParams{
 			UserId:            user.Id,
 			Asset:             wallet.Symbol,
 			Network:           network,
 			Address:           addr.Address,
 			WalletId:          wallet.Id,
 			AccountIdentifier: addr.Id,
+			Tag:               addr.Tag,
 		})
 		if err != nil {
 			zap.L().Warn("Failed to store address",
@@ -329,28 +342,8 @@ func discoverAndSync(ctx context.Context, services *common.Services) {
 	// Ensure platform account exists for unattributed addresses.
 	platformUser := ensurePlatformAccount(ctx, services)
 
-	// Load known networks from assets.yaml (if exists) or use defaults.
-	networks := []string{"ethereum-mainnet", "base-mainnet", "bitcoin-mainnet"}
-	assetConfigs, err := common.LoadAssetConfig("assets.yaml")
-	if err == nil {
-		seen := make(map[string]bool)
-		for _, ac := range assetConfigs {
-			if !seen[ac.Network] {
-				networks = append(networks, ac.Network)
-				seen[ac.Network] = true
-			}
-		}
-		// Deduplicate.
-		unique := make(map[string]bool)
-		var deduped []string
-		for _, n := range networks {
-			if !unique[n] {
-				unique[n] = true
-				deduped = append(deduped, n)
-			}
-		}
-		networks = deduped
-	}
+	// Search every network in the asset registry.
+	networks := assets.Current().Networks()
 
 	totalSynced := 0
 	for _, wallet := range allWallets {
@@ -374,13 +367,14 @@ func discoverAndSync(ctx context.Context, services *common.Services) {
 			if platformUser != nil {
 				for _, addr := range primeAddresses {
 					if !assignedAddresses[strings.ToLower(addr.Address)] {
-						_, storeErr := services.DbService.StoreAddress(ctx, store.StoreAddressParams{
+						_, storeErr := storeAddress(ctx, services, store.StoreAddressParams{
 							UserId:            platformUser.Id,
 							Asset:             wallet.Symbol,
 							Network:           network,
 							Address:           addr.Address,
 							WalletId:          wallet.Id,
 							AccountIdentifier: addr.Id,
+							Tag:               addr.Tag,
 						})
 						if storeErr == nil {
 							totalSynced++
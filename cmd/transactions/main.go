@@ -0,0 +1,268 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var csvHeader = []string{
+	"id", "user_id", "type", "asset", "amount", "status", "effective_at",
+	"network", "address", "source_address", "external_transaction_id", "tx_hash", "reference",
+}
+
+func csvRow(tx models.Transaction) []string {
+	return []string{
+		tx.Id, tx.UserId, tx.TransactionType, tx.Asset, tx.Amount.String(), tx.Status,
+		tx.EffectiveAt.UTC().Format(time.RFC3339), tx.Network, tx.Address, tx.SourceAddress,
+		tx.ExternalTransactionId, tx.TxHash, tx.Reference,
+	}
+}
+
+// jsonTransaction is the JSON shape of a transaction; models.Transaction carries
+// only db tags.
+type jsonTransaction struct {
+	Id                    string            `json:"id"`
+	UserId                string            `json:"user_id"`
+	Type                  string            `json:"type"`
+	Asset                 string            `json:"asset"`
+	Amount                decimal.Decimal   `json:"amount"`
+	BalanceAfter          decimal.Decimal   `json:"balance_after"`
+	Status                string            `json:"status"`
+	EffectiveAt           time.Time         `json:"effective_at"`
+	ProcessedAt           time.Time         `json:"processed_at"`
+	Network               string            `json:"network,omitempty"`
+	Address               string            `json:"address,omitempty"`
+	SourceAddress         string            `json:"source_address,omitempty"`
+	ExternalTransactionId string            `json:"external_transaction_id,omitempty"`
+	TxHash                string            `json:"tx_hash,omitempty"`
+	Reference             string            `json:"reference,omitempty"`
+	NetworkFees           string            `json:"network_fees,omitempty"`
+	Fees                  string            `json:"fees,omitempty"`
+	Metadata              map[string]string `json:"metadata,omitempty"`
+}
+
+func toJSON(tx models.Transaction) jsonTransaction {
+	return jsonTransaction{
+		Id:                    tx.Id,
+		UserId:                tx.UserId,
+		Type:                  tx.TransactionType,
+		Asset:                 tx.Asset,
+		Amount:                tx.Amount,
+		BalanceAfter:          tx.BalanceAfter,
+		Status:                tx.Status,
+		EffectiveAt:           tx.EffectiveAt,
+		ProcessedAt:           tx.ProcessedAt,
+		Network:               tx.Network,
+		Address:               tx.Address,
+		SourceAddress:         tx.SourceAddress,
+		ExternalTransactionId: tx.ExternalTransactionId,
+		TxHash:                tx.TxHash,
+		Reference:             tx.Reference,
+		NetworkFees:           tx.NetworkFees,
+		Fees:                  tx.Fees,
+		Metadata:              tx.Metadata,
+	}
+}
+
+func shorten(s string, n int) string {
+	if len(s) > n {
+		return s[:n-3] + "..."
+	}
+	return s
+}
+
+func printTable(transactions []models.Transaction) {
+	fmt.Printf("%-20s %-10s %-12s %-8s %20s %-10s %-18s %s\n",
+		"EFFECTIVE", "USER", "TYPE", "ASSET", "AMOUNT", "STATUS", "NETWORK", "EXTERNAL ID")
+	common.PrintSeparator("-", common.WideWidth)
+	for _, tx := range transactions {
+		fmt.Printf("%-20s %-10s %-12s %-8s %20s %-10s %-18s %s\n",
+			tx.EffectiveAt.UTC().Format("2006-01-02 15:04:05"),
+			shorten(tx.UserId, 10),
+			shorten(tx.TransactionType, 12),
+			tx.Asset,
+			tx.Amount.String(),
+			tx.Status,
+			shorten(tx.Network, 18),
+			tx.ExternalTransactionId)
+	}
+}
+
+func parseAmount(value, name string) (*decimal.Decimal, error) {
+	if value == "" {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+	return &d, nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	emailFlag := flag.String("email", "", "Filter by user email")
+	userIdFlag := flag.String("user-id", "", "Filter by user ID")
+	assetFlag := flag.String("asset", "", "Filter by asset symbol (e.g. USDC)")
+	networkFlag := flag.String("network", "", "Filter by network (e.g. base-mainnet)")
+	typeFlag := flag.String("type", "", "Filter by transaction type (deposit, withdrawal, ...)")
+	statusFlag := flag.String("status", "", "Filter by status")
+	fromFlag := flag.String("from", "", "Effective time from, inclusive: RFC3339 or YYYY-MM-DD")
+	toFlag := flag.String("to", "", "Effective time to: RFC3339 (exclusive) or YYYY-MM-DD (whole day included)")
+	minAmountFlag := flag.String("min-amount", "", "Minimum absolute amount")
+	maxAmountFlag := flag.String("max-amount", "", "Maximum absolute amount")
+	externalIdFlag := flag.String("external-id", "", "Filter by Prime transaction ID")
+	hashFlag := flag.String("hash", "", "Filter by on-chain transaction hash")
+	addressFlag := flag.String("address", "", "Filter by deposit, source or destination address")
+	limitFlag := flag.Int("limit", 50, "Page size")
+	cursorFlag := flag.String("cursor", "", "Cursor from a previous page")
+	allFlag := flag.Bool("all", false, "Follow cursors and return every matching transaction")
+	formatFlag := flag.String("format", "table", "Output format: table, json or csv")
+	flag.Parse()
+
+	if *formatFlag != "table" && *formatFlag != "json" && *formatFlag != "csv" {
+		logger.Fatal("Invalid --format, expected table, json or csv", zap.String("format", *formatFlag))
+	}
+
+	from, to, err := common.ParseTimeRange(*fromFlag, *toFlag)
+	if err != nil {
+		logger.Fatal("Invalid time range", zap.Error(err))
+	}
+	minAmount, err := parseAmount(*minAmountFlag, "min-amount")
+	if err != nil {
+		logger.Fatal("Invalid amount", zap.Error(err))
+	}
+	maxAmount, err := parseAmount(*maxAmountFlag, "max-amount")
+	if err != nil {
+		logger.Fatal("Invalid amount", zap.Error(err))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	userId := *userIdFlag
+	if *emailFlag != "" {
+		user, err := dbService.GetUserByEmail(ctx, *emailFlag)
+		if err != nil {
+			logger.Fatal("Failed to find user", zap.String("email", *emailFlag), zap.Error(err))
+		}
+		if userId != "" && userId != user.Id {
+			logger.Fatal("--email and --user-id refer to different users")
+		}
+		userId = user.Id
+	}
+
+	query := store.TransactionQuery{
+		UserId:       userId,
+		Asset:        *assetFlag,
+		Network:      *networkFlag,
+		Type:         *typeFlag,
+		Status:       *statusFlag,
+		From:         from,
+		To:           to,
+		MinAmount:    minAmount,
+		MaxAmount:    maxAmount,
+		ExternalTxId: *externalIdFlag,
+		TxHash:       *hashFlag,
+		Address:      *addressFlag,
+		Limit:        *limitFlag,
+		Cursor:       *cursorFlag,
+	}
+
+	var transactions []models.Transaction
+	var nextCursor string
+	for {
+		page, err := dbService.SearchTransactions(ctx, query)
+		if err != nil {
+			logger.Fatal("Failed to search transactions", zap.Error(err))
+		}
+		transactions = append(transactions, page.Transactions...)
+		nextCursor = page.NextCursor
+		if !*allFlag || nextCursor == "" {
+			break
+		}
+		query.Cursor = nextCursor
+	}
+
+	switch *formatFlag {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		out := struct {
+			Transactions []jsonTransaction `json:"transactions"`
+			NextCursor   string            `json:"next_cursor,omitempty"`
+		}{Transactions: make([]jsonTransaction, len(transactions)), NextCursor: nextCursor}
+		for i, tx := range transactions {
+			out.Transactions[i] = toJSON(tx)
+		}
+		if err := enc.Encode(out); err != nil {
+			logger.Fatal("Failed to write JSON", zap.Error(err))
+		}
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(csvHeader); err != nil {
+			logger.Fatal("Failed to write CSV", zap.Error(err))
+		}
+		for _, tx := range transactions {
+			if err := w.Write(csvRow(tx)); err != nil {
+				logger.Fatal("Failed to write CSV", zap.Error(err))
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			logger.Fatal("Failed to write CSV", zap.Error(err))
+		}
+		if nextCursor != "" {
+			fmt.Fprintf(os.Stderr, "next cursor: %s\n", nextCursor)
+		}
+	default:
+		common.PrintHeader("TRANSACTIONS", common.WideWidth)
+		printTable(transactions)
+		footer := fmt.Sprintf("%d transactions", len(transactions))
+		if nextCursor != "" {
+			footer += fmt.Sprintf(" (more available: --cursor %s)", nextCursor)
+		}
+		common.PrintFooter(footer, common.WideWidth)
+	}
+}
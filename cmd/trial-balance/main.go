@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/models"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type assetTotals struct {
+	debits  decimal.Decimal
+	credits decimal.Decimal
+}
+
+// printTrialBalance prints one section per asset and returns the assets whose
+// debits and credits differ.
+func printTrialBalance(lines []models.TrialBalanceLine) []string {
+	var unbalanced []string
+	for i := 0; i < len(lines); {
+		asset := lines[i].Asset
+		var totals assetTotals
+
+		fmt.Printf("\n┌─ Asset: %s\n", asset)
+		fmt.Printf("│  %-50s %20s %20s %20s\n", "ACCOUNT", "DEBITS", "CREDITS", "NET")
+		common.PrintBoxSeparator(114)
+		for ; i < len(lines) && lines[i].Asset == asset; i++ {
+			l := lines[i]
+			totals.debits = totals.debits.Add(l.Debits)
+			totals.credits = totals.credits.Add(l.Credits)
+			fmt.Printf("│  %-50s %20s %20s %20s\n", l.Account, l.Debits.String(), l.Credits.String(), l.Net().String())
+		}
+
+		status := "BALANCED"
+		if !totals.debits.Equal(totals.credits) {
+			status = "OUT OF BALANCE"
+			unbalanced = append(unbalanced, asset)
+		}
+		fmt.Printf("└  %-50s %20s %20s %20s\n", "TOTAL ("+status+")",
+			totals.debits.String(), totals.credits.String(), totals.debits.Sub(totals.credits).String())
+	}
+	return unbalanced
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	asOfFlag := flag.String("as-of", "", "Trial balance as of a point in time: RFC3339 or YYYY-MM-DD (end of day UTC)")
+	flag.Parse()
+
+	var asOf time.Time
+	if *asOfFlag != "" {
+		var err error
+		asOf, err = common.ParseAsOf(*asOfFlag)
+		if err != nil {
+			logger.Fatal("Invalid --as-of value", zap.Error(err))
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	lines, err := dbService.GetTrialBalance(ctx, asOf)
+	if err != nil {
+		logger.Fatal("Failed to compute trial balance", zap.Error(err))
+	}
+
+	title := "TRIAL BALANCE"
+	if !asOf.IsZero() {
+		title = fmt.Sprintf("TRIAL BALANCE AS OF %s", asOf.UTC().Format(time.RFC3339))
+	}
+	common.PrintHeader(title, common.WideWidth)
+	unbalanced := printTrialBalance(lines)
+
+	if len(unbalanced) > 0 {
+		common.PrintFooter(fmt.Sprintf("OUT OF BALANCE: %v", unbalanced), common.WideWidth)
+		logger.Error("Trial balance does not balance", zap.Strings("assets", unbalanced))
+		dbService.Close()
+		os.Exit(1)
+	}
+	common.PrintFooter(fmt.Sprintf("SUMMARY: %d account lines, debits equal credits for every asset", len(lines)), common.WideWidth)
+}
@@ -0,0 +1,128 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: tx-status <prime transaction id>
+       tx-status --quarantined
+
+Shows a Prime transaction's lifecycle state and every transition the listener
+booked or quarantined for it.
+`)
+}
+
+func printHistory(ctx context.Context, db store.LedgerStore, primeTxId string) error {
+	state, err := db.GetTxState(ctx, primeTxId)
+	if err != nil {
+		return err
+	}
+	history, err := db.GetTxHistory(ctx, primeTxId)
+	if err != nil {
+		return err
+	}
+	if state == nil && len(history) == 0 {
+		return fmt.Errorf("no lifecycle recorded for transaction %s", primeTxId)
+	}
+
+	common.PrintHeader("TRANSACTION "+primeTxId, common.WideWidth)
+	if state != nil {
+		fmt.Printf("Kind:         %s\n", state.Kind)
+		fmt.Printf("State:        %s (Prime status %s)\n", state.State, state.PrimeStatus)
+		fmt.Printf("Updated:      %s\n", state.UpdatedAt.Format(time.RFC3339))
+		if state.Quarantined {
+			fmt.Println("⚠ Quarantined: Prime reported a status the lifecycle does not allow; see below")
+		}
+	}
+
+	fmt.Printf("\n┌─ History (%d transitions)\n", len(history))
+	for i, h := range history {
+		fmt.Printf("%s%s  %-9s -> %-9s %-27s %-16s %s", common.BoxPrefix(i == len(history)-1),
+			h.CreatedAt.Format(time.RFC3339), h.FromState, h.ToState, h.PrimeStatus, h.Action, h.Outcome)
+		if h.Detail != "" {
+			fmt.Printf("  (%s)", h.Detail)
+		}
+		fmt.Println()
+	}
+	common.PrintFooter("", common.WideWidth)
+	return nil
+}
+
+func printQuarantined(ctx context.Context, db store.LedgerStore) error {
+	states, err := db.GetQuarantinedTxs(ctx)
+	if err != nil {
+		return err
+	}
+
+	common.PrintHeader("QUARANTINED TRANSACTIONS", common.WideWidth)
+	fmt.Printf("\n┌─ Transactions (%d)\n", len(states))
+	for i, s := range states {
+		fmt.Printf("%s%s  %-10s %-9s %-27s updated %s\n", common.BoxPrefix(i == len(states)-1),
+			s.PrimeTxId, s.Kind, s.State, s.PrimeStatus, s.UpdatedAt.Format(time.RFC3339))
+	}
+	common.PrintFooter("Run tx-status <id> for a transaction's history", common.WideWidth)
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	quarantined := flag.Bool("quarantined", false, "List transactions with a quarantined transition instead")
+	flag.Usage = usage
+	flag.Parse()
+	if (*quarantined && flag.NArg() != 0) || (!*quarantined && flag.NArg() != 1) {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+
+	if *quarantined {
+		err = printQuarantined(ctx, dbService)
+	} else {
+		err = printHistory(ctx, dbService, flag.Arg(0))
+	}
+	if err != nil {
+		dbService.Close()
+		logger.Fatal("tx-status failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,309 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/config"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+const quarantinedDepositLimit = 50
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: users <command> [flags]
+
+Commands:
+  update      Change a user's name and/or email
+  deactivate  Hide a user from lookups; deposits to their addresses go to suspense
+  close       Close a user account and retire its addresses (--force --sweep-to to move balances)
+  freeze      Block withdrawals and quarantine deposits for a user
+  unfreeze    Lift a freeze
+  show        Show a user's freeze status, audit log and quarantined deposits
+
+Reason codes: %s
+
+Run "users <command> --help" for the command's flags.
+`, strings.Join(models.FreezeReasons, ", "))
+}
+
+// userFlags selects the user a command acts on
+type userFlags struct {
+	email  *string
+	userId *string
+}
+
+func addUserFlags(fs *flag.FlagSet) userFlags {
+	return userFlags{
+		email:  fs.String("email", "", "User email"),
+		userId: fs.String("user-id", "", "User id"),
+	}
+}
+
+func (f userFlags) resolve(ctx context.Context, db store.LedgerStore) (*models.User, error) {
+	switch {
+	case *f.userId != "":
+		return db.GetUserById(ctx, *f.userId)
+	case *f.email != "":
+		return db.GetUserByEmail(ctx, *f.email)
+	default:
+		return nil, fmt.Errorf("--email or --user-id is required")
+	}
+}
+
+func runUpdate(ctx context.Context, db store.LedgerStore, service *api.LedgerService, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	user := addUserFlags(fs)
+	name := fs.String("name", "", "New name")
+	email := fs.String("new-email", "", "New email")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name != "" {
+		if err := common.ValidateName(*name); err != nil {
+			return err
+		}
+	}
+	if *email != "" {
+		if err := common.ValidateEmail(*email); err != nil {
+			return err
+		}
+	}
+
+	target, err := user.resolve(ctx, db)
+	if err != nil {
+		return err
+	}
+	updated, err := service.UpdateUser(ctx, store.UpdateUserParams{UserId: target.Id, Name: *name, Email: *email})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ Updated user %s\n", updated.Id)
+	fmt.Printf("   Name:  %s → %s\n", target.Name, updated.Name)
+	fmt.Printf("   Email: %s → %s\n\n", target.Email, updated.Email)
+	return nil
+}
+
+func runDeactivate(ctx context.Context, db store.LedgerStore, service *api.LedgerService, args []string) error {
+	fs := flag.NewFlagSet("deactivate", flag.ExitOnError)
+	user := addUserFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	target, err := user.resolve(ctx, db)
+	if err != nil {
+		return err
+	}
+	if err := service.DeactivateUser(ctx, target.Id); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n⏸  %s (%s) is deactivated\n", target.Name, target.Email)
+	fmt.Println("   Balances and addresses are kept; new deposits to their addresses go to suspense.")
+	fmt.Println()
+	return nil
+}
+
+func runClose(ctx context.Context, db store.LedgerStore, service *api.LedgerService, args []string) error {
+	fs := flag.NewFlagSet("close", flag.ExitOnError)
+	user := addUserFlags(fs)
+	sweepTo := fs.String("sweep-to", "", "Account to sweep remaining balances to (e.g. users:{id} or fees:closure); requires --force")
+	force := fs.Bool("force", false, "Sweep non-zero balances to --sweep-to instead of refusing to close")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Deactivated users can still be closed, so an explicit id skips the active-user lookup
+	userId, label := *user.userId, *user.userId
+	if userId == "" {
+		target, err := user.resolve(ctx, db)
+		if err != nil {
+			return err
+		}
+		userId, label = target.Id, fmt.Sprintf("%s (%s)", target.Name, target.Email)
+	}
+
+	closure, err := service.CloseUser(ctx, store.CloseUserParams{UserId: userId, SweepTo: *sweepTo, Force: *force})
+	if err != nil {
+		if errors.Is(err, store.ErrNonZeroBalance) {
+			fmt.Println("\n❌ The user still holds funds. Rerun with --force --sweep-to <account> to sweep them.")
+		}
+		return err
+	}
+
+	fmt.Printf("\n🔚 %s is closed\n", label)
+	for _, b := range closure.Swept {
+		fmt.Printf("   Swept %s %s to %s\n", b.Amount.String(), b.Asset, b.To)
+	}
+	fmt.Printf("   Retired %d deposit addresses; future deposits to them go to suspense.\n\n", closure.RetiredAddresses)
+	return nil
+}
+
+func runFreeze(ctx context.Context, db store.LedgerStore, service *api.LedgerService, args []string, freeze bool) error {
+	name := "unfreeze"
+	if freeze {
+		name = "freeze"
+	}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	user := addUserFlags(fs)
+	reason := fs.String("reason", "", "Reason code (required): "+strings.Join(models.FreezeReasons, ", "))
+	actor := fs.String("actor", os.Getenv("USER"), "Operator making the change (default: $USER)")
+	note := fs.String("note", "", "Free-text note for the audit log")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	target, err := user.resolve(ctx, db)
+	if err != nil {
+		return err
+	}
+	params := store.FreezeParams{UserId: target.Id, Reason: *reason, Actor: *actor, Note: *note}
+
+	var status *models.FreezeStatus
+	if freeze {
+		status, err = service.FreezeUser(ctx, params)
+	} else {
+		status, err = service.UnfreezeUser(ctx, params)
+	}
+	if err != nil {
+		return err
+	}
+
+	if status.Frozen {
+		fmt.Printf("\n🔒 %s (%s) is frozen: %s, by %s\n", target.Name, target.Email, status.Reason, status.Actor)
+		fmt.Println("   Withdrawals are blocked and new deposits go to quarantine.")
+	} else {
+		fmt.Printf("\n🔓 %s (%s) is no longer frozen: %s, by %s\n", target.Name, target.Email, status.Reason, status.Actor)
+		fmt.Println("   Deposits quarantined while frozen stay in quarantine.")
+	}
+	fmt.Println()
+	return nil
+}
+
+func runShow(ctx context.Context, db store.LedgerStore, service *api.LedgerService, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	user := addUserFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	target, err := user.resolve(ctx, db)
+	if err != nil {
+		return err
+	}
+	status, err := service.GetFreezeStatus(ctx, target.Id)
+	if err != nil {
+		return fmt.Errorf("failed to get freeze status: %w", err)
+	}
+	events, err := service.GetFreezeEvents(ctx, target.Id)
+	if err != nil {
+		return fmt.Errorf("failed to get freeze events: %w", err)
+	}
+	quarantined, err := service.GetQuarantinedDeposits(ctx, target.Id, quarantinedDepositLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get quarantined deposits: %w", err)
+	}
+
+	common.PrintHeader(fmt.Sprintf("USER %s (%s)", target.Name, target.Email), common.DefaultWidth)
+	fmt.Printf("Id:      %s\n", target.Id)
+	if status.Frozen {
+		fmt.Printf("Status:  FROZEN since %s (%s, by %s)\n", status.UpdatedAt.Format(time.RFC3339), status.Reason, status.Actor)
+		if status.Note != "" {
+			fmt.Printf("Note:    %s\n", status.Note)
+		}
+	} else {
+		fmt.Println("Status:  active")
+	}
+
+	fmt.Printf("\n┌─ Freeze audit log (%d events)\n", len(events))
+	for i, e := range events {
+		fmt.Printf("%s%s  %-8s %-16s by %s", common.BoxPrefix(i == len(events)-1),
+			e.CreatedAt.Format(time.RFC3339), e.Action, e.Reason, e.Actor)
+		if e.Note != "" {
+			fmt.Printf("  (%s)", e.Note)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("\n┌─ Quarantined deposits (%d)\n", len(quarantined))
+	for i, tx := range quarantined {
+		fmt.Printf("%s%s  %s %s  to %s  (tx %s)\n", common.BoxPrefix(i == len(quarantined)-1),
+			tx.EffectiveAt.Format(time.RFC3339), tx.Amount.String(), tx.Asset, tx.Address, tx.Id)
+	}
+	common.PrintFooter("", common.DefaultWidth)
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	logger, loggerCleanup := common.InitializeLogger()
+	defer loggerCleanup()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", zap.Error(err))
+	}
+
+	dbService, err := common.InitializeDatabaseOnly(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbService.Close()
+	service := api.NewLedgerService(dbService)
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "update":
+		err = runUpdate(ctx, dbService, service, args)
+	case "deactivate":
+		err = runDeactivate(ctx, dbService, service, args)
+	case "close":
+		err = runClose(ctx, dbService, service, args)
+	case "freeze":
+		err = runFreeze(ctx, dbService, service, args, true)
+	case "unfreeze":
+		err = runFreeze(ctx, dbService, service, args, false)
+	case "show":
+		err = runShow(ctx, dbService, service, args)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		logger.Fatal("users "+command+" failed", zap.Error(err))
+	}
+}
@@ -22,7 +22,11 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 
+	"prime-send-receive-go/internal/addressing"
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/common"
 	"prime-send-receive-go/internal/config"
 	"prime-send-receive-go/internal/models"
@@ -39,11 +43,15 @@ type withdrawalRequest struct {
 	asset       string
 	amount      decimal.Decimal
 	destination string
+	tag         string
 }
 
 type assetInfo struct {
-	symbol  string
-	network string
+	symbol        string
+	network       string
+	precision     int
+	minWithdrawal decimal.Decimal
+	tagRequired   bool
 }
 
 func parseAndValidateFlags() (*withdrawalRequest, error) {
@@ -51,6 +59,7 @@ func parseAndValidateFlags() (*withdrawalRequest, error) {
 	assetFlag := flag.String("asset", "", "Asset symbol (e.g., BTC, ETH) (required)")
 	amountFlag := flag.String("amount", "", "Amount to withdraw (required)")
 	destinationFlag := flag.String("destination", "", "Destination address (required)")
+	tagFlag := flag.String("tag", "", "Destination tag or memo (required on tag-based networks such as XRP, XLM and ATOM)")
 	flag.Parse()
 
 	if *emailFlag == "" || *assetFlag == "" || *amountFlag == "" || *destinationFlag == "" {
@@ -71,20 +80,90 @@ func parseAndValidateFlags() (*withdrawalRequest, error) {
 		asset:       *assetFlag,
 		amount:      amount,
 		destination: *destinationFlag,
+		tag:         *tagFlag,
 	}, nil
 }
 
+// parseAsset resolves SYMBOL-network-type against the asset registry
 func parseAsset(assetStr string) (*assetInfo, error) {
-	parts := strings.SplitN(assetStr, "-", 2)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid asset format, expected: SYMBOL-network-type (e.g., ETH-ethereum-mainnet)")
+	a, err := assets.Current().ParseAsset(assetStr)
+	if err != nil {
+		return nil, err
 	}
 	return &assetInfo{
-		symbol:  parts[0],
-		network: parts[1],
+		symbol:        a.Symbol,
+		network:       a.Network,
+		precision:     a.Precision,
+		minWithdrawal: a.MinWithdrawal,
+		tagRequired:   a.TagRequired,
 	}, nil
 }
 
+// checkAmount refuses amounts below the asset's minimum withdrawal or with more
+// decimals than the ledger keeps
+func checkAmount(asset *assetInfo, amount decimal.Decimal) error {
+	if amount.LessThan(asset.minWithdrawal) {
+		return fmt.Errorf("amount %s is below the %s minimum withdrawal of %s", amount, asset.symbol, asset.minWithdrawal)
+	}
+	if !amount.Equal(amount.Truncate(int32(asset.precision))) {
+		return fmt.Errorf("amount %s has more than %d decimals for %s", amount, asset.precision, asset.symbol)
+	}
+	return nil
+}
+
+// checkDestination refuses destinations whose address and tag are not on the user's
+// allowlist for the asset and network or are still cooling down and, when configured, ones without a
+// usable Prime address book entry.
+func checkDestination(ctx context.Context, services *common.Services, cfg models.WithdrawalConfig, userId string, asset *assetInfo, destination, tag string) error {
+	if !cfg.EnforceAllowlist {
+		zap.L().Warn("Withdrawal allowlist is not enforced", zap.String("destination", destination))
+		return nil
+	}
+	service := api.NewLedgerService(services.DbService)
+	entry, err := service.CheckWithdrawalDestination(ctx, api.WithdrawalDestination{
+		UserId:  userId,
+		Asset:   asset.symbol,
+		Network: asset.network,
+		Address: destination,
+		Tag:     tag,
+	}, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !cfg.VerifyAddressBook {
+		return nil
+	}
+
+	book, err := services.PrimeService.LookupAddressBook(ctx, services.DefaultPortfolio.Id, destination)
+	if err != nil {
+		return fmt.Errorf("failed to verify address book entry: %w", err)
+	}
+	if book == nil {
+		return fmt.Errorf("%s is allowlisted but not in the Prime address book", destination)
+	}
+	if !containsFold(cfg.AddressBookStates, book.State) {
+		return fmt.Errorf("address book entry %q is %s, expected one of %s", book.Name, book.State,
+			strings.Join(cfg.AddressBookStates, ", "))
+	}
+	if book.Symbol != "" && !strings.EqualFold(book.Symbol, asset.symbol) {
+		return fmt.Errorf("address book entry %q is for %s, not %s", book.Name, book.Symbol, asset.symbol)
+	}
+	zap.L().Info("Withdrawal destination verified",
+		zap.String("destination", destination),
+		zap.String("allowlisted_by", entry.AddedBy),
+		zap.String("address_book_state", book.State))
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
 func verifyBalance(ctx context.Context, services *common.Services, user *models.User, symbol, network string, amount decimal.Decimal) (decimal.Decimal, error) {
 	// First try per-network balance (Formance returns per-network; SQLite ignores network).
 	balances, err := services.DbService.GetAllUserBalances(ctx, user.Id)
@@ -110,9 +189,16 @@ func verifyBalance(ctx context.Context, services *common.Services, user *models.
 		checkBalance = networkBalance
 	}
 
+	// Funds reserved by holds cannot be withdrawn
+	detail, err := services.DbService.GetUserBalanceDetail(ctx, user.Id, symbol)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get held balance: %w", err)
+	}
+	checkBalance = checkBalance.Sub(detail.Held)
+
 	if checkBalance.LessThan(amount) {
-		return checkBalance, fmt.Errorf("insufficient balance: current=%s, requested=%s, shortfall=%s",
-			checkBalance.String(), amount.String(), amount.Sub(checkBalance).String())
+		return checkBalance, fmt.Errorf("insufficient balance: available=%s, held=%s, requested=%s, shortfall=%s",
+			checkBalance.String(), detail.Held.String(), amount.String(), amount.Sub(checkBalance).String())
 	}
 
 	zap.L().Info("Balance verification successful",
@@ -186,6 +272,9 @@ func reserveFunds(ctx context.Context, services *common.Services, userId, symbol
 		if errors.Is(err, store.ErrDuplicateTransaction) {
 			return fmt.Errorf("withdrawal with this idempotency key is already being processed - please retry in a moment")
 		}
+		if errors.Is(err, store.ErrUserFrozen) {
+			return fmt.Errorf("user account is frozen: %w", err)
+		}
 		return fmt.Errorf("failed to debit balance: %w", err)
 	}
 
@@ -199,7 +288,8 @@ func executeWithdrawal(ctx context.Context, services *common.Services, req *with
 		zap.String("portfolio_id", services.DefaultPortfolio.Id),
 		zap.String("wallet_id", walletId),
 		zap.String("amount", req.amount.String()),
-		zap.String("destination", req.destination))
+		zap.String("destination", req.destination),
+		zap.String("destination_tag", req.tag))
 
 	withdrawal, err := services.PrimeService.CreateWithdrawal(ctx, prime.CreateWithdrawalParams{
 		PortfolioId:        services.DefaultPortfolio.Id,
@@ -208,6 +298,7 @@ func executeWithdrawal(ctx context.Context, services *common.Services, req *with
 		Amount:             req.amount.String(),
 		Asset:              req.asset,
 		IdempotencyKey:     idempotencyKey,
+		DestinationTag:     req.tag,
 	})
 	if err != nil {
 		return fmt.Errorf("Prime API withdrawal failed: %w", err)
@@ -216,7 +307,11 @@ func executeWithdrawal(ctx context.Context, services *common.Services, req *with
 	fmt.Printf("✅ Withdrawal created successfully!\n")
 	fmt.Printf("   Activity ID: %s\n", withdrawal.ActivityId)
 	fmt.Printf("   Amount:      %s %s\n", withdrawal.Amount, withdrawal.Asset)
-	fmt.Printf("   Destination: %s\n\n", withdrawal.Destination)
+	fmt.Printf("   Destination: %s\n", withdrawal.Destination)
+	if req.tag != "" {
+		fmt.Printf("   Tag:         %s\n", req.tag)
+	}
+	fmt.Println()
 
 	return nil
 }
@@ -299,14 +394,79 @@ func main() {
 		zap.L().Fatal("User not found", zap.String("email", req.email), zap.Error(err))
 	}
 
+	// Frozen users cannot withdraw
+	freeze, err := services.DbService.GetFreezeStatus(ctx, targetUser.Id)
+	if err != nil {
+		zap.L().Fatal("Failed to check freeze status", zap.String("user_id", targetUser.Id), zap.Error(err))
+	}
+	if freeze.Frozen {
+		common.PrintHeader("WITHDRAWAL FAILED", common.DefaultWidth)
+		fmt.Printf("User:              %s (%s)\n", targetUser.Name, targetUser.Email)
+		fmt.Printf("Account is frozen: %s (by %s)\n", freeze.Reason, freeze.Actor)
+		common.PrintSeparator("=", common.DefaultWidth)
+		zap.L().Fatal("User is frozen", zap.String("user_id", targetUser.Id), zap.String("reason", freeze.Reason))
+	}
+
 	// Parse asset to extract symbol and network
 	asset, err := parseAsset(req.asset)
 	if err != nil {
 		common.PrintHeader("WITHDRAWAL FAILED", common.DefaultWidth)
-		fmt.Printf("Error: Invalid asset format: %s\n", req.asset)
-		fmt.Printf("Expected: SYMBOL-network-type (e.g. USDC-base-mainnet)\n")
+		fmt.Printf("Error: %v\n", err)
+		fmt.Printf("Expected: SYMBOL-network-type (e.g. USDC-base-mainnet) listed in the asset registry\n")
+		common.PrintSeparator("=", common.DefaultWidth)
+		zap.L().Fatal("Invalid asset", zap.String("asset", req.asset), zap.Error(err))
+	}
+	if err := checkAmount(asset, req.amount); err != nil {
+		common.PrintHeader("WITHDRAWAL FAILED", common.DefaultWidth)
+		fmt.Printf("Error: %v\n", err)
+		common.PrintSeparator("=", common.DefaultWidth)
+		zap.L().Fatal("Invalid withdrawal amount", zap.String("amount", req.amount.String()), zap.Error(err))
+	}
+
+	// Catch destination typos before any funds are reserved
+	destination, err := addressing.Normalize(asset.network, req.destination)
+	if err != nil {
+		common.PrintHeader("WITHDRAWAL FAILED", common.DefaultWidth)
+		fmt.Printf("Error: %v\n", err)
+		common.PrintSeparator("=", common.DefaultWidth)
+		zap.L().Fatal("Invalid destination address", zap.String("destination", req.destination), zap.Error(err))
+	}
+	req.destination = destination
+
+	// Shared addresses on tag-based networks credit nobody without a tag
+	tag, err := addressing.NormalizeTag(asset.network, req.tag)
+	if err == nil && asset.tagRequired && tag == "" {
+		err = fmt.Errorf("%w for %s", addressing.ErrTagRequired, asset.network)
+	}
+	if err != nil {
+		common.PrintHeader("WITHDRAWAL FAILED", common.DefaultWidth)
+		fmt.Printf("Error: %v\n", err)
+		if errors.Is(err, addressing.ErrTagRequired) {
+			fmt.Printf("%s withdrawals need --tag with the receiver's destination tag or memo\n", asset.network)
+		}
 		common.PrintSeparator("=", common.DefaultWidth)
-		zap.L().Fatal("Invalid asset format", zap.String("asset", req.asset), zap.Error(err))
+		zap.L().Fatal("Invalid destination tag", zap.String("tag", req.tag), zap.Error(err))
+	}
+	req.tag = tag
+
+	// Only allowlisted destinations past their cooldown can receive withdrawals
+	if err := checkDestination(ctx, services, cfg.Withdrawal, targetUser.Id, asset, req.destination, req.tag); err != nil {
+		common.PrintHeader("WITHDRAWAL FAILED", common.DefaultWidth)
+		fmt.Printf("User:              %s (%s)\n", targetUser.Name, targetUser.Email)
+		fmt.Printf("Destination:       %s\n", req.destination)
+		if req.tag != "" {
+			fmt.Printf("Tag:               %s\n", req.tag)
+		}
+		fmt.Printf("Error:             %v\n", err)
+		common.PrintSeparator("=", common.DefaultWidth)
+		if errors.Is(err, store.ErrAddressNotAllowlisted) {
+			hint := []string{"--email", targetUser.Email, "--address", req.destination, "--asset", asset.symbol, "--network", asset.network}
+			if req.tag != "" {
+				hint = append(hint, "--tag", req.tag)
+			}
+			fmt.Println("\nAdd it with: go run cmd/allowlist/main.go add", strings.Join(hint, " "))
+		}
+		zap.L().Fatal("Withdrawal destination rejected", zap.String("destination", req.destination), zap.Error(err))
 	}
 
 	// Verify balance (checks per-network balance for Formance, aggregated for SQLite)
@@ -0,0 +1,147 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package addressing validates blockchain addresses for the networks Prime
+// custodies and rewrites them in a canonical form. Addresses are checked before
+// they are stored, used as a withdrawal destination or matched against deposits,
+// so a typo is caught here rather than by Prime after funds were reserved.
+//
+// EVM addresses must carry a valid EIP-55 checksum when written in mixed case and
+// are normalised to it. Bitcoin addresses are base58check (P2PKH, P2SH) or
+// bech32/bech32m segwit, with bech32 normalised to lower case. Solana addresses
+// are base58 encodings of 32-byte public keys and are case-sensitive.
+package addressing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Address families
+const (
+	FamilyEVM     = "evm"
+	FamilyBitcoin = "bitcoin"
+	FamilySolana  = "solana"
+)
+
+// ErrInvalidAddress is returned for addresses that do not match their network's format
+var ErrInvalidAddress = errors.New("invalid address")
+
+// evmChains are the Prime network prefixes whose addresses are EVM accounts
+var evmChains = map[string]bool{
+	"ethereum":  true,
+	"base":      true,
+	"arbitrum":  true,
+	"polygon":   true,
+	"avalanche": true,
+	"optimism":  true,
+}
+
+// Family returns the address family of a Prime network such as "base-mainnet",
+// or "" for networks this package does not know.
+func Family(network string) string {
+	chain, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(network)), "-")
+	switch {
+	case evmChains[chain]:
+		return FamilyEVM
+	case chain == "bitcoin":
+		return FamilyBitcoin
+	case chain == "solana":
+		return FamilySolana
+	default:
+		return ""
+	}
+}
+
+// isMainnet reports whether a network names its chain's main network
+func isMainnet(network string) bool {
+	_, env, found := strings.Cut(strings.ToLower(strings.TrimSpace(network)), "-")
+	return !found || env == "mainnet"
+}
+
+// Normalize validates address for network and returns its canonical form.
+// Addresses on networks this package does not know are only trimmed.
+func Normalize(network, address string) (string, error) {
+	address, err := trim(address)
+	if err != nil {
+		return "", err
+	}
+
+	var normalized string
+	switch Family(network) {
+	case FamilyEVM:
+		normalized, err = normalizeEVM(address)
+	case FamilyBitcoin:
+		normalized, err = normalizeBitcoin(address, isMainnet(network))
+	case FamilySolana:
+		normalized, err = normalizeSolana(address)
+	default:
+		return address, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w %q for %s: %v", ErrInvalidAddress, address, network, err)
+	}
+	return normalized, nil
+}
+
+// Validate reports whether address is well formed for network
+func Validate(network, address string) error {
+	_, err := Normalize(network, address)
+	return err
+}
+
+// NormalizeAny is Normalize for addresses whose network is not known, such as
+// external withdrawal addresses. It checks the formats that can be recognised from
+// the address alone: 0x-prefixed 40-digit hex as EVM and bc1/tb1 as bech32. Any
+// other address is only trimmed.
+func NormalizeAny(address string) (string, error) {
+	address, err := trim(address)
+	if err != nil {
+		return "", err
+	}
+
+	var normalized string
+	lower := strings.ToLower(address)
+	switch {
+	case len(address) == 42 && strings.HasPrefix(lower, "0x"):
+		normalized, err = normalizeEVM(address)
+	case strings.HasPrefix(lower, "bc1"):
+		normalized, err = normalizeBech32(address, "bc")
+	case strings.HasPrefix(lower, "tb1"):
+		normalized, err = normalizeBech32(address, "tb")
+	default:
+		return address, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %v", ErrInvalidAddress, address, err)
+	}
+	return normalized, nil
+}
+
+// trim removes surrounding whitespace and rejects empty addresses or ones with
+// whitespace inside them
+func trim(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", fmt.Errorf("%w: address is empty", ErrInvalidAddress)
+	}
+	if strings.IndexFunc(address, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("%w %q: contains whitespace", ErrInvalidAddress, address)
+	}
+	return address, nil
+}
@@ -0,0 +1,211 @@
+package addressing
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeccak256(t *testing.T) {
+	tests := map[string]string{
+		"":    "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+		"abc": "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+	}
+	for input, want := range tests {
+		got := keccak256([]byte(input))
+		if hex.EncodeToString(got[:]) != want {
+			t.Errorf("keccak256(%q) = %x, want %s", input, got, want)
+		}
+	}
+	// Longer than one block
+	long := make([]byte, 200)
+	if a, b := keccak256(long), keccak256(long[:199]); a == b {
+		t.Error("Expected different hashes for inputs spanning a block boundary")
+	}
+}
+
+func TestNormalize_EVM(t *testing.T) {
+	// EIP-55 test vectors
+	checksummed := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+	for _, network := range []string{"ethereum-mainnet", "base-mainnet", "arbitrum-mainnet", "polygon-mainnet", "avalanche-mainnet"} {
+		for _, want := range checksummed {
+			for _, input := range []string{want, "0x" + strings.ToLower(want[2:]), "0x" + strings.ToUpper(want[2:]), "  " + want + "\n"} {
+				got, err := Normalize(network, input)
+				if err != nil || got != want {
+					t.Errorf("Normalize(%s, %q) = %q, %v; want %q", network, input, got, err, want)
+				}
+			}
+		}
+	}
+
+	invalid := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", // last letter's case flipped
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beae",  // 39 digits
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaedd",
+		"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed00",
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaeg",
+		"0x5aaeb6053f3e94c9b9a09f3 669435e7ef1beaed",
+		"",
+	}
+	for _, input := range invalid {
+		if _, err := Normalize("base-mainnet", input); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("Normalize(base-mainnet, %q) = %v, want ErrInvalidAddress", input, err)
+		}
+	}
+}
+
+func TestNormalize_Bitcoin(t *testing.T) {
+	valid := []struct {
+		network, input, want string
+	}{
+		{"bitcoin-mainnet", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},
+		{"bitcoin-mainnet", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+		{"bitcoin-mainnet", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{"bitcoin-mainnet", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"},
+		{"bitcoin-testnet", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"},
+	}
+	for _, tt := range valid {
+		got, err := Normalize(tt.network, tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%s, %q) = %q, %v; want %q", tt.network, tt.input, got, err, tt.want)
+		}
+	}
+
+	invalid := []struct {
+		network, input string
+	}{
+		{"bitcoin-mainnet", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"},                             // base58check checksum
+		{"bitcoin-mainnet", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},                     // bech32 checksum
+		{"bitcoin-mainnet", "bc1qw508d6qejxtdg4y5r3zarvaRY0c5xw7kv8f3t4"},                     // mixed case
+		{"bitcoin-mainnet", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},                     // v1 with bech32 checksum
+		{"bitcoin-mainnet", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7"}, // testnet on mainnet
+		{"bitcoin-testnet", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},                             // mainnet on testnet
+		{"bitcoin-mainnet", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},                     // wrong family
+		{"bitcoin-mainnet", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN0"},                             // 0 is not base58
+	}
+	for _, tt := range invalid {
+		if _, err := Normalize(tt.network, tt.input); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("Normalize(%s, %q) = %v, want ErrInvalidAddress", tt.network, tt.input, err)
+		}
+	}
+}
+
+func TestNormalize_Solana(t *testing.T) {
+	for _, input := range []string{
+		"11111111111111111111111111111111",
+		"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+		"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+	} {
+		if got, err := Normalize("solana-mainnet", input); err != nil || got != input {
+			t.Errorf("Normalize(solana-mainnet, %q) = %q, %v", input, got, err)
+		}
+	}
+	for _, input := range []string{
+		"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwy",       // too short
+		"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1vv", // 33 bytes
+		"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1l",  // l is not base58
+	} {
+		if _, err := Normalize("solana-mainnet", input); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("Normalize(solana-mainnet, %q) = %v, want ErrInvalidAddress", input, err)
+		}
+	}
+}
+
+func TestNormalize_UnknownNetwork(t *testing.T) {
+	got, err := Normalize("xrp-mainnet", " rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh ")
+	if err != nil || got != "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh" {
+		t.Errorf("Expected unknown networks to be trimmed only, got %q, %v", got, err)
+	}
+	if _, err := Normalize("xrp-mainnet", "  "); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("Expected an empty address to fail, got %v", err)
+	}
+}
+
+func TestNormalizeAny(t *testing.T) {
+	valid := map[string]string{
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed":   "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4":   "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+	}
+	for input, want := range valid {
+		if got, err := NormalizeAny(input); err != nil || got != want {
+			t.Errorf("NormalizeAny(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", ""} {
+		if _, err := NormalizeAny(input); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("NormalizeAny(%q) = %v, want ErrInvalidAddress", input, err)
+		}
+	}
+}
+
+func TestFamily(t *testing.T) {
+	tests := map[string]string{
+		"ethereum-mainnet":  FamilyEVM,
+		"Base-Sepolia":      FamilyEVM,
+		"avalanche-mainnet": FamilyEVM,
+		"bitcoin-mainnet":   FamilyBitcoin,
+		"solana-mainnet":    FamilySolana,
+		"external":          "",
+		"":                  "",
+	}
+	for network, want := range tests {
+		if got := Family(network); got != want {
+			t.Errorf("Family(%q) = %q, want %q", network, got, want)
+		}
+	}
+}
+
+func TestTagRequired(t *testing.T) {
+	tests := map[string]bool{
+		"xrp-mainnet":     true,
+		"Stellar-Mainnet": true,
+		"cosmos-mainnet":  true,
+		"base-mainnet":    false,
+		"external":        false,
+		"":                false,
+	}
+	for network, want := range tests {
+		if got := TagRequired(network); got != want {
+			t.Errorf("TagRequired(%q) = %v, want %v", network, got, want)
+		}
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	valid := []struct{ network, tag, want string }{
+		{"xrp-mainnet", " 12345 ", "12345"},
+		{"xrp-mainnet", "4294967295", "4294967295"},
+		{"stellar-mainnet", "invoice 42", "invoice 42"},
+		{"cosmos-mainnet", "user-7", "user-7"},
+		{"base-mainnet", "", ""},
+		{"base-mainnet", " ignored ", "ignored"},
+	}
+	for _, tt := range valid {
+		got, err := NormalizeTag(tt.network, tt.tag)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeTag(%q, %q) = %q, %v, want %q", tt.network, tt.tag, got, err, tt.want)
+		}
+	}
+
+	if _, err := NormalizeTag("xrp-mainnet", " "); !errors.Is(err, ErrTagRequired) {
+		t.Errorf("Expected ErrTagRequired for empty XRP tag, got %v", err)
+	}
+	invalid := []struct{ network, tag string }{
+		{"xrp-mainnet", "abc"},
+		{"xrp-mainnet", "-1"},
+		{"xrp-mainnet", "4294967296"},
+		{"stellar-mainnet", strings.Repeat("m", 29)},
+	}
+	for _, tt := range invalid {
+		if _, err := NormalizeTag(tt.network, tt.tag); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("NormalizeTag(%q, %q) = %v, want ErrInvalidTag", tt.network, tt.tag, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addressing
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Base58check version bytes for pay-to-pubkey-hash and pay-to-script-hash
+var (
+	mainnetVersions = []byte{0x00, 0x05}
+	testnetVersions = []byte{0x6f, 0xc4}
+)
+
+// normalizeBitcoin accepts segwit (bech32/bech32m) and legacy base58check
+// addresses for mainnet or testnet
+func normalizeBitcoin(address string, mainnet bool) (string, error) {
+	hrp, versions := "bc", mainnetVersions
+	if !mainnet {
+		hrp, versions = "tb", testnetVersions
+	}
+	if strings.HasPrefix(strings.ToLower(address), hrp+"1") {
+		return normalizeBech32(address, hrp)
+	}
+	if err := checkBase58Check(address, versions); err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+func checkBase58Check(address string, versions []byte) error {
+	decoded, err := base58Decode(address)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != 25 {
+		return fmt.Errorf("expected a bech32 or base58check address, got %d bytes", len(decoded))
+	}
+	payload, checksum := decoded[:21], decoded[21:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return errors.New("base58check checksum mismatch")
+	}
+	if bytes.IndexByte(versions, payload[0]) < 0 {
+		return fmt.Errorf("version byte 0x%02x is for a different network", payload[0])
+	}
+	return nil
+}
+
+// Bech32 (BIP 173) and bech32m (BIP 350) checksum constants
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// normalizeBech32 checks a segwit address with the given human-readable part and
+// returns it in lower case. Witness version 0 uses bech32, later versions bech32m.
+func normalizeBech32(address, hrp string) (string, error) {
+	if len(address) > 90 {
+		return "", errors.New("bech32 address is longer than 90 characters")
+	}
+	lower := strings.ToLower(address)
+	if address != lower && address != strings.ToUpper(address) {
+		return "", errors.New("bech32 address mixes upper and lower case")
+	}
+	sep := strings.LastIndexByte(lower, '1')
+	if sep < 1 || sep+7 > len(lower) {
+		return "", errors.New("malformed bech32 address")
+	}
+	if lower[:sep] != hrp {
+		return "", fmt.Errorf("expected prefix %s1, got %s1", hrp, lower[:sep])
+	}
+
+	data := make([]byte, 0, len(lower)-sep-1)
+	for _, c := range lower[sep+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return "", fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(v))
+	}
+	check := bech32Polymod(append(bech32ExpandHRP(hrp), data...))
+
+	values := data[:len(data)-6]
+	if len(values) == 0 {
+		return "", errors.New("missing witness version")
+	}
+	version := values[0]
+	if version > 16 {
+		return "", fmt.Errorf("invalid witness version %d", version)
+	}
+	switch {
+	case version == 0 && check != bech32Const:
+		return "", errors.New("bech32 checksum mismatch")
+	case version > 0 && check != bech32mConst:
+		return "", errors.New("bech32m checksum mismatch")
+	}
+
+	program, err := convertBits(values[1:], 5, 8)
+	if err != nil {
+		return "", err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return "", fmt.Errorf("invalid witness program length %d", len(program))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return "", fmt.Errorf("invalid version 0 witness program length %d", len(program))
+	}
+	return lower, nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// convertBits regroups 5-bit words into bytes, rejecting non-zero padding
+func convertBits(data []byte, from, to uint) ([]byte, error) {
+	var acc uint32
+	var nbits uint
+	maxv := uint32(1)<<to - 1
+	out := make([]byte, 0, len(data)*int(from)/int(to))
+	for _, v := range data {
+		acc = acc<<from | uint32(v)
+		nbits += from
+		for nbits >= to {
+			nbits -= to
+			out = append(out, byte(acc>>nbits&maxv))
+		}
+	}
+	if nbits >= from || (acc<<(to-nbits))&maxv != 0 {
+		return nil, errors.New("invalid witness program padding")
+	}
+	return out, nil
+}
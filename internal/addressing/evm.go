@@ -0,0 +1,67 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addressing
+
+import (
+	"errors"
+	"strings"
+)
+
+// normalizeEVM checks a 0x-prefixed 20-byte hex address and returns its EIP-55
+// checksummed form. All-lower and all-upper case addresses carry no checksum and
+// are accepted; mixed case must match the checksum exactly.
+func normalizeEVM(address string) (string, error) {
+	digits, ok := strings.CutPrefix(address, "0x")
+	if !ok || len(digits) != 40 {
+		return "", errors.New("expected 0x followed by 40 hex digits")
+	}
+	for _, c := range digits {
+		if !isHexDigit(c) {
+			return "", errors.New("expected 0x followed by 40 hex digits")
+		}
+	}
+
+	checksummed := checksumEVM(strings.ToLower(digits))
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && digits != checksummed {
+		return "", errors.New("EIP-55 checksum mismatch")
+	}
+	return "0x" + checksummed, nil
+}
+
+// checksumEVM upper-cases each letter of the lower-case hex address whose nibble
+// in the Keccak-256 hash of the address is 8 or more (EIP-55)
+func checksumEVM(lower string) string {
+	hash := keccak256([]byte(lower))
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' || c > 'f' {
+			continue
+		}
+		nibble := hash[i/2] & 0x0f
+		if i%2 == 0 {
+			nibble = hash[i/2] >> 4
+		}
+		if nibble >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
+
+func isHexDigit(c rune) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
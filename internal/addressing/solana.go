@@ -0,0 +1,62 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addressing
+
+import (
+	"fmt"
+	"strings"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// normalizeSolana checks that the address is a base58 encoded 32-byte public key
+func normalizeSolana(address string) (string, error) {
+	decoded, err := base58Decode(address)
+	if err != nil {
+		return "", err
+	}
+	if len(decoded) != 32 {
+		return "", fmt.Errorf("expected a 32-byte public key, got %d bytes", len(decoded))
+	}
+	return address, nil
+}
+
+// base58Decode decodes Bitcoin-alphabet base58; each leading '1' is a zero byte
+func base58Decode(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	var value []byte // big-endian, without the leading zeros
+	for i := zeros; i < len(s); i++ {
+		digit := strings.IndexByte(base58Alphabet, s[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", s[i])
+		}
+		carry := digit
+		for j := len(value) - 1; j >= 0; j-- {
+			carry += int(value[j]) * 58
+			value[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			value = append([]byte{byte(carry)}, value...)
+			carry >>= 8
+		}
+	}
+	return append(make([]byte, zeros), value...), nil
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addressing
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrTagRequired is returned when a tag-based network is used without a tag
+	ErrTagRequired = errors.New("destination tag required")
+	// ErrInvalidTag is returned for tags that do not match their network's format
+	ErrInvalidTag = errors.New("invalid destination tag")
+)
+
+// tagNetwork describes how a network that shares one address between many
+// accounts identifies the account: a numeric destination tag or a text memo.
+type tagNetwork struct {
+	numeric bool
+	bits    int
+	maxLen  int
+}
+
+// tagChains are the Prime network prefixes that identify the receiving account
+// by (address, tag) rather than by address alone
+var tagChains = map[string]tagNetwork{
+	"xrp":     {numeric: true, bits: 32},
+	"ripple":  {numeric: true, bits: 32},
+	"stellar": {maxLen: 28},
+	"cosmos":  {maxLen: 256},
+	"hedera":  {maxLen: 100},
+	"eos":     {maxLen: 256},
+}
+
+// TagRequired reports whether deposits and withdrawals on network must carry a
+// destination tag or memo to reach the right account.
+func TagRequired(network string) bool {
+	chain, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(network)), "-")
+	_, ok := tagChains[chain]
+	return ok
+}
+
+// NormalizeTag validates tag for network and returns it trimmed. An empty tag is
+// rejected on tag-based networks; on other networks tags are only trimmed.
+func NormalizeTag(network, tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	chain, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(network)), "-")
+	spec, ok := tagChains[chain]
+	if !ok {
+		return tag, nil
+	}
+	if tag == "" {
+		return "", fmt.Errorf("%w for %s", ErrTagRequired, network)
+	}
+	if spec.numeric {
+		if _, err := strconv.ParseUint(tag, 10, spec.bits); err != nil {
+			return "", fmt.Errorf("%w %q for %s: must be a %d-bit unsigned integer", ErrInvalidTag, tag, network, spec.bits)
+		}
+		return tag, nil
+	}
+	if len(tag) > spec.maxLen {
+		return "", fmt.Errorf("%w %q for %s: longer than %d bytes", ErrInvalidTag, tag, network, spec.maxLen)
+	}
+	return tag, nil
+}
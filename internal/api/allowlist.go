@@ -0,0 +1,139 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/addressing"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// AddAllowlistAddress approves a withdrawal destination for a user, locked until its cooldown ends
+func (s *LedgerService) AddAllowlistAddress(ctx context.Context, params store.AllowlistParams) (*models.AllowlistEntry, error) {
+	address, err := addressing.NormalizeAny(params.Address)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := addressing.NormalizeTag(params.Network, params.Tag)
+	if err != nil {
+		return nil, err
+	}
+	params.Address, params.Tag = address, tag
+	entry, err := s.db.AddAllowlistAddress(ctx, params)
+	if err != nil {
+		zap.L().Warn("Failed to add allowlist address",
+			zap.String("user_id", params.UserId),
+			zap.String("address", params.Address),
+			zap.String("tag", params.Tag),
+			zap.String("actor", params.Actor),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to add allowlist address: %w", err)
+	}
+	return entry, nil
+}
+
+// RemoveAllowlistAddress takes a withdrawal destination off a user's allowlist
+func (s *LedgerService) RemoveAllowlistAddress(ctx context.Context, params store.AllowlistParams) error {
+	if err := s.db.RemoveAllowlistAddress(ctx, params); err != nil {
+		zap.L().Warn("Failed to remove allowlist address",
+			zap.String("user_id", params.UserId),
+			zap.String("address", params.Address),
+			zap.String("tag", params.Tag),
+			zap.String("actor", params.Actor),
+			zap.Error(err))
+		return fmt.Errorf("failed to remove allowlist address: %w", err)
+	}
+	return nil
+}
+
+// GetAllowlist returns a user's allowlisted withdrawal destinations
+func (s *LedgerService) GetAllowlist(ctx context.Context, userId string) ([]models.AllowlistEntry, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	return s.db.GetAllowlist(ctx, userId)
+}
+
+// GetAllowlistEvents returns a user's allowlist audit log, oldest first
+func (s *LedgerService) GetAllowlistEvents(ctx context.Context, userId string) ([]models.AllowlistEvent, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	return s.db.GetAllowlistEvents(ctx, userId)
+}
+
+// WithdrawalDestination is where a user asks to withdraw an asset to
+type WithdrawalDestination struct {
+	UserId  string
+	Asset   string // symbol, e.g. USDC
+	Network string // e.g. base-mainnet
+	Address string
+	Tag     string // destination tag or memo, required on tag-based networks
+}
+
+// CheckWithdrawalDestination returns the allowlist entry covering the destination's address
+// and tag, asset and network if the user may withdraw to it at now. The tag must match the
+// entry's exactly, so allowlisting one tag at a shared exchange address does not allow the
+// others. It fails with ErrAddressNotAllowlisted or ErrAddressCoolingDown otherwise.
+func (s *LedgerService) CheckWithdrawalDestination(ctx context.Context, dest WithdrawalDestination, now time.Time) (*models.AllowlistEntry, error) {
+	entries, err := s.GetAllowlist(ctx, dest.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowlist: %w", err)
+	}
+	address, err := addressing.NormalizeAny(dest.Address)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := addressing.NormalizeTag(dest.Network, dest.Tag)
+	if err != nil {
+		return nil, err
+	}
+	destination := models.AllowlistDestination(address, tag)
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Address != address || entry.Tag != tag {
+			continue
+		}
+		if !entry.Covers(dest.Asset, dest.Network) {
+			return nil, fmt.Errorf("%w: %s is only allowlisted for %s", store.ErrAddressNotAllowlisted, destination, entryScope(entry))
+		}
+		if !entry.Usable(now) {
+			return entry, fmt.Errorf("%w: %s unlocks at %s (in %s)", store.ErrAddressCoolingDown, destination,
+				entry.ActiveAt.Format(time.RFC3339), entry.ActiveAt.Sub(now).Round(time.Minute))
+		}
+		return entry, nil
+	}
+	return nil, fmt.Errorf("%w: %s", store.ErrAddressNotAllowlisted, destination)
+}
+
+// entryScope describes the asset and network an entry is limited to
+func entryScope(entry *models.AllowlistEntry) string {
+	switch {
+	case entry.Asset == "":
+		return "withdrawals on " + entry.Network
+	case entry.Network == "":
+		return entry.Asset
+	default:
+		return entry.Asset + " on " + entry.Network
+	}
+}
@@ -19,14 +19,16 @@ package api
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
 
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
-// GetUserBalance returns the current balance for a user and specific asset
+// GetUserBalance returns the current total balance, including held funds, for a user and specific asset
 func (s *LedgerService) GetUserBalance(ctx context.Context, userId, asset string) (decimal.Decimal, error) {
 	if userId == "" || asset == "" {
 		return decimal.Zero, fmt.Errorf("user_id and asset are required")
@@ -44,7 +46,30 @@ func (s *LedgerService) GetUserBalance(ctx context.Context, userId, asset string
 	return balance, nil
 }
 
-// GetUserBalances returns all non-zero balances for a user
+// GetUserBalanceAt returns the balance for a user and asset as of the given effective time
+func (s *LedgerService) GetUserBalanceAt(ctx context.Context, userId, asset string, at time.Time) (decimal.Decimal, error) {
+	if userId == "" || asset == "" {
+		return decimal.Zero, fmt.Errorf("user_id and asset are required")
+	}
+	if at.IsZero() {
+		return decimal.Zero, fmt.Errorf("as-of time is required")
+	}
+
+	balance, err := s.db.GetUserBalanceAt(ctx, userId, asset, at)
+	if err != nil {
+		zap.L().Error("Failed to get user balance at point in time",
+			zap.String("user_id", userId),
+			zap.String("asset_network", asset),
+			zap.Time("at", at),
+			zap.Error(err))
+		return decimal.Zero, fmt.Errorf("failed to retrieve balance")
+	}
+
+	return balance, nil
+}
+
+// GetUserBalances returns all non-zero balances for a user, each split into the
+// part reserved by holds and the part available
 func (s *LedgerService) GetUserBalances(ctx context.Context, userId string) ([]models.UserBalance, error) {
 	if userId == "" {
 		return nil, fmt.Errorf("user_id is required")
@@ -58,9 +83,16 @@ func (s *LedgerService) GetUserBalances(ctx context.Context, userId string) ([]m
 
 	result := make([]models.UserBalance, len(balances))
 	for i, balance := range balances {
+		detail, err := s.db.GetUserBalanceDetail(ctx, userId, balance.Asset)
+		if err != nil {
+			zap.L().Error("Failed to get balance detail", zap.String("user_id", userId), zap.String("asset", balance.Asset), zap.Error(err))
+			return nil, fmt.Errorf("failed to retrieve balances")
+		}
 		result[i] = models.UserBalance{
-			Asset:   balance.Asset,
-			Balance: balance.Balance,
+			Asset:     balance.Asset,
+			Balance:   balance.Balance,
+			Held:      detail.Held,
+			Available: balance.Balance.Sub(detail.Held),
 		}
 	}
 
@@ -99,8 +131,76 @@ func (s *LedgerService) GetTransactionHistory(ctx context.Context, userId, asset
 			Address:     tx.Address,
 			Status:      tx.Status,
 			ProcessedAt: tx.ProcessedAt,
+			EffectiveAt: tx.EffectiveAt,
+			Metadata:    tx.Metadata,
 		}
 	}
 
 	return result, nil
 }
+
+// FindTransactionsByMetadata looks up transactions across all users by metadata,
+// e.g. {"prime_tx_id": "0xabc"} to answer "where is my transaction"
+func (s *LedgerService) FindTransactionsByMetadata(ctx context.Context, filter map[string]string, limit int) ([]models.TransactionRecord, error) {
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("at least one metadata filter is required")
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	transactions, err := s.db.FindTransactionsByMetadata(ctx, filter, limit)
+	if err != nil {
+		zap.L().Error("Failed to find transactions by metadata", zap.Any("filter", filter), zap.Error(err))
+		return nil, fmt.Errorf("failed to retrieve transactions")
+	}
+
+	result := make([]models.TransactionRecord, len(transactions))
+	for i, tx := range transactions {
+		result[i] = toTransactionRecord(tx)
+	}
+
+	return result, nil
+}
+
+// SearchTransactions returns one page of transactions across all users matching
+// query. Pass the returned NextCursor back in query.Cursor to fetch the next page.
+func (s *LedgerService) SearchTransactions(ctx context.Context, query store.TransactionQuery) (*models.TransactionSearchResult, error) {
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if query.MinAmount != nil && query.MaxAmount != nil && query.MinAmount.GreaterThan(*query.MaxAmount) {
+		return nil, fmt.Errorf("min amount must not exceed max amount")
+	}
+
+	page, err := s.db.SearchTransactions(ctx, query)
+	if err != nil {
+		zap.L().Error("Failed to search transactions", zap.Error(err))
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+
+	result := &models.TransactionSearchResult{
+		Transactions: make([]models.TransactionRecord, len(page.Transactions)),
+		NextCursor:   page.NextCursor,
+	}
+	for i, tx := range page.Transactions {
+		result.Transactions[i] = toTransactionRecord(tx)
+	}
+	return result, nil
+}
+
+func toTransactionRecord(tx models.Transaction) models.TransactionRecord {
+	return models.TransactionRecord{
+		Id:          tx.Id,
+		UserId:      tx.UserId,
+		Type:        tx.TransactionType,
+		Asset:       tx.Asset,
+		Amount:      tx.Amount,
+		Address:     tx.Address,
+		Status:      tx.Status,
+		ProcessedAt: tx.ProcessedAt,
+		EffectiveAt: tx.EffectiveAt,
+		Metadata:    tx.Metadata,
+	}
+}
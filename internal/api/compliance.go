@@ -0,0 +1,71 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// FreezeUser blocks withdrawals for a user; deposits to them go to quarantine
+func (s *LedgerService) FreezeUser(ctx context.Context, params store.FreezeParams) (*models.FreezeStatus, error) {
+	status, err := s.db.FreezeUser(ctx, params)
+	if err != nil {
+		zap.L().Warn("Failed to freeze user", zap.String("user_id", params.UserId), zap.String("actor", params.Actor), zap.Error(err))
+		return nil, fmt.Errorf("failed to freeze user: %w", err)
+	}
+	return status, nil
+}
+
+// UnfreezeUser lifts a freeze on a user
+func (s *LedgerService) UnfreezeUser(ctx context.Context, params store.FreezeParams) (*models.FreezeStatus, error) {
+	status, err := s.db.UnfreezeUser(ctx, params)
+	if err != nil {
+		zap.L().Warn("Failed to unfreeze user", zap.String("user_id", params.UserId), zap.String("actor", params.Actor), zap.Error(err))
+		return nil, fmt.Errorf("failed to unfreeze user: %w", err)
+	}
+	return status, nil
+}
+
+// GetFreezeStatus returns a user's current freeze state
+func (s *LedgerService) GetFreezeStatus(ctx context.Context, userId string) (*models.FreezeStatus, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	return s.db.GetFreezeStatus(ctx, userId)
+}
+
+// GetFreezeEvents returns a user's freeze audit log, oldest first
+func (s *LedgerService) GetFreezeEvents(ctx context.Context, userId string) ([]models.FreezeEvent, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	return s.db.GetFreezeEvents(ctx, userId)
+}
+
+// GetQuarantinedDeposits returns deposits meant for a user that were quarantined while they were frozen
+func (s *LedgerService) GetQuarantinedDeposits(ctx context.Context, userId string, limit int) ([]models.TransactionRecord, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	return s.FindTransactionsByMetadata(ctx, map[string]string{"quarantined_user_id": userId}, limit)
+}
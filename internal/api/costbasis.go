@@ -0,0 +1,59 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/costbasis"
+	"prime-send-receive-go/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// RealizedGainsReport rebuilds tax lots from ledger history up to the end of year and
+// returns that year's disposals and realized gains, for one user or all users (empty userId)
+func (s *LedgerService) RealizedGainsReport(ctx context.Context, year int, userId string, opts costbasis.Options) (*models.RealizedGainsReport, error) {
+	if year < 1970 || year > 9999 {
+		return nil, fmt.Errorf("invalid tax year %d", year)
+	}
+	opts.UserId = userId
+	opts.To = costbasis.YearEnd(year)
+
+	book, err := costbasis.Build(ctx, s.db, s.prices, opts)
+	if err != nil {
+		zap.L().Error("Failed to build cost basis", zap.Int("year", year), zap.String("user_id", userId), zap.Error(err))
+		return nil, fmt.Errorf("failed to build cost basis: %w", err)
+	}
+	return book.RealizedGains(year, userId), nil
+}
+
+// GetOpenLots rebuilds tax lots from ledger history before asOf (all history for a zero
+// asOf) and returns the lots with quantity remaining
+func (s *LedgerService) GetOpenLots(ctx context.Context, userId string, asOf time.Time, opts costbasis.Options) ([]models.TaxLot, error) {
+	opts.UserId = userId
+	opts.To = asOf
+
+	book, err := costbasis.Build(ctx, s.db, s.prices, opts)
+	if err != nil {
+		zap.L().Error("Failed to build cost basis", zap.String("user_id", userId), zap.Error(err))
+		return nil, fmt.Errorf("failed to build cost basis: %w", err)
+	}
+	return book.OpenLots(userId), nil
+}
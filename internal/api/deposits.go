@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 
+	"prime-send-receive-go/internal/addressing"
 	"prime-send-receive-go/internal/models"
 	"prime-send-receive-go/internal/store"
 
@@ -50,8 +51,21 @@ func (s *LedgerService) ProcessDeposit(ctx context.Context, address, asset strin
 		}, nil
 	}
 
+	normalized, err := addressing.NormalizeAny(address)
+	if err != nil {
+		zap.L().Error("Invalid deposit address",
+			zap.String("address", address),
+			zap.String("external_tx_id", externalTxId),
+			zap.Error(err))
+		return &models.DepositResult{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	address = normalized
+
 	// Process the deposit through subledger
-	err := s.db.ProcessDeposit(ctx, address, asset, amount, externalTxId)
+	err = s.db.ProcessDeposit(ctx, address, asset, amount, externalTxId)
 	if err != nil {
 		if errors.Is(err, store.ErrDuplicateTransaction) {
 			zap.L().Info("Duplicate transaction detected in API service",
@@ -79,8 +93,22 @@ func (s *LedgerService) ProcessDeposit(ctx context.Context, address, asset strin
 		}, nil
 	}
 
-	user, _, err := s.db.FindUserByAddress(ctx, address)
-	if err != nil || user == nil {
+	user, _, err := s.db.FindUserByAddress(ctx, address, models.DepositTag(ctx))
+	if err == nil && user == nil && s.creditedToSuspense(ctx, externalTxId) {
+		// The address was retired or its user deactivated
+		zap.L().Warn("Deposit credited to suspense",
+			zap.String("address", address),
+			zap.String("asset_network", asset),
+			zap.String("amount", amount.String()),
+			zap.String("external_tx_id", externalTxId))
+		return &models.DepositResult{
+			Success:  true,
+			Asset:    asset,
+			Amount:   amount,
+			Suspense: true,
+		}, nil
+	}
+	if err != nil {
 		zap.L().Error("User lookup failed after deposit processing",
 			zap.String("address", address),
 			zap.Error(err))
@@ -96,6 +124,12 @@ func (s *LedgerService) ProcessDeposit(ctx context.Context, address, asset strin
 		newBalance = decimal.Zero
 	}
 
+	// A frozen user's deposit was credited to quarantine, not to them
+	quarantined := false
+	if status, err := s.db.GetFreezeStatus(ctx, user.Id); err == nil && status.Frozen {
+		quarantined = true
+	}
+
 	zap.L().Info("Deposit processed successfully",
 		zap.String("user_id", user.Id),
 		zap.String("user_name", user.Name),
@@ -104,11 +138,12 @@ func (s *LedgerService) ProcessDeposit(ctx context.Context, address, asset strin
 		zap.String("new_balance", newBalance.String()))
 
 	return &models.DepositResult{
-		Success:    true,
-		UserId:     user.Id,
-		Asset:      asset,
-		Amount:     amount,
-		NewBalance: newBalance,
+		Success:     true,
+		UserId:      user.Id,
+		Asset:       asset,
+		Amount:      amount,
+		NewBalance:  newBalance,
+		Quarantined: quarantined,
 	}, nil
 }
 
@@ -119,3 +154,19 @@ func (s *LedgerService) CreateDepositAddress(ctx context.Context, userId, asset,
 	}
 	return "", fmt.Errorf("address generation requires Prime API integration")
 }
+
+// creditedToSuspense reports whether the deposit with this Prime transaction id was
+// credited to the suspense platform user.
+func (s *LedgerService) creditedToSuspense(ctx context.Context, externalTxId string) bool {
+	page, err := s.db.SearchTransactions(ctx, store.TransactionQuery{ExternalTxId: externalTxId, Limit: 10})
+	if err != nil {
+		zap.L().Warn("Failed to look up deposit", zap.String("external_tx_id", externalTxId), zap.Error(err))
+		return false
+	}
+	for _, tx := range page.Transactions {
+		if strings.HasPrefix(tx.UserId, store.SuspenseUserId("")) {
+			return true
+		}
+	}
+	return false
+}
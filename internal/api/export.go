@@ -0,0 +1,143 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// Export formats
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+)
+
+var exportCSVHeader = []string{
+	"transaction_id", "sequence", "effective_at", "account", "counterparty", "asset",
+	"amount", "direction", "external_tx_id", "prime_tx_id", "network", "metadata",
+}
+
+// exportRecord is the JSON Lines shape of a posting.
+type exportRecord struct {
+	TransactionId string            `json:"transaction_id"`
+	Sequence      int               `json:"sequence"`
+	EffectiveAt   string            `json:"effective_at"`
+	Account       string            `json:"account"`
+	Counterparty  string            `json:"counterparty"`
+	Asset         string            `json:"asset"`
+	Amount        string            `json:"amount"`
+	Direction     string            `json:"direction"`
+	ExternalTxId  string            `json:"external_tx_id,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// ExportPostings streams every posting with an effective time in [query.From, query.To)
+// to w as CSV or JSON Lines and returns the number of rows written. Rows are written
+// chunk by chunk, so the export is never held in memory.
+func (s *LedgerService) ExportPostings(ctx context.Context, query store.ExportQuery, format string, w io.Writer) (int, error) {
+	if format != ExportFormatCSV && format != ExportFormatJSONL {
+		return 0, fmt.Errorf("unsupported export format %q (expected %s or %s)", format, ExportFormatCSV, ExportFormatJSONL)
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return 0, fmt.Errorf("from must be before to")
+	}
+
+	var csvWriter *csv.Writer
+	var jsonEncoder *json.Encoder
+	if format == ExportFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(exportCSVHeader); err != nil {
+			return 0, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+	} else {
+		jsonEncoder = json.NewEncoder(w)
+	}
+
+	rows := 0
+	err := s.db.ExportPostings(ctx, query, func(postings []store.Posting) error {
+		for _, p := range postings {
+			if csvWriter != nil {
+				var metadata []byte
+				if p.Metadata != nil {
+					var err error
+					if metadata, err = json.Marshal(p.Metadata); err != nil { // map keys are sorted
+						return fmt.Errorf("failed to encode metadata: %w", err)
+					}
+				}
+				if err := csvWriter.Write([]string{
+					p.TransactionId,
+					strconv.Itoa(p.Sequence),
+					p.EffectiveAt.UTC().Format(time.RFC3339Nano),
+					p.Account,
+					p.Counterparty,
+					p.Asset,
+					p.Amount.String(),
+					p.Direction,
+					p.ExternalTxId,
+					store.PrimeTxIdFromMetadata(p.Metadata),
+					p.Metadata["network"],
+					string(metadata),
+				}); err != nil {
+					return fmt.Errorf("failed to write CSV row: %w", err)
+				}
+			} else {
+				if err := jsonEncoder.Encode(exportRecord{
+					TransactionId: p.TransactionId,
+					Sequence:      p.Sequence,
+					EffectiveAt:   p.EffectiveAt.UTC().Format(time.RFC3339Nano),
+					Account:       p.Account,
+					Counterparty:  p.Counterparty,
+					Asset:         p.Asset,
+					Amount:        p.Amount.String(),
+					Direction:     p.Direction,
+					ExternalTxId:  p.ExternalTxId,
+					Metadata:      p.Metadata,
+				}); err != nil {
+					return fmt.Errorf("failed to write JSON line: %w", err)
+				}
+			}
+			rows++
+		}
+		// Flush per chunk so output streams to the consumer
+		if csvWriter != nil {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		zap.L().Error("Export failed", zap.Int("rows_written", rows), zap.Error(err))
+		return rows, fmt.Errorf("export failed after %d rows: %w", rows, err)
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return rows, fmt.Errorf("failed to flush CSV: %w", err)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,49 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/fees"
+	"prime-send-receive-go/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// PreviewCustodyFees computes each user's custody fee for a period without charging it
+func (s *LedgerService) PreviewCustodyFees(ctx context.Context, period fees.Period, opts fees.Options) (*models.CustodyFeeReport, error) {
+	report, err := fees.Preview(ctx, s.db, period, opts)
+	if err != nil {
+		zap.L().Error("Failed to preview custody fees", zap.String("from", period.From.Format(time.DateOnly)), zap.Error(err))
+		return nil, fmt.Errorf("failed to preview custody fees: %w", err)
+	}
+	return report, nil
+}
+
+// ChargeCustodyFees charges every custody fee for a period that has not been charged
+// yet. The report is returned alongside an error when some charges failed.
+func (s *LedgerService) ChargeCustodyFees(ctx context.Context, period fees.Period, opts fees.Options) (*models.CustodyFeeReport, error) {
+	report, err := fees.Charge(ctx, s.db, period, opts)
+	if err != nil {
+		zap.L().Error("Failed to charge custody fees", zap.String("from", period.From.Format(time.DateOnly)), zap.Error(err))
+		return report, fmt.Errorf("failed to charge custody fees: %w", err)
+	}
+	return report, nil
+}
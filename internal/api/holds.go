@@ -0,0 +1,99 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// GetUserBalanceDetail returns a user's total, held and available balance in one asset
+func (s *LedgerService) GetUserBalanceDetail(ctx context.Context, userId, asset string) (*models.BalanceDetail, error) {
+	if userId == "" || asset == "" {
+		return nil, fmt.Errorf("user_id and asset are required")
+	}
+	detail, err := s.db.GetUserBalanceDetail(ctx, userId, asset)
+	if err != nil {
+		zap.L().Error("Failed to get balance detail", zap.String("user_id", userId), zap.String("asset", asset), zap.Error(err))
+		return nil, fmt.Errorf("failed to retrieve balance")
+	}
+	return detail, nil
+}
+
+// PlaceHold reserves part of a user's available balance. Returns an error wrapping
+// store.ErrInsufficientAvailable when the available balance is too small.
+func (s *LedgerService) PlaceHold(ctx context.Context, params store.PlaceHoldParams) (*models.Hold, error) {
+	hold, err := s.db.PlaceHold(ctx, params)
+	if err != nil {
+		zap.L().Warn("Failed to place hold",
+			zap.String("hold_id", params.HoldId),
+			zap.String("user_id", params.UserId),
+			zap.String("amount", params.Amount.String()),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to place hold: %w", err)
+	}
+	return hold, nil
+}
+
+// CaptureHold takes amount (zero for the whole hold) from the user and closes the
+// hold; the uncaptured remainder becomes available again.
+func (s *LedgerService) CaptureHold(ctx context.Context, holdId string, amount decimal.Decimal) (*models.Hold, error) {
+	hold, err := s.db.CaptureHold(ctx, holdId, amount)
+	if err != nil {
+		zap.L().Warn("Failed to capture hold", zap.String("hold_id", holdId), zap.Error(err))
+		return nil, fmt.Errorf("failed to capture hold: %w", err)
+	}
+	return hold, nil
+}
+
+// ReleaseHold closes a hold and makes its funds available again
+func (s *LedgerService) ReleaseHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	hold, err := s.db.ReleaseHold(ctx, holdId)
+	if err != nil {
+		zap.L().Warn("Failed to release hold", zap.String("hold_id", holdId), zap.Error(err))
+		return nil, fmt.Errorf("failed to release hold: %w", err)
+	}
+	return hold, nil
+}
+
+// GetHold returns a hold in any status
+func (s *LedgerService) GetHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	hold, err := s.db.GetHold(ctx, holdId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	return hold, nil
+}
+
+// GetActiveHolds returns a user's unexpired active holds; an empty asset means all assets
+func (s *LedgerService) GetActiveHolds(ctx context.Context, userId, asset string) ([]models.Hold, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	holds, err := s.db.GetActiveHolds(ctx, userId, asset)
+	if err != nil {
+		zap.L().Error("Failed to get active holds", zap.String("user_id", userId), zap.Error(err))
+		return nil, fmt.Errorf("failed to retrieve holds")
+	}
+	return holds, nil
+}
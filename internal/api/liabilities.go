@@ -0,0 +1,68 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/liabilities"
+	"prime-send-receive-go/pkg/liabilityproof"
+
+	"go.uber.org/zap"
+)
+
+// BuildLiabilitySnapshot commits every end-user balance as of asOf to a Merkle sum tree.
+// Assets are the configured ones plus any asset with ledger activity by asOf.
+func (s *LedgerService) BuildLiabilitySnapshot(ctx context.Context, asOf time.Time, configuredAssets []string, secret []byte) (*liabilities.Snapshot, error) {
+	lines, err := s.db.GetTrialBalance(ctx, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger assets: %w", err)
+	}
+	assets := append([]string(nil), configuredAssets...)
+	for _, l := range lines {
+		assets = append(assets, l.Asset)
+	}
+
+	snapshot, err := liabilities.Build(ctx, s.db, asOf, assets, secret)
+	if err != nil {
+		zap.L().Error("Failed to build liability snapshot", zap.Error(err))
+		return nil, fmt.Errorf("failed to build liability snapshot: %w", err)
+	}
+
+	zap.L().Info("Liability snapshot built",
+		zap.Time("as_of", snapshot.AsOf),
+		zap.String("root_hash", snapshot.Root.Hash),
+		zap.Int("users", len(snapshot.Leaves)),
+		zap.Int("assets", len(snapshot.Assets)),
+		zap.Int("clamped_negative_balances", snapshot.Clamped))
+	return snapshot, nil
+}
+
+// GetLiabilityProof returns a user's inclusion proof from a snapshot and checks it
+// verifies before handing it out
+func (s *LedgerService) GetLiabilityProof(snapshot *liabilities.Snapshot, userId string) (*liabilityproof.Proof, error) {
+	proof, err := snapshot.Proof(userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := liabilityproof.Verify(*proof); err != nil {
+		return nil, fmt.Errorf("generated proof for user %s does not verify: %w", userId, err)
+	}
+	return proof, nil
+}
@@ -0,0 +1,84 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
+	"prime-send-receive-go/internal/reports"
+
+	"go.uber.org/zap"
+)
+
+// TrialBalanceReport returns debits and credits per account with per-asset totals as of asOf
+func (s *LedgerService) TrialBalanceReport(ctx context.Context, asOf time.Time) (*models.TrialBalanceReport, error) {
+	report, err := reports.TrialBalance(ctx, s.db, asOf)
+	if err != nil {
+		zap.L().Error("Failed to build trial balance report", zap.Error(err))
+		return nil, fmt.Errorf("failed to build trial balance report: %w", err)
+	}
+	if s.prices != nil {
+		report.Liabilities, err = pricing.Value(ctx, s.prices, asOf, reports.UserLiabilities(report.Lines))
+		if err != nil {
+			zap.L().Error("Failed to value user liabilities", zap.Error(err))
+			return nil, fmt.Errorf("failed to value user liabilities: %w", err)
+		}
+	}
+	return report, nil
+}
+
+// GeneralLedgerReport returns opening balance, postings and closing balance for each
+// account starting with accountPrefix over [from, to)
+func (s *LedgerService) GeneralLedgerReport(ctx context.Context, from, to time.Time, accountPrefix string) (*models.GeneralLedgerReport, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	report, err := reports.GeneralLedger(ctx, s.db, from, to, accountPrefix)
+	if err != nil {
+		zap.L().Error("Failed to build general ledger report", zap.Error(err))
+		return nil, fmt.Errorf("failed to build general ledger report: %w", err)
+	}
+	return report, nil
+}
+
+// MovementSummaryReport returns inflows and outflows per account type over [from, to)
+func (s *LedgerService) MovementSummaryReport(ctx context.Context, from, to time.Time) (*models.MovementSummaryReport, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	report, err := reports.MovementSummary(ctx, s.db, from, to)
+	if err != nil {
+		zap.L().Error("Failed to build movement summary report", zap.Error(err))
+		return nil, fmt.Errorf("failed to build movement summary report: %w", err)
+	}
+	return report, nil
+}
+
+// SolvencyReport compares user liabilities with wallet custody as of asOf, valued in USD
+// at prices in effect at asOf when a price source is configured
+func (s *LedgerService) SolvencyReport(ctx context.Context, asOf time.Time) (*models.SolvencyReport, error) {
+	report, err := reports.Solvency(ctx, s.db, s.prices, asOf)
+	if err != nil {
+		zap.L().Error("Failed to build solvency report", zap.Error(err))
+		return nil, fmt.Errorf("failed to build solvency report: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,77 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/rewards"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// FindReward looks up a platform REWARD transaction by its Prime transaction id
+func (s *LedgerService) FindReward(ctx context.Context, primeTxId string) (*rewards.Reward, error) {
+	if primeTxId == "" {
+		return nil, fmt.Errorf("reward transaction id is required")
+	}
+	page, err := s.db.SearchTransactions(ctx, store.TransactionQuery{ExternalTxId: primeTxId, Limit: 10})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reward transaction: %w", err)
+	}
+	for _, tx := range page.Transactions {
+		txType := tx.Metadata["transaction_type"]
+		if txType == "" {
+			txType = tx.TransactionType
+		}
+		if tx.ExternalTransactionId != primeTxId || !strings.EqualFold(txType, "REWARD") {
+			continue
+		}
+		return &rewards.Reward{
+			TransactionId: primeTxId,
+			Asset:         tx.Asset,
+			Amount:        tx.Amount.Abs(),
+			EffectiveAt:   tx.EffectiveAt,
+		}, nil
+	}
+	return nil, fmt.Errorf("no REWARD transaction %s in the ledger", primeTxId)
+}
+
+// PlanRewardDistribution computes how a reward would be split without posting it
+func (s *LedgerService) PlanRewardDistribution(ctx context.Context, reward rewards.Reward, opts rewards.Options) (*models.RewardDistributionReport, error) {
+	report, err := rewards.Plan(ctx, s.db, reward, opts)
+	if err != nil {
+		zap.L().Error("Failed to plan reward distribution", zap.String("reward_tx_id", reward.TransactionId), zap.Error(err))
+		return nil, fmt.Errorf("failed to plan reward distribution: %w", err)
+	}
+	return report, nil
+}
+
+// DistributeReward splits a reward between users and posts it atomically. Returns an
+// error wrapping rewards.ErrAlreadyDistributed if the reward was distributed before.
+func (s *LedgerService) DistributeReward(ctx context.Context, reward rewards.Reward, opts rewards.Options) (*models.RewardDistributionReport, error) {
+	report, err := rewards.Distribute(ctx, s.db, reward, opts)
+	if err != nil {
+		zap.L().Error("Failed to distribute reward", zap.String("reward_tx_id", reward.TransactionId), zap.Error(err))
+		return nil, fmt.Errorf("failed to distribute reward: %w", err)
+	}
+	return report, nil
+}
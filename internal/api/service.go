@@ -20,12 +20,14 @@ import (
 	"context"
 	"fmt"
 
+	"prime-send-receive-go/internal/pricing"
 	"prime-send-receive-go/internal/store"
 )
 
 // LedgerService provides minimal API
 type LedgerService struct {
-	db store.LedgerStore
+	db     store.LedgerStore
+	prices pricing.PriceSource // optional; enables USD valuations
 }
 
 func NewLedgerService(db store.LedgerStore) *LedgerService {
@@ -34,6 +36,12 @@ func NewLedgerService(db store.LedgerStore) *LedgerService {
 	}
 }
 
+// WithPriceSource enables USD valuations in balances and reports
+func (s *LedgerService) WithPriceSource(prices pricing.PriceSource) *LedgerService {
+	s.prices = prices
+	return s
+}
+
 func (s *LedgerService) HealthCheck(ctx context.Context) error {
 	_, err := s.db.GetUsers(ctx)
 	if err != nil {
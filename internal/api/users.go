@@ -0,0 +1,72 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// UpdateUser changes a user's name and/or email
+func (s *LedgerService) UpdateUser(ctx context.Context, params store.UpdateUserParams) (*models.User, error) {
+	user, err := s.db.UpdateUser(ctx, params)
+	if err != nil {
+		zap.L().Warn("Failed to update user", zap.String("user_id", params.UserId), zap.Error(err))
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+// DeactivateUser hides a user from lookups; deposits to their addresses go to suspense
+func (s *LedgerService) DeactivateUser(ctx context.Context, userId string) error {
+	if userId == "" {
+		return fmt.Errorf("user_id is required")
+	}
+	if err := s.db.DeactivateUser(ctx, userId); err != nil {
+		zap.L().Warn("Failed to deactivate user", zap.String("user_id", userId), zap.Error(err))
+		return fmt.Errorf("failed to deactivate user: %w", err)
+	}
+	return nil
+}
+
+// CloseUser closes a user account, sweeping balances first when forced, and retires its addresses
+func (s *LedgerService) CloseUser(ctx context.Context, params store.CloseUserParams) (*models.UserClosure, error) {
+	closure, err := s.db.CloseUser(ctx, params)
+	if err != nil {
+		zap.L().Warn("Failed to close user",
+			zap.String("user_id", params.UserId),
+			zap.String("sweep_to", params.SweepTo),
+			zap.Bool("force", params.Force),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to close user: %w", err)
+	}
+	return closure, nil
+}
+
+// GetSuspenseDeposits returns deposits to a user's addresses that were credited to suspense
+// after the user was deactivated or closed
+func (s *LedgerService) GetSuspenseDeposits(ctx context.Context, userId string, limit int) ([]models.TransactionRecord, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	return s.FindTransactionsByMetadata(ctx, map[string]string{"suspense_user_id": userId}, limit)
+}
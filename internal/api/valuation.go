@@ -0,0 +1,80 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
+	"prime-send-receive-go/internal/reports"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// GetUserValuation returns a user's current balances per asset with latest USD prices and total
+func (s *LedgerService) GetUserValuation(ctx context.Context, userId string) (*models.Valuation, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if s.prices == nil {
+		return nil, fmt.Errorf("no price source configured")
+	}
+
+	balances, err := s.db.GetAllUserBalances(ctx, userId)
+	if err != nil {
+		zap.L().Error("Failed to get user balances", zap.String("user_id", userId), zap.Error(err))
+		return nil, fmt.Errorf("failed to retrieve balances")
+	}
+
+	// Formance reports one balance per network; value the asset total
+	amounts := make(map[string]decimal.Decimal)
+	for _, b := range balances {
+		amounts[b.Asset] = amounts[b.Asset].Add(b.Balance)
+	}
+
+	valuation, err := pricing.Value(ctx, s.prices, time.Time{}, amounts)
+	if err != nil {
+		zap.L().Error("Failed to value user balances", zap.String("user_id", userId), zap.Error(err))
+		return nil, fmt.Errorf("failed to value balances: %w", err)
+	}
+	return valuation, nil
+}
+
+// GetLiabilitiesValuation returns total user balances per asset as of asOf, valued in
+// USD at prices in effect at asOf (latest prices for a zero asOf)
+func (s *LedgerService) GetLiabilitiesValuation(ctx context.Context, asOf time.Time) (*models.Valuation, error) {
+	if s.prices == nil {
+		return nil, fmt.Errorf("no price source configured")
+	}
+
+	lines, err := s.db.GetTrialBalance(ctx, asOf)
+	if err != nil {
+		zap.L().Error("Failed to get trial balance", zap.Error(err))
+		return nil, fmt.Errorf("failed to get trial balance: %w", err)
+	}
+
+	valuation, err := pricing.Value(ctx, s.prices, asOf, reports.UserLiabilities(lines))
+	if err != nil {
+		zap.L().Error("Failed to value user liabilities", zap.Error(err))
+		return nil, fmt.Errorf("failed to value user liabilities: %w", err)
+	}
+	return valuation, nil
+}
@@ -44,7 +44,20 @@ func (s *LedgerService) ProcessWithdrawal(ctx context.Context, userId, asset str
 
 	err := s.db.ProcessWithdrawal(ctx, userId, asset, amount, externalTxId)
 	if err != nil {
-		if errors.Is(err, store.ErrDuplicateTransaction) {
+		if errors.Is(err, store.ErrUserFrozen) {
+			zap.L().Warn("Withdrawal rejected for frozen user",
+				zap.String("user_id", userId),
+				zap.String("asset_network", asset),
+				zap.String("amount", amount.String()),
+				zap.String("external_tx_id", externalTxId))
+		} else if errors.Is(err, store.ErrInsufficientAvailable) {
+			zap.L().Warn("Withdrawal rejected for insufficient available balance",
+				zap.String("user_id", userId),
+				zap.String("asset_network", asset),
+				zap.String("amount", amount.String()),
+				zap.String("external_tx_id", externalTxId),
+				zap.Error(err))
+		} else if errors.Is(err, store.ErrDuplicateTransaction) {
 			zap.L().Info("Duplicate withdrawal detected in API service",
 				zap.String("user_id", userId),
 				zap.String("asset_network", asset),
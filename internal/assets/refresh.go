@@ -0,0 +1,82 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assets
+
+import (
+	"fmt"
+	"strings"
+
+	"prime-send-receive-go/internal/models"
+)
+
+// Refresh returns a copy of r updated from Prime's asset and network metadata,
+// with one note per change or disagreement. Prime's destination tag requirement
+// is adopted, and networks Prime lists for a registered symbol are added as
+// disabled assets. Precision is never changed, since it fixes how existing ledger
+// amounts are scaled: a mismatch is only reported. Symbols the registry does not
+// know are reported and skipped.
+func (r *Registry) Refresh(primeAssets []models.PrimeAsset) (*Registry, []string, error) {
+	refreshed := &Registry{
+		assets: append([]Asset(nil), r.assets...),
+		index:  make(map[string]int, len(r.index)),
+	}
+	for key, pos := range r.index {
+		refreshed.index[key] = pos
+	}
+
+	var notes []string
+	for _, pa := range primeAssets {
+		symbol := r.Canonical(strings.ToUpper(pa.Symbol))
+		if !r.KnownPrecision(symbol) {
+			notes = append(notes, fmt.Sprintf("%s is not registered; add it to the assets file to use it", pa.Symbol))
+			continue
+		}
+		if pa.Precision > 0 && pa.Precision != r.Precision(symbol) {
+			notes = append(notes, fmt.Sprintf("%s precision is %d in the registry but %d in Prime", symbol, r.Precision(symbol), pa.Precision))
+		}
+
+		for _, pn := range pa.Networks {
+			network := strings.ToLower(pn.Network)
+			if network == "" {
+				continue
+			}
+			key := Asset{Symbol: symbol, Network: network}.Name()
+			pos, ok := refreshed.index[key]
+			if !ok {
+				refreshed.assets = append(refreshed.assets, Asset{
+					Symbol:      symbol,
+					Network:     network,
+					PrimeSymbol: symbol,
+					Precision:   r.Precision(symbol),
+					TagRequired: pn.TagRequired,
+				})
+				refreshed.index[key] = len(refreshed.assets) - 1
+				notes = append(notes, fmt.Sprintf("added %s from Prime (disabled)", key))
+				continue
+			}
+			if a := &refreshed.assets[pos]; a.TagRequired != pn.TagRequired {
+				notes = append(notes, fmt.Sprintf("%s tag_required changed to %t by Prime", key, pn.TagRequired))
+				a.TagRequired = pn.TagRequired
+			}
+		}
+	}
+
+	if err := refreshed.build(); err != nil {
+		return nil, notes, err
+	}
+	return refreshed, notes, nil
+}
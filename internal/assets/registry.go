@@ -0,0 +1,379 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package assets is the registry of the assets and networks this service handles.
+// It replaces per-package symbol maps, precision tables and network lists: the
+// listener maps Prime's network-specific symbols to canonical ones, the Formance
+// backend scales amounts by precision, the onboarding commands search networks and
+// withdrawals look up minimums and tag requirements, all here.
+//
+// A registry starts from the built-in catalogue embedded in builtin.yaml, which
+// assets.yaml extends and overrides. Entries listed in assets.yaml are enabled
+// unless they say otherwise; built-in entries are only known, not enabled.
+package assets
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"prime-send-receive-go/internal/addressing"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultPrecision is used for symbols the registry does not know
+const DefaultPrecision = 6
+
+// maxPrecision bounds configured precisions to what ledgers can represent
+const maxPrecision = 36
+
+var (
+	// ErrUnknownAsset is returned for symbol/network pairs the registry does not know
+	ErrUnknownAsset = errors.New("unknown asset")
+	// ErrInvalidRegistry is returned when an assets file fails validation
+	ErrInvalidRegistry = errors.New("invalid asset registry")
+)
+
+//go:embed builtin.yaml
+var builtinYAML []byte
+
+// Asset is one asset on one network.
+type Asset struct {
+	Symbol        string          // canonical ledger symbol, e.g. "USDC"
+	Network       string          // Prime network id, e.g. "base-mainnet"; "" for ledger-only assets
+	PrimeSymbol   string          // Prime's symbol on this network, e.g. "BASEUSDC"
+	Precision     int             // decimals the ledger keeps
+	MinWithdrawal decimal.Decimal // smallest amount that may be withdrawn
+	TagRequired   bool            // deposits and withdrawals need a destination tag
+	Enabled       bool            // provisioned and monitored
+}
+
+// Name returns the SYMBOL-network form used on the command line, e.g.
+// "USDC-base-mainnet".
+func (a Asset) Name() string {
+	if a.Network == "" {
+		return a.Symbol
+	}
+	return a.Symbol + "-" + a.Network
+}
+
+// assetEntry is an assets file entry. Unset fields keep the built-in value.
+type assetEntry struct {
+	Symbol        string `yaml:"symbol"`
+	Network       string `yaml:"network"`
+	PrimeSymbol   string `yaml:"prime_symbol"`
+	Precision     *int   `yaml:"precision"`
+	MinWithdrawal string `yaml:"min_withdrawal"`
+	TagRequired   *bool  `yaml:"tag_required"`
+	Enabled       *bool  `yaml:"enabled"`
+}
+
+type assetsFile struct {
+	Assets []assetEntry `yaml:"assets"`
+}
+
+// Registry is an immutable, validated set of assets.
+type Registry struct {
+	assets    []Asset
+	index     map[string]int    // Name() -> position in assets
+	canonical map[string]string // Prime or canonical symbol -> canonical symbol
+	precision map[string]int    // canonical symbol -> precision
+}
+
+var current atomic.Pointer[Registry]
+
+func init() {
+	registry, err := parse(nil, "")
+	if err != nil {
+		panic(fmt.Sprintf("built-in asset registry: %v", err))
+	}
+	current.Store(registry)
+}
+
+// Current returns the registry in use by this process. Until SetCurrent is called
+// it holds the built-in catalogue.
+func Current() *Registry {
+	return current.Load()
+}
+
+// SetCurrent replaces the process-wide registry.
+func SetCurrent(r *Registry) {
+	current.Store(r)
+}
+
+// Builtin returns a registry holding only the built-in catalogue.
+func Builtin() *Registry {
+	registry, _ := parse(nil, "")
+	return registry
+}
+
+// Load reads and validates an assets file on top of the built-in catalogue.
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	return parse(data, path)
+}
+
+// Parse validates assets file contents on top of the built-in catalogue.
+func Parse(data []byte) (*Registry, error) {
+	return parse(data, "assets file")
+}
+
+func parse(data []byte, source string) (*Registry, error) {
+	var builtin assetsFile
+	if err := yaml.UnmarshalStrict(builtinYAML, &builtin); err != nil {
+		return nil, fmt.Errorf("unable to parse built-in assets: %w", err)
+	}
+	var configured assetsFile
+	if data != nil {
+		if err := yaml.UnmarshalStrict(data, &configured); err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", source, err)
+		}
+	}
+
+	r := &Registry{index: make(map[string]int)}
+	if err := r.merge(builtin.Assets, false, "built-in assets"); err != nil {
+		return nil, err
+	}
+	if err := r.merge(configured.Assets, true, source); err != nil {
+		return nil, err
+	}
+	if err := r.build(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// merge applies entries over the assets already in r. Entries that do not say
+// whether they are enabled get enabledByDefault.
+func (r *Registry) merge(entries []assetEntry, enabledByDefault bool, source string) error {
+	for i, e := range entries {
+		symbol := strings.ToUpper(strings.TrimSpace(e.Symbol))
+		network := strings.ToLower(strings.TrimSpace(e.Network))
+		if symbol == "" {
+			return fmt.Errorf("%w: %s: asset at index %d missing symbol", ErrInvalidRegistry, source, i)
+		}
+
+		key := Asset{Symbol: symbol, Network: network}.Name()
+		pos, ok := r.index[key]
+		if !ok {
+			r.assets = append(r.assets, Asset{
+				Symbol:      symbol,
+				Network:     network,
+				PrimeSymbol: symbol,
+				Precision:   -1,
+				TagRequired: addressing.TagRequired(network),
+			})
+			pos = len(r.assets) - 1
+			r.index[key] = pos
+		}
+		a := &r.assets[pos]
+
+		if e.PrimeSymbol != "" {
+			a.PrimeSymbol = strings.ToUpper(strings.TrimSpace(e.PrimeSymbol))
+		}
+		if e.Precision != nil {
+			a.Precision = *e.Precision
+		}
+		if e.MinWithdrawal != "" {
+			minimum, err := decimal.NewFromString(strings.TrimSpace(e.MinWithdrawal))
+			if err != nil || minimum.IsNegative() {
+				return fmt.Errorf("%w: %s: %s has invalid min_withdrawal %q", ErrInvalidRegistry, source, key, e.MinWithdrawal)
+			}
+			a.MinWithdrawal = minimum
+		}
+		if e.TagRequired != nil {
+			a.TagRequired = *e.TagRequired
+		}
+		a.Enabled = enabledByDefault
+		if e.Enabled != nil {
+			a.Enabled = *e.Enabled
+		}
+	}
+	return nil
+}
+
+// build checks the merged assets for consistency and fills in the lookup maps.
+func (r *Registry) build() error {
+	r.canonical = make(map[string]string)
+	r.precision = make(map[string]int)
+
+	for _, a := range r.assets {
+		if a.Precision >= 0 {
+			if p, ok := r.precision[a.Symbol]; ok && p != a.Precision {
+				return fmt.Errorf("%w: %s has precision %d on one network and %d on another", ErrInvalidRegistry, a.Symbol, p, a.Precision)
+			}
+			if a.Precision > maxPrecision {
+				return fmt.Errorf("%w: %s precision %d exceeds %d", ErrInvalidRegistry, a.Name(), a.Precision, maxPrecision)
+			}
+			r.precision[a.Symbol] = a.Precision
+		}
+	}
+
+	for i := range r.assets {
+		a := &r.assets[i]
+		if a.Precision < 0 {
+			p, ok := r.precision[a.Symbol]
+			if !ok {
+				p = DefaultPrecision
+			}
+			a.Precision = p
+			r.precision[a.Symbol] = p
+		}
+		if a.Enabled && a.Network == "" {
+			return fmt.Errorf("%w: enabled asset %s missing network", ErrInvalidRegistry, a.Symbol)
+		}
+		if !a.MinWithdrawal.Equal(a.MinWithdrawal.Truncate(int32(a.Precision))) {
+			return fmt.Errorf("%w: %s min_withdrawal %s has more than %d decimals", ErrInvalidRegistry, a.Name(), a.MinWithdrawal, a.Precision)
+		}
+		for _, symbol := range []string{a.Symbol, a.PrimeSymbol} {
+			if existing, ok := r.canonical[symbol]; ok && existing != a.Symbol {
+				return fmt.Errorf("%w: Prime symbol %s maps to both %s and %s", ErrInvalidRegistry, symbol, existing, a.Symbol)
+			}
+			r.canonical[symbol] = a.Symbol
+		}
+	}
+	return nil
+}
+
+// All returns every asset in the registry, enabled or not.
+func (r *Registry) All() []Asset {
+	return append([]Asset(nil), r.assets...)
+}
+
+// Enabled returns the assets to provision and monitor, in file order.
+func (r *Registry) Enabled() []Asset {
+	var enabled []Asset
+	for _, a := range r.assets {
+		if a.Enabled {
+			enabled = append(enabled, a)
+		}
+	}
+	return enabled
+}
+
+// Lookup returns the asset for symbol on network.
+func (r *Registry) Lookup(symbol, network string) (Asset, bool) {
+	pos, ok := r.index[Asset{Symbol: strings.ToUpper(symbol), Network: strings.ToLower(network)}.Name()]
+	if !ok {
+		return Asset{}, false
+	}
+	return r.assets[pos], true
+}
+
+// ParseAsset resolves the SYMBOL-network form used on the command line, e.g.
+// "USDC-base-mainnet", to a registered asset.
+func (r *Registry) ParseAsset(name string) (Asset, error) {
+	symbol, network, found := strings.Cut(strings.TrimSpace(name), "-")
+	if !found || symbol == "" || network == "" {
+		return Asset{}, fmt.Errorf("invalid asset format %q, expected: SYMBOL-network-type (e.g., ETH-ethereum-mainnet)", name)
+	}
+	a, ok := r.Lookup(symbol, network)
+	if !ok {
+		return Asset{}, fmt.Errorf("%w %s: add it to the assets file", ErrUnknownAsset, name)
+	}
+	return a, nil
+}
+
+// Canonical maps a Prime symbol such as "BASEUSDC" to its canonical ledger symbol.
+// Unknown symbols are returned unchanged.
+func (r *Registry) Canonical(symbol string) string {
+	if canonical, ok := r.canonical[symbol]; ok {
+		return canonical
+	}
+	return symbol
+}
+
+// Precision returns the decimals the ledger keeps for a canonical symbol, or
+// DefaultPrecision for unknown symbols.
+func (r *Registry) Precision(symbol string) int {
+	if p, ok := r.precision[symbol]; ok {
+		return p
+	}
+	return DefaultPrecision
+}
+
+// KnownPrecision reports whether the registry knows symbol's precision.
+func (r *Registry) KnownPrecision(symbol string) bool {
+	_, ok := r.precision[symbol]
+	return ok
+}
+
+// Symbols returns the canonical symbols in the registry, sorted.
+func (r *Registry) Symbols() []string {
+	symbols := make([]string, 0, len(r.precision))
+	for symbol := range r.precision {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Networks returns the networks of all registered assets, enabled or not, in
+// registry order.
+func (r *Registry) Networks() []string {
+	var networks []string
+	seen := make(map[string]bool)
+	for _, a := range r.assets {
+		if a.Network != "" && !seen[a.Network] {
+			seen[a.Network] = true
+			networks = append(networks, a.Network)
+		}
+	}
+	return networks
+}
+
+// TagRequired reports whether transfers on network need a destination tag.
+func (r *Registry) TagRequired(network string) bool {
+	network = strings.ToLower(network)
+	for _, a := range r.assets {
+		if a.Network == network && a.TagRequired {
+			return true
+		}
+	}
+	return addressing.TagRequired(network)
+}
+
+// CheckReplaces reports whether r can replace previous in a running process. A
+// symbol's precision and the canonical symbol a Prime symbol maps to fix how
+// existing ledger amounts are read, so neither may change without a restart
+// and migration.
+func (r *Registry) CheckReplaces(previous *Registry) error {
+	var problems []string
+	for symbol, precision := range previous.precision {
+		if p, ok := r.precision[symbol]; ok && p != precision {
+			problems = append(problems, fmt.Sprintf("%s precision %d -> %d", symbol, precision, p))
+		}
+	}
+	for symbol, canonical := range previous.canonical {
+		if c, ok := r.canonical[symbol]; ok && c != canonical {
+			problems = append(problems, fmt.Sprintf("%s maps to %s instead of %s", symbol, c, canonical))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrInvalidRegistry, strings.Join(problems, "; "))
+	}
+	return nil
+}
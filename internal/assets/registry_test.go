@@ -0,0 +1,164 @@
+package assets
+
+import (
+	"errors"
+	"testing"
+
+	"prime-send-receive-go/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBuiltin(t *testing.T) {
+	r := Builtin()
+	precisions := map[string]int{"USD": 2, "USDC": 6, "USDT": 6, "BTC": 8, "ETH": 18, "SOL": 9, "DOGE": DefaultPrecision}
+	for symbol, want := range precisions {
+		if got := r.Precision(symbol); got != want {
+			t.Errorf("Precision(%q) = %d, want %d", symbol, got, want)
+		}
+	}
+	canonical := map[string]string{"BASEUSDC": "USDC", "SPLUSDC": "USDC", "AVAUSDC": "USDC", "ARBUSDC": "USDC", "BASEETH": "ETH", "USDC": "USDC", "DOGE": "DOGE"}
+	for symbol, want := range canonical {
+		if got := r.Canonical(symbol); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", symbol, got, want)
+		}
+	}
+	if len(r.Enabled()) != 0 {
+		t.Errorf("Expected no built-in asset to be enabled, got %v", r.Enabled())
+	}
+	for _, network := range []string{"ethereum-mainnet", "base-mainnet", "bitcoin-mainnet", "solana-mainnet", "polygon-mainnet", "arbitrum-mainnet", "avalanche-mainnet"} {
+		found := false
+		for _, n := range r.Networks() {
+			found = found || n == network
+		}
+		if !found {
+			t.Errorf("Expected %s in Networks(), got %v", network, r.Networks())
+		}
+	}
+	if !r.TagRequired("xrp-mainnet") || r.TagRequired("base-mainnet") {
+		t.Error("Expected XRP, and not Base, to require a destination tag")
+	}
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse([]byte(`
+assets:
+  - symbol: "usdc"
+    network: "base-mainnet"
+    min_withdrawal: "5"
+  - symbol: "BTC"
+    network: "bitcoin-mainnet"
+  - symbol: "ETH"
+    network: "base-mainnet"
+    enabled: false
+  - symbol: "PYUSD"
+    network: "ethereum-mainnet"
+    prime_symbol: "PYUSD"
+    precision: 6
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var enabled []string
+	for _, a := range r.Enabled() {
+		enabled = append(enabled, a.Name())
+	}
+	if len(enabled) != 3 || enabled[0] != "USDC-base-mainnet" || enabled[1] != "BTC-bitcoin-mainnet" || enabled[2] != "PYUSD-ethereum-mainnet" {
+		t.Errorf("Unexpected enabled assets %v", enabled)
+	}
+
+	a, err := r.ParseAsset("USDC-base-mainnet")
+	if err != nil || a.PrimeSymbol != "BASEUSDC" || a.Precision != 6 || !a.MinWithdrawal.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("ParseAsset = %+v, %v", a, err)
+	}
+	if _, err := r.ParseAsset("DOGE-dogecoin-mainnet"); !errors.Is(err, ErrUnknownAsset) {
+		t.Errorf("Expected ErrUnknownAsset, got %v", err)
+	}
+	if _, err := r.ParseAsset("USDC"); err == nil {
+		t.Error("Expected an asset without a network to be rejected")
+	}
+	if r.Precision("PYUSD") != 6 {
+		t.Errorf("Expected PYUSD precision 6, got %d", r.Precision("PYUSD"))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing symbol":      "assets:\n  - network: base-mainnet\n",
+		"enabled no network":  "assets:\n  - symbol: DOGE\n",
+		"precision conflict":  "assets:\n  - symbol: USDC\n    network: base-mainnet\n    precision: 8\n",
+		"shared prime symbol": "assets:\n  - symbol: EURC\n    network: base-mainnet\n    prime_symbol: BASEUSDC\n",
+		"negative minimum":    "assets:\n  - symbol: BTC\n    network: bitcoin-mainnet\n    min_withdrawal: \"-1\"\n",
+		"minimum decimals":    "assets:\n  - symbol: BTC\n    network: bitcoin-mainnet\n    min_withdrawal: \"0.000000001\"\n",
+		"unknown field":       "assets:\n  - symbol: BTC\n    network: bitcoin-mainnet\n    decimals: 8\n",
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected Parse to fail", name)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	r := Builtin()
+	refreshed, notes, err := r.Refresh([]models.PrimeAsset{
+		{Symbol: "USDC", Precision: 6, Networks: []models.PrimeAssetNetwork{{Network: "base-mainnet"}, {Network: "optimism-mainnet"}}},
+		{Symbol: "ETH", Precision: 8, Networks: []models.PrimeAssetNetwork{{Network: "ethereum-mainnet"}}},
+		{Symbol: "XRP", Precision: 6, Networks: []models.PrimeAssetNetwork{{Network: "xrp-mainnet", TagRequired: true}}},
+		{Symbol: "DOGE", Precision: 8, Networks: []models.PrimeAssetNetwork{{Network: "dogecoin-mainnet"}}},
+	})
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if len(notes) != 3 {
+		t.Errorf("Expected notes for the new network, the ETH precision and DOGE, got %v", notes)
+	}
+	if a, ok := refreshed.Lookup("USDC", "optimism-mainnet"); !ok || a.Enabled || a.Precision != 6 {
+		t.Errorf("Expected a disabled USDC-optimism-mainnet, got %+v, %v", a, ok)
+	}
+	if refreshed.Precision("ETH") != 18 {
+		t.Error("Expected Refresh to keep the registry precision")
+	}
+	if _, ok := refreshed.Lookup("DOGE", "dogecoin-mainnet"); ok {
+		t.Error("Expected unregistered symbols to be skipped")
+	}
+	if _, ok := r.Lookup("USDC", "optimism-mainnet"); ok {
+		t.Error("Expected Refresh to leave the original registry unchanged")
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	defer SetCurrent(Current())
+	r, err := Parse([]byte("assets:\n  - symbol: BTC\n    network: bitcoin-mainnet\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	SetCurrent(r)
+	if len(Current().Enabled()) != 1 {
+		t.Errorf("Expected Current to return the registry passed to SetCurrent")
+	}
+}
+
+func TestCheckReplaces(t *testing.T) {
+	previous, err := Parse([]byte("assets:\n  - symbol: BTC\n    network: bitcoin-mainnet\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	added, err := Parse([]byte("assets:\n  - symbol: BTC\n    network: bitcoin-mainnet\n  - symbol: PYUSD\n    network: ethereum-mainnet\n    precision: 6\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := added.CheckReplaces(previous); err != nil {
+		t.Errorf("Expected adding an asset to be compatible, got %v", err)
+	}
+
+	rescaled, err := Parse([]byte("assets:\n  - symbol: BTC\n    network: bitcoin-mainnet\n    precision: 10\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := rescaled.CheckReplaces(previous); !errors.Is(err, ErrInvalidRegistry) {
+		t.Errorf("Expected a precision change to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assets
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// fileState identifies a version of a watched file.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{exists: true, size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+// WatchFile polls path every interval and calls onChange whenever the file is
+// created, modified or removed. Stat errors are passed to onError and do not
+// count as a change. WatchFile blocks until ctx is cancelled.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func(), onError func(error)) {
+	last, err := statFile(path)
+	if err != nil && onError != nil {
+		onError(err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			state, err := statFile(path)
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				continue
+			}
+			if state != last {
+				last = state
+				onChange()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
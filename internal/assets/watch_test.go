@@ -0,0 +1,55 @@
+package assets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.yaml")
+	changes := make(chan struct{}, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchFile(ctx, path, 5*time.Millisecond, func() { changes <- struct{}{} }, nil)
+		close(done)
+	}()
+
+	expectChange := func(step string) {
+		t.Helper()
+		select {
+		case <-changes:
+		case <-time.After(time.Second):
+			t.Fatalf("%s: expected a change notification", step)
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("assets: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("create")
+
+	if err := os.WriteFile(path, []byte("assets:\n  - symbol: BTC\n    network: bitcoin-mainnet\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("modify")
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("remove")
+
+	select {
+	case <-changes:
+		t.Error("Expected no notification without a change")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	cancel()
+	<-done
+}
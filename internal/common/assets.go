@@ -21,18 +21,17 @@ import (
 	"os"
 	"path/filepath"
 
-	"gopkg.in/yaml.v2"
+	"prime-send-receive-go/internal/assets"
 )
 
+// AssetConfig is an enabled asset from the assets file
 type AssetConfig struct {
-	Symbol  string `yaml:"symbol"`
-	Network string `yaml:"network"`
-}
-
-type AssetsConfig struct {
-	Assets []AssetConfig `yaml:"assets"`
+	Symbol  string
+	Network string
 }
 
+// LoadAssetConfig validates an assets file against the asset registry and returns
+// its enabled assets.
 func LoadAssetConfig(assetsFile string) ([]AssetConfig, error) {
 	var assetsPath string
 	if filepath.IsAbs(assetsFile) {
@@ -45,26 +44,17 @@ func LoadAssetConfig(assetsFile string) ([]AssetConfig, error) {
 		assetsPath = filepath.Join(wd, assetsFile)
 	}
 
-	data, err := os.ReadFile(assetsPath)
+	registry, err := assets.Load(assetsPath)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read %s: %w", assetsFile, err)
-	}
-
-	var config AssetsConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("unable to parse %s: %w", assetsFile, err)
+		return nil, err
 	}
 
-	for i, asset := range config.Assets {
-		if asset.Symbol == "" {
-			return nil, fmt.Errorf("asset at index %d missing symbol", i)
-		}
-		if asset.Network == "" {
-			return nil, fmt.Errorf("asset at index %d missing network", i)
-		}
+	enabled := registry.Enabled()
+	configs := make([]AssetConfig, len(enabled))
+	for i, a := range enabled {
+		configs[i] = AssetConfig{Symbol: a.Symbol, Network: a.Network}
 	}
-
-	return config.Assets, nil
+	return configs, nil
 }
 
 func LoadAssetSymbols(assetsFile string) ([]string, error) {
@@ -18,11 +18,14 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"strings"
 
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/database"
 	"prime-send-receive-go/internal/formance"
 	"prime-send-receive-go/internal/models"
@@ -120,6 +123,10 @@ func InitializeServices(ctx context.Context, cfg *models.Config) (*Services, err
 		fSvc.SetPortfolioID(defaultPortfolio.Id)
 	}
 
+	if cfg.Assets.RefreshFromPrime {
+		refreshAssetRegistry(ctx, primeService, defaultPortfolio)
+	}
+
 	return &Services{
 		DbService:        ledger,
 		PrimeService:     primeService,
@@ -136,6 +143,11 @@ func InitializeDatabaseOnly(ctx context.Context, cfg *models.Config) (store.Ledg
 
 // initLedgerStore selects and initialises the backend based on BACKEND_TYPE.
 func initLedgerStore(ctx context.Context, cfg *models.Config) (store.LedgerStore, error) {
+	// Precision and symbol mapping must be settled before anything is posted
+	if err := loadAssetRegistry(cfg.Assets.File); err != nil {
+		return nil, err
+	}
+
 	switch strings.ToLower(cfg.BackendType) {
 	case "formance":
 		zap.L().Info("Using Formance backend", zap.String("stack_url", cfg.Formance.StackURL))
@@ -146,6 +158,74 @@ func initLedgerStore(ctx context.Context, cfg *models.Config) (store.LedgerStore
 	}
 }
 
+// loadAssetRegistry validates the assets file and makes it the registry every
+// package consults. Without an assets file the built-in registry is used.
+func loadAssetRegistry(assetsFile string) error {
+	registry, err := assets.Load(assetsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		zap.L().Info("No assets file found, using built-in asset registry", zap.String("file", assetsFile))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load asset registry: %w", err)
+	}
+	assets.SetCurrent(registry)
+	zap.L().Info("Loaded asset registry",
+		zap.String("file", assetsFile),
+		zap.Int("assets", len(registry.All())),
+		zap.Int("enabled", len(registry.Enabled())))
+	return nil
+}
+
+// refreshAssetRegistry updates the registry from Prime's asset metadata. Failures
+// keep the registry loaded from the assets file.
+func refreshAssetRegistry(ctx context.Context, primeService *prime.Service, portfolio *models.Portfolio) {
+	primeAssets, err := primeService.ListAssets(ctx, portfolio.EntityId)
+	if err != nil {
+		zap.L().Warn("Failed to refresh asset registry from Prime", zap.Error(err))
+		return
+	}
+	registry, notes, err := assets.Current().Refresh(primeAssets)
+	for _, note := range notes {
+		zap.L().Info("Asset registry refresh", zap.String("note", note))
+	}
+	if err != nil {
+		zap.L().Warn("Prime asset metadata conflicts with the asset registry", zap.Error(err))
+		return
+	}
+	assets.SetCurrent(registry)
+	zap.L().Info("Refreshed asset registry from Prime", zap.Int("prime_assets", len(primeAssets)))
+}
+
+// ReloadAssetRegistry re-reads the assets file into the running registry,
+// re-applying Prime's asset metadata when enabled. A missing, invalid or
+// incompatible file leaves the current registry in place.
+func ReloadAssetRegistry(ctx context.Context, cfg *models.Config, services *Services) error {
+	registry, err := assets.Load(cfg.Assets.File)
+	if err != nil {
+		return fmt.Errorf("failed to reload asset registry: %w", err)
+	}
+	if err := registry.CheckReplaces(assets.Current()); err != nil {
+		return fmt.Errorf("reloaded asset registry is incompatible: %w", err)
+	}
+
+	previous := assets.Current()
+	assets.SetCurrent(registry)
+	if cfg.Assets.RefreshFromPrime {
+		refreshAssetRegistry(ctx, services.PrimeService, services.DefaultPortfolio)
+		if err := assets.Current().CheckReplaces(previous); err != nil {
+			assets.SetCurrent(previous)
+			return fmt.Errorf("refreshed asset registry is incompatible: %w", err)
+		}
+	}
+
+	zap.L().Info("Reloaded asset registry",
+		zap.String("file", cfg.Assets.File),
+		zap.Int("assets", len(assets.Current().All())),
+		zap.Int("enabled", len(assets.Current().Enabled())))
+	return nil
+}
+
 func (cs *Services) Close() {
 	if cs.DbService != nil {
 		cs.DbService.Close()
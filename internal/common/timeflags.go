@@ -0,0 +1,75 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseAsOf parses an --as-of style flag value. Accepts RFC3339 timestamps or a
+// plain date (YYYY-MM-DD), which is read as the end of that day in UTC so the
+// whole day's activity is included.
+func ParseAsOf(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339 or YYYY-MM-DD", value)
+	}
+	return d.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+// ParseTimeRange parses --from/--to style flag values into a half-open [from, to)
+// range. Either may be empty. A plain date for from is the start of that day; a plain
+// date for to includes the whole day, so --from 2025-03-01 --to 2025-03-31 covers March.
+func ParseTimeRange(from, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	if from != "" {
+		t, err := parseTimeOrDate(from)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = t
+	}
+	if to != "" {
+		t, err := parseTimeOrDate(to)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if _, dateErr := time.Parse("2006-01-02", to); dateErr == nil {
+			t = t.Add(24 * time.Hour)
+		}
+		end = t
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid range: %s is not before %s", from, to)
+	}
+	return start, end, nil
+}
+
+func parseTimeOrDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339 or YYYY-MM-DD", value)
+	}
+	return d, nil
+}
@@ -19,12 +19,37 @@ package common
 import (
 	"context"
 	"fmt"
+	"regexp"
 
 	"prime-send-receive-go/internal/store"
 
 	"go.uber.org/zap"
 )
 
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+
+// ValidateEmail checks that email is present and well formed
+func ValidateEmail(email string) error {
+	if email == "" {
+		return fmt.Errorf("email cannot be empty")
+	}
+	if !emailRegex.MatchString(email) {
+		return fmt.Errorf("invalid email format: %s", email)
+	}
+	return nil
+}
+
+// ValidateName checks that name is present and at least two characters long
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if len(name) < 2 {
+		return fmt.Errorf("name must be at least 2 characters")
+	}
+	return nil
+}
+
 // UserInfo represents simplified user information for command-line utilities
 type UserInfo struct {
 	Id    string
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"prime-send-receive-go/internal/models"
@@ -41,6 +42,45 @@ func Load() (*models.Config, error) {
 		return nil, err
 	}
 
+	discoveryInterval, err := getEnvDuration("LISTENER_DISCOVERY_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	assetsReloadInterval, err := getEnvDuration("LISTENER_ASSETS_RELOAD_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPollInterval, err := getEnvDuration("LISTENER_MAX_POLL_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	// An idle wallet polled less often than the lookback window would miss transactions
+	if maxPollInterval >= lookbackWindow {
+		return nil, fmt.Errorf("LISTENER_MAX_POLL_INTERVAL (%s) must be shorter than LISTENER_LOOKBACK_WINDOW (%s)", maxPollInterval, lookbackWindow)
+	}
+
+	leaseTTL, err := getEnvDuration("LISTENER_LEASE_TTL", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	leaseRenewInterval, err := getEnvDuration("LISTENER_LEASE_RENEW_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	retryBaseDelay, err := getEnvDuration("LISTENER_RETRY_BASE_DELAY", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	retryMaxDelay, err := getEnvDuration("LISTENER_RETRY_MAX_DELAY", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
 	connMaxLifetime, err := getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute)
 	if err != nil {
 		return nil, err
@@ -56,6 +96,16 @@ func Load() (*models.Config, error) {
 		return nil, err
 	}
 
+	priceCacheTTL, err := getEnvDuration("PRICE_CACHE_TTL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	allowlistCooldown, err := getEnvDuration("WITHDRAWAL_ALLOWLIST_COOLDOWN", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
 	return &models.Config{
 		BackendType: getEnvString("BACKEND_TYPE", "sqlite"),
 		Formance: models.FormanceConfig{
@@ -74,10 +124,46 @@ func Load() (*models.Config, error) {
 			CreateDummyUsers: getEnvBool("CREATE_DUMMY_USERS", false),
 		},
 		Listener: models.ListenerConfig{
-			LookbackWindow:  lookbackWindow,
-			PollingInterval: pollingInterval,
-			CleanupInterval: cleanupInterval,
-			AssetsFile:      getEnvString("ASSETS_FILE", "assets.yaml"),
+			LookbackWindow:       lookbackWindow,
+			PollingInterval:      pollingInterval,
+			CleanupInterval:      cleanupInterval,
+			AssetsFile:           getEnvString("ASSETS_FILE", "assets.yaml"),
+			DiscoveryInterval:    discoveryInterval,
+			AssetsReloadInterval: assetsReloadInterval,
+			PollConcurrency:      getEnvInt("LISTENER_POLL_CONCURRENCY", 8),
+			MaxPollInterval:      maxPollInterval,
+			StatusAddr:           getEnvString("LISTENER_STATUS_ADDR", ""),
+			LeaderElection:       getEnvBool("LISTENER_LEADER_ELECTION", false),
+			InstanceId:           getEnvString("LISTENER_INSTANCE_ID", ""),
+			LeaseTTL:             leaseTTL,
+			LeaseRenewInterval:   leaseRenewInterval,
+			RetryPolicy: models.RetryPolicy{
+				BaseDelay:   retryBaseDelay,
+				MaxDelay:    retryMaxDelay,
+				MaxAttempts: getEnvInt("LISTENER_RETRY_MAX_ATTEMPTS", 10),
+			},
+			DeadLetterAlertThreshold: getEnvInt("LISTENER_DLQ_ALERT_THRESHOLD", 1),
+			DeadLetterWebhook:        getEnvString("LISTENER_DLQ_ALERT_WEBHOOK", ""),
+		},
+		Pricing: models.PricingConfig{
+			Source:   getEnvString("PRICE_SOURCE", ""),
+			File:     getEnvString("PRICE_FILE", ""),
+			URL:      getEnvString("PRICE_URL", ""),
+			CacheTTL: priceCacheTTL,
+		},
+		CustodyFee: models.CustodyFeeConfig{
+			AnnualBps: int64(getEnvInt("CUSTODY_FEE_BPS", 0)),
+			Precision: int32(getEnvInt("CUSTODY_FEE_PRECISION", 6)),
+		},
+		Withdrawal: models.WithdrawalConfig{
+			EnforceAllowlist:  getEnvBool("WITHDRAWAL_ALLOWLIST_ENFORCE", true),
+			AllowlistCooldown: allowlistCooldown,
+			VerifyAddressBook: getEnvBool("WITHDRAWAL_ALLOWLIST_VERIFY_ADDRESS_BOOK", false),
+			AddressBookStates: getEnvList("WITHDRAWAL_ALLOWLIST_ADDRESS_BOOK_STATES", []string{"ACTIVE"}),
+		},
+		Assets: models.AssetsConfig{
+			File:             getEnvString("ASSETS_FILE", "assets.yaml"),
+			RefreshFromPrime: getEnvBool("ASSETS_REFRESH_FROM_PRIME", false),
 		},
 	}, nil
 }
@@ -117,3 +203,16 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvList(key string, defaultValue []string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
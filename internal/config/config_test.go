@@ -0,0 +1,24 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_MaxPollIntervalWithinLookback(t *testing.T) {
+	t.Setenv("LISTENER_LOOKBACK_WINDOW", "10m")
+	t.Setenv("LISTENER_MAX_POLL_INTERVAL", "5m")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Listener.MaxPollInterval != 5*time.Minute {
+		t.Errorf("Expected a 5m max poll interval, got %s", cfg.Listener.MaxPollInterval)
+	}
+
+	t.Setenv("LISTENER_MAX_POLL_INTERVAL", "10m")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "LISTENER_LOOKBACK_WINDOW") {
+		t.Errorf("Expected a max poll interval equal to the lookback window to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package costbasis tracks tax lots per user and asset. Every increase of a user
+// account opens a lot at the USD price of the moment; every decrease consumes lots by
+// the chosen method and realizes a gain or loss. A withdrawal that failed and was
+// reversed or reverted is dropped together with its reversal, so it neither realizes
+// a gain nor resets the holding period. The book is rebuilt from ledger
+// postings on demand, so it is deterministic for a given history and price source
+// and identical for both backends.
+package costbasis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+// Lot selection methods
+const (
+	MethodFIFO       = "fifo"
+	MethodLIFO       = "lifo"
+	MethodSpecificID = "specific-id"
+)
+
+// Holding period terms
+const (
+	TermShort = "short"
+	TermLong  = "long"
+)
+
+// Selection names a lot, and how much of it, to consume for a disposal.
+type Selection struct {
+	LotId    string
+	Quantity decimal.Decimal
+}
+
+// Options controls how a book is built.
+type Options struct {
+	Method string // MethodFIFO (default), MethodLIFO or MethodSpecificID
+	// Selections maps a disposal transaction id to the lots it consumes, for
+	// MethodSpecificID. Any quantity not covered by a selection is consumed FIFO.
+	Selections map[string][]Selection
+	UserId     string    // only this user; empty for all users
+	To         time.Time // history cutoff (exclusive); zero for all history
+}
+
+// Book holds every lot and disposal derived from ledger history.
+type Book struct {
+	Method    string
+	Lots      []*models.TaxLot // in acquisition order
+	Disposals []models.LotDisposal
+}
+
+type holding struct {
+	user, asset string
+}
+
+// Build replays user postings in effective-time order and returns the resulting book.
+func Build(ctx context.Context, db store.LedgerStore, prices pricing.PriceSource, opts Options) (*Book, error) {
+	if prices == nil {
+		return nil, fmt.Errorf("a price source is required for cost basis")
+	}
+	method := opts.Method
+	if method == "" {
+		method = MethodFIFO
+	}
+	if method != MethodFIFO && method != MethodLIFO && method != MethodSpecificID {
+		return nil, fmt.Errorf("unknown cost basis method %q: expected fifo, lifo or specific-id", method)
+	}
+
+	var postings []store.Posting
+	err := db.ExportPostings(ctx, store.ExportQuery{To: opts.To}, func(chunk []store.Posting) error {
+		for _, p := range chunk {
+			owner, ok := store.UserIdFromAccount(p.Account)
+			if !ok || p.Amount.IsZero() {
+				continue
+			}
+			// Moving funds into or out of the user's own holds is not a disposal
+			if counterparty, ok := store.UserIdFromAccount(p.Counterparty); ok && counterparty == owner {
+				continue
+			}
+			if opts.UserId != "" && owner != opts.UserId {
+				continue
+			}
+			postings = append(postings, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger postings: %w", err)
+	}
+
+	postings = dropReversed(postings)
+
+	// Backends export in different orders; lots need effective-time order
+	sort.SliceStable(postings, func(i, j int) bool {
+		a, b := postings[i], postings[j]
+		if !a.EffectiveAt.Equal(b.EffectiveAt) {
+			return a.EffectiveAt.Before(b.EffectiveAt)
+		}
+		if a.TransactionId != b.TransactionId {
+			return a.TransactionId < b.TransactionId
+		}
+		return a.Sequence < b.Sequence
+	})
+
+	b := &bookBuilder{
+		book:       &Book{Method: method},
+		prices:     prices,
+		selections: make(map[string][]Selection),
+		open:       make(map[holding][]*models.TaxLot),
+		byId:       make(map[string]*models.TaxLot),
+	}
+	if method == MethodSpecificID {
+		for txId, sel := range opts.Selections {
+			b.selections[txId] = append([]Selection(nil), sel...)
+		}
+	}
+
+	for _, p := range postings {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		price, priced, err := b.price(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		if p.Direction == store.DirectionDebit {
+			b.acquire(p, price, priced)
+			continue
+		}
+		if err := b.dispose(p, price, priced); err != nil {
+			return nil, err
+		}
+	}
+	return b.book, nil
+}
+
+// OpenLots returns lots with a remaining quantity, optionally for one user.
+func (b *Book) OpenLots(userId string) []models.TaxLot {
+	var lots []models.TaxLot
+	for _, l := range b.Lots {
+		if l.Remaining.IsPositive() && (userId == "" || l.UserId == userId) {
+			lots = append(lots, *l)
+		}
+	}
+	return lots
+}
+
+type bookBuilder struct {
+	book       *Book
+	prices     pricing.PriceSource
+	selections map[string][]Selection
+	open       map[holding][]*models.TaxLot // acquisition order
+	byId       map[string]*models.TaxLot
+}
+
+func userId(p store.Posting) string {
+	owner, _ := store.UserIdFromAccount(p.Account)
+	return owner
+}
+
+func (b *bookBuilder) price(ctx context.Context, p store.Posting) (decimal.Decimal, bool, error) {
+	price, err := b.prices.Price(ctx, p.Asset, p.EffectiveAt)
+	if errors.Is(err, pricing.ErrNoPrice) {
+		return decimal.Zero, false, nil
+	}
+	if err != nil {
+		return decimal.Zero, false, fmt.Errorf("failed to price %s at %s: %w", p.Asset, p.EffectiveAt.Format(time.RFC3339), err)
+	}
+	return price, true, nil
+}
+
+func (b *bookBuilder) acquire(p store.Posting, price decimal.Decimal, priced bool) {
+	lot := &models.TaxLot{
+		Id:            fmt.Sprintf("%s:%d", p.TransactionId, p.Sequence),
+		UserId:        userId(p),
+		Asset:         p.Asset,
+		TransactionId: p.TransactionId,
+		AcquiredAt:    p.EffectiveAt,
+		Quantity:      p.Amount,
+		Remaining:     p.Amount,
+		UnitCost:      price,
+		Priced:        priced,
+	}
+	h := holding{lot.UserId, lot.Asset}
+	b.open[h] = append(b.open[h], lot)
+	b.byId[lot.Id] = lot
+	b.book.Lots = append(b.book.Lots, lot)
+}
+
+func (b *bookBuilder) dispose(p store.Posting, price decimal.Decimal, priced bool) error {
+	h := holding{userId(p), p.Asset}
+	remaining := p.Amount
+
+	// Specific lots first, then the method's order for anything left
+	for len(b.selections[p.TransactionId]) > 0 && remaining.IsPositive() {
+		sel := &b.selections[p.TransactionId][0]
+		lot, ok := b.byId[sel.LotId]
+		if !ok || lot.UserId != h.user || lot.Asset != h.asset {
+			return fmt.Errorf("transaction %s selects lot %s, which is not an open %s lot of user %s", p.TransactionId, sel.LotId, h.asset, h.user)
+		}
+		qty := decimal.Min(sel.Quantity, remaining)
+		if qty.GreaterThan(lot.Remaining) {
+			return fmt.Errorf("transaction %s selects %s from lot %s, which has %s remaining", p.TransactionId, qty, lot.Id, lot.Remaining)
+		}
+		b.consume(p, lot, qty, price, priced)
+		remaining = remaining.Sub(qty)
+		sel.Quantity = sel.Quantity.Sub(qty)
+		if !sel.Quantity.IsPositive() {
+			b.selections[p.TransactionId] = b.selections[p.TransactionId][1:]
+		}
+	}
+
+	for remaining.IsPositive() {
+		lot := b.next(h)
+		if lot == nil {
+			b.record(p, nil, remaining, price, priced)
+			return nil
+		}
+		qty := decimal.Min(lot.Remaining, remaining)
+		b.consume(p, lot, qty, price, priced)
+		remaining = remaining.Sub(qty)
+	}
+	return nil
+}
+
+// next returns the lot to consume by the book's method, dropping exhausted lots.
+func (b *bookBuilder) next(h holding) *models.TaxLot {
+	lots := b.open[h]
+	for len(lots) > 0 {
+		i := 0
+		if b.book.Method == MethodLIFO {
+			i = len(lots) - 1
+		}
+		if lots[i].Remaining.IsPositive() {
+			b.open[h] = lots
+			return lots[i]
+		}
+		if i == 0 {
+			lots = lots[1:]
+		} else {
+			lots = lots[:i]
+		}
+	}
+	b.open[h] = lots
+	return nil
+}
+
+func (b *bookBuilder) consume(p store.Posting, lot *models.TaxLot, qty, price decimal.Decimal, priced bool) {
+	lot.Remaining = lot.Remaining.Sub(qty)
+	b.record(p, lot, qty, price, priced)
+}
+
+func (b *bookBuilder) record(p store.Posting, lot *models.TaxLot, qty, price decimal.Decimal, priced bool) {
+	d := models.LotDisposal{
+		UserId:        userId(p),
+		Asset:         p.Asset,
+		TransactionId: p.TransactionId,
+		DisposedAt:    p.EffectiveAt,
+		Quantity:      qty,
+		CostBasis:     decimal.Zero,
+		Proceeds:      qty.Mul(price).Round(2),
+		Term:          TermShort,
+		Priced:        priced,
+	}
+	if lot == nil {
+		d.Uncovered = true
+		d.AcquiredAt = p.EffectiveAt
+	} else {
+		d.LotId = lot.Id
+		d.AcquiredAt = lot.AcquiredAt
+		d.CostBasis = qty.Mul(lot.UnitCost).Round(2)
+		d.Priced = priced && lot.Priced
+		if p.EffectiveAt.After(lot.AcquiredAt.AddDate(1, 0, 0)) {
+			d.Term = TermLong
+		}
+	}
+	d.Gain = d.Proceeds.Sub(d.CostBasis)
+	b.book.Disposals = append(b.book.Disposals, d)
+}
+
+// How reversals name the transaction they undo: a Formance native revert carries
+// the reverted transaction id, and a compensating reversal is booked as
+// "{withdrawal ref}-reversal" (the platform round trip of a failed wallet withdrawal
+// as "-failed-reversal").
+const revertsMetadataKey = "com.formance.spec/state/reverts"
+
+var reversalSuffixes = []string{"-failed-reversal", "-reversal"}
+
+type reversalKey struct {
+	user, asset, ref string
+}
+
+// reversalOf returns the reference of the withdrawal a user debit reverses.
+func reversalOf(p store.Posting) (string, bool) {
+	if p.Direction != store.DirectionDebit {
+		return "", false
+	}
+	if id := p.Metadata[revertsMetadataKey]; id != "" {
+		return "tx:" + id, true
+	}
+	ref := p.Metadata["reversal_ref"]
+	if ref == "" {
+		ref = p.ExternalTxId
+	}
+	for _, suffix := range reversalSuffixes {
+		if original, ok := strings.CutSuffix(ref, suffix); ok && original != "" {
+			return "ref:" + original, true
+		}
+	}
+	return "", false
+}
+
+// withdrawalRefs returns every reference a reversal may use for a user credit.
+func withdrawalRefs(p store.Posting) []string {
+	refs := []string{"tx:" + p.TransactionId}
+	if p.ExternalTxId != "" {
+		refs = append(refs, "ref:"+p.ExternalTxId)
+	}
+	if ref := p.Metadata["withdrawal_ref"]; ref != "" && ref != p.ExternalTxId {
+		refs = append(refs, "ref:"+ref)
+	}
+	return refs
+}
+
+// dropReversed removes each reversed withdrawal together with its reversal. A
+// reversal whose withdrawal is not found is kept as an acquisition.
+func dropReversed(postings []store.Posting) []store.Posting {
+	reversals := make(map[reversalKey]bool)
+	for _, p := range postings {
+		if ref, ok := reversalOf(p); ok {
+			reversals[reversalKey{userId(p), p.Asset, ref}] = false
+		}
+	}
+	if len(reversals) == 0 {
+		return postings
+	}
+
+	drop := make(map[int]bool)
+	for i, p := range postings {
+		if p.Direction != store.DirectionCredit {
+			continue
+		}
+		for _, ref := range withdrawalRefs(p) {
+			key := reversalKey{userId(p), p.Asset, ref}
+			if _, ok := reversals[key]; ok {
+				reversals[key] = true
+				drop[i] = true
+				break
+			}
+		}
+	}
+
+	kept := make([]store.Posting, 0, len(postings)-len(drop))
+	for i, p := range postings {
+		if drop[i] {
+			continue
+		}
+		if ref, ok := reversalOf(p); ok && reversals[reversalKey{userId(p), p.Asset, ref}] {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
@@ -0,0 +1,236 @@
+package costbasis
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
+	"prime-send-receive-go/internal/store"
+	"prime-send-receive-go/internal/testutil"
+
+	"github.com/shopspring/decimal"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t.Add(12 * time.Hour)
+}
+
+// move returns the user-side posting of a deposit (in) or withdrawal (out)
+func move(txId, date, user, direction, amount string) store.Posting {
+	return store.Posting{
+		TransactionId: txId,
+		EffectiveAt:   day(date),
+		Account:       "users:" + user,
+		Counterparty:  "prime:wallets:w1",
+		Asset:         "BTC",
+		Amount:        testutil.Dec(amount),
+		Direction:     direction,
+	}
+}
+
+func testPrices(t *testing.T) pricing.PriceSource {
+	t.Helper()
+	src, err := pricing.NewHistoricalSource(map[string]map[string]decimal.Decimal{
+		"BTC": {
+			"2023-01-10": testutil.Dec("20000"),
+			"2024-02-01": testutil.Dec("40000"),
+			"2024-03-01": testutil.Dec("60000"),
+			"2024-06-01": testutil.Dec("70000"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewHistoricalSource: %v", err)
+	}
+	return src
+}
+
+// Postings are deliberately out of time order, as a Formance export by id can be
+func testStore() *testutil.Ledger {
+	return &testutil.Ledger{Postings: []store.Posting{
+		move("4", "2024-06-01", "u1", store.DirectionCredit, "1.5"),
+		move("1", "2023-01-10", "u1", store.DirectionDebit, "1"),
+		move("2", "2024-02-01", "u1", store.DirectionDebit, "1"),
+		move("3", "2024-03-01", "u2", store.DirectionDebit, "2"),
+		{Account: "prime:wallets:w1", TransactionId: "1", EffectiveAt: day("2023-01-10"), Asset: "BTC", Amount: testutil.Dec("1"), Direction: store.DirectionCredit},
+	}}
+}
+
+func TestBuild_Methods(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		opts       Options
+		wantCost   string
+		wantLong   string
+		wantRemain map[string]string
+	}{
+		// Sells all of the 2023 lot (long term) and half of the 2024 lot
+		{"fifo", Options{Method: MethodFIFO}, "40000", "50000", map[string]string{"1:0": "0", "2:0": "0.5"}},
+		// Sells all of the 2024 lot and half of the 2023 lot
+		{"lifo", Options{Method: MethodLIFO}, "50000", "25000", map[string]string{"1:0": "0.5", "2:0": "0"}},
+		// Picks 1 from the 2024 lot, the remaining 0.5 falls back to FIFO
+		{"specific-id", Options{Method: MethodSpecificID, Selections: map[string][]Selection{"4": {{LotId: "2:0", Quantity: testutil.Dec("1")}}}},
+			"50000", "25000", map[string]string{"1:0": "0.5", "2:0": "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book, err := Build(ctx, testStore(), testPrices(t), tt.opts)
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			report := book.RealizedGains(2024, "u1")
+			if len(report.Totals) != 1 {
+				t.Fatalf("expected 1 total, got %d", len(report.Totals))
+			}
+			total := report.Totals[0]
+			if !total.Proceeds.Equal(testutil.Dec("105000")) || !total.CostBasis.Equal(testutil.Dec(tt.wantCost)) {
+				t.Errorf("proceeds %s cost %s, want 105000 and %s", total.Proceeds, total.CostBasis, tt.wantCost)
+			}
+			if !total.LongTermGain.Equal(testutil.Dec(tt.wantLong)) {
+				t.Errorf("long-term gain %s, want %s", total.LongTermGain, tt.wantLong)
+			}
+			for _, lot := range book.Lots {
+				if want, ok := tt.wantRemain[lot.Id]; ok && !lot.Remaining.Equal(testutil.Dec(want)) {
+					t.Errorf("lot %s remaining %s, want %s", lot.Id, lot.Remaining, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuild_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	first, err := Build(ctx, testStore(), testPrices(t), Options{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	// Reversed export order must give the same book
+	reversed := testStore()
+	for i, j := 0, len(reversed.Postings)-1; i < j; i, j = i+1, j-1 {
+		reversed.Postings[i], reversed.Postings[j] = reversed.Postings[j], reversed.Postings[i]
+	}
+	second, err := Build(ctx, reversed, testPrices(t), Options{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(first.Disposals) != len(second.Disposals) {
+		t.Fatalf("disposal counts differ: %d vs %d", len(first.Disposals), len(second.Disposals))
+	}
+	for i := range first.Disposals {
+		a, b := first.Disposals[i], second.Disposals[i]
+		if a.LotId != b.LotId || !a.Quantity.Equal(b.Quantity) || !a.Gain.Equal(b.Gain) {
+			t.Errorf("disposal %d differs: %+v vs %+v", i, a, b)
+		}
+	}
+}
+
+func TestBuild_UncoveredAndUnpriced(t *testing.T) {
+	db := &testutil.Ledger{Postings: []store.Posting{
+		move("1", "2022-12-01", "u1", store.DirectionDebit, "1"), // before the first close
+		move("2", "2024-03-01", "u1", store.DirectionCredit, "3"),
+	}}
+	book, err := Build(context.Background(), db, testPrices(t), Options{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(book.Disposals) != 2 {
+		t.Fatalf("expected 2 disposals, got %d", len(book.Disposals))
+	}
+	if book.Disposals[0].Priced || !book.Disposals[0].CostBasis.IsZero() {
+		t.Errorf("expected unpriced lot to dispose with zero cost: %+v", book.Disposals[0])
+	}
+	if !book.Disposals[1].Uncovered || !book.Disposals[1].Quantity.Equal(testutil.Dec("2")) {
+		t.Errorf("expected 2 BTC uncovered: %+v", book.Disposals[1])
+	}
+}
+
+func TestBuild_InvalidSelection(t *testing.T) {
+	opts := Options{Method: MethodSpecificID, Selections: map[string][]Selection{"4": {{LotId: "3:0", Quantity: testutil.Dec("1")}}}}
+	if _, err := Build(context.Background(), testStore(), testPrices(t), opts); err == nil {
+		t.Error("expected selecting another user's lot to fail")
+	}
+}
+
+func TestLoadSelections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "selections.csv")
+	content := "disposal_tx_id,lot_id,quantity\n4,2:0,1\n4,1:0,0.5\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	selections, err := LoadSelections(path)
+	if err != nil {
+		t.Fatalf("LoadSelections: %v", err)
+	}
+	if got := selections["4"]; len(got) != 2 || got[0].LotId != "2:0" || !got[1].Quantity.Equal(testutil.Dec("0.5")) {
+		t.Errorf("unexpected selections: %+v", got)
+	}
+}
+
+// checkReversedWithdrawal expects the reversed 2024-02-01 withdrawal to leave the 2023
+// lot untouched, so the real 0.5 BTC sale in June is long term against it
+func checkReversedWithdrawal(t *testing.T, book *Book) {
+	t.Helper()
+	if len(book.Lots) != 1 || !book.Lots[0].AcquiredAt.Equal(day("2023-01-10")) {
+		t.Fatalf("Expected only the 2023 lot, got %+v", book.Lots)
+	}
+	if len(book.Disposals) != 1 {
+		t.Fatalf("Expected only the June sale, got %+v", book.Disposals)
+	}
+	d := book.Disposals[0]
+	if d.Term != TermLong || !d.CostBasis.Equal(testutil.Dec("10000")) || !d.Gain.Equal(testutil.Dec("25000")) {
+		t.Errorf("Expected a long-term 25000 gain on 10000 cost, got %+v", d)
+	}
+	if !book.Lots[0].Remaining.Equal(testutil.Dec("0.5")) {
+		t.Errorf("Expected 0.5 left in the lot, got %s", book.Lots[0].Remaining)
+	}
+}
+
+func TestBuild_RevertedWithdrawal(t *testing.T) {
+	// A Formance native revert undoes the withdrawal's transaction at its effective date
+	revert := move("3", "2024-02-01", "u1", store.DirectionDebit, "1")
+	revert.Metadata = map[string]string{revertsMetadataKey: "2"}
+	db := &testutil.Ledger{Postings: []store.Posting{
+		move("1", "2023-01-10", "u1", store.DirectionDebit, "1"),
+		move("2", "2024-02-01", "u1", store.DirectionCredit, "1"),
+		revert,
+		move("4", "2024-06-01", "u1", store.DirectionCredit, "0.5"),
+	}}
+	book, err := Build(context.Background(), db, testPrices(t), Options{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	checkReversedWithdrawal(t, book)
+}
+
+func TestBuild_ReversedWithdrawalOnSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewSQLiteStore(t)
+	testutil.Deposit(t, db, "u1", "BTC", testutil.Dec("1"), "dep-1", day("2023-01-10"))
+	at := func(date string) context.Context {
+		return models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{TransactionTime: day(date)})
+	}
+	if err := db.ProcessWithdrawal(at("2024-02-01"), "u1", "BTC", testutil.Dec("1"), "wd-1"); err != nil {
+		t.Fatalf("ProcessWithdrawal: %v", err)
+	}
+	// cmd/withdrawal rolls back a withdrawal Prime rejected with a compensating credit
+	if err := db.ReverseWithdrawal(at("2024-03-01"), "u1", "BTC", testutil.Dec("1"), "wd-1"); err != nil {
+		t.Fatalf("ReverseWithdrawal: %v", err)
+	}
+	if err := db.ProcessWithdrawal(at("2024-06-01"), "u1", "BTC", testutil.Dec("0.5"), "wd-2"); err != nil {
+		t.Fatalf("ProcessWithdrawal: %v", err)
+	}
+
+	book, err := Build(ctx, db, testPrices(t), Options{UserId: "u1"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	checkReversedWithdrawal(t, book)
+}
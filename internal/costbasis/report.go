@@ -0,0 +1,120 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package costbasis
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// YearEnd is the history cutoff for a tax year: midnight UTC on January 1st of the next year.
+func YearEnd(year int) time.Time {
+	return time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// RealizedGains returns the disposals in a UTC calendar year, optionally for one user,
+// with totals per user and asset.
+func (b *Book) RealizedGains(year int, userId string) *models.RealizedGainsReport {
+	report := &models.RealizedGainsReport{Year: year, Method: b.Method}
+
+	type key struct{ user, asset string }
+	totals := make(map[key]*models.RealizedGainsTotal)
+	var keys []key
+	for _, d := range b.Disposals {
+		if d.DisposedAt.UTC().Year() != year || (userId != "" && d.UserId != userId) {
+			continue
+		}
+		report.Disposals = append(report.Disposals, d)
+
+		k := key{d.UserId, d.Asset}
+		t, ok := totals[k]
+		if !ok {
+			t = &models.RealizedGainsTotal{UserId: d.UserId, Asset: d.Asset}
+			totals[k] = t
+			keys = append(keys, k)
+		}
+		t.Quantity = t.Quantity.Add(d.Quantity)
+		t.CostBasis = t.CostBasis.Add(d.CostBasis)
+		t.Proceeds = t.Proceeds.Add(d.Proceeds)
+		t.Gain = t.Gain.Add(d.Gain)
+		if d.Term == TermLong {
+			t.LongTermGain = t.LongTermGain.Add(d.Gain)
+		} else {
+			t.ShortTermGain = t.ShortTermGain.Add(d.Gain)
+		}
+		if !d.Priced {
+			t.UnpricedItems++
+		}
+		if d.Uncovered {
+			t.UncoveredItems++
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].user != keys[j].user {
+			return keys[i].user < keys[j].user
+		}
+		return keys[i].asset < keys[j].asset
+	})
+	for _, k := range keys {
+		report.Totals = append(report.Totals, *totals[k])
+	}
+	return report
+}
+
+// LoadSelections reads specific-ID lot selections from a CSV file of
+// disposal_tx_id,lot_id,quantity rows with an optional header.
+func LoadSelections(path string) (map[string][]Selection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	selections := make(map[string][]Selection)
+	for i, row := range rows {
+		qty, err := decimal.NewFromString(strings.TrimSpace(row[2]))
+		if err != nil {
+			if i == 0 {
+				continue // header
+			}
+			return nil, fmt.Errorf("%s line %d: invalid quantity %q", path, i+1, row[2])
+		}
+		if !qty.IsPositive() {
+			return nil, fmt.Errorf("%s line %d: quantity must be positive", path, i+1)
+		}
+		txId := strings.TrimSpace(row[0])
+		selections[txId] = append(selections[txId], Selection{LotId: strings.TrimSpace(row[1]), Quantity: qty})
+	}
+	return selections, nil
+}
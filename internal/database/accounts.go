@@ -0,0 +1,120 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"strings"
+)
+
+// Chart of accounts. Paths mirror the Formance backend so exports and reports
+// from either backend line up; SQLite has a single platform user, so the
+// portfolio segment is omitted. Every journal posting moves an amount from one
+// account to another: the receiving account is debited, the sending account credited.
+const (
+	accountUsersPrefix        = "users:"
+	accountWalletsPrefix      = "prime:wallets:"
+	accountDepositsPending    = "prime:deposits:pending"
+	accountWithdrawalsPending = "prime:withdrawals:pending"
+	accountConversions        = "prime:conversions"
+	accountFeesPrefix         = "fees:"
+	// accountRewardsDistribution clears reward distributions: the platform leg pays
+	// into it and each user leg pays out of it, so it nets to zero per distribution
+	accountRewardsDistribution = "prime:rewards:distribution"
+	// accountHoldsCaptured receives captured holds. Nothing here clears it: its balance
+	// is owed to the parties the holds were placed for, who are paid outside the ledger
+	accountHoldsCaptured = "prime:holds:captured"
+	// accountClosures clears balances swept from a closed user to another user
+	accountClosures = "prime:closures"
+
+	// unassignedWallet stands in for the Prime wallet when the write did not carry one
+	unassignedWallet = "unassigned"
+)
+
+// Account types stored in journal_entries.account_type
+const (
+	accountTypeUser               = "user"
+	accountTypeWallet             = "wallet"
+	accountTypeDepositsPending    = "deposits_pending"
+	accountTypeWithdrawalsPending = "withdrawals_pending"
+	accountTypeConversion         = "conversion"
+	accountTypeFees               = "fees"
+	accountTypeRewards            = "rewards"
+	accountTypeHolds              = "holds"
+	accountTypeClosures           = "closures"
+	accountTypeOther              = "other"
+)
+
+func userAccount(userId string) string {
+	return accountUsersPrefix + userId
+}
+
+func feeAccount(kind string) string {
+	return accountFeesPrefix + kind
+}
+
+func walletAccount(walletId string) string {
+	if walletId == "" {
+		walletId = unassignedWallet
+	}
+	return accountWalletsPrefix + walletId
+}
+
+// accountType classifies an account path for journal_entries.account_type.
+func accountType(account string) string {
+	switch {
+	case strings.HasPrefix(account, accountUsersPrefix):
+		return accountTypeUser
+	case strings.HasPrefix(account, accountWalletsPrefix):
+		return accountTypeWallet
+	case account == accountDepositsPending:
+		return accountTypeDepositsPending
+	case account == accountWithdrawalsPending:
+		return accountTypeWithdrawalsPending
+	case account == accountConversions:
+		return accountTypeConversion
+	case strings.HasPrefix(account, accountFeesPrefix):
+		return accountTypeFees
+	case account == accountRewardsDistribution:
+		return accountTypeRewards
+	case account == accountHoldsCaptured:
+		return accountTypeHolds
+	case account == accountClosures:
+		return accountTypeClosures
+	default:
+		return accountTypeOther
+	}
+}
+
+// defaultCounterparty returns the account on the other side of a user posting when
+// the caller did not name one: conversions clear through the conversions account,
+// reward distributions through the rewards clearing account, fee charges into the
+// fees account of their kind, hold captures into the captured holds account,
+// everything else moves against the Prime wallet recorded in metadata.
+func defaultCounterparty(transactionType string, metadata map[string]string) string {
+	switch transactionType {
+	case "conversion-out", "conversion-in":
+		return accountConversions
+	case transactionTypeRewardDistribution:
+		return accountRewardsDistribution
+	case transactionTypeFee:
+		return feeAccount(metadata["fee_kind"])
+	case transactionTypeHoldCapture:
+		return accountHoldsCaptured
+	default:
+		return walletAccount(metadata["prime_wallet_id"])
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
+	"prime-send-receive-go/internal/addressing"
 	"prime-send-receive-go/internal/models"
 	"prime-send-receive-go/internal/store"
 
@@ -36,14 +38,16 @@ func (s *Service) StoreAddress(ctx context.Context, params StoreAddressParams) (
 		zap.String("user_id", params.UserId),
 		zap.String("asset", params.Asset),
 		zap.String("network", params.Network),
-		zap.String("address", params.Address))
+		zap.String("address", params.Address),
+		zap.String("tag", params.Tag))
 
 	// Generate UUID for the address
 	addressId := uuid.New().String()
+	params.Address = canonicalAddress(params.Address)
 
 	addr := &models.Address{}
-	err := s.db.QueryRowContext(ctx, queryInsertAddress, addressId, params.UserId, params.Asset, params.Network, params.Address, params.WalletId, params.AccountIdentifier).Scan(
-		&addr.Id, &addr.UserId, &addr.Asset, &addr.Network, &addr.Address, &addr.WalletId, &addr.AccountIdentifier, &addr.CreatedAt,
+	err := s.db.QueryRowContext(ctx, queryInsertAddress, addressId, params.UserId, params.Asset, params.Network, params.Address, params.WalletId, params.AccountIdentifier, params.Tag).Scan(
+		&addr.Id, &addr.UserId, &addr.Asset, &addr.Network, &addr.Address, &addr.WalletId, &addr.AccountIdentifier, &addr.Tag, &addr.CreatedAt,
 	)
 	if err != nil {
 		zap.L().Error("Failed to insert address",
@@ -81,7 +85,7 @@ func (s *Service) GetAddresses(ctx context.Context, userId string, asset string,
 	var addresses []models.Address
 	for rows.Next() {
 		var addr models.Address
-		err := rows.Scan(&addr.Id, &addr.UserId, &addr.Asset, &addr.Network, &addr.Address, &addr.WalletId, &addr.AccountIdentifier, &addr.CreatedAt)
+		err := rows.Scan(&addr.Id, &addr.UserId, &addr.Asset, &addr.Network, &addr.Address, &addr.WalletId, &addr.AccountIdentifier, &addr.Tag, &addr.CreatedAt)
 		if err != nil {
 			zap.L().Error("Failed to scan address row", zap.Error(err))
 			return nil, fmt.Errorf("unable to scan address row: %w", err)
@@ -122,7 +126,7 @@ func (s *Service) GetAllUserAddresses(ctx context.Context, userId string) ([]mod
 	var addresses []models.Address
 	for rows.Next() {
 		var addr models.Address
-		err := rows.Scan(&addr.Id, &addr.UserId, &addr.Asset, &addr.Network, &addr.Address, &addr.WalletId, &addr.AccountIdentifier, &addr.CreatedAt)
+		err := rows.Scan(&addr.Id, &addr.UserId, &addr.Asset, &addr.Network, &addr.Address, &addr.WalletId, &addr.AccountIdentifier, &addr.Tag, &addr.CreatedAt)
 		if err != nil {
 			zap.L().Error("Failed to scan address row", zap.Error(err))
 			return nil, fmt.Errorf("unable to scan address row: %w", err)
@@ -142,18 +146,18 @@ func (s *Service) GetAllUserAddresses(ctx context.Context, userId string) ([]mod
 	return addresses, nil
 }
 
-func (s *Service) FindUserByAddress(ctx context.Context, address string) (*models.User, *models.Address, error) {
-	zap.L().Debug("Finding user by address", zap.String("address", address))
+func (s *Service) FindUserByAddress(ctx context.Context, address, tag string) (*models.User, *models.Address, error) {
+	zap.L().Debug("Finding user by address", zap.String("address", address), zap.String("tag", tag))
 
 	var user models.User
 	var addr models.Address
-	err := s.db.QueryRowContext(ctx, queryFindUserByAddress, address).Scan(
+	err := s.db.QueryRowContext(ctx, queryFindUserByAddress, canonicalAddress(address), tag).Scan(
 		&user.Id, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt,
-		&addr.Id, &addr.UserId, &addr.Asset, &addr.Network, &addr.Address, &addr.WalletId, &addr.AccountIdentifier, &addr.CreatedAt,
+		&addr.Id, &addr.UserId, &addr.Asset, &addr.Network, &addr.Address, &addr.WalletId, &addr.AccountIdentifier, &addr.Tag, &addr.CreatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		zap.L().Debug("No user found for address", zap.String("address", address))
+		zap.L().Debug("No user found for address", zap.String("address", address), zap.String("tag", tag))
 		return nil, nil, nil
 	}
 
@@ -168,3 +172,91 @@ func (s *Service) FindUserByAddress(ctx context.Context, address string) (*model
 		zap.String("user_name", user.Name))
 	return &user, &addr, nil
 }
+
+// migrationNormalizeAddresses names the one-off address rewrite in schema_migrations
+const migrationNormalizeAddresses = "normalize_addresses"
+
+// canonicalAddress is the form addresses are stored and looked up in, so they can be
+// compared exactly: addressing.NormalizeAny's canonical form, which matches
+// addressing.Normalize for every valid address, or the trimmed input when it does not
+// parse. Validating addresses is left to the callers.
+func canonicalAddress(address string) string {
+	if normalized, err := addressing.NormalizeAny(address); err == nil {
+		return normalized
+	}
+	return strings.TrimSpace(address)
+}
+
+// normalizeStoredAddresses rewrites deposit, withdrawal and allowlisted addresses
+// stored before they were normalized on the way in, which were matched
+// case-insensitively. It runs once, recorded in schema_migrations.
+func (s *Service) normalizeStoredAddresses() error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin address normalization: %w", err)
+	}
+	defer tx.Rollback()
+
+	applied, err := migrationApplied(ctx, tx, migrationNormalizeAddresses)
+	if err != nil || applied {
+		return err
+	}
+
+	updated := 0
+	for _, q := range []struct{ get, update string }{
+		{queryGetStoredAddresses, queryUpdateAddress},
+		{queryGetAllowlistAddresses, queryUpdateAllowlistAddress},
+	} {
+		n, err := normalizeAddressColumn(ctx, tx, q.get, q.update)
+		if err != nil {
+			return err
+		}
+		updated += n
+	}
+
+	if err := recordMigration(ctx, tx, migrationNormalizeAddresses); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address normalization: %w", err)
+	}
+	if updated > 0 {
+		zap.L().Info("Normalized stored addresses", zap.Int("rows", updated))
+	}
+	return nil
+}
+
+// normalizeAddressColumn rewrites each (id, address) row from get whose address is
+// not in canonical form, using update, and returns how many it changed.
+func normalizeAddressColumn(ctx context.Context, tx *sql.Tx, get, update string) (int, error) {
+	rows, err := tx.QueryContext(ctx, get)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stored addresses: %w", err)
+	}
+	changed := make(map[string]string)
+	for rows.Next() {
+		var id, address string
+		if err := rows.Scan(&id, &address); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan stored address: %w", err)
+		}
+		if canonical := canonicalAddress(address); canonical != address {
+			changed[id] = canonical
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("error iterating stored addresses: %w", err)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close stored address rows: %w", err)
+	}
+
+	for id, address := range changed {
+		if _, err := tx.ExecContext(ctx, update, address, id); err != nil {
+			return 0, fmt.Errorf("failed to normalize address %s: %w", id, err)
+		}
+	}
+	return len(changed), nil
+}
@@ -0,0 +1,165 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+const sharedXrpAddress = "rPEPPER7kfTD9w2To4CQk6UCfuHM9c6GDY"
+
+func TestFindUserByAddress_Tags(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, "user2", "Other User", "other@example.com"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	for userId, tag := range map[string]string{"user1": "101", "user2": "202"} {
+		if _, err := service.StoreAddress(ctx, store.StoreAddressParams{
+			UserId: userId, Asset: "XRP", Network: "xrp-mainnet", Address: sharedXrpAddress, WalletId: "w-xrp", AccountIdentifier: tag, Tag: tag,
+		}); err != nil {
+			t.Fatalf("StoreAddress failed: %v", err)
+		}
+	}
+
+	user, addr, err := service.FindUserByAddress(ctx, sharedXrpAddress, "202")
+	if err != nil || user == nil || user.Id != "user2" || addr.Tag != "202" {
+		t.Fatalf("Expected user2 for tag 202, got %+v, %+v, %v", user, addr, err)
+	}
+	for _, tag := range []string{"", "999"} {
+		if user, _, err := service.FindUserByAddress(ctx, sharedXrpAddress, tag); err != nil || user != nil {
+			t.Errorf("Expected no user for tag %q, got %+v, %v", tag, user, err)
+		}
+	}
+	if user, _, err := service.FindUserByAddress(ctx, "0xabc", ""); err != nil || user == nil || user.Id != "user1" {
+		t.Errorf("Expected untagged address to resolve to user1, got %+v, %v", user, err)
+	}
+
+	addresses, err := service.GetAddresses(ctx, "user1", "XRP", "xrp-mainnet")
+	if err != nil || len(addresses) != 1 || addresses[0].Tag != "101" {
+		t.Errorf("Expected user1's XRP address with tag 101, got %+v, %v", addresses, err)
+	}
+}
+
+func TestProcessDeposit_MatchesByTag(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, "user2", "Other User", "other@example.com"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	for userId, tag := range map[string]string{"user1": "101", "user2": "202"} {
+		if _, err := service.StoreAddress(ctx, store.StoreAddressParams{
+			UserId: userId, Asset: "XRP", Network: "xrp-mainnet", Address: sharedXrpAddress, WalletId: "w-xrp", AccountIdentifier: tag, Tag: tag,
+		}); err != nil {
+			t.Fatalf("StoreAddress failed: %v", err)
+		}
+	}
+
+	tagged := models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{DestinationTag: "202"})
+	if err := service.ProcessDeposit(tagged, sharedXrpAddress, "XRP", decimal.NewFromInt(25), "tx-xrp-1"); err != nil {
+		t.Fatalf("ProcessDeposit failed: %v", err)
+	}
+	if balance, _ := service.GetUserBalance(ctx, "user2", "XRP"); !balance.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("Expected user2 XRP balance 25, got %s", balance)
+	}
+	if balance, _ := service.GetUserBalance(ctx, "user1", "XRP"); !balance.IsZero() {
+		t.Errorf("Expected user1 XRP balance 0, got %s", balance)
+	}
+
+	unknown := models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{DestinationTag: "999"})
+	if err := service.ProcessDeposit(unknown, sharedXrpAddress, "XRP", decimal.NewFromInt(5), "tx-xrp-2"); err == nil {
+		t.Error("Expected a deposit with an unknown tag to be rejected")
+	}
+	if err := service.ProcessDeposit(ctx, sharedXrpAddress, "XRP", decimal.NewFromInt(5), "tx-xrp-3"); err == nil {
+		t.Error("Expected an untagged deposit to a shared address to be rejected")
+	}
+}
+
+func TestFindUserByAddress_MatchesNormalizedAddress(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	const evm = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	const sol = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	for network, address := range map[string]string{"base-mainnet": strings.ToLower(evm), "solana-mainnet": sol} {
+		if _, err := service.StoreAddress(ctx, store.StoreAddressParams{
+			UserId: "user1", Asset: "USDC", Network: network, Address: address, WalletId: "w1", AccountIdentifier: "a1",
+		}); err != nil {
+			t.Fatalf("StoreAddress failed: %v", err)
+		}
+	}
+
+	// EVM addresses are stored with their checksum and found in any case
+	for _, address := range []string{evm, strings.ToLower(evm), "0x" + strings.ToUpper(evm[2:])} {
+		if user, addr, err := service.FindUserByAddress(ctx, address, ""); err != nil || user == nil || addr.Address != evm {
+			t.Errorf("Expected %s to resolve to the checksummed address, got %+v, %v", address, addr, err)
+		}
+	}
+	// Base58 addresses are case-sensitive
+	if user, _, err := service.FindUserByAddress(ctx, sol, ""); err != nil || user == nil {
+		t.Errorf("Expected the Solana address to resolve, got %+v, %v", user, err)
+	}
+	if user, _, err := service.FindUserByAddress(ctx, strings.ToLower(sol), ""); err != nil || user != nil {
+		t.Errorf("Expected a case variant of the Solana address not to resolve, got %+v, %v", user, err)
+	}
+}
+
+func TestNormalizeStoredAddresses(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	const evm = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	// Written before addresses were normalized on the way in
+	if _, err := service.db.Exec(`INSERT INTO addresses (id, user_id, asset, network, address, wallet_id, account_identifier)
+		VALUES ('legacy', 'user1', 'ETH', 'ethereum-mainnet', ?, 'w2', 'a2')`, strings.ToLower(evm)); err != nil {
+		t.Fatalf("Failed to insert legacy address: %v", err)
+	}
+	if _, err := service.db.Exec(`INSERT INTO withdrawal_allowlist (id, user_id, address, added_by, added_at, active_at)
+		VALUES ('legacy', 'user1', ?, 'ops', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`, " "+strings.ToLower(evm)); err != nil {
+		t.Fatalf("Failed to insert legacy allowlist entry: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := service.normalizeStoredAddresses(); err != nil {
+			t.Fatalf("normalizeStoredAddresses failed: %v", err)
+		}
+	}
+	if user, _, err := service.FindUserByAddress(ctx, evm, ""); err != nil || user == nil {
+		t.Errorf("Expected the legacy address to resolve after normalizing, got %+v, %v", user, err)
+	}
+	entries, err := service.GetAllowlist(ctx, "user1")
+	if err != nil || len(entries) != 1 || entries[0].Address != evm {
+		t.Errorf("Expected the allowlist entry to be normalized, got %+v, %v", entries, err)
+	}
+	// Invalid test addresses are left as stored
+	if user, _, err := service.FindUserByAddress(ctx, "0xabc", ""); err != nil || user == nil {
+		t.Errorf("Expected 0xabc to still resolve, got %+v, %v", user, err)
+	}
+}
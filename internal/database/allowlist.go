@@ -0,0 +1,189 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AddAllowlistAddress approves a withdrawal destination for an active user. The
+// entry and its audit event are written in one database transaction.
+func (s *Service) AddAllowlistAddress(ctx context.Context, params store.AllowlistParams) (*models.AllowlistEntry, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var one int
+	if err := tx.QueryRowContext(ctx, queryUserIsActive, params.UserId).Scan(&one); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %s", params.UserId)
+		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	now := time.Now().UTC()
+	entry := &models.AllowlistEntry{
+		UserId:           params.UserId,
+		Address:          canonicalAddress(params.Address),
+		Tag:              strings.TrimSpace(params.Tag),
+		Asset:            params.Asset,
+		Network:          params.Network,
+		AddressBookState: params.AddressBookState,
+		AddedBy:          params.Actor,
+		Note:             params.Note,
+		AddedAt:          now,
+		ActiveAt:         now.Add(params.Cooldown),
+	}
+	if _, err := tx.ExecContext(ctx, queryInsertAllowlistEntry, uuid.New().String(), entry.UserId, entry.Address, entry.Tag,
+		entry.Asset, entry.Network, entry.AddressBookState, entry.AddedBy, entry.Note, entry.AddedAt, entry.ActiveAt); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("%w: %s", store.ErrAddressAllowlisted, models.AllowlistDestination(entry.Address, entry.Tag))
+		}
+		return nil, fmt.Errorf("failed to add allowlist entry: %w", err)
+	}
+	if err := insertAllowlistEvent(ctx, tx, params, entry.Address, entry.Tag, models.AllowlistActionAdd, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit allowlist entry: %w", err)
+	}
+
+	zap.L().Info("Withdrawal address allowlisted",
+		zap.String("user_id", entry.UserId),
+		zap.String("address", entry.Address),
+		zap.String("tag", entry.Tag),
+		zap.String("actor", entry.AddedBy),
+		zap.Time("active_at", entry.ActiveAt))
+	return entry, nil
+}
+
+// RemoveAllowlistAddress takes a destination off the user's allowlist. Adding it
+// again later starts a new cooldown.
+func (s *Service) RemoveAllowlistAddress(ctx context.Context, params store.AllowlistParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	address, tag := canonicalAddress(params.Address), strings.TrimSpace(params.Tag)
+	res, err := tx.ExecContext(ctx, queryRemoveAllowlistEntry, now, params.UserId, address, tag)
+	if err != nil {
+		return fmt.Errorf("failed to remove allowlist entry: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check allowlist removal: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("%w: %s", store.ErrAddressNotAllowlisted, models.AllowlistDestination(address, tag))
+	}
+	if err := insertAllowlistEvent(ctx, tx, params, address, tag, models.AllowlistActionRemove, now); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit allowlist removal: %w", err)
+	}
+
+	zap.L().Info("Withdrawal address removed from allowlist",
+		zap.String("user_id", params.UserId),
+		zap.String("address", address),
+		zap.String("tag", tag),
+		zap.String("actor", params.Actor))
+	return nil
+}
+
+func insertAllowlistEvent(ctx context.Context, tx *sql.Tx, params store.AllowlistParams, address, tag, action string, now time.Time) error {
+	if _, err := tx.ExecContext(ctx, queryInsertAllowlistEvent, uuid.New().String(), params.UserId, address, tag,
+		action, params.Actor, params.Note, now); err != nil {
+		return fmt.Errorf("failed to record allowlist event: %w", err)
+	}
+	return nil
+}
+
+// GetAllowlist returns the user's live allowlist entries, oldest first.
+func (s *Service) GetAllowlist(ctx context.Context, userId string) ([]models.AllowlistEntry, error) {
+	rows, err := s.db.QueryContext(ctx, queryGetAllowlist, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allowlist: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			zap.L().Warn("Failed to close rows", zap.Error(err))
+		}
+	}(rows)
+
+	var entries []models.AllowlistEntry
+	for rows.Next() {
+		var e models.AllowlistEntry
+		if err := rows.Scan(&e.UserId, &e.Address, &e.Tag, &e.Asset, &e.Network, &e.AddressBookState, &e.AddedBy, &e.Note,
+			&e.AddedAt, &e.ActiveAt); err != nil {
+			return nil, fmt.Errorf("failed to scan allowlist entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating allowlist: %w", err)
+	}
+	return entries, nil
+}
+
+// GetAllowlistEvents returns the user's allowlist audit log, oldest first.
+func (s *Service) GetAllowlistEvents(ctx context.Context, userId string) ([]models.AllowlistEvent, error) {
+	rows, err := s.db.QueryContext(ctx, queryGetAllowlistEvents, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allowlist events: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			zap.L().Warn("Failed to close rows", zap.Error(err))
+		}
+	}(rows)
+
+	var events []models.AllowlistEvent
+	for rows.Next() {
+		var e models.AllowlistEvent
+		if err := rows.Scan(&e.UserId, &e.Address, &e.Tag, &e.Action, &e.Actor, &e.Note, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan allowlist event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating allowlist events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,244 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/addressing"
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+)
+
+// usdcTo is a USDC withdrawal by user1 on Base to address
+func usdcTo(address string) api.WithdrawalDestination {
+	return api.WithdrawalDestination{UserId: "user1", Asset: "USDC", Network: "base-mainnet", Address: address}
+}
+
+func TestAllowlist_CooldownAndAuditLog(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	ledger := api.NewLedgerService(service)
+
+	if _, err := ledger.CheckWithdrawalDestination(ctx, usdcTo("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"), time.Now()); !errors.Is(err, store.ErrAddressNotAllowlisted) {
+		t.Fatalf("Expected ErrAddressNotAllowlisted before adding, got %v", err)
+	}
+
+	params := store.AllowlistParams{UserId: "user1", Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", Asset: "USDC", Actor: "ops", Note: "ticket 42", Cooldown: time.Hour}
+	entry, err := service.AddAllowlistAddress(ctx, params)
+	if err != nil {
+		t.Fatalf("AddAllowlistAddress failed: %v", err)
+	}
+	if got := entry.ActiveAt.Sub(entry.AddedAt); got != time.Hour {
+		t.Errorf("Expected a one hour cooldown, got %s", got)
+	}
+	if _, err := service.AddAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Actor: "ops"}); !errors.Is(err, store.ErrAddressAllowlisted) {
+		t.Errorf("Expected ErrAddressAllowlisted for a case variant, got %v", err)
+	}
+
+	// Locked during the cooldown, usable after it, matching the normalized address
+	if _, err := ledger.CheckWithdrawalDestination(ctx, usdcTo("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"), entry.AddedAt.Add(time.Minute)); !errors.Is(err, store.ErrAddressCoolingDown) {
+		t.Errorf("Expected ErrAddressCoolingDown during the cooldown, got %v", err)
+	}
+	if _, err := ledger.CheckWithdrawalDestination(ctx, usdcTo("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"), entry.ActiveAt); err != nil {
+		t.Errorf("Expected the address to be usable after the cooldown, got %v", err)
+	}
+
+	if err := service.RemoveAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Actor: "ops"}); err != nil {
+		t.Fatalf("RemoveAllowlistAddress failed: %v", err)
+	}
+	if err := service.RemoveAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Actor: "ops"}); !errors.Is(err, store.ErrAddressNotAllowlisted) {
+		t.Errorf("Expected ErrAddressNotAllowlisted removing twice, got %v", err)
+	}
+	if _, err := ledger.CheckWithdrawalDestination(ctx, usdcTo("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"), entry.ActiveAt); !errors.Is(err, store.ErrAddressNotAllowlisted) {
+		t.Errorf("Expected a removed address to be refused, got %v", err)
+	}
+
+	// Adding it again starts a new cooldown
+	readded, err := service.AddAllowlistAddress(ctx, params)
+	if err != nil {
+		t.Fatalf("Re-adding failed: %v", err)
+	}
+	if !readded.ActiveAt.After(entry.ActiveAt) {
+		t.Errorf("Expected a new cooldown, got active at %s (was %s)", readded.ActiveAt, entry.ActiveAt)
+	}
+
+	entries, err := service.GetAllowlist(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetAllowlist failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Asset != "USDC" || entries[0].AddedBy != "ops" || entries[0].Usable(time.Now()) {
+		t.Errorf("Expected one live entry still cooling down, got %+v", entries)
+	}
+
+	events, err := service.GetAllowlistEvents(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetAllowlistEvents failed: %v", err)
+	}
+	wantActions := []string{models.AllowlistActionAdd, models.AllowlistActionRemove, models.AllowlistActionAdd}
+	if len(events) != len(wantActions) {
+		t.Fatalf("Expected %d events, got %+v", len(wantActions), events)
+	}
+	for i, action := range wantActions {
+		if events[i].Action != action || events[i].Actor != "ops" {
+			t.Errorf("Event %d: expected %s by ops, got %+v", i, action, events[i])
+		}
+	}
+	if events[0].Note != "ticket 42" {
+		t.Errorf("Expected the note in the audit log, got %q", events[0].Note)
+	}
+}
+
+func TestAllowlist_RequiresActiveUser(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := service.AddAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: "0xDEST"}); err == nil {
+		t.Error("Expected an error without an actor")
+	}
+	if _, err := service.AddAllowlistAddress(ctx, store.AllowlistParams{UserId: "missing", Address: "0xDEST", Actor: "ops"}); err == nil {
+		t.Error("Expected an error for an unknown user")
+	}
+	if err := service.DeactivateUser(ctx, "user1"); err != nil {
+		t.Fatalf("DeactivateUser failed: %v", err)
+	}
+	if _, err := service.AddAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: "0xDEST", Actor: "ops"}); err == nil {
+		t.Error("Expected an error for a deactivated user")
+	}
+}
+
+func TestAllowlist_MatchesAssetAndNetwork(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	ledger := api.NewLedgerService(service)
+
+	if _, err := service.AddAllowlistAddress(ctx, store.AllowlistParams{
+		UserId: "user1", Address: "0xdest", Asset: "USDC", Network: "base-mainnet", Actor: "ops",
+	}); err != nil {
+		t.Fatalf("AddAllowlistAddress failed: %v", err)
+	}
+	if _, err := service.AddAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: "0xany", Actor: "ops"}); err != nil {
+		t.Fatalf("AddAllowlistAddress failed: %v", err)
+	}
+	now := time.Now().Add(time.Minute)
+
+	entries, err := service.GetAllowlist(ctx, "user1")
+	if err != nil || len(entries) != 2 || entries[0].Network != "base-mainnet" {
+		t.Fatalf("Expected the network to be stored, got %+v, %v", entries, err)
+	}
+	if _, err := ledger.CheckWithdrawalDestination(ctx, usdcTo("0xdest"), now); err != nil {
+		t.Errorf("Expected USDC on Base to be allowed, got %v", err)
+	}
+	for _, dest := range []api.WithdrawalDestination{
+		{UserId: "user1", Asset: "ETH", Network: "base-mainnet", Address: "0xdest"},
+		{UserId: "user1", Asset: "USDC", Network: "ethereum-mainnet", Address: "0xdest"},
+	} {
+		if _, err := ledger.CheckWithdrawalDestination(ctx, dest, now); !errors.Is(err, store.ErrAddressNotAllowlisted) {
+			t.Errorf("Expected %s on %s to be refused, got %v", dest.Asset, dest.Network, err)
+		}
+	}
+
+	// An entry added without an asset or network covers every withdrawal to it
+	if _, err := ledger.CheckWithdrawalDestination(ctx, api.WithdrawalDestination{
+		UserId: "user1", Asset: "ETH", Network: "ethereum-mainnet", Address: "0xany",
+	}, now); err != nil {
+		t.Errorf("Expected an unscoped entry to be allowed, got %v", err)
+	}
+}
+
+func TestAllowlist_Base58IsCaseSensitive(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	ledger := api.NewLedgerService(service)
+
+	const mint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	if _, err := service.AddAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: mint, Actor: "ops"}); err != nil {
+		t.Fatalf("AddAllowlistAddress failed: %v", err)
+	}
+	if _, err := service.AddAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: strings.ToLower(mint), Actor: "ops"}); err != nil {
+		t.Errorf("Expected a base58 address differing in case to be a separate entry, got %v", err)
+	}
+	if err := service.RemoveAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: strings.ToLower(mint), Actor: "ops"}); err != nil {
+		t.Fatalf("RemoveAllowlistAddress failed: %v", err)
+	}
+	if _, err := ledger.CheckWithdrawalDestination(ctx, usdcTo(mint), time.Now()); err != nil {
+		t.Errorf("Expected the original address to stay allowlisted, got %v", err)
+	}
+	if _, err := ledger.CheckWithdrawalDestination(ctx, usdcTo(strings.ToUpper(mint)), time.Now()); !errors.Is(err, store.ErrAddressNotAllowlisted) {
+		t.Errorf("Expected a case variant to be refused, got %v", err)
+	}
+}
+
+func TestAllowlist_MatchesTag(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	ledger := api.NewLedgerService(service)
+
+	xrp := store.AllowlistParams{UserId: "user1", Address: sharedXrpAddress, Asset: "XRP", Network: "xrp-mainnet", Actor: "ops"}
+	if _, err := ledger.AddAllowlistAddress(ctx, xrp); !errors.Is(err, addressing.ErrTagRequired) {
+		t.Errorf("Expected ErrTagRequired without a tag, got %v", err)
+	}
+	xrp.Tag = "101"
+	if _, err := ledger.AddAllowlistAddress(ctx, xrp); err != nil {
+		t.Fatalf("AddAllowlistAddress failed: %v", err)
+	}
+	if _, err := ledger.AddAllowlistAddress(ctx, xrp); !errors.Is(err, store.ErrAddressAllowlisted) {
+		t.Errorf("Expected ErrAddressAllowlisted for the same tag, got %v", err)
+	}
+	other := xrp
+	other.Tag = "202"
+	if _, err := ledger.AddAllowlistAddress(ctx, other); err != nil {
+		t.Errorf("Expected another tag at the same address to be a separate entry, got %v", err)
+	}
+
+	now := time.Now().Add(time.Minute)
+	withdrawal := api.WithdrawalDestination{UserId: "user1", Asset: "XRP", Network: "xrp-mainnet", Address: sharedXrpAddress, Tag: "101"}
+	if entry, err := ledger.CheckWithdrawalDestination(ctx, withdrawal, now); err != nil || entry.Tag != "101" {
+		t.Errorf("Expected tag 101 to be allowed, got %+v, %v", entry, err)
+	}
+	withdrawal.Tag = "303"
+	if _, err := ledger.CheckWithdrawalDestination(ctx, withdrawal, now); !errors.Is(err, store.ErrAddressNotAllowlisted) {
+		t.Errorf("Expected another customer's tag to be refused, got %v", err)
+	}
+
+	if err := ledger.RemoveAllowlistAddress(ctx, store.AllowlistParams{UserId: "user1", Address: sharedXrpAddress, Tag: "101", Actor: "ops"}); err != nil {
+		t.Fatalf("RemoveAllowlistAddress failed: %v", err)
+	}
+	withdrawal.Tag = "101"
+	if _, err := ledger.CheckWithdrawalDestination(ctx, withdrawal, now); !errors.Is(err, store.ErrAddressNotAllowlisted) {
+		t.Errorf("Expected the removed tag to be refused, got %v", err)
+	}
+	withdrawal.Tag = "202"
+	if _, err := ledger.CheckWithdrawalDestination(ctx, withdrawal, now); err != nil {
+		t.Errorf("Expected tag 202 to stay allowlisted, got %v", err)
+	}
+
+	events, err := service.GetAllowlistEvents(ctx, "user1")
+	if err != nil || len(events) != 3 || events[2].Tag != "101" || events[2].Action != models.AllowlistActionRemove {
+		t.Errorf("Expected the tag in the audit log, got %+v, %v", events, err)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
@@ -51,6 +52,43 @@ func (s *SubledgerService) GetBalance(ctx context.Context, userId, asset string)
 	return balance, nil
 }
 
+// GetBalanceAt returns the balance for user/asset as of a point in time.
+// Sums the confirmed transactions whose effective time is at or before at, so
+// a backfilled transaction counts at its effective time rather than when it
+// was inserted (balance_after is a running total in insertion order).
+func (s *SubledgerService) GetBalanceAt(ctx context.Context, userId, asset string, at time.Time) (decimal.Decimal, error) {
+	zap.L().Debug("Getting balance at point in time",
+		zap.String("user_id", userId),
+		zap.String("asset_network", asset),
+		zap.Time("at", at))
+
+	rows, err := s.db.QueryContext(ctx, queryGetAmountsAt, userId, asset, at.UTC())
+	if err != nil {
+		zap.L().Error("Failed to get balance at point in time", zap.String("user_id", userId), zap.String("asset_network", asset), zap.Error(err))
+		return decimal.Zero, fmt.Errorf("failed to get balance at %s: %w", at.Format(time.RFC3339), err)
+	}
+	defer rows.Close()
+
+	balance := decimal.Zero
+	for rows.Next() {
+		var amountStr string
+		if err := rows.Scan(&amountStr); err != nil {
+			return decimal.Zero, fmt.Errorf("failed to scan amount: %w", err)
+		}
+		amount, err := decimal.NewFromString(amountStr)
+		if err != nil {
+			zap.L().Error("Failed to parse amount", zap.String("amount_str", amountStr), zap.Error(err))
+			return decimal.Zero, fmt.Errorf("failed to parse amount: %w", err)
+		}
+		balance = balance.Add(amount)
+	}
+	if err := rows.Err(); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get balance at %s: %w", at.Format(time.RFC3339), err)
+	}
+
+	return balance, nil
+}
+
 // GetAllBalances returns all non-zero balances for a user
 func (s *SubledgerService) GetAllBalances(ctx context.Context, userId string) ([]models.AccountBalance, error) {
 	zap.L().Debug("Getting all balances", zap.String("user_id", userId))
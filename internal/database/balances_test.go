@@ -20,6 +20,7 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/shopspring/decimal"
@@ -105,13 +106,13 @@ func TestGetUserBalance_WithTransactions(t *testing.T) {
 	asset := "BTC"
 
 	depositAmount := decimal.NewFromFloat(2.0)
-	_, err := service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{userId, asset, "deposit", depositAmount, "tx1", "addr1", ""})
+	_, err := service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "deposit", Amount: depositAmount, ExternalTxId: "tx1", Address: "addr1"})
 	if err != nil {
 		t.Fatalf("Failed to create deposit: %v", err)
 	}
 
 	withdrawalAmount := decimal.NewFromFloat(-0.5)
-	_, err = service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{userId, asset, "withdrawal", withdrawalAmount, "tx2", "", ""})
+	_, err = service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "withdrawal", Amount: withdrawalAmount, ExternalTxId: "tx2"})
 	if err != nil {
 		t.Fatalf("Failed to create withdrawal: %v", err)
 	}
@@ -135,13 +136,13 @@ func TestGetAllUserBalances(t *testing.T) {
 	userId := "user1"
 
 	btcAmount := decimal.NewFromFloat(1.0)
-	_, err := service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{userId, "BTC", "deposit", btcAmount, "tx1", "", ""})
+	_, err := service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: "BTC", TransactionType: "deposit", Amount: btcAmount, ExternalTxId: "tx1"})
 	if err != nil {
 		t.Fatalf("Failed to create BTC deposit: %v", err)
 	}
 
 	ethAmount := decimal.NewFromFloat(10.0)
-	_, err = service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{userId, "ETH", "deposit", ethAmount, "tx2", "", ""})
+	_, err = service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: "ETH", TransactionType: "deposit", Amount: ethAmount, ExternalTxId: "tx2"})
 	if err != nil {
 		t.Fatalf("Failed to create ETH deposit: %v", err)
 	}
@@ -169,3 +170,77 @@ func TestGetAllUserBalances(t *testing.T) {
 		t.Errorf("Expected ETH balance %s, got %s", expectedETH.String(), found["ETH"].String())
 	}
 }
+
+func TestGetUserBalanceAt(t *testing.T) {
+	service, cleanup := setupBalanceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	userId := "user1"
+	asset := "BTC"
+
+	day1 := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	_, err := service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "deposit", Amount: decimal.NewFromFloat(2.0), ExternalTxId: "tx1", TransactionTime: day1})
+	if err != nil {
+		t.Fatalf("Failed to create deposit: %v", err)
+	}
+	// Effective time in a non-UTC zone must still compare correctly.
+	_, err = service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "withdrawal", Amount: decimal.NewFromFloat(-0.5), ExternalTxId: "tx2", TransactionTime: day2.In(time.FixedZone("EST", -5*3600))})
+	if err != nil {
+		t.Fatalf("Failed to create withdrawal: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected decimal.Decimal
+	}{
+		{"before first transaction", day1.Add(-time.Second), decimal.Zero},
+		{"exactly at first transaction", day1, decimal.NewFromFloat(2.0)},
+		{"between transactions", day1.Add(12 * time.Hour), decimal.NewFromFloat(2.0)},
+		{"after last transaction", day2.Add(time.Hour), decimal.NewFromFloat(1.5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balance, err := service.GetUserBalanceAt(ctx, userId, asset, tt.at)
+			if err != nil {
+				t.Fatalf("GetUserBalanceAt failed: %v", err)
+			}
+			if !balance.Equal(tt.expected) {
+				t.Errorf("Expected balance %s, got %s", tt.expected.String(), balance.String())
+			}
+		})
+	}
+}
+
+func TestGetUserBalanceAt_Backfill(t *testing.T) {
+	service, cleanup := setupBalanceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	t1 := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	t2 := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	// The later transaction is inserted first, as a recovery scan would do
+	_, err := service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{UserId: "user1", Asset: "BTC", TransactionType: "deposit", Amount: decimal.NewFromInt(5), ExternalTxId: "tx-late", TransactionTime: t2})
+	if err != nil {
+		t.Fatalf("Failed to create deposit: %v", err)
+	}
+	_, err = service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{UserId: "user1", Asset: "BTC", TransactionType: "deposit", Amount: decimal.NewFromInt(3), ExternalTxId: "tx-early", TransactionTime: t1})
+	if err != nil {
+		t.Fatalf("Failed to create deposit: %v", err)
+	}
+
+	for at, expected := range map[time.Time]int64{t1: 3, t2: 8} {
+		balance, err := service.GetUserBalanceAt(ctx, "user1", "BTC", at)
+		if err != nil {
+			t.Fatalf("GetUserBalanceAt failed: %v", err)
+		}
+		if !balance.Equal(decimal.NewFromInt(expected)) {
+			t.Errorf("Expected balance %d at %s, got %s", expected, at, balance)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// FreezeUser blocks withdrawals for the user and sends their deposits to quarantine.
+func (s *Service) FreezeUser(ctx context.Context, params store.FreezeParams) (*models.FreezeStatus, error) {
+	return s.setFrozen(ctx, params, true)
+}
+
+// UnfreezeUser lifts a freeze. Deposits already quarantined stay in quarantine.
+func (s *Service) UnfreezeUser(ctx context.Context, params store.FreezeParams) (*models.FreezeStatus, error) {
+	return s.setFrozen(ctx, params, false)
+}
+
+// setFrozen updates the user's freeze state and appends to the audit log in one
+// database transaction. Freezing a frozen user, or unfreezing one that is not
+// frozen, is an error and logs nothing.
+func (s *Service) setFrozen(ctx context.Context, params store.FreezeParams, frozen bool) (*models.FreezeStatus, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	action := models.FreezeActionUnfreeze
+	if frozen {
+		action = models.FreezeActionFreeze
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, querySetFreezeStatus, frozen, params.Reason, params.Actor, params.Note, now, now,
+		params.UserId, !frozen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update freeze status: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to check freeze update: %w", err)
+	} else if n == 0 {
+		if _, err := scanFreezeStatus(tx.QueryRowContext(ctx, queryGetFreezeStatus, params.UserId), params.UserId); err != nil {
+			return nil, err
+		}
+		if frozen {
+			return nil, fmt.Errorf("user %s is already frozen", params.UserId)
+		}
+		return nil, fmt.Errorf("user %s is not frozen", params.UserId)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryInsertFreezeEvent, uuid.New().String(), params.UserId, action,
+		params.Reason, params.Actor, params.Note, now); err != nil {
+		return nil, fmt.Errorf("failed to record freeze event: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit freeze status: %w", err)
+	}
+
+	zap.L().Warn("User freeze status changed",
+		zap.String("user_id", params.UserId),
+		zap.String("action", action),
+		zap.String("reason", params.Reason),
+		zap.String("actor", params.Actor))
+	return s.GetFreezeStatus(ctx, params.UserId)
+}
+
+// GetFreezeStatus returns the user's current freeze state.
+func (s *Service) GetFreezeStatus(ctx context.Context, userId string) (*models.FreezeStatus, error) {
+	return scanFreezeStatus(s.db.QueryRowContext(ctx, queryGetFreezeStatus, userId), userId)
+}
+
+func scanFreezeStatus(row rowScanner, userId string) (*models.FreezeStatus, error) {
+	status := models.FreezeStatus{UserId: userId}
+	var updatedAt sql.NullTime
+	err := row.Scan(&status.Frozen, &status.Reason, &status.Actor, &status.Note, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user not found: %s", userId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get freeze status: %w", err)
+	}
+	status.UpdatedAt = updatedAt.Time
+	return &status, nil
+}
+
+// GetFreezeEvents returns the user's freeze audit log, oldest first.
+func (s *Service) GetFreezeEvents(ctx context.Context, userId string) ([]models.FreezeEvent, error) {
+	rows, err := s.db.QueryContext(ctx, queryGetFreezeEvents, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query freeze events: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			zap.L().Warn("Failed to close rows", zap.Error(err))
+		}
+	}(rows)
+
+	var events []models.FreezeEvent
+	for rows.Next() {
+		var e models.FreezeEvent
+		if err := rows.Scan(&e.UserId, &e.Action, &e.Reason, &e.Actor, &e.Note, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan freeze event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating freeze events: %w", err)
+	}
+	return events, nil
+}
+
+// checkNotFrozen returns ErrUserFrozen if the user is frozen.
+func (s *Service) checkNotFrozen(ctx context.Context, userId string) error {
+	status, err := s.GetFreezeStatus(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if status.Frozen {
+		return frozenError(status)
+	}
+	return nil
+}
+
+// freezeStatusTx reads the user's freeze inside tx, so a freeze committed before the
+// write it guards is never missed. Inactive users are read too.
+func freezeStatusTx(ctx context.Context, tx *sql.Tx, userId string) (*models.FreezeStatus, error) {
+	return scanFreezeStatus(tx.QueryRowContext(ctx, queryGetUserFreezeStatus, userId), userId)
+}
+
+// checkNotFrozenTx is checkNotFrozen read through freezeStatusTx.
+func checkNotFrozenTx(ctx context.Context, tx *sql.Tx, userId string) error {
+	status, err := freezeStatusTx(ctx, tx, userId)
+	if err != nil {
+		return err
+	}
+	if status.Frozen {
+		return frozenError(status)
+	}
+	return nil
+}
+
+func frozenError(status *models.FreezeStatus) error {
+	return fmt.Errorf("%w: %s (%s)", store.ErrUserFrozen, status.UserId, status.Reason)
+}
+
+// quarantineDeposit credits a deposit for a frozen user to the quarantine platform
+// user instead, tagged with the user it was meant for.
+func (s *Service) quarantineDeposit(ctx context.Context, user *models.User, addr *models.Address, amount decimal.Decimal, transactionId string, status *models.FreezeStatus) error {
+	_, err := s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
+		UserId:          store.QuarantineUserId(""),
+		Asset:           addr.Asset,
+		TransactionType: "deposit",
+		Amount:          amount,
+		ExternalTxId:    transactionId,
+		Address:         addr.Address,
+		Reference:       fmt.Sprintf("QUARANTINED_DEPOSIT: %s %s for frozen user %s", amount.String(), addr.Asset, user.Id),
+		Metadata: map[string]string{
+			"quarantined_user_id": user.Id,
+			"quarantine_reason":   status.Reason,
+		},
+	}))
+	if err != nil {
+		return fmt.Errorf("error processing quarantined deposit: %w", err)
+	}
+
+	zap.L().Warn("Deposit to frozen user quarantined",
+		zap.String("user_id", user.Id),
+		zap.String("asset", addr.Asset),
+		zap.String("amount", amount.String()),
+		zap.String("freeze_reason", status.Reason),
+		zap.String("external_tx_id", transactionId))
+	return nil
+}
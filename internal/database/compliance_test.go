@@ -0,0 +1,178 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+// setupComplianceTestDB uses the full schema, so freeze columns and the audit table exist.
+func setupComplianceTestDB(t *testing.T) (*Service, func()) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	service := &Service{db: db, subledger: NewSubledgerService(db)}
+	if err := service.initSchema(false); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	if err := service.subledger.InitSchema(); err != nil {
+		t.Fatalf("Failed to create subledger schema: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, "user1", "Test User", "test@example.com"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := service.StoreAddress(ctx, store.StoreAddressParams{
+		UserId: "user1", Asset: "USDC", Network: "base-mainnet", Address: "0xabc", WalletId: "w1", AccountIdentifier: "a1",
+	}); err != nil {
+		t.Fatalf("StoreAddress failed: %v", err)
+	}
+	return service, func() { db.Close() }
+}
+
+func TestFreezeUser_AuditLog(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	freeze := store.FreezeParams{UserId: "user1", Reason: models.FreezeReasonFraud, Actor: "ops@example.com", Note: "case 42"}
+	status, err := service.FreezeUser(ctx, freeze)
+	if err != nil {
+		t.Fatalf("FreezeUser failed: %v", err)
+	}
+	if !status.Frozen || status.Reason != models.FreezeReasonFraud || status.Actor != "ops@example.com" {
+		t.Errorf("Unexpected status after freeze: %+v", status)
+	}
+	if _, err := service.FreezeUser(ctx, freeze); err == nil {
+		t.Error("Expected freezing a frozen user to fail")
+	}
+
+	unfreeze := store.FreezeParams{UserId: "user1", Reason: models.FreezeReasonReviewCleared, Actor: "lead@example.com"}
+	if status, err = service.UnfreezeUser(ctx, unfreeze); err != nil || status.Frozen {
+		t.Fatalf("Expected user to be unfrozen, got %+v, %v", status, err)
+	}
+	if _, err := service.UnfreezeUser(ctx, unfreeze); err == nil {
+		t.Error("Expected unfreezing an unfrozen user to fail")
+	}
+
+	events, err := service.GetFreezeEvents(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetFreezeEvents failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Action != models.FreezeActionFreeze || events[1].Action != models.FreezeActionUnfreeze {
+		t.Fatalf("Expected a freeze then an unfreeze event, got %+v", events)
+	}
+	if events[0].Note != "case 42" || events[1].Actor != "lead@example.com" {
+		t.Errorf("Audit events lost their details: %+v", events)
+	}
+}
+
+func TestFreezeUser_Validation(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, params := range []store.FreezeParams{
+		{UserId: "user1", Reason: "because", Actor: "ops"},
+		{UserId: "user1", Reason: models.FreezeReasonFraud},
+		{UserId: "missing", Reason: models.FreezeReasonFraud, Actor: "ops"},
+	} {
+		if _, err := service.FreezeUser(ctx, params); err == nil {
+			t.Errorf("Expected FreezeUser(%+v) to fail", params)
+		}
+	}
+}
+
+func TestFrozenUser_WithdrawalBlockedDepositQuarantined(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(100), "dep-1"); err != nil {
+		t.Fatalf("ProcessDeposit failed: %v", err)
+	}
+	if _, err := service.FreezeUser(ctx, store.FreezeParams{UserId: "user1", Reason: models.FreezeReasonLegalOrder, Actor: "ops"}); err != nil {
+		t.Fatalf("FreezeUser failed: %v", err)
+	}
+
+	if err := service.ProcessWithdrawal(ctx, "user1", "USDC", decimal.NewFromInt(10), "wd-1"); !errors.Is(err, store.ErrUserFrozen) {
+		t.Errorf("Expected ErrUserFrozen, got %v", err)
+	}
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(25), "dep-2"); err != nil {
+		t.Fatalf("ProcessDeposit to frozen user failed: %v", err)
+	}
+
+	balance, err := service.GetUserBalance(ctx, "user1", "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !balance.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected the frozen user's balance to stay 100, got %s", balance)
+	}
+	quarantine, err := service.GetUserBalance(ctx, store.QuarantineUserId(""), "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !quarantine.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("Expected 25 in quarantine, got %s", quarantine)
+	}
+	txs, err := service.FindTransactionsByMetadata(ctx, map[string]string{"quarantined_user_id": "user1"}, 10)
+	if err != nil || len(txs) != 1 || txs[0].ExternalTransactionId != "dep-2" {
+		t.Errorf("Expected the quarantined deposit to be tagged with the user, got %+v, %v", txs, err)
+	}
+}
+
+func TestProcessTransaction_ChecksFreezeInsideTransaction(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(100), "dep-1"); err != nil {
+		t.Fatalf("ProcessDeposit failed: %v", err)
+	}
+	debit := ProcessTransactionParams{
+		UserId: "user1", Asset: "USDC", TransactionType: "withdrawal", Amount: decimal.NewFromInt(-10), ExternalTxId: "wd-1", CheckNotFrozen: true,
+	}
+
+	if _, err := service.FreezeUser(ctx, store.FreezeParams{UserId: "user1", Reason: models.FreezeReasonCompliance, Actor: "ops"}); err != nil {
+		t.Fatalf("FreezeUser failed: %v", err)
+	}
+	// The subledger reads the freeze itself, so callers need no pre-check
+	if _, err := service.subledger.ProcessTransaction(ctx, debit); !errors.Is(err, store.ErrUserFrozen) {
+		t.Fatalf("Expected ErrUserFrozen, got %v", err)
+	}
+	balance, err := service.GetUserBalance(ctx, "user1", "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !balance.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected the balance to stay 100, got %s", balance)
+	}
+}
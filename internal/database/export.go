@@ -0,0 +1,211 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const defaultExportChunkSize = 500
+
+type journalLine struct {
+	account string
+	debit   decimal.Decimal
+	credit  decimal.Decimal
+}
+
+// ExportPostings streams every posting with an effective time in [query.From, query.To)
+// to handle, one chunk of transactions at a time. Transactions are ordered by
+// (effective_at, id) and their lines debit-first, so repeated exports of the same
+// range produce identical output. Postings come from journal_entries; a transaction
+// without journal entries (none are expected) is exported against external:{type}.
+func (s *SubledgerService) ExportPostings(ctx context.Context, query store.ExportQuery, handle store.PostingHandler) error {
+	chunkSize := query.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultExportChunkSize
+	}
+
+	var where []string
+	var args []any
+	if !query.From.IsZero() {
+		where = append(where, "julianday(effective_at) >= julianday(?)")
+		args = append(args, query.From.UTC())
+	}
+	if !query.To.IsZero() {
+		where = append(where, "julianday(effective_at) < julianday(?)")
+		args = append(args, query.To.UTC())
+	}
+
+	var lastEffective, lastId string
+	for {
+		clauses := where
+		chunkArgs := append([]any{}, args...)
+		if lastId != "" {
+			clauses = append(clauses, "(julianday(effective_at) > julianday(?) OR (julianday(effective_at) = julianday(?) AND id > ?))")
+			chunkArgs = append(chunkArgs, lastEffective, lastEffective, lastId)
+		}
+
+		var sqlQuery strings.Builder
+		sqlQuery.WriteString(querySearchTransactions)
+		if len(clauses) > 0 {
+			sqlQuery.WriteString(" WHERE ")
+			sqlQuery.WriteString(strings.Join(clauses, " AND "))
+		}
+		sqlQuery.WriteString(" ORDER BY julianday(effective_at) ASC, id ASC LIMIT ?")
+		chunkArgs = append(chunkArgs, chunkSize)
+
+		rows, err := s.db.QueryContext(ctx, sqlQuery.String(), chunkArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to query transactions for export: %w", err)
+		}
+		transactions, err := scanTransactions(rows)
+		if err != nil {
+			return err
+		}
+		if len(transactions) == 0 {
+			return nil
+		}
+		if err := s.loadMetadata(ctx, transactions); err != nil {
+			return err
+		}
+
+		lines, err := s.getJournalLines(ctx, transactions)
+		if err != nil {
+			return err
+		}
+
+		var postings []store.Posting
+		for _, t := range transactions {
+			postings = append(postings, transactionPostings(t, lines[t.Id])...)
+		}
+
+		zap.L().Debug("Exporting postings chunk",
+			zap.Int("transactions", len(transactions)),
+			zap.Int("postings", len(postings)))
+
+		if err := handle(postings); err != nil {
+			return err
+		}
+
+		if len(transactions) < chunkSize {
+			return nil
+		}
+		last := transactions[len(transactions)-1]
+		lastEffective = last.EffectiveAt.UTC().Format(time.RFC3339Nano)
+		lastId = last.Id
+	}
+}
+
+// getJournalLines loads the journal entries of the given transactions keyed by
+// transaction id, debits first and then by account for a stable order.
+func (s *SubledgerService) getJournalLines(ctx context.Context, transactions []models.Transaction) (map[string][]journalLine, error) {
+	placeholders := make([]string, len(transactions))
+	args := make([]any, len(transactions))
+	for i, t := range transactions {
+		placeholders[i] = "?"
+		args[i] = t.Id
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		fmt.Sprintf(queryGetJournalEntriesForTransactions, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query journal entries: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			zap.L().Warn("Failed to close rows", zap.Error(err))
+		}
+	}(rows)
+
+	lines := make(map[string][]journalLine)
+	for rows.Next() {
+		var txId, accountId, debitStr, creditStr string
+		if err := rows.Scan(&txId, &accountId, &debitStr, &creditStr); err != nil {
+			return nil, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		debit, err := decimal.NewFromString(debitStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid debit amount %q: %w", debitStr, err)
+		}
+		credit, err := decimal.NewFromString(creditStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credit amount %q: %w", creditStr, err)
+		}
+		lines[txId] = append(lines[txId], journalLine{account: accountId, debit: debit, credit: credit})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating journal entry rows: %w", err)
+	}
+	return lines, nil
+}
+
+// transactionPostings flattens a transaction and its journal lines into export rows.
+// The counterparty of each line is the first line on the opposite side.
+func transactionPostings(t models.Transaction, lines []journalLine) []store.Posting {
+	if len(lines) == 0 {
+		user := userAccount(t.UserId)
+		external := "external:" + t.TransactionType
+		if t.Amount.IsNegative() {
+			lines = []journalLine{{account: external, debit: t.Amount.Neg()}, {account: user, credit: t.Amount.Neg()}}
+		} else {
+			lines = []journalLine{{account: user, debit: t.Amount}, {account: external, credit: t.Amount}}
+		}
+	}
+
+	var firstDebit, firstCredit string
+	for _, l := range lines {
+		if l.debit.IsPositive() && firstDebit == "" {
+			firstDebit = l.account
+		}
+		if l.credit.IsPositive() && firstCredit == "" {
+			firstCredit = l.account
+		}
+	}
+
+	postings := make([]store.Posting, 0, len(lines))
+	for _, l := range lines {
+		p := store.Posting{
+			TransactionId: t.Id,
+			EffectiveAt:   t.EffectiveAt,
+			Account:       l.account,
+			Asset:         t.Asset,
+			ExternalTxId:  t.ExternalTransactionId,
+			Metadata:      t.Metadata,
+		}
+		switch {
+		case l.debit.IsPositive():
+			p.Amount, p.Direction, p.Counterparty = l.debit, store.DirectionDebit, firstCredit
+		case l.credit.IsPositive():
+			p.Amount, p.Direction, p.Counterparty = l.credit, store.DirectionCredit, firstDebit
+		default:
+			continue
+		}
+		p.Sequence = len(postings)
+		postings = append(postings, p)
+	}
+	return postings
+}
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestExportPostings(t *testing.T) {
+	service, cleanup := setupTestDb(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	txs := []ProcessTransactionParams{
+		// Ingested out of effective order on purpose
+		{UserId: "user1", Asset: "USDC", TransactionType: "withdrawal", Amount: decimal.RequireFromString("-2.5"), ExternalTxId: "tx-2", TransactionTime: day.Add(2 * time.Hour)},
+		{UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(10), ExternalTxId: "tx-1", TransactionTime: day.Add(time.Hour), Metadata: map[string]string{"prime_tx_id": "0xabc", "prime_wallet_id": "w1"}},
+		{UserId: "prime-platform", Asset: "USDC", TransactionType: "conversion-in", Amount: decimal.NewFromInt(7), ExternalTxId: "tx-3", TransactionTime: day.Add(3 * time.Hour)},
+		{UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(1), ExternalTxId: "tx-out-of-range", TransactionTime: day.Add(48 * time.Hour)},
+	}
+	for _, p := range txs {
+		if _, err := service.ProcessTransaction(ctx, p); err != nil {
+			t.Fatalf("ProcessTransaction %s failed: %v", p.ExternalTxId, err)
+		}
+	}
+
+	export := func() ([]store.Posting, int) {
+		var all []store.Posting
+		chunks := 0
+		err := service.ExportPostings(ctx, store.ExportQuery{From: day, To: day.Add(24 * time.Hour), ChunkSize: 1}, func(postings []store.Posting) error {
+			chunks++
+			all = append(all, postings...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ExportPostings failed: %v", err)
+		}
+		return all, chunks
+	}
+
+	postings, chunks := export()
+	if chunks != 3 {
+		t.Errorf("Expected 3 chunks of one transaction, got %d", chunks)
+	}
+	if len(postings) != 6 {
+		t.Fatalf("Expected 6 postings (two per transaction), got %d: %+v", len(postings), postings)
+	}
+
+	expected := []struct {
+		externalId, account, counterparty, direction, amount string
+	}{
+		{"tx-1", "users:user1", "prime:wallets:w1", store.DirectionDebit, "10"},
+		{"tx-1", "prime:wallets:w1", "users:user1", store.DirectionCredit, "10"},
+		{"tx-2", "prime:wallets:unassigned", "users:user1", store.DirectionDebit, "2.5"},
+		{"tx-2", "users:user1", "prime:wallets:unassigned", store.DirectionCredit, "2.5"},
+		{"tx-3", "users:prime-platform", "prime:conversions", store.DirectionDebit, "7"},
+		{"tx-3", "prime:conversions", "users:prime-platform", store.DirectionCredit, "7"},
+	}
+	for i, e := range expected {
+		p := postings[i]
+		if p.ExternalTxId != e.externalId || p.Account != e.account || p.Counterparty != e.counterparty ||
+			p.Direction != e.direction || p.Amount.String() != e.amount {
+			t.Errorf("Posting %d = %s %s/%s %s %s, want %+v", i, p.ExternalTxId, p.Account, p.Counterparty, p.Direction, p.Amount, e)
+		}
+	}
+	if postings[0].Metadata["prime_tx_id"] != "0xabc" {
+		t.Errorf("Expected Prime metadata on posting, got %v", postings[0].Metadata)
+	}
+
+	again, _ := export()
+	for i := range postings {
+		if postings[i].TransactionId != again[i].TransactionId || postings[i].Sequence != again[i].Sequence {
+			t.Fatalf("Export is not deterministic at row %d", i)
+		}
+	}
+}
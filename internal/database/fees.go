@@ -0,0 +1,78 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+const transactionTypeFee = "fee"
+
+// ChargeFee debits a fee from the user into fees:{kind}. The reference is stored as
+// the external transaction id, so charging the same reference twice is rejected.
+func (s *Service) ChargeFee(ctx context.Context, params store.FeeChargeParams) error {
+	if params.Reference == "" || params.Kind == "" {
+		return fmt.Errorf("fee reference and kind are required")
+	}
+	if !params.Amount.IsPositive() {
+		return fmt.Errorf("fee amount must be positive, got %s", params.Amount)
+	}
+
+	_, err := s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+		UserId:          params.UserId,
+		Asset:           params.Asset,
+		TransactionType: transactionTypeFee,
+		Amount:          params.Amount.Neg(),
+		ExternalTxId:    params.Reference,
+		Reference:       params.Description,
+		Metadata: mergeMetadata(map[string]string{
+			"fee_kind":      params.Kind,
+			"fee_reference": params.Reference,
+		}, params.Metadata),
+		Counterparty: feeAccount(params.Kind),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to charge %s fee: %w", params.Kind, err)
+	}
+
+	zap.L().Info("Fee charged",
+		zap.String("reference", params.Reference),
+		zap.String("user_id", params.UserId),
+		zap.String("asset", params.Asset),
+		zap.String("amount", params.Amount.String()),
+		zap.String("kind", params.Kind))
+	return nil
+}
+
+// HasFeeCharge reports whether a fee with this reference has been charged.
+func (s *Service) HasFeeCharge(ctx context.Context, reference string) (bool, error) {
+	var id string
+	err := s.db.QueryRowContext(ctx, queryCheckDuplicateTransaction, reference).Scan(&id)
+	if err == nil {
+		return true, nil
+	}
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check fee charge: %w", err)
+}
@@ -0,0 +1,75 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestChargeFee_PostsToFeesAccountOnce(t *testing.T) {
+	service, cleanup := setupBalanceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+		UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(100), ExternalTxId: "dep-1",
+	}); err != nil {
+		t.Fatalf("ProcessTransaction failed: %v", err)
+	}
+
+	params := store.FeeChargeParams{
+		Reference:   "custody-fee-20250301-20250401-USDC-user1",
+		UserId:      "user1",
+		Asset:       "USDC",
+		Amount:      decimal.RequireFromString("0.25"),
+		Kind:        "custody",
+		Description: "Custody fee",
+	}
+	if err := service.ChargeFee(ctx, params); err != nil {
+		t.Fatalf("ChargeFee failed: %v", err)
+	}
+	if charged, err := service.HasFeeCharge(ctx, params.Reference); err != nil || !charged {
+		t.Fatalf("Expected fee to be recorded, got %v, %v", charged, err)
+	}
+	if err := service.ChargeFee(ctx, params); !errors.Is(err, store.ErrDuplicateTransaction) {
+		t.Fatalf("Expected ErrDuplicateTransaction on a second charge, got %v", err)
+	}
+
+	lines, err := service.GetTrialBalance(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	assertBalanced(t, lines)
+	usdc := trialBalanceByAccount(lines, "USDC")
+	if net := usdc["fees:custody"].Net(); !net.Equal(decimal.RequireFromString("0.25")) {
+		t.Errorf("Expected fees:custody to receive 0.25, got %s", net)
+	}
+	balance, err := service.GetUserBalance(ctx, "user1", "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !balance.Equal(decimal.RequireFromString("99.75")) {
+		t.Errorf("Expected balance 99.75 after one charge, got %s", balance)
+	}
+}
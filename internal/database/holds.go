@@ -0,0 +1,338 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const transactionTypeHoldCapture = "hold-capture"
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func scanHold(row rowScanner) (*models.Hold, error) {
+	var h models.Hold
+	var amount, captured string
+	var expiresAt, settledAt sql.NullTime
+	if err := row.Scan(&h.Id, &h.UserId, &h.Asset, &amount, &captured, &h.Status, &h.Reason,
+		&expiresAt, &h.CreatedAt, &settledAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if h.Amount, err = decimal.NewFromString(amount); err != nil {
+		return nil, fmt.Errorf("failed to parse hold amount %q: %w", amount, err)
+	}
+	if h.Captured, err = decimal.NewFromString(captured); err != nil {
+		return nil, fmt.Errorf("failed to parse captured amount %q: %w", captured, err)
+	}
+	h.ExpiresAt = expiresAt.Time
+	h.SettledAt = settledAt.Time
+	return &h, nil
+}
+
+func queryHolds(ctx context.Context, q queryer, query string, args ...any) ([]models.Hold, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []models.Hold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, *h)
+	}
+	return holds, rows.Err()
+}
+
+func sumHolds(holds []models.Hold) decimal.Decimal {
+	held := decimal.Zero
+	for _, h := range holds {
+		held = held.Add(h.Amount)
+	}
+	return held
+}
+
+// GetUserBalanceDetail splits the user's balance into held and available. Holds past
+// their expiry no longer count, even before ExpireHolds marks them.
+func (s *Service) GetUserBalanceDetail(ctx context.Context, userId, asset string) (*models.BalanceDetail, error) {
+	total, err := s.GetUserBalance(ctx, userId, asset)
+	if err != nil {
+		return nil, err
+	}
+	holds, err := queryHolds(ctx, s.db, queryGetActiveHolds, userId, asset, asset, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active holds: %w", err)
+	}
+	held := sumHolds(holds)
+	return &models.BalanceDetail{
+		UserId:    userId,
+		Asset:     asset,
+		Total:     total,
+		Held:      held,
+		Available: total.Sub(held),
+	}, nil
+}
+
+// PlaceHold reserves part of the user's available balance. The balance and the
+// existing holds are read in the same database transaction as the insert, so two
+// holds cannot both claim the same funds. A frozen user's funds cannot be held.
+func (s *Service) PlaceHold(ctx context.Context, params store.PlaceHoldParams) (*models.Hold, error) {
+	if params.HoldId == "" || params.UserId == "" || params.Asset == "" {
+		return nil, fmt.Errorf("hold id, user id and asset are required")
+	}
+	if !params.Amount.IsPositive() {
+		return nil, fmt.Errorf("hold amount must be positive, got %s", params.Amount)
+	}
+	now := time.Now().UTC()
+	if !params.ExpiresAt.IsZero() && !params.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("hold expiry %s is in the past", params.ExpiresAt.Format(time.RFC3339))
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkNotFrozenTx(ctx, tx, params.UserId); err != nil {
+		return nil, err
+	}
+	if _, err := scanHold(tx.QueryRowContext(ctx, queryGetHold, params.HoldId)); err == nil {
+		return nil, fmt.Errorf("%w: hold %s already exists", store.ErrDuplicateTransaction, params.HoldId)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check for existing hold: %w", err)
+	}
+
+	var balanceStr string
+	var accountId string
+	var version int64
+	total := decimal.Zero
+	err = tx.QueryRowContext(ctx, queryGetAccountBalance, params.UserId, params.Asset).Scan(&accountId, &balanceStr, &version)
+	if err == nil {
+		if total, err = decimal.NewFromString(balanceStr); err != nil {
+			return nil, fmt.Errorf("failed to parse balance %q: %w", balanceStr, err)
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get current balance: %w", err)
+	}
+	holds, err := queryHolds(ctx, tx, queryGetActiveHolds, params.UserId, params.Asset, params.Asset, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active holds: %w", err)
+	}
+	if available := total.Sub(sumHolds(holds)); available.LessThan(params.Amount) {
+		return nil, fmt.Errorf("%w: available %s, hold %s", store.ErrInsufficientAvailable, available, params.Amount)
+	}
+
+	var expiresAt any
+	if !params.ExpiresAt.IsZero() {
+		expiresAt = params.ExpiresAt.UTC()
+	}
+	if _, err := tx.ExecContext(ctx, queryInsertHold, params.HoldId, params.UserId, params.Asset,
+		params.Amount.String(), params.Reason, expiresAt, now); err != nil {
+		return nil, fmt.Errorf("failed to insert hold: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit hold: %w", err)
+	}
+
+	zap.L().Info("Hold placed",
+		zap.String("hold_id", params.HoldId),
+		zap.String("user_id", params.UserId),
+		zap.String("asset", params.Asset),
+		zap.String("amount", params.Amount.String()))
+	return s.GetHold(ctx, params.HoldId)
+}
+
+// CaptureHold takes amount (zero for the whole hold) from the user into the captured
+// holds account and closes the hold; any uncaptured remainder becomes available again.
+// The captured funds stay in that account, which nothing in the service settles.
+// Capturing is a debit, so it fails with ErrUserFrozen while the user is frozen.
+func (s *Service) CaptureHold(ctx context.Context, holdId string, amount decimal.Decimal) (*models.Hold, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := s.activeHold(ctx, tx, holdId)
+	if err != nil {
+		return nil, err
+	}
+	if amount.IsZero() {
+		amount = hold.Amount
+	}
+	if amount.IsNegative() || amount.GreaterThan(hold.Amount) {
+		return nil, fmt.Errorf("capture amount %s must be between 0 and the held %s", amount, hold.Amount)
+	}
+
+	if _, err := s.subledger.applyTransaction(ctx, tx, ProcessTransactionParams{
+		UserId:          hold.UserId,
+		Asset:           hold.Asset,
+		TransactionType: transactionTypeHoldCapture,
+		Amount:          amount.Neg(),
+		ExternalTxId:    "hold-" + hold.Id + "-capture",
+		Reference:       hold.Reason,
+		Metadata:        map[string]string{"hold_id": hold.Id},
+		Counterparty:    accountHoldsCaptured,
+		CheckNotFrozen:  true,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to post hold capture: %w", err)
+	}
+	if err := settleHold(ctx, tx, hold.Id, models.HoldStatusCaptured, amount); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit hold capture: %w", err)
+	}
+
+	zap.L().Info("Hold captured",
+		zap.String("hold_id", hold.Id),
+		zap.String("user_id", hold.UserId),
+		zap.String("asset", hold.Asset),
+		zap.String("captured", amount.String()),
+		zap.String("held", hold.Amount.String()))
+	return s.GetHold(ctx, holdId)
+}
+
+// ReleaseHold closes an active hold without moving funds.
+func (s *Service) ReleaseHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := s.activeHold(ctx, tx, holdId)
+	if err != nil {
+		return nil, err
+	}
+	if err := settleHold(ctx, tx, hold.Id, models.HoldStatusReleased, decimal.Zero); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit hold release: %w", err)
+	}
+
+	zap.L().Info("Hold released", zap.String("hold_id", hold.Id), zap.String("user_id", hold.UserId))
+	return s.GetHold(ctx, holdId)
+}
+
+// GetHold returns a hold in any status.
+func (s *Service) GetHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	hold, err := scanHold(s.db.QueryRowContext(ctx, queryGetHold, holdId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", store.ErrHoldNotFound, holdId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	return hold, nil
+}
+
+// GetActiveHolds returns the user's unexpired active holds; an empty asset means all assets.
+func (s *Service) GetActiveHolds(ctx context.Context, userId, asset string) ([]models.Hold, error) {
+	holds, err := queryHolds(ctx, s.db, queryGetActiveHolds, userId, asset, asset, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active holds: %w", err)
+	}
+	return holds, nil
+}
+
+// ExpireHolds marks every active hold past its expiry as expired and returns them.
+func (s *Service) ExpireHolds(ctx context.Context, now time.Time) ([]models.Hold, error) {
+	expired, err := queryHolds(ctx, s.db, queryGetExpiredHolds, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired holds: %w", err)
+	}
+
+	var out []models.Hold
+	for _, h := range expired {
+		res, err := s.db.ExecContext(ctx, querySettleHold, models.HoldStatusExpired, "0", now.UTC(), h.Id)
+		if err != nil {
+			return out, fmt.Errorf("failed to expire hold %s: %w", h.Id, err)
+		}
+		// A concurrent capture or release got there first
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		h.Status, h.SettledAt = models.HoldStatusExpired, now.UTC()
+		out = append(out, h)
+		zap.L().Info("Hold expired", zap.String("hold_id", h.Id), zap.String("user_id", h.UserId))
+	}
+	return out, nil
+}
+
+// activeHold loads a hold inside tx and checks it can still be captured or released.
+// A hold found past its expiry is marked expired.
+func (s *Service) activeHold(ctx context.Context, tx *sql.Tx, holdId string) (*models.Hold, error) {
+	hold, err := scanHold(tx.QueryRowContext(ctx, queryGetHold, holdId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", store.ErrHoldNotFound, holdId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	now := time.Now().UTC()
+	if hold.Expired(now) {
+		if err := settleHold(ctx, tx, hold.Id, models.HoldStatusExpired, decimal.Zero); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit hold expiry: %w", err)
+		}
+		return nil, fmt.Errorf("%w: hold %s expired at %s", store.ErrHoldNotActive, holdId, hold.ExpiresAt.Format(time.RFC3339))
+	}
+	if hold.Status != models.HoldStatusActive {
+		return nil, fmt.Errorf("%w: hold %s is %s", store.ErrHoldNotActive, holdId, hold.Status)
+	}
+	return hold, nil
+}
+
+func settleHold(ctx context.Context, tx *sql.Tx, holdId, status string, captured decimal.Decimal) error {
+	res, err := tx.ExecContext(ctx, querySettleHold, status, captured.String(), time.Now().UTC(), holdId)
+	if err != nil {
+		return fmt.Errorf("failed to update hold: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check hold update: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("%w: hold %s", store.ErrHoldNotActive, holdId)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func setupHoldTestDB(t *testing.T) (*Service, func()) {
+	t.Helper()
+	service, cleanup := setupComplianceTestDB(t)
+	if _, err := service.subledger.ProcessTransaction(context.Background(), ProcessTransactionParams{
+		UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(100), ExternalTxId: "dep-1",
+	}); err != nil {
+		cleanup()
+		t.Fatalf("ProcessTransaction failed: %v", err)
+	}
+	return service, cleanup
+}
+
+func assertDetail(t *testing.T, service *Service, total, held, available string) {
+	t.Helper()
+	detail, err := service.GetUserBalanceDetail(context.Background(), "user1", "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalanceDetail failed: %v", err)
+	}
+	if !detail.Total.Equal(decimal.RequireFromString(total)) ||
+		!detail.Held.Equal(decimal.RequireFromString(held)) ||
+		!detail.Available.Equal(decimal.RequireFromString(available)) {
+		t.Errorf("Expected total %s, held %s, available %s, got %s, %s, %s",
+			total, held, available, detail.Total, detail.Held, detail.Available)
+	}
+}
+
+func TestPlaceHold_ReducesAvailable(t *testing.T) {
+	service, cleanup := setupHoldTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	params := store.PlaceHoldParams{HoldId: "h1", UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(60), Reason: "order-1"}
+	hold, err := service.PlaceHold(ctx, params)
+	if err != nil {
+		t.Fatalf("PlaceHold failed: %v", err)
+	}
+	if hold.Status != models.HoldStatusActive {
+		t.Errorf("Expected an active hold, got %s", hold.Status)
+	}
+	assertDetail(t, service, "100", "60", "40")
+
+	if _, err := service.PlaceHold(ctx, params); !errors.Is(err, store.ErrDuplicateTransaction) {
+		t.Errorf("Expected ErrDuplicateTransaction for a reused hold id, got %v", err)
+	}
+	over := store.PlaceHoldParams{HoldId: "h2", UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(41)}
+	if _, err := service.PlaceHold(ctx, over); !errors.Is(err, store.ErrInsufficientAvailable) {
+		t.Errorf("Expected ErrInsufficientAvailable, got %v", err)
+	}
+}
+
+func TestCaptureHold_Partial(t *testing.T) {
+	service, cleanup := setupHoldTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.PlaceHold(ctx, store.PlaceHoldParams{
+		HoldId: "h1", UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(60),
+	}); err != nil {
+		t.Fatalf("PlaceHold failed: %v", err)
+	}
+	hold, err := service.CaptureHold(ctx, "h1", decimal.NewFromInt(25))
+	if err != nil {
+		t.Fatalf("CaptureHold failed: %v", err)
+	}
+	if hold.Status != models.HoldStatusCaptured || !hold.Captured.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("Expected captured 25, got %s %s", hold.Status, hold.Captured)
+	}
+	assertDetail(t, service, "75", "0", "75")
+
+	lines, err := service.GetTrialBalance(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	assertBalanced(t, lines)
+	if net := trialBalanceByAccount(lines, "USDC")[accountHoldsCaptured].Net(); !net.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("Expected %s to receive 25, got %s", accountHoldsCaptured, net)
+	}
+
+	if _, err := service.CaptureHold(ctx, "h1", decimal.Zero); !errors.Is(err, store.ErrHoldNotActive) {
+		t.Errorf("Expected ErrHoldNotActive on a second capture, got %v", err)
+	}
+	if _, err := service.ReleaseHold(ctx, "h1"); !errors.Is(err, store.ErrHoldNotActive) {
+		t.Errorf("Expected ErrHoldNotActive when releasing a captured hold, got %v", err)
+	}
+	if _, err := service.CaptureHold(ctx, "missing", decimal.Zero); !errors.Is(err, store.ErrHoldNotFound) {
+		t.Errorf("Expected ErrHoldNotFound, got %v", err)
+	}
+}
+
+func TestReleaseHold_RestoresAvailable(t *testing.T) {
+	service, cleanup := setupHoldTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.PlaceHold(ctx, store.PlaceHoldParams{
+		HoldId: "h1", UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(60),
+	}); err != nil {
+		t.Fatalf("PlaceHold failed: %v", err)
+	}
+	hold, err := service.ReleaseHold(ctx, "h1")
+	if err != nil {
+		t.Fatalf("ReleaseHold failed: %v", err)
+	}
+	if hold.Status != models.HoldStatusReleased {
+		t.Errorf("Expected a released hold, got %s", hold.Status)
+	}
+	assertDetail(t, service, "100", "0", "100")
+}
+
+func TestExpireHolds(t *testing.T) {
+	service, cleanup := setupHoldTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, id := range []string{"h1", "h2"} {
+		if _, err := service.PlaceHold(ctx, store.PlaceHoldParams{
+			HoldId: id, UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(30), ExpiresAt: time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatalf("PlaceHold failed: %v", err)
+		}
+	}
+	if _, err := service.db.Exec(`UPDATE holds SET expires_at = ? WHERE id = 'h1'`, time.Now().UTC().Add(-time.Minute)); err != nil {
+		t.Fatalf("Failed to backdate hold: %v", err)
+	}
+	// An expired hold stops counting before ExpireHolds runs
+	assertDetail(t, service, "100", "30", "70")
+
+	expired, err := service.ExpireHolds(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("ExpireHolds failed: %v", err)
+	}
+	if len(expired) != 1 || expired[0].Id != "h1" {
+		t.Fatalf("Expected only h1 to expire, got %+v", expired)
+	}
+	if _, err := service.CaptureHold(ctx, "h1", decimal.Zero); !errors.Is(err, store.ErrHoldNotActive) {
+		t.Errorf("Expected ErrHoldNotActive for an expired hold, got %v", err)
+	}
+	if again, err := service.ExpireHolds(ctx, time.Now()); err != nil || len(again) != 0 {
+		t.Errorf("Expected nothing left to expire, got %v, %v", again, err)
+	}
+}
+
+func TestProcessWithdrawal_CannotSpendHeldFunds(t *testing.T) {
+	service, cleanup := setupHoldTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.PlaceHold(ctx, store.PlaceHoldParams{
+		HoldId: "h1", UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(60),
+	}); err != nil {
+		t.Fatalf("PlaceHold failed: %v", err)
+	}
+
+	if err := service.ProcessWithdrawal(ctx, "user1", "USDC", decimal.NewFromInt(41), "wd-1"); !errors.Is(err, store.ErrInsufficientAvailable) {
+		t.Fatalf("Expected ErrInsufficientAvailable for a withdrawal into held funds, got %v", err)
+	}
+	assertDetail(t, service, "100", "60", "40")
+
+	if err := service.ProcessWithdrawal(ctx, "user1", "USDC", decimal.NewFromInt(40), "wd-2"); err != nil {
+		t.Fatalf("Expected the available 40 to be withdrawable, got %v", err)
+	}
+	assertDetail(t, service, "60", "60", "0")
+}
+
+func TestHolds_FrozenUser(t *testing.T) {
+	service, cleanup := setupHoldTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.PlaceHold(ctx, store.PlaceHoldParams{HoldId: "h1", UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(60)}); err != nil {
+		t.Fatalf("PlaceHold failed: %v", err)
+	}
+	if _, err := service.FreezeUser(ctx, store.FreezeParams{UserId: "user1", Reason: models.FreezeReasonFraud, Actor: "ops"}); err != nil {
+		t.Fatalf("FreezeUser failed: %v", err)
+	}
+
+	if _, err := service.PlaceHold(ctx, store.PlaceHoldParams{HoldId: "h2", UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(10)}); !errors.Is(err, store.ErrUserFrozen) {
+		t.Errorf("Expected ErrUserFrozen placing a hold, got %v", err)
+	}
+	if _, err := service.CaptureHold(ctx, "h1", decimal.Zero); !errors.Is(err, store.ErrUserFrozen) {
+		t.Errorf("Expected ErrUserFrozen capturing a hold, got %v", err)
+	}
+	if hold, err := service.GetHold(ctx, "h1"); err != nil || hold.Status != models.HoldStatusActive {
+		t.Errorf("Expected h1 to stay active, got %+v, %v", hold, err)
+	}
+	assertDetail(t, service, "100", "60", "40")
+}
@@ -0,0 +1,177 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// migrationJournalChartOfAccounts names the one-off journal rebuild in schema_migrations
+const migrationJournalChartOfAccounts = "journal_chart_of_accounts"
+
+// backfillJournal brings databases written before the chart of accounts up to date:
+// legacy user_asset/system_liability rows are dropped and every transaction without
+// journal entries gets its balanced posting. It runs once, recorded in
+// schema_migrations, and the delete, rebuild and record share one transaction so a
+// failed rebuild leaves the old journal in place.
+func (s *SubledgerService) backfillJournal() error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin journal backfill: %w", err)
+	}
+	defer tx.Rollback()
+
+	applied, err := migrationApplied(ctx, tx, migrationJournalChartOfAccounts)
+	if err != nil || applied {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteLegacyJournalEntries); err != nil {
+		return fmt.Errorf("failed to delete legacy journal entries: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, queryGetTransactionsWithoutJournal)
+	if err != nil {
+		return fmt.Errorf("failed to find transactions without journal entries: %w", err)
+	}
+
+	type pending struct {
+		transaction  models.Transaction
+		counterparty string
+	}
+	var missing []pending
+	for rows.Next() {
+		var t models.Transaction
+		var amountStr, reference, walletId string
+		if err := rows.Scan(&t.Id, &t.UserId, &t.Asset, &t.TransactionType, &amountStr, &reference, &walletId); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan transaction for journal backfill: %w", err)
+		}
+		if t.Amount, err = decimal.NewFromString(amountStr); err != nil {
+			rows.Close()
+			return fmt.Errorf("invalid amount %q on transaction %s: %w", amountStr, t.Id, err)
+		}
+		t.Metadata = map[string]string{"prime_wallet_id": walletId}
+
+		// Unmatched failed withdrawals round-trip through the pending account
+		counterparty := ""
+		if strings.HasPrefix(reference, "FAILED_WITHDRAWAL") {
+			counterparty = accountWithdrawalsPending
+		}
+		missing = append(missing, pending{transaction: t, counterparty: counterparty})
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating transactions for journal backfill: %w", err)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to close journal backfill rows: %w", err)
+	}
+
+	for _, p := range missing {
+		if err := s.addJournalEntries(ctx, tx, &p.transaction, p.counterparty); err != nil {
+			return fmt.Errorf("failed to backfill journal for transaction %s: %w", p.transaction.Id, err)
+		}
+	}
+
+	if err := recordMigration(ctx, tx, migrationJournalChartOfAccounts); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit journal backfill: %w", err)
+	}
+	if len(missing) > 0 {
+		zap.L().Info("Backfilled journal entries", zap.Int("transactions", len(missing)))
+	}
+	return nil
+}
+
+// GetTrialBalance returns total debits and credits per account and asset for every
+// journal posting with an effective time at or before asOf (zero means all time),
+// ordered by asset then account. Within each asset debits equal credits.
+func (s *SubledgerService) GetTrialBalance(ctx context.Context, asOf time.Time) ([]models.TrialBalanceLine, error) {
+	if asOf.IsZero() {
+		asOf = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	rows, err := s.db.QueryContext(ctx, queryGetTrialBalanceEntries, asOf.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trial balance: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			zap.L().Warn("Failed to close rows", zap.Error(err))
+		}
+	}(rows)
+
+	// Summed in Go: SUM() over REAL columns would reintroduce float rounding
+	type key struct{ account, asset string }
+	totals := make(map[key]*models.TrialBalanceLine)
+	for rows.Next() {
+		var account, asset, debitStr, creditStr string
+		if err := rows.Scan(&account, &asset, &debitStr, &creditStr); err != nil {
+			return nil, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		debit, err := decimal.NewFromString(debitStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid debit amount %q: %w", debitStr, err)
+		}
+		credit, err := decimal.NewFromString(creditStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credit amount %q: %w", creditStr, err)
+		}
+
+		k := key{account, asset}
+		line, ok := totals[k]
+		if !ok {
+			line = &models.TrialBalanceLine{Account: account, Asset: asset}
+			totals[k] = line
+		}
+		line.Debits = line.Debits.Add(debit)
+		line.Credits = line.Credits.Add(credit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating journal entries: %w", err)
+	}
+
+	lines := make([]models.TrialBalanceLine, 0, len(totals))
+	for _, line := range totals {
+		lines = append(lines, *line)
+	}
+	sortTrialBalance(lines)
+	return lines, nil
+}
+
+func sortTrialBalance(lines []models.TrialBalanceLine) {
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Asset != lines[j].Asset {
+			return lines[i].Asset < lines[j].Asset
+		}
+		return lines[i].Account < lines[j].Account
+	})
+}
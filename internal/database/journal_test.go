@@ -0,0 +1,255 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func trialBalanceByAccount(lines []models.TrialBalanceLine, asset string) map[string]models.TrialBalanceLine {
+	out := make(map[string]models.TrialBalanceLine)
+	for _, l := range lines {
+		if l.Asset == asset {
+			out[l.Account] = l
+		}
+	}
+	return out
+}
+
+func assertBalanced(t *testing.T, lines []models.TrialBalanceLine) {
+	t.Helper()
+	debits := make(map[string]decimal.Decimal)
+	credits := make(map[string]decimal.Decimal)
+	for _, l := range lines {
+		debits[l.Asset] = debits[l.Asset].Add(l.Debits)
+		credits[l.Asset] = credits[l.Asset].Add(l.Credits)
+	}
+	for asset := range debits {
+		if !debits[asset].Equal(credits[asset]) {
+			t.Errorf("%s does not balance: debits %s, credits %s", asset, debits[asset], credits[asset])
+		}
+	}
+}
+
+func TestJournal_AllTransactionTypesBalance(t *testing.T) {
+	service, cleanup := setupBalanceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	// setupBalanceTestDB's users table is minimal; user lookups also read timestamps
+	if _, err := service.db.Exec(`ALTER TABLE users ADD COLUMN active BOOLEAN NOT NULL DEFAULT 1;
+		ALTER TABLE users ADD COLUMN created_at TIMESTAMP DEFAULT '2025-01-01 00:00:00';
+		ALTER TABLE users ADD COLUMN updated_at TIMESTAMP DEFAULT '2025-01-01 00:00:00';
+		ALTER TABLE users ADD COLUMN frozen BOOLEAN NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN frozen_reason TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN frozen_by TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN frozen_note TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN frozen_updated_at TIMESTAMP;
+		ALTER TABLE users ADD COLUMN closed_at TIMESTAMP;
+		ALTER TABLE addresses ADD COLUMN retired_at TIMESTAMP;
+		ALTER TABLE addresses ADD COLUMN tag TEXT NOT NULL DEFAULT ''`); err != nil {
+		t.Fatalf("Failed to extend users table: %v", err)
+	}
+	if _, err := service.db.Exec(`INSERT INTO addresses (id, user_id, asset, network, address, wallet_id, account_identifier)
+		VALUES ('a1', 'user1', 'USDC', 'base-mainnet', '0xdeposit', 'w-usdc', '')`); err != nil {
+		t.Fatalf("Failed to insert address: %v", err)
+	}
+
+	depositCtx := models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{WalletId: "w-usdc"})
+	if err := service.ProcessDeposit(depositCtx, "0xdeposit", "USDC", decimal.RequireFromString("100.1"), "dep-1"); err != nil {
+		t.Fatalf("ProcessDeposit failed: %v", err)
+	}
+	if err := service.ProcessWithdrawal(ctx, "user1", "USDC", decimal.RequireFromString("30.05"), "wd-1"); err != nil {
+		t.Fatalf("ProcessWithdrawal failed: %v", err)
+	}
+	if err := service.ReverseWithdrawal(ctx, "user1", "USDC", decimal.RequireFromString("30.05"), "wd-1"); err != nil {
+		t.Fatalf("ReverseWithdrawal failed: %v", err)
+	}
+	if err := service.RecordPlatformTransaction(ctx, store.PlatformTransactionParams{
+		TransactionId: "reward-1", Type: "REWARD", Status: "TRANSACTION_DONE", Symbol: "ETH", Amount: "0.5", WalletId: "w-eth",
+	}); err != nil {
+		t.Fatalf("RecordPlatformTransaction failed: %v", err)
+	}
+	if err := service.RecordConversion(ctx, store.ConversionParams{
+		TransactionId: "conv-1", SourceSymbol: "ETH", SourceAmount: "0.2", DestinationSymbol: "USDC", DestinationAmount: "600",
+	}); err != nil {
+		t.Fatalf("RecordConversion failed: %v", err)
+	}
+	if err := service.RecordFailedWithdrawalPlatform(ctx, store.FailedWithdrawalPlatformParams{
+		TransactionId: "failed-1", Status: "TRANSACTION_FAILED", Symbol: "USDC", Amount: decimal.NewFromInt(5), WalletId: "w-usdc",
+	}); err != nil {
+		t.Fatalf("RecordFailedWithdrawalPlatform failed: %v", err)
+	}
+
+	var missing int
+	if err := service.db.QueryRow(`SELECT COUNT(*) FROM transactions t
+		WHERE NOT EXISTS (SELECT 1 FROM journal_entries j WHERE j.transaction_id = t.id)`).Scan(&missing); err != nil {
+		t.Fatalf("Failed to count transactions without journal: %v", err)
+	}
+	if missing != 0 {
+		t.Errorf("Expected every transaction to have journal entries, %d have none", missing)
+	}
+
+	lines, err := service.GetTrialBalance(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	assertBalanced(t, lines)
+
+	usdc := trialBalanceByAccount(lines, "USDC")
+	if net := usdc["users:user1"].Net(); !net.Equal(decimal.RequireFromString("100.1")) {
+		t.Errorf("Expected users:user1 USDC net 100.1 after reversal, got %s", net)
+	}
+	if net := usdc["prime:withdrawals:pending"].Net(); !net.IsZero() {
+		t.Errorf("Expected failed round trip to net to zero in pending, got %s", net)
+	}
+	if net := usdc["prime:conversions"].Net(); !net.Equal(decimal.NewFromInt(-600)) {
+		t.Errorf("Expected conversions USDC net -600, got %s", net)
+	}
+	eth := trialBalanceByAccount(lines, "ETH")
+	if net := eth["prime:wallets:w-eth"].Net(); !net.Equal(decimal.RequireFromString("-0.5")) {
+		t.Errorf("Expected reward to credit ETH wallet 0.5, got %s", net)
+	}
+	if net := eth["users:prime-platform"].Net(); !net.Equal(decimal.RequireFromString("0.3")) {
+		t.Errorf("Expected platform ETH net 0.3, got %s", net)
+	}
+
+	// User accounts agree with the cached balances
+	balance, err := service.GetUserBalance(ctx, "user1", "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !balance.Equal(usdc["users:user1"].Net()) {
+		t.Errorf("Journal net %s disagrees with balance %s", usdc["users:user1"].Net(), balance)
+	}
+}
+
+func TestGetTrialBalance_AsOf(t *testing.T) {
+	service, cleanup := setupTestDb(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, amount := range []int64{10, 20} {
+		if _, err := service.ProcessTransaction(ctx, ProcessTransactionParams{
+			UserId: "user1", Asset: "BTC", TransactionType: "deposit", Amount: decimal.NewFromInt(amount),
+			ExternalTxId: string(rune('a' + i)), TransactionTime: day.Add(time.Duration(i) * 24 * time.Hour),
+		}); err != nil {
+			t.Fatalf("ProcessTransaction failed: %v", err)
+		}
+	}
+
+	lines, err := service.GetTrialBalance(ctx, day.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	if got := trialBalanceByAccount(lines, "BTC")["users:user1"].Debits; !got.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("Expected 10 BTC debited as of day one, got %s", got)
+	}
+}
+
+func TestInitSchema_BackfillsLegacyJournal(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	service := NewSubledgerService(db)
+	if err := service.InitSchema(); err != nil {
+		t.Fatalf("InitSchema failed: %v", err)
+	}
+
+	// Rows as written before the chart of accounts: old account types, and no
+	// journal at all for platform types.
+	legacy := `
+		INSERT INTO transactions (id, user_id, asset, transaction_type, amount, balance_before, balance_after, reference, effective_at)
+		VALUES ('t1', 'user1', 'BTC', 'deposit', 1, 0, 1, '', '2024-06-01 10:00:00'),
+		       ('t2', 'prime-platform', 'BTC', 'REWARD', 0.25, 0, 0.25, '', '2024-06-02 10:00:00'),
+		       ('t3', 'prime-platform', 'BTC', 'withdrawal', -0.1, 0.25, 0.15, 'FAILED_WITHDRAWAL: 0.1 BTC [FAILED]', '2024-06-03 10:00:00');
+		INSERT INTO journal_entries (id, transaction_id, account_type, account_id, asset, debit_amount, credit_amount)
+		VALUES ('j1', 't1', 'user_asset', 'user1_BTC', 'BTC', 1, 0),
+		       ('j2', 't1', 'system_liability', 'user_deposits_BTC', 'BTC', 0, 1);
+	`
+	if _, err := db.Exec(legacy); err != nil {
+		t.Fatalf("Failed to insert legacy rows: %v", err)
+	}
+	// A database from before the rebuild has no record of it
+	if _, err := db.Exec("DELETE FROM schema_migrations"); err != nil {
+		t.Fatalf("Failed to clear migrations: %v", err)
+	}
+
+	if err := service.InitSchema(); err != nil {
+		t.Fatalf("InitSchema on legacy journal failed: %v", err)
+	}
+
+	lines, err := service.GetTrialBalance(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	assertBalanced(t, lines)
+	btc := trialBalanceByAccount(lines, "BTC")
+	if _, ok := btc["user1_BTC"]; ok {
+		t.Error("Expected legacy journal rows to be replaced")
+	}
+	if !btc["users:user1"].Net().Equal(decimal.NewFromInt(1)) {
+		t.Errorf("Expected users:user1 net 1, got %s", btc["users:user1"].Net())
+	}
+	if !btc["prime:withdrawals:pending"].Debits.Equal(decimal.RequireFromString("0.1")) {
+		t.Errorf("Expected legacy failed withdrawal to post to pending, got %+v", btc["prime:withdrawals:pending"])
+	}
+	if len(btc) != 4 {
+		t.Errorf("Expected 4 BTC accounts, got %v", btc)
+	}
+}
+
+func TestInitSchema_JournalBackfillRunsOnce(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	service := NewSubledgerService(db)
+	if err := service.InitSchema(); err != nil {
+		t.Fatalf("InitSchema failed: %v", err)
+	}
+
+	// Once recorded, later startups leave the journal alone, whatever it holds
+	if _, err := db.Exec(`INSERT INTO journal_entries (id, transaction_id, account_type, account_id, asset, debit_amount, credit_amount)
+		VALUES ('j1', 't1', 'user_asset', 'user1_BTC', 'BTC', 1, 0)`); err != nil {
+		t.Fatalf("Failed to insert journal row: %v", err)
+	}
+	if err := service.InitSchema(); err != nil {
+		t.Fatalf("InitSchema failed: %v", err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM journal_entries WHERE id = 'j1'").Scan(&count); err != nil {
+		t.Fatalf("Failed to count journal rows: %v", err)
+	}
+	if count != 1 {
+		t.Error("Expected the journal rebuild not to run a second time")
+	}
+}
@@ -0,0 +1,141 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// execer is satisfied by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// AcquireLease acquires, renews or takes over a named lease. Expiry is judged by
+// this process's clock, so hosts sharing a lease need roughly synchronised clocks.
+func (s *Service) AcquireLease(ctx context.Context, params store.AcquireLeaseParams) (*models.Lease, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(params.TTL)
+
+	current, err := s.GetLease(ctx, params.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result sql.Result
+	switch {
+	case current == nil:
+		result, err = s.db.ExecContext(ctx, queryInsertLease, params.Name, params.Holder, now, now, expiresAt)
+		if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("%w: %s", store.ErrLeaseHeld, params.Name)
+		}
+	case current.Holder == params.Holder && !current.Expired(now):
+		result, err = s.db.ExecContext(ctx, queryRenewLease, now, expiresAt, params.Name, params.Holder, current.Token)
+	case current.Expired(now):
+		result, err = s.db.ExecContext(ctx, queryTakeOverLease, params.Holder, now, now, expiresAt, params.Name, current.Token)
+	default:
+		return nil, fmt.Errorf("%w: %s by %s until %s", store.ErrLeaseHeld, params.Name, current.Holder, current.ExpiresAt.Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lease %s: %w", params.Name, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to acquire lease %s: %w", params.Name, err)
+	} else if n == 0 {
+		// Another contender changed the lease between the read and the update
+		return nil, fmt.Errorf("%w: %s", store.ErrLeaseHeld, params.Name)
+	}
+
+	lease, err := s.GetLease(ctx, params.Name)
+	if err != nil {
+		return nil, err
+	}
+	if lease == nil || lease.Holder != params.Holder {
+		return nil, fmt.Errorf("%w: %s", store.ErrLeaseHeld, params.Name)
+	}
+	if current == nil || current.Token != lease.Token {
+		zap.L().Info("Lease acquired",
+			zap.String("name", lease.Name),
+			zap.String("holder", lease.Holder),
+			zap.Int64("token", lease.Token))
+	}
+	return lease, nil
+}
+
+// ReleaseLease expires the lease now so a standby can take it over without
+// waiting for the TTL. Releasing a lease that has changed hands is a no-op.
+func (s *Service) ReleaseLease(ctx context.Context, fence models.LeaseFence) error {
+	if _, err := s.db.ExecContext(ctx, queryReleaseLease, time.Now().UTC(), fence.Name, fence.Holder, fence.Token); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", fence.Name, err)
+	}
+	return nil
+}
+
+// GetLease returns the named lease, or nil if it has never been acquired.
+func (s *Service) GetLease(ctx context.Context, name string) (*models.Lease, error) {
+	var l models.Lease
+	err := s.db.QueryRowContext(ctx, queryGetLease, name).Scan(&l.Name, &l.Holder, &l.Token, &l.AcquiredAt, &l.RenewedAt, &l.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease %s: %w", name, err)
+	}
+	return &l, nil
+}
+
+// CheckLease fails with store.ErrLeaseLost unless fence still holds its unexpired lease.
+func (s *Service) CheckLease(ctx context.Context, fence models.LeaseFence) error {
+	return checkLease(ctx, s.db, fence)
+}
+
+// checkFence checks the fence attached to ctx, if any, inside a ledger write.
+func checkFence(ctx context.Context, tx execer) error {
+	fence := models.GetLeaseFence(ctx)
+	if fence == nil {
+		return nil
+	}
+	return checkLease(ctx, tx, *fence)
+}
+
+func checkLease(ctx context.Context, db execer, fence models.LeaseFence) error {
+	result, err := db.ExecContext(ctx, queryCheckLease, fence.Name, fence.Holder, fence.Token, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to check lease %s: %w", fence.Name, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check lease %s: %w", fence.Name, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s token %d", store.ErrLeaseLost, fence.Name, fence.Token)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAcquireLease_RenewAndTakeOver(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if lease, err := service.GetLease(ctx, "listener:p1"); err != nil || lease != nil {
+		t.Fatalf("Expected no lease before acquiring, got %+v, %v", lease, err)
+	}
+
+	a := store.AcquireLeaseParams{Name: "listener:p1", Holder: "a", TTL: time.Minute}
+	first, err := service.AcquireLease(ctx, a)
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+	if first.Holder != "a" || first.Token != 1 {
+		t.Errorf("Unexpected lease %+v", first)
+	}
+
+	renewed, err := service.AcquireLease(ctx, a)
+	if err != nil {
+		t.Fatalf("Renewal failed: %v", err)
+	}
+	if renewed.Token != 1 || renewed.ExpiresAt.Before(first.ExpiresAt) {
+		t.Errorf("Expected renewal to keep the token and extend expiry, got %+v", renewed)
+	}
+
+	b := store.AcquireLeaseParams{Name: "listener:p1", Holder: "b", TTL: time.Minute}
+	if _, err := service.AcquireLease(ctx, b); !errors.Is(err, store.ErrLeaseHeld) {
+		t.Fatalf("Expected ErrLeaseHeld for a live lease, got %v", err)
+	}
+
+	// Once a's lease lapses b takes over with the next token
+	if _, err := service.db.Exec(`UPDATE leases SET expires_at = ? WHERE name = ?`, time.Now().UTC().Add(-time.Second), "listener:p1"); err != nil {
+		t.Fatalf("Failed to expire lease: %v", err)
+	}
+	taken, err := service.AcquireLease(ctx, b)
+	if err != nil {
+		t.Fatalf("Takeover failed: %v", err)
+	}
+	if taken.Holder != "b" || taken.Token != 2 {
+		t.Errorf("Expected b to hold token 2, got %+v", taken)
+	}
+	if _, err := service.AcquireLease(ctx, a); !errors.Is(err, store.ErrLeaseHeld) {
+		t.Errorf("Expected the old holder to be refused, got %v", err)
+	}
+
+	// Releasing lets a standby in without waiting for the TTL
+	if err := service.ReleaseLease(ctx, taken.Fence()); err != nil {
+		t.Fatalf("ReleaseLease failed: %v", err)
+	}
+	if again, err := service.AcquireLease(ctx, a); err != nil || again.Token != 3 {
+		t.Errorf("Expected a to take over with token 3 after release, got %+v, %v", again, err)
+	}
+}
+
+func TestLeaseFence_RefusesStaleWrites(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	lease, err := service.AcquireLease(ctx, store.AcquireLeaseParams{Name: "listener:p1", Holder: "a", TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+	fenced := models.WithLeaseFence(ctx, lease.Fence())
+
+	if err := service.CheckLease(ctx, lease.Fence()); err != nil {
+		t.Errorf("Expected the current fence to pass, got %v", err)
+	}
+	if err := service.ProcessDeposit(fenced, "0xabc", "USDC", decimal.NewFromInt(10), "dep-1"); err != nil {
+		t.Fatalf("Fenced deposit failed: %v", err)
+	}
+
+	// Another instance takes over; writes under the old token must be refused
+	if _, err := service.db.Exec(`UPDATE leases SET expires_at = ? WHERE name = ?`, time.Now().UTC().Add(-time.Second), "listener:p1"); err != nil {
+		t.Fatalf("Failed to expire lease: %v", err)
+	}
+	if _, err := service.AcquireLease(ctx, store.AcquireLeaseParams{Name: "listener:p1", Holder: "b", TTL: time.Minute}); err != nil {
+		t.Fatalf("Takeover failed: %v", err)
+	}
+
+	if err := service.CheckLease(ctx, lease.Fence()); !errors.Is(err, store.ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost for the old fence, got %v", err)
+	}
+	if err := service.ProcessDeposit(fenced, "0xabc", "USDC", decimal.NewFromInt(10), "dep-2"); !errors.Is(err, store.ErrLeaseLost) {
+		t.Fatalf("Expected the stale deposit to be refused with ErrLeaseLost, got %v", err)
+	}
+
+	balance, err := service.GetUserBalance(ctx, "user1", "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !balance.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("Expected only the fenced-in deposit to land, balance %s", balance)
+	}
+
+	// Writes without a fence are unaffected
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(5), "dep-3"); err != nil {
+		t.Errorf("Unfenced deposit failed: %v", err)
+	}
+}
+
+func TestLeaseFence_ExpiredLeaseFencesRetryQueue(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	lease, err := service.AcquireLease(ctx, store.AcquireLeaseParams{Name: "listener:p1", Holder: "a", TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+	fenced := models.WithLeaseFence(ctx, lease.Fence())
+	params := store.RecordTxFailureParams{
+		PortfolioId: "p1",
+		Transaction: models.PrimeTransaction{Id: "tx1", Type: "DEPOSIT", Status: "TRANSACTION_IMPORTED"},
+		Error:       "no user for address",
+		Policy:      models.RetryPolicy{BaseDelay: time.Minute, MaxAttempts: 3},
+	}
+	if _, err := service.RecordTxFailure(fenced, params); err != nil {
+		t.Fatalf("Fenced RecordTxFailure failed: %v", err)
+	}
+
+	// The lease lapses with nobody taking it over; the old holder must still stop writing
+	if _, err := service.db.Exec(`UPDATE leases SET expires_at = ? WHERE name = ?`, time.Now().UTC().Add(-time.Second), "listener:p1"); err != nil {
+		t.Fatalf("Failed to expire lease: %v", err)
+	}
+	if err := service.CheckLease(ctx, lease.Fence()); !errors.Is(err, store.ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost for an expired lease, got %v", err)
+	}
+	if _, err := service.RecordTxFailure(fenced, params); !errors.Is(err, store.ErrLeaseLost) {
+		t.Errorf("Expected RecordTxFailure under an expired lease to be refused, got %v", err)
+	}
+	if err := service.ResolveFailedTx(fenced, "tx1"); !errors.Is(err, store.ErrLeaseLost) {
+		t.Errorf("Expected ResolveFailedTx under an expired lease to be refused, got %v", err)
+	}
+	if failed, err := service.GetFailedTx(ctx, "tx1"); err != nil || failed == nil || failed.Attempts != 1 {
+		t.Errorf("Expected the entry to keep its single attempt, got %+v, %v", failed, err)
+	}
+}
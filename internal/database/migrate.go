@@ -0,0 +1,115 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// columnMigration describes a column added after the table was first shipped.
+// CREATE TABLE IF NOT EXISTS leaves existing databases untouched, so new columns
+// are added here with ALTER TABLE and optionally backfilled.
+type columnMigration struct {
+	table    string
+	column   string
+	ddl      string // column definition, e.g. "TEXT NOT NULL DEFAULT ''"
+	backfill string // optional UPDATE run once after the column is added
+}
+
+// migrationApplied reports whether the named one-off data migration has run.
+func migrationApplied(ctx context.Context, tx *sql.Tx, name string) (bool, error) {
+	var appliedAt string
+	err := tx.QueryRowContext(ctx, queryGetMigration, name).Scan(&appliedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check migration %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// recordMigration marks the named data migration as run, in the same
+// transaction as the migration itself.
+func recordMigration(ctx context.Context, tx *sql.Tx, name string) error {
+	if _, err := tx.ExecContext(ctx, queryInsertMigration, name); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", name, err)
+	}
+	return nil
+}
+
+// applyColumnMigrations adds any missing columns. Safe to run on every startup.
+func applyColumnMigrations(db *sql.DB, migrations []columnMigration) error {
+	for _, m := range migrations {
+		exists, err := columnExists(db, m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.ddl)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+		if m.backfill != "" {
+			if _, err := db.Exec(m.backfill); err != nil {
+				return fmt.Errorf("failed to backfill column %s.%s: %w", m.table, m.column, err)
+			}
+		}
+		zap.L().Info("Added column", zap.String("table", m.table), zap.String("column", m.column))
+	}
+	return nil
+}
+
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read schema of %s: %w", table, err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			zap.L().Warn("Failed to close rows", zap.Error(err))
+		}
+	}(rows)
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to scan schema of %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("error iterating schema of %s: %w", table, err)
+	}
+	return false, nil
+}
@@ -24,6 +24,12 @@ const (
 		WHERE active = 1
 		ORDER BY created_at`
 
+	queryGetOpenUsers = `
+		SELECT id, name, email, created_at, updated_at
+		FROM users
+		WHERE closed_at IS NULL
+		ORDER BY created_at`
+
 	queryInsertUser = `
 		INSERT OR IGNORE INTO users (id, name, email) VALUES (?, ?, ?)`
 
@@ -37,30 +43,227 @@ const (
 		FROM users
 		WHERE email = ? AND active = 1`
 
+	// Lifecycle queries. Emails stay reserved by deactivated and closed users.
+	queryEmailInUse = `
+		SELECT id FROM users WHERE email = ? AND id != ? LIMIT 1`
+
+	queryUpdateUser = `
+		UPDATE users SET name = ?, email = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND active = 1`
+
+	queryDeactivateUser = `
+		UPDATE users SET active = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND active = 1`
+
+	queryGetUserState = `
+		SELECT active, closed_at FROM users WHERE id = ?`
+
+	queryCloseUser = `
+		UPDATE users SET active = 0, closed_at = ?, updated_at = ?
+		WHERE id = ? AND closed_at IS NULL`
+
+	queryRetireUserAddresses = `
+		UPDATE addresses SET retired_at = ?
+		WHERE user_id = ? AND retired_at IS NULL`
+
+	// queryFindSuspenseAddress finds an address that no longer credits its owner:
+	// retired, or owned by a deactivated or closed user
+	queryFindSuspenseAddress = `
+		SELECT a.user_id, a.asset, a.network, a.retired_at IS NOT NULL
+		FROM addresses a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.address = ? AND a.tag = ? AND (a.retired_at IS NOT NULL OR u.active = 0)
+		ORDER BY a.created_at DESC
+		LIMIT 1`
+
+	// Freeze queries
+	queryGetFreezeStatus = `
+		SELECT frozen, frozen_reason, frozen_by, frozen_note, frozen_updated_at
+		FROM users
+		WHERE id = ? AND active = 1`
+
+	// queryGetUserFreezeStatus reads the freeze whether or not the user is active,
+	// for writes that must see it inside their own transaction
+	queryGetUserFreezeStatus = `
+		SELECT frozen, frozen_reason, frozen_by, frozen_note, frozen_updated_at
+		FROM users
+		WHERE id = ?`
+
+	querySetFreezeStatus = `
+		UPDATE users
+		SET frozen = ?, frozen_reason = ?, frozen_by = ?, frozen_note = ?, frozen_updated_at = ?, updated_at = ?
+		WHERE id = ? AND active = 1 AND frozen = ?`
+
+	queryInsertFreezeEvent = `
+		INSERT INTO user_freeze_events (id, user_id, action, reason, actor, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	queryGetFreezeEvents = `
+		SELECT user_id, action, reason, actor, note, created_at
+		FROM user_freeze_events
+		WHERE user_id = ?
+		ORDER BY created_at, rowid`
+
+	// Withdrawal allowlist queries
+	queryUserIsActive = `
+		SELECT 1 FROM users WHERE id = ? AND active = 1`
+
+	queryInsertAllowlistEntry = `
+		INSERT INTO withdrawal_allowlist (id, user_id, address, tag, asset, network, address_book_state, added_by, note, added_at, active_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	queryRemoveAllowlistEntry = `
+		UPDATE withdrawal_allowlist SET removed_at = ?
+		WHERE user_id = ? AND address = ? AND tag = ? AND removed_at IS NULL`
+
+	queryGetAllowlist = `
+		SELECT user_id, address, tag, asset, network, address_book_state, added_by, note, added_at, active_at
+		FROM withdrawal_allowlist
+		WHERE user_id = ? AND removed_at IS NULL
+		ORDER BY added_at, rowid`
+
+	queryInsertAllowlistEvent = `
+		INSERT INTO withdrawal_allowlist_events (id, user_id, address, tag, action, actor, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	queryGetAllowlistEvents = `
+		SELECT user_id, address, tag, action, actor, note, created_at
+		FROM withdrawal_allowlist_events
+		WHERE user_id = ?
+		ORDER BY created_at, rowid`
+
+	// Lease queries. Renewals and takeovers compare the token so that only one
+	// contender can win, and the fence check is a write so it holds the database
+	// write lock for the rest of the ledger transaction. An expired lease fails the
+	// fence check even before anyone takes it over.
+	queryGetLease = `
+		SELECT name, holder, token, acquired_at, renewed_at, expires_at
+		FROM leases
+		WHERE name = ?`
+
+	queryInsertLease = `
+		INSERT INTO leases (name, holder, token, acquired_at, renewed_at, expires_at)
+		VALUES (?, ?, 1, ?, ?, ?)`
+
+	queryRenewLease = `
+		UPDATE leases SET renewed_at = ?, expires_at = ?
+		WHERE name = ? AND holder = ? AND token = ?`
+
+	queryTakeOverLease = `
+		UPDATE leases SET holder = ?, token = token + 1, acquired_at = ?, renewed_at = ?, expires_at = ?
+		WHERE name = ? AND token = ?`
+
+	queryReleaseLease = `
+		UPDATE leases SET expires_at = ?
+		WHERE name = ? AND holder = ? AND token = ?`
+
+	queryCheckLease = `
+		UPDATE leases SET token = token
+		WHERE name = ? AND holder = ? AND token = ? AND julianday(expires_at) > julianday(?)`
+
+	// Transaction lifecycle queries. State updates compare the current state so a
+	// transition applies only once.
+	queryGetTxState = `
+		SELECT prime_tx_id, kind, state, prime_status, quarantined, created_at, updated_at
+		FROM prime_tx_states
+		WHERE prime_tx_id = ?`
+
+	queryInsertTxState = `
+		INSERT INTO prime_tx_states (prime_tx_id, kind, state, prime_status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	queryAdvanceTxState = `
+		UPDATE prime_tx_states SET state = ?, prime_status = ?, updated_at = ?
+		WHERE prime_tx_id = ? AND state = ?`
+
+	queryQuarantineTxState = `
+		INSERT INTO prime_tx_states (prime_tx_id, kind, state, prime_status, quarantined, created_at, updated_at)
+		VALUES (?, ?, ?, '', 1, ?, ?)
+		ON CONFLICT(prime_tx_id) DO UPDATE SET quarantined = 1, updated_at = excluded.updated_at`
+
+	queryInsertTxTransition = `
+		INSERT INTO prime_tx_transitions (prime_tx_id, kind, from_state, to_state, prime_status, action, outcome, detail, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	queryGetTxHistory = `
+		SELECT prime_tx_id, kind, from_state, to_state, prime_status, action, outcome, detail, created_at
+		FROM prime_tx_transitions
+		WHERE prime_tx_id = ?
+		ORDER BY id`
+
+	queryGetQuarantinedTxs = `
+		SELECT prime_tx_id, kind, state, prime_status, quarantined, created_at, updated_at
+		FROM prime_tx_states
+		WHERE quarantined = 1
+		ORDER BY updated_at`
+
+	// Retry queue queries. The wallet and Prime transaction are stored as JSON.
+	queryFailedTxColumns = `
+		SELECT prime_tx_id, portfolio_id, wallet, payload, state, attempts, last_error,
+		       next_attempt_at, first_failed_at, last_failed_at, updated_by, note
+		FROM failed_transactions`
+
+	queryGetFailedTx = queryFailedTxColumns + `
+		WHERE prime_tx_id = ?`
+
+	queryUpsertFailedTx = `
+		INSERT INTO failed_transactions (prime_tx_id, portfolio_id, wallet, payload, state, attempts, last_error,
+		                                 next_attempt_at, first_failed_at, last_failed_at, updated_by, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(prime_tx_id) DO UPDATE SET
+			portfolio_id = excluded.portfolio_id, wallet = excluded.wallet, payload = excluded.payload,
+			state = excluded.state, attempts = excluded.attempts, last_error = excluded.last_error,
+			next_attempt_at = excluded.next_attempt_at, last_failed_at = excluded.last_failed_at,
+			updated_by = excluded.updated_by, note = excluded.note`
+
+	queryDeleteFailedTx = `
+		DELETE FROM failed_transactions WHERE prime_tx_id = ?`
+
+	queryRequeueFailedTx = `
+		UPDATE failed_transactions SET state = ?, attempts = 0, next_attempt_at = ?, updated_by = ?, note = ?
+		WHERE prime_tx_id = ?`
+
+	queryDiscardFailedTx = `
+		UPDATE failed_transactions SET state = ?, updated_by = ?, note = ?
+		WHERE prime_tx_id = ?`
+
 	// Address queries
 	queryInsertAddress = `
-		INSERT INTO addresses (id, user_id, asset, network, address, wallet_id, account_identifier)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-		RETURNING *`
+		INSERT INTO addresses (id, user_id, asset, network, address, wallet_id, account_identifier, tag)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, user_id, asset, network, address, wallet_id, account_identifier, tag, created_at`
+
+	// Addresses stored before they were normalized on the way in
+	queryGetStoredAddresses = `
+		SELECT id, address FROM addresses`
+
+	queryUpdateAddress = `
+		UPDATE addresses SET address = ? WHERE id = ?`
+
+	queryGetAllowlistAddresses = `
+		SELECT id, address FROM withdrawal_allowlist`
+
+	queryUpdateAllowlistAddress = `
+		UPDATE withdrawal_allowlist SET address = ? WHERE id = ?`
 
 	queryGetUserAddresses = `
-		SELECT id, user_id, asset, network, address, wallet_id, account_identifier, created_at
+		SELECT id, user_id, asset, network, address, wallet_id, account_identifier, tag, created_at
 		FROM addresses
 		WHERE user_id = ? AND asset = ? AND network = ?
 		ORDER BY created_at DESC`
 
 	queryGetAllUserAddresses = `
-		SELECT id, user_id, asset, network, address, wallet_id, account_identifier, created_at
+		SELECT id, user_id, asset, network, address, wallet_id, account_identifier, tag, created_at
 		FROM addresses
 		WHERE user_id = ?
 		ORDER BY asset, created_at DESC`
 
 	queryFindUserByAddress = `
 		SELECT u.id, u.name, u.email, u.created_at, u.updated_at,
-		       a.id, a.user_id, a.asset, a.network, a.address, a.wallet_id, a.account_identifier, a.created_at
+		       a.id, a.user_id, a.asset, a.network, a.address, a.wallet_id, a.account_identifier, a.tag, a.created_at
 		FROM users u
 		JOIN addresses a ON u.id = a.user_id
-		WHERE LOWER(a.address) = LOWER(?) AND u.active = 1`
+		WHERE a.address = ? AND a.tag = ? AND u.active = 1 AND a.retired_at IS NULL`
 
 	// Balance queries
 	queryGetBalance = `
@@ -74,6 +277,20 @@ const (
 		WHERE user_id = ? AND balance != 0
 		ORDER BY asset`
 
+	// Rows written before the on-chain hash was stored as prime_tx_id metadata
+	// carry it in tx_hash or under the legacy transaction_id key
+	queryBackfillPrimeTxIdMetadata = `
+		INSERT OR IGNORE INTO transaction_metadata (transaction_id, key, value)
+		SELECT id, 'prime_tx_id', tx_hash FROM transactions WHERE tx_hash != ''
+		UNION ALL
+		SELECT transaction_id, 'prime_tx_id', value FROM transaction_metadata WHERE key = 'transaction_id'`
+
+	queryGetAmountsAt = `
+		SELECT amount
+		FROM transactions
+		WHERE user_id = ? AND asset = ? AND status = 'confirmed'
+		  AND julianday(effective_at) <= julianday(?)`
+
 	queryReconcileBalance = `
 		SELECT COALESCE(SUM(amount), 0) as calculated_balance
 		FROM transactions 
@@ -95,10 +312,12 @@ const (
 	queryInsertTransaction = `
 		INSERT INTO transactions (
 			id, user_id, asset, transaction_type, amount, balance_before, balance_after,
-			external_transaction_id, address, reference, status, created_at, processed_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			external_transaction_id, address, reference, status, created_at, processed_at,
+			effective_at, network, source_address, tx_hash, network_fees, fees
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		RETURNING id, user_id, asset, transaction_type, amount, balance_before, balance_after,
-		          external_transaction_id, address, reference, status, created_at, processed_at`
+		          external_transaction_id, address, reference, status, created_at, processed_at,
+		          effective_at, network, source_address, tx_hash, network_fees, fees`
 
 	queryUpdateAccountBalance = `
 		UPDATE account_balances 
@@ -106,19 +325,101 @@ const (
 		WHERE user_id = ? AND asset = ? AND version = ?`
 
 	queryInsertJournalEntry = `
-		INSERT INTO journal_entries (id, transaction_id, account_type, account_id, debit_amount, credit_amount)
-		VALUES (?, ?, ?, ?, ?, ?)`
+		INSERT INTO journal_entries (id, transaction_id, account_type, account_id, asset, debit_amount, credit_amount)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
 
 	queryGetTransactionHistory = `
 		SELECT id, user_id, asset, transaction_type, amount, balance_before, balance_after,
-		       external_transaction_id, address, reference, status, created_at, processed_at
+		       external_transaction_id, address, reference, status, created_at, processed_at,
+		       effective_at, network, source_address, tx_hash, network_fees, fees
 		FROM transactions 
 		WHERE user_id = ? AND asset = ?
-		ORDER BY created_at DESC
+		ORDER BY julianday(effective_at) DESC, julianday(created_at) DESC
 		LIMIT ? OFFSET ?`
 
 	queryGetMostRecentTransactionTime = `
-		SELECT MAX(created_at) 
+		SELECT effective_at
 		FROM transactions 
-		WHERE external_transaction_id IS NOT NULL AND external_transaction_id != ''`
+		WHERE external_transaction_id IS NOT NULL AND external_transaction_id != ''
+		ORDER BY julianday(effective_at) DESC
+		LIMIT 1`
+
+	queryInsertTransactionMetadata = `
+		INSERT OR REPLACE INTO transaction_metadata (transaction_id, key, value) VALUES (?, ?, ?)`
+
+	queryGetTransactionMetadata = `
+		SELECT key, value FROM transaction_metadata WHERE transaction_id = ?`
+
+	queryFindTransactionsByMetadata = `
+		SELECT id, user_id, asset, transaction_type, amount, balance_before, balance_after,
+		       external_transaction_id, address, reference, status, created_at, processed_at,
+		       effective_at, network, source_address, tx_hash, network_fees, fees
+		FROM transactions
+		WHERE `
+
+	querySearchTransactions = `
+		SELECT id, user_id, asset, transaction_type, amount, balance_before, balance_after,
+		       external_transaction_id, address, reference, status, created_at, processed_at,
+		       effective_at, network, source_address, tx_hash, network_fees, fees
+		FROM transactions`
+
+	// %s is replaced with one placeholder per transaction id
+	queryGetJournalEntriesForTransactions = `
+		SELECT transaction_id, account_id, CAST(debit_amount AS TEXT), CAST(credit_amount AS TEXT)
+		FROM journal_entries
+		WHERE transaction_id IN (%s)
+		ORDER BY transaction_id, CASE WHEN debit_amount > 0 THEN 0 ELSE 1 END, account_id`
+
+	queryGetTrialBalanceEntries = `
+		SELECT j.account_id, j.asset, CAST(j.debit_amount AS TEXT), CAST(j.credit_amount AS TEXT)
+		FROM journal_entries j
+		JOIN transactions t ON t.id = j.transaction_id
+		WHERE julianday(t.effective_at) <= julianday(?)`
+
+	// Migration queries
+	queryGetMigration = `
+		SELECT applied_at FROM schema_migrations WHERE name = ?`
+
+	queryInsertMigration = `
+		INSERT INTO schema_migrations (name) VALUES (?)`
+
+	queryDeleteLegacyJournalEntries = `
+		DELETE FROM journal_entries WHERE account_type IN ('user_asset', 'system_liability')`
+
+	queryGetTransactionsWithoutJournal = `
+		SELECT t.id, t.user_id, t.asset, t.transaction_type, CAST(t.amount AS TEXT), COALESCE(t.reference, ''),
+		       COALESCE((SELECT value FROM transaction_metadata m WHERE m.transaction_id = t.id AND m.key = 'prime_wallet_id'), '')
+		FROM transactions t
+		WHERE NOT EXISTS (SELECT 1 FROM journal_entries j WHERE j.transaction_id = t.id)
+		ORDER BY t.rowid`
+
+	// Hold queries
+	queryHoldColumns = `id, user_id, asset, amount, captured, status, reason, expires_at, created_at, settled_at`
+
+	queryInsertHold = `
+		INSERT INTO holds (id, user_id, asset, amount, status, reason, expires_at, created_at)
+		VALUES (?, ?, ?, ?, 'active', ?, ?, ?)`
+
+	queryGetHold = `
+		SELECT ` + queryHoldColumns + `
+		FROM holds
+		WHERE id = ?`
+
+	// Active holds that have not passed their expiry at the given time
+	queryGetActiveHolds = `
+		SELECT ` + queryHoldColumns + `
+		FROM holds
+		WHERE user_id = ? AND (? = '' OR asset = ?) AND status = 'active'
+		  AND (expires_at IS NULL OR julianday(expires_at) > julianday(?))
+		ORDER BY created_at, id`
+
+	queryGetExpiredHolds = `
+		SELECT ` + queryHoldColumns + `
+		FROM holds
+		WHERE status = 'active' AND expires_at IS NOT NULL AND julianday(expires_at) <= julianday(?)
+		ORDER BY expires_at, id`
+
+	querySettleHold = `
+		UPDATE holds SET status = ?, captured = ?, settled_at = ?
+		WHERE id = ? AND status = 'active'`
 )
@@ -0,0 +1,206 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+)
+
+// RecordTxFailure queues a failed transaction, or counts another failed
+// attempt, moving it to the dead-letter queue once the policy is exhausted.
+// It is fenced like ledger writes.
+func (s *Service) RecordTxFailure(ctx context.Context, params store.RecordTxFailureParams) (*models.FailedTransaction, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkFence(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	existing, err := scanFailedTx(tx.QueryRowContext(ctx, queryGetFailedTx, params.Transaction.Id))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get retry entry for %s: %w", params.Transaction.Id, err)
+	}
+	failed := params.Apply(existing, time.Now().UTC())
+
+	wallet, err := json.Marshal(failed.Wallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode wallet: %w", err)
+	}
+	payload, err := json.Marshal(failed.Transaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, queryUpsertFailedTx, failed.PrimeTxId, failed.PortfolioId, string(wallet), string(payload),
+		failed.State, failed.Attempts, failed.LastError, failed.NextAttemptAt, failed.FirstFailedAt, failed.LastFailedAt,
+		failed.UpdatedBy, failed.Note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record failure of %s: %w", failed.PrimeTxId, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return failed, nil
+}
+
+// GetFailedTx returns a transaction's retry queue entry, or nil if it has none.
+func (s *Service) GetFailedTx(ctx context.Context, primeTxId string) (*models.FailedTransaction, error) {
+	failed, err := scanFailedTx(s.db.QueryRowContext(ctx, queryGetFailedTx, primeTxId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retry entry for %s: %w", primeTxId, err)
+	}
+	return failed, nil
+}
+
+// GetFailedTxs returns the retry queue entries matching query, oldest failure first.
+func (s *Service) GetFailedTxs(ctx context.Context, query store.FailedTxQuery) ([]models.FailedTransaction, error) {
+	var where []string
+	var args []any
+	add := func(clause string, values ...any) {
+		where = append(where, clause)
+		args = append(args, values...)
+	}
+	if query.PortfolioId != "" {
+		add("portfolio_id = ?", query.PortfolioId)
+	}
+	if query.State != "" {
+		add("state = ?", query.State)
+	}
+	if !query.DueBy.IsZero() {
+		add("julianday(next_attempt_at) <= julianday(?)", query.DueBy.UTC())
+	}
+
+	var sqlQuery strings.Builder
+	sqlQuery.WriteString(queryFailedTxColumns)
+	if len(where) > 0 {
+		sqlQuery.WriteString(" WHERE ")
+		sqlQuery.WriteString(strings.Join(where, " AND "))
+	}
+	sqlQuery.WriteString(" ORDER BY julianday(first_failed_at), prime_tx_id")
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retry queue: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.FailedTransaction
+	for rows.Next() {
+		failed, err := scanFailedTx(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retry entry: %w", err)
+		}
+		entries = append(entries, *failed)
+	}
+	return entries, rows.Err()
+}
+
+// ResolveFailedTx removes a transaction from the retry queue once it has been
+// processed, fenced like ledger writes.
+func (s *Service) ResolveFailedTx(ctx context.Context, primeTxId string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkFence(ctx, tx); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, queryDeleteFailedTx, primeTxId)
+	if err != nil {
+		return fmt.Errorf("failed to resolve retry entry for %s: %w", primeTxId, err)
+	}
+	if err := requireRow(result, primeTxId); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RequeueFailedTx moves a dead or discarded transaction back into the queue,
+// due now, with a fresh set of attempts.
+func (s *Service) RequeueFailedTx(ctx context.Context, params store.UpdateFailedTxParams) (*models.FailedTransaction, error) {
+	result, err := s.db.ExecContext(ctx, queryRequeueFailedTx, models.RetryStateQueued, time.Now().UTC(),
+		params.Actor, params.Note, params.PrimeTxId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue %s: %w", params.PrimeTxId, err)
+	}
+	if err := requireRow(result, params.PrimeTxId); err != nil {
+		return nil, err
+	}
+	return s.GetFailedTx(ctx, params.PrimeTxId)
+}
+
+// DiscardFailedTx drops a transaction from the queue for good. The entry is
+// kept, so the listener does not pick the transaction up again.
+func (s *Service) DiscardFailedTx(ctx context.Context, params store.UpdateFailedTxParams) error {
+	result, err := s.db.ExecContext(ctx, queryDiscardFailedTx, models.RetryStateDiscarded, params.Actor, params.Note, params.PrimeTxId)
+	if err != nil {
+		return fmt.Errorf("failed to discard %s: %w", params.PrimeTxId, err)
+	}
+	return requireRow(result, params.PrimeTxId)
+}
+
+// requireRow fails with store.ErrFailedTxNotFound unless the statement changed a row
+func requireRow(result sql.Result, primeTxId string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update retry entry for %s: %w", primeTxId, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", store.ErrFailedTxNotFound, primeTxId)
+	}
+	return nil
+}
+
+func scanFailedTx(row rowScanner) (*models.FailedTransaction, error) {
+	var f models.FailedTransaction
+	var wallet, payload string
+	if err := row.Scan(&f.PrimeTxId, &f.PortfolioId, &wallet, &payload, &f.State, &f.Attempts, &f.LastError,
+		&f.NextAttemptAt, &f.FirstFailedAt, &f.LastFailedAt, &f.UpdatedBy, &f.Note); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(wallet), &f.Wallet); err != nil {
+		return nil, fmt.Errorf("failed to decode wallet of %s: %w", f.PrimeTxId, err)
+	}
+	if err := json.Unmarshal([]byte(payload), &f.Transaction); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction %s: %w", f.PrimeTxId, err)
+	}
+	return &f, nil
+}
@@ -0,0 +1,148 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+)
+
+func TestRetryQueue(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if failed, err := service.GetFailedTx(ctx, "tx1"); err != nil || failed != nil {
+		t.Fatalf("Expected no entry before a failure, got %+v, %v", failed, err)
+	}
+
+	params := store.RecordTxFailureParams{
+		PortfolioId: "p1",
+		Wallet:      models.WalletInfo{Id: "w1", AssetSymbol: "USDC"},
+		Transaction: models.PrimeTransaction{Id: "tx1", Type: "DEPOSIT", Status: "TRANSACTION_IMPORTED", Amount: "5", Symbol: "USDC"},
+		Error:       "no user for address",
+		Policy:      models.RetryPolicy{BaseDelay: time.Minute, MaxDelay: time.Hour, MaxAttempts: 2},
+	}
+	failed, err := service.RecordTxFailure(ctx, params)
+	if err != nil {
+		t.Fatalf("RecordTxFailure failed: %v", err)
+	}
+	if failed.State != models.RetryStateQueued || failed.Attempts != 1 {
+		t.Errorf("Expected queued entry after one failure, got %+v", failed)
+	}
+
+	// Not due until the backoff has passed
+	due, err := service.GetFailedTxs(ctx, store.FailedTxQuery{PortfolioId: "p1", DueBy: time.Now().UTC()})
+	if err != nil {
+		t.Fatalf("GetFailedTxs failed: %v", err)
+	}
+	if len(due) != 0 {
+		t.Errorf("Expected nothing due yet, got %d", len(due))
+	}
+	due, err = service.GetFailedTxs(ctx, store.FailedTxQuery{PortfolioId: "p1", DueBy: time.Now().UTC().Add(2 * time.Minute)})
+	if err != nil || len(due) != 1 {
+		t.Fatalf("Expected one due entry, got %d, %v", len(due), err)
+	}
+
+	params.Error = "still no user for address"
+	if failed, err = service.RecordTxFailure(ctx, params); err != nil {
+		t.Fatalf("RecordTxFailure failed: %v", err)
+	}
+	if failed.State != models.RetryStateDead || failed.Attempts != 2 {
+		t.Errorf("Expected dead letter after max attempts, got %+v", failed)
+	}
+
+	stored, err := service.GetFailedTx(ctx, "tx1")
+	if err != nil || stored == nil {
+		t.Fatalf("GetFailedTx failed: %+v, %v", stored, err)
+	}
+	if stored.LastError != "still no user for address" || stored.Transaction.Amount != "5" || stored.Wallet.Id != "w1" {
+		t.Errorf("Expected the full payload and last error to be kept, got %+v", stored)
+	}
+
+	dead, err := service.GetFailedTxs(ctx, store.FailedTxQuery{State: models.RetryStateDead})
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("Expected one dead letter, got %d, %v", len(dead), err)
+	}
+	if other, err := service.GetFailedTxs(ctx, store.FailedTxQuery{PortfolioId: "p2"}); err != nil || len(other) != 0 {
+		t.Errorf("Expected no entries for another portfolio, got %d, %v", len(other), err)
+	}
+
+	requeued, err := service.RequeueFailedTx(ctx, store.UpdateFailedTxParams{PrimeTxId: "tx1", Actor: "ops", Note: "address assigned"})
+	if err != nil {
+		t.Fatalf("RequeueFailedTx failed: %v", err)
+	}
+	if requeued.State != models.RetryStateQueued || requeued.Attempts != 0 || requeued.UpdatedBy != "ops" || requeued.NextAttemptAt.After(time.Now().UTC()) {
+		t.Errorf("Expected a fresh entry due now, got %+v", requeued)
+	}
+
+	if err := service.ResolveFailedTx(ctx, "tx1"); err != nil {
+		t.Fatalf("ResolveFailedTx failed: %v", err)
+	}
+	if failed, err := service.GetFailedTx(ctx, "tx1"); err != nil || failed != nil {
+		t.Errorf("Expected no entry after resolving, got %+v, %v", failed, err)
+	}
+	if err := service.ResolveFailedTx(ctx, "tx1"); !errors.Is(err, store.ErrFailedTxNotFound) {
+		t.Errorf("Expected ErrFailedTxNotFound, got %v", err)
+	}
+}
+
+func TestDiscardFailedTx(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	params := store.RecordTxFailureParams{
+		PortfolioId: "p1",
+		Transaction: models.PrimeTransaction{Id: "tx1", Type: "WITHDRAWAL", Status: "TRANSACTION_DONE"},
+		Error:       "unknown user",
+		Policy:      models.RetryPolicy{BaseDelay: time.Minute, MaxAttempts: 1},
+	}
+	if _, err := service.RecordTxFailure(ctx, params); err != nil {
+		t.Fatalf("RecordTxFailure failed: %v", err)
+	}
+
+	discard := store.UpdateFailedTxParams{PrimeTxId: "tx1", Actor: "ops", Note: "test withdrawal"}
+	if err := service.DiscardFailedTx(ctx, discard); err != nil {
+		t.Fatalf("DiscardFailedTx failed: %v", err)
+	}
+	failed, err := service.GetFailedTx(ctx, "tx1")
+	if err != nil || failed == nil {
+		t.Fatalf("Expected the discarded entry to be kept, got %+v, %v", failed, err)
+	}
+	if failed.State != models.RetryStateDiscarded || failed.Note != "test withdrawal" {
+		t.Errorf("Unexpected discarded entry %+v", failed)
+	}
+
+	// A later failure counts the attempt but keeps it out of the queue
+	if failed, err = service.RecordTxFailure(ctx, params); err != nil {
+		t.Fatalf("RecordTxFailure failed: %v", err)
+	}
+	if failed.State != models.RetryStateDiscarded || failed.Attempts != 2 {
+		t.Errorf("Expected the entry to stay discarded, got %+v", failed)
+	}
+
+	discard.PrimeTxId = "missing"
+	if err := service.DiscardFailedTx(ctx, discard); !errors.Is(err, store.ErrFailedTxNotFound) {
+		t.Errorf("Expected ErrFailedTxNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const transactionTypeRewardDistribution = "reward-distribution"
+
+// DistributeReward moves a reward from the platform user to each allocated user in a
+// single database transaction. The platform leg carries the distribution id as its
+// external id and each user leg {distribution id}-{user id}, so a re-run is rejected
+// as a duplicate before anything is written. A frozen user's share is credited to the
+// quarantine platform user instead, tagged with the user it was meant for.
+func (s *Service) DistributeReward(ctx context.Context, params store.RewardDistributionParams) error {
+	if len(params.Allocations) == 0 {
+		return fmt.Errorf("reward distribution %s has no allocations", params.DistributionId)
+	}
+
+	total := decimal.Zero
+	for _, a := range params.Allocations {
+		if !a.Amount.IsPositive() {
+			return fmt.Errorf("allocation for user %s must be positive, got %s", a.UserId, a.Amount)
+		}
+		total = total.Add(a.Amount)
+	}
+
+	metadata := mergeMetadata(params.Metadata, map[string]string{
+		"distribution_id": params.DistributionId,
+		"reward_tx_id":    params.RewardTxId,
+	})
+	batch := make([]ProcessTransactionParams, 0, len(params.Allocations)+1)
+	batch = append(batch, ProcessTransactionParams{
+		UserId:          "prime-platform",
+		Asset:           params.Asset,
+		TransactionType: transactionTypeRewardDistribution,
+		Amount:          total.Neg(),
+		ExternalTxId:    params.DistributionId,
+		Reference:       fmt.Sprintf("REWARD DISTRIBUTION: %s %s to %d users", total, params.Asset, len(params.Allocations)),
+		Metadata:        metadata,
+		Counterparty:    accountRewardsDistribution,
+	})
+	for _, a := range params.Allocations {
+		batch = append(batch, ProcessTransactionParams{
+			UserId:          a.UserId,
+			Asset:           params.Asset,
+			TransactionType: transactionTypeRewardDistribution,
+			Amount:          a.Amount,
+			ExternalTxId:    params.DistributionId + "-" + a.UserId,
+			Reference:       fmt.Sprintf("REWARD: %s %s from %s", a.Amount, params.Asset, params.RewardTxId),
+			Metadata:        metadata,
+			Counterparty:    accountRewardsDistribution,
+			// A frozen user's share waits in quarantine like a deposit would
+			QuarantineIfFrozen: true,
+		})
+	}
+
+	if _, err := s.subledger.ProcessTransactionBatch(ctx, batch); err != nil {
+		return fmt.Errorf("failed to distribute reward: %w", err)
+	}
+
+	zap.L().Info("Reward distributed",
+		zap.String("distribution_id", params.DistributionId),
+		zap.String("reward_tx_id", params.RewardTxId),
+		zap.String("asset", params.Asset),
+		zap.String("total", total.String()),
+		zap.Int("users", len(params.Allocations)))
+	return nil
+}
+
+// HasRewardDistribution reports whether a distribution with this id has been posted.
+func (s *Service) HasRewardDistribution(ctx context.Context, distributionId string) (bool, error) {
+	var id string
+	err := s.db.QueryRowContext(ctx, queryCheckDuplicateTransaction, distributionId).Scan(&id)
+	if err == nil {
+		return true, nil
+	}
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check reward distribution: %w", err)
+}
@@ -0,0 +1,160 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDistributeReward_PostsAtomicallyAndOnce(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, "user2", "Second User", "second@example.com"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if err := service.RecordPlatformTransaction(ctx, store.PlatformTransactionParams{
+		TransactionId: "reward-1", Type: "REWARD", Status: "TRANSACTION_DONE", Symbol: "ETH", Amount: "1", WalletId: "w-eth",
+	}); err != nil {
+		t.Fatalf("RecordPlatformTransaction failed: %v", err)
+	}
+
+	params := store.RewardDistributionParams{
+		DistributionId: "reward-distribution-reward-1",
+		RewardTxId:     "reward-1",
+		Asset:          "ETH",
+		Allocations: []store.RewardAllocation{
+			{UserId: "user1", Amount: decimal.RequireFromString("0.6")},
+			{UserId: "user2", Amount: decimal.RequireFromString("0.3")},
+		},
+	}
+	if err := service.DistributeReward(ctx, params); err != nil {
+		t.Fatalf("DistributeReward failed: %v", err)
+	}
+
+	exists, err := service.HasRewardDistribution(ctx, params.DistributionId)
+	if err != nil || !exists {
+		t.Fatalf("Expected distribution to be recorded, got %v, %v", exists, err)
+	}
+
+	lines, err := service.GetTrialBalance(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	assertBalanced(t, lines)
+	eth := trialBalanceByAccount(lines, "ETH")
+	if net := eth[accountRewardsDistribution].Net(); !net.IsZero() {
+		t.Errorf("Expected the distribution clearing account to net to zero, got %s", net)
+	}
+	if net := eth["users:prime-platform"].Net(); !net.Equal(decimal.RequireFromString("0.1")) {
+		t.Errorf("Expected the platform to keep 0.1 commission, got %s", net)
+	}
+	if net := eth["users:user2"].Net(); !net.Equal(decimal.RequireFromString("0.3")) {
+		t.Errorf("Expected user2 to receive 0.3, got %s", net)
+	}
+
+	if err := service.DistributeReward(ctx, params); !errors.Is(err, store.ErrDuplicateTransaction) {
+		t.Fatalf("Expected ErrDuplicateTransaction on re-run, got %v", err)
+	}
+	balance, err := service.GetUserBalance(ctx, "user1", "ETH")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !balance.Equal(decimal.RequireFromString("0.6")) {
+		t.Errorf("Expected user1 balance 0.6 after rejected re-run, got %s", balance)
+	}
+}
+
+func TestDistributeReward_RollsBackOnDuplicateLeg(t *testing.T) {
+	service, cleanup := setupBalanceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	// A pre-existing user leg makes the whole batch fail before the platform leg commits
+	if _, err := service.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+		UserId: "user1", Asset: "ETH", TransactionType: transactionTypeRewardDistribution,
+		Amount: decimal.RequireFromString("0.1"), ExternalTxId: "dist-1-user1",
+	}); err != nil {
+		t.Fatalf("ProcessTransaction failed: %v", err)
+	}
+
+	err := service.DistributeReward(ctx, store.RewardDistributionParams{
+		DistributionId: "dist-1",
+		RewardTxId:     "reward-1",
+		Asset:          "ETH",
+		Allocations:    []store.RewardAllocation{{UserId: "user1", Amount: decimal.RequireFromString("0.5")}},
+	})
+	if !errors.Is(err, store.ErrDuplicateTransaction) {
+		t.Fatalf("Expected ErrDuplicateTransaction, got %v", err)
+	}
+	if exists, _ := service.HasRewardDistribution(ctx, "dist-1"); exists {
+		t.Error("Expected the platform leg to be rolled back")
+	}
+}
+
+func TestDistributeReward_QuarantinesFrozenUser(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, "user2", "Second User", "second@example.com"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := service.FreezeUser(ctx, store.FreezeParams{UserId: "user2", Reason: models.FreezeReasonFraud, Actor: "ops"}); err != nil {
+		t.Fatalf("FreezeUser failed: %v", err)
+	}
+	if err := service.RecordPlatformTransaction(ctx, store.PlatformTransactionParams{
+		TransactionId: "reward-1", Type: "REWARD", Status: "TRANSACTION_DONE", Symbol: "ETH", Amount: "1", WalletId: "w-eth",
+	}); err != nil {
+		t.Fatalf("RecordPlatformTransaction failed: %v", err)
+	}
+
+	if err := service.DistributeReward(ctx, store.RewardDistributionParams{
+		DistributionId: "reward-distribution-reward-1",
+		RewardTxId:     "reward-1",
+		Asset:          "ETH",
+		Allocations: []store.RewardAllocation{
+			{UserId: "user1", Amount: decimal.RequireFromString("0.6")},
+			{UserId: "user2", Amount: decimal.RequireFromString("0.3")},
+		},
+	}); err != nil {
+		t.Fatalf("DistributeReward failed: %v", err)
+	}
+
+	for user, want := range map[string]string{"user1": "0.6", "user2": "0", store.QuarantineUserId(""): "0.3"} {
+		balance, err := service.GetUserBalance(ctx, user, "ETH")
+		if err != nil {
+			t.Fatalf("GetUserBalance failed: %v", err)
+		}
+		if !balance.Equal(decimal.RequireFromString(want)) {
+			t.Errorf("Expected %s to hold %s ETH, got %s", user, want, balance)
+		}
+	}
+	txs, err := service.FindTransactionsByMetadata(ctx, map[string]string{"quarantined_user_id": "user2"}, 10)
+	if err != nil || len(txs) != 1 || txs[0].ExternalTransactionId != "reward-distribution-reward-1-user2" {
+		t.Errorf("Expected the quarantined share to be tagged with the user, got %+v, %v", txs, err)
+	}
+}
@@ -0,0 +1,208 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 1000
+)
+
+// searchCursor is the keyset position after the last row of a page. Rows are
+// ordered by (effective_at, id) descending, so the next page starts strictly below it.
+type searchCursor struct {
+	EffectiveAt string `json:"t"`
+	Id          string `json:"id"`
+}
+
+func encodeSearchCursor(tx models.Transaction) string {
+	b, _ := json.Marshal(searchCursor{EffectiveAt: tx.EffectiveAt.UTC().Format(time.RFC3339Nano), Id: tx.Id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSearchCursor(cursor string) (*searchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var c searchCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.EffectiveAt == "" || c.Id == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// SearchTransactions returns transactions matching every set filter in query, newest
+// effective time first, using keyset pagination on (effective_at, id).
+func (s *SubledgerService) SearchTransactions(ctx context.Context, query store.TransactionQuery) (*store.TransactionPage, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	var where []string
+	var args []any
+	add := func(clause string, values ...any) {
+		where = append(where, clause)
+		args = append(args, values...)
+	}
+
+	if query.UserId != "" {
+		add("user_id = ?", query.UserId)
+	}
+	if query.Asset != "" {
+		add("asset = ?", query.Asset)
+	}
+	if query.Network != "" {
+		add("network = ?", query.Network)
+	}
+	if query.Type != "" {
+		add("transaction_type = ?", query.Type)
+	}
+	if query.Status != "" {
+		add("status = ?", query.Status)
+	}
+	if !query.From.IsZero() {
+		add("julianday(effective_at) >= julianday(?)", query.From.UTC())
+	}
+	if !query.To.IsZero() {
+		add("julianday(effective_at) < julianday(?)", query.To.UTC())
+	}
+	// amount is stored as REAL, so the bounds are applied loosely here and
+	// exactly as decimals once the rows are scanned
+	if query.MinAmount != nil {
+		add("ABS(amount) >= ?", query.MinAmount.InexactFloat64()*(1-amountSlack))
+	}
+	if query.MaxAmount != nil {
+		add("ABS(amount) <= ?", query.MaxAmount.InexactFloat64()*(1+amountSlack))
+	}
+	if query.ExternalTxId != "" {
+		// Derived legs (conversion -src/-dst, -reversal, ...) share the Prime id as prefix
+		add(`(external_transaction_id = ? OR external_transaction_id LIKE ? || '-%' ESCAPE '\')`, query.ExternalTxId, escapeLike(query.ExternalTxId))
+	}
+	if query.TxHash != "" {
+		add("(tx_hash = ? OR id IN (SELECT transaction_id FROM transaction_metadata WHERE key = 'prime_tx_id' AND value = ?))",
+			query.TxHash, query.TxHash)
+	}
+	if query.Address != "" {
+		add(`(LOWER(address) = LOWER(?) OR LOWER(source_address) = LOWER(?)
+			OR id IN (SELECT transaction_id FROM transaction_metadata
+			          WHERE key IN ('destination_address', 'deposit_address') AND LOWER(value) = LOWER(?)))`,
+			query.Address, query.Address, query.Address)
+	}
+	var cursor *searchCursor
+	if query.Cursor != "" {
+		c, err := decodeSearchCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = c
+	}
+
+	zap.L().Debug("Searching transactions", zap.Int("filters", len(where)), zap.Int("limit", limit))
+
+	// Fetch one extra row to know whether another page exists. Rows the exact
+	// amount check drops are made up from the following rows.
+	var transactions []models.Transaction
+	for len(transactions) <= limit {
+		batch, err := s.searchBatch(ctx, where, args, cursor, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range batch {
+			if amountInRange(tx, query) {
+				transactions = append(transactions, tx)
+			}
+		}
+		if len(batch) <= limit {
+			break
+		}
+		cursor = &searchCursor{EffectiveAt: batch[len(batch)-1].EffectiveAt.UTC().Format(time.RFC3339Nano), Id: batch[len(batch)-1].Id}
+	}
+
+	page := &store.TransactionPage{}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		page.NextCursor = encodeSearchCursor(transactions[limit-1])
+	}
+	if err := s.loadMetadata(ctx, transactions); err != nil {
+		return nil, err
+	}
+	page.Transactions = transactions
+
+	return page, nil
+}
+
+// searchBatch returns up to limit rows matching where, below cursor if set.
+func (s *SubledgerService) searchBatch(ctx context.Context, where []string, args []any, cursor *searchCursor, limit int) ([]models.Transaction, error) {
+	if cursor != nil {
+		where = append(where[:len(where):len(where)],
+			"(julianday(effective_at) < julianday(?) OR (julianday(effective_at) = julianday(?) AND id < ?))")
+		args = append(args[:len(args):len(args)], cursor.EffectiveAt, cursor.EffectiveAt, cursor.Id)
+	}
+
+	var sqlQuery strings.Builder
+	sqlQuery.WriteString(querySearchTransactions)
+	if len(where) > 0 {
+		sqlQuery.WriteString(" WHERE ")
+		sqlQuery.WriteString(strings.Join(where, " AND "))
+	}
+	sqlQuery.WriteString(" ORDER BY julianday(effective_at) DESC, id DESC LIMIT ?")
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery.String(), append(args[:len(args):len(args)], limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	return scanTransactions(rows)
+}
+
+// amountSlack widens the REAL amount bounds so no row within the exact decimal
+// bounds is dropped by float rounding.
+const amountSlack = 1e-9
+
+// amountInRange applies the amount filters exactly.
+func amountInRange(tx models.Transaction, query store.TransactionQuery) bool {
+	amount := tx.Amount.Abs()
+	if query.MinAmount != nil && amount.LessThan(*query.MinAmount) {
+		return false
+	}
+	if query.MaxAmount != nil && amount.GreaterThan(*query.MaxAmount) {
+		return false
+	}
+	return true
+}
+
+// escapeLike escapes LIKE wildcards so value matches literally with ESCAPE '\'.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
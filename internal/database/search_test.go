@@ -0,0 +1,178 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSearchTransactions(t *testing.T) {
+	service, cleanup := setupTestDb(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	deposits := []ProcessTransactionParams{
+		{UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(10), ExternalTxId: "tx-1", Address: "0xAbC", Network: "base-mainnet", TransactionTime: day},
+		{UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(20), ExternalTxId: "tx-2", Network: "ethereum-mainnet", TransactionTime: day.Add(time.Hour)},
+		{UserId: "user1", Asset: "ETH", TransactionType: "deposit", Amount: decimal.NewFromInt(1), ExternalTxId: "tx-3", TxHash: "0xhash", TransactionTime: day.Add(2 * time.Hour)},
+		{UserId: "user2", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(30), ExternalTxId: "tx-4", TransactionTime: day.Add(24 * time.Hour)},
+		{UserId: "user1", Asset: "USDC", TransactionType: "withdrawal", Amount: decimal.NewFromInt(-5), ExternalTxId: "tx-5", TransactionTime: day.Add(25 * time.Hour)},
+	}
+	for _, p := range deposits {
+		if _, err := service.ProcessTransaction(ctx, p); err != nil {
+			t.Fatalf("ProcessTransaction %s failed: %v", p.ExternalTxId, err)
+		}
+	}
+
+	ids := func(page *store.TransactionPage) []string {
+		var out []string
+		for _, tx := range page.Transactions {
+			out = append(out, tx.ExternalTransactionId)
+		}
+		return out
+	}
+
+	minAmount := decimal.NewFromInt(5)
+	maxAmount := decimal.NewFromInt(20)
+	tests := []struct {
+		name     string
+		query    store.TransactionQuery
+		expected []string
+	}{
+		{"all newest first", store.TransactionQuery{}, []string{"tx-5", "tx-4", "tx-3", "tx-2", "tx-1"}},
+		{"user and asset", store.TransactionQuery{UserId: "user1", Asset: "USDC"}, []string{"tx-5", "tx-2", "tx-1"}},
+		{"type", store.TransactionQuery{Type: "withdrawal"}, []string{"tx-5"}},
+		{"network", store.TransactionQuery{Network: "base-mainnet"}, []string{"tx-1"}},
+		{"time range", store.TransactionQuery{From: day, To: day.Add(24 * time.Hour)}, []string{"tx-3", "tx-2", "tx-1"}},
+		{"absolute amount range", store.TransactionQuery{MinAmount: &minAmount, MaxAmount: &maxAmount}, []string{"tx-5", "tx-2", "tx-1"}},
+		{"hash", store.TransactionQuery{TxHash: "0xhash"}, []string{"tx-3"}},
+		{"address case-insensitive", store.TransactionQuery{Address: "0xabc"}, []string{"tx-1"}},
+		{"external id", store.TransactionQuery{ExternalTxId: "tx-4"}, []string{"tx-4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := service.SearchTransactions(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("SearchTransactions failed: %v", err)
+			}
+			got := ids(page)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("Expected %v, got %v", tt.expected, got)
+				}
+			}
+			if page.NextCursor != "" {
+				t.Errorf("Expected no next cursor, got %q", page.NextCursor)
+			}
+		})
+	}
+
+	// Page through everything two at a time.
+	var paged []string
+	query := store.TransactionQuery{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("Pagination did not terminate")
+		}
+		page, err := service.SearchTransactions(ctx, query)
+		if err != nil {
+			t.Fatalf("SearchTransactions failed: %v", err)
+		}
+		paged = append(paged, ids(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	expected := []string{"tx-5", "tx-4", "tx-3", "tx-2", "tx-1"}
+	if len(paged) != len(expected) {
+		t.Fatalf("Expected %v across pages, got %v", expected, paged)
+	}
+	for i := range paged {
+		if paged[i] != expected[i] {
+			t.Fatalf("Expected %v across pages, got %v", expected, paged)
+		}
+	}
+
+	if _, err := service.SearchTransactions(ctx, store.TransactionQuery{Cursor: "not-a-cursor"}); err == nil {
+		t.Error("Expected error for invalid cursor")
+	}
+}
+
+func TestSearchTransactions_ExactFilters(t *testing.T) {
+	service, cleanup := setupTestDb(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := []ProcessTransactionParams{
+		{UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(1), ExternalTxId: "tx_1", TransactionTime: day},
+		{UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.RequireFromString("0.5"), ExternalTxId: "txA1-src", TransactionTime: day.Add(time.Hour)},
+		{UserId: "user1", Asset: "USDC", TransactionType: "deposit", Amount: decimal.NewFromInt(1), ExternalTxId: "tx_1-dst", TransactionTime: day.Add(2 * time.Hour)},
+	}
+	for _, p := range rows {
+		if _, err := service.ProcessTransaction(ctx, p); err != nil {
+			t.Fatalf("ProcessTransaction %s failed: %v", p.ExternalTxId, err)
+		}
+	}
+
+	// _ in the id is not a wildcard
+	page, err := service.SearchTransactions(ctx, store.TransactionQuery{ExternalTxId: "tx_1"})
+	if err != nil {
+		t.Fatalf("SearchTransactions failed: %v", err)
+	}
+	if len(page.Transactions) != 2 || page.Transactions[0].ExternalTransactionId != "tx_1-dst" || page.Transactions[1].ExternalTransactionId != "tx_1" {
+		t.Errorf("Expected tx_1 and its leg only, got %+v", page.Transactions)
+	}
+
+	// Rounds to 1.0 as a float, but 1 is above it as a decimal
+	below := decimal.RequireFromString("0.99999999999999999")
+	page, err = service.SearchTransactions(ctx, store.TransactionQuery{MaxAmount: &below})
+	if err != nil {
+		t.Fatalf("SearchTransactions failed: %v", err)
+	}
+	if len(page.Transactions) != 1 || page.Transactions[0].ExternalTransactionId != "txA1-src" {
+		t.Errorf("Expected only the 0.5 deposit below the bound, got %+v", page.Transactions)
+	}
+
+	// Rows dropped by the exact check don't shorten the page
+	above := decimal.RequireFromString("0.50000000000000001")
+	page, err = service.SearchTransactions(ctx, store.TransactionQuery{MinAmount: &above, Limit: 1})
+	if err != nil {
+		t.Fatalf("SearchTransactions failed: %v", err)
+	}
+	if len(page.Transactions) != 1 || page.NextCursor == "" {
+		t.Fatalf("Expected a full page and a cursor, got %+v, %q", page.Transactions, page.NextCursor)
+	}
+	page, err = service.SearchTransactions(ctx, store.TransactionQuery{MinAmount: &above, Limit: 1, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("SearchTransactions failed: %v", err)
+	}
+	if len(page.Transactions) != 1 || page.Transactions[0].ExternalTransactionId != "tx_1" || page.NextCursor != "" {
+		t.Errorf("Expected tx_1 on the last page, got %+v, %q", page.Transactions, page.NextCursor)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"prime-send-receive-go/internal/models"
@@ -97,6 +98,14 @@ func NewService(ctx context.Context, cfg models.DatabaseConfig) (*Service, error
 		return nil, fmt.Errorf("unable to initialize subledger schema: %w", err)
 	}
 
+	if err := service.normalizeStoredAddresses(); err != nil {
+		err := db.Close()
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unable to normalize stored addresses: %w", err)
+	}
+
 	zap.L().Info("Database service initialized successfully")
 	return service, nil
 }
@@ -145,7 +154,102 @@ func (s *Service) initSchema(createDummyUsers bool) error {
 	-- Create index for created_at for sorting
 	CREATE INDEX IF NOT EXISTS idx_addresses_created_at ON addresses(created_at);
 
+	-- Audit log of user freezes and unfreezes
+	CREATE TABLE IF NOT EXISTS user_freeze_events (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_user_freeze_events_user ON user_freeze_events(user_id, created_at);
+
+	-- Withdrawal destinations each user may send to; removed entries are kept
+	CREATE TABLE IF NOT EXISTS withdrawal_allowlist (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		address TEXT NOT NULL,
+		tag TEXT NOT NULL DEFAULT '',
+		asset TEXT NOT NULL DEFAULT '',
+		network TEXT NOT NULL DEFAULT '',
+		address_book_state TEXT NOT NULL DEFAULT '',
+		added_by TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		added_at TIMESTAMP NOT NULL,
+		active_at TIMESTAMP NOT NULL,
+		removed_at TIMESTAMP
+	);
+
+	-- Audit log of allowlist additions and removals
+	CREATE TABLE IF NOT EXISTS withdrawal_allowlist_events (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		address TEXT NOT NULL,
+		tag TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_withdrawal_allowlist_events_user ON withdrawal_allowlist_events(user_id, created_at);
 
+	-- Named leases for leader election; token fences writes by earlier holders
+	CREATE TABLE IF NOT EXISTS leases (
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		token INTEGER NOT NULL,
+		acquired_at TIMESTAMP NOT NULL,
+		renewed_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+
+	-- Lifecycle state of each Prime transaction the listener books, and its history
+	CREATE TABLE IF NOT EXISTS prime_tx_states (
+		prime_tx_id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		state TEXT NOT NULL,
+		prime_status TEXT NOT NULL DEFAULT '',
+		quarantined BOOLEAN NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS prime_tx_transitions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		prime_tx_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		from_state TEXT NOT NULL,
+		to_state TEXT NOT NULL,
+		prime_status TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		outcome TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_prime_tx_transitions_tx ON prime_tx_transitions(prime_tx_id, id);
+
+	-- Listener transactions waiting to be retried, and the dead-letter queue
+	CREATE TABLE IF NOT EXISTS failed_transactions (
+		prime_tx_id TEXT PRIMARY KEY,
+		portfolio_id TEXT NOT NULL,
+		wallet TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		state TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at TIMESTAMP NOT NULL,
+		first_failed_at TIMESTAMP NOT NULL,
+		last_failed_at TIMESTAMP NOT NULL,
+		updated_by TEXT NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_failed_transactions_due ON failed_transactions(portfolio_id, state, next_attempt_at);
 	`
 
 	_, err := s.db.Exec(schema)
@@ -153,6 +257,35 @@ func (s *Service) initSchema(createDummyUsers bool) error {
 		return err
 	}
 
+	// Current freeze state (added after the initial schema)
+	if err := applyColumnMigrations(s.db, []columnMigration{
+		{table: "users", column: "frozen", ddl: "BOOLEAN NOT NULL DEFAULT 0"},
+		{table: "users", column: "frozen_reason", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "users", column: "frozen_by", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "users", column: "frozen_note", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "users", column: "frozen_updated_at", ddl: "TIMESTAMP"},
+		{table: "users", column: "closed_at", ddl: "TIMESTAMP"},
+		{table: "addresses", column: "retired_at", ddl: "TIMESTAMP"},
+		{table: "addresses", column: "tag", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "withdrawal_allowlist", column: "network", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "withdrawal_allowlist", column: "tag", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "withdrawal_allowlist_events", column: "tag", ddl: "TEXT NOT NULL DEFAULT ''"},
+	}); err != nil {
+		return err
+	}
+
+	// At most one live allowlist entry per user, address and tag. Addresses are stored
+	// normalized, so they compare exactly: base58 addresses that differ only in case are
+	// distinct. Created after the tag column is added to older databases.
+	if _, err := s.db.Exec(`
+	DROP INDEX IF EXISTS idx_withdrawal_allowlist_live;
+	DROP INDEX IF EXISTS idx_withdrawal_allowlist_live_address;
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_withdrawal_allowlist_live_address_tag
+		ON withdrawal_allowlist(user_id, address, tag) WHERE removed_at IS NULL;
+	`); err != nil {
+		return fmt.Errorf("failed to create allowlist index: %w", err)
+	}
+
 	// Insert 3 dummy users for testing if configured to do so
 	if createDummyUsers {
 		users := []struct {
@@ -186,29 +319,41 @@ func (s *Service) GetUserBalance(ctx context.Context, userId string, asset strin
 	return s.subledger.GetBalance(ctx, userId, asset)
 }
 
+// GetUserBalanceAt returns the balance for a user and asset as of the given effective time.
+func (s *Service) GetUserBalanceAt(ctx context.Context, userId, asset string, at time.Time) (decimal.Decimal, error) {
+	return s.subledger.GetBalanceAt(ctx, userId, asset, at)
+}
+
 func (s *Service) GetAllUserBalances(ctx context.Context, userId string) ([]models.AccountBalance, error) {
 	return s.subledger.GetAllBalances(ctx, userId)
 }
 
-// ProcessDepositPending is a no-op for SQLite (no pending concept for deposits).
+// ProcessDepositPending returns ErrNotSupported: SQLite has no pending phase
+// for deposits, so they are booked directly once confirmed.
 func (s *Service) ProcessDepositPending(_ context.Context, _, _ string, _ decimal.Decimal, _, _ string) error {
-	return nil
+	return fmt.Errorf("pending deposits: %w", ErrNotSupported)
 }
 
-// ConfirmDeposit in SQLite just delegates to ProcessDeposit (single-phase).
-func (s *Service) ConfirmDeposit(ctx context.Context, address, asset string, amount decimal.Decimal, transactionId string) error {
-	return s.ProcessDeposit(ctx, address, asset, amount, transactionId)
+// ConfirmDeposit returns ErrNotSupported: with nothing parked in pending, the
+// listener books a confirmed deposit directly through ProcessDeposit.
+func (s *Service) ConfirmDeposit(_ context.Context, _, _ string, _ decimal.Decimal, _ string) error {
+	return fmt.Errorf("pending deposits: %w", ErrNotSupported)
 }
 
 func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amount decimal.Decimal, transactionId string) error {
-	// Find user by address
-	user, addr, err := s.FindUserByAddress(ctx, address)
+	// Find user by address, and by destination tag on tag-based networks
+	tag := models.DepositTag(ctx)
+	user, addr, err := s.FindUserByAddress(ctx, address, tag)
 	if err != nil {
 		return fmt.Errorf("error finding user by address: %w", err)
 	}
 
 	if user == nil {
-		zap.L().Warn("Deposit to unknown address", zap.String("address", address))
+		// Retired addresses and addresses of inactive users route to suspense
+		if handled, err := s.suspenseDeposit(ctx, address, tag, amount, transactionId); handled || err != nil {
+			return err
+		}
+		zap.L().Warn("Deposit to unknown address", zap.String("address", address), zap.String("tag", tag))
 		return fmt.Errorf("no user found for address: %s", address)
 	}
 
@@ -224,7 +369,16 @@ func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amo
 			zap.String("network", addr.Network))
 	}
 
-	_, err = s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	// Deposits to frozen users are held in quarantine until reviewed
+	status, err := s.GetFreezeStatus(ctx, user.Id)
+	if err != nil {
+		return fmt.Errorf("error checking freeze status: %w", err)
+	}
+	if status.Frozen {
+		return s.quarantineDeposit(ctx, user, addr, amount, transactionId, status)
+	}
+
+	_, err = s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          user.Id,
 		Asset:           canonicalSymbol,
 		TransactionType: "deposit",
@@ -232,7 +386,7 @@ func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amo
 		ExternalTxId:    transactionId,
 		Address:         address,
 		Reference:       "",
-	})
+	}))
 	if err != nil {
 		return fmt.Errorf("error processing deposit transaction: %w", err)
 	}
@@ -247,15 +401,17 @@ func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amo
 	return nil
 }
 
-// ProcessWithdrawal processes a withdrawal transaction for a user by user Id
+// ProcessWithdrawal processes a withdrawal transaction for a user by user Id. Funds
+// reserved by active holds cannot be withdrawn: a withdrawal larger than the available
+// balance fails with store.ErrInsufficientAvailable, and a frozen user's with
+// store.ErrUserFrozen; both are read in the same transaction as the debit.
 func (s *Service) ProcessWithdrawal(ctx context.Context, userId, asset string, amount decimal.Decimal, transactionId string) error {
 	user, err := s.GetUserById(ctx, userId)
 	if err != nil {
 		zap.L().Warn("Withdrawal for unknown user", zap.String("user_id", userId))
 		return fmt.Errorf("error getting user: %w", err)
 	}
-
-	// Get current balance for logging purposes (no validation for historical transactions)
+	// Current balance for logging; the available-balance check runs with the debit
 	currentBalance, err := s.GetUserBalance(ctx, userId, asset)
 	if err != nil {
 		return fmt.Errorf("error getting current balance: %w", err)
@@ -267,7 +423,7 @@ func (s *Service) ProcessWithdrawal(ctx context.Context, userId, asset string, a
 		zap.String("current_balance", currentBalance.String()),
 		zap.String("withdrawal_amount", amount.String()))
 
-	_, err = s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err = s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          user.Id,
 		Asset:           asset,
 		TransactionType: "withdrawal",
@@ -275,7 +431,13 @@ func (s *Service) ProcessWithdrawal(ctx context.Context, userId, asset string, a
 		ExternalTxId:    transactionId,
 		Address:         "",
 		Reference:       "",
-	})
+		CheckAvailable:  true,
+		CheckNotFrozen:  true,
+	}))
+	if errors.Is(err, ErrUserFrozen) {
+		zap.L().Warn("Withdrawal blocked for frozen user", zap.String("user_id", userId), zap.Error(err))
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("error processing withdrawal transaction: %w", err)
 	}
@@ -291,14 +453,20 @@ func (s *Service) ProcessWithdrawal(ctx context.Context, userId, asset string, a
 
 // ProcessWithdrawalFromWallet records a pending withdrawal from the Prime wallet (SQLite).
 func (s *Service) ProcessWithdrawalFromWallet(ctx context.Context, params store.WithdrawalFromWalletParams) error {
-	_, err := s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err := s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          "prime-platform",
 		Asset:           params.Symbol,
 		TransactionType: "withdrawal",
 		Amount:          params.Amount.Neg(),
 		ExternalTxId:    params.TransactionId,
 		Reference:       fmt.Sprintf("WITHDRAWAL_PENDING: %s %s", params.Amount.String(), params.Symbol),
-	})
+		TransactionTime: params.TransactionTime,
+		Network:         params.Network,
+		TxHash:          params.PrimeTxId,
+		NetworkFees:     params.NetworkFees,
+		Fees:            params.Fees,
+		Metadata:        walletWithdrawalMetadata(params),
+	}))
 	if err != nil && !errors.Is(err, store.ErrDuplicateTransaction) {
 		return fmt.Errorf("failed to record wallet withdrawal: %w", err)
 	}
@@ -310,28 +478,42 @@ func (s *Service) ProcessWithdrawalFromWallet(ctx context.Context, params store.
 // the net balance is zero but the audit trail exists.
 func (s *Service) RecordFailedWithdrawalPlatform(ctx context.Context, params store.FailedWithdrawalPlatformParams) error {
 	// Step 1: synthetic withdrawal (debit)
-	_, err := s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err := s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          "prime-platform",
 		Asset:           params.Symbol,
 		TransactionType: "withdrawal",
 		Amount:          params.Amount.Neg(),
 		ExternalTxId:    params.TransactionId,
 		Reference:       fmt.Sprintf("FAILED_WITHDRAWAL: %s %s [%s]", params.Amount.String(), params.Symbol, params.Status),
-	})
+		Counterparty:    accountWithdrawalsPending,
+		TransactionTime: params.TransactionTime,
+		Network:         params.Network,
+		TxHash:          params.PrimeTxId,
+		NetworkFees:     params.NetworkFees,
+		Fees:            params.Fees,
+		Metadata:        walletWithdrawalMetadata(store.WithdrawalFromWalletParams(params)),
+	}))
 	if err != nil && !errors.Is(err, store.ErrDuplicateTransaction) {
 		return fmt.Errorf("failed to record failed withdrawal initiation: %w", err)
 	}
 
 	// Step 2: synthetic reversal (credit back)
 	reversalTxId := params.TransactionId + "-failed-reversal"
-	_, err = s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err = s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          "prime-platform",
 		Asset:           params.Symbol,
 		TransactionType: "deposit",
 		Amount:          params.Amount,
 		ExternalTxId:    reversalTxId,
 		Reference:       fmt.Sprintf("FAILED_WITHDRAWAL_REVERSAL: %s %s [%s]", params.Amount.String(), params.Symbol, params.Status),
-	})
+		Counterparty:    accountWithdrawalsPending,
+		TransactionTime: params.TransactionTime,
+		Network:         params.Network,
+		TxHash:          params.PrimeTxId,
+		NetworkFees:     params.NetworkFees,
+		Fees:            params.Fees,
+		Metadata:        walletWithdrawalMetadata(store.WithdrawalFromWalletParams(params)),
+	}))
 	if err != nil && !errors.Is(err, store.ErrDuplicateTransaction) {
 		return fmt.Errorf("failed to record failed withdrawal reversal: %w", err)
 	}
@@ -346,13 +528,29 @@ func (s *Service) RecordFailedWithdrawalPlatform(ctx context.Context, params sto
 
 // ConfirmWithdrawalDirect records a confirmed withdrawal directly (SQLite).
 func (s *Service) ConfirmWithdrawalDirect(ctx context.Context, params store.WithdrawalConfirmDirectParams) error {
-	_, err := s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err := s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          params.UserId,
 		Asset:           params.Asset,
 		TransactionType: "withdrawal",
 		Amount:          params.Amount.Neg(),
 		ExternalTxId:    params.ExternalTxId,
-	})
+		TransactionTime: params.TransactionTime,
+		Network:         params.Network,
+		TxHash:          params.PrimeTxId,
+		NetworkFees:     params.NetworkFees,
+		Fees:            params.Fees,
+		Metadata: mergeMetadata(map[string]string{"withdrawal_ref": params.WithdrawalRef},
+			walletWithdrawalMetadata(store.WithdrawalFromWalletParams{
+				Status:             "TRANSACTION_DONE",
+				WalletId:           params.WalletId,
+				DestinationAddress: params.DestinationAddress,
+				IdempotencyKey:     params.IdempotencyKey,
+				PrimeTxId:          params.PrimeTxId,
+				Network:            params.Network,
+				NetworkFees:        params.NetworkFees,
+				Fees:               params.Fees,
+			})),
+	}))
 	if err != nil && !errors.Is(err, store.ErrDuplicateTransaction) {
 		return err
 	}
@@ -375,7 +573,7 @@ func (s *Service) RecordPlatformTransaction(ctx context.Context, params store.Pl
 		return fmt.Errorf("invalid amount %q: %w", params.Amount, err)
 	}
 
-	_, err = s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err = s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          "prime-platform",
 		Asset:           params.Symbol,
 		TransactionType: params.Type,
@@ -383,7 +581,18 @@ func (s *Service) RecordPlatformTransaction(ctx context.Context, params store.Pl
 		ExternalTxId:    params.TransactionId,
 		Address:         "",
 		Reference:       fmt.Sprintf("%s: %s %s %s", params.Type, params.Amount, params.Symbol, params.Network),
-	})
+		TransactionTime: params.TransactionTime,
+		Network:         params.Network,
+		TxHash:          store.PrimeTxIdFromMetadata(params.Metadata),
+		NetworkFees:     params.Metadata["network_fees"],
+		Fees:            params.Metadata["fees"],
+		Metadata: mergeMetadata(params.Metadata, map[string]string{
+			"transaction_type": params.Type,
+			"prime_status":     params.Status,
+			"prime_wallet_id":  params.WalletId,
+			"network":          params.Network,
+		}),
+	}))
 	if err != nil {
 		if errors.Is(err, store.ErrDuplicateTransaction) {
 			return nil
@@ -409,27 +618,50 @@ func (s *Service) RecordConversion(ctx context.Context, params store.ConversionP
 	}
 
 	// Debit source asset.
-	_, err := s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err := s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          "prime-platform",
 		Asset:           params.SourceSymbol,
 		TransactionType: "conversion-out",
 		Amount:          srcAmt.Neg(),
 		ExternalTxId:    params.TransactionId + "-src",
 		Reference:       fmt.Sprintf("CONVERSION: -%s %s -> %s", params.SourceAmount, params.SourceSymbol, params.DestinationSymbol),
-	})
+		TransactionTime: params.TransactionTime,
+		Network:         params.Network,
+		Fees:            params.Fees,
+		Metadata: map[string]string{
+			"prime_status":          params.Status,
+			"source_symbol":         params.SourceSymbol,
+			"destination_symbol":    params.DestinationSymbol,
+			"source_wallet_id":      params.SourceWalletId,
+			"destination_wallet_id": params.DestWalletId,
+			"fees":                  params.Fees,
+			"fee_symbol":            params.FeeSymbol,
+		},
+	}))
 	if err != nil && !errors.Is(err, store.ErrDuplicateTransaction) {
 		return fmt.Errorf("failed to record conversion source: %w", err)
 	}
 
 	// Credit destination asset.
-	_, err = s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err = s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          "prime-platform",
 		Asset:           params.DestinationSymbol,
 		TransactionType: "conversion-in",
 		Amount:          dstAmt,
 		ExternalTxId:    params.TransactionId + "-dst",
 		Reference:       fmt.Sprintf("CONVERSION: +%s %s <- %s", dstAmount, params.DestinationSymbol, params.SourceSymbol),
-	})
+		TransactionTime: params.TransactionTime,
+		Network:         params.Network,
+		Metadata: map[string]string{
+			"prime_status":          params.Status,
+			"source_symbol":         params.SourceSymbol,
+			"destination_symbol":    params.DestinationSymbol,
+			"source_wallet_id":      params.SourceWalletId,
+			"destination_wallet_id": params.DestWalletId,
+			"fees":                  params.Fees,
+			"fee_symbol":            params.FeeSymbol,
+		},
+	}))
 	if err != nil && !errors.Is(err, store.ErrDuplicateTransaction) {
 		return fmt.Errorf("failed to record conversion destination: %w", err)
 	}
@@ -441,6 +673,26 @@ func (s *Service) GetTransactionHistory(ctx context.Context, userId, asset strin
 	return s.subledger.GetTransactionHistory(ctx, userId, asset, limit, offset)
 }
 
+// FindTransactionsByMetadata returns transactions whose metadata matches every key/value in filter.
+func (s *Service) FindTransactionsByMetadata(ctx context.Context, filter map[string]string, limit int) ([]models.Transaction, error) {
+	return s.subledger.FindTransactionsByMetadata(ctx, filter, limit)
+}
+
+// SearchTransactions returns a page of transactions matching query.
+func (s *Service) SearchTransactions(ctx context.Context, query store.TransactionQuery) (*store.TransactionPage, error) {
+	return s.subledger.SearchTransactions(ctx, query)
+}
+
+// ExportPostings streams postings in the query's effective-time range to handle.
+func (s *Service) ExportPostings(ctx context.Context, query store.ExportQuery, handle store.PostingHandler) error {
+	return s.subledger.ExportPostings(ctx, query, handle)
+}
+
+// GetTrialBalance returns debits and credits per account and asset as of the given time.
+func (s *Service) GetTrialBalance(ctx context.Context, asOf time.Time) ([]models.TrialBalanceLine, error) {
+	return s.subledger.GetTrialBalance(ctx, asOf)
+}
+
 func (s *Service) ReconcileUserBalance(ctx context.Context, userId, asset string) error {
 	return s.subledger.ReconcileBalance(ctx, userId, asset)
 }
@@ -462,7 +714,7 @@ func (s *Service) HasPendingWithdrawal(ctx context.Context, withdrawalRef string
 // RevertTransaction is not natively supported by SQLite.
 // Returns ErrNotSupported so callers fall back to ReverseWithdrawal.
 func (s *Service) RevertTransaction(_ context.Context, _ string) error {
-	return fmt.Errorf("native revert not supported by SQLite backend")
+	return fmt.Errorf("native revert: %w", ErrNotSupported)
 }
 
 // ReverseWithdrawal credits back a withdrawal that failed (rollback)
@@ -477,7 +729,7 @@ func (s *Service) ReverseWithdrawal(ctx context.Context, userId, asset string, a
 		zap.String("reversal_tx", reversalTxId))
 
 	// Credit back the amount (deposit to reverse the withdrawal)
-	_, err := s.subledger.ProcessTransaction(ctx, ProcessTransactionParams{
+	_, err := s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
 		UserId:          userId,
 		Asset:           asset,
 		TransactionType: "deposit",
@@ -485,7 +737,7 @@ func (s *Service) ReverseWithdrawal(ctx context.Context, userId, asset string, a
 		ExternalTxId:    reversalTxId,
 		Address:         "",
 		Reference:       "Reversal of failed withdrawal",
-	})
+	}))
 	if err != nil {
 		return fmt.Errorf("error reversing withdrawal: %w", err)
 	}
@@ -497,3 +749,78 @@ func (s *Service) ReverseWithdrawal(ctx context.Context, userId, asset string, a
 
 	return nil
 }
+
+// withPrimeContext fills the effective time and Prime metadata of a ledger write
+// from PrimeDepositContext when the caller passed it through ctx rather than params.
+// Fields already set on params take precedence.
+func withPrimeContext(ctx context.Context, params ProcessTransactionParams) ProcessTransactionParams {
+	pdc := models.GetPrimeDepositContext(ctx)
+	if pdc == nil {
+		return params
+	}
+	if params.TransactionTime.IsZero() {
+		params.TransactionTime = pdc.TransactionTime
+	}
+	if params.Network == "" {
+		params.Network = pdc.Network
+	}
+	if params.SourceAddress == "" {
+		params.SourceAddress = pdc.SourceAddress
+	}
+	if params.TxHash == "" {
+		params.TxHash = pdc.TransactionId
+	}
+	if params.NetworkFees == "" {
+		params.NetworkFees = pdc.NetworkFees
+	}
+	if params.Fees == "" {
+		params.Fees = pdc.Fees
+	}
+
+	// Mirror the keys the Formance backend writes as transaction metadata
+	params.Metadata = mergeMetadata(params.Metadata, map[string]string{
+		store.MetadataPrimeTxId: pdc.TransactionId,
+		"source_address":        pdc.SourceAddress,
+		"source_type":           pdc.SourceType,
+		"network_fees":          pdc.NetworkFees,
+		"fees":                  pdc.Fees,
+		"blockchain_ids":        strings.Join(pdc.BlockchainIds, ","),
+		"network":               pdc.Network,
+		"prime_api_symbol":      pdc.PrimeApiSymbol,
+		"prime_wallet_id":       pdc.WalletId,
+		"prime_created_at":      pdc.CreatedAt,
+		"prime_completed_at":    pdc.CompletedAt,
+	})
+	return params
+}
+
+// walletWithdrawalMetadata is the Prime metadata recorded with each leg of a withdrawal.
+func walletWithdrawalMetadata(params store.WithdrawalFromWalletParams) map[string]string {
+	return map[string]string{
+		"prime_status":          params.Status,
+		"prime_api_symbol":      params.PrimeApiSymbol,
+		"prime_wallet_id":       params.WalletId,
+		"destination_address":   params.DestinationAddress,
+		"idempotency_key":       params.IdempotencyKey,
+		store.MetadataPrimeTxId: params.PrimeTxId,
+		"network":               params.Network,
+		"network_fees":          params.NetworkFees,
+		"fees":                  params.Fees,
+	}
+}
+
+// mergeMetadata returns base with any keys from extra that base does not already set.
+func mergeMetadata(base, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range extra {
+		if v != "" {
+			merged[k] = v
+		}
+	}
+	for k, v := range base {
+		if v != "" {
+			merged[k] = v
+		}
+	}
+	return merged
+}
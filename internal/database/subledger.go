@@ -18,6 +18,7 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 
 	"prime-send-receive-go/internal/store"
 )
@@ -27,6 +28,9 @@ var (
 	ErrDuplicateTransaction   = store.ErrDuplicateTransaction
 	ErrConcurrentModification = store.ErrConcurrentModification
 	ErrUserNotFound           = store.ErrUserNotFound
+	ErrInsufficientAvailable  = store.ErrInsufficientAvailable
+	ErrUserFrozen             = store.ErrUserFrozen
+	ErrNotSupported           = store.ErrNotSupported
 )
 
 // SubledgerService handles subledger operations
@@ -68,7 +72,13 @@ func (s *SubledgerService) InitSchema() error {
 		reference TEXT,
 		status TEXT DEFAULT 'confirmed',
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		effective_at TIMESTAMP,
+		network TEXT NOT NULL DEFAULT '',
+		source_address TEXT NOT NULL DEFAULT '',
+		tx_hash TEXT NOT NULL DEFAULT '',
+		network_fees TEXT NOT NULL DEFAULT '',
+		fees TEXT NOT NULL DEFAULT ''
 	);
 
 	-- Performance Indexes for Account Balances
@@ -84,12 +94,13 @@ func (s *SubledgerService) InitSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_transactions_address ON transactions(address);
 	CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);
 
-	-- Optional: Journal Entries for Double-Entry Bookkeeping
+	-- Journal Entries for Double-Entry Bookkeeping (account_id is a chart-of-accounts path)
 	CREATE TABLE IF NOT EXISTS journal_entries (
 		id TEXT PRIMARY KEY,
 		transaction_id TEXT NOT NULL,
 		account_type TEXT NOT NULL,
 		account_id TEXT NOT NULL,
+		asset TEXT NOT NULL DEFAULT '',
 		debit_amount REAL DEFAULT 0,
 		credit_amount REAL DEFAULT 0,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -97,8 +108,73 @@ func (s *SubledgerService) InitSchema() error {
 
 	CREATE INDEX IF NOT EXISTS idx_journal_transaction_id ON journal_entries(transaction_id);
 	CREATE INDEX IF NOT EXISTS idx_journal_account ON journal_entries(account_type, account_id);
+
+	-- Transaction Metadata (Prime context, one row per key)
+	CREATE TABLE IF NOT EXISTS transaction_metadata (
+		transaction_id TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (transaction_id, key)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_transaction_metadata_key_value ON transaction_metadata(key, value);
+
+	-- Holds reserve part of a user's balance without moving it (amounts are exact decimal text)
+	CREATE TABLE IF NOT EXISTS holds (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		asset TEXT NOT NULL,
+		amount TEXT NOT NULL,
+		captured TEXT NOT NULL DEFAULT '0',
+		status TEXT NOT NULL DEFAULT 'active',
+		reason TEXT NOT NULL DEFAULT '',
+		expires_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		settled_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_holds_user_asset_status ON holds(user_id, asset, status);
+	CREATE INDEX IF NOT EXISTS idx_holds_status_expires ON holds(status, expires_at);
+
+	-- Data migrations that must only ever run once
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
 	`
 
-	_, err := s.db.Exec(schema)
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Prime effective time and metadata columns (added after the initial schema).
+	if err := applyColumnMigrations(s.db, []columnMigration{
+		{table: "transactions", column: "effective_at", ddl: "TIMESTAMP",
+			backfill: "UPDATE transactions SET effective_at = created_at WHERE effective_at IS NULL"},
+		{table: "transactions", column: "network", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "transactions", column: "source_address", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "transactions", column: "tx_hash", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "transactions", column: "network_fees", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "transactions", column: "fees", ddl: "TEXT NOT NULL DEFAULT ''"},
+		{table: "journal_entries", column: "asset", ddl: "TEXT NOT NULL DEFAULT ''",
+			backfill: "UPDATE journal_entries SET asset = (SELECT asset FROM transactions t WHERE t.id = journal_entries.transaction_id)"},
+	}); err != nil {
+		return err
+	}
+
+	if _, err := s.db.Exec(queryBackfillPrimeTxIdMetadata); err != nil {
+		return fmt.Errorf("failed to backfill prime_tx_id metadata: %w", err)
+	}
+
+	if err := s.backfillJournal(); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`
+	CREATE INDEX IF NOT EXISTS idx_transactions_effective_at ON transactions(effective_at);
+	CREATE INDEX IF NOT EXISTS idx_transactions_user_asset_effective ON transactions(user_id, asset, effective_at);
+	CREATE INDEX IF NOT EXISTS idx_transactions_tx_hash ON transactions(tx_hash);
+	CREATE INDEX IF NOT EXISTS idx_journal_account_asset ON journal_entries(account_id, asset);
+	`)
 	return err
 }
@@ -20,12 +20,15 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
 )
 
 // ProcessTransactionParams contains the parameters for processing a transaction
@@ -37,6 +40,19 @@ type ProcessTransactionParams struct {
 	ExternalTxId    string
 	Address         string
 	Reference       string
+	TransactionTime time.Time // effective time of the Prime transaction; defaults to now
+	Network         string    // raw Prime network (e.g. "base-mainnet")
+	SourceAddress   string    // external sender address for deposits
+	TxHash          string    // on-chain transaction hash
+	NetworkFees     string
+	Fees            string
+	Metadata        map[string]string // Prime context persisted to transaction_metadata
+	Counterparty    string            // ledger account on the other side of users:{UserId}; defaults by type
+	CheckAvailable  bool              // refuse a debit that would dip into funds reserved by active holds
+	CheckNotFrozen  bool              // refuse the write with ErrUserFrozen while the user is frozen
+	// QuarantineIfFrozen credits the quarantine platform user instead while the user
+	// is frozen, tagged with the user it was meant for
+	QuarantineIfFrozen bool
 }
 
 // ProcessTransaction atomically updates balance and records transaction
@@ -49,10 +65,18 @@ func (s *SubledgerService) ProcessTransaction(ctx context.Context, params Proces
 		zap.String("amount", params.Amount.String()),
 		zap.String("external_tx_id", params.ExternalTxId))
 
-	// Check for duplicate external transaction Id
+	// Start database transaction for atomicity
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Check for duplicate external transaction Id inside the transaction, so a
+	// second process cannot insert the same id between the check and the insert
 	if params.ExternalTxId != "" {
 		var existingTxId string
-		err := s.db.QueryRowContext(ctx, queryCheckDuplicateTransaction, params.ExternalTxId).Scan(&existingTxId)
+		err := tx.QueryRowContext(ctx, queryCheckDuplicateTransaction, params.ExternalTxId).Scan(&existingTxId)
 		if err == nil {
 			zap.L().Warn("Duplicate external transaction Id detected, skipping",
 				zap.String("external_tx_id", params.ExternalTxId),
@@ -63,19 +87,98 @@ func (s *SubledgerService) ProcessTransaction(ctx context.Context, params Proces
 		}
 	}
 
-	// Start database transaction for atomicity
+	transaction, err := s.applyTransaction(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	zap.L().Info("Transaction processed successfully",
+		zap.String("transaction_id", transaction.Id),
+		zap.String("user_id", params.UserId),
+		zap.String("asset_network", params.Asset),
+		zap.String("old_balance", transaction.BalanceBefore.String()),
+		zap.String("new_balance", transaction.BalanceAfter.String()))
+
+	return transaction, nil
+}
+
+// ProcessTransactionBatch applies several transactions in one database transaction:
+// either every balance update and journal posting lands or none does. Any duplicate
+// external transaction id fails the whole batch with ErrDuplicateTransaction.
+func (s *SubledgerService) ProcessTransactionBatch(ctx context.Context, batch []ProcessTransactionParams) ([]*models.Transaction, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	transactions := make([]*models.Transaction, 0, len(batch))
+	for _, params := range batch {
+		if params.ExternalTxId != "" {
+			var existingTxId string
+			err := tx.QueryRowContext(ctx, queryCheckDuplicateTransaction, params.ExternalTxId).Scan(&existingTxId)
+			if err == nil {
+				return nil, fmt.Errorf("%w: external_transaction_id %s already exists", ErrDuplicateTransaction, params.ExternalTxId)
+			} else if err != sql.ErrNoRows {
+				return nil, fmt.Errorf("failed to check for duplicate transaction: %w", err)
+			}
+		}
+
+		transaction, err := s.applyTransaction(ctx, tx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply transaction for user %s: %w", params.UserId, err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	zap.L().Info("Transaction batch processed successfully", zap.Int("transactions", len(transactions)))
+	return transactions, nil
+}
+
+// applyTransaction updates the balance, records the transaction, its metadata and its
+// journal posting inside tx.
+func (s *SubledgerService) applyTransaction(ctx context.Context, tx *sql.Tx, params ProcessTransactionParams) (*models.Transaction, error) {
+	// Writes made under a lease that has changed hands are refused
+	if err := checkFence(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	// The freeze is read in the same transaction as the write, so a freeze that
+	// commits first is never missed
+	if params.CheckNotFrozen {
+		if err := checkNotFrozenTx(ctx, tx, params.UserId); err != nil {
+			return nil, err
+		}
+	}
+	if params.QuarantineIfFrozen {
+		status, err := freezeStatusTx(ctx, tx, params.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if status.Frozen {
+			params.Metadata = mergeMetadata(params.Metadata, map[string]string{
+				"quarantined_user_id": params.UserId,
+				"quarantine_reason":   status.Reason,
+			})
+			params.UserId = store.QuarantineUserId("")
+		}
+	}
+
 	// Get current balance (with row locking)
 	var currentBalanceStr string
 	var accountId string
 	var version int64
 
-	err = tx.QueryRowContext(ctx, queryGetAccountBalance, params.UserId, params.Asset).Scan(&accountId, &currentBalanceStr, &version)
+	err := tx.QueryRowContext(ctx, queryGetAccountBalance, params.UserId, params.Asset).Scan(&accountId, &currentBalanceStr, &version)
 
 	var currentBalance decimal.Decimal
 	if err == sql.ErrNoRows {
@@ -97,23 +200,42 @@ func (s *SubledgerService) ProcessTransaction(ctx context.Context, params Proces
 		}
 	}
 
+	// Holds are read in the same transaction as the debit, so a hold placed
+	// concurrently cannot be spent twice
+	if params.CheckAvailable && params.Amount.IsNegative() {
+		holds, err := queryHolds(ctx, tx, queryGetActiveHolds, params.UserId, params.Asset, params.Asset, time.Now().UTC())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get active holds: %w", err)
+		}
+		if available := currentBalance.Sub(sumHolds(holds)); available.LessThan(params.Amount.Neg()) {
+			return nil, fmt.Errorf("%w: available %s, debit %s", ErrInsufficientAvailable, available, params.Amount.Neg())
+		}
+	}
+
 	// Calculate new balance
 	newBalance := currentBalance.Add(params.Amount)
 
 	// Create transaction record
 	transactionId := uuid.New().String()
-	now := time.Now()
+	now := time.Now().UTC()
+	effectiveAt := now
+	if !params.TransactionTime.IsZero() {
+		effectiveAt = params.TransactionTime.UTC()
+	}
 	transaction := &models.Transaction{}
 
 	var amountStr, balanceBeforeStr, balanceAfterStr string
 	err = tx.QueryRowContext(ctx, queryInsertTransaction,
 		transactionId, params.UserId, params.Asset, params.TransactionType,
 		params.Amount.String(), currentBalance.String(), newBalance.String(),
-		params.ExternalTxId, params.Address, params.Reference, "confirmed", now, now).
+		params.ExternalTxId, params.Address, params.Reference, "confirmed", now, now,
+		effectiveAt, params.Network, params.SourceAddress, params.TxHash, params.NetworkFees, params.Fees).
 		Scan(&transaction.Id, &transaction.UserId, &transaction.Asset, &transaction.TransactionType,
 			&amountStr, &balanceBeforeStr, &balanceAfterStr,
 			&transaction.ExternalTransactionId, &transaction.Address, &transaction.Reference,
-			&transaction.Status, &transaction.CreatedAt, &transaction.ProcessedAt)
+			&transaction.Status, &transaction.CreatedAt, &transaction.ProcessedAt,
+			&transaction.EffectiveAt, &transaction.Network, &transaction.SourceAddress, &transaction.TxHash,
+			&transaction.NetworkFees, &transaction.Fees)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert transaction: %w", err)
 	}
@@ -145,78 +267,56 @@ func (s *SubledgerService) ProcessTransaction(ctx context.Context, params Proces
 		return nil, fmt.Errorf("balance update failed - %w", ErrConcurrentModification)
 	}
 
-	// Optional: Add double-entry journal entries
-	if err := s.addJournalEntries(ctx, tx, transaction); err != nil {
-		return nil, fmt.Errorf("failed to add journal entries: %w", err)
+	// Persist Prime metadata alongside the transaction (empty values are skipped)
+	for key, value := range params.Metadata {
+		if value == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, queryInsertTransactionMetadata, transactionId, key, value); err != nil {
+			return nil, fmt.Errorf("failed to insert transaction metadata %q: %w", key, err)
+		}
+		if transaction.Metadata == nil {
+			transaction.Metadata = make(map[string]string)
+		}
+		transaction.Metadata[key] = value
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	// Balanced double-entry posting in the same SQL transaction
+	if err := s.addJournalEntries(ctx, tx, transaction, params.Counterparty); err != nil {
+		return nil, fmt.Errorf("failed to add journal entries: %w", err)
 	}
 
-	zap.L().Info("Transaction processed successfully",
-		zap.String("transaction_id", transactionId),
-		zap.String("user_id", params.UserId),
-		zap.String("asset_network", params.Asset),
-		zap.String("old_balance", currentBalance.String()),
-		zap.String("new_balance", newBalance.String()))
-
 	return transaction, nil
 }
 
-// addJournalEntries creates double-entry bookkeeping entries
-func (s *SubledgerService) addJournalEntries(ctx context.Context, tx *sql.Tx, transaction *models.Transaction) error {
-	// For a deposit: Debit user asset account, Credit system liability account
-	// For a withdrawal: Credit user asset account, Debit system liability account
-
-	journalEntries := []struct {
-		accountType  string
-		accountId    string
-		debitAmount  decimal.Decimal
-		creditAmount decimal.Decimal
-	}{}
-
-	switch transaction.TransactionType {
-	case "deposit":
-		// User asset account increases (debit)
-		journalEntries = append(journalEntries, struct {
-			accountType  string
-			accountId    string
-			debitAmount  decimal.Decimal
-			creditAmount decimal.Decimal
-		}{"user_asset", fmt.Sprintf("%s_%s", transaction.UserId, transaction.Asset), transaction.Amount, decimal.Zero})
-
-		// System liability increases (credit) - we owe the user this amount
-		journalEntries = append(journalEntries, struct {
-			accountType  string
-			accountId    string
-			debitAmount  decimal.Decimal
-			creditAmount decimal.Decimal
-		}{"system_liability", fmt.Sprintf("user_deposits_%s", transaction.Asset), decimal.Zero, transaction.Amount})
-
-	case "withdrawal":
-		// User asset account decreases (credit)
-		journalEntries = append(journalEntries, struct {
-			accountType  string
-			accountId    string
-			debitAmount  decimal.Decimal
-			creditAmount decimal.Decimal
-		}{"user_asset", fmt.Sprintf("%s_%s", transaction.UserId, transaction.Asset), decimal.Zero, transaction.Amount.Neg()})
-
-		// System liability decreases (debit) - we no longer owe the user this amount
-		journalEntries = append(journalEntries, struct {
-			accountType  string
-			accountId    string
-			debitAmount  decimal.Decimal
-			creditAmount decimal.Decimal
-		}{"system_liability", fmt.Sprintf("user_deposits_%s", transaction.Asset), transaction.Amount.Neg(), decimal.Zero})
-	}
-
-	for _, entry := range journalEntries {
-		entryId := uuid.New().String()
+// addJournalEntries writes the balanced double-entry posting for a transaction: the
+// user account and its counterparty, one debited and one credited for the same amount.
+// Money flows from the credited account to the debited one, so a deposit debits
+// users:{id} and credits the wallet, and a withdrawal does the reverse.
+func (s *SubledgerService) addJournalEntries(ctx context.Context, tx *sql.Tx, transaction *models.Transaction, counterparty string) error {
+	if transaction.Amount.IsZero() {
+		return nil
+	}
+	if counterparty == "" {
+		counterparty = defaultCounterparty(transaction.TransactionType, transaction.Metadata)
+	}
+
+	debitAccount, creditAccount := userAccount(transaction.UserId), counterparty
+	if transaction.Amount.IsNegative() {
+		debitAccount, creditAccount = counterparty, userAccount(transaction.UserId)
+	}
+	amount := transaction.Amount.Abs().String()
+
+	for _, entry := range []struct {
+		account       string
+		debit, credit string
+	}{
+		{debitAccount, amount, "0"},
+		{creditAccount, "0", amount},
+	} {
 		_, err := tx.ExecContext(ctx, queryInsertJournalEntry,
-			entryId, transaction.Id, entry.accountType, entry.accountId, entry.debitAmount.String(), entry.creditAmount.String())
+			uuid.New().String(), transaction.Id, accountType(entry.account), entry.account,
+			transaction.Asset, entry.debit, entry.credit)
 		if err != nil {
 			return err
 		}
@@ -237,6 +337,65 @@ func (s *SubledgerService) GetTransactionHistory(ctx context.Context, userId, as
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction history: %w", err)
 	}
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadMetadata(ctx, transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// FindTransactionsByMetadata returns transactions whose metadata matches every
+// key/value pair in filter (e.g. {"prime_tx_id": "0xabc"}), newest effective time first.
+func (s *SubledgerService) FindTransactionsByMetadata(ctx context.Context, filter map[string]string, limit int) ([]models.Transaction, error) {
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("at least one metadata filter is required")
+	}
+
+	zap.L().Debug("Finding transactions by metadata", zap.Any("filter", filter), zap.Int("limit", limit))
+
+	// Sort keys so the generated SQL is stable for a given filter
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var query strings.Builder
+	query.WriteString(queryFindTransactionsByMetadata)
+	args := make([]any, 0, len(keys)*2+1)
+	for i, key := range keys {
+		if i > 0 {
+			query.WriteString(" AND ")
+		}
+		query.WriteString("id IN (SELECT transaction_id FROM transaction_metadata WHERE key = ? AND value = ?)")
+		args = append(args, key, filter[key])
+	}
+	query.WriteString(" ORDER BY julianday(effective_at) DESC, julianday(created_at) DESC LIMIT ?")
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transactions by metadata: %w", err)
+	}
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadMetadata(ctx, transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// scanTransactions reads transaction rows selected with the standard column list and closes rows.
+func scanTransactions(rows *sql.Rows) ([]models.Transaction, error) {
 	defer func(rows *sql.Rows) {
 		if err := rows.Close(); err != nil {
 			zap.L().Warn("Failed to close rows", zap.Error(err))
@@ -250,7 +409,9 @@ func (s *SubledgerService) GetTransactionHistory(ctx context.Context, userId, as
 		err := rows.Scan(&tx.Id, &tx.UserId, &tx.Asset, &tx.TransactionType,
 			&amountStr, &balanceBeforeStr, &balanceAfterStr,
 			&tx.ExternalTransactionId, &tx.Address, &tx.Reference,
-			&tx.Status, &tx.CreatedAt, &tx.ProcessedAt)
+			&tx.Status, &tx.CreatedAt, &tx.ProcessedAt,
+			&tx.EffectiveAt, &tx.Network, &tx.SourceAddress, &tx.TxHash,
+			&tx.NetworkFees, &tx.Fees)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
@@ -282,7 +443,47 @@ func (s *SubledgerService) GetTransactionHistory(ctx context.Context, userId, as
 	return transactions, nil
 }
 
-// GetMostRecentTransactionTime returns the most recent transaction timestamp for recovery
+// loadMetadata attaches transaction_metadata rows to each transaction in place.
+func (s *SubledgerService) loadMetadata(ctx context.Context, transactions []models.Transaction) error {
+	for i := range transactions {
+		metadata, err := s.getTransactionMetadata(ctx, transactions[i].Id)
+		if err != nil {
+			return err
+		}
+		transactions[i].Metadata = metadata
+	}
+	return nil
+}
+
+func (s *SubledgerService) getTransactionMetadata(ctx context.Context, transactionId string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, queryGetTransactionMetadata, transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction metadata: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			zap.L().Warn("Failed to close rows", zap.Error(err))
+		}
+	}(rows)
+
+	var metadata map[string]string
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction metadata: %w", err)
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction metadata rows: %w", err)
+	}
+	return metadata, nil
+}
+
+// GetMostRecentTransactionTime returns the most recent Prime effective time for recovery
 func (s *SubledgerService) GetMostRecentTransactionTime(ctx context.Context) (time.Time, error) {
 	var timestampStr sql.NullString
 	err := s.db.QueryRowContext(ctx, queryGetMostRecentTransactionTime).Scan(&timestampStr)
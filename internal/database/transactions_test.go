@@ -21,6 +21,9 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/store"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/shopspring/decimal"
@@ -56,7 +59,7 @@ func TestProcessTransaction_Deposit(t *testing.T) {
 	amount := decimal.NewFromFloat(1.5)
 
 	// Process deposit
-	result, err := service.ProcessTransaction(ctx, ProcessTransactionParams{userId, asset, "deposit", amount, "tx1", "addr1", "memo1"})
+	result, err := service.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "deposit", Amount: amount, ExternalTxId: "tx1", Address: "addr1", Reference: "memo1"})
 	if err != nil {
 		t.Fatalf("ProcessTransaction failed: %v", err)
 	}
@@ -86,14 +89,14 @@ func TestProcessTransaction_Withdrawal(t *testing.T) {
 
 	// First, make a deposit
 	depositAmount := decimal.NewFromFloat(2.0)
-	_, err := service.ProcessTransaction(ctx, ProcessTransactionParams{userId, asset, "deposit", depositAmount, "tx1", "addr1", ""})
+	_, err := service.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "deposit", Amount: depositAmount, ExternalTxId: "tx1", Address: "addr1"})
 	if err != nil {
 		t.Fatalf("Initial deposit failed: %v", err)
 	}
 
 	// Now process withdrawal (should be negative amount)
 	withdrawalAmount := decimal.NewFromFloat(-0.5)
-	result, err := service.ProcessTransaction(ctx, ProcessTransactionParams{userId, asset, "withdrawal", withdrawalAmount, "tx2", "", ""})
+	result, err := service.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "withdrawal", Amount: withdrawalAmount, ExternalTxId: "tx2"})
 	if err != nil {
 		t.Fatalf("ProcessTransaction withdrawal failed: %v", err)
 	}
@@ -116,13 +119,13 @@ func TestProcessTransaction_DuplicateHandling(t *testing.T) {
 	txId := "duplicate-tx"
 
 	// Process transaction first time
-	_, err := service.ProcessTransaction(ctx, ProcessTransactionParams{userId, asset, "deposit", amount, txId, "addr1", ""})
+	_, err := service.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "deposit", Amount: amount, ExternalTxId: txId, Address: "addr1"})
 	if err != nil {
 		t.Fatalf("First ProcessTransaction failed: %v", err)
 	}
 
 	// Process same transaction again - should return error for duplicate
-	_, err = service.ProcessTransaction(ctx, ProcessTransactionParams{userId, asset, "deposit", amount, txId, "addr1", ""})
+	_, err = service.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "deposit", Amount: amount, ExternalTxId: txId, Address: "addr1"})
 	if err == nil {
 		t.Fatalf("Expected duplicate transaction error, got nil")
 	}
@@ -143,7 +146,7 @@ func TestProcessTransaction_NegativeBalanceAllowed(t *testing.T) {
 
 	// Process withdrawal from zero balance (should be allowed for historical transactions)
 	withdrawalAmount := decimal.NewFromFloat(-1.0)
-	result, err := service.ProcessTransaction(ctx, ProcessTransactionParams{userId, asset, "withdrawal", withdrawalAmount, "tx1", "", ""})
+	result, err := service.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "withdrawal", Amount: withdrawalAmount, ExternalTxId: "tx1"})
 	if err != nil {
 		t.Fatalf("ProcessTransaction with negative balance failed: %v", err)
 	}
@@ -153,3 +156,211 @@ func TestProcessTransaction_NegativeBalanceAllowed(t *testing.T) {
 		t.Errorf("Expected negative balance %s, got %s", withdrawalAmount.String(), result.BalanceAfter.String())
 	}
 }
+
+func TestProcessTransaction_EffectiveTimeOrdering(t *testing.T) {
+	service, cleanup := setupTestDb(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	userId := "user1"
+	asset := "USDC"
+	early := time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)
+	late := time.Date(2025, 1, 12, 8, 0, 0, 0, time.UTC)
+
+	// Ingest the later transaction first, as a recovery backfill would.
+	_, err := service.ProcessTransaction(ctx, ProcessTransactionParams{UserId: userId, Asset: asset, TransactionType: "deposit", Amount: decimal.NewFromInt(5), ExternalTxId: "late", TransactionTime: late})
+	if err != nil {
+		t.Fatalf("Late deposit failed: %v", err)
+	}
+	result, err := service.ProcessTransaction(ctx, ProcessTransactionParams{
+		UserId:          userId,
+		Asset:           asset,
+		TransactionType: "deposit",
+		Amount:          decimal.NewFromInt(3),
+		ExternalTxId:    "early",
+		Address:         "0xdeposit",
+		TransactionTime: early,
+		Network:         "base-mainnet",
+		SourceAddress:   "0xsender",
+		TxHash:          "0xabc",
+		NetworkFees:     "0.01",
+		Fees:            "0",
+	})
+	if err != nil {
+		t.Fatalf("Early deposit failed: %v", err)
+	}
+
+	if !result.EffectiveAt.Equal(early) {
+		t.Errorf("Expected effective_at %s, got %s", early, result.EffectiveAt)
+	}
+	if result.Network != "base-mainnet" || result.SourceAddress != "0xsender" || result.TxHash != "0xabc" || result.NetworkFees != "0.01" {
+		t.Errorf("Prime metadata not persisted: %+v", result)
+	}
+
+	history, err := service.GetTransactionHistory(ctx, userId, asset, 10, 0)
+	if err != nil {
+		t.Fatalf("GetTransactionHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(history))
+	}
+	if history[0].ExternalTransactionId != "late" || history[1].ExternalTransactionId != "early" {
+		t.Errorf("Expected history ordered by effective time (late, early), got (%s, %s)",
+			history[0].ExternalTransactionId, history[1].ExternalTransactionId)
+	}
+
+	mostRecent, err := service.GetMostRecentTransactionTime(ctx)
+	if err != nil {
+		t.Fatalf("GetMostRecentTransactionTime failed: %v", err)
+	}
+	if !mostRecent.Equal(late) {
+		t.Errorf("Expected most recent effective time %s, got %s", late, mostRecent)
+	}
+	// The backfilled entry counts at its effective time, not its insertion order
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected int64
+	}{
+		{"at the early entry", early, 3},
+		{"between the entries", early.Add(24 * time.Hour), 3},
+		{"at the late entry", late, 8},
+	}
+	for _, tt := range tests {
+		balance, err := service.GetBalanceAt(ctx, userId, asset, tt.at)
+		if err != nil {
+			t.Fatalf("GetBalanceAt failed: %v", err)
+		}
+		if !balance.Equal(decimal.NewFromInt(tt.expected)) {
+			t.Errorf("%s: expected balance %d, got %s", tt.name, tt.expected, balance)
+		}
+	}
+}
+
+func TestInitSchema_MigratesLegacyTransactionsTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	legacy := `
+		CREATE TABLE transactions (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			asset TEXT NOT NULL,
+			transaction_type TEXT NOT NULL,
+			amount REAL NOT NULL,
+			balance_before REAL NOT NULL,
+			balance_after REAL NOT NULL,
+			external_transaction_id TEXT,
+			address TEXT,
+			reference TEXT,
+			status TEXT DEFAULT 'confirmed',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO transactions (id, user_id, asset, transaction_type, amount, balance_before, balance_after,
+			external_transaction_id, address, reference, created_at)
+		VALUES ('t1', 'user1', 'BTC', 'deposit', 1, 0, 1, 'ext1', '', '', '2024-06-01 10:00:00');
+	`
+	if _, err := db.Exec(legacy); err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+
+	service := NewSubledgerService(db)
+	if err := service.InitSchema(); err != nil {
+		t.Fatalf("InitSchema on legacy database failed: %v", err)
+	}
+
+	history, err := service.GetTransactionHistory(context.Background(), "user1", "BTC", 10, 0)
+	if err != nil {
+		t.Fatalf("GetTransactionHistory failed: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 transaction, got %d", len(history))
+	}
+	expected := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	if !history[0].EffectiveAt.Equal(expected) {
+		t.Errorf("Expected effective_at backfilled from created_at (%s), got %s", expected, history[0].EffectiveAt)
+	}
+}
+
+func TestFindTransactionsByMetadata(t *testing.T) {
+	service, cleanup := setupBalanceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	err := service.ProcessWithdrawalFromWallet(ctx, store.WithdrawalFromWalletParams{
+		TransactionId:      "prime-wd-1",
+		Status:             "OTHER_TRANSACTION_STATUS",
+		Symbol:             "USDC",
+		PrimeApiSymbol:     "BASEUSDC",
+		Amount:             decimal.NewFromInt(10),
+		WalletId:           "wallet-1",
+		DestinationAddress: "0xdest",
+		IdempotencyKey:     "idem-1",
+		Network:            "base-mainnet",
+		PrimeTxId:          "0xabc",
+	})
+	if err != nil {
+		t.Fatalf("ProcessWithdrawalFromWallet failed: %v", err)
+	}
+
+	found, err := service.FindTransactionsByMetadata(ctx, map[string]string{"prime_tx_id": "0xabc"}, 10)
+	if err != nil {
+		t.Fatalf("FindTransactionsByMetadata failed: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 transaction, got %d", len(found))
+	}
+	if found[0].ExternalTransactionId != "prime-wd-1" {
+		t.Errorf("Expected external id prime-wd-1, got %s", found[0].ExternalTransactionId)
+	}
+	if found[0].Metadata["idempotency_key"] != "idem-1" || found[0].Metadata["prime_api_symbol"] != "BASEUSDC" {
+		t.Errorf("Expected Prime metadata on result, got %v", found[0].Metadata)
+	}
+	if _, ok := found[0].Metadata["fees"]; ok {
+		t.Errorf("Expected empty metadata values to be skipped, got %v", found[0].Metadata)
+	}
+
+	// Every key must match.
+	none, err := service.FindTransactionsByMetadata(ctx, map[string]string{"prime_tx_id": "0xabc", "idempotency_key": "other"}, 10)
+	if err != nil {
+		t.Fatalf("FindTransactionsByMetadata failed: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("Expected no transactions for mismatched filter, got %d", len(none))
+	}
+}
+
+func TestInitSchema_BackfillsPrimeTxIdMetadata(t *testing.T) {
+	service, cleanup := setupBalanceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	// Written before the hash was kept as prime_tx_id metadata: one under the
+	// legacy key, one with the hash column only
+	legacy := []ProcessTransactionParams{
+		{UserId: "prime-platform", Asset: "ETH", TransactionType: "REWARD", Amount: decimal.NewFromInt(1), ExternalTxId: "reward-1", Metadata: map[string]string{"transaction_id": "0xold"}},
+		{UserId: "prime-platform", Asset: "ETH", TransactionType: "REWARD", Amount: decimal.NewFromInt(2), ExternalTxId: "reward-2", TxHash: "0xhash"},
+	}
+	for _, p := range legacy {
+		if _, err := service.subledger.ProcessTransaction(ctx, p); err != nil {
+			t.Fatalf("ProcessTransaction failed: %v", err)
+		}
+	}
+
+	if err := service.subledger.InitSchema(); err != nil {
+		t.Fatalf("InitSchema failed: %v", err)
+	}
+	for hash, externalId := range map[string]string{"0xold": "reward-1", "0xhash": "reward-2"} {
+		found, err := service.FindTransactionsByMetadata(ctx, map[string]string{store.MetadataPrimeTxId: hash}, 10)
+		if err != nil {
+			t.Fatalf("FindTransactionsByMetadata failed: %v", err)
+		}
+		if len(found) != 1 || found[0].ExternalTransactionId != externalId {
+			t.Errorf("Expected %s to find %s, got %+v", hash, externalId, found)
+		}
+	}
+}
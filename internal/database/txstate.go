@@ -0,0 +1,174 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+)
+
+// GetTxState returns a Prime transaction's lifecycle state, or nil if it has
+// never been recorded.
+func (s *Service) GetTxState(ctx context.Context, primeTxId string) (*models.TxState, error) {
+	state, err := scanTxState(s.db.QueryRowContext(ctx, queryGetTxState, primeTxId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state of transaction %s: %w", primeTxId, err)
+	}
+	return state, nil
+}
+
+// RecordTxTransition advances a transaction and appends its history entry in
+// one database transaction, fenced like ledger writes.
+func (s *Service) RecordTxTransition(ctx context.Context, params store.TxTransitionParams) (*models.TxState, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkFence(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	if params.FromState == models.TxStateNew {
+		_, err = tx.ExecContext(ctx, queryInsertTxState, params.PrimeTxId, params.Kind, params.ToState, params.PrimeStatus, now, now)
+		if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("%w: %s is no longer %s", store.ErrTxStateConflict, params.PrimeTxId, params.FromState)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to record state of transaction %s: %w", params.PrimeTxId, err)
+		}
+	} else {
+		result, err := tx.ExecContext(ctx, queryAdvanceTxState, params.ToState, params.PrimeStatus, now, params.PrimeTxId, params.FromState)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record state of transaction %s: %w", params.PrimeTxId, err)
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to record state of transaction %s: %w", params.PrimeTxId, err)
+		} else if n == 0 {
+			return nil, fmt.Errorf("%w: %s is no longer %s", store.ErrTxStateConflict, params.PrimeTxId, params.FromState)
+		}
+	}
+
+	if err := insertTxTransition(ctx, tx, params, models.TxOutcomeApplied, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetTxState(ctx, params.PrimeTxId)
+}
+
+// QuarantineTx records a transition that was refused and flags the transaction
+// for review. Its state is left as it was.
+func (s *Service) QuarantineTx(ctx context.Context, params store.TxTransitionParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkFence(ctx, tx); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, queryQuarantineTxState, params.PrimeTxId, params.Kind, params.FromState, now, now); err != nil {
+		return fmt.Errorf("failed to quarantine transaction %s: %w", params.PrimeTxId, err)
+	}
+	if err := insertTxTransition(ctx, tx, params, models.TxOutcomeQuarantined, now); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetTxHistory returns a transaction's lifecycle history, oldest first.
+func (s *Service) GetTxHistory(ctx context.Context, primeTxId string) ([]models.TxTransition, error) {
+	rows, err := s.db.QueryContext(ctx, queryGetTxHistory, primeTxId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history of transaction %s: %w", primeTxId, err)
+	}
+	defer rows.Close()
+
+	var history []models.TxTransition
+	for rows.Next() {
+		var t models.TxTransition
+		if err := rows.Scan(&t.PrimeTxId, &t.Kind, &t.FromState, &t.ToState, &t.PrimeStatus, &t.Action, &t.Outcome, &t.Detail, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transition: %w", err)
+		}
+		history = append(history, t)
+	}
+	return history, rows.Err()
+}
+
+// GetQuarantinedTxs returns every transaction flagged by QuarantineTx.
+func (s *Service) GetQuarantinedTxs(ctx context.Context) ([]models.TxState, error) {
+	rows, err := s.db.QueryContext(ctx, queryGetQuarantinedTxs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var states []models.TxState
+	for rows.Next() {
+		state, err := scanTxState(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction state: %w", err)
+		}
+		states = append(states, *state)
+	}
+	return states, rows.Err()
+}
+
+func insertTxTransition(ctx context.Context, db execer, params store.TxTransitionParams, outcome string, now time.Time) error {
+	_, err := db.ExecContext(ctx, queryInsertTxTransition, params.PrimeTxId, params.Kind, params.FromState, params.ToState,
+		params.PrimeStatus, params.Action, outcome, params.Detail, now)
+	if err != nil {
+		return fmt.Errorf("failed to record transition of transaction %s: %w", params.PrimeTxId, err)
+	}
+	return nil
+}
+
+func scanTxState(row rowScanner) (*models.TxState, error) {
+	var t models.TxState
+	if err := row.Scan(&t.PrimeTxId, &t.Kind, &t.State, &t.PrimeStatus, &t.Quarantined, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
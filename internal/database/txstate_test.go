@@ -0,0 +1,152 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+)
+
+func TestRecordTxTransition(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if state, err := service.GetTxState(ctx, "tx1"); err != nil || state != nil {
+		t.Fatalf("Expected no state before recording, got %+v, %v", state, err)
+	}
+
+	pending := store.TxTransitionParams{
+		PrimeTxId: "tx1", Kind: models.TxKindDeposit, FromState: models.TxStateNew, ToState: models.TxStatePending,
+		PrimeStatus: "TRANSACTION_IMPORT_PENDING", Action: "record_pending", Detail: "5 USDC",
+	}
+	state, err := service.RecordTxTransition(ctx, pending)
+	if err != nil {
+		t.Fatalf("RecordTxTransition failed: %v", err)
+	}
+	if state.State != models.TxStatePending || state.Kind != models.TxKindDeposit || state.PrimeStatus != "TRANSACTION_IMPORT_PENDING" {
+		t.Errorf("Unexpected state %+v", state)
+	}
+
+	// Recording the same step twice conflicts instead of appending history
+	if _, err := service.RecordTxTransition(ctx, pending); !errors.Is(err, store.ErrTxStateConflict) {
+		t.Fatalf("Expected ErrTxStateConflict for a repeated transition, got %v", err)
+	}
+
+	credited := pending
+	credited.FromState, credited.ToState = models.TxStatePending, models.TxStateCredited
+	credited.PrimeStatus, credited.Action = "TRANSACTION_IMPORTED", "confirm_pending"
+	if state, err = service.RecordTxTransition(ctx, credited); err != nil {
+		t.Fatalf("RecordTxTransition failed: %v", err)
+	}
+	if state.State != models.TxStateCredited {
+		t.Errorf("Expected credited, got %+v", state)
+	}
+	if _, err := service.RecordTxTransition(ctx, credited); !errors.Is(err, store.ErrTxStateConflict) {
+		t.Fatalf("Expected ErrTxStateConflict from a stale state, got %v", err)
+	}
+
+	history, err := service.GetTxHistory(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("GetTxHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %+v", history)
+	}
+	if history[0].Action != "record_pending" || history[0].Detail != "5 USDC" || history[1].FromState != models.TxStatePending ||
+		history[1].ToState != models.TxStateCredited || history[1].Outcome != models.TxOutcomeApplied {
+		t.Errorf("Unexpected history %+v", history)
+	}
+
+	if _, err := service.RecordTxTransition(ctx, store.TxTransitionParams{PrimeTxId: "tx2", Kind: "conversion", FromState: models.TxStateNew, ToState: models.TxStatePending, Action: "x"}); err == nil {
+		t.Error("Expected an invalid kind to be rejected")
+	}
+}
+
+func TestQuarantineTx(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	done := store.TxTransitionParams{
+		PrimeTxId: "tx1", Kind: models.TxKindWithdrawal, FromState: models.TxStateNew, ToState: models.TxStateCompleted,
+		PrimeStatus: "TRANSACTION_DONE", Action: "debit_direct",
+	}
+	if _, err := service.RecordTxTransition(ctx, done); err != nil {
+		t.Fatalf("RecordTxTransition failed: %v", err)
+	}
+
+	err := service.QuarantineTx(ctx, store.TxTransitionParams{
+		PrimeTxId: "tx1", Kind: models.TxKindWithdrawal, FromState: models.TxStateCompleted, ToState: models.TxStateFailed,
+		PrimeStatus: "TRANSACTION_FAILED", Action: "none", Detail: "illegal transition",
+	})
+	if err != nil {
+		t.Fatalf("QuarantineTx failed: %v", err)
+	}
+
+	state, err := service.GetTxState(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("GetTxState failed: %v", err)
+	}
+	if state.State != models.TxStateCompleted || state.PrimeStatus != "TRANSACTION_DONE" || !state.Quarantined {
+		t.Errorf("Expected the state kept and flagged, got %+v", state)
+	}
+
+	history, err := service.GetTxHistory(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("GetTxHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[1].Outcome != models.TxOutcomeQuarantined || history[1].Detail != "illegal transition" {
+		t.Errorf("Unexpected history %+v", history)
+	}
+
+	quarantined, err := service.GetQuarantinedTxs(ctx)
+	if err != nil {
+		t.Fatalf("GetQuarantinedTxs failed: %v", err)
+	}
+	if len(quarantined) != 1 || quarantined[0].PrimeTxId != "tx1" {
+		t.Errorf("Expected tx1 quarantined, got %+v", quarantined)
+	}
+}
+
+func TestRecordTxTransition_Fenced(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	lease, err := service.AcquireLease(ctx, store.AcquireLeaseParams{Name: "listener:p1", Holder: "a", TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("AcquireLease failed: %v", err)
+	}
+	stale := lease.Fence()
+	stale.Token++
+
+	_, err = service.RecordTxTransition(models.WithLeaseFence(ctx, stale), store.TxTransitionParams{
+		PrimeTxId: "tx1", Kind: models.TxKindDeposit, FromState: models.TxStateNew, ToState: models.TxStatePending, Action: "record_pending",
+	})
+	if !errors.Is(err, store.ErrLeaseLost) {
+		t.Fatalf("Expected ErrLeaseLost for a stale fence, got %v", err)
+	}
+	if state, _ := service.GetTxState(ctx, "tx1"); state != nil {
+		t.Errorf("Expected nothing recorded under a stale fence, got %+v", state)
+	}
+}
@@ -21,16 +21,31 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
 
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+const transactionTypeAccountClosure = "account-closure"
+
 func (s *Service) GetUsers(ctx context.Context) ([]models.User, error) {
 	zap.L().Debug("Querying active users")
+	return s.queryUsers(ctx, queryGetActiveUsers)
+}
 
-	rows, err := s.db.QueryContext(ctx, queryGetActiveUsers)
+// GetOpenUsers returns active and deactivated users, leaving out closed ones.
+func (s *Service) GetOpenUsers(ctx context.Context) ([]models.User, error) {
+	zap.L().Debug("Querying open users")
+	return s.queryUsers(ctx, queryGetOpenUsers)
+}
+
+func (s *Service) queryUsers(ctx context.Context, query string) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		zap.L().Error("Failed to query users", zap.Error(err))
 		return nil, fmt.Errorf("unable to query users: %w", err)
@@ -123,3 +138,239 @@ func (s *Service) CreateUser(ctx context.Context, userId, name, email string) (*
 	// Return the created user
 	return s.GetUserByEmail(ctx, email)
 }
+
+// UpdateUser changes an active user's name and/or email.
+func (s *Service) UpdateUser(ctx context.Context, params store.UpdateUserParams) (*models.User, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	user, err := s.GetUserById(ctx, params.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if params.Name != "" {
+		user.Name = params.Name
+	}
+	if params.Email != "" && params.Email != user.Email {
+		var owner string
+		err := s.db.QueryRowContext(ctx, queryEmailInUse, params.Email, params.UserId).Scan(&owner)
+		if err == nil {
+			return nil, fmt.Errorf("%w: %s", store.ErrEmailTaken, params.Email)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("unable to check email: %w", err)
+		}
+		user.Email = params.Email
+	}
+
+	if _, err := s.db.ExecContext(ctx, queryUpdateUser, user.Name, user.Email, params.UserId); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("%w: %s", store.ErrEmailTaken, params.Email)
+		}
+		zap.L().Error("Failed to update user", zap.String("user_id", params.UserId), zap.Error(err))
+		return nil, fmt.Errorf("unable to update user: %w", err)
+	}
+
+	zap.L().Info("User updated", zap.String("user_id", params.UserId), zap.String("name", user.Name), zap.String("email", user.Email))
+	return s.GetUserById(ctx, params.UserId)
+}
+
+// DeactivateUser hides an active user from lookups. Their balances and addresses are
+// kept, and deposits to their addresses are credited to suspense.
+func (s *Service) DeactivateUser(ctx context.Context, userId string) error {
+	res, err := s.db.ExecContext(ctx, queryDeactivateUser, userId)
+	if err != nil {
+		return fmt.Errorf("unable to deactivate user: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("unable to get rows affected: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("user not found: %s", userId)
+	}
+
+	zap.L().Info("User deactivated", zap.String("user_id", userId))
+	return nil
+}
+
+// CloseUser closes an active or deactivated user: balances must be zero, or are swept
+// when forced, then every address is retired and the user deactivated for good.
+// A frozen user, or one with active holds, cannot be closed.
+func (s *Service) CloseUser(ctx context.Context, params store.CloseUserParams) (*models.UserClosure, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	var active bool
+	var closedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, queryGetUserState, params.UserId).Scan(&active, &closedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user not found: %s", params.UserId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to query user: %w", err)
+	}
+	if closedAt.Valid {
+		return nil, fmt.Errorf("user %s is already closed", params.UserId)
+	}
+	if err := s.checkNotFrozen(ctx, params.UserId); err != nil {
+		return nil, err
+	}
+
+	holds, err := s.GetActiveHolds(ctx, params.UserId, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(holds) > 0 {
+		return nil, fmt.Errorf("user %s has %d active holds; capture or release them first", params.UserId, len(holds))
+	}
+
+	balances, err := s.GetAllUserBalances(ctx, params.UserId)
+	if err != nil {
+		return nil, err
+	}
+	closure := &models.UserClosure{UserId: params.UserId}
+	if len(balances) > 0 {
+		if !params.Force {
+			return nil, fmt.Errorf("%w: %s holds %s", store.ErrNonZeroBalance, params.UserId, describeBalances(balances))
+		}
+		if closure.Swept, err = s.sweepBalances(ctx, params, balances); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	closure.ClosedAt = time.Now().UTC()
+	res, err := tx.ExecContext(ctx, queryRetireUserAddresses, closure.ClosedAt, params.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retire addresses: %w", err)
+	}
+	retired, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get rows affected: %w", err)
+	}
+	closure.RetiredAddresses = int(retired)
+	if _, err := tx.ExecContext(ctx, queryCloseUser, closure.ClosedAt, closure.ClosedAt, params.UserId); err != nil {
+		return nil, fmt.Errorf("unable to close user: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user closure: %w", err)
+	}
+
+	zap.L().Warn("User closed",
+		zap.String("user_id", params.UserId),
+		zap.Int("swept_balances", len(closure.Swept)),
+		zap.Int("retired_addresses", closure.RetiredAddresses))
+	return closure, nil
+}
+
+// sweepBalances moves every balance of a closing user to params.SweepTo in one
+// atomic batch. A user destination is reached through the closures clearing account
+// so each side is posted once; any other account is the direct counterparty.
+func (s *Service) sweepBalances(ctx context.Context, params store.CloseUserParams, balances []models.AccountBalance) ([]models.SweptBalance, error) {
+	targetUserId, toUser := strings.CutPrefix(params.SweepTo, accountUsersPrefix)
+	if toUser {
+		if _, err := s.GetUserById(ctx, targetUserId); err != nil {
+			return nil, fmt.Errorf("invalid sweep account %s: %w", params.SweepTo, err)
+		}
+	}
+
+	var batch []ProcessTransactionParams
+	var swept []models.SweptBalance
+	for _, b := range balances {
+		if b.Balance.IsNegative() {
+			return nil, fmt.Errorf("cannot sweep negative %s balance %s of user %s", b.Asset, b.Balance, params.UserId)
+		}
+		ref := fmt.Sprintf("ACCOUNT CLOSURE: %s %s from %s to %s", b.Balance, b.Asset, params.UserId, params.SweepTo)
+		metadata := map[string]string{"closed_user_id": params.UserId, "sweep_to": params.SweepTo}
+		leg := ProcessTransactionParams{
+			UserId:          params.UserId,
+			Asset:           b.Asset,
+			TransactionType: transactionTypeAccountClosure,
+			Amount:          b.Balance.Neg(),
+			ExternalTxId:    fmt.Sprintf("close-%s-%s", params.UserId, b.Asset),
+			Reference:       ref,
+			Metadata:        metadata,
+			Counterparty:    params.SweepTo,
+		}
+		if toUser {
+			leg.Counterparty = accountClosures
+			batch = append(batch, leg, ProcessTransactionParams{
+				UserId:          targetUserId,
+				Asset:           b.Asset,
+				TransactionType: transactionTypeAccountClosure,
+				Amount:          b.Balance,
+				ExternalTxId:    fmt.Sprintf("close-%s-%s-%s", params.UserId, b.Asset, targetUserId),
+				Reference:       ref,
+				Metadata:        metadata,
+				Counterparty:    accountClosures,
+			})
+		} else {
+			batch = append(batch, leg)
+		}
+		swept = append(swept, models.SweptBalance{Asset: b.Asset, Amount: b.Balance, To: params.SweepTo})
+	}
+
+	if _, err := s.subledger.ProcessTransactionBatch(ctx, batch); err != nil {
+		return nil, fmt.Errorf("failed to sweep balances: %w", err)
+	}
+	return swept, nil
+}
+
+func describeBalances(balances []models.AccountBalance) string {
+	parts := make([]string, len(balances))
+	for i, b := range balances {
+		parts[i] = b.Balance.String() + " " + b.Asset
+	}
+	return strings.Join(parts, ", ")
+}
+
+// suspenseDeposit credits a deposit to a retired address, or to an address of a
+// deactivated or closed user, to the suspense platform user. It reports false when
+// the address is unknown.
+func (s *Service) suspenseDeposit(ctx context.Context, address, tag string, amount decimal.Decimal, transactionId string) (bool, error) {
+	var ownerId, asset, network string
+	var retired bool
+	err := s.db.QueryRowContext(ctx, queryFindSuspenseAddress, canonicalAddress(address), tag).Scan(&ownerId, &asset, &network, &retired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to query address owner: %w", err)
+	}
+	reason := "user_inactive"
+	if retired {
+		reason = "address_retired"
+	}
+
+	_, err = s.subledger.ProcessTransaction(ctx, withPrimeContext(ctx, ProcessTransactionParams{
+		UserId:          store.SuspenseUserId(""),
+		Asset:           asset,
+		TransactionType: "deposit",
+		Amount:          amount,
+		ExternalTxId:    transactionId,
+		Address:         address,
+		Reference:       fmt.Sprintf("SUSPENSE_DEPOSIT: %s %s to %s address of user %s", amount.String(), asset, strings.ReplaceAll(reason, "_", " "), ownerId),
+		Metadata: map[string]string{
+			"suspense_user_id": ownerId,
+			"suspense_reason":  reason,
+		},
+	}))
+	if err != nil {
+		return true, fmt.Errorf("error processing suspense deposit: %w", err)
+	}
+
+	zap.L().Warn("Deposit to retired address credited to suspense",
+		zap.String("user_id", ownerId),
+		zap.String("address", address),
+		zap.String("asset", asset),
+		zap.String("network", network),
+		zap.String("amount", amount.String()),
+		zap.String("suspense_reason", reason),
+		zap.String("external_tx_id", transactionId))
+	return true, nil
+}
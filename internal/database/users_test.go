@@ -0,0 +1,199 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestUpdateUser_EmailUniqueness(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, "user2", "Other User", "other@example.com"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	user, err := service.UpdateUser(ctx, store.UpdateUserParams{UserId: "user1", Name: "Renamed User"})
+	if err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if user.Name != "Renamed User" || user.Email != "test@example.com" {
+		t.Errorf("Expected only the name to change, got %+v", user)
+	}
+
+	if _, err := service.UpdateUser(ctx, store.UpdateUserParams{UserId: "user1", Email: "other@example.com"}); !errors.Is(err, store.ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken, got %v", err)
+	}
+
+	// A deactivated user keeps their email reserved
+	if err := service.DeactivateUser(ctx, "user2"); err != nil {
+		t.Fatalf("DeactivateUser failed: %v", err)
+	}
+	if _, err := service.UpdateUser(ctx, store.UpdateUserParams{UserId: "user1", Email: "other@example.com"}); !errors.Is(err, store.ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken for a deactivated user's email, got %v", err)
+	}
+
+	if user, err = service.UpdateUser(ctx, store.UpdateUserParams{UserId: "user1", Email: "new@example.com"}); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if _, err := service.GetUserByEmail(ctx, "new@example.com"); err != nil || user.Email != "new@example.com" {
+		t.Errorf("Expected user to be found by new email, got %+v, %v", user, err)
+	}
+	if _, err := service.UpdateUser(ctx, store.UpdateUserParams{UserId: "user2", Name: "Ghost"}); err == nil {
+		t.Error("Expected updating a deactivated user to fail")
+	}
+}
+
+func TestDeactivateUser_DepositsGoToSuspense(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(100), "dep-1"); err != nil {
+		t.Fatalf("ProcessDeposit failed: %v", err)
+	}
+	if err := service.DeactivateUser(ctx, "user1"); err != nil {
+		t.Fatalf("DeactivateUser failed: %v", err)
+	}
+	if err := service.DeactivateUser(ctx, "user1"); err == nil {
+		t.Error("Expected deactivating an inactive user to fail")
+	}
+	if _, err := service.GetUserById(ctx, "user1"); err == nil {
+		t.Error("Expected a deactivated user to be hidden from lookups")
+	}
+
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(25), "dep-2"); err != nil {
+		t.Fatalf("ProcessDeposit to deactivated user failed: %v", err)
+	}
+	if bal, _ := service.GetUserBalance(ctx, "user1", "USDC"); !bal.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected deactivated user's balance to stay 100, got %s", bal)
+	}
+	if bal, _ := service.GetUserBalance(ctx, store.SuspenseUserId(""), "USDC"); !bal.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("Expected suspense balance 25, got %s", bal)
+	}
+	txs, err := service.FindTransactionsByMetadata(ctx, map[string]string{"suspense_user_id": "user1"}, 10)
+	if err != nil || len(txs) != 1 || txs[0].Metadata["suspense_reason"] != "user_inactive" {
+		t.Errorf("Expected one suspense deposit tagged with the user, got %+v, %v", txs, err)
+	}
+}
+
+func TestCloseUser(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, "user2", "Other User", "other@example.com"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(100), "dep-1"); err != nil {
+		t.Fatalf("ProcessDeposit failed: %v", err)
+	}
+
+	if _, err := service.CloseUser(ctx, store.CloseUserParams{UserId: "user1"}); !errors.Is(err, store.ErrNonZeroBalance) {
+		t.Fatalf("Expected ErrNonZeroBalance, got %v", err)
+	}
+	if _, err := service.FreezeUser(ctx, store.FreezeParams{UserId: "user1", Reason: models.FreezeReasonLegalOrder, Actor: "ops"}); err != nil {
+		t.Fatalf("FreezeUser failed: %v", err)
+	}
+	if _, err := service.CloseUser(ctx, store.CloseUserParams{UserId: "user1", Force: true, SweepTo: "users:user2"}); !errors.Is(err, store.ErrUserFrozen) {
+		t.Fatalf("Expected ErrUserFrozen, got %v", err)
+	}
+	if _, err := service.UnfreezeUser(ctx, store.FreezeParams{UserId: "user1", Reason: models.FreezeReasonReviewCleared, Actor: "ops"}); err != nil {
+		t.Fatalf("UnfreezeUser failed: %v", err)
+	}
+	if _, err := service.CloseUser(ctx, store.CloseUserParams{UserId: "user1", Force: true, SweepTo: "users:missing"}); err == nil {
+		t.Fatal("Expected sweeping to an unknown user to fail")
+	}
+
+	closure, err := service.CloseUser(ctx, store.CloseUserParams{UserId: "user1", Force: true, SweepTo: "users:user2"})
+	if err != nil {
+		t.Fatalf("CloseUser failed: %v", err)
+	}
+	if len(closure.Swept) != 1 || !closure.Swept[0].Amount.Equal(decimal.NewFromInt(100)) || closure.RetiredAddresses != 1 {
+		t.Errorf("Unexpected closure: %+v", closure)
+	}
+	if bal, _ := service.GetUserBalance(ctx, "user1", "USDC"); !bal.IsZero() {
+		t.Errorf("Expected closed user's balance to be 0, got %s", bal)
+	}
+	if bal, _ := service.GetUserBalance(ctx, "user2", "USDC"); !bal.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected swept balance 100, got %s", bal)
+	}
+	if _, err := service.CloseUser(ctx, store.CloseUserParams{UserId: "user1"}); err == nil {
+		t.Error("Expected closing a closed user to fail")
+	}
+
+	// The retired address no longer resolves and routes deposits to suspense
+	if user, _, err := service.FindUserByAddress(ctx, "0xabc", ""); err != nil || user != nil {
+		t.Errorf("Expected retired address not to resolve, got %+v, %v", user, err)
+	}
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(5), "dep-2"); err != nil {
+		t.Fatalf("ProcessDeposit to retired address failed: %v", err)
+	}
+	if bal, _ := service.GetUserBalance(ctx, store.SuspenseUserId(""), "USDC"); !bal.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("Expected suspense balance 5, got %s", bal)
+	}
+	txs, err := service.FindTransactionsByMetadata(ctx, map[string]string{"suspense_user_id": "user1"}, 10)
+	if err != nil || len(txs) != 1 || txs[0].Metadata["suspense_reason"] != "address_retired" {
+		t.Errorf("Expected one suspense deposit for the retired address, got %+v, %v", txs, err)
+	}
+
+	lines, err := service.GetTrialBalance(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	assertBalanced(t, lines)
+}
+
+func TestCloseUser_ActiveHoldBlocksClose(t *testing.T) {
+	service, cleanup := setupComplianceTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := service.ProcessDeposit(ctx, "0xabc", "USDC", decimal.NewFromInt(100), "dep-1"); err != nil {
+		t.Fatalf("ProcessDeposit failed: %v", err)
+	}
+	if _, err := service.PlaceHold(ctx, store.PlaceHoldParams{HoldId: "h1", UserId: "user1", Asset: "USDC", Amount: decimal.NewFromInt(10)}); err != nil {
+		t.Fatalf("PlaceHold failed: %v", err)
+	}
+	if _, err := service.CloseUser(ctx, store.CloseUserParams{UserId: "user1", Force: true, SweepTo: "fees:closure"}); err == nil {
+		t.Fatal("Expected an active hold to block the close")
+	}
+
+	if _, err := service.ReleaseHold(ctx, "h1"); err != nil {
+		t.Fatalf("ReleaseHold failed: %v", err)
+	}
+	closure, err := service.CloseUser(ctx, store.CloseUserParams{UserId: "user1", Force: true, SweepTo: "fees:closure"})
+	if err != nil {
+		t.Fatalf("CloseUser failed: %v", err)
+	}
+	if len(closure.Swept) != 1 || closure.Swept[0].To != "fees:closure" {
+		t.Errorf("Unexpected closure: %+v", closure)
+	}
+	if bal, _ := service.GetUserBalance(ctx, "user1", "USDC"); !bal.IsZero() {
+		t.Errorf("Expected closed user's balance to be 0, got %s", bal)
+	}
+}
@@ -0,0 +1,344 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fees computes and charges periodic fees on user balances. Custody fees are
+// an annual rate in basis points of each user's average daily balance, prorated to
+// the days in the period.
+package fees
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// KindCustody is the fee kind; charges post to fees:custody
+const KindCustody = "custody"
+
+const (
+	maxAnnualBps       = 10000
+	daysPerYear        = 365
+	day                = 24 * time.Hour
+	platformUserPrefix = "prime-platform"
+	// averageScale is the precision kept for reported average daily balances
+	averageScale = 18
+)
+
+// Period is a whole number of UTC days, To exclusive.
+type Period struct {
+	From, To time.Time
+}
+
+// NewPeriod validates that from and to are UTC midnights with from before to.
+func NewPeriod(from, to time.Time) (Period, error) {
+	from, to = from.UTC(), to.UTC()
+	if !from.Equal(from.Truncate(day)) || !to.Equal(to.Truncate(day)) {
+		return Period{}, fmt.Errorf("fee periods must start and end at UTC midnight")
+	}
+	if !from.Before(to) {
+		return Period{}, fmt.Errorf("fee period start %s must be before its end %s", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	return Period{From: from, To: to}, nil
+}
+
+// MonthPeriod returns the calendar month named YYYY-MM.
+func MonthPeriod(month string) (Period, error) {
+	from, err := time.Parse("2006-01", month)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid month %q, expected YYYY-MM", month)
+	}
+	return Period{From: from, To: from.AddDate(0, 1, 0)}, nil
+}
+
+// PreviousMonth returns the last complete calendar month before now.
+func PreviousMonth(now time.Time) Period {
+	now = now.UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return Period{From: thisMonth.AddDate(0, -1, 0), To: thisMonth}
+}
+
+// Days is the number of days in the period.
+func (p Period) Days() int {
+	return int(p.To.Sub(p.From) / day)
+}
+
+// Reference is the idempotency key of one user's custody fee in one asset for a period.
+func Reference(userId, asset string, p Period) string {
+	return fmt.Sprintf("custody-fee-%s-%s-%s-%s", p.From.Format("20060102"), p.To.Format("20060102"), asset, userId)
+}
+
+// Options controls a custody fee run.
+type Options struct {
+	AnnualBps int64
+	Precision int32  // decimals fees are rounded down to
+	UserId    string // only this user; empty means every end user
+	Asset     string // only this asset; empty means every asset
+}
+
+// Preview computes every user's custody fee for the period without charging it.
+// Lines already charged are marked so.
+func Preview(ctx context.Context, db store.LedgerStore, period Period, opts Options) (*models.CustodyFeeReport, error) {
+	if opts.AnnualBps < 0 || opts.AnnualBps > maxAnnualBps {
+		return nil, fmt.Errorf("custody fee must be between 0 and %d bps a year, got %d", maxAnnualBps, opts.AnnualBps)
+	}
+	if opts.Precision < 0 {
+		return nil, fmt.Errorf("precision must not be negative, got %d", opts.Precision)
+	}
+	days := period.Days()
+	if days <= 0 {
+		return nil, fmt.Errorf("fee period must span at least one day")
+	}
+
+	sums, err := dailyBalanceSums(ctx, db, period, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CustodyFeeReport{
+		From:      period.From,
+		To:        period.To,
+		Days:      days,
+		AnnualBps: opts.AnnualBps,
+		Precision: opts.Precision,
+	}
+	// fee = sum of daily balances × bps / 10000 / 365, i.e. average × rate × days / 365
+	rate := decimal.NewFromInt(opts.AnnualBps).Div(decimal.NewFromInt(maxAnnualBps * daysPerYear))
+	for _, key := range sortedKeys(sums) {
+		sum := sums[key]
+		line := models.CustodyFeeLine{
+			UserId:              key.userId,
+			Asset:               key.asset,
+			AverageDailyBalance: sum.DivRound(decimal.NewFromInt(int64(days)), averageScale),
+			Fee:                 sum.Mul(rate).RoundFloor(opts.Precision),
+			Reference:           Reference(key.userId, key.asset, period),
+			Status:              models.FeeStatusPreview,
+		}
+		line.Description = fmt.Sprintf("Custody fee %s to %s: %s bps a year on average balance %s %s",
+			period.From.Format(time.DateOnly), period.To.Add(-day).Format(time.DateOnly),
+			decimal.NewFromInt(opts.AnnualBps), line.AverageDailyBalance.RoundFloor(opts.Precision), key.asset)
+
+		if !line.Fee.IsPositive() {
+			line.Status = models.FeeStatusBelowMinimum
+		} else {
+			charged, err := db.HasFeeCharge(ctx, line.Reference)
+			if err != nil {
+				return nil, err
+			}
+			if charged {
+				line.Status = models.FeeStatusAlreadyCharged
+			}
+		}
+		report.Lines = append(report.Lines, line)
+	}
+	report.Totals = totals(report.Lines)
+	return report, nil
+}
+
+// Charge computes the period's custody fees and posts every one not yet charged. A
+// user whose current balance cannot cover the fee is skipped and reported. Runs are
+// idempotent: a fee already charged for the period is never charged again. Failed
+// lines are reported and the returned error counts them.
+func Charge(ctx context.Context, db store.LedgerStore, period Period, opts Options) (*models.CustodyFeeReport, error) {
+	report, err := Preview(ctx, db, period, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := 0
+	for i := range report.Lines {
+		line := &report.Lines[i]
+		if line.Status != models.FeeStatusPreview {
+			continue
+		}
+		balance, err := db.GetUserBalance(ctx, line.UserId, line.Asset)
+		if err != nil {
+			line.Status, line.Error = models.FeeStatusFailed, err.Error()
+			failed++
+			continue
+		}
+		if balance.LessThan(line.Fee) {
+			line.Status = models.FeeStatusInsufficientBalance
+			zap.L().Warn("Balance does not cover custody fee",
+				zap.String("user_id", line.UserId), zap.String("asset", line.Asset),
+				zap.String("balance", balance.String()), zap.String("fee", line.Fee.String()))
+			continue
+		}
+
+		err = db.ChargeFee(ctx, store.FeeChargeParams{
+			Reference:   line.Reference,
+			UserId:      line.UserId,
+			Asset:       line.Asset,
+			Amount:      line.Fee,
+			Kind:        KindCustody,
+			Description: line.Description,
+			Metadata: map[string]string{
+				"fee_period_from":       period.From.Format(time.DateOnly),
+				"fee_period_to":         period.To.Format(time.DateOnly),
+				"fee_annual_bps":        decimal.NewFromInt(opts.AnnualBps).String(),
+				"average_daily_balance": line.AverageDailyBalance.String(),
+			},
+		})
+		switch {
+		case err == nil:
+			line.Status = models.FeeStatusCharged
+		case errors.Is(err, store.ErrDuplicateTransaction):
+			line.Status = models.FeeStatusAlreadyCharged
+		default:
+			line.Status, line.Error = models.FeeStatusFailed, err.Error()
+			failed++
+		}
+	}
+	report.Totals = totals(report.Lines)
+
+	zap.L().Info("Custody fee run complete",
+		zap.String("from", period.From.Format(time.DateOnly)),
+		zap.String("to", period.To.Format(time.DateOnly)),
+		zap.Int("lines", len(report.Lines)),
+		zap.Int("failed", failed))
+	if failed > 0 {
+		return report, fmt.Errorf("%d custody fee charges failed", failed)
+	}
+	return report, nil
+}
+
+type balanceKey struct {
+	userId, asset string
+}
+
+// dailyBalanceSums returns, per end user and asset, the sum over the period's days of
+// the end-of-day balance, counting negative balances as zero. Opening balances come
+// from the trial balance just before the period; the period's postings are replayed
+// day by day.
+func dailyBalanceSums(ctx context.Context, db store.LedgerStore, period Period, opts Options) (map[balanceKey]decimal.Decimal, error) {
+	include := func(account, asset string) (string, bool) {
+		userId, ok := store.UserIdFromAccount(account)
+		if !ok || strings.HasPrefix(userId, platformUserPrefix) {
+			return "", false
+		}
+		if (opts.UserId != "" && userId != opts.UserId) || (opts.Asset != "" && asset != opts.Asset) {
+			return "", false
+		}
+		return userId, true
+	}
+
+	opening, err := db.GetTrialBalance(ctx, period.From.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opening balances: %w", err)
+	}
+	balances := make(map[balanceKey]decimal.Decimal)
+	for _, l := range opening {
+		if userId, ok := include(l.Account, l.Asset); ok {
+			balances[balanceKey{userId, l.Asset}] = l.Net()
+		}
+	}
+
+	days := period.Days()
+	deltas := make(map[balanceKey][]decimal.Decimal)
+	err = db.ExportPostings(ctx, store.ExportQuery{From: period.From, To: period.To}, func(postings []store.Posting) error {
+		for _, p := range postings {
+			userId, ok := include(p.Account, p.Asset)
+			if !ok {
+				continue
+			}
+			i := int(p.EffectiveAt.Sub(period.From) / day)
+			if i < 0 || i >= days {
+				continue
+			}
+			key := balanceKey{userId, p.Asset}
+			if deltas[key] == nil {
+				deltas[key] = make([]decimal.Decimal, days)
+			}
+			if p.Direction == store.DirectionDebit {
+				deltas[key][i] = deltas[key][i].Add(p.Amount)
+			} else {
+				deltas[key][i] = deltas[key][i].Sub(p.Amount)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read postings for fee period: %w", err)
+	}
+
+	sums := make(map[balanceKey]decimal.Decimal)
+	for key := range deltas {
+		if _, ok := balances[key]; !ok {
+			balances[key] = decimal.Zero
+		}
+	}
+	for key, balance := range balances {
+		sum := decimal.Zero
+		for i := 0; i < days; i++ {
+			if deltas[key] != nil {
+				balance = balance.Add(deltas[key][i])
+			}
+			if balance.IsPositive() {
+				sum = sum.Add(balance)
+			}
+		}
+		if sum.IsPositive() {
+			sums[key] = sum
+		}
+	}
+	return sums, nil
+}
+
+func sortedKeys(m map[balanceKey]decimal.Decimal) []balanceKey {
+	keys := make([]balanceKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].userId != keys[j].userId {
+			return keys[i].userId < keys[j].userId
+		}
+		return keys[i].asset < keys[j].asset
+	})
+	return keys
+}
+
+func totals(lines []models.CustodyFeeLine) []models.CustodyFeeTotal {
+	byAsset := make(map[string]*models.CustodyFeeTotal)
+	var assets []string
+	for _, l := range lines {
+		t, ok := byAsset[l.Asset]
+		if !ok {
+			t = &models.CustodyFeeTotal{Asset: l.Asset}
+			byAsset[l.Asset] = t
+			assets = append(assets, l.Asset)
+		}
+		t.Fees = t.Fees.Add(l.Fee)
+		if l.Status == models.FeeStatusCharged || l.Status == models.FeeStatusAlreadyCharged {
+			t.Charged = t.Charged.Add(l.Fee)
+		}
+		t.Users++
+	}
+	sort.Strings(assets)
+	out := make([]models.CustodyFeeTotal, 0, len(assets))
+	for _, a := range assets {
+		out = append(out, *byAsset[a])
+	}
+	return out
+}
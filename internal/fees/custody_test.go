@@ -0,0 +1,182 @@
+package fees
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+	"prime-send-receive-go/internal/testutil"
+
+	"github.com/shopspring/decimal"
+)
+
+// testStore opens March from a fixed trial balance. GetUserBalance, checked at charge
+// time, is Balances plus every posting; u3 has since withdrawn all but 0.001 BTC.
+func testStore() *testutil.Ledger {
+	db := testutil.NewLedger()
+	db.Trial = []models.TrialBalanceLine{
+		{Account: "users:u1", Asset: "USDC", Debits: testutil.Dec("150"), Credits: testutil.Dec("50")},
+		{Account: "users:u3", Asset: "BTC", Debits: testutil.Dec("1")},
+		{Account: "users:prime-platform", Asset: "USDC", Debits: testutil.Dec("1000")},
+		{Account: "prime:wallets:w1", Asset: "USDC", Credits: testutil.Dec("1100")},
+	}
+	db.Postings = []store.Posting{
+		// u1 deposits 62 USDC on 16 March; it counts from that day's close
+		{Account: "users:u1", EffectiveAt: time.Date(2025, 3, 16, 9, 0, 0, 0, time.UTC), Asset: "USDC", Amount: testutil.Dec("62"), Direction: store.DirectionDebit},
+		{Account: "prime:wallets:w1", EffectiveAt: time.Date(2025, 3, 16, 9, 0, 0, 0, time.UTC), Asset: "USDC", Amount: testutil.Dec("62"), Direction: store.DirectionCredit},
+		// after the period, so ignored
+		{Account: "users:u1", EffectiveAt: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), Asset: "USDC", Amount: testutil.Dec("1000"), Direction: store.DirectionDebit},
+	}
+	db.Balances["u1:USDC"] = testutil.Dec("100")
+	db.Balances["u3:BTC"] = testutil.Dec("0.001")
+	return db
+}
+
+func lineFor(t *testing.T, r *models.CustodyFeeReport, userId, asset string) models.CustodyFeeLine {
+	t.Helper()
+	for _, l := range r.Lines {
+		if l.UserId == userId && l.Asset == asset {
+			return l
+		}
+	}
+	t.Fatalf("No fee line for %s %s", userId, asset)
+	return models.CustodyFeeLine{}
+}
+
+func TestPreview_AverageDailyBalance(t *testing.T) {
+	period, err := MonthPeriod("2025-03")
+	if err != nil {
+		t.Fatalf("MonthPeriod failed: %v", err)
+	}
+	// 365 bps a year is 1 bp per day of balance
+	report, err := Preview(context.Background(), testStore(), period, Options{AnnualBps: 365, Precision: 6})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if report.Days != 31 || len(report.Lines) != 2 {
+		t.Fatalf("Expected 31 days and 2 lines, got %d days and %d lines", report.Days, len(report.Lines))
+	}
+
+	// 15 days at 100 and 16 days at 162
+	u1 := lineFor(t, report, "u1", "USDC")
+	if !u1.AverageDailyBalance.Equal(testutil.Dec("132")) {
+		t.Errorf("Expected average daily balance 132, got %s", u1.AverageDailyBalance)
+	}
+	if !u1.Fee.Equal(testutil.Dec("0.4092")) {
+		t.Errorf("Expected fee 0.4092, got %s", u1.Fee)
+	}
+	if u1.Status != models.FeeStatusPreview || u1.Reference != "custody-fee-20250301-20250401-USDC-u1" {
+		t.Errorf("Unexpected status %q or reference %q", u1.Status, u1.Reference)
+	}
+	if u3 := lineFor(t, report, "u3", "BTC"); !u3.Fee.Equal(testutil.Dec("0.0031")) {
+		t.Errorf("Expected BTC fee 0.0031, got %s", u3.Fee)
+	}
+}
+
+func TestCharge_IsIdempotentAndSkipsShortBalances(t *testing.T) {
+	db := testStore()
+	period, _ := MonthPeriod("2025-03")
+	opts := Options{AnnualBps: 365, Precision: 6}
+
+	report, err := Charge(context.Background(), db, period, opts)
+	if err != nil {
+		t.Fatalf("Charge failed: %v", err)
+	}
+	if status := lineFor(t, report, "u1", "USDC").Status; status != models.FeeStatusCharged {
+		t.Errorf("Expected u1 to be charged, got %s", status)
+	}
+	if status := lineFor(t, report, "u3", "BTC").Status; status != models.FeeStatusInsufficientBalance {
+		t.Errorf("Expected u3 to be skipped for insufficient balance, got %s", status)
+	}
+	charge, ok := db.Fees["custody-fee-20250301-20250401-USDC-u1"]
+	if !ok || charge.Kind != KindCustody || !charge.Amount.Equal(testutil.Dec("0.4092")) {
+		t.Fatalf("Expected a 0.4092 custody charge, got %+v", charge)
+	}
+
+	again, err := Charge(context.Background(), db, period, opts)
+	if err != nil {
+		t.Fatalf("Second charge failed: %v", err)
+	}
+	if status := lineFor(t, again, "u1", "USDC").Status; status != models.FeeStatusAlreadyCharged {
+		t.Errorf("Expected u1 to be already charged on re-run, got %s", status)
+	}
+	if len(db.Fees) != 1 {
+		t.Errorf("Expected one charge in total, got %d", len(db.Fees))
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	if _, err := NewPeriod(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected a period not starting at midnight to be rejected")
+	}
+	prev := PreviousMonth(time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC))
+	if !prev.From.Equal(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)) || prev.Days() != 31 {
+		t.Errorf("Expected December 2024, got %s with %d days", prev.From, prev.Days())
+	}
+}
+
+func TestCharge_PostsToSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewSQLiteStore(t)
+	testutil.Deposit(t, db, "u1", "USDC", testutil.Dec("100"), "dep-1", time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC))
+	testutil.Deposit(t, db, "u1", "USDC", testutil.Dec("62"), "dep-2", time.Date(2025, 3, 16, 9, 0, 0, 0, time.UTC))
+	period, _ := MonthPeriod("2025-03")
+	opts := Options{AnnualBps: 365, Precision: 6}
+
+	report, err := Charge(ctx, db, period, opts)
+	if err != nil {
+		t.Fatalf("Charge failed: %v", err)
+	}
+	if line := lineFor(t, report, "u1", "USDC"); line.Status != models.FeeStatusCharged || !line.Fee.Equal(testutil.Dec("0.4092")) {
+		t.Fatalf("Expected a 0.4092 charge, got %+v", line)
+	}
+	balance, err := db.GetUserBalance(ctx, "u1", "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !balance.Equal(testutil.Dec("161.5908")) {
+		t.Errorf("Expected balance 161.5908 after the charge, got %s", balance)
+	}
+	lines, err := db.GetTrialBalance(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	testutil.AssertBalanced(t, lines)
+	fees := decimal.Zero
+	for _, l := range lines {
+		if l.Account == "fees:custody" && l.Asset == "USDC" {
+			fees = l.Net()
+		}
+	}
+	if !fees.Equal(testutil.Dec("0.4092")) {
+		t.Errorf("Expected fees:custody to receive 0.4092, got %s", fees)
+	}
+
+	again, err := Charge(ctx, db, period, opts)
+	if err != nil {
+		t.Fatalf("Second charge failed: %v", err)
+	}
+	if status := lineFor(t, again, "u1", "USDC").Status; status != models.FeeStatusAlreadyCharged {
+		t.Errorf("Expected u1 to be already charged on re-run, got %s", status)
+	}
+}
+
+func TestCharge_DeactivatedUserOnSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewSQLiteStore(t)
+	testutil.Deposit(t, db, "u1", "USDC", testutil.Dec("100"), "dep-1", time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC))
+	if err := db.DeactivateUser(ctx, "u1"); err != nil {
+		t.Fatalf("DeactivateUser failed: %v", err)
+	}
+	period, _ := MonthPeriod("2025-03")
+
+	report, err := Charge(ctx, db, period, Options{AnnualBps: 365, Precision: 6})
+	if err != nil {
+		t.Fatalf("Charge failed: %v", err)
+	}
+	if line := lineFor(t, report, "u1", "USDC"); line.Status != models.FeeStatusCharged || !line.Fee.Equal(testutil.Dec("0.31")) {
+		t.Errorf("Expected a deactivated user to be charged 0.31, got %+v", line)
+	}
+}
@@ -0,0 +1,54 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fees
+
+import (
+	"context"
+	"time"
+
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// RunSchedule charges the previous calendar month's custody fees now and then on every
+// tick of interval until ctx is done. Charges are idempotent per user, asset and
+// period, so ticking more often than monthly only retries what is still outstanding,
+// such as users whose balance could not cover the fee.
+func RunSchedule(ctx context.Context, db store.LedgerStore, opts Options, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	run := func() {
+		period := PreviousMonth(time.Now())
+		if _, err := Charge(ctx, db, period, opts); err != nil {
+			zap.L().Error("Scheduled custody fee run failed",
+				zap.String("from", period.From.Format(time.DateOnly)),
+				zap.Error(err))
+		}
+	}
+
+	run()
+	for {
+		select {
+		case <-ticker.C:
+			run()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/models"
 	"prime-send-receive-go/internal/store"
 
@@ -28,6 +29,7 @@ func (s *Service) StoreAddress(ctx context.Context, params store.StoreAddressPar
 		zap.String("asset", params.Asset),
 		zap.String("network", params.Network),
 		zap.String("address", params.Address),
+		zap.String("tag", params.Tag),
 		zap.Bool("is_withdrawal", isWithdrawalAddr))
 
 	var meta map[string]string
@@ -36,7 +38,7 @@ func (s *Service) StoreAddress(ctx context.Context, params store.StoreAddressPar
 		// Withdrawal address: separate prefix, not added to deposit_addresses map.
 		// Value is the asset symbol (e.g. "USDC" from address book, or "WITHDRAWAL" if unknown).
 		meta = map[string]string{
-			withdrawalAddrMetaKey(params.Address): params.Asset,
+			taggedMetaKey(withdrawalAddrMetaKey(params.Address), params.Tag): params.Asset,
 		}
 	} else {
 		// Deposit address: added to deposit_addresses map + deposit_addr_ key.
@@ -52,13 +54,20 @@ func (s *Service) StoreAddress(ctx context.Context, params store.StoreAddressPar
 		walletJSON, _ := json.Marshal(wallets)
 
 		meta = map[string]string{
-			"deposit_addresses":         string(depositJSON),
-			"wallet_ids":                string(walletJSON),
-			addrMetaKey(params.Address): params.Asset,
+			"deposit_addresses": string(depositJSON),
+			"wallet_ids":        string(walletJSON),
+			taggedMetaKey(addrMetaKey(params.Address), params.Tag): params.Asset,
 		}
 		if params.AccountIdentifier != "" {
 			meta["account_identifier"] = params.AccountIdentifier
 		}
+		// Tags are per user, so a shared address maps to this user's own tag
+		if params.Tag != "" {
+			tags := s.getMetadataMap(ctx, userAccount, "deposit_tags")
+			tags[strings.ToLower(params.Address)] = params.Tag
+			tagJSON, _ := json.Marshal(tags)
+			meta["deposit_tags"] = string(tagJSON)
+		}
 	}
 
 	_, err := s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
@@ -79,6 +88,7 @@ func (s *Service) StoreAddress(ctx context.Context, params store.StoreAddressPar
 		Address:           params.Address,
 		WalletId:          params.WalletId,
 		AccountIdentifier: params.AccountIdentifier,
+		Tag:               params.Tag,
 		CreatedAt:         now,
 	}, nil
 }
@@ -120,18 +130,20 @@ func (s *Service) GetAllUserAddresses(ctx context.Context, userId string) ([]mod
 }
 
 // FindUserByAddress queries Formance accounts by the per-address metadata key
-// to find the user account that owns a given deposit or withdrawal address.
-func (s *Service) FindUserByAddress(ctx context.Context, address string) (*models.User, *models.Address, error) {
-	depositKey := addrMetaKey(address)
-	withdrawalKey := withdrawalAddrMetaKey(address)
+// to find the user account that owns a given deposit or withdrawal address, or
+// (address, tag) pair on tag-based networks.
+func (s *Service) FindUserByAddress(ctx context.Context, address, tag string) (*models.User, *models.Address, error) {
+	depositKey := taggedMetaKey(addrMetaKey(address), tag)
+	withdrawalKey := taggedMetaKey(withdrawalAddrMetaKey(address), tag)
 
 	zap.L().Debug("Looking up user by address via metadata query",
-		zap.String("address", address))
+		zap.String("address", address),
+		zap.String("tag", tag))
 
 	// Search both deposit_addr_ and withdrawal_addr_ keys.
 	var orClauses []any
 	depositFilter := "metadata[" + depositKey + "]"
-	for symbol := range assetPrecision {
+	for _, symbol := range assets.Current().Symbols() {
 		orClauses = append(orClauses, map[string]any{
 			"$match": map[string]any{depositFilter: symbol},
 		})
@@ -141,7 +153,7 @@ func (s *Service) FindUserByAddress(ctx context.Context, address string) (*model
 	orClauses = append(orClauses, map[string]any{
 		"$match": map[string]any{withdrawalFilter: "WITHDRAWAL"},
 	})
-	for symbol := range assetPrecision {
+	for _, symbol := range assets.Current().Symbols() {
 		orClauses = append(orClauses, map[string]any{
 			"$match": map[string]any{withdrawalFilter: symbol},
 		})
@@ -169,6 +181,10 @@ func (s *Service) FindUserByAddress(ctx context.Context, address string) (*model
 	if userId == "" || !strings.HasPrefix(acct.Address, "users:") {
 		return nil, nil, nil
 	}
+	// Addresses of deactivated and closed users no longer resolve to them
+	if !isActiveUser(meta) {
+		return nil, nil, nil
+	}
 
 	// Determine asset from whichever key matched (deposit or withdrawal).
 	asset := meta[depositKey]
@@ -191,6 +207,7 @@ func (s *Service) FindUserByAddress(ctx context.Context, address string) (*model
 		Address:           address,
 		WalletId:          walletIDs[asset],
 		AccountIdentifier: meta["account_identifier"],
+		Tag:               tag,
 		CreatedAt:         now,
 	}
 	return user, addr, nil
@@ -208,6 +225,15 @@ func withdrawalAddrMetaKey(address string) string {
 	return "withdrawal_addr_" + strings.ToLower(address)
 }
 
+// taggedMetaKey qualifies an address metadata key with a destination tag, so
+// users sharing one address on a tag-based network get distinct keys.
+func taggedMetaKey(key, tag string) string {
+	if tag == "" {
+		return key
+	}
+	return key + "_tag_" + strings.ToLower(tag)
+}
+
 // getMetadataMap reads a JSON-encoded string map from an account's metadata key.
 func (s *Service) getMetadataMap(ctx context.Context, account, key string) map[string]string {
 	resp, err := s.client.Ledger.V2.GetAccount(ctx, operations.V2GetAccountRequest{
@@ -269,7 +295,7 @@ func parseJSONMapList(raw string) map[string][]string {
 // appendUnique appends a value to a slice only if it's not already present.
 func appendUnique(slice []string, val string) []string {
 	for _, v := range slice {
-		if strings.EqualFold(v, val) {
+		if v == val {
 			return slice
 		}
 	}
@@ -280,6 +306,7 @@ func appendUnique(slice []string, val string) []string {
 func parseAddressesFromMeta(userId, networkFilter, assetFilter string, meta map[string]string) []models.Address {
 	depAddrs := parseJSONMapList(meta["deposit_addresses"])
 	walletIDs := parseJSONMap(meta["wallet_ids"])
+	tags := parseJSONMap(meta["deposit_tags"])
 	if len(depAddrs) == 0 {
 		return nil
 	}
@@ -299,6 +326,7 @@ func parseAddressesFromMeta(userId, networkFilter, assetFilter string, meta map[
 				Address:           addr,
 				WalletId:          walletIDs[asset],
 				AccountIdentifier: meta["account_identifier"],
+				Tag:               tags[strings.ToLower(addr)],
 				CreatedAt:         now,
 			})
 		}
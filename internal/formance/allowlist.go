@@ -0,0 +1,192 @@
+package formance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"go.uber.org/zap"
+)
+
+// Allowlist entries live in the user account's metadata under
+// allowlist_{lower-cased address}, with _tag_{tag} appended for tagged entries,
+// holding the entry as JSON. Removing an address
+// sets removed_at on the entry rather than deleting the key. Like freezes, each
+// change also adds an allowlist_event_{unix nanos} key for the audit log.
+
+const (
+	allowlistKeyPrefix      = "allowlist_"
+	allowlistEventKeyPrefix = "allowlist_event_"
+)
+
+// AddAllowlistAddress approves a withdrawal destination for an active user.
+func (s *Service) AddAllowlistAddress(ctx context.Context, params store.AllowlistParams) (*models.AllowlistEntry, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	meta, err := s.userMetadata(ctx, params.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if !isActiveUser(meta) {
+		return nil, fmt.Errorf("user not found: %s", params.UserId)
+	}
+	address, tag := strings.TrimSpace(params.Address), strings.TrimSpace(params.Tag)
+	if existing, ok := meta[allowlistMetaKey(address, tag)]; ok {
+		var e models.AllowlistEntry
+		if err := json.Unmarshal([]byte(existing), &e); err == nil && e.RemovedAt == nil {
+			return nil, fmt.Errorf("%w: %s", store.ErrAddressAllowlisted, models.AllowlistDestination(address, tag))
+		}
+	}
+
+	now := time.Now().UTC()
+	entry := &models.AllowlistEntry{
+		UserId:           params.UserId,
+		Address:          address,
+		Tag:              tag,
+		Asset:            params.Asset,
+		Network:          params.Network,
+		AddressBookState: params.AddressBookState,
+		AddedBy:          params.Actor,
+		Note:             params.Note,
+		AddedAt:          now,
+		ActiveAt:         now.Add(params.Cooldown),
+	}
+	if err := s.writeAllowlist(ctx, params, entry, models.AllowlistActionAdd, now); err != nil {
+		return nil, err
+	}
+
+	zap.L().Info("Withdrawal address allowlisted",
+		zap.String("user_id", entry.UserId),
+		zap.String("address", entry.Address),
+		zap.String("tag", entry.Tag),
+		zap.String("actor", entry.AddedBy),
+		zap.Time("active_at", entry.ActiveAt))
+	return entry, nil
+}
+
+// RemoveAllowlistAddress takes a destination off the user's allowlist. Adding it
+// again later starts a new cooldown.
+func (s *Service) RemoveAllowlistAddress(ctx context.Context, params store.AllowlistParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	meta, err := s.userMetadata(ctx, params.UserId)
+	if err != nil {
+		return err
+	}
+	address, tag := strings.TrimSpace(params.Address), strings.TrimSpace(params.Tag)
+	var entry models.AllowlistEntry
+	raw, ok := meta[allowlistMetaKey(address, tag)]
+	if ok {
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			return fmt.Errorf("failed to decode allowlist entry for %s: %w", address, err)
+		}
+	}
+	if !ok || entry.RemovedAt != nil {
+		return fmt.Errorf("%w: %s", store.ErrAddressNotAllowlisted, models.AllowlistDestination(address, tag))
+	}
+
+	now := time.Now().UTC()
+	entry.RemovedAt = &now
+	if err := s.writeAllowlist(ctx, params, &entry, models.AllowlistActionRemove, now); err != nil {
+		return err
+	}
+
+	zap.L().Info("Withdrawal address removed from allowlist",
+		zap.String("user_id", params.UserId),
+		zap.String("address", address),
+		zap.String("tag", tag),
+		zap.String("actor", params.Actor))
+	return nil
+}
+
+// writeAllowlist stores the entry and its audit event in a single metadata update.
+func (s *Service) writeAllowlist(ctx context.Context, params store.AllowlistParams, entry *models.AllowlistEntry, action string, now time.Time) error {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode allowlist entry: %w", err)
+	}
+	event, err := json.Marshal(models.AllowlistEvent{
+		UserId:    params.UserId,
+		Address:   entry.Address,
+		Tag:       entry.Tag,
+		Action:    action,
+		Actor:     params.Actor,
+		Note:      params.Note,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode allowlist event: %w", err)
+	}
+
+	_, err = s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+		Ledger:  s.ledger,
+		Address: "users:" + params.UserId,
+		RequestBody: map[string]string{
+			allowlistMetaKey(entry.Address, entry.Tag):                      string(encoded),
+			allowlistEventKeyPrefix + strconv.FormatInt(now.UnixNano(), 10): string(event),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update allowlist: %w", err)
+	}
+	return nil
+}
+
+// GetAllowlist returns the user's live allowlist entries, oldest first.
+func (s *Service) GetAllowlist(ctx context.Context, userId string) ([]models.AllowlistEntry, error) {
+	meta, err := s.userMetadata(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	var entries []models.AllowlistEntry
+	for key, value := range meta {
+		if !strings.HasPrefix(key, allowlistKeyPrefix) || strings.HasPrefix(key, allowlistEventKeyPrefix) {
+			continue
+		}
+		var e models.AllowlistEntry
+		if err := json.Unmarshal([]byte(value), &e); err != nil {
+			return nil, fmt.Errorf("failed to decode allowlist entry %s: %w", key, err)
+		}
+		if e.RemovedAt == nil {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].AddedAt.Before(entries[j].AddedAt) })
+	return entries, nil
+}
+
+// GetAllowlistEvents returns the user's allowlist audit log, oldest first.
+func (s *Service) GetAllowlistEvents(ctx context.Context, userId string) ([]models.AllowlistEvent, error) {
+	meta, err := s.userMetadata(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	var events []models.AllowlistEvent
+	for key, value := range meta {
+		if !strings.HasPrefix(key, allowlistEventKeyPrefix) {
+			continue
+		}
+		var e models.AllowlistEvent
+		if err := json.Unmarshal([]byte(value), &e); err != nil {
+			return nil, fmt.Errorf("failed to decode allowlist event %s: %w", key, err)
+		}
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+	return events, nil
+}
+
+// allowlistMetaKey returns the metadata key for an allowlisted address and tag.
+func allowlistMetaKey(address, tag string) string {
+	return taggedMetaKey(allowlistKeyPrefix+strings.ToLower(address), tag)
+}
@@ -2,6 +2,7 @@ package formance
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"time"
 
@@ -14,18 +15,38 @@ import (
 	"go.uber.org/zap"
 )
 
-// GetUserBalance returns the current balance for a user and asset.
-// Queries the single users:{userId} account directly.
+// GetUserBalance returns the current total balance for a user and asset: the
+// users:{userId} account plus any funds held in users:{userId}:holds.
 func (s *Service) GetUserBalance(ctx context.Context, userId, asset string) (decimal.Decimal, error) {
 	zap.L().Debug("Getting user balance from Formance",
 		zap.String("user_id", userId), zap.String("asset", asset))
 
+	detail, err := s.GetUserBalanceDetail(ctx, userId, asset)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return detail.Total, nil
+}
+
+// GetUserBalanceAt returns the balance for a user and asset as of a point in time.
+// Uses the volumes endpoint bounded by endTime on effective dates (not insertion
+// dates), so backdated Prime transactions land at their real effective time.
+func (s *Service) GetUserBalanceAt(ctx context.Context, userId, asset string, at time.Time) (decimal.Decimal, error) {
+	zap.L().Debug("Getting point-in-time user balance from Formance",
+		zap.String("user_id", userId), zap.String("asset", asset), zap.Time("at", at))
+
 	fAsset := formanceAsset(asset)
-	vols := s.getAccountVolumes(ctx, "users:"+userId)
-	if bal := volumeBalance(vols, fAsset); bal != nil {
-		return bigIntToDecimal(bal, asset), nil
+	total := decimal.Zero
+	for _, addr := range []string{"users:" + userId, userHoldsAccount(userId)} {
+		vols, err := s.getAccountVolumesAt(ctx, addr, at)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if bal := volumeBalance(vols, fAsset); bal != nil {
+			total = total.Add(bigIntToDecimal(bal, asset))
+		}
 	}
-	return decimal.Zero, nil
+	return total, nil
 }
 
 // GetAllUserBalances returns all non-zero balances for a user.
@@ -37,11 +58,25 @@ func (s *Service) GetAllUserBalances(ctx context.Context, userId string) ([]mode
 	acctTime := s.getAccountUpdatedAt(ctx, addr)
 	lastTx := s.getLastTransactionForAccount(ctx, addr)
 
+	// Held funds still belong to the user
+	totals := make(map[string]*big.Int)
+	for _, v := range []map[string]shared.V2Volume{vols, s.getAccountVolumes(ctx, userHoldsAccount(userId))} {
+		for fAsset, vol := range v {
+			bal := volumeBalance(map[string]shared.V2Volume{fAsset: vol}, fAsset)
+			if bal == nil {
+				continue
+			}
+			if totals[fAsset] == nil {
+				totals[fAsset] = new(big.Int)
+			}
+			totals[fAsset].Add(totals[fAsset], bal)
+		}
+	}
+
 	now := acctTime
 	var balances []models.AccountBalance
-	for fAsset, vol := range vols {
-		bal := volumeBalance(map[string]shared.V2Volume{fAsset: vol}, fAsset)
-		if bal == nil || bal.Sign() == 0 {
+	for fAsset, bal := range totals {
+		if bal.Sign() == 0 {
 			continue
 		}
 		symbol := assetSymbol(fAsset)
@@ -79,6 +114,41 @@ func (s *Service) getAccountVolumes(ctx context.Context, address string) map[str
 	return resp.V2AccountResponse.Data.Volumes
 }
 
+// getAccountVolumesAt fetches the volumes of a single account as of a point in time.
+// The ledger treats endTime as exclusive, so it is pushed forward by the ledger's
+// microsecond resolution to include transactions effective exactly at `at`.
+func (s *Service) getAccountVolumesAt(ctx context.Context, address string, at time.Time) (map[string]shared.V2Volume, error) {
+	endTime := at.Add(time.Microsecond)
+	vols := make(map[string]shared.V2Volume)
+	req := operations.V2GetVolumesWithBalancesRequest{
+		Ledger:        s.ledger,
+		EndTime:       &endTime,
+		InsertionDate: ptrBool(false),
+		RequestBody: map[string]any{
+			"$match": map[string]any{"account": address},
+		},
+	}
+	for {
+		resp, err := s.client.Ledger.V2.GetVolumesWithBalances(ctx, req)
+		if err != nil {
+			zap.L().Warn("Failed to get point-in-time volumes", zap.String("address", address), zap.Error(err))
+			return nil, fmt.Errorf("failed to get volumes for %s at %s: %w", address, at.Format(time.RFC3339), err)
+		}
+		cursor := resp.V2VolumesWithBalanceCursorResponse.Cursor
+		for _, v := range cursor.Data {
+			if v.Account != address {
+				continue
+			}
+			vols[v.Asset] = shared.V2Volume{Input: v.Input, Output: v.Output, Balance: v.Balance}
+		}
+		if !cursor.HasMore || cursor.Next == nil {
+			break
+		}
+		req = operations.V2GetVolumesWithBalancesRequest{Ledger: s.ledger, Cursor: cursor.Next}
+	}
+	return vols, nil
+}
+
 // getAccountUpdatedAt returns the last updated timestamp for an account.
 func (s *Service) getAccountUpdatedAt(ctx context.Context, address string) time.Time {
 	resp, err := s.client.Ledger.V2.GetAccount(ctx, operations.V2GetAccountRequest{
@@ -146,11 +216,7 @@ func bigIntToDecimal(raw *big.Int, symbol string) decimal.Decimal {
 	if raw == nil {
 		return decimal.Zero
 	}
-	p := 6
-	if v, ok := assetPrecision[symbol]; ok {
-		p = v
-	}
-	return decimal.NewFromBigInt(raw, -int32(p))
+	return decimal.NewFromBigInt(raw, -int32(precisionFor(symbol)))
 }
 
 // assetSymbol extracts the symbol from a Formance asset like "USDC/6".
@@ -0,0 +1,182 @@
+package formance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"go.uber.org/zap"
+)
+
+// Freeze state lives in the user account's metadata (frozen, frozen_reason, ...).
+// Each freeze or unfreeze also adds a freeze_event_{unix nanos} key holding the
+// event as JSON, so the audit log is append-only and travels with the account.
+
+const freezeEventKeyPrefix = "freeze_event_"
+
+// FreezeUser blocks withdrawals for the user and sends their deposits to quarantine.
+func (s *Service) FreezeUser(ctx context.Context, params store.FreezeParams) (*models.FreezeStatus, error) {
+	return s.setFrozen(ctx, params, true)
+}
+
+// UnfreezeUser lifts a freeze. Deposits already quarantined stay in quarantine.
+func (s *Service) UnfreezeUser(ctx context.Context, params store.FreezeParams) (*models.FreezeStatus, error) {
+	return s.setFrozen(ctx, params, false)
+}
+
+// setFrozen writes the new state and its audit event in a single metadata update.
+func (s *Service) setFrozen(ctx context.Context, params store.FreezeParams, frozen bool) (*models.FreezeStatus, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	current, err := s.GetFreezeStatus(ctx, params.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if current.Frozen == frozen {
+		if frozen {
+			return nil, fmt.Errorf("user %s is already frozen", params.UserId)
+		}
+		return nil, fmt.Errorf("user %s is not frozen", params.UserId)
+	}
+
+	action := models.FreezeActionUnfreeze
+	if frozen {
+		action = models.FreezeActionFreeze
+	}
+	now := time.Now().UTC()
+	event, err := json.Marshal(models.FreezeEvent{
+		UserId:    params.UserId,
+		Action:    action,
+		Reason:    params.Reason,
+		Actor:     params.Actor,
+		Note:      params.Note,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode freeze event: %w", err)
+	}
+
+	_, err = s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+		Ledger:  s.ledger,
+		Address: "users:" + params.UserId,
+		RequestBody: map[string]string{
+			"frozen":            strconv.FormatBool(frozen),
+			"frozen_reason":     params.Reason,
+			"frozen_by":         params.Actor,
+			"frozen_note":       params.Note,
+			"frozen_updated_at": now.Format(time.RFC3339Nano),
+			freezeEventKeyPrefix + strconv.FormatInt(now.UnixNano(), 10): string(event),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update freeze status: %w", err)
+	}
+
+	zap.L().Warn("User freeze status changed",
+		zap.String("user_id", params.UserId),
+		zap.String("action", action),
+		zap.String("reason", params.Reason),
+		zap.String("actor", params.Actor))
+	return s.GetFreezeStatus(ctx, params.UserId)
+}
+
+// GetFreezeStatus returns the user's current freeze state.
+func (s *Service) GetFreezeStatus(ctx context.Context, userId string) (*models.FreezeStatus, error) {
+	meta, err := s.userMetadata(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.FreezeStatus{
+		UserId: userId,
+		Frozen: meta["frozen"] == "true",
+		Reason: meta["frozen_reason"],
+		Actor:  meta["frozen_by"],
+		Note:   meta["frozen_note"],
+	}
+	if t, err := time.Parse(time.RFC3339Nano, meta["frozen_updated_at"]); err == nil {
+		status.UpdatedAt = t
+	}
+	return status, nil
+}
+
+// GetFreezeEvents returns the user's freeze audit log, oldest first.
+func (s *Service) GetFreezeEvents(ctx context.Context, userId string) ([]models.FreezeEvent, error) {
+	meta, err := s.userMetadata(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	var events []models.FreezeEvent
+	for key, value := range meta {
+		if !strings.HasPrefix(key, freezeEventKeyPrefix) {
+			continue
+		}
+		var e models.FreezeEvent
+		if err := json.Unmarshal([]byte(value), &e); err != nil {
+			return nil, fmt.Errorf("failed to decode freeze event %s: %w", key, err)
+		}
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+	return events, nil
+}
+
+// userMetadata returns the metadata of an end user's account.
+func (s *Service) userMetadata(ctx context.Context, userId string) (map[string]string, error) {
+	resp, err := s.client.Ledger.V2.GetAccount(ctx, operations.V2GetAccountRequest{
+		Ledger:  s.ledger,
+		Address: "users:" + userId,
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, fmt.Errorf("user not found: %s", userId)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	meta := resp.V2AccountResponse.Data.Metadata
+	if meta["email"] == "" {
+		return nil, fmt.Errorf("user not found: %s", userId)
+	}
+	return meta, nil
+}
+
+// checkNotFrozen returns ErrUserFrozen if the user is frozen. Platform accounts
+// have no user metadata and are never frozen.
+func (s *Service) checkNotFrozen(ctx context.Context, userId string) error {
+	if strings.HasPrefix(userId, "prime-platform") {
+		return nil
+	}
+	status, err := s.GetFreezeStatus(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if status.Frozen {
+		return fmt.Errorf("%w: %s (%s)", store.ErrUserFrozen, userId, status.Reason)
+	}
+	return nil
+}
+
+// depositRecipient returns the account a deposit for userId should credit: the user,
+// or the quarantine platform user while the user is frozen. quarantinedUserId is set
+// only in the second case.
+func (s *Service) depositRecipient(ctx context.Context, userId string) (recipient, quarantinedUserId string, err error) {
+	status, err := s.GetFreezeStatus(ctx, userId)
+	if err != nil {
+		return "", "", fmt.Errorf("error checking freeze status: %w", err)
+	}
+	if !status.Frozen {
+		return userId, "", nil
+	}
+	zap.L().Warn("Deposit to frozen user quarantined",
+		zap.String("user_id", userId),
+		zap.String("freeze_reason", status.Reason))
+	return store.QuarantineUserId(s.portfolioID), userId, nil
+}
@@ -0,0 +1,103 @@
+package formance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/shared"
+	"go.uber.org/zap"
+)
+
+const defaultExportChunkSize = 100
+
+// ExportPostings streams every posting with a timestamp in [query.From, query.To) to
+// handle, one ListTransactions page at a time. Transactions are ordered by ledger id,
+// which is immutable, so repeated exports of the same range produce identical output.
+// Each posting yields a credit row on its source and a debit row on its destination.
+func (s *Service) ExportPostings(ctx context.Context, query store.ExportQuery, handle store.PostingHandler) error {
+	chunkSize := int64(query.ChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = defaultExportChunkSize
+	}
+
+	var clauses []any
+	if !query.From.IsZero() {
+		clauses = append(clauses, map[string]any{"$gte": map[string]any{"timestamp": query.From.UTC().Format(time.RFC3339Nano)}})
+	}
+	if !query.To.IsZero() {
+		clauses = append(clauses, map[string]any{"$lt": map[string]any{"timestamp": query.To.UTC().Format(time.RFC3339Nano)}})
+	}
+	req := operations.V2ListTransactionsRequest{
+		Ledger:   s.ledger,
+		PageSize: &chunkSize,
+		Sort:     strPtr("id:asc"),
+	}
+	switch len(clauses) {
+	case 0:
+	case 1:
+		req.RequestBody = clauses[0].(map[string]any)
+	default:
+		req.RequestBody = map[string]any{"$and": clauses}
+	}
+
+	for {
+		resp, err := s.client.Ledger.V2.ListTransactions(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to list transactions for export: %w", err)
+		}
+
+		cursor := resp.V2TransactionsCursorResponse.Cursor
+		var postings []store.Posting
+		for _, tx := range cursor.Data {
+			postings = append(postings, exportPostings(tx)...)
+		}
+
+		zap.L().Debug("Exporting postings chunk",
+			zap.Int("transactions", len(cursor.Data)),
+			zap.Int("postings", len(postings)))
+
+		if len(postings) > 0 {
+			if err := handle(postings); err != nil {
+				return err
+			}
+		}
+
+		if !cursor.HasMore || cursor.Next == nil {
+			return nil
+		}
+		// Only the cursor may be set when following a page
+		req = operations.V2ListTransactionsRequest{Ledger: s.ledger, Cursor: cursor.Next}
+	}
+}
+
+// exportPostings flattens a Formance transaction into export rows.
+func exportPostings(tx shared.V2Transaction) []store.Posting {
+	postings := make([]store.Posting, 0, 2*len(tx.Postings))
+	for _, p := range tx.Postings {
+		symbol := assetSymbol(p.Asset)
+		amount := bigIntToDecimal(p.Amount, symbol)
+		base := store.Posting{
+			TransactionId: fmt.Sprintf("%d", tx.ID),
+			EffectiveAt:   tx.Timestamp,
+			Asset:         symbol,
+			Amount:        amount,
+			ExternalTxId:  tx.Metadata["external_tx_id"],
+			Metadata:      tx.Metadata,
+		}
+
+		debit := base
+		debit.Sequence = len(postings)
+		debit.Account, debit.Counterparty, debit.Direction = p.Destination, p.Source, store.DirectionDebit
+		postings = append(postings, debit)
+
+		credit := base
+		credit.Sequence = len(postings)
+		credit.Account, credit.Counterparty, credit.Direction = p.Source, p.Destination, store.DirectionCredit
+		postings = append(postings, credit)
+	}
+	return postings
+}
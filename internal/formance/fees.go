@@ -0,0 +1,116 @@
+package formance
+
+import (
+	"context"
+	"fmt"
+
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/shared"
+	"go.uber.org/zap"
+)
+
+const numscriptFeeCharge = `vars {
+  asset $asset
+  number $amount
+  account $user_id
+  account $fee_kind
+  string $fee_reference
+  string $asset_symbol
+  string $amount_human
+  string $description
+}
+
+send [$asset $amount] (
+  source = @users:$user_id
+  destination = @fees:$fee_kind
+)
+
+set_tx_meta("event_type", "fee_charge")
+set_tx_meta("external_tx_id", $fee_reference)
+set_tx_meta("fee_reference", $fee_reference)
+set_tx_meta("fee_kind", $fee_kind)
+set_tx_meta("asset_symbol", $asset_symbol)
+set_tx_meta("amount_human", $amount_human)
+set_tx_meta("description", $description)
+`
+
+// ChargeFee moves a fee from the user to fees:{kind}, referenced by the fee reference
+// so Formance rejects a second charge. The user account may not overdraw.
+func (s *Service) ChargeFee(ctx context.Context, params store.FeeChargeParams) error {
+	if params.Reference == "" || !validAccountSegment.MatchString(params.Kind) {
+		return fmt.Errorf("fee reference and a valid kind are required")
+	}
+	if !params.Amount.IsPositive() {
+		return fmt.Errorf("fee amount must be positive, got %s", params.Amount)
+	}
+	precision := int32(precisionFor(params.Asset))
+	minor := params.Amount.Shift(precision)
+	if !minor.Equal(minor.Truncate(0)) {
+		return fmt.Errorf("fee %s has more than %d decimals", params.Amount, precision)
+	}
+
+	postTx := shared.V2PostTransaction{
+		Reference: strPtr(params.Reference),
+		Script: &shared.V2PostTransactionScript{
+			Plain: numscriptFeeCharge,
+			Vars: map[string]string{
+				"asset":         formanceAsset(params.Asset),
+				"amount":        minor.String(),
+				"user_id":       params.UserId,
+				"fee_kind":      params.Kind,
+				"fee_reference": params.Reference,
+				"asset_symbol":  params.Asset,
+				"amount_human":  params.Amount.String(),
+				"description":   params.Description,
+			},
+		},
+	}
+	for k, v := range params.Metadata {
+		if v == "" {
+			continue
+		}
+		if postTx.Metadata == nil {
+			postTx.Metadata = make(map[string]string)
+		}
+		postTx.Metadata[k] = v
+	}
+
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
+		Ledger:            s.ledger,
+		V2PostTransaction: postTx,
+	})
+	if err != nil {
+		if isConflictError(err) {
+			return fmt.Errorf("%w: fee %s already charged", store.ErrDuplicateTransaction, params.Reference)
+		}
+		return fmt.Errorf("failed to charge %s fee: %w", params.Kind, err)
+	}
+
+	zap.L().Info("Fee charged in Formance",
+		zap.String("reference", params.Reference),
+		zap.String("user_id", params.UserId),
+		zap.String("asset", params.Asset),
+		zap.String("amount", params.Amount.String()),
+		zap.String("kind", params.Kind))
+	return nil
+}
+
+// HasFeeCharge reports whether a fee with this reference has been charged.
+func (s *Service) HasFeeCharge(ctx context.Context, reference string) (bool, error) {
+	pageSize := int64(1)
+	resp, err := s.client.Ledger.V2.ListTransactions(ctx, operations.V2ListTransactionsRequest{
+		Ledger:   s.ledger,
+		PageSize: &pageSize,
+		RequestBody: map[string]any{
+			"$match": map[string]any{
+				"metadata[fee_reference]": reference,
+			},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check fee charge: %w", err)
+	}
+	return len(resp.V2TransactionsCursorResponse.Cursor.Data) > 0, nil
+}
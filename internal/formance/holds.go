@@ -0,0 +1,451 @@
+package formance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/shared"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Held funds move from users:{id} to users:{id}:holds, so users:{id} is what the user
+// can spend and the two together are the user's total. Each hold is one placement
+// transaction whose metadata carries the hold's state, and at most one settlement
+// transaction (capture, release or expiry) referenced hold-{id}-settle, which the
+// ledger refuses to post twice.
+
+const numscriptHoldPlaced = `vars {
+  asset $asset
+  number $amount
+  account $user_id
+  string $hold_id
+  string $asset_symbol
+  string $amount_human
+  string $expires_at
+  string $reason
+}
+
+send [$asset $amount] (
+  source = @users:$user_id
+  destination = @users:$user_id:holds
+)
+
+set_tx_meta("event_type", "hold_placed")
+set_tx_meta("hold_id", $hold_id)
+set_tx_meta("hold_status", "active")
+set_tx_meta("user_id", $user_id)
+set_tx_meta("asset_symbol", $asset_symbol)
+set_tx_meta("amount_human", $amount_human)
+set_tx_meta("expires_at", $expires_at)
+set_tx_meta("reason", $reason)
+`
+
+const numscriptHoldReleased = `vars {
+  asset $asset
+  number $amount
+  account $user_id
+  string $hold_id
+  string $hold_status
+}
+
+send [$asset $amount] (
+  source = @users:$user_id:holds
+  destination = @users:$user_id
+)
+
+set_tx_meta("event_type", "hold_settled")
+set_tx_meta("hold_id", $hold_id)
+set_tx_meta("hold_status", $hold_status)
+set_tx_meta("hold_captured", "0")
+`
+
+// holdCaptureScript sends the captured part of a hold to the captured holds account
+// and returns the rest to the user. The split is written into the script because the
+// destination allocation needs literal amounts.
+func holdCaptureScript(fAsset string, held, captured decimal.Decimal) string {
+	destination := "@prime:portfolio:$portfolio_id:holds:captured"
+	if captured.LessThan(held) {
+		destination = fmt.Sprintf(`{
+    max [%s %s] to @prime:portfolio:$portfolio_id:holds:captured
+    remaining to @users:$user_id
+  }`, fAsset, captured.String())
+	}
+	return fmt.Sprintf(`vars {
+  account $user_id
+  account $portfolio_id
+  string $hold_id
+  string $hold_captured
+  string $asset_symbol
+}
+
+send [%s %s] (
+  source = @users:$user_id:holds
+  destination = %s
+)
+
+set_tx_meta("event_type", "hold_settled")
+set_tx_meta("hold_id", $hold_id)
+set_tx_meta("hold_status", "captured")
+set_tx_meta("hold_captured", $hold_captured)
+set_tx_meta("asset_symbol", $asset_symbol)
+`, fAsset, held.String(), destination)
+}
+
+func userHoldsAccount(userId string) string {
+	return "users:" + userId + store.HoldsAccountSuffix
+}
+
+// GetUserBalanceDetail reads the user's available and holds accounts. Expired holds
+// keep their funds in the holds account until ExpireHolds releases them.
+func (s *Service) GetUserBalanceDetail(ctx context.Context, userId, asset string) (*models.BalanceDetail, error) {
+	fAsset := formanceAsset(asset)
+	available, held := decimal.Zero, decimal.Zero
+	if bal := volumeBalance(s.getAccountVolumes(ctx, "users:"+userId), fAsset); bal != nil {
+		available = bigIntToDecimal(bal, asset)
+	}
+	if bal := volumeBalance(s.getAccountVolumes(ctx, userHoldsAccount(userId)), fAsset); bal != nil {
+		held = bigIntToDecimal(bal, asset)
+	}
+	return &models.BalanceDetail{
+		UserId:    userId,
+		Asset:     asset,
+		Total:     available.Add(held),
+		Held:      held,
+		Available: available,
+	}, nil
+}
+
+// PlaceHold moves the amount into the user's holds account. The user account may not
+// overdraw, so a hold larger than the available balance is rejected by the ledger. A
+// frozen user's funds cannot be held.
+func (s *Service) PlaceHold(ctx context.Context, params store.PlaceHoldParams) (*models.Hold, error) {
+	if params.HoldId == "" || !validAccountSegment.MatchString(params.UserId) || params.Asset == "" {
+		return nil, fmt.Errorf("hold id, a valid user id and asset are required")
+	}
+	if !params.Amount.IsPositive() {
+		return nil, fmt.Errorf("hold amount must be positive, got %s", params.Amount)
+	}
+	if !params.ExpiresAt.IsZero() && !params.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("hold expiry %s is in the past", params.ExpiresAt.Format(time.RFC3339))
+	}
+	if err := s.checkNotFrozen(ctx, params.UserId); err != nil {
+		return nil, err
+	}
+	expiresAt := ""
+	if !params.ExpiresAt.IsZero() {
+		expiresAt = params.ExpiresAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
+		Ledger: s.ledger,
+		V2PostTransaction: shared.V2PostTransaction{
+			Reference: strPtr("hold-" + params.HoldId),
+			Script: &shared.V2PostTransactionScript{
+				Plain: numscriptHoldPlaced,
+				Vars: map[string]string{
+					"asset":        formanceAsset(params.Asset),
+					"amount":       params.Amount.Shift(int32(precisionFor(params.Asset))).BigInt().String(),
+					"user_id":      params.UserId,
+					"hold_id":      params.HoldId,
+					"asset_symbol": params.Asset,
+					"amount_human": params.Amount.String(),
+					"expires_at":   expiresAt,
+					"reason":       params.Reason,
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConflictError(err) {
+			return nil, fmt.Errorf("%w: hold %s already exists", store.ErrDuplicateTransaction, params.HoldId)
+		}
+		if isInsufficientFundError(err) {
+			return nil, fmt.Errorf("%w: hold %s %s", store.ErrInsufficientAvailable, params.Amount, params.Asset)
+		}
+		return nil, fmt.Errorf("failed to place hold: %w", err)
+	}
+
+	zap.L().Info("Hold placed in Formance",
+		zap.String("hold_id", params.HoldId),
+		zap.String("user_id", params.UserId),
+		zap.String("asset", params.Asset),
+		zap.String("amount", params.Amount.String()))
+	return s.GetHold(ctx, params.HoldId)
+}
+
+// CaptureHold sends amount (zero for the whole hold) to the captured holds account and
+// returns any remainder to the user. Capturing is a debit, so it fails with
+// ErrUserFrozen while the user is frozen.
+func (s *Service) CaptureHold(ctx context.Context, holdId string, amount decimal.Decimal) (*models.Hold, error) {
+	hold, placement, err := s.activeHold(ctx, holdId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNotFrozen(ctx, hold.UserId); err != nil {
+		return nil, err
+	}
+	if amount.IsZero() {
+		amount = hold.Amount
+	}
+	if amount.IsNegative() || amount.GreaterThan(hold.Amount) {
+		return nil, fmt.Errorf("capture amount %s must be between 0 and the held %s", amount, hold.Amount)
+	}
+
+	precision := int32(precisionFor(hold.Asset))
+	minor := amount.Shift(precision)
+	if !minor.Equal(minor.Truncate(0)) {
+		return nil, fmt.Errorf("capture amount %s has more than %d decimals", amount, precision)
+	}
+	script := holdCaptureScript(formanceAsset(hold.Asset), hold.Amount.Shift(precision), minor)
+	vars := map[string]string{
+		"user_id":       hold.UserId,
+		"portfolio_id":  s.portfolioID,
+		"hold_id":       hold.Id,
+		"hold_captured": amount.String(),
+		"asset_symbol":  hold.Asset,
+	}
+	if err := s.settleHold(ctx, hold, placement, script, vars, models.HoldStatusCaptured, amount); err != nil {
+		return nil, err
+	}
+	zap.L().Info("Hold captured in Formance",
+		zap.String("hold_id", hold.Id),
+		zap.String("captured", amount.String()),
+		zap.String("held", hold.Amount.String()))
+	return s.GetHold(ctx, holdId)
+}
+
+// ReleaseHold returns the held funds to the user.
+func (s *Service) ReleaseHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	hold, placement, err := s.activeHold(ctx, holdId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.releaseHold(ctx, hold, placement, models.HoldStatusReleased); err != nil {
+		return nil, err
+	}
+	zap.L().Info("Hold released in Formance", zap.String("hold_id", hold.Id))
+	return s.GetHold(ctx, holdId)
+}
+
+// GetHold returns a hold in any status.
+func (s *Service) GetHold(ctx context.Context, holdId string) (*models.Hold, error) {
+	placement, err := s.findHoldTransaction(ctx, holdId, "hold_placed")
+	if err != nil {
+		return nil, err
+	}
+	if placement == nil {
+		return nil, fmt.Errorf("%w: %s", store.ErrHoldNotFound, holdId)
+	}
+	hold := toHold(*placement)
+	if hold.Status == models.HoldStatusActive {
+		// The settlement is the source of truth if updating the placement failed
+		settlement, err := s.findHoldTransaction(ctx, holdId, "hold_settled")
+		if err != nil {
+			return nil, err
+		}
+		if settlement != nil {
+			hold.Status = settlement.Metadata["hold_status"]
+			hold.Captured, _ = decimal.NewFromString(settlement.Metadata["hold_captured"])
+			hold.SettledAt = settlement.Timestamp
+		}
+	}
+	return &hold, nil
+}
+
+// GetActiveHolds returns the user's unexpired active holds; an empty asset means all assets.
+func (s *Service) GetActiveHolds(ctx context.Context, userId, asset string) ([]models.Hold, error) {
+	match := []any{map[string]any{"$match": map[string]any{"metadata[user_id]": userId}}}
+	if asset != "" {
+		match = append(match, map[string]any{"$match": map[string]any{"metadata[asset_symbol]": asset}})
+	}
+	placements, err := s.listActiveHolds(ctx, match...)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var holds []models.Hold
+	for _, h := range placements {
+		if !h.Expired(now) {
+			holds = append(holds, h)
+		}
+	}
+	return holds, nil
+}
+
+// ExpireHolds releases every active hold past its expiry back to its user.
+func (s *Service) ExpireHolds(ctx context.Context, now time.Time) ([]models.Hold, error) {
+	placements, err := s.listActiveHolds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var expired []models.Hold
+	for _, h := range placements {
+		if !h.Expired(now) {
+			continue
+		}
+		placement, err := s.findHoldTransaction(ctx, h.Id, "hold_placed")
+		if err != nil {
+			return expired, err
+		}
+		if err := s.releaseHold(ctx, h, placement, models.HoldStatusExpired); err != nil {
+			if errors.Is(err, store.ErrHoldNotActive) {
+				continue
+			}
+			return expired, err
+		}
+		h.Status, h.SettledAt = models.HoldStatusExpired, now
+		expired = append(expired, h)
+		zap.L().Info("Hold expired in Formance", zap.String("hold_id", h.Id), zap.String("user_id", h.UserId))
+	}
+	return expired, nil
+}
+
+// activeHold returns a hold that can still be captured or released and its placement
+// transaction. A hold past its expiry is released as expired.
+func (s *Service) activeHold(ctx context.Context, holdId string) (models.Hold, *shared.V2Transaction, error) {
+	hold, err := s.GetHold(ctx, holdId)
+	if err != nil {
+		return models.Hold{}, nil, err
+	}
+	if hold.Status != models.HoldStatusActive {
+		return models.Hold{}, nil, fmt.Errorf("%w: hold %s is %s", store.ErrHoldNotActive, holdId, hold.Status)
+	}
+	placement, err := s.findHoldTransaction(ctx, holdId, "hold_placed")
+	if err != nil {
+		return models.Hold{}, nil, err
+	}
+	if hold.Expired(time.Now()) {
+		if err := s.releaseHold(ctx, *hold, placement, models.HoldStatusExpired); err != nil {
+			return models.Hold{}, nil, err
+		}
+		return models.Hold{}, nil, fmt.Errorf("%w: hold %s expired at %s", store.ErrHoldNotActive, holdId, hold.ExpiresAt.Format(time.RFC3339))
+	}
+	return *hold, placement, nil
+}
+
+func (s *Service) releaseHold(ctx context.Context, hold models.Hold, placement *shared.V2Transaction, status string) error {
+	vars := map[string]string{
+		"asset":       formanceAsset(hold.Asset),
+		"amount":      hold.Amount.Shift(int32(precisionFor(hold.Asset))).BigInt().String(),
+		"user_id":     hold.UserId,
+		"hold_id":     hold.Id,
+		"hold_status": status,
+	}
+	return s.settleHold(ctx, hold, placement, numscriptHoldReleased, vars, status, decimal.Zero)
+}
+
+// settleHold posts the hold's one settlement transaction, then records the outcome on
+// the placement so GetActiveHolds can filter on it.
+func (s *Service) settleHold(ctx context.Context, hold models.Hold, placement *shared.V2Transaction, script string, vars map[string]string, status string, captured decimal.Decimal) error {
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
+		Ledger: s.ledger,
+		V2PostTransaction: shared.V2PostTransaction{
+			Reference: strPtr("hold-" + hold.Id + "-settle"),
+			Script:    &shared.V2PostTransactionScript{Plain: script, Vars: vars},
+		},
+	})
+	if err != nil {
+		if isConflictError(err) {
+			return fmt.Errorf("%w: hold %s is already settled", store.ErrHoldNotActive, hold.Id)
+		}
+		return fmt.Errorf("failed to settle hold %s: %w", hold.Id, err)
+	}
+
+	_, err = s.client.Ledger.V2.AddMetadataOnTransaction(ctx, operations.V2AddMetadataOnTransactionRequest{
+		Ledger: s.ledger,
+		ID:     placement.ID,
+		RequestBody: map[string]string{
+			"hold_status":   status,
+			"hold_captured": captured.String(),
+			"settled_at":    time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	})
+	if err != nil {
+		zap.L().Warn("Hold settled but its placement metadata was not updated",
+			zap.String("hold_id", hold.Id), zap.String("status", status), zap.Error(err))
+	}
+	return nil
+}
+
+// findHoldTransaction returns the hold's placement or settlement transaction, or nil.
+func (s *Service) findHoldTransaction(ctx context.Context, holdId, eventType string) (*shared.V2Transaction, error) {
+	pageSize := int64(1)
+	resp, err := s.client.Ledger.V2.ListTransactions(ctx, operations.V2ListTransactionsRequest{
+		Ledger:   s.ledger,
+		PageSize: &pageSize,
+		RequestBody: map[string]any{
+			"$and": []any{
+				map[string]any{"$match": map[string]any{"metadata[hold_id]": holdId}},
+				map[string]any{"$match": map[string]any{"metadata[event_type]": eventType}},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find hold %s: %w", holdId, err)
+	}
+	if data := resp.V2TransactionsCursorResponse.Cursor.Data; len(data) > 0 {
+		return &data[0], nil
+	}
+	return nil, nil
+}
+
+// listActiveHolds pages through the placements still marked active, with extra filters.
+func (s *Service) listActiveHolds(ctx context.Context, filters ...any) ([]models.Hold, error) {
+	clauses := append([]any{
+		map[string]any{"$match": map[string]any{"metadata[event_type]": "hold_placed"}},
+		map[string]any{"$match": map[string]any{"metadata[hold_status]": models.HoldStatusActive}},
+	}, filters...)
+	req := operations.V2ListTransactionsRequest{
+		Ledger:      s.ledger,
+		PageSize:    ptrInt64(100),
+		RequestBody: map[string]any{"$and": clauses},
+	}
+
+	var holds []models.Hold
+	for {
+		resp, err := s.client.Ledger.V2.ListTransactions(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list holds: %w", err)
+		}
+		cursor := resp.V2TransactionsCursorResponse.Cursor
+		for _, tx := range cursor.Data {
+			holds = append(holds, toHold(tx))
+		}
+		if !cursor.HasMore || cursor.Next == nil {
+			break
+		}
+		req = operations.V2ListTransactionsRequest{Ledger: s.ledger, Cursor: cursor.Next}
+	}
+	return holds, nil
+}
+
+// toHold reads a hold from its placement transaction's metadata.
+func toHold(tx shared.V2Transaction) models.Hold {
+	meta := tx.Metadata
+	hold := models.Hold{
+		Id:        meta["hold_id"],
+		UserId:    meta["user_id"],
+		Asset:     meta["asset_symbol"],
+		Status:    meta["hold_status"],
+		Reason:    meta["reason"],
+		CreatedAt: tx.Timestamp,
+	}
+	hold.Amount, _ = decimal.NewFromString(meta["amount_human"])
+	if captured, err := decimal.NewFromString(meta["hold_captured"]); err == nil {
+		hold.Captured = captured
+	}
+	if t, err := time.Parse(time.RFC3339Nano, meta["expires_at"]); err == nil {
+		hold.ExpiresAt = t
+	}
+	if t, err := time.Parse(time.RFC3339Nano, meta["settled_at"]); err == nil {
+		hold.SettledAt = t
+	}
+	return hold
+}
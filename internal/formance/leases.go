@@ -0,0 +1,247 @@
+package formance
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/shared"
+	"go.uber.org/zap"
+)
+
+// A lease lives in the metadata of a leases:{name} account. Taking a lease over
+// posts a zero-amount LEASE transaction referenced lease:{name}:{token} that also
+// writes the new holder into the account metadata, so Formance's unique
+// references let only one contender claim each token. Renewals only move the
+// expiry: they write the two time keys, never the holder or token, and re-read
+// the lease afterwards, failing with store.ErrLeaseLost if a takeover landed in
+// between. A takeover that lands after that re-read is caught by the fence
+// check on the old holder's next write.
+
+const (
+	leaseHolderKey     = "lease_holder"
+	leaseTokenKey      = "lease_token"
+	leaseAcquiredAtKey = "lease_acquired_at"
+	leaseRenewedAtKey  = "lease_renewed_at"
+	leaseExpiresAtKey  = "lease_expires_at"
+	leaseAsset         = "LEASE"
+)
+
+// leaseAccount returns the account holding the named lease's metadata.
+func leaseAccount(name string) (string, error) {
+	for _, segment := range strings.Split(name, ":") {
+		if !validAccountSegment.MatchString(segment) {
+			return "", fmt.Errorf("invalid lease name %q", name)
+		}
+	}
+	return "leases:" + name, nil
+}
+
+// AcquireLease acquires, renews or takes over a named lease. Expiry is judged by
+// this process's clock, so hosts sharing a lease need roughly synchronised clocks.
+func (s *Service) AcquireLease(ctx context.Context, params store.AcquireLeaseParams) (*models.Lease, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	account, err := leaseAccount(params.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.GetLease(ctx, params.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	lease := &models.Lease{
+		Name:       params.Name,
+		Holder:     params.Holder,
+		Token:      1,
+		AcquiredAt: now,
+		RenewedAt:  now,
+		ExpiresAt:  now.Add(params.TTL),
+	}
+
+	switch {
+	case current != nil && current.Holder == params.Holder && !current.Expired(now):
+		lease.Token = current.Token
+		lease.AcquiredAt = current.AcquiredAt
+		_, err := s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+			Ledger:  s.ledger,
+			Address: account,
+			RequestBody: map[string]string{
+				leaseRenewedAtKey: lease.RenewedAt.Format(time.RFC3339Nano),
+				leaseExpiresAtKey: lease.ExpiresAt.Format(time.RFC3339Nano),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to renew lease %s: %w", params.Name, err)
+		}
+		after, err := s.GetLease(ctx, params.Name)
+		if err != nil {
+			return nil, err
+		}
+		if after == nil || after.Holder != params.Holder || after.Token != lease.Token {
+			return nil, fmt.Errorf("%w: %s token %d was taken over during renewal", store.ErrLeaseLost, params.Name, lease.Token)
+		}
+		return lease, nil
+	case current != nil && !current.Expired(now):
+		return nil, fmt.Errorf("%w: %s by %s until %s", store.ErrLeaseHeld, params.Name, current.Holder, current.ExpiresAt.Format(time.RFC3339))
+	case current != nil:
+		lease.Token = current.Token + 1
+	}
+
+	token := strconv.FormatInt(lease.Token, 10)
+	_, err = s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+		Ledger: s.ledger,
+		V2PostTransaction: shared.V2PostTransaction{
+			Reference: strPtr("lease:" + params.Name + ":" + token),
+			Postings: []shared.V2Posting{{
+				Amount:      big.NewInt(0),
+				Asset:       leaseAsset,
+				Source:      "world",
+				Destination: account,
+			}},
+			Metadata: map[string]string{
+				"event_type":   "lease_acquired",
+				"lease_name":   params.Name,
+				leaseHolderKey: params.Holder,
+				leaseTokenKey:  token,
+			},
+			AccountMetadata: map[string]map[string]string{account: leaseMetadata(lease)},
+		},
+	})
+	if err != nil {
+		if isConflictError(err) {
+			return nil, fmt.Errorf("%w: %s token %s was claimed first", store.ErrLeaseHeld, params.Name, token)
+		}
+		return nil, fmt.Errorf("failed to acquire lease %s: %w", params.Name, err)
+	}
+
+	zap.L().Info("Lease acquired",
+		zap.String("name", lease.Name),
+		zap.String("holder", lease.Holder),
+		zap.Int64("token", lease.Token))
+	return lease, nil
+}
+
+// ReleaseLease expires the lease now so a standby can take it over without
+// waiting for the TTL. Releasing a lease that has changed hands is a no-op.
+func (s *Service) ReleaseLease(ctx context.Context, fence models.LeaseFence) error {
+	lease, err := s.GetLease(ctx, fence.Name)
+	if err != nil {
+		return err
+	}
+	if lease == nil || lease.Holder != fence.Holder || lease.Token != fence.Token {
+		return nil
+	}
+	lease.ExpiresAt = time.Now().UTC()
+	_, err = s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+		Ledger:      s.ledger,
+		Address:     "leases:" + fence.Name,
+		RequestBody: leaseMetadata(lease),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", fence.Name, err)
+	}
+	return nil
+}
+
+// GetLease returns the named lease, or nil if it has never been acquired.
+func (s *Service) GetLease(ctx context.Context, name string) (*models.Lease, error) {
+	account, err := leaseAccount(name)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Ledger.V2.GetAccount(ctx, operations.V2GetAccountRequest{
+		Ledger:  s.ledger,
+		Address: account,
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get lease %s: %w", name, err)
+	}
+	return parseLease(name, resp.V2AccountResponse.Data.Metadata)
+}
+
+// CheckLease fails with store.ErrLeaseLost unless fence still holds its unexpired lease.
+func (s *Service) CheckLease(ctx context.Context, fence models.LeaseFence) error {
+	lease, err := s.GetLease(ctx, fence.Name)
+	if err != nil {
+		return err
+	}
+	if lease == nil || lease.Holder != fence.Holder || lease.Token != fence.Token || lease.Expired(time.Now().UTC()) {
+		return fmt.Errorf("%w: %s token %d", store.ErrLeaseLost, fence.Name, fence.Token)
+	}
+	return nil
+}
+
+// checkFence checks the fence attached to ctx, if any, before a write. Like
+// createTransaction's check it cannot be atomic with the write that follows.
+func (s *Service) checkFence(ctx context.Context) error {
+	fence := models.GetLeaseFence(ctx)
+	if fence == nil {
+		return nil
+	}
+	return s.CheckLease(ctx, *fence)
+}
+
+// createTransaction posts a ledger transaction, first checking the fence attached
+// to ctx, if any, and recording it on the transaction. Formance cannot make the
+// check and the post atomic, so a write racing a takeover can still land; unique
+// references remain the backstop against double processing.
+func (s *Service) createTransaction(ctx context.Context, request operations.V2CreateTransactionRequest) (*operations.V2CreateTransactionResponse, error) {
+	if err := s.checkFence(ctx); err != nil {
+		return nil, err
+	}
+	if fence := models.GetLeaseFence(ctx); fence != nil {
+		if request.V2PostTransaction.Metadata == nil {
+			request.V2PostTransaction.Metadata = make(map[string]string)
+		}
+		request.V2PostTransaction.Metadata["lease_fence"] = fence.Name + ":" + strconv.FormatInt(fence.Token, 10)
+	}
+	return s.client.Ledger.V2.CreateTransaction(ctx, request)
+}
+
+// leaseMetadata encodes a lease as account metadata.
+func leaseMetadata(l *models.Lease) map[string]string {
+	return map[string]string{
+		leaseHolderKey:     l.Holder,
+		leaseTokenKey:      strconv.FormatInt(l.Token, 10),
+		leaseAcquiredAtKey: l.AcquiredAt.Format(time.RFC3339Nano),
+		leaseRenewedAtKey:  l.RenewedAt.Format(time.RFC3339Nano),
+		leaseExpiresAtKey:  l.ExpiresAt.Format(time.RFC3339Nano),
+	}
+}
+
+// parseLease decodes lease account metadata; an account without it has no lease.
+func parseLease(name string, meta map[string]string) (*models.Lease, error) {
+	if meta[leaseHolderKey] == "" {
+		return nil, nil
+	}
+	lease := &models.Lease{Name: name, Holder: meta[leaseHolderKey]}
+	var err error
+	if lease.Token, err = strconv.ParseInt(meta[leaseTokenKey], 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid token on lease %s: %w", name, err)
+	}
+	for key, target := range map[string]*time.Time{
+		leaseAcquiredAtKey: &lease.AcquiredAt,
+		leaseRenewedAtKey:  &lease.RenewedAt,
+		leaseExpiresAtKey:  &lease.ExpiresAt,
+	} {
+		if *target, err = time.Parse(time.RFC3339Nano, meta[key]); err != nil {
+			return nil, fmt.Errorf("invalid %s on lease %s: %w", key, name, err)
+		}
+	}
+	return lease, nil
+}
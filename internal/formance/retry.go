@@ -0,0 +1,220 @@
+package formance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+)
+
+// A retry queue entry lives in the metadata of a retries:{prime id} account as
+// JSON under retry_entry, with its state and portfolio copied to their own keys
+// for filtering. Accounts cannot be deleted, so a resolved entry is marked
+// resolved and treated as absent.
+
+const (
+	retryEntryKey       = "retry_entry"
+	retryStateKey       = "retry_state"
+	retryPortfolioKey   = "retry_portfolio_id"
+	retryStateResolved  = "resolved"
+	retryAccountSegment = "retries:"
+)
+
+// retryStates are the states GetFailedTxs lists when no state is given
+var retryStates = []string{models.RetryStateQueued, models.RetryStateDead, models.RetryStateDiscarded}
+
+// retryAccount returns the account holding a transaction's retry queue entry.
+func retryAccount(primeTxId string) (string, error) {
+	if !validAccountSegment.MatchString(primeTxId) {
+		return "", fmt.Errorf("invalid prime transaction id %q", primeTxId)
+	}
+	return retryAccountSegment + primeTxId, nil
+}
+
+// RecordTxFailure queues a failed transaction, or counts another failed
+// attempt, moving it to the dead-letter queue once the policy is exhausted.
+func (s *Service) RecordTxFailure(ctx context.Context, params store.RecordTxFailureParams) (*models.FailedTransaction, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	existing, err := s.GetFailedTx(ctx, params.Transaction.Id)
+	if err != nil {
+		return nil, err
+	}
+	failed := params.Apply(existing, time.Now().UTC())
+	if err := s.writeFailedTx(ctx, failed); err != nil {
+		return nil, err
+	}
+	return failed, nil
+}
+
+// GetFailedTx returns a transaction's retry queue entry, or nil if it has none.
+func (s *Service) GetFailedTx(ctx context.Context, primeTxId string) (*models.FailedTransaction, error) {
+	account, err := retryAccount(primeTxId)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Ledger.V2.GetAccount(ctx, operations.V2GetAccountRequest{
+		Ledger:  s.ledger,
+		Address: account,
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get retry entry for %s: %w", primeTxId, err)
+	}
+	return parseFailedTx(resp.V2AccountResponse.Data.Metadata)
+}
+
+// GetFailedTxs returns the retry queue entries matching query, oldest failure first.
+func (s *Service) GetFailedTxs(ctx context.Context, query store.FailedTxQuery) ([]models.FailedTransaction, error) {
+	states := retryStates
+	if query.State != "" {
+		states = []string{query.State}
+	}
+
+	var entries []models.FailedTransaction
+	for _, state := range states {
+		clauses := []any{map[string]any{"$match": map[string]any{"metadata[" + retryStateKey + "]": state}}}
+		if query.PortfolioId != "" {
+			clauses = append(clauses, map[string]any{"$match": map[string]any{"metadata[" + retryPortfolioKey + "]": query.PortfolioId}})
+		}
+		req := operations.V2ListAccountsRequest{
+			Ledger:      s.ledger,
+			PageSize:    ptrInt64(100),
+			RequestBody: map[string]any{"$and": clauses},
+		}
+		for {
+			resp, err := s.client.Ledger.V2.ListAccounts(ctx, req)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list retry queue: %w", err)
+			}
+			cursor := resp.V2AccountsCursorResponse.Cursor
+			for _, acct := range cursor.Data {
+				failed, err := parseFailedTx(acct.Metadata)
+				if err != nil {
+					return nil, err
+				}
+				if failed == nil || (!query.DueBy.IsZero() && failed.NextAttemptAt.After(query.DueBy)) {
+					continue
+				}
+				entries = append(entries, *failed)
+			}
+			if !cursor.HasMore || cursor.Next == nil {
+				break
+			}
+			req = operations.V2ListAccountsRequest{Ledger: s.ledger, Cursor: cursor.Next}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].FirstFailedAt.Before(entries[j].FirstFailedAt) })
+	return entries, nil
+}
+
+// ResolveFailedTx removes a transaction from the retry queue once it has been processed.
+func (s *Service) ResolveFailedTx(ctx context.Context, primeTxId string) error {
+	failed, err := s.requireFailedTx(ctx, primeTxId)
+	if err != nil {
+		return err
+	}
+	failed.State = retryStateResolved
+	return s.writeFailedTx(ctx, failed)
+}
+
+// RequeueFailedTx moves a dead or discarded transaction back into the queue,
+// due now, with a fresh set of attempts.
+func (s *Service) RequeueFailedTx(ctx context.Context, params store.UpdateFailedTxParams) (*models.FailedTransaction, error) {
+	failed, err := s.requireFailedTx(ctx, params.PrimeTxId)
+	if err != nil {
+		return nil, err
+	}
+	failed.State = models.RetryStateQueued
+	failed.Attempts = 0
+	failed.NextAttemptAt = time.Now().UTC()
+	failed.UpdatedBy, failed.Note = params.Actor, params.Note
+	if err := s.writeFailedTx(ctx, failed); err != nil {
+		return nil, err
+	}
+	return failed, nil
+}
+
+// DiscardFailedTx drops a transaction from the queue for good. The entry is
+// kept, so the listener does not pick the transaction up again.
+func (s *Service) DiscardFailedTx(ctx context.Context, params store.UpdateFailedTxParams) error {
+	failed, err := s.requireFailedTx(ctx, params.PrimeTxId)
+	if err != nil {
+		return err
+	}
+	failed.State = models.RetryStateDiscarded
+	failed.UpdatedBy, failed.Note = params.Actor, params.Note
+	return s.writeFailedTx(ctx, failed)
+}
+
+// requireFailedTx returns a transaction's entry or store.ErrFailedTxNotFound
+func (s *Service) requireFailedTx(ctx context.Context, primeTxId string) (*models.FailedTransaction, error) {
+	failed, err := s.GetFailedTx(ctx, primeTxId)
+	if err != nil {
+		return nil, err
+	}
+	if failed == nil {
+		return nil, fmt.Errorf("%w: %s", store.ErrFailedTxNotFound, primeTxId)
+	}
+	return failed, nil
+}
+
+// writeFailedTx stores an entry and its filter keys in a single metadata update,
+// after checking the fence attached to ctx, if any.
+func (s *Service) writeFailedTx(ctx context.Context, failed *models.FailedTransaction) error {
+	if err := s.checkFence(ctx); err != nil {
+		return err
+	}
+	account, err := retryAccount(failed.PrimeTxId)
+	if err != nil {
+		return err
+	}
+	meta, err := failedTxMetadata(failed)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+		Ledger:      s.ledger,
+		Address:     account,
+		RequestBody: meta,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record retry entry for %s: %w", failed.PrimeTxId, err)
+	}
+	return nil
+}
+
+// failedTxMetadata encodes an entry and its filter keys as account metadata.
+func failedTxMetadata(failed *models.FailedTransaction) (map[string]string, error) {
+	encoded, err := json.Marshal(failed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode retry entry: %w", err)
+	}
+	return map[string]string{
+		retryEntryKey:     string(encoded),
+		retryStateKey:     failed.State,
+		retryPortfolioKey: failed.PortfolioId,
+	}, nil
+}
+
+// parseFailedTx decodes a retry account's metadata; a resolved or missing entry is nil.
+func parseFailedTx(meta map[string]string) (*models.FailedTransaction, error) {
+	raw := meta[retryEntryKey]
+	if raw == "" || meta[retryStateKey] == retryStateResolved {
+		return nil, nil
+	}
+	var failed models.FailedTransaction
+	if err := json.Unmarshal([]byte(raw), &failed); err != nil {
+		return nil, fmt.Errorf("failed to decode retry entry: %w", err)
+	}
+	return &failed, nil
+}
@@ -0,0 +1,176 @@
+package formance
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/shared"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// validAccountSegment guards user ids written literally into generated Numscript
+var validAccountSegment = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// rewardDistributionScript builds a single multi-destination send from the platform
+// user to every allocated user. The destinations vary per distribution, so the amounts
+// and accounts are written into the script; only metadata goes through vars.
+func rewardDistributionScript(symbol string, allocations []store.RewardAllocation) (string, error) {
+	fAsset := formanceAsset(symbol)
+	precision := int32(precisionFor(symbol))
+
+	var destinations strings.Builder
+	total := decimal.Zero
+	for _, a := range allocations {
+		if !validAccountSegment.MatchString(a.UserId) {
+			return "", fmt.Errorf("user id %q cannot be used as a Formance account", a.UserId)
+		}
+		if !a.Amount.IsPositive() {
+			return "", fmt.Errorf("allocation for user %s must be positive, got %s", a.UserId, a.Amount)
+		}
+		minor := a.Amount.Shift(precision)
+		if !minor.Equal(minor.Truncate(0)) {
+			return "", fmt.Errorf("allocation %s for user %s has more than %d decimals", a.Amount, a.UserId, precision)
+		}
+		fmt.Fprintf(&destinations, "    max [%s %s] to @users:%s\n", fAsset, minor.String(), a.UserId)
+		total = total.Add(minor)
+	}
+
+	return fmt.Sprintf(`vars {
+  account $platform_user_id
+  string $distribution_id
+  string $reward_tx_id
+  string $asset_symbol
+  string $amount_human
+}
+
+send [%s %s] (
+  source = @users:$platform_user_id
+  destination = {
+%s    remaining kept
+  }
+)
+
+set_tx_meta("event_type", "reward_distribution")
+set_tx_meta("external_tx_id", $distribution_id)
+set_tx_meta("distribution_id", $distribution_id)
+set_tx_meta("reward_tx_id", $reward_tx_id)
+set_tx_meta("asset_symbol", $asset_symbol)
+set_tx_meta("amount_human", $amount_human)
+`, fAsset, total.String(), destinations.String()), nil
+}
+
+// DistributeReward posts every allocation as one Numscript transaction referenced by
+// the distribution id, so Formance applies all of it or none, and rejects a re-run.
+// A frozen user's share goes to the quarantine platform user, and the transaction's
+// quarantined_user_id lists the users it was meant for.
+func (s *Service) DistributeReward(ctx context.Context, params store.RewardDistributionParams) error {
+	if len(params.Allocations) == 0 {
+		return fmt.Errorf("reward distribution %s has no allocations", params.DistributionId)
+	}
+	allocations, quarantined, err := s.quarantineFrozenAllocations(ctx, params.Allocations)
+	if err != nil {
+		return err
+	}
+	script, err := rewardDistributionScript(params.Asset, allocations)
+	if err != nil {
+		return err
+	}
+	total := decimal.Zero
+	for _, a := range params.Allocations {
+		total = total.Add(a.Amount)
+	}
+
+	postTx := shared.V2PostTransaction{
+		Reference: strPtr(params.DistributionId),
+		Script: &shared.V2PostTransactionScript{
+			Plain: script,
+			Vars: map[string]string{
+				"platform_user_id": "prime-platform-" + s.portfolioID,
+				"distribution_id":  params.DistributionId,
+				"reward_tx_id":     params.RewardTxId,
+				"asset_symbol":     params.Asset,
+				"amount_human":     total.String(),
+			},
+		},
+	}
+	for k, v := range params.Metadata {
+		if v == "" {
+			continue
+		}
+		if postTx.Metadata == nil {
+			postTx.Metadata = make(map[string]string)
+		}
+		postTx.Metadata[k] = v
+	}
+	if len(quarantined) > 0 {
+		if postTx.Metadata == nil {
+			postTx.Metadata = make(map[string]string)
+		}
+		postTx.Metadata["quarantined_user_id"] = strings.Join(quarantined, ",")
+	}
+
+	_, err = s.createTransaction(ctx, operations.V2CreateTransactionRequest{
+		Ledger:            s.ledger,
+		V2PostTransaction: postTx,
+	})
+	if err != nil {
+		if isConflictError(err) {
+			return fmt.Errorf("%w: reward distribution %s already posted", store.ErrDuplicateTransaction, params.DistributionId)
+		}
+		return fmt.Errorf("failed to distribute reward: %w", err)
+	}
+
+	zap.L().Info("Reward distributed in Formance",
+		zap.String("distribution_id", params.DistributionId),
+		zap.String("reward_tx_id", params.RewardTxId),
+		zap.String("asset", params.Asset),
+		zap.String("total", total.String()),
+		zap.Int("users", len(params.Allocations)))
+	return nil
+}
+
+// HasRewardDistribution reports whether a distribution with this id has been posted.
+func (s *Service) HasRewardDistribution(ctx context.Context, distributionId string) (bool, error) {
+	pageSize := int64(1)
+	resp, err := s.client.Ledger.V2.ListTransactions(ctx, operations.V2ListTransactionsRequest{
+		Ledger:   s.ledger,
+		PageSize: &pageSize,
+		RequestBody: map[string]any{
+			"$match": map[string]any{
+				"metadata[distribution_id]": distributionId,
+			},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check reward distribution: %w", err)
+	}
+	return len(resp.V2TransactionsCursorResponse.Cursor.Data) > 0, nil
+}
+
+// quarantineFrozenAllocations redirects each frozen user's allocation to the
+// quarantine platform user and returns the ids of the users redirected.
+func (s *Service) quarantineFrozenAllocations(ctx context.Context, allocations []store.RewardAllocation) ([]store.RewardAllocation, []string, error) {
+	out := make([]store.RewardAllocation, 0, len(allocations))
+	var quarantined []string
+	for _, a := range allocations {
+		status, err := s.GetFreezeStatus(ctx, a.UserId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error checking freeze status: %w", err)
+		}
+		if status.Frozen {
+			zap.L().Warn("Reward share for frozen user quarantined",
+				zap.String("user_id", a.UserId),
+				zap.String("freeze_reason", status.Reason))
+			quarantined = append(quarantined, a.UserId)
+			a.UserId = store.QuarantineUserId(s.portfolioID)
+		}
+		out = append(out, a)
+	}
+	return out, quarantined, nil
+}
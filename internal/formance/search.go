@@ -0,0 +1,199 @@
+package formance
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 1000
+)
+
+// searchCursor resumes a search part way through a Formance page: Next is the
+// Formance cursor of that page and Skip the rows of it already returned.
+type searchCursor struct {
+	Next string `json:"c"`
+	Skip int    `json:"s,omitempty"`
+}
+
+func encodeSearchCursor(next string, skip int) string {
+	b, _ := json.Marshal(searchCursor{Next: next, Skip: skip})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSearchCursor(cursor string) (*searchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var c searchCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Next == "" || c.Skip < 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// SearchTransactions returns transactions matching every set filter in query, newest
+// effective time first. User, asset, network, id, hash, address and time filters are
+// pushed down to the ledger as a metadata/account query; type, status and amount are
+// derived from postings and applied here, reading further ledger pages until the
+// page holds query.Limit rows or the ledger runs out.
+func (s *Service) SearchTransactions(ctx context.Context, query store.TransactionQuery) (*store.TransactionPage, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	var next *string
+	skip := 0
+	if query.Cursor != "" {
+		c, err := decodeSearchCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		next, skip = &c.Next, c.Skip
+	}
+
+	zap.L().Debug("Searching Formance transactions", zap.Int("limit", limit), zap.Bool("cursor", query.Cursor != ""))
+
+	page := &store.TransactionPage{}
+	for {
+		req := operations.V2ListTransactionsRequest{Ledger: s.ledger}
+		if next != nil {
+			// The cursor already encodes page size, sort and filter; nothing else may be set
+			req.Cursor = next
+		} else {
+			pageSize := int64(limit)
+			req.PageSize = &pageSize
+			req.Sort = strPtr("timestamp:desc")
+			if body := searchRequestBody(query); body != nil {
+				req.RequestBody = body
+			}
+		}
+
+		resp, err := s.client.Ledger.V2.ListTransactions(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search transactions: %w", err)
+		}
+
+		cursor := resp.V2TransactionsCursorResponse.Cursor
+		hasMore := cursor.HasMore && cursor.Next != nil
+		for i := skip; i < len(cursor.Data); i++ {
+			tx := cursor.Data[i]
+			userId := query.UserId
+			if userId == "" {
+				userId = userFromPostings(tx.Postings)
+			}
+			if userId == "" {
+				userId = tx.Metadata["user_id"]
+			}
+			t := toModelTransaction(tx, userId, query.Asset)
+			if !matchesDerivedFilters(t, query) {
+				continue
+			}
+			page.Transactions = append(page.Transactions, t)
+			if len(page.Transactions) < limit {
+				continue
+			}
+
+			// Full: resume after this row. The first ledger page holds at most
+			// limit rows, so a part-read page always has a cursor of its own.
+			switch {
+			case i+1 < len(cursor.Data) && next != nil:
+				page.NextCursor = encodeSearchCursor(*next, i+1)
+			case hasMore:
+				page.NextCursor = encodeSearchCursor(*cursor.Next, 0)
+			}
+			return page, nil
+		}
+
+		if !hasMore {
+			return page, nil
+		}
+		next, skip = cursor.Next, 0
+	}
+}
+
+// searchRequestBody builds the ListTransactions filter for the fields Formance can
+// match natively. Returns nil when no such filter is set.
+func searchRequestBody(query store.TransactionQuery) map[string]any {
+	match := func(field, value string) map[string]any {
+		return map[string]any{"$match": map[string]any{field: value}}
+	}
+
+	var clauses []any
+	if query.UserId != "" {
+		account := "users:" + query.UserId
+		clauses = append(clauses, map[string]any{"$or": []any{match("source", account), match("destination", account)}})
+	}
+	if query.Asset != "" {
+		clauses = append(clauses, match("metadata[asset_symbol]", query.Asset))
+	}
+	if query.Network != "" {
+		clauses = append(clauses, match("metadata[network]", query.Network))
+	}
+	if query.ExternalTxId != "" {
+		clauses = append(clauses, match("metadata[external_tx_id]", query.ExternalTxId))
+	}
+	if query.TxHash != "" {
+		clauses = append(clauses, map[string]any{"$or": []any{
+			match("metadata["+store.MetadataPrimeTxId+"]", query.TxHash),
+			match("metadata["+store.MetadataLegacyPrimeTxId+"]", query.TxHash),
+		}})
+	}
+	if query.Address != "" {
+		clauses = append(clauses, map[string]any{"$or": []any{
+			match("metadata[deposit_address]", query.Address),
+			match("metadata[destination_address]", query.Address),
+			match("metadata[source_address]", query.Address),
+		}})
+	}
+	if !query.From.IsZero() {
+		clauses = append(clauses, map[string]any{"$gte": map[string]any{"timestamp": query.From.UTC().Format(time.RFC3339Nano)}})
+	}
+	if !query.To.IsZero() {
+		clauses = append(clauses, map[string]any{"$lt": map[string]any{"timestamp": query.To.UTC().Format(time.RFC3339Nano)}})
+	}
+
+	switch len(clauses) {
+	case 0:
+		return nil
+	case 1:
+		return clauses[0].(map[string]any)
+	default:
+		return map[string]any{"$and": clauses}
+	}
+}
+
+// matchesDerivedFilters applies the filters that depend on values computed from
+// postings rather than stored metadata.
+func matchesDerivedFilters(t models.Transaction, query store.TransactionQuery) bool {
+	if query.Type != "" && !strings.EqualFold(t.TransactionType, query.Type) {
+		return false
+	}
+	if query.Status != "" && !strings.EqualFold(t.Status, query.Status) {
+		return false
+	}
+	amount := t.Amount.Abs()
+	if query.MinAmount != nil && amount.LessThan(*query.MinAmount) {
+		return false
+	}
+	if query.MaxAmount != nil && amount.GreaterThan(*query.MaxAmount) {
+		return false
+	}
+	return true
+}
@@ -18,16 +18,6 @@ import (
 // Compile-time check: *Service must satisfy store.LedgerStore.
 var _ store.LedgerStore = (*Service)(nil)
 
-// assetPrecision maps canonical asset symbols to their decimal precision.
-var assetPrecision = map[string]int{
-	"USD":  2,
-	"USDC": 6,
-	"USDT": 6,
-	"BTC":  8,
-	"ETH":  18,
-	"SOL":  9,
-}
-
 // Service implements store.LedgerStore backed by a Formance Stack ledger.
 type Service struct {
 	client      *v3.Formance
@@ -106,10 +96,7 @@ func (s *Service) Close() {}
 
 // formanceAsset returns the Formance UMN notation, e.g. "USDC/6".
 func formanceAsset(symbol string) string {
-	if p, ok := assetPrecision[symbol]; ok {
-		return fmt.Sprintf("%s/%d", symbol, p)
-	}
-	return fmt.Sprintf("%s/6", symbol) // default precision 6
+	return fmt.Sprintf("%s/%d", symbol, precisionFor(symbol))
 }
 
 // isConflictError checks whether a Formance SDK error is a CONFLICT (duplicate reference).
@@ -124,6 +111,12 @@ func isNotFoundError(err error) bool {
 	return errors.As(err, &apiErr) && apiErr.ErrorCode == shared.V2ErrorsEnumNotFound
 }
 
+// isInsufficientFundError checks whether a Formance SDK error is INSUFFICIENT_FUND.
+func isInsufficientFundError(err error) bool {
+	var apiErr *sdkerrors.V2ErrorResponse
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == shared.V2ErrorsEnumInsufficientFund
+}
+
 // isAlreadyRevertedError checks whether a Formance SDK error is ALREADY_REVERT.
 func isAlreadyRevertedError(err error) bool {
 	var apiErr *sdkerrors.V2ErrorResponse
@@ -1,8 +1,21 @@
 package formance
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	v3 "github.com/formancehq/formance-sdk-go/v3"
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/shared"
 	"github.com/shopspring/decimal"
 )
 
@@ -94,6 +107,17 @@ func TestAddrMetaKey(t *testing.T) {
 	}
 }
 
+func TestAllowlistMetaKey(t *testing.T) {
+	for _, tc := range []struct{ address, tag, want string }{
+		{"0xABC", "", "allowlist_0xabc"},
+		{"rPEPPER7kfTD9w2To4CQk6UCfuHM9c6GDY", "101", "allowlist_rpepper7kftd9w2to4cqk6ucfuhm9c6gdy_tag_101"},
+	} {
+		if got := allowlistMetaKey(tc.address, tc.tag); got != tc.want {
+			t.Errorf("allowlistMetaKey(%q, %q) = %q, want %q", tc.address, tc.tag, got, tc.want)
+		}
+	}
+}
+
 func TestParseAddressesFromMeta_ListFormat(t *testing.T) {
 	meta := map[string]string{
 		"deposit_addresses":  `{"USDC":["0xABC","0xDEF"],"BTC":["bc1qxyz"]}`,
@@ -148,9 +172,375 @@ func TestAppendUnique(t *testing.T) {
 	if len(s) != 2 {
 		t.Fatalf("expected 2, got %d", len(s))
 	}
-	// Duplicate (case-insensitive) should not be added.
-	s = appendUnique(s, "0xabc")
+	// Addresses are stored normalized, so only an exact duplicate is skipped
+	s = appendUnique(s, "0xABC")
 	if len(s) != 2 {
 		t.Fatalf("expected 2 after duplicate, got %d", len(s))
 	}
+	s = appendUnique(s, "0xabc")
+	if len(s) != 3 {
+		t.Fatalf("expected a case variant to be added, got %d", len(s))
+	}
+}
+
+func TestToModelTransaction(t *testing.T) {
+	ts := time.Date(2025, 2, 1, 9, 30, 0, 0, time.UTC)
+	tx := shared.V2Transaction{
+		ID:        big.NewInt(42),
+		Timestamp: ts,
+		Postings: []shared.V2Posting{{
+			Source:      "prime:portfolio:p1:wallets:w1",
+			Destination: "users:u1",
+			Asset:       "USDC/6",
+			Amount:      big.NewInt(2500000),
+		}},
+		Metadata: map[string]string{
+			"event_type":     "deposit_received",
+			"external_tx_id": "prime-1",
+			"prime_tx_id":    "0xabc",
+			"network":        "base-mainnet",
+		},
+	}
+
+	got := toModelTransaction(tx, userFromPostings(tx.Postings), "")
+	if got.UserId != "u1" {
+		t.Errorf("UserId = %q, want u1", got.UserId)
+	}
+	if got.Asset != "USDC" || !got.Amount.Equal(decimal.RequireFromString("2.5")) {
+		t.Errorf("got %s %s, want 2.5 USDC", got.Amount, got.Asset)
+	}
+	if got.TxHash != "0xabc" || got.Network != "base-mainnet" || got.Metadata["external_tx_id"] != "prime-1" {
+		t.Errorf("metadata not mapped: %+v", got)
+	}
+	if !got.EffectiveAt.Equal(ts) {
+		t.Errorf("EffectiveAt = %s, want %s", got.EffectiveAt, ts)
+	}
+}
+
+func TestSearchRequestBody(t *testing.T) {
+	if body := searchRequestBody(store.TransactionQuery{Type: "deposit"}); body != nil {
+		t.Errorf("expected nil body for derived-only filters, got %v", body)
+	}
+
+	single := searchRequestBody(store.TransactionQuery{Asset: "USDC"})
+	match, ok := single["$match"].(map[string]any)
+	if !ok || match["metadata[asset_symbol]"] != "USDC" {
+		t.Errorf("single filter = %v, want $match on asset_symbol", single)
+	}
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	combined := searchRequestBody(store.TransactionQuery{UserId: "u1", From: from, TxHash: "0xabc"})
+	clauses, ok := combined["$and"].([]any)
+	if !ok || len(clauses) != 3 {
+		t.Fatalf("combined filter = %v, want $and of 3 clauses", combined)
+	}
+}
+
+func TestMatchesDerivedFilters(t *testing.T) {
+	minAmount := decimal.NewFromInt(5)
+	tx := toModelTransaction(shared.V2Transaction{
+		ID: big.NewInt(1),
+		Postings: []shared.V2Posting{{
+			Source: "users:u1", Destination: "prime:portfolio:p1:wallets:w1", Asset: "USDC/6", Amount: big.NewInt(3000000),
+		}},
+		Metadata: map[string]string{"event_type": "withdrawal_initiated"},
+	}, "u1", "USDC")
+
+	if !matchesDerivedFilters(tx, store.TransactionQuery{Type: "withdrawal"}) {
+		t.Error("expected withdrawal to match type filter")
+	}
+	if matchesDerivedFilters(tx, store.TransactionQuery{Type: "deposit"}) {
+		t.Error("expected withdrawal not to match deposit filter")
+	}
+	if matchesDerivedFilters(tx, store.TransactionQuery{MinAmount: &minAmount}) {
+		t.Error("expected |-3| to fail min amount 5")
+	}
+}
+
+func TestExportPostings(t *testing.T) {
+	ts := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	rows := exportPostings(shared.V2Transaction{
+		ID:        big.NewInt(7),
+		Timestamp: ts,
+		Postings: []shared.V2Posting{{
+			Source:      "prime:portfolio:p1:wallets:w1",
+			Destination: "users:u1",
+			Asset:       "USDC/6",
+			Amount:      big.NewInt(1500000),
+		}},
+		Metadata: map[string]string{"external_tx_id": "prime-1"},
+	})
+
+	if len(rows) != 2 {
+		t.Fatalf("expected debit and credit rows, got %d", len(rows))
+	}
+	debit, credit := rows[0], rows[1]
+	if debit.Direction != store.DirectionDebit || debit.Account != "users:u1" || debit.Counterparty != "prime:portfolio:p1:wallets:w1" {
+		t.Errorf("unexpected debit row: %+v", debit)
+	}
+	if credit.Direction != store.DirectionCredit || credit.Account != "prime:portfolio:p1:wallets:w1" || credit.Sequence != 1 {
+		t.Errorf("unexpected credit row: %+v", credit)
+	}
+	if debit.TransactionId != "7" || debit.Asset != "USDC" || !debit.Amount.Equal(decimal.RequireFromString("1.5")) || debit.ExternalTxId != "prime-1" {
+		t.Errorf("unexpected posting fields: %+v", debit)
+	}
+}
+
+func TestRewardDistributionScript(t *testing.T) {
+	script, err := rewardDistributionScript("USDC", []store.RewardAllocation{
+		{UserId: "user-1", Amount: decimal.RequireFromString("1.5")},
+		{UserId: "user_2", Amount: decimal.RequireFromString("0.000001")},
+	})
+	if err != nil {
+		t.Fatalf("rewardDistributionScript failed: %v", err)
+	}
+	for _, want := range []string{
+		"send [USDC/6 1500001]",
+		"max [USDC/6 1500000] to @users:user-1",
+		"max [USDC/6 1] to @users:user_2",
+		"remaining kept",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected script to contain %q, got:\n%s", want, script)
+		}
+	}
+
+	if _, err := rewardDistributionScript("USDC", []store.RewardAllocation{
+		{UserId: "user1\n@world", Amount: decimal.NewFromInt(1)},
+	}); err == nil {
+		t.Error("expected an unsafe user id to be rejected")
+	}
+	if _, err := rewardDistributionScript("USDC", []store.RewardAllocation{
+		{UserId: "user1", Amount: decimal.RequireFromString("0.0000001")},
+	}); err == nil {
+		t.Error("expected an amount below the asset precision to be rejected")
+	}
+}
+
+func TestHoldCaptureScript(t *testing.T) {
+	full := holdCaptureScript("USDC/6", decimal.NewFromInt(500), decimal.NewFromInt(500))
+	if !strings.Contains(full, "send [USDC/6 500]") || !strings.Contains(full, "destination = @prime:portfolio:$portfolio_id:holds:captured") {
+		t.Errorf("expected a full capture to send everything to the captured account, got:\n%s", full)
+	}
+	if strings.Contains(full, "remaining to @users:$user_id") {
+		t.Error("expected a full capture to return nothing to the user")
+	}
+
+	partial := holdCaptureScript("USDC/6", decimal.NewFromInt(500), decimal.NewFromInt(200))
+	for _, want := range []string{
+		"send [USDC/6 500]",
+		"source = @users:$user_id:holds",
+		"max [USDC/6 200] to @prime:portfolio:$portfolio_id:holds:captured",
+		"remaining to @users:$user_id",
+	} {
+		if !strings.Contains(partial, want) {
+			t.Errorf("expected script to contain %q, got:\n%s", want, partial)
+		}
+	}
+}
+
+func TestUserAccountHelpers(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"users:abc":       true,
+		"users:abc:holds": false,
+		"users:":          false,
+		"fees:custody":    false,
+	} {
+		if got := isUserAccount(addr); got != want {
+			t.Errorf("isUserAccount(%q) = %v, want %v", addr, got, want)
+		}
+	}
+	if !isActiveUser(map[string]string{}) || !isActiveUser(map[string]string{"active": "true"}) {
+		t.Error("expected users without active=false to be active")
+	}
+	if isActiveUser(map[string]string{"active": "false"}) {
+		t.Error("expected active=false to mark the user inactive")
+	}
+}
+
+func TestLeaseMetadataRoundTrip(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	lease := &models.Lease{
+		Name:       "listener:p-1",
+		Holder:     "host-42",
+		Token:      7,
+		AcquiredAt: now,
+		RenewedAt:  now.Add(time.Second),
+		ExpiresAt:  now.Add(15 * time.Second),
+	}
+
+	parsed, err := parseLease(lease.Name, leaseMetadata(lease))
+	if err != nil {
+		t.Fatalf("parseLease failed: %v", err)
+	}
+	if *parsed != *lease {
+		t.Errorf("Round trip = %+v, want %+v", parsed, lease)
+	}
+
+	if none, err := parseLease("listener:p-1", map[string]string{}); err != nil || none != nil {
+		t.Errorf("Expected no lease without metadata, got %+v, %v", none, err)
+	}
+	if _, err := parseLease("listener:p-1", map[string]string{leaseHolderKey: "x", leaseTokenKey: "nan"}); err == nil {
+		t.Error("Expected an invalid token to be rejected")
+	}
+}
+
+func TestLeaseAccount(t *testing.T) {
+	if account, err := leaseAccount("listener:5a3c-9f"); err != nil || account != "leases:listener:5a3c-9f" {
+		t.Errorf("leaseAccount = %q, %v", account, err)
+	}
+	for _, name := range []string{"", "listener:", "listener:a b", "bad/name"} {
+		if _, err := leaseAccount(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestTxStateMetadataRoundTrip(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	state := &models.TxState{
+		PrimeTxId:   "5a3c-9f",
+		Kind:        models.TxKindWithdrawal,
+		State:       models.TxStateCompleted,
+		PrimeStatus: "TRANSACTION_DONE",
+		Quarantined: true,
+		CreatedAt:   now,
+		UpdatedAt:   now.Add(time.Minute),
+	}
+
+	parsed, err := parseTxState(state.PrimeTxId, txStateMetadata(state))
+	if err != nil {
+		t.Fatalf("parseTxState failed: %v", err)
+	}
+	if *parsed != *state {
+		t.Errorf("Round trip = %+v, want %+v", parsed, state)
+	}
+
+	if none, err := parseTxState("5a3c-9f", map[string]string{}); err != nil || none != nil {
+		t.Errorf("Expected no state without metadata, got %+v, %v", none, err)
+	}
+	if account, err := txStateAccount("5a3c-9f"); err != nil || account != "primetx:5a3c-9f" {
+		t.Errorf("txStateAccount = %q, %v", account, err)
+	}
+	if _, err := txStateAccount("a:b"); err == nil {
+		t.Error("Expected an id with a separator to be rejected")
+	}
+}
+
+func TestFailedTxMetadataRoundTrip(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	failed := &models.FailedTransaction{
+		PrimeTxId:     "5a3c-9f",
+		PortfolioId:   "p1",
+		Wallet:        models.WalletInfo{Id: "w1", AssetSymbol: "USDC"},
+		Transaction:   models.PrimeTransaction{Id: "5a3c-9f", Type: "DEPOSIT", Status: "TRANSACTION_IMPORTED", Amount: "5"},
+		State:         models.RetryStateDead,
+		Attempts:      10,
+		LastError:     "no user for address",
+		NextAttemptAt: now.Add(time.Hour),
+		FirstFailedAt: now,
+		LastFailedAt:  now.Add(time.Minute),
+	}
+
+	meta, err := failedTxMetadata(failed)
+	if err != nil {
+		t.Fatalf("failedTxMetadata failed: %v", err)
+	}
+	if meta[retryStateKey] != models.RetryStateDead || meta[retryPortfolioKey] != "p1" {
+		t.Errorf("Expected filter keys to be copied, got %v", meta)
+	}
+	parsed, err := parseFailedTx(meta)
+	if err != nil {
+		t.Fatalf("parseFailedTx failed: %v", err)
+	}
+	if parsed.Transaction.Amount != "5" || parsed.LastError != failed.LastError || !parsed.LastFailedAt.Equal(failed.LastFailedAt) {
+		t.Errorf("Round trip = %+v, want %+v", parsed, failed)
+	}
+
+	// A resolved entry reads as absent
+	meta[retryStateKey] = retryStateResolved
+	if none, err := parseFailedTx(meta); err != nil || none != nil {
+		t.Errorf("Expected no entry once resolved, got %+v, %v", none, err)
+	}
+	if _, err := retryAccount("a:b"); err == nil {
+		t.Error("Expected an id with a separator to be rejected")
+	}
+}
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	c, err := decodeSearchCursor(encodeSearchCursor("formance-next", 7))
+	if err != nil {
+		t.Fatalf("decodeSearchCursor failed: %v", err)
+	}
+	if c.Next != "formance-next" || c.Skip != 7 {
+		t.Errorf("Round trip = %+v", c)
+	}
+	for _, bad := range []string{"not base64!", encodeSearchCursor("", 0), encodeSearchCursor("next", -1)} {
+		if _, err := decodeSearchCursor(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+// ---------- Paging against a fake ledger API ----------
+
+// accountPages serves ListAccounts from fixed pages, chained by "page-{n}" cursors
+func accountPages(t *testing.T, pages ...[]shared.V2Account) *Service {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := 0
+		if c := r.URL.Query().Get("cursor"); c != "" {
+			if _, err := fmt.Sscanf(c, "page-%d", &page); err != nil {
+				t.Errorf("Unexpected cursor %q", c)
+			}
+		}
+		cursor := shared.V2AccountsCursorResponseCursor{Data: pages[page], PageSize: 100}
+		if page+1 < len(pages) {
+			cursor.HasMore, cursor.Next = true, v3.Pointer(fmt.Sprintf("page-%d", page+1))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(shared.V2AccountsCursorResponse{Cursor: cursor}); err != nil {
+			t.Errorf("Failed to encode page: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &Service{client: v3.New(v3.WithServerURL(srv.URL)), ledger: "test"}
+}
+
+func TestGetUsers_FollowsCursor(t *testing.T) {
+	var first, second []shared.V2Account
+	for i := 0; i < 100; i++ {
+		first = append(first, shared.V2Account{Address: fmt.Sprintf("users:u%03d", i), Metadata: map[string]string{"entity_type": "end_user"}})
+	}
+	second = []shared.V2Account{
+		{Address: "users:u100", Metadata: map[string]string{"entity_type": "end_user"}},
+		{Address: "users:u101", Metadata: map[string]string{"entity_type": "end_user", "active": "false"}},
+		{Address: "users:u100:ethereum-mainnet", Metadata: map[string]string{"entity_type": "end_user"}},
+	}
+	s := accountPages(t, first, second)
+
+	users, err := s.GetUsers(context.Background())
+	if err != nil {
+		t.Fatalf("GetUsers failed: %v", err)
+	}
+	if len(users) != 101 || users[100].Id != "u100" {
+		t.Errorf("Expected 101 active users across both pages, got %d", len(users))
+	}
+}
+
+func TestGetOpenUsers_KeepsDeactivatedUsers(t *testing.T) {
+	s := accountPages(t, []shared.V2Account{
+		{Address: "users:u1", Metadata: map[string]string{"entity_type": "end_user", "active": "true"}},
+		{Address: "users:u2", Metadata: map[string]string{"entity_type": "end_user", "active": "false"}},
+	}, []shared.V2Account{
+		{Address: "users:u3", Metadata: map[string]string{"entity_type": "end_user", "active": "false", "closed_at": "2025-01-01T00:00:00Z"}},
+	})
+
+	users, err := s.GetOpenUsers(context.Background())
+	if err != nil {
+		t.Fatalf("GetOpenUsers failed: %v", err)
+	}
+	if len(users) != 2 || users[0].Id != "u1" || users[1].Id != "u2" {
+		t.Errorf("Expected u1 and deactivated u2 but not closed u3, got %+v", users)
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/models"
 	"prime-send-receive-go/internal/store"
 
@@ -28,6 +29,7 @@ const numscriptDepositPending = `vars {
   account $wallet_id
   string $external_tx_id
   string $deposit_address
+  string $deposit_tag
   string $asset_symbol
   string $prime_status
 }
@@ -40,6 +42,7 @@ send [$asset $amount] (
 set_tx_meta("event_type", "deposit_pending")
 set_tx_meta("external_tx_id", $external_tx_id)
 set_tx_meta("deposit_address", $deposit_address)
+set_tx_meta("deposit_tag", $deposit_tag)
 set_tx_meta("asset_symbol", $asset_symbol)
 set_tx_meta("prime_status", $prime_status)
 `
@@ -51,8 +54,11 @@ const numscriptDepositConfirmed = `vars {
   account $portfolio_id
   string $external_tx_id
   string $deposit_address
+  string $deposit_tag
   string $asset_symbol
   string $prime_status
+  string $quarantined_user_id
+  string $suspense_user_id
 }
 
 send [$asset $amount] (
@@ -63,8 +69,11 @@ send [$asset $amount] (
 set_tx_meta("event_type", "deposit_confirmed")
 set_tx_meta("external_tx_id", $external_tx_id)
 set_tx_meta("deposit_address", $deposit_address)
+set_tx_meta("deposit_tag", $deposit_tag)
 set_tx_meta("asset_symbol", $asset_symbol)
 set_tx_meta("prime_status", $prime_status)
+set_tx_meta("quarantined_user_id", $quarantined_user_id)
+set_tx_meta("suspense_user_id", $suspense_user_id)
 `
 
 const numscriptDepositReceived = `vars {
@@ -75,6 +84,7 @@ const numscriptDepositReceived = `vars {
   account $wallet_id
   string $external_tx_id
   string $deposit_address
+  string $deposit_tag
   string $prime_status
   string $asset_symbol
   string $network
@@ -93,6 +103,8 @@ const numscriptDepositReceived = `vars {
   string $blockchain_ids
   string $prime_created_at
   string $prime_completed_at
+  string $quarantined_user_id
+  string $suspense_user_id
 }
 
 send [$asset $amount] (
@@ -103,6 +115,7 @@ send [$asset $amount] (
 set_tx_meta("event_type", "deposit_received")
 set_tx_meta("external_tx_id", $external_tx_id)
 set_tx_meta("deposit_address", $deposit_address)
+set_tx_meta("deposit_tag", $deposit_tag)
 set_tx_meta("prime_status", $prime_status)
 set_tx_meta("asset_symbol", $asset_symbol)
 set_tx_meta("network", $network)
@@ -121,6 +134,8 @@ set_tx_meta("fees", $fees)
 set_tx_meta("blockchain_ids", $blockchain_ids)
 set_tx_meta("prime_created_at", $prime_created_at)
 set_tx_meta("prime_completed_at", $prime_completed_at)
+set_tx_meta("quarantined_user_id", $quarantined_user_id)
+set_tx_meta("suspense_user_id", $suspense_user_id)
 `
 
 const numscriptWithdrawalPendingFromWallet = `vars {
@@ -135,6 +150,10 @@ const numscriptWithdrawalPendingFromWallet = `vars {
   string $idempotency_key
   string $prime_api_symbol
   string $amount_human
+  string $network
+  string $prime_tx_id
+  string $network_fees
+  string $fees
 }
 
 send [$asset $amount] (
@@ -150,6 +169,10 @@ set_tx_meta("destination_address", $destination_address)
 set_tx_meta("idempotency_key", $idempotency_key)
 set_tx_meta("prime_api_symbol", $prime_api_symbol)
 set_tx_meta("amount_human", $amount_human)
+set_tx_meta("network", $network)
+set_tx_meta("prime_tx_id", $prime_tx_id)
+set_tx_meta("network_fees", $network_fees)
+set_tx_meta("fees", $fees)
 `
 
 const numscriptWithdrawalInitiated = `vars {
@@ -212,6 +235,10 @@ const numscriptWithdrawalFailedPlatformRoundTrip = `vars {
   string $destination_address
   string $idempotency_key
   string $prime_api_symbol
+  string $network
+  string $prime_tx_id
+  string $network_fees
+  string $fees
 }
 
 send [$asset $amount] (
@@ -232,6 +259,10 @@ set_tx_meta("amount_human", $amount_human)
 set_tx_meta("destination_address", $destination_address)
 set_tx_meta("idempotency_key", $idempotency_key)
 set_tx_meta("prime_api_symbol", $prime_api_symbol)
+set_tx_meta("network", $network)
+set_tx_meta("prime_tx_id", $prime_tx_id)
+set_tx_meta("network_fees", $network_fees)
+set_tx_meta("fees", $fees)
 `
 
 const numscriptWithdrawalConfirmed = `vars {
@@ -282,6 +313,8 @@ const numscriptWithdrawalConfirmedDirect = `vars {
   string $network
   string $prime_tx_id
   string $idempotency_key
+  string $network_fees
+  string $fees
 }
 
 send [$asset $amount] (
@@ -300,6 +333,8 @@ set_tx_meta("user_id", $user_id)
 set_tx_meta("network", $network)
 set_tx_meta("prime_tx_id", $prime_tx_id)
 set_tx_meta("idempotency_key", $idempotency_key)
+set_tx_meta("network_fees", $network_fees)
+set_tx_meta("fees", $fees)
 `
 
 const numscriptConversion = `vars {
@@ -391,6 +426,7 @@ func (s *Service) ProcessDepositPending(ctx context.Context, asset, walletId str
 				"wallet_id":       walletId,
 				"external_tx_id":  transactionId,
 				"deposit_address": depositAddress,
+				"deposit_tag":     models.DepositTag(ctx),
 				"asset_symbol":    asset,
 				"prime_status":    "TRANSACTION_IMPORT_PENDING",
 			},
@@ -400,7 +436,7 @@ func (s *Service) ProcessDepositPending(ctx context.Context, asset, walletId str
 		postTx.Timestamp = &pdc.TransactionTime
 	}
 
-	_, err := s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -421,14 +457,22 @@ func (s *Service) ProcessDepositPending(ctx context.Context, asset, walletId str
 // ConfirmDeposit moves funds from pending deposits to the user's account.
 // This is phase 2 of the two-phase deposit flow (TRANSACTION_IMPORTED).
 func (s *Service) ConfirmDeposit(ctx context.Context, address, asset string, amount decimal.Decimal, transactionId string) error {
-	user, addr, err := s.FindUserByAddress(ctx, address)
+	tag := models.DepositTag(ctx)
+	user, addr, err := s.FindUserByAddress(ctx, address, tag)
 	if err != nil {
 		return fmt.Errorf("error finding user by address: %w", err)
 	}
-	var userId, canonicalSymbol string
+	var userId, canonicalSymbol, quarantinedUserId, suspenseUserId string
 	if user != nil {
 		userId = user.Id
 		canonicalSymbol = addr.Asset
+		if userId, quarantinedUserId, err = s.depositRecipient(ctx, user.Id); err != nil {
+			return err
+		}
+	} else if suspenseUserId, canonicalSymbol, err = s.suspenseAddressOwner(ctx, address, tag); err != nil {
+		return err
+	} else if suspenseUserId != "" {
+		userId = store.SuspenseUserId(s.portfolioID)
 	} else {
 		userId = "prime-platform-" + s.portfolioID
 		canonicalSymbol = normalizeSymbolFallback(asset)
@@ -444,14 +488,17 @@ func (s *Service) ConfirmDeposit(ctx context.Context, address, asset string, amo
 		Script: &shared.V2PostTransactionScript{
 			Plain: numscriptDepositConfirmed,
 			Vars: map[string]string{
-				"asset":           fAsset,
-				"amount":          smallAmt,
-				"user_id":         userId,
-				"portfolio_id":    s.portfolioID,
-				"external_tx_id":  transactionId,
-				"deposit_address": address,
-				"asset_symbol":    canonicalSymbol,
-				"prime_status":    "TRANSACTION_IMPORTED",
+				"asset":               fAsset,
+				"amount":              smallAmt,
+				"user_id":             userId,
+				"portfolio_id":        s.portfolioID,
+				"external_tx_id":      transactionId,
+				"deposit_address":     address,
+				"deposit_tag":         tag,
+				"asset_symbol":        canonicalSymbol,
+				"prime_status":        "TRANSACTION_IMPORTED",
+				"quarantined_user_id": quarantinedUserId,
+				"suspense_user_id":    suspenseUserId,
 			},
 		},
 	}
@@ -459,7 +506,7 @@ func (s *Service) ConfirmDeposit(ctx context.Context, address, asset string, amo
 		postTx.Timestamp = &pdc.TransactionTime
 	}
 
-	_, err = s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err = s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -479,7 +526,8 @@ func (s *Service) ConfirmDeposit(ctx context.Context, address, asset string, amo
 
 // ProcessDeposit credits a user's network account from the Prime wallet (single-phase, for backward compat).
 func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amount decimal.Decimal, transactionId string) error {
-	user, addr, err := s.FindUserByAddress(ctx, address)
+	tag := models.DepositTag(ctx)
+	user, addr, err := s.FindUserByAddress(ctx, address, tag)
 	if err != nil {
 		return fmt.Errorf("error finding user by address: %w", err)
 	}
@@ -487,11 +535,13 @@ func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amo
 	// If no user is mapped to this address, credit the platform account.
 	canonicalSymbol := asset
 	var network, walletId, accountIdentifier, userName, userEmail string
-	var userId string
+	var userId, quarantinedUserId, suspenseUserId string
 
 	if user != nil {
 		canonicalSymbol = addr.Asset
-		userId = user.Id
+		if userId, quarantinedUserId, err = s.depositRecipient(ctx, user.Id); err != nil {
+			return err
+		}
 		network = addr.Network
 		walletId = addr.WalletId
 		accountIdentifier = addr.AccountIdentifier
@@ -502,6 +552,11 @@ func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amo
 				zap.String("prime_symbol", asset),
 				zap.String("canonical", canonicalSymbol))
 		}
+	} else if suspenseUserId, canonicalSymbol, err = s.suspenseAddressOwner(ctx, address, tag); err != nil {
+		return err
+	} else if suspenseUserId != "" {
+		userId = store.SuspenseUserId(s.portfolioID)
+		network = "suspense"
 	} else {
 		userId = "prime-platform-" + s.portfolioID
 		canonicalSymbol = normalizeSymbolFallback(asset)
@@ -529,32 +584,35 @@ func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amo
 	smallAmt := amount.Shift(int32(precisionFor(canonicalSymbol))).BigInt().String()
 
 	vars := map[string]string{
-		"asset":              fAsset,
-		"amount":             smallAmt,
-		"user_id":            userId,
-		"network":            network,
-		"portfolio_id":       s.portfolioID,
-		"wallet_id":          walletId,
-		"external_tx_id":     transactionId,
-		"deposit_address":    address,
-		"prime_status":       "TRANSACTION_IMPORTED",
-		"asset_symbol":       canonicalSymbol,
-		"user_name":          userName,
-		"user_email":         userEmail,
-		"prime_wallet_id":    walletId,
-		"prime_network":      network,
-		"account_identifier": accountIdentifier,
-		"prime_api_symbol":   asset,
-		"canonical_symbol":   canonicalSymbol,
-		"amount_human":       amount.String(),
-		"prime_tx_id":        "",
-		"source_address":     "",
-		"source_type":        "",
-		"network_fees":       "",
-		"fees":               "",
-		"blockchain_ids":     "",
-		"prime_created_at":   "",
-		"prime_completed_at": "",
+		"asset":               fAsset,
+		"amount":              smallAmt,
+		"user_id":             userId,
+		"network":             network,
+		"portfolio_id":        s.portfolioID,
+		"wallet_id":           walletId,
+		"external_tx_id":      transactionId,
+		"deposit_address":     address,
+		"deposit_tag":         tag,
+		"prime_status":        "TRANSACTION_IMPORTED",
+		"asset_symbol":        canonicalSymbol,
+		"user_name":           userName,
+		"user_email":          userEmail,
+		"prime_wallet_id":     walletId,
+		"prime_network":       network,
+		"account_identifier":  accountIdentifier,
+		"prime_api_symbol":    asset,
+		"canonical_symbol":    canonicalSymbol,
+		"amount_human":        amount.String(),
+		"prime_tx_id":         "",
+		"source_address":      "",
+		"source_type":         "",
+		"network_fees":        "",
+		"fees":                "",
+		"blockchain_ids":      "",
+		"prime_created_at":    "",
+		"prime_completed_at":  "",
+		"quarantined_user_id": quarantinedUserId,
+		"suspense_user_id":    suspenseUserId,
 	}
 
 	// Enrich with full Prime transaction data if available via context.
@@ -583,7 +641,7 @@ func (s *Service) ProcessDeposit(ctx context.Context, address, asset string, amo
 		postTx.Timestamp = &pdc.TransactionTime
 	}
 
-	_, err = s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err = s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -625,6 +683,10 @@ func (s *Service) ProcessWithdrawalFromWallet(ctx context.Context, params store.
 				"idempotency_key":     params.IdempotencyKey,
 				"prime_api_symbol":    params.PrimeApiSymbol,
 				"amount_human":        params.Amount.String(),
+				"network":             params.Network,
+				"prime_tx_id":         params.PrimeTxId,
+				"network_fees":        params.NetworkFees,
+				"fees":                params.Fees,
 			},
 		},
 	}
@@ -632,7 +694,7 @@ func (s *Service) ProcessWithdrawalFromWallet(ctx context.Context, params store.
 		postTx.Timestamp = &params.TransactionTime
 	}
 
-	_, err := s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -650,33 +712,48 @@ func (s *Service) ProcessWithdrawalFromWallet(ctx context.Context, params store.
 	return nil
 }
 
-// ProcessWithdrawal debits a user's account into the pending withdrawal account.
+// ProcessWithdrawal debits a user's account into the pending withdrawal account. The
+// ledger refuses to overdraw users:{id}, which fails with store.ErrInsufficientAvailable.
 func (s *Service) ProcessWithdrawal(ctx context.Context, userId, asset string, amount decimal.Decimal, transactionId string) error {
+	if err := s.checkNotFrozen(ctx, userId); err != nil {
+		zap.L().Warn("Withdrawal blocked for frozen user", zap.String("user_id", userId), zap.Error(err))
+		return err
+	}
+
 	fAsset := formanceAsset(asset)
 	smallAmt := amount.Shift(int32(precisionFor(asset))).BigInt().String()
 
-	_, err := s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
-		Ledger: s.ledger,
-		V2PostTransaction: shared.V2PostTransaction{
-			Reference: strPtr(transactionId),
-			Script: &shared.V2PostTransactionScript{
-				Plain: numscriptWithdrawalInitiated,
-				Vars: map[string]string{
-					"asset":               fAsset,
-					"amount":              smallAmt,
-					"user_id":             userId,
-					"portfolio_id":        s.portfolioID,
-					"destination_address": "",
-					"withdrawal_ref":      transactionId,
-					"asset_symbol":        asset,
-				},
+	postTx := shared.V2PostTransaction{
+		Reference: strPtr(transactionId),
+		Script: &shared.V2PostTransactionScript{
+			Plain: numscriptWithdrawalInitiated,
+			Vars: map[string]string{
+				"asset":               fAsset,
+				"amount":              smallAmt,
+				"user_id":             userId,
+				"portfolio_id":        s.portfolioID,
+				"destination_address": "",
+				"withdrawal_ref":      transactionId,
+				"asset_symbol":        asset,
 			},
 		},
+	}
+	if pdc := models.GetPrimeDepositContext(ctx); pdc != nil && !pdc.TransactionTime.IsZero() {
+		postTx.Timestamp = &pdc.TransactionTime
+	}
+
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
+		Ledger:            s.ledger,
+		V2PostTransaction: postTx,
 	})
 	if err != nil {
 		if isConflictError(err) {
 			return fmt.Errorf("%w: external_transaction_id %s already exists", store.ErrDuplicateTransaction, transactionId)
 		}
+		// Held funds have already left users:{id}, so this covers holds too
+		if isInsufficientFundError(err) {
+			return fmt.Errorf("%w: withdrawal %s %s", store.ErrInsufficientAvailable, amount, asset)
+		}
 		return fmt.Errorf("error processing withdrawal transaction: %w", err)
 	}
 
@@ -734,8 +811,11 @@ func (s *Service) ConfirmWithdrawal(ctx context.Context, userId, asset string, a
 			},
 		},
 	}
+	if pdc := models.GetPrimeDepositContext(ctx); pdc != nil && !pdc.TransactionTime.IsZero() {
+		postTx.Timestamp = &pdc.TransactionTime
+	}
 
-	_, err = s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err = s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -785,6 +865,8 @@ func (s *Service) ConfirmWithdrawalDirect(ctx context.Context, params store.With
 				"network":             params.Network,
 				"prime_tx_id":         params.PrimeTxId,
 				"idempotency_key":     params.IdempotencyKey,
+				"network_fees":        params.NetworkFees,
+				"fees":                params.Fees,
 			},
 		},
 	}
@@ -792,7 +874,7 @@ func (s *Service) ConfirmWithdrawalDirect(ctx context.Context, params store.With
 		postTx.Timestamp = &params.TransactionTime
 	}
 
-	_, err := s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -877,6 +959,9 @@ func (s *Service) RevertTransaction(ctx context.Context, reference string) error
 		return nil
 	}
 
+	if err := s.checkFence(ctx); err != nil {
+		return err
+	}
 	_, err = s.client.Ledger.V2.RevertTransaction(ctx, operations.V2RevertTransactionRequest{
 		Ledger:          s.ledger,
 		ID:              tx.ID,
@@ -926,6 +1011,10 @@ func (s *Service) RecordFailedWithdrawalPlatform(ctx context.Context, params sto
 				"destination_address": params.DestinationAddress,
 				"idempotency_key":     params.IdempotencyKey,
 				"prime_api_symbol":    params.PrimeApiSymbol,
+				"network":             params.Network,
+				"prime_tx_id":         params.PrimeTxId,
+				"network_fees":        params.NetworkFees,
+				"fees":                params.Fees,
 			},
 		},
 	}
@@ -933,7 +1022,7 @@ func (s *Service) RecordFailedWithdrawalPlatform(ctx context.Context, params sto
 		postTx.Timestamp = &params.TransactionTime
 	}
 
-	_, err := s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -960,24 +1049,29 @@ func (s *Service) ReverseWithdrawal(ctx context.Context, userId, asset string, a
 	fAsset := formanceAsset(asset)
 	smallAmt := amount.Shift(int32(precisionFor(asset))).BigInt().String()
 
-	_, err := s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
-		Ledger: s.ledger,
-		V2PostTransaction: shared.V2PostTransaction{
-			Reference: strPtr(reversalRef),
-			Script: &shared.V2PostTransactionScript{
-				Plain: numscriptWithdrawalFailedReversal,
-				Vars: map[string]string{
-					"asset":          fAsset,
-					"amount":         smallAmt,
-					"user_id":        userId,
-					"portfolio_id":   s.portfolioID,
-					"external_tx_id": originalTxId,
-					"prime_status":   "TRANSACTION_FAILED",
-					"withdrawal_ref": originalTxId,
-					"reversal_ref":   reversalRef,
-				},
+	postTx := shared.V2PostTransaction{
+		Reference: strPtr(reversalRef),
+		Script: &shared.V2PostTransactionScript{
+			Plain: numscriptWithdrawalFailedReversal,
+			Vars: map[string]string{
+				"asset":          fAsset,
+				"amount":         smallAmt,
+				"user_id":        userId,
+				"portfolio_id":   s.portfolioID,
+				"external_tx_id": originalTxId,
+				"prime_status":   "TRANSACTION_FAILED",
+				"withdrawal_ref": originalTxId,
+				"reversal_ref":   reversalRef,
 			},
 		},
+	}
+	if pdc := models.GetPrimeDepositContext(ctx); pdc != nil && !pdc.TransactionTime.IsZero() {
+		postTx.Timestamp = &pdc.TransactionTime
+	}
+
+	_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
+		Ledger:            s.ledger,
+		V2PostTransaction: postTx,
 	})
 	if err != nil {
 		if isConflictError(err) {
@@ -1039,8 +1133,19 @@ func (s *Service) RecordPlatformTransaction(ctx context.Context, params store.Pl
 	if !params.TransactionTime.IsZero() {
 		postTx.Timestamp = &params.TransactionTime
 	}
+	// Extra Prime context (idempotency key, on-chain hash, fees) goes alongside the
+	// keys set by the script; empty values are dropped.
+	for k, v := range params.Metadata {
+		if v == "" {
+			continue
+		}
+		if postTx.Metadata == nil {
+			postTx.Metadata = make(map[string]string)
+		}
+		postTx.Metadata[k] = v
+	}
 
-	_, err = s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err = s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -1114,7 +1219,7 @@ func (s *Service) RecordConversion(ctx context.Context, params store.ConversionP
 		postTx.Timestamp = &params.TransactionTime
 	}
 
-	_, err = s.client.Ledger.V2.CreateTransaction(ctx, operations.V2CreateTransactionRequest{
+	_, err = s.createTransaction(ctx, operations.V2CreateTransactionRequest{
 		Ledger:            s.ledger,
 		V2PostTransaction: postTx,
 	})
@@ -1140,6 +1245,7 @@ func (s *Service) GetTransactionHistory(ctx context.Context, userId, asset strin
 	resp, err := s.client.Ledger.V2.ListTransactions(ctx, operations.V2ListTransactionsRequest{
 		Ledger:   s.ledger,
 		PageSize: &pageSize,
+		Sort:     strPtr("timestamp:desc"),
 		RequestBody: map[string]any{
 			"$or": []any{
 				map[string]any{"$match": map[string]any{"source": userPrefix}},
@@ -1163,58 +1269,140 @@ func (s *Service) GetTransactionHistory(ctx context.Context, userId, asset strin
 			continue
 		}
 
-		eventType := tx.Metadata["event_type"]
-		txType := "deposit"
-		if strings.Contains(eventType, "withdrawal") {
-			txType = "withdrawal"
-		}
+		result = append(result, toModelTransaction(tx, userId, asset))
 
-		// Derive signed amount from postings.
-		amt := decimal.Zero
-		for _, p := range tx.Postings {
-			symbol := assetSymbol(p.Asset)
-			if symbol != asset {
-				continue
-			}
-			pAmt := bigIntToDecimal(p.Amount, symbol)
-			if strings.HasPrefix(p.Source, userPrefix) {
-				amt = pAmt.Neg()
-			} else if strings.HasPrefix(p.Destination, userPrefix) {
-				amt = pAmt
-			}
+		if len(result) >= limit {
+			break
 		}
+	}
+	return result, nil
+}
+
+// FindTransactionsByMetadata returns transactions whose metadata matches every
+// key/value pair in filter (e.g. {"prime_tx_id": "0xabc"}), newest effective time first.
+func (s *Service) FindTransactionsByMetadata(ctx context.Context, filter map[string]string, limit int) ([]models.Transaction, error) {
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("at least one metadata filter is required")
+	}
 
-		ref := ""
-		if tx.Reference != nil {
-			ref = *tx.Reference
+	clauses := make([]any, 0, len(filter))
+	for k, v := range filter {
+		clause := map[string]any{"$match": map[string]any{"metadata[" + k + "]": v}}
+		if k == store.MetadataPrimeTxId {
+			// Platform transactions recorded before the rename only carry the legacy key
+			clause = map[string]any{"$or": []any{clause,
+				map[string]any{"$match": map[string]any{"metadata[" + store.MetadataLegacyPrimeTxId + "]": v}}}}
 		}
+		clauses = append(clauses, clause)
+	}
+	body := map[string]any{"$and": clauses}
+	if len(clauses) == 1 {
+		body = clauses[0].(map[string]any)
+	}
 
-		result = append(result, models.Transaction{
-			Id:                    fmt.Sprintf("%d", tx.ID),
-			UserId:                userId,
-			Asset:                 asset,
-			TransactionType:       txType,
-			Amount:                amt,
-			ExternalTransactionId: tx.Metadata["external_tx_id"],
-			Reference:             ref,
-			Status:                "confirmed",
-			CreatedAt:             tx.Timestamp,
-			ProcessedAt:           tx.Timestamp,
-		})
+	pageSize := int64(limit)
+	resp, err := s.client.Ledger.V2.ListTransactions(ctx, operations.V2ListTransactionsRequest{
+		Ledger:      s.ledger,
+		PageSize:    &pageSize,
+		Sort:        strPtr("timestamp:desc"),
+		RequestBody: body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions by metadata: %w", err)
+	}
 
-		if len(result) >= limit {
-			break
+	var result []models.Transaction
+	for _, tx := range resp.V2TransactionsCursorResponse.Cursor.Data {
+		userId := userFromPostings(tx.Postings)
+		if userId == "" {
+			userId = tx.Metadata["user_id"]
 		}
+		result = append(result, toModelTransaction(tx, userId, tx.Metadata["asset_symbol"]))
 	}
 	return result, nil
 }
 
-// GetMostRecentTransactionTime returns the timestamp of the most recent transaction.
+// toModelTransaction maps a Formance transaction onto models.Transaction from the
+// point of view of one user: the amount is signed by whether users:{userId} is the
+// source or destination of the posting in the given asset. An empty asset uses the
+// first posting's asset.
+func toModelTransaction(tx shared.V2Transaction, userId, asset string) models.Transaction {
+	userPrefix := "users:" + userId
+	if asset == "" && len(tx.Postings) > 0 {
+		asset = assetSymbol(tx.Postings[0].Asset)
+	}
+
+	eventType := tx.Metadata["event_type"]
+	txType := "deposit"
+	if strings.Contains(eventType, "withdrawal") {
+		txType = "withdrawal"
+	} else if eventType == "fee_charge" {
+		txType = "fee"
+	}
+
+	// Derive signed amount from postings.
+	amt := decimal.Zero
+	for _, p := range tx.Postings {
+		symbol := assetSymbol(p.Asset)
+		if symbol != asset {
+			continue
+		}
+		pAmt := bigIntToDecimal(p.Amount, symbol)
+		if strings.HasPrefix(p.Source, userPrefix) {
+			amt = pAmt.Neg()
+		} else if strings.HasPrefix(p.Destination, userPrefix) {
+			amt = pAmt
+		} else if userId == "" && amt.IsZero() {
+			amt = pAmt
+		}
+	}
+
+	ref := ""
+	if tx.Reference != nil {
+		ref = *tx.Reference
+	}
+
+	return models.Transaction{
+		Id:                    fmt.Sprintf("%d", tx.ID),
+		UserId:                userId,
+		Asset:                 asset,
+		TransactionType:       txType,
+		Amount:                amt,
+		ExternalTransactionId: tx.Metadata["external_tx_id"],
+		Address:               tx.Metadata["deposit_address"],
+		Reference:             ref,
+		Status:                "confirmed",
+		CreatedAt:             tx.Timestamp,
+		ProcessedAt:           tx.Timestamp,
+		EffectiveAt:           tx.Timestamp,
+		Network:               tx.Metadata["network"],
+		SourceAddress:         tx.Metadata["source_address"],
+		TxHash:                store.PrimeTxIdFromMetadata(tx.Metadata),
+		NetworkFees:           tx.Metadata["network_fees"],
+		Fees:                  tx.Metadata["fees"],
+		Metadata:              tx.Metadata,
+	}
+}
+
+// userFromPostings returns the user id of the first users:{id} account touched by the postings.
+func userFromPostings(postings []shared.V2Posting) string {
+	for _, p := range postings {
+		for _, acct := range []string{p.Source, p.Destination} {
+			if strings.HasPrefix(acct, "users:") {
+				return strings.TrimPrefix(acct, "users:")
+			}
+		}
+	}
+	return ""
+}
+
+// GetMostRecentTransactionTime returns the most recent effective timestamp in the ledger.
 func (s *Service) GetMostRecentTransactionTime(ctx context.Context) (time.Time, error) {
 	pageSize := int64(1)
 	resp, err := s.client.Ledger.V2.ListTransactions(ctx, operations.V2ListTransactionsRequest{
 		Ledger:   s.ledger,
 		PageSize: &pageSize,
+		Sort:     strPtr("timestamp:desc"),
 	})
 	if err != nil {
 		return time.Time{}, fmt.Errorf("failed to get recent transaction: %w", err)
@@ -1239,21 +1427,12 @@ func (s *Service) ReconcileUserBalance(ctx context.Context, userId, asset string
 // normalizeSymbolFallback normalizes Prime API symbols (e.g. BASEUSDC -> USDC) for
 // deposits that aren't mapped to a user (no address index to resolve from).
 func normalizeSymbolFallback(symbol string) string {
-	mapping := map[string]string{
-		"BASEUSDC": "USDC", "SPLUSDC": "USDC", "AVAUSDC": "USDC", "ARBUSDC": "USDC",
-		"BASEETH": "ETH",
-	}
-	if canonical, ok := mapping[symbol]; ok {
-		return canonical
-	}
-	return symbol
+	return assets.Current().Canonical(symbol)
 }
 
+// precisionFor returns the registry precision of a canonical symbol.
 func precisionFor(symbol string) int {
-	if p, ok := assetPrecision[symbol]; ok {
-		return p
-	}
-	return 6
+	return assets.Current().Precision(symbol)
 }
 
 func strPtr(s string) *string  { return &s }
@@ -0,0 +1,59 @@
+package formance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+	"go.uber.org/zap"
+)
+
+// GetTrialBalance returns total debits (volume input) and credits (volume output) per
+// account and asset as of the given effective time (zero means now), ordered by
+// asset then account. Formance postings always balance, so debits equal credits per asset.
+func (s *Service) GetTrialBalance(ctx context.Context, asOf time.Time) ([]models.TrialBalanceLine, error) {
+	req := operations.V2GetVolumesWithBalancesRequest{
+		Ledger:        s.ledger,
+		InsertionDate: ptrBool(false),
+	}
+	if !asOf.IsZero() {
+		endTime := asOf.Add(time.Microsecond)
+		req.EndTime = &endTime
+	}
+
+	var lines []models.TrialBalanceLine
+	for {
+		resp, err := s.client.Ledger.V2.GetVolumesWithBalances(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get volumes for trial balance: %w", err)
+		}
+		cursor := resp.V2VolumesWithBalanceCursorResponse.Cursor
+		for _, v := range cursor.Data {
+			symbol := assetSymbol(v.Asset)
+			lines = append(lines, models.TrialBalanceLine{
+				Account: v.Account,
+				Asset:   symbol,
+				Debits:  bigIntToDecimal(v.Input, symbol),
+				Credits: bigIntToDecimal(v.Output, symbol),
+			})
+		}
+		if !cursor.HasMore || cursor.Next == nil {
+			break
+		}
+		req = operations.V2GetVolumesWithBalancesRequest{Ledger: s.ledger, Cursor: cursor.Next}
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Asset != lines[j].Asset {
+			return lines[i].Asset < lines[j].Asset
+		}
+		return lines[i].Account < lines[j].Account
+	})
+
+	zap.L().Debug("Computed Formance trial balance", zap.Int("lines", len(lines)))
+	return lines, nil
+}
@@ -0,0 +1,258 @@
+package formance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
+)
+
+// A Prime transaction's lifecycle state lives in the metadata of a
+// primetx:{prime id} account, with each history entry under
+// tx_transition_{unix nanos} as JSON. Formance cannot compare and set metadata,
+// so the state check before a transition is best effort; the listener's unique
+// ledger references still stop a transition racing another from double booking.
+
+const (
+	txKindKey               = "tx_kind"
+	txStateKey              = "tx_state"
+	txPrimeStatusKey        = "tx_prime_status"
+	txQuarantinedKey        = "tx_quarantined"
+	txCreatedAtKey          = "tx_created_at"
+	txUpdatedAtKey          = "tx_updated_at"
+	txTransitionKeyPrefix   = "tx_transition_"
+	txStateAccountNamespace = "primetx:"
+)
+
+// txStateAccount returns the account holding a Prime transaction's lifecycle.
+func txStateAccount(primeTxId string) (string, error) {
+	if !validAccountSegment.MatchString(primeTxId) {
+		return "", fmt.Errorf("invalid prime transaction id %q", primeTxId)
+	}
+	return txStateAccountNamespace + primeTxId, nil
+}
+
+// GetTxState returns a Prime transaction's lifecycle state, or nil if it has
+// never been recorded.
+func (s *Service) GetTxState(ctx context.Context, primeTxId string) (*models.TxState, error) {
+	meta, err := s.txAccountMetadata(ctx, primeTxId)
+	if err != nil {
+		return nil, err
+	}
+	return parseTxState(primeTxId, meta)
+}
+
+// RecordTxTransition advances a transaction and appends its history entry in a
+// single metadata update.
+func (s *Service) RecordTxTransition(ctx context.Context, params store.TxTransitionParams) (*models.TxState, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkFence(ctx); err != nil {
+		return nil, err
+	}
+	current, err := s.GetTxState(ctx, params.PrimeTxId)
+	if err != nil {
+		return nil, err
+	}
+	currentState := models.TxStateNew
+	if current != nil {
+		currentState = current.State
+	}
+	if currentState != params.FromState {
+		return nil, fmt.Errorf("%w: %s is %s, not %s", store.ErrTxStateConflict, params.PrimeTxId, currentState, params.FromState)
+	}
+
+	now := time.Now().UTC()
+	state := &models.TxState{
+		PrimeTxId:   params.PrimeTxId,
+		Kind:        params.Kind,
+		State:       params.ToState,
+		PrimeStatus: params.PrimeStatus,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if current != nil {
+		state.Quarantined = current.Quarantined
+		state.CreatedAt = current.CreatedAt
+	}
+	if err := s.writeTxState(ctx, params, state, models.TxOutcomeApplied, now); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// QuarantineTx records a transition that was refused and flags the transaction
+// for review. Its state is left as it was.
+func (s *Service) QuarantineTx(ctx context.Context, params store.TxTransitionParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkFence(ctx); err != nil {
+		return err
+	}
+	current, err := s.GetTxState(ctx, params.PrimeTxId)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	state := current
+	if state == nil {
+		state = &models.TxState{PrimeTxId: params.PrimeTxId, Kind: params.Kind, State: params.FromState, CreatedAt: now}
+	}
+	state.Quarantined = true
+	state.UpdatedAt = now
+	return s.writeTxState(ctx, params, state, models.TxOutcomeQuarantined, now)
+}
+
+// GetTxHistory returns a transaction's lifecycle history, oldest first.
+func (s *Service) GetTxHistory(ctx context.Context, primeTxId string) ([]models.TxTransition, error) {
+	meta, err := s.txAccountMetadata(ctx, primeTxId)
+	if err != nil {
+		return nil, err
+	}
+	var history []models.TxTransition
+	for key, value := range meta {
+		if !strings.HasPrefix(key, txTransitionKeyPrefix) {
+			continue
+		}
+		var t models.TxTransition
+		if err := json.Unmarshal([]byte(value), &t); err != nil {
+			return nil, fmt.Errorf("failed to decode transition %s: %w", key, err)
+		}
+		history = append(history, t)
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].CreatedAt.Before(history[j].CreatedAt) })
+	return history, nil
+}
+
+// GetQuarantinedTxs returns every transaction flagged by QuarantineTx.
+func (s *Service) GetQuarantinedTxs(ctx context.Context) ([]models.TxState, error) {
+	resp, err := s.client.Ledger.V2.ListAccounts(ctx, operations.V2ListAccountsRequest{
+		Ledger:   s.ledger,
+		PageSize: ptrInt64(100),
+		RequestBody: map[string]any{
+			"$match": map[string]any{
+				"metadata[" + txQuarantinedKey + "]": "true",
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined transactions: %w", err)
+	}
+
+	var states []models.TxState
+	for _, acct := range resp.V2AccountsCursorResponse.Cursor.Data {
+		primeTxId, ok := strings.CutPrefix(acct.Address, txStateAccountNamespace)
+		if !ok {
+			continue
+		}
+		state, err := parseTxState(primeTxId, acct.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if state != nil {
+			states = append(states, *state)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].UpdatedAt.Before(states[j].UpdatedAt) })
+	return states, nil
+}
+
+// txAccountMetadata returns the metadata of a transaction's lifecycle account,
+// or nil if the account does not exist.
+func (s *Service) txAccountMetadata(ctx context.Context, primeTxId string) (map[string]string, error) {
+	account, err := txStateAccount(primeTxId)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Ledger.V2.GetAccount(ctx, operations.V2GetAccountRequest{
+		Ledger:  s.ledger,
+		Address: account,
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get state of transaction %s: %w", primeTxId, err)
+	}
+	return resp.V2AccountResponse.Data.Metadata, nil
+}
+
+// writeTxState stores the state and its history entry in a single metadata update.
+func (s *Service) writeTxState(ctx context.Context, params store.TxTransitionParams, state *models.TxState, outcome string, now time.Time) error {
+	account, err := txStateAccount(params.PrimeTxId)
+	if err != nil {
+		return err
+	}
+	transition, err := json.Marshal(models.TxTransition{
+		PrimeTxId:   params.PrimeTxId,
+		Kind:        params.Kind,
+		FromState:   params.FromState,
+		ToState:     params.ToState,
+		PrimeStatus: params.PrimeStatus,
+		Action:      params.Action,
+		Outcome:     outcome,
+		Detail:      params.Detail,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode transition: %w", err)
+	}
+
+	meta := txStateMetadata(state)
+	meta[txTransitionKeyPrefix+strconv.FormatInt(now.UnixNano(), 10)] = string(transition)
+	_, err = s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+		Ledger:      s.ledger,
+		Address:     account,
+		RequestBody: meta,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record state of transaction %s: %w", params.PrimeTxId, err)
+	}
+	return nil
+}
+
+// txStateMetadata encodes a lifecycle state as account metadata.
+func txStateMetadata(t *models.TxState) map[string]string {
+	return map[string]string{
+		txKindKey:        t.Kind,
+		txStateKey:       t.State,
+		txPrimeStatusKey: t.PrimeStatus,
+		txQuarantinedKey: strconv.FormatBool(t.Quarantined),
+		txCreatedAtKey:   t.CreatedAt.Format(time.RFC3339Nano),
+		txUpdatedAtKey:   t.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+// parseTxState decodes lifecycle account metadata; an account without it has no state.
+func parseTxState(primeTxId string, meta map[string]string) (*models.TxState, error) {
+	if meta[txStateKey] == "" {
+		return nil, nil
+	}
+	state := &models.TxState{
+		PrimeTxId:   primeTxId,
+		Kind:        meta[txKindKey],
+		State:       meta[txStateKey],
+		PrimeStatus: meta[txPrimeStatusKey],
+		Quarantined: meta[txQuarantinedKey] == "true",
+	}
+	var err error
+	for key, target := range map[string]*time.Time{
+		txCreatedAtKey: &state.CreatedAt,
+		txUpdatedAtKey: &state.UpdatedAt,
+	} {
+		if *target, err = time.Parse(time.RFC3339Nano, meta[key]); err != nil {
+			return nil, fmt.Errorf("invalid %s on transaction %s: %w", key, primeTxId, err)
+		}
+	}
+	return state, nil
+}
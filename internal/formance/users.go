@@ -6,7 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
 
 	"github.com/formancehq/formance-sdk-go/v3/pkg/models/operations"
 	"github.com/formancehq/formance-sdk-go/v3/pkg/models/shared"
@@ -23,10 +25,14 @@ func decimalFromString(s string) (decimal.Decimal, error) {
 
 func (s *Service) CreateUser(ctx context.Context, userId, name, email string) (*models.User, error) {
 	// Check if a user with this email already exists -- reject to prevent duplicates.
-	existing, err := s.GetUserByEmail(ctx, email)
-	if err == nil && existing != nil {
+	// Deactivated and closed users keep their email reserved.
+	existingId, err := s.emailOwner(ctx, email, "")
+	if err != nil {
+		return nil, err
+	}
+	if existingId != "" {
 		zap.L().Info("User with this email already exists in Formance",
-			zap.String("existing_id", existing.Id),
+			zap.String("existing_id", existingId),
 			zap.String("email", email))
 		return nil, fmt.Errorf("user with email %s already exists", email)
 	}
@@ -66,7 +72,7 @@ func (s *Service) GetUserById(ctx context.Context, userId string) (*models.User,
 	}
 
 	acct := resp.V2AccountResponse.Data
-	if acct.Metadata["email"] == "" {
+	if acct.Metadata["email"] == "" || !isActiveUser(acct.Metadata) {
 		return nil, fmt.Errorf("user not found: %s", userId)
 	}
 
@@ -89,7 +95,7 @@ func (s *Service) GetUserByEmail(ctx context.Context, email string) (*models.Use
 
 	for i := range resp.V2AccountsCursorResponse.Cursor.Data {
 		acct := &resp.V2AccountsCursorResponse.Cursor.Data[i]
-		if strings.HasPrefix(acct.Address, "users:") && !strings.Contains(acct.Address[6:], ":") {
+		if isUserAccount(acct.Address) && isActiveUser(acct.Metadata) {
 			return accountToUser(acct), nil
 		}
 	}
@@ -97,7 +103,18 @@ func (s *Service) GetUserByEmail(ctx context.Context, email string) (*models.Use
 }
 
 func (s *Service) GetUsers(ctx context.Context) ([]models.User, error) {
-	resp, err := s.client.Ledger.V2.ListAccounts(ctx, operations.V2ListAccountsRequest{
+	return s.listUsers(ctx, isActiveUser)
+}
+
+// GetOpenUsers returns active and deactivated users, leaving out closed ones.
+func (s *Service) GetOpenUsers(ctx context.Context) ([]models.User, error) {
+	return s.listUsers(ctx, func(meta map[string]string) bool { return meta["closed_at"] == "" })
+}
+
+// listUsers pages through every end-user account and returns the top-level ones
+// (users:{id}, not users:{id}:{network}) whose metadata passes keep.
+func (s *Service) listUsers(ctx context.Context, keep func(map[string]string) bool) ([]models.User, error) {
+	req := operations.V2ListAccountsRequest{
 		Ledger:   s.ledger,
 		PageSize: ptrInt64(100),
 		RequestBody: map[string]any{
@@ -105,25 +122,317 @@ func (s *Service) GetUsers(ctx context.Context) ([]models.User, error) {
 				"metadata[entity_type]": "end_user",
 			},
 		},
+	}
+	var users []models.User
+	for {
+		resp, err := s.client.Ledger.V2.ListAccounts(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+		cursor := resp.V2AccountsCursorResponse.Cursor
+		for i := range cursor.Data {
+			acct := &cursor.Data[i]
+			if isUserAccount(acct.Address) && keep(acct.Metadata) {
+				users = append(users, *accountToUser(acct))
+			}
+		}
+		if !cursor.HasMore || cursor.Next == nil {
+			return users, nil
+		}
+		req = operations.V2ListAccountsRequest{Ledger: s.ledger, Cursor: cursor.Next}
+	}
+}
+
+// ---------- Lifecycle ----------
+
+// UpdateUser changes an active user's name and/or email.
+func (s *Service) UpdateUser(ctx context.Context, params store.UpdateUserParams) (*models.User, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	user, err := s.GetUserById(ctx, params.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := make(map[string]string)
+	if params.Name != "" {
+		meta["name"] = params.Name
+	}
+	if params.Email != "" && params.Email != user.Email {
+		owner, err := s.emailOwner(ctx, params.Email, params.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if owner != "" {
+			return nil, fmt.Errorf("%w: %s", store.ErrEmailTaken, params.Email)
+		}
+		meta["email"] = params.Email
+	}
+
+	_, err = s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+		Ledger:      s.ledger,
+		Address:     "users:" + params.UserId,
+		RequestBody: meta,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list users: %w", err)
+		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
-	var users []models.User
-	for i := range resp.V2AccountsCursorResponse.Cursor.Data {
-		acct := &resp.V2AccountsCursorResponse.Cursor.Data[i]
-		// Only top-level user accounts (users:{id}, not users:{id}:{network}).
-		parts := strings.Split(acct.Address, ":")
-		if len(parts) == 2 && parts[0] == "users" {
-			users = append(users, *accountToUser(acct))
+	zap.L().Info("User updated in Formance", zap.String("user_id", params.UserId))
+	return s.GetUserById(ctx, params.UserId)
+}
+
+// DeactivateUser marks an active user inactive. Their balances and addresses are
+// kept, and deposits to their addresses are credited to suspense.
+func (s *Service) DeactivateUser(ctx context.Context, userId string) error {
+	if _, err := s.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+	_, err := s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+		Ledger:      s.ledger,
+		Address:     "users:" + userId,
+		RequestBody: map[string]string{"active": "false"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to deactivate user: %w", err)
+	}
+
+	zap.L().Info("User deactivated in Formance", zap.String("user_id", userId))
+	return nil
+}
+
+// CloseUser closes an active or deactivated user: balances must be zero, or are swept
+// when forced, then every address is retired and the account marked closed.
+// A frozen user, or one with active holds, cannot be closed. Sweeps are referenced
+// per asset, so a close interrupted part way can be run again.
+func (s *Service) CloseUser(ctx context.Context, params store.CloseUserParams) (*models.UserClosure, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	meta, err := s.userMetadata(ctx, params.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if meta["closed_at"] != "" {
+		return nil, fmt.Errorf("user %s is already closed", params.UserId)
+	}
+	if err := s.checkNotFrozen(ctx, params.UserId); err != nil {
+		return nil, err
+	}
+
+	holds, err := s.GetActiveHolds(ctx, params.UserId, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(holds) > 0 {
+		return nil, fmt.Errorf("user %s has %d active holds; capture or release them first", params.UserId, len(holds))
+	}
+
+	balances, err := s.GetAllUserBalances(ctx, params.UserId)
+	if err != nil {
+		return nil, err
+	}
+	closure := &models.UserClosure{UserId: params.UserId}
+	if len(balances) > 0 {
+		if !params.Force {
+			parts := make([]string, len(balances))
+			for i, b := range balances {
+				parts[i] = b.Balance.String() + " " + b.Asset
+			}
+			return nil, fmt.Errorf("%w: %s holds %s", store.ErrNonZeroBalance, params.UserId, strings.Join(parts, ", "))
+		}
+		if closure.Swept, err = s.sweepBalances(ctx, params, balances); err != nil {
+			return nil, err
+		}
+	}
+
+	closure.ClosedAt = time.Now().UTC()
+	update := map[string]string{
+		"active":    "false",
+		"closed_at": closure.ClosedAt.Format(time.RFC3339Nano),
+	}
+	for key, value := range meta {
+		if !strings.HasPrefix(key, "deposit_addr_") && !strings.HasPrefix(key, "withdrawal_addr_") {
+			continue
+		}
+		if strings.HasPrefix(value, retiredAddrPrefix) {
+			continue
+		}
+		update[key] = retiredAddrPrefix + value
+		if strings.HasPrefix(key, "deposit_addr_") {
+			closure.RetiredAddresses++
 		}
 	}
-	return users, nil
+	_, err = s.client.Ledger.V2.AddMetadataToAccount(ctx, operations.V2AddMetadataToAccountRequest{
+		Ledger:      s.ledger,
+		Address:     "users:" + params.UserId,
+		RequestBody: update,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to close user: %w", err)
+	}
+
+	zap.L().Warn("User closed in Formance",
+		zap.String("user_id", params.UserId),
+		zap.Int("swept_balances", len(closure.Swept)),
+		zap.Int("retired_addresses", closure.RetiredAddresses))
+	return closure, nil
+}
+
+const numscriptAccountClosure = `vars {
+  asset $asset
+  number $amount
+  account $user_id
+  account $sweep_to
+  string $closure_ref
+  string $asset_symbol
+  string $amount_human
+}
+
+send [$asset $amount] (
+  source = @users:$user_id
+  destination = $sweep_to
+)
+
+set_tx_meta("event_type", "account_closure")
+set_tx_meta("external_tx_id", $closure_ref)
+set_tx_meta("closed_user_id", $user_id)
+set_tx_meta("sweep_to", $sweep_to)
+set_tx_meta("asset_symbol", $asset_symbol)
+set_tx_meta("amount_human", $amount_human)
+`
+
+// sweepBalances moves each balance of a closing user to params.SweepTo, one
+// transaction per asset referenced close-{user}-{asset}.
+func (s *Service) sweepBalances(ctx context.Context, params store.CloseUserParams, balances []models.AccountBalance) ([]models.SweptBalance, error) {
+	for _, segment := range strings.Split(params.SweepTo, ":") {
+		if !validAccountSegment.MatchString(segment) {
+			return nil, fmt.Errorf("invalid sweep account %q", params.SweepTo)
+		}
+	}
+	if targetUserId, ok := strings.CutPrefix(params.SweepTo, "users:"); ok {
+		if _, err := s.GetUserById(ctx, targetUserId); err != nil {
+			return nil, fmt.Errorf("invalid sweep account %s: %w", params.SweepTo, err)
+		}
+	}
+
+	var swept []models.SweptBalance
+	for _, b := range balances {
+		if b.Balance.IsNegative() {
+			return nil, fmt.Errorf("cannot sweep negative %s balance %s of user %s", b.Asset, b.Balance, params.UserId)
+		}
+		ref := fmt.Sprintf("close-%s-%s", params.UserId, b.Asset)
+		_, err := s.createTransaction(ctx, operations.V2CreateTransactionRequest{
+			Ledger: s.ledger,
+			V2PostTransaction: shared.V2PostTransaction{
+				Reference: strPtr(ref),
+				Script: &shared.V2PostTransactionScript{
+					Plain: numscriptAccountClosure,
+					Vars: map[string]string{
+						"asset":        formanceAsset(b.Asset),
+						"amount":       b.Balance.Shift(int32(precisionFor(b.Asset))).BigInt().String(),
+						"user_id":      params.UserId,
+						"sweep_to":     params.SweepTo,
+						"closure_ref":  ref,
+						"asset_symbol": b.Asset,
+						"amount_human": b.Balance.String(),
+					},
+				},
+			},
+		})
+		if err != nil && !isConflictError(err) {
+			return nil, fmt.Errorf("failed to sweep %s balance: %w", b.Asset, err)
+		}
+		swept = append(swept, models.SweptBalance{Asset: b.Asset, Amount: b.Balance, To: params.SweepTo})
+	}
+	return swept, nil
+}
+
+// suspenseAddressOwner returns the user and asset of a deposit address that no
+// longer credits its owner: retired, or owned by a deactivated or closed user.
+// ownerId is empty when the address is unknown or still live.
+func (s *Service) suspenseAddressOwner(ctx context.Context, address, tag string) (ownerId, asset string, err error) {
+	depositKey := taggedMetaKey(addrMetaKey(address), tag)
+	depositFilter := "metadata[" + depositKey + "]"
+	var orClauses []any
+	for _, symbol := range assets.Current().Symbols() {
+		for _, value := range []string{symbol, retiredAddrPrefix + symbol} {
+			orClauses = append(orClauses, map[string]any{
+				"$match": map[string]any{depositFilter: value},
+			})
+		}
+	}
+
+	resp, err := s.client.Ledger.V2.ListAccounts(ctx, operations.V2ListAccountsRequest{
+		Ledger:   s.ledger,
+		PageSize: ptrInt64(1),
+		RequestBody: map[string]any{
+			"$or": orClauses,
+		},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to query accounts by address: %w", err)
+	}
+	if len(resp.V2AccountsCursorResponse.Cursor.Data) == 0 {
+		return "", "", nil
+	}
+	acct := resp.V2AccountsCursorResponse.Cursor.Data[0]
+	if !isUserAccount(acct.Address) {
+		return "", "", nil
+	}
+	value := acct.Metadata[depositKey]
+	if !strings.HasPrefix(value, retiredAddrPrefix) && isActiveUser(acct.Metadata) {
+		return "", "", nil
+	}
+
+	ownerId = strings.TrimPrefix(acct.Address, "users:")
+	zap.L().Warn("Deposit to retired address credited to suspense",
+		zap.String("user_id", ownerId),
+		zap.String("address", address))
+	return ownerId, strings.TrimPrefix(value, retiredAddrPrefix), nil
+}
+
+// emailOwner returns the id of the user, active or not, holding email other than
+// exceptUserId, or "" when the email is free.
+func (s *Service) emailOwner(ctx context.Context, email, exceptUserId string) (string, error) {
+	resp, err := s.client.Ledger.V2.ListAccounts(ctx, operations.V2ListAccountsRequest{
+		Ledger:   s.ledger,
+		PageSize: ptrInt64(100),
+		RequestBody: map[string]any{
+			"$match": map[string]any{
+				"metadata[email]": email,
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search user by email: %w", err)
+	}
+	for _, acct := range resp.V2AccountsCursorResponse.Cursor.Data {
+		if userId := strings.TrimPrefix(acct.Address, "users:"); isUserAccount(acct.Address) && userId != exceptUserId {
+			return userId, nil
+		}
+	}
+	return "", nil
 }
 
 // ---------- helpers ----------
 
+// retiredAddrPrefix marks a deposit_addr_/withdrawal_addr_ value whose address was
+// retired when its user was closed, so address lookups no longer match it.
+const retiredAddrPrefix = "retired:"
+
+// isUserAccount reports whether address is a top-level users:{id} account.
+func isUserAccount(address string) bool {
+	parts := strings.Split(address, ":")
+	return len(parts) == 2 && parts[0] == "users" && parts[1] != ""
+}
+
+// isActiveUser reports whether a user account has not been deactivated or closed.
+func isActiveUser(meta map[string]string) bool {
+	return meta["active"] != "false"
+}
+
 func accountToUser(acct *shared.V2Account) *models.User {
 	meta := acct.Metadata
 	addr := acct.Address
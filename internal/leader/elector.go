@@ -0,0 +1,226 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package leader elects one active instance per named lease so that hot
+// standbys can run alongside it. The leader renews its lease well within the
+// TTL; standbys retry on the same interval and take over once it lapses, so
+// failover takes at most TTL plus one renew interval. Work done as leader
+// carries the lease's fencing token, which the ledger backends check on writes.
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// LeaseStore is the part of store.LedgerStore the elector needs
+type LeaseStore interface {
+	AcquireLease(ctx context.Context, params store.AcquireLeaseParams) (*models.Lease, error)
+	ReleaseLease(ctx context.Context, fence models.LeaseFence) error
+}
+
+// Config configures an Elector
+type Config struct {
+	Store         LeaseStore
+	Name          string        // lease name, e.g. "listener:{portfolio id}"
+	Holder        string        // this instance's identity; see DefaultHolder
+	TTL           time.Duration // how long a lease lasts without renewal
+	RenewInterval time.Duration // how often to renew or retry; defaults to TTL/3
+}
+
+// Elector acquires and renews a lease in the background and reports whether
+// this instance currently leads.
+type Elector struct {
+	cfg Config
+
+	mutex sync.RWMutex
+	lease *models.Lease
+	lost  bool
+
+	changes     chan bool
+	notifyMutex sync.Mutex
+}
+
+// DefaultHolder identifies this process by host name and pid.
+func DefaultHolder() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// NewElector validates cfg and returns an elector that has not yet run.
+func NewElector(cfg Config) (*Elector, error) {
+	if cfg.Store == nil {
+		return nil, errors.New("lease store is required")
+	}
+	if cfg.Holder == "" {
+		cfg.Holder = DefaultHolder()
+	}
+	if cfg.RenewInterval <= 0 {
+		cfg.RenewInterval = cfg.TTL / 3
+	}
+	if err := (store.AcquireLeaseParams{Name: cfg.Name, Holder: cfg.Holder, TTL: cfg.TTL}).Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.RenewInterval >= cfg.TTL {
+		return nil, fmt.Errorf("renew interval %s must be shorter than the lease ttl %s", cfg.RenewInterval, cfg.TTL)
+	}
+	return &Elector{cfg: cfg, changes: make(chan bool, 1)}, nil
+}
+
+// Name returns the lease name
+func (e *Elector) Name() string { return e.cfg.Name }
+
+// Holder returns this instance's identity
+func (e *Elector) Holder() string { return e.cfg.Holder }
+
+// Changes delivers the latest leadership state whenever it changes. Only the
+// most recent state is kept, so a slow reader never blocks the elector.
+func (e *Elector) Changes() <-chan bool { return e.changes }
+
+// IsLeader reports whether this instance holds an unexpired lease
+func (e *Elector) IsLeader() bool {
+	_, ok := e.Fence()
+	return ok
+}
+
+// Fence returns the fencing token to write under, and false when this instance
+// does not lead.
+func (e *Elector) Fence() (models.LeaseFence, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.lease == nil || e.lost || e.lease.Expired(time.Now().UTC()) {
+		return models.LeaseFence{}, false
+	}
+	return e.lease.Fence(), true
+}
+
+// Lost marks the lease as lost after a write was refused for its fence, so work
+// stops before the next renewal notices.
+func (e *Elector) Lost() {
+	e.mutex.Lock()
+	wasLeader := e.lease != nil && !e.lost
+	e.lost = true
+	e.mutex.Unlock()
+
+	if wasLeader {
+		zap.L().Warn("Lease lost on write; standing down", zap.String("lease", e.cfg.Name))
+		e.notify(false)
+	}
+}
+
+// Run campaigns for the lease until ctx is cancelled, then releases it so a
+// standby can take over immediately.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.RenewInterval)
+	defer ticker.Stop()
+
+	e.campaign(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			e.campaign(ctx)
+		case <-ctx.Done():
+			e.release()
+			return
+		}
+	}
+}
+
+// campaign acquires or renews the lease once and publishes any change.
+func (e *Elector) campaign(ctx context.Context) {
+	wasLeader := e.IsLeader()
+
+	lease, err := e.cfg.Store.AcquireLease(ctx, store.AcquireLeaseParams{
+		Name:   e.cfg.Name,
+		Holder: e.cfg.Holder,
+		TTL:    e.cfg.TTL,
+	})
+
+	e.mutex.Lock()
+	switch {
+	case err == nil:
+		e.lease = lease
+		e.lost = false
+	case errors.Is(err, store.ErrLeaseHeld), errors.Is(err, store.ErrLeaseLost):
+		e.lease = nil
+	default:
+		// Keep leading until the current lease expires; the next renewal may succeed
+		zap.L().Warn("Failed to renew lease",
+			zap.String("lease", e.cfg.Name),
+			zap.Error(err))
+	}
+	e.mutex.Unlock()
+
+	isLeader := e.IsLeader()
+	if isLeader == wasLeader {
+		return
+	}
+	if isLeader {
+		zap.L().Info("Became leader",
+			zap.String("lease", e.cfg.Name),
+			zap.String("holder", e.cfg.Holder),
+			zap.Int64("token", lease.Token))
+	} else {
+		zap.L().Warn("No longer leader",
+			zap.String("lease", e.cfg.Name),
+			zap.String("holder", e.cfg.Holder),
+			zap.Error(err))
+	}
+	e.notify(isLeader)
+}
+
+func (e *Elector) notify(isLeader bool) {
+	e.notifyMutex.Lock()
+	defer e.notifyMutex.Unlock()
+
+	select {
+	case <-e.changes:
+	default:
+	}
+	e.changes <- isLeader
+}
+
+// release gives up the lease on shutdown
+func (e *Elector) release() {
+	fence, ok := e.Fence()
+	e.mutex.Lock()
+	e.lease = nil
+	e.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.cfg.Store.ReleaseLease(ctx, fence); err != nil {
+		zap.L().Warn("Failed to release lease", zap.String("lease", e.cfg.Name), zap.Error(err))
+		return
+	}
+	zap.L().Info("Released lease", zap.String("lease", e.cfg.Name), zap.Int64("token", fence.Token))
+}
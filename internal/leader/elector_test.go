@@ -0,0 +1,162 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+)
+
+// memoryLeases is an in-memory LeaseStore with the same takeover rules as the backends
+type memoryLeases struct {
+	mutex  sync.Mutex
+	leases map[string]*models.Lease
+	err    error
+}
+
+func (m *memoryLeases) AcquireLease(ctx context.Context, params store.AcquireLeaseParams) (*models.Lease, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+	now := time.Now().UTC()
+	current := m.leases[params.Name]
+	switch {
+	case current == nil:
+		current = &models.Lease{Name: params.Name, Holder: params.Holder, Token: 1, AcquiredAt: now}
+	case current.Holder == params.Holder && !current.Expired(now):
+	case current.Expired(now):
+		current = &models.Lease{Name: params.Name, Holder: params.Holder, Token: current.Token + 1, AcquiredAt: now}
+	default:
+		return nil, fmt.Errorf("%w: %s", store.ErrLeaseHeld, params.Name)
+	}
+	current.RenewedAt = now
+	current.ExpiresAt = now.Add(params.TTL)
+	m.leases[params.Name] = current
+
+	copied := *current
+	return &copied, nil
+}
+
+func (m *memoryLeases) ReleaseLease(ctx context.Context, fence models.LeaseFence) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if l := m.leases[fence.Name]; l != nil && l.Holder == fence.Holder && l.Token == fence.Token {
+		l.ExpiresAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func newTestElector(t *testing.T, leases LeaseStore, holder string) *Elector {
+	t.Helper()
+	e, err := NewElector(Config{Store: leases, Name: "listener:p1", Holder: holder, TTL: time.Minute, RenewInterval: time.Second})
+	if err != nil {
+		t.Fatalf("NewElector failed: %v", err)
+	}
+	return e
+}
+
+func expectChange(t *testing.T, e *Elector, want bool) {
+	t.Helper()
+	select {
+	case got := <-e.Changes():
+		if got != want {
+			t.Errorf("%s: leadership change %v, want %v", e.Holder(), got, want)
+		}
+	default:
+		t.Errorf("%s: expected a leadership change to %v", e.Holder(), want)
+	}
+}
+
+func TestElector_LeaderAndStandby(t *testing.T) {
+	leases := &memoryLeases{leases: make(map[string]*models.Lease)}
+	ctx := context.Background()
+	a := newTestElector(t, leases, "a")
+	b := newTestElector(t, leases, "b")
+
+	a.campaign(ctx)
+	b.campaign(ctx)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("Expected a to lead and b to stand by")
+	}
+	expectChange(t, a, true)
+	if fence, ok := a.Fence(); !ok || fence.Token != 1 || fence.Holder != "a" {
+		t.Errorf("Unexpected fence %+v, %v", fence, ok)
+	}
+
+	// a shuts down and releases; b takes over on its next campaign with a new token
+	a.release()
+	b.campaign(ctx)
+	if a.IsLeader() || !b.IsLeader() {
+		t.Fatalf("Expected b to take over after a released")
+	}
+	expectChange(t, b, true)
+	if fence, _ := b.Fence(); fence.Token != 2 {
+		t.Errorf("Expected token 2 after failover, got %d", fence.Token)
+	}
+}
+
+func TestElector_LostAndRenewalErrors(t *testing.T) {
+	leases := &memoryLeases{leases: make(map[string]*models.Lease)}
+	ctx := context.Background()
+	a := newTestElector(t, leases, "a")
+
+	a.campaign(ctx)
+	expectChange(t, a, true)
+
+	// A transient store failure keeps leadership until the lease expires
+	leases.err = errors.New("database is locked")
+	a.campaign(ctx)
+	if !a.IsLeader() {
+		t.Error("Expected a renewal error not to drop leadership while the lease is live")
+	}
+	leases.err = nil
+
+	// A renewal that finds the lease taken over gives it up at once
+	leases.err = fmt.Errorf("%w: listener:p1 token 1 was taken over during renewal", store.ErrLeaseLost)
+	a.campaign(ctx)
+	if a.IsLeader() {
+		t.Error("Expected a renewal refused with ErrLeaseLost to drop leadership")
+	}
+	expectChange(t, a, false)
+	leases.err = nil
+	a.campaign(ctx)
+	expectChange(t, a, true)
+
+	a.Lost()
+	if a.IsLeader() {
+		t.Error("Expected Lost to stop leading immediately")
+	}
+	expectChange(t, a, false)
+	if _, ok := a.Fence(); ok {
+		t.Error("Expected no fence after the lease was lost")
+	}
+}
+
+func TestNewElector_Validation(t *testing.T) {
+	leases := &memoryLeases{leases: make(map[string]*models.Lease)}
+	if _, err := NewElector(Config{Name: "x", TTL: time.Second}); err == nil {
+		t.Error("Expected a missing store to be rejected")
+	}
+	if _, err := NewElector(Config{Store: leases, TTL: time.Second}); err == nil {
+		t.Error("Expected a missing name to be rejected")
+	}
+	if _, err := NewElector(Config{Store: leases, Name: "x", TTL: time.Second, RenewInterval: time.Second}); err == nil {
+		t.Error("Expected a renew interval not shorter than the ttl to be rejected")
+	}
+	e, err := NewElector(Config{Store: leases, Name: "x", TTL: 15 * time.Second})
+	if err != nil {
+		t.Fatalf("NewElector failed: %v", err)
+	}
+	if e.cfg.RenewInterval != 5*time.Second || e.Holder() == "" {
+		t.Errorf("Expected defaults for renew interval and holder, got %s %q", e.cfg.RenewInterval, e.Holder())
+	}
+}
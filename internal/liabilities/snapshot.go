@@ -0,0 +1,239 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package liabilities snapshots end-user balances at a point in time and builds the
+// proof-of-liabilities Merkle sum tree defined by pkg/liabilityproof.
+package liabilities
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/store"
+	"prime-send-receive-go/pkg/liabilityproof"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// platformUserPrefix marks ledger users that hold platform funds, not customer liabilities
+const platformUserPrefix = "prime-platform"
+
+// Leaf is one user's committed balances. The nonce keeps leaf hashes from revealing
+// balances to anyone who does not hold the user's proof.
+type Leaf struct {
+	UserId   string            `json:"user_id"`
+	Nonce    string            `json:"nonce"`
+	Balances []decimal.Decimal `json:"balances"`
+}
+
+// Snapshot is the full, private tree input: every leaf with its nonce. Keep it
+// confidential; publish Summary() and hand each user only their own Proof.
+type Snapshot struct {
+	AsOf    time.Time           `json:"as_of"`
+	Assets  []string            `json:"assets"`
+	Leaves  []Leaf              `json:"leaves"` // sorted by user id
+	Root    liabilityproof.Node `json:"root"`
+	Clamped int                 `json:"clamped_negative_balances"` // negative balances committed as zero
+}
+
+// Summary is the public part of a snapshot.
+type Summary struct {
+	AsOf      time.Time                  `json:"as_of"`
+	RootHash  string                     `json:"root_hash"`
+	Totals    map[string]decimal.Decimal `json:"totals"`
+	LeafCount int                        `json:"leaf_count"`
+}
+
+// Nonce derives a user's nonce for a snapshot from the operator secret, so the same
+// secret and balance data always rebuild the same tree.
+func Nonce(secret []byte, asOf time.Time, userId string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(asOf.UTC().Format(time.RFC3339Nano) + "|" + userId))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Build snapshots every end-user balance in assets as of asOf and computes the root.
+// Negative balances are committed as zero, which can only overstate liabilities.
+func Build(ctx context.Context, db store.LedgerStore, asOf time.Time, assets []string, secret []byte) (*Snapshot, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("a nonce secret is required")
+	}
+	if asOf.IsZero() {
+		return nil, fmt.Errorf("as-of time is required")
+	}
+
+	assets = append([]string(nil), assets...)
+	sort.Strings(assets)
+	assets = dedupe(assets)
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("at least one asset is required")
+	}
+
+	users, err := db.GetOpenUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	snapshot := &Snapshot{AsOf: asOf.UTC(), Assets: assets}
+	for _, user := range users {
+		if strings.HasPrefix(user.Id, platformUserPrefix) {
+			continue
+		}
+		balances := make([]decimal.Decimal, len(assets))
+		for i, asset := range assets {
+			bal, err := db.GetUserBalanceAt(ctx, user.Id, asset, asOf)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get %s balance for user %s: %w", asset, user.Id, err)
+			}
+			if bal.IsNegative() {
+				zap.L().Warn("Negative balance committed as zero in liability snapshot",
+					zap.String("user_id", user.Id),
+					zap.String("asset", asset),
+					zap.String("balance", bal.String()))
+				snapshot.Clamped++
+				bal = decimal.Zero
+			}
+			balances[i] = bal
+		}
+		snapshot.Leaves = append(snapshot.Leaves, Leaf{
+			UserId:   user.Id,
+			Nonce:    Nonce(secret, asOf, user.Id),
+			Balances: balances,
+		})
+	}
+	if len(snapshot.Leaves) == 0 {
+		return nil, fmt.Errorf("no users to include in snapshot")
+	}
+	sort.Slice(snapshot.Leaves, func(i, j int) bool { return snapshot.Leaves[i].UserId < snapshot.Leaves[j].UserId })
+
+	levels := snapshot.levels()
+	snapshot.Root = levels[len(levels)-1][0]
+	return snapshot, nil
+}
+
+// levels returns every level of the tree, leaves first. A node without a sibling is
+// carried up unchanged rather than duplicated, so no balance is counted twice.
+func (s *Snapshot) levels() [][]liabilityproof.Node {
+	level := make([]liabilityproof.Node, len(s.Leaves))
+	for i, l := range s.Leaves {
+		level[i] = liabilityproof.Leaf(l.UserId, l.Nonce, l.Balances)
+	}
+	levels := [][]liabilityproof.Node{level}
+	for len(level) > 1 {
+		next := make([]liabilityproof.Node, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, liabilityproof.Parent(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// Proof returns the inclusion proof for one user.
+func (s *Snapshot) Proof(userId string) (*liabilityproof.Proof, error) {
+	index := sort.Search(len(s.Leaves), func(i int) bool { return s.Leaves[i].UserId >= userId })
+	if index == len(s.Leaves) || s.Leaves[index].UserId != userId {
+		return nil, fmt.Errorf("user %s is not in the snapshot", userId)
+	}
+
+	leaf := s.Leaves[index]
+	proof := &liabilityproof.Proof{
+		Assets:   s.Assets,
+		UserId:   leaf.UserId,
+		Nonce:    leaf.Nonce,
+		Balances: leaf.Balances,
+		Root:     s.Root,
+	}
+	levels := s.levels()
+	for _, level := range levels[:len(levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			side := liabilityproof.SideRight
+			if sibling < index {
+				side = liabilityproof.SideLeft
+			}
+			proof.Path = append(proof.Path, liabilityproof.Step{Side: side, Node: level[sibling]})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Summary returns the publishable root and totals.
+func (s *Snapshot) Summary() Summary {
+	totals := make(map[string]decimal.Decimal, len(s.Assets))
+	for i, asset := range s.Assets {
+		totals[asset] = s.Root.Sums[i]
+	}
+	return Summary{AsOf: s.AsOf, RootHash: s.Root.Hash, Totals: totals, LeafCount: len(s.Leaves)}
+}
+
+// Save writes the snapshot as JSON, readable only by the owner.
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads a snapshot written by Save and checks its leaves still produce its root.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if len(s.Leaves) == 0 {
+		return nil, fmt.Errorf("snapshot %s has no leaves", path)
+	}
+	levels := s.levels()
+	if root := levels[len(levels)-1][0]; root.Hash != s.Root.Hash {
+		return nil, fmt.Errorf("snapshot %s is corrupt: leaves do not produce the recorded root", path)
+	}
+	return &s, nil
+}
+
+func dedupe(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if s == "" || (i > 0 && s == sorted[i-1]) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package liabilities
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/testutil"
+	"prime-send-receive-go/pkg/liabilityproof"
+
+	"github.com/shopspring/decimal"
+)
+
+func testStore() *testutil.Ledger {
+	db := testutil.NewLedger()
+	db.Users = []models.User{
+		{Id: "u3"}, {Id: "u1"}, {Id: "prime-platform"}, {Id: "u2"}, {Id: "u5"}, {Id: "u4"},
+	}
+	for key, v := range map[string]string{
+		"u1:BTC":             "1.25",
+		"u1:USDC":            "100",
+		"u2:USDC":            "50.5",
+		"u3:BTC":             "-0.1",
+		"u4:BTC":             "0.75",
+		"u5:USDC":            "0.01",
+		"prime-platform:BTC": "1000",
+	} {
+		db.Balances[key] = testutil.Dec(v)
+	}
+	return db
+}
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	asOf := time.Date(2025, 6, 30, 23, 59, 59, 0, time.UTC)
+	secret := []byte("secret")
+
+	s, err := Build(ctx, testStore(), asOf, []string{"USDC", "BTC", "USDC"}, secret)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(s.Leaves) != 5 {
+		t.Fatalf("leaves = %d, want 5 (platform user excluded)", len(s.Leaves))
+	}
+	if s.Clamped != 1 {
+		t.Errorf("clamped = %d, want 1", s.Clamped)
+	}
+	summary := s.Summary()
+	if !summary.Totals["BTC"].Equal(testutil.Dec("2")) {
+		t.Errorf("BTC total = %s, want 2", summary.Totals["BTC"])
+	}
+	if !summary.Totals["USDC"].Equal(testutil.Dec("150.51")) {
+		t.Errorf("USDC total = %s, want 150.51", summary.Totals["USDC"])
+	}
+
+	again, err := Build(ctx, testStore(), asOf, []string{"BTC", "USDC"}, secret)
+	if err != nil {
+		t.Fatalf("Build again: %v", err)
+	}
+	if again.Root.Hash != s.Root.Hash {
+		t.Error("rebuilding the same snapshot produced a different root")
+	}
+	other, err := Build(ctx, testStore(), asOf, []string{"BTC", "USDC"}, []byte("other"))
+	if err != nil {
+		t.Fatalf("Build with other secret: %v", err)
+	}
+	if other.Root.Hash == s.Root.Hash {
+		t.Error("a different nonce secret should change the root")
+	}
+
+	// Every user's proof verifies, including the odd leaf carried up without a sibling
+	for _, leaf := range s.Leaves {
+		p, err := s.Proof(leaf.UserId)
+		if err != nil {
+			t.Fatalf("Proof(%s): %v", leaf.UserId, err)
+		}
+		if err := liabilityproof.Verify(*p); err != nil {
+			t.Errorf("Verify(%s): %v", leaf.UserId, err)
+		}
+	}
+	if _, err := s.Proof("prime-platform"); err == nil {
+		t.Error("expected no proof for the platform user")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	s, err := Build(context.Background(), testStore(), time.Now(), []string{"BTC"}, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Root.Hash != s.Root.Hash {
+		t.Errorf("loaded root %s, want %s", loaded.Root.Hash, s.Root.Hash)
+	}
+
+	loaded.Leaves[0].Balances[0] = decimal.Zero
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected Load to reject a snapshot whose leaves no longer match its root")
+	}
+}
+
+func TestBuild_IncludesDeactivatedUsersOnSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewSQLiteStore(t)
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	testutil.Deposit(t, db, "u1", "USDC", testutil.Dec("100"), "dep-1", at)
+	testutil.Deposit(t, db, "u2", "USDC", testutil.Dec("40"), "dep-2", at)
+
+	// Deactivating hides u2 from lookups but leaves the 40 USDC on the books
+	if err := db.DeactivateUser(ctx, "u2"); err != nil {
+		t.Fatalf("DeactivateUser: %v", err)
+	}
+
+	s, err := Build(ctx, db, at.Add(time.Hour), []string{"USDC"}, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	summary := s.Summary()
+	if summary.LeafCount != 2 {
+		t.Errorf("leaves = %d, want 2", summary.LeafCount)
+	}
+	if !summary.Totals["USDC"].Equal(testutil.Dec("140")) {
+		t.Errorf("USDC total = %s, want 140", summary.Totals["USDC"])
+	}
+	if _, err := s.Proof("u2"); err != nil {
+		t.Errorf("Proof for deactivated user: %v", err)
+	}
+}
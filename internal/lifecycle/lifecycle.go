@@ -0,0 +1,102 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lifecycle is the state machine Prime deposits and withdrawals move
+// through as the listener books them. Prime statuses map to target states;
+// only the transitions listed here may be booked, and anything else (a status
+// arriving out of order, or regressing after a terminal state) is rejected so
+// the ledger is never moved twice for the same step.
+//
+//	deposit:    new -> pending -> credited, new -> credited
+//	withdrawal: new -> pending -> completed | failed, new -> completed | failed
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+
+	"prime-send-receive-go/internal/models"
+)
+
+// ErrIllegalTransition is returned for a transition the lifecycle does not allow
+var ErrIllegalTransition = errors.New("illegal transaction state transition")
+
+// Ledger actions recorded with each transition
+const (
+	ActionNone           = "none"            // nothing was booked
+	ActionAlreadyBooked  = "already_booked"  // the ledger already had this step
+	ActionRecordPending  = "record_pending"  // deposit parked in pending
+	ActionConfirmPending = "confirm_pending" // pending moved to the user, or pending withdrawal settled
+	ActionCreditDirect   = "credit_direct"   // deposit credited without a pending step
+	ActionCreditFrozen   = "credit_frozen"   // deposit credited to a frozen user and held
+	ActionDebitPending   = "debit_pending"   // user debited to pending
+	ActionWalletPending  = "wallet_pending"  // unmatched withdrawal moved from the wallet to pending
+	ActionDebitDirect    = "debit_direct"    // withdrawal debited without a pending step
+	ActionRevert         = "revert"          // failed withdrawal reverted natively
+	ActionCreditBack     = "credit_back"     // failed withdrawal credited back with a compensating transaction
+	ActionRecordFailure  = "record_failure"  // unmatched failed withdrawal recorded against the platform
+)
+
+// targets maps each kind's Prime statuses to the state they move a transaction to
+var targets = map[string]map[string]string{
+	models.TxKindDeposit: {
+		"TRANSACTION_IMPORT_PENDING": models.TxStatePending,
+		"TRANSACTION_IMPORTED":       models.TxStateCredited,
+	},
+	models.TxKindWithdrawal: {
+		"OTHER_TRANSACTION_STATUS": models.TxStatePending,
+		"TRANSACTION_DONE":         models.TxStateCompleted,
+		"TRANSACTION_CANCELLED":    models.TxStateFailed,
+		"TRANSACTION_REJECTED":     models.TxStateFailed,
+		"TRANSACTION_FAILED":       models.TxStateFailed,
+		"TRANSACTION_EXPIRED":      models.TxStateFailed,
+	},
+}
+
+// allowed lists each kind's legal transitions by from state
+var allowed = map[string]map[string][]string{
+	models.TxKindDeposit: {
+		models.TxStateNew:     {models.TxStatePending, models.TxStateCredited},
+		models.TxStatePending: {models.TxStateCredited},
+	},
+	models.TxKindWithdrawal: {
+		models.TxStateNew:     {models.TxStatePending, models.TxStateCompleted, models.TxStateFailed},
+		models.TxStatePending: {models.TxStateCompleted, models.TxStateFailed},
+	},
+}
+
+// Target returns the state a Prime status moves a transaction of kind to, or ""
+// for a status the lifecycle does not act on (e.g. a withdrawal still in flight).
+func Target(kind, primeStatus string) string {
+	return targets[kind][primeStatus]
+}
+
+// Terminal reports whether no transition leaves state
+func Terminal(kind, state string) bool {
+	return state != models.TxStateNew && len(allowed[kind][state]) == 0
+}
+
+// Check reports whether a transaction of kind may move from one state to
+// another, failing with ErrIllegalTransition if not. Staying in the same state
+// is not a transition; callers treat it as already done.
+func Check(kind, from, to string) error {
+	for _, next := range allowed[kind][from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s %s -> %s", ErrIllegalTransition, kind, from, to)
+}
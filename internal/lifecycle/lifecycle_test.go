@@ -0,0 +1,73 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+
+	"prime-send-receive-go/internal/models"
+)
+
+func TestTarget(t *testing.T) {
+	tests := []struct {
+		kind, status, want string
+	}{
+		{models.TxKindDeposit, "TRANSACTION_IMPORT_PENDING", models.TxStatePending},
+		{models.TxKindDeposit, "TRANSACTION_IMPORTED", models.TxStateCredited},
+		{models.TxKindDeposit, "TRANSACTION_DONE", ""},
+		{models.TxKindWithdrawal, "OTHER_TRANSACTION_STATUS", models.TxStatePending},
+		{models.TxKindWithdrawal, "TRANSACTION_DONE", models.TxStateCompleted},
+		{models.TxKindWithdrawal, "TRANSACTION_EXPIRED", models.TxStateFailed},
+		{models.TxKindWithdrawal, "TRANSACTION_CREATED", ""},
+		{"conversion", "TRANSACTION_DONE", ""},
+	}
+	for _, tt := range tests {
+		if got := Target(tt.kind, tt.status); got != tt.want {
+			t.Errorf("Target(%s, %s) = %q, want %q", tt.kind, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	legal := [][3]string{
+		{models.TxKindDeposit, models.TxStateNew, models.TxStatePending},
+		{models.TxKindDeposit, models.TxStateNew, models.TxStateCredited},
+		{models.TxKindDeposit, models.TxStatePending, models.TxStateCredited},
+		{models.TxKindWithdrawal, models.TxStateNew, models.TxStateFailed},
+		{models.TxKindWithdrawal, models.TxStatePending, models.TxStateCompleted},
+		{models.TxKindWithdrawal, models.TxStatePending, models.TxStateFailed},
+	}
+	for _, tr := range legal {
+		if err := Check(tr[0], tr[1], tr[2]); err != nil {
+			t.Errorf("Check(%v) = %v, want nil", tr, err)
+		}
+	}
+
+	illegal := [][3]string{
+		{models.TxKindDeposit, models.TxStateCredited, models.TxStatePending},
+		{models.TxKindWithdrawal, models.TxStateCompleted, models.TxStateFailed},
+		{models.TxKindWithdrawal, models.TxStateFailed, models.TxStateCompleted},
+		{models.TxKindWithdrawal, models.TxStateCompleted, models.TxStatePending},
+		{models.TxKindWithdrawal, models.TxStateNew, models.TxStateCredited},
+	}
+	for _, tr := range illegal {
+		if err := Check(tr[0], tr[1], tr[2]); !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("Check(%v) = %v, want ErrIllegalTransition", tr, err)
+		}
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	for _, state := range []string{models.TxStateCompleted, models.TxStateFailed} {
+		if !Terminal(models.TxKindWithdrawal, state) {
+			t.Errorf("Expected withdrawal %s to be terminal", state)
+		}
+	}
+	if !Terminal(models.TxKindDeposit, models.TxStateCredited) {
+		t.Error("Expected a credited deposit to be terminal")
+	}
+	for _, state := range []string{models.TxStateNew, models.TxStatePending} {
+		if Terminal(models.TxKindDeposit, state) {
+			t.Errorf("Expected deposit %s not to be terminal", state)
+		}
+	}
+}
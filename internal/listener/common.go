@@ -24,7 +24,9 @@ import (
 	"time"
 
 	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/assets"
 	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/leader"
 	"prime-send-receive-go/internal/models"
 	"prime-send-receive-go/internal/prime"
 	"prime-send-receive-go/internal/store"
@@ -41,6 +43,30 @@ type SendReceiveListenerConfig struct {
 	LookbackWindow  time.Duration
 	PollingInterval time.Duration
 	CleanupInterval time.Duration
+
+	// DiscoveryInterval is how often wallets are rediscovered from Prime; 0 disables
+	DiscoveryInterval time.Duration
+	// OnWalletEvent, if set, receives every monitored wallet membership change
+	OnWalletEvent func(models.WalletEvent)
+
+	// PollConcurrency caps concurrent wallet polls; 0 uses DefaultPollConcurrency
+	PollConcurrency int
+	// MaxPollInterval is the longest an idle wallet goes between polls;
+	// active wallets are polled every PollingInterval. It must be shorter
+	// than LookbackWindow and is clamped to half of it otherwise
+	MaxPollInterval time.Duration
+
+	// Elector, if set, limits polling and ledger writes to the instance holding
+	// the lease; the others stand by
+	Elector *leader.Elector
+
+	// RetryPolicy queues failed transactions for retry with backoff and
+	// dead-letters them once exhausted; a zero BaseDelay disables the queue
+	RetryPolicy models.RetryPolicy
+	// DeadLetterAlertThreshold is the dead-letter queue depth from which every
+	// new dead letter is passed to OnDeadLetter
+	DeadLetterAlertThreshold int
+	OnDeadLetter             func(models.DeadLetterAlert)
 }
 
 // SendReceiveListener polls Prime API for new deposits and processes them
@@ -57,8 +83,24 @@ type SendReceiveListener struct {
 	cleanupInterval time.Duration
 
 	// Monitoring configuration
-	portfolioId      string
-	monitoredWallets []models.WalletInfo
+	portfolioId       string
+	assetsFile        string
+	monitoredWallets  []models.WalletInfo
+	walletsMutex      sync.RWMutex
+	discoveryInterval time.Duration
+	discoveryMutex    sync.Mutex
+	onWalletEvent     func(models.WalletEvent)
+	scheduler         *walletScheduler
+	elector           *leader.Elector
+	pollers           sync.WaitGroup
+
+	// Retry queue for failed transactions
+	retryPolicy    models.RetryPolicy
+	alertThreshold int
+	onDeadLetter   func(models.DeadLetterAlert)
+	retrying       map[string]bool // ids with a retry queue entry
+	retryMutex     sync.Mutex
+	retryStats     retryStats
 
 	// Control channels
 	stopChan chan struct{}
@@ -67,17 +109,36 @@ type SendReceiveListener struct {
 
 // NewSendReceiveListener creates a new deposit listener
 func NewSendReceiveListener(cfg SendReceiveListenerConfig) *SendReceiveListener {
+	// Each poll looks back LookbackWindow, so a wallet left idle for longer would skip
+	// transactions; config.Load rejects this, callers building the config directly are clamped
+	maxPollInterval := cfg.MaxPollInterval
+	if cfg.LookbackWindow > 0 && maxPollInterval >= cfg.LookbackWindow {
+		maxPollInterval = cfg.LookbackWindow / 2
+		zap.L().Warn("Max poll interval is not shorter than the lookback window, clamping",
+			zap.Duration("max_poll_interval", cfg.MaxPollInterval),
+			zap.Duration("lookback_window", cfg.LookbackWindow),
+			zap.Duration("clamped_to", maxPollInterval))
+	}
+
 	return &SendReceiveListener{
-		primeService:    cfg.PrimeService,
-		apiService:      cfg.ApiService,
-		dbService:       cfg.DbService,
-		processedTxIds:  make(map[string]time.Time),
-		lookbackWindow:  cfg.LookbackWindow,
-		pollingInterval: cfg.PollingInterval,
-		cleanupInterval: cfg.CleanupInterval,
-		portfolioId:     cfg.PortfolioId,
-		stopChan:        make(chan struct{}),
-		doneChan:        make(chan struct{}),
+		primeService:      cfg.PrimeService,
+		apiService:        cfg.ApiService,
+		dbService:         cfg.DbService,
+		processedTxIds:    make(map[string]time.Time),
+		lookbackWindow:    cfg.LookbackWindow,
+		pollingInterval:   cfg.PollingInterval,
+		cleanupInterval:   cfg.CleanupInterval,
+		portfolioId:       cfg.PortfolioId,
+		discoveryInterval: cfg.DiscoveryInterval,
+		onWalletEvent:     cfg.OnWalletEvent,
+		scheduler:         newWalletScheduler(cfg.PollConcurrency, cfg.PollingInterval, maxPollInterval),
+		elector:           cfg.Elector,
+		retryPolicy:       cfg.RetryPolicy,
+		alertThreshold:    cfg.DeadLetterAlertThreshold,
+		onDeadLetter:      cfg.OnDeadLetter,
+		retrying:          make(map[string]bool),
+		stopChan:          make(chan struct{}),
+		doneChan:          make(chan struct{}),
 	}
 }
 
@@ -135,39 +196,68 @@ func collectWalletsFromAllUsers(ctx context.Context, dbService store.LedgerStore
 // If assetsFile is empty, discovers ALL wallets from the Prime portfolio.
 // If assetsFile is provided, only monitors wallets for the assets listed in that file.
 func (d *SendReceiveListener) LoadMonitoredWallets(ctx context.Context, assetsFile string) error {
+	wallets, err := d.discoverWallets(ctx, assetsFile, true)
+	if err != nil {
+		return err
+	}
+	d.setMonitoredWallets(wallets)
+
+	zap.L().Info("Monitoring wallets",
+		zap.String("portfolio_id", d.portfolioId),
+		zap.Bool("filtered", assetsFile != ""),
+		zap.Int("count", len(wallets)))
+	for _, w := range wallets {
+		zap.L().Info("  Wallet",
+			zap.String("id", w.Id),
+			zap.String("asset", w.AssetSymbol))
+	}
+	return nil
+}
+
+// discoverWallets returns the wallets to monitor. Without fallback, a failed or
+// empty Prime lookup is an error rather than a switch to the local store, so that
+// a transient Prime outage never shrinks the monitored set.
+func (d *SendReceiveListener) discoverWallets(ctx context.Context, assetsFile string, fallback bool) ([]models.WalletInfo, error) {
 	if assetsFile != "" {
-		return d.loadFilteredWallets(ctx, assetsFile)
+		return d.loadFilteredWallets(ctx, assetsFile, fallback)
 	}
-	return d.loadAllWallets(ctx, assetsFile)
+	return d.loadAllWallets(ctx, assetsFile, fallback)
+}
+
+// uniqueWallets converts Prime wallets to WalletInfo, dropping duplicates
+func uniqueWallets(wallets []models.Wallet) []models.WalletInfo {
+	result := make([]models.WalletInfo, 0, len(wallets))
+	seen := make(map[string]bool)
+	for _, w := range wallets {
+		if !seen[w.Id] {
+			seen[w.Id] = true
+			result = append(result, models.WalletInfo{
+				Id:          w.Id,
+				AssetSymbol: w.Symbol,
+			})
+		}
+	}
+	return result
 }
 
 // loadAllWallets discovers ALL trading wallets from Prime.
-func (d *SendReceiveListener) loadAllWallets(ctx context.Context, assetsFileFallback string) error {
-	zap.L().Info("Discovering ALL wallets from Prime portfolio",
+func (d *SendReceiveListener) loadAllWallets(ctx context.Context, assetsFileFallback string, fallback bool) ([]models.WalletInfo, error) {
+	zap.L().Debug("Discovering ALL wallets from Prime portfolio",
 		zap.String("portfolio_id", d.portfolioId))
 
 	allWallets, err := d.primeService.ListWallets(ctx, d.portfolioId, "TRADING", nil)
 	if err == nil && len(allWallets) > 0 {
-		d.monitoredWallets = make([]models.WalletInfo, 0, len(allWallets))
-		seen := make(map[string]bool)
-		for _, w := range allWallets {
-			if !seen[w.Id] {
-				seen[w.Id] = true
-				d.monitoredWallets = append(d.monitoredWallets, models.WalletInfo{
-					Id:          w.Id,
-					AssetSymbol: w.Symbol,
-				})
-			}
-		}
+		wallets := uniqueWallets(allWallets)
+		zap.L().Debug("Discovered ALL Prime wallets",
+			zap.Int("count", len(wallets)))
+		return wallets, nil
+	}
 
-		zap.L().Info("Monitoring ALL Prime wallets",
-			zap.Int("count", len(d.monitoredWallets)))
-		for _, w := range d.monitoredWallets {
-			zap.L().Info("  Wallet",
-				zap.String("id", w.Id),
-				zap.String("asset", w.AssetSymbol))
+	if !fallback {
+		if err == nil {
+			err = fmt.Errorf("no wallets returned")
 		}
-		return nil
+		return nil, fmt.Errorf("failed to discover wallets from Prime: %w", err)
 	}
 
 	// Fallback to assets.yaml + local store if Prime call failed.
@@ -175,21 +265,21 @@ func (d *SendReceiveListener) loadAllWallets(ctx context.Context, assetsFileFall
 		zap.L().Warn("Could not discover wallets from Prime, falling back to local store",
 			zap.Error(err))
 	}
-	return d.loadFilteredWallets(ctx, assetsFileFallback)
+	return d.loadFilteredWallets(ctx, assetsFileFallback, fallback)
 }
 
 // loadFilteredWallets monitors only wallets matching assets in the given file.
-func (d *SendReceiveListener) loadFilteredWallets(ctx context.Context, assetsFile string) error {
+func (d *SendReceiveListener) loadFilteredWallets(ctx context.Context, assetsFile string, fallback bool) ([]models.WalletInfo, error) {
 	if assetsFile == "" {
 		assetsFile = "assets.yaml"
 	}
 
-	zap.L().Info("Loading filtered wallets from assets file",
+	zap.L().Debug("Loading filtered wallets from assets file",
 		zap.String("file", assetsFile))
 
 	assetConfigs, err := common.LoadAssetConfig(assetsFile)
 	if err != nil {
-		return fmt.Errorf("failed to load assets from %s: %w", assetsFile, err)
+		return nil, fmt.Errorf("failed to load assets from %s: %w", assetsFile, err)
 	}
 
 	// Try Prime first with specific symbols.
@@ -202,41 +292,38 @@ func (d *SendReceiveListener) loadFilteredWallets(ctx context.Context, assetsFil
 		}
 	}
 
-	wallets, err := d.primeService.ListWallets(ctx, d.portfolioId, "TRADING", symbols)
-	if err == nil && len(wallets) > 0 {
-		d.monitoredWallets = make([]models.WalletInfo, 0, len(wallets))
-		walletSeen := make(map[string]bool)
-		for _, w := range wallets {
-			if !walletSeen[w.Id] {
-				walletSeen[w.Id] = true
-				d.monitoredWallets = append(d.monitoredWallets, models.WalletInfo{
-					Id:          w.Id,
-					AssetSymbol: w.Symbol,
-				})
-			}
-		}
-		zap.L().Info("Monitoring filtered Prime wallets",
-			zap.Int("count", len(d.monitoredWallets)),
+	primeWallets, err := d.primeService.ListWallets(ctx, d.portfolioId, "TRADING", symbols)
+	if err == nil && len(primeWallets) > 0 {
+		wallets := uniqueWallets(primeWallets)
+		zap.L().Debug("Discovered filtered Prime wallets",
+			zap.Int("count", len(wallets)),
 			zap.Strings("symbols", symbols))
-		return nil
+		return wallets, nil
+	}
+
+	if !fallback {
+		if err == nil {
+			err = fmt.Errorf("no wallets returned for %v", symbols)
+		}
+		return nil, fmt.Errorf("failed to discover wallets from Prime: %w", err)
 	}
 
 	// Fallback: local store.
 	assetSymbols := getUniqueAssetSymbols(assetConfigs)
 	users, userErr := d.dbService.GetUsers(ctx)
 	if userErr != nil {
-		return fmt.Errorf("failed to get users: %w", userErr)
+		return nil, fmt.Errorf("failed to get users: %w", userErr)
 	}
 
 	walletMap := collectWalletsFromAllUsers(ctx, d.dbService, users, assetSymbols)
-	d.monitoredWallets = make([]models.WalletInfo, 0, len(walletMap))
+	wallets := make([]models.WalletInfo, 0, len(walletMap))
 	for _, wallet := range walletMap {
-		d.monitoredWallets = append(d.monitoredWallets, wallet)
+		wallets = append(wallets, wallet)
 	}
 
 	zap.L().Info("Loaded monitored wallets from local store (fallback)",
-		zap.Int("count", len(d.monitoredWallets)))
-	return nil
+		zap.Int("count", len(wallets)))
+	return wallets, nil
 }
 
 // fetchWalletTransactions calls Prime API to get wallet transactions
@@ -290,6 +377,10 @@ func (d *SendReceiveListener) fetchWalletTransactions(ctx context.Context, walle
 			primeTransaction.TransferTo.Value = tx.TransferTo.Value
 			primeTransaction.TransferTo.Address = tx.TransferTo.Address
 			primeTransaction.TransferTo.AccountIdentifier = tx.TransferTo.AccountIdentifier
+			// On tag-based networks the account identifier is the destination tag
+			if assets.Current().TagRequired(tx.Network) {
+				primeTransaction.TransferTo.Memo = tx.TransferTo.AccountIdentifier
+			}
 		}
 
 		transactions = append(transactions, primeTransaction)
@@ -311,6 +402,17 @@ func (d *SendReceiveListener) isTransactionProcessed(txId string) bool {
 	return exists
 }
 
+// isStatusProcessed checks if we've already processed this transaction, or
+// already handled it at its current status
+func (d *SendReceiveListener) isStatusProcessed(tx models.PrimeTransaction) bool {
+	return d.isTransactionProcessed(tx.Id) || d.isTransactionProcessed(statusKey(tx))
+}
+
+// statusKey identifies a transaction at one status in the processed cache
+func statusKey(tx models.PrimeTransaction) string {
+	return tx.Id + "@" + tx.Status
+}
+
 // markTransactionProcessed marks a transaction as processed
 func (d *SendReceiveListener) markTransactionProcessed(txId string) {
 	d.mutex.Lock()
@@ -319,7 +421,7 @@ func (d *SendReceiveListener) markTransactionProcessed(txId string) {
 	d.processedTxIds[txId] = time.Now()
 }
 
-// cleanupLoop periodically cleans old processed transaction IDs
+// cleanupLoop periodically cleans old processed transaction IDs and expires holds
 func (d *SendReceiveListener) cleanupLoop(ctx context.Context) {
 	ticker := time.NewTicker(d.cleanupInterval)
 	defer ticker.Stop()
@@ -328,6 +430,9 @@ func (d *SendReceiveListener) cleanupLoop(ctx context.Context) {
 		select {
 		case <-ticker.C:
 			d.cleanupProcessedTransactions()
+			if d.active() {
+				d.expireHolds(ctx)
+			}
 		case <-d.stopChan:
 			return
 		case <-ctx.Done():
@@ -336,6 +441,22 @@ func (d *SendReceiveListener) cleanupLoop(ctx context.Context) {
 	}
 }
 
+// expireHolds closes holds past their expiry so their funds become available again
+func (d *SendReceiveListener) expireHolds(ctx context.Context) {
+	ctx, err := d.leaderContext(ctx)
+	if err != nil {
+		return
+	}
+	expired, err := d.dbService.ExpireHolds(ctx, time.Now().UTC())
+	d.checkLeaseLost(err)
+	if err != nil {
+		zap.L().Error("Failed to expire holds", zap.Error(err))
+	}
+	if len(expired) > 0 {
+		zap.L().Info("Expired holds", zap.Int("count", len(expired)))
+	}
+}
+
 // cleanupProcessedTransactions removes old entries from processed transactions map
 func (d *SendReceiveListener) cleanupProcessedTransactions() {
 	d.mutex.Lock()
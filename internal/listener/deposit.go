@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/shopspring/decimal"
+	"prime-send-receive-go/internal/lifecycle"
 	"prime-send-receive-go/internal/models"
 	"prime-send-receive-go/internal/store"
 
@@ -34,93 +35,83 @@ import (
 // Phase 1: TRANSACTION_IMPORT_PENDING -- park funds in pending deposits account
 // Phase 2: TRANSACTION_IMPORTED -- move from pending to user account
 func (d *SendReceiveListener) processDeposit(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) error {
-	if tx.Status == "TRANSACTION_IMPORT_PENDING" {
-		return d.processDepositPending(ctx, tx, wallet)
-	}
-
-	if tx.Status == "TRANSACTION_IMPORTED" {
-		return d.processDepositConfirmed(ctx, tx, wallet)
-	}
-
-	zap.L().Debug("Skipping deposit with unhandled status",
-		zap.String("transaction_id", tx.Id),
-		zap.String("status", tx.Status),
-		zap.String("symbol", tx.Symbol),
-		zap.String("amount", tx.Amount))
-	return nil
+	return d.advanceLifecycle(ctx, models.TxKindDeposit, tx, func(ctx context.Context, from, to string) (string, error) {
+		if to == models.TxStatePending {
+			return d.processDepositPending(ctx, tx, wallet)
+		}
+		return d.processDepositConfirmed(ctx, tx, wallet, from == models.TxStatePending)
+	})
 }
 
 // processDepositPending handles phase 1: park funds in pending.
-func (d *SendReceiveListener) processDepositPending(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) error {
+func (d *SendReceiveListener) processDepositPending(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) (string, error) {
 	amount, err := decimal.NewFromString(tx.Amount)
 	if err != nil {
-		return fmt.Errorf("invalid amount: %w", err)
+		return "", fmt.Errorf("invalid amount: %w", err)
 	}
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return nil
+		return lifecycle.ActionNone, nil
 	}
 
 	canonicalSymbol := normalizeSymbol(tx.Symbol)
 
-	var lookupAddress string
-	if tx.TransferTo.AccountIdentifier != "" {
-		lookupAddress = tx.TransferTo.AccountIdentifier
-	} else if tx.TransferTo.Address != "" {
-		lookupAddress = tx.TransferTo.Address
+	lookupAddress, tag := depositDestination(tx)
+	if tag != "" {
+		ctx = models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{DestinationTag: tag})
 	}
 
 	err = d.dbService.ProcessDepositPending(ctx, canonicalSymbol, wallet.Id, amount, tx.Id, lookupAddress)
 	if err != nil {
 		if errors.Is(err, store.ErrDuplicateTransaction) {
-			d.markTransactionProcessed(tx.Id)
-			return nil
+			return lifecycle.ActionAlreadyBooked, nil
+		}
+		if errors.Is(err, store.ErrNotSupported) {
+			// Nothing was booked, so leave the state alone and credit the
+			// deposit directly once Prime confirms it
+			zap.L().Debug("Backend has no pending deposits, waiting for confirmation",
+				zap.String("transaction_id", tx.Id))
+			return lifecycle.ActionNone, nil
 		}
-		return fmt.Errorf("failed to record pending deposit: %w", err)
+		return "", fmt.Errorf("failed to record pending deposit: %w", err)
 	}
 
-	d.markTransactionProcessed(tx.Id)
 	zap.L().Info("Pending deposit recorded",
 		zap.String("transaction_id", tx.Id),
 		zap.String("symbol", canonicalSymbol),
 		zap.String("amount", amount.String()))
-	return nil
+	return lifecycle.ActionRecordPending, nil
 }
 
-// processDepositConfirmed handles phase 2: move from pending to user (or direct deposit).
-func (d *SendReceiveListener) processDepositConfirmed(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) error {
+// processDepositConfirmed handles phase 2: move from pending to user (or direct
+// deposit). fromPending is set when the lifecycle shows phase 1 was booked.
+func (d *SendReceiveListener) processDepositConfirmed(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo, fromPending bool) (string, error) {
 
 	amount, err := decimal.NewFromString(tx.Amount)
 	if err != nil {
-		return fmt.Errorf("invalid amount: %w", err)
+		return "", fmt.Errorf("invalid amount: %w", err)
 	}
 
 	if amount.LessThanOrEqual(decimal.Zero) {
 		zap.L().Debug("Skipping zero/negative amount transaction",
 			zap.String("transaction_id", tx.Id),
 			zap.String("amount", amount.String()))
-		return nil
+		return lifecycle.ActionNone, nil
 	}
 
-	var lookupAddress string
-	if tx.TransferTo.AccountIdentifier != "" {
-		lookupAddress = tx.TransferTo.AccountIdentifier
-		zap.L().Debug("Using account_identifier for address lookup",
-			zap.String("transaction_id", tx.Id),
-			zap.String("account_identifier", tx.TransferTo.AccountIdentifier),
-			zap.String("address", tx.TransferTo.Address))
-	} else {
-		lookupAddress = tx.TransferTo.Address
-		zap.L().Debug("Using address for lookup",
-			zap.String("transaction_id", tx.Id),
-			zap.String("address", tx.TransferTo.Address))
-	}
+	lookupAddress, tag := depositDestination(tx)
+	zap.L().Debug("Resolved deposit destination for lookup",
+		zap.String("transaction_id", tx.Id),
+		zap.String("lookup_address", lookupAddress),
+		zap.String("tag", tag),
+		zap.String("account_identifier", tx.TransferTo.AccountIdentifier),
+		zap.String("address", tx.TransferTo.Address))
 
 	if lookupAddress == "" {
 		zap.L().Debug("No address or account_identifier found in transfer_to",
 			zap.String("transaction_id", tx.Id),
 			zap.String("transfer_to_type", tx.TransferTo.Type),
 			zap.String("transfer_to_value", tx.TransferTo.Value))
-		return nil
+		return lifecycle.ActionNone, nil
 	}
 
 	assetNetwork := fmt.Sprintf("%s-%s", tx.Symbol, tx.Network)
@@ -131,6 +122,7 @@ func (d *SendReceiveListener) processDepositConfirmed(ctx context.Context, tx mo
 		zap.String("lookup_address", lookupAddress),
 		zap.String("deposit_address", tx.TransferTo.Address),
 		zap.String("account_identifier", tx.TransferTo.AccountIdentifier),
+		zap.String("tag", tag),
 		zap.String("prime_api_symbol", tx.Symbol),
 		zap.String("prime_api_network", tx.Network),
 		zap.String("asset_network", assetNetwork),
@@ -165,6 +157,7 @@ func (d *SendReceiveListener) processDepositConfirmed(ctx context.Context, tx mo
 		Network:         tx.Network,
 		PrimeApiSymbol:  tx.Symbol,
 		WalletId:        tx.WalletId,
+		DestinationTag:  tag,
 		CreatedAt:       tx.CreatedAt.UTC().Format(time.RFC3339),
 		CompletedAt:     tx.CompletedAt.UTC().Format(time.RFC3339),
 		TransactionTime: txTime,
@@ -173,30 +166,39 @@ func (d *SendReceiveListener) processDepositConfirmed(ctx context.Context, tx mo
 	// Try two-phase: confirm from pending -> user (if pending phase was recorded).
 	confirmErr := d.dbService.ConfirmDeposit(depositCtx, lookupAddress, tx.Symbol, amount, tx.Id)
 	if confirmErr == nil {
-		d.markTransactionProcessed(tx.Id)
 		zap.L().Info("Deposit confirmed (pending -> user)",
 			zap.String("transaction_id", tx.Id))
-		return nil
+		return lifecycle.ActionConfirmPending, nil
 	}
-	// Fall back to single-phase direct deposit (no pending phase existed).
+	if fromPending && !errors.Is(confirmErr, store.ErrNotSupported) {
+		if errors.Is(confirmErr, store.ErrDuplicateTransaction) {
+			zap.L().Info("Pending deposit already confirmed - marking as handled",
+				zap.String("transaction_id", tx.Id))
+			return lifecycle.ActionAlreadyBooked, nil
+		}
+		// Phase 1 was booked, so a direct deposit would credit the funds twice
+		return "", fmt.Errorf("failed to confirm pending deposit: %w", confirmErr)
+	}
+	// Fall back to single-phase direct deposit. Pending deposits booked before
+	// the lifecycle was tracked still confirm above; backends without a
+	// pending phase always land here.
 	result, err := d.apiService.ProcessDeposit(depositCtx, lookupAddress, tx.Symbol, amount, tx.Id)
 	if err != nil {
 		if errors.Is(err, store.ErrDuplicateTransaction) {
 			zap.L().Info("Duplicate transaction detected - already processed, marking as handled",
 				zap.String("transaction_id", tx.Id))
-			d.markTransactionProcessed(tx.Id)
-			return nil
+			return lifecycle.ActionAlreadyBooked, nil
 		}
 		if errors.Is(err, store.ErrUserNotFound) {
 			zap.L().Warn("Deposit to unrecognized address - marking as processed to avoid repeated errors",
 				zap.String("transaction_id", tx.Id),
 				zap.String("address", lookupAddress),
+				zap.String("tag", tag),
 				zap.String("asset_network", assetNetwork),
 				zap.String("amount", amount.String()))
-			d.markTransactionProcessed(tx.Id)
-			return nil
+			return lifecycle.ActionNone, nil
 		}
-		return fmt.Errorf("failed to process deposit: %w", err)
+		return "", fmt.Errorf("failed to process deposit: %w", err)
 	}
 
 	if !result.Success {
@@ -204,24 +206,29 @@ func (d *SendReceiveListener) processDepositConfirmed(ctx context.Context, tx mo
 		if result.Error == store.ErrDuplicateTransaction.Error() {
 			zap.L().Info("Duplicate transaction detected - already processed, marking as handled",
 				zap.String("transaction_id", tx.Id))
-			d.markTransactionProcessed(tx.Id)
-			return nil
+			return lifecycle.ActionAlreadyBooked, nil
 		}
 		// Check if this is an unrecognized address
 		if result.Error == store.ErrUserNotFound.Error() {
 			zap.L().Warn("Deposit to unrecognized address - marking as processed to avoid repeated errors",
 				zap.String("transaction_id", tx.Id),
 				zap.String("error", result.Error))
-			d.markTransactionProcessed(tx.Id)
-			return nil
+			return lifecycle.ActionNone, nil
 		}
 		zap.L().Warn("Deposit processing failed",
 			zap.String("transaction_id", tx.Id),
 			zap.String("error", result.Error))
-		return fmt.Errorf("deposit processing failed: %s", result.Error)
+		return "", fmt.Errorf("deposit processing failed: %s", result.Error)
 	}
 
-	d.markTransactionProcessed(tx.Id)
+	if result.Quarantined {
+		zap.L().Warn("Deposit quarantined - user is frozen",
+			zap.String("transaction_id", tx.Id),
+			zap.String("user_id", result.UserId),
+			zap.String("asset", result.Asset),
+			zap.String("amount", result.Amount.String()))
+		return lifecycle.ActionCreditFrozen, nil
+	}
 
 	zap.L().Info("Deposit processed successfully - balance updated",
 		zap.String("transaction_id", tx.Id),
@@ -231,5 +238,18 @@ func (d *SendReceiveListener) processDepositConfirmed(ctx context.Context, tx mo
 		zap.String("new_balance", result.NewBalance.String()),
 		zap.Time("processed_at", time.Now()))
 
-	return nil
+	return lifecycle.ActionCreditDirect, nil
+}
+
+// depositDestination returns the address and destination tag a deposit was sent
+// to. Tag-based networks share one address between users, so the pair identifies
+// the user; elsewhere the account identifier, when Prime sets one, is used alone.
+func depositDestination(tx models.PrimeTransaction) (address, tag string) {
+	if tx.TransferTo.Memo != "" {
+		return tx.TransferTo.Address, tx.TransferTo.Memo
+	}
+	if tx.TransferTo.AccountIdentifier != "" {
+		return tx.TransferTo.AccountIdentifier, ""
+	}
+	return tx.TransferTo.Address, ""
 }
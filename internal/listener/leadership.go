@@ -0,0 +1,74 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// errNotLeader is returned for writes attempted while another instance leads
+var errNotLeader = errors.New("listener is on standby")
+
+// active reports whether this listener should poll and write. Without an
+// elector it always is.
+func (d *SendReceiveListener) active() bool {
+	return d.elector == nil || d.elector.IsLeader()
+}
+
+// leaderContext attaches the lease's fencing token to ctx so the ledger refuses
+// the write if another instance has taken over since.
+func (d *SendReceiveListener) leaderContext(ctx context.Context) (context.Context, error) {
+	if d.elector == nil {
+		return ctx, nil
+	}
+	fence, ok := d.elector.Fence()
+	if !ok {
+		return ctx, fmt.Errorf("%w for lease %s", errNotLeader, d.elector.Name())
+	}
+	return models.WithLeaseFence(ctx, fence), nil
+}
+
+// checkLeaseLost stands the listener down as soon as a write is fenced off
+func (d *SendReceiveListener) checkLeaseLost(err error) {
+	if d.elector != nil && errors.Is(err, store.ErrLeaseLost) {
+		d.elector.Lost()
+	}
+}
+
+// onLeadershipChange runs the recovery scan when this instance takes over, so
+// transactions the previous leader had not processed are picked up before
+// regular polling resumes.
+func (d *SendReceiveListener) onLeadershipChange(ctx context.Context, isLeader bool) {
+	if !isLeader {
+		fmt.Printf("\n%s[standby] Portfolio %s is led by another instance%s\n", colorYellow, d.portfolioId, colorReset)
+		return
+	}
+
+	fmt.Printf("\n%s[leader] Took over portfolio %s; running recovery%s\n", colorGreen, d.portfolioId, colorReset)
+	if err := d.performStartupRecovery(ctx); err != nil {
+		zap.L().Error("Recovery after taking over leadership failed",
+			zap.String("portfolio_id", d.portfolioId),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,159 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"prime-send-receive-go/internal/lifecycle"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// lifecycleStep books the ledger side of moving a transaction from one state to
+// the next and reports the lifecycle action it took
+type lifecycleStep func(ctx context.Context, from, to string) (string, error)
+
+// advanceLifecycle moves a deposit or withdrawal to the state its Prime status
+// implies. The step runs only for a transition the lifecycle allows; an illegal
+// one is quarantined and books nothing. The transition is recorded after the
+// step, so a crash in between re-runs the step, which the ledger's duplicate
+// detection turns into already_booked.
+func (d *SendReceiveListener) advanceLifecycle(ctx context.Context, kind string, tx models.PrimeTransaction, step lifecycleStep) error {
+	to := lifecycle.Target(kind, tx.Status)
+	if to == "" {
+		zap.L().Debug("Skipping transaction with unhandled status",
+			zap.String("transaction_id", tx.Id),
+			zap.String("kind", kind),
+			zap.String("status", tx.Status),
+			zap.String("symbol", tx.Symbol),
+			zap.String("amount", tx.Amount))
+		return nil
+	}
+
+	current, err := d.dbService.GetTxState(ctx, tx.Id)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction state: %w", err)
+	}
+	from := models.TxStateNew
+	if current != nil {
+		from = current.State
+	}
+
+	if from == to {
+		d.markLifecycleProcessed(kind, tx, to)
+		return nil
+	}
+	if err := lifecycle.Check(kind, from, to); err != nil {
+		if qErr := d.quarantineTransaction(ctx, kind, tx, from, to, err); qErr != nil {
+			return qErr
+		}
+		d.markTransactionProcessed(tx.Id)
+		return nil
+	}
+
+	action, err := step(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	d.markLifecycleProcessed(kind, tx, to)
+	if action == lifecycle.ActionNone {
+		return nil
+	}
+
+	_, err = d.dbService.RecordTxTransition(ctx, store.TxTransitionParams{
+		PrimeTxId:   tx.Id,
+		Kind:        kind,
+		FromState:   from,
+		ToState:     to,
+		PrimeStatus: tx.Status,
+		Action:      action,
+		Detail:      tx.Amount + " " + tx.Symbol,
+	})
+	if errors.Is(err, store.ErrTxStateConflict) {
+		zap.L().Warn("Transaction state changed while booking it",
+			zap.String("transaction_id", tx.Id),
+			zap.String("from", from),
+			zap.String("to", to),
+			zap.Error(err))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record transaction state: %w", err)
+	}
+
+	zap.L().Info("Transaction state advanced",
+		zap.String("transaction_id", tx.Id),
+		zap.String("kind", kind),
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.String("action", action))
+	return nil
+}
+
+// quarantineTransaction records an illegal transition once per state and
+// status, so a transaction left in Prime's lookback window is not re-recorded
+// every restart.
+func (d *SendReceiveListener) quarantineTransaction(ctx context.Context, kind string, tx models.PrimeTransaction, from, to string, cause error) error {
+	history, err := d.dbService.GetTxHistory(ctx, tx.Id)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction history: %w", err)
+	}
+	if n := len(history); n > 0 {
+		last := history[n-1]
+		if last.Outcome == models.TxOutcomeQuarantined && last.FromState == from && last.PrimeStatus == tx.Status {
+			return nil
+		}
+	}
+
+	zap.L().Error("Illegal transaction state transition - quarantined, nothing booked",
+		zap.String("transaction_id", tx.Id),
+		zap.String("kind", kind),
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.String("status", tx.Status),
+		zap.String("amount", tx.Amount),
+		zap.String("symbol", tx.Symbol))
+	err = d.dbService.QuarantineTx(ctx, store.TxTransitionParams{
+		PrimeTxId:   tx.Id,
+		Kind:        kind,
+		FromState:   from,
+		ToState:     to,
+		PrimeStatus: tx.Status,
+		Action:      lifecycle.ActionNone,
+		Detail:      cause.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to quarantine transaction: %w", err)
+	}
+	return nil
+}
+
+// markLifecycleProcessed stops further polling of a transaction once it is in a
+// terminal state; before that only its current status is skipped, so the next
+// status Prime reports is still processed.
+func (d *SendReceiveListener) markLifecycleProcessed(kind string, tx models.PrimeTransaction, state string) {
+	if lifecycle.Terminal(kind, state) {
+		d.markTransactionProcessed(tx.Id)
+		return
+	}
+	d.markTransactionProcessed(statusKey(tx))
+}
@@ -0,0 +1,256 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"prime-send-receive-go/internal/api"
+	"prime-send-receive-go/internal/lifecycle"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+	"prime-send-receive-go/internal/testutil"
+
+	"github.com/shopspring/decimal"
+)
+
+// txStateStore keeps transaction lifecycles in memory
+type txStateStore struct {
+	store.LedgerStore
+	states  map[string]*models.TxState
+	history map[string][]models.TxTransition
+}
+
+func newTxStateStore() *txStateStore {
+	return &txStateStore{states: map[string]*models.TxState{}, history: map[string][]models.TxTransition{}}
+}
+
+func (s *txStateStore) GetTxState(_ context.Context, id string) (*models.TxState, error) {
+	return s.states[id], nil
+}
+
+func (s *txStateStore) RecordTxTransition(_ context.Context, p store.TxTransitionParams) (*models.TxState, error) {
+	current := models.TxStateNew
+	if state := s.states[p.PrimeTxId]; state != nil {
+		current = state.State
+	}
+	if current != p.FromState {
+		return nil, fmt.Errorf("%w: %s", store.ErrTxStateConflict, p.PrimeTxId)
+	}
+	s.states[p.PrimeTxId] = &models.TxState{PrimeTxId: p.PrimeTxId, Kind: p.Kind, State: p.ToState, PrimeStatus: p.PrimeStatus}
+	s.append(p, models.TxOutcomeApplied)
+	return s.states[p.PrimeTxId], nil
+}
+
+func (s *txStateStore) QuarantineTx(_ context.Context, p store.TxTransitionParams) error {
+	if state := s.states[p.PrimeTxId]; state != nil {
+		state.Quarantined = true
+	}
+	s.append(p, models.TxOutcomeQuarantined)
+	return nil
+}
+
+func (s *txStateStore) GetTxHistory(_ context.Context, id string) ([]models.TxTransition, error) {
+	return s.history[id], nil
+}
+
+func (s *txStateStore) append(p store.TxTransitionParams, outcome string) {
+	s.history[p.PrimeTxId] = append(s.history[p.PrimeTxId], models.TxTransition{
+		PrimeTxId: p.PrimeTxId, FromState: p.FromState, ToState: p.ToState,
+		PrimeStatus: p.PrimeStatus, Action: p.Action, Outcome: outcome,
+	})
+}
+
+// recordingStep records the transitions it is asked to book
+type recordingStep struct {
+	calls  []string
+	action string
+	err    error
+}
+
+func (r *recordingStep) step(_ context.Context, from, to string) (string, error) {
+	r.calls = append(r.calls, from+"->"+to)
+	return r.action, r.err
+}
+
+func TestAdvanceLifecycle_Deposit(t *testing.T) {
+	ctx := context.Background()
+	db := newTxStateStore()
+	d := NewSendReceiveListener(SendReceiveListenerConfig{DbService: db, PortfolioId: "portfolio"})
+
+	pending := models.PrimeTransaction{Id: "tx1", Type: "DEPOSIT", Status: "TRANSACTION_IMPORT_PENDING", Amount: "5", Symbol: "USDC"}
+	imported := pending
+	imported.Status = "TRANSACTION_IMPORTED"
+
+	steps := &recordingStep{action: lifecycle.ActionRecordPending}
+	if err := d.advanceLifecycle(ctx, models.TxKindDeposit, pending, steps.step); err != nil {
+		t.Fatalf("advanceLifecycle failed: %v", err)
+	}
+	if state := db.states["tx1"]; state == nil || state.State != models.TxStatePending {
+		t.Fatalf("Expected tx1 pending, got %+v", state)
+	}
+	if !d.isStatusProcessed(pending) || d.isStatusProcessed(imported) {
+		t.Error("Expected only the pending status to be marked processed")
+	}
+
+	// Seeing the same status again books nothing
+	if err := d.advanceLifecycle(ctx, models.TxKindDeposit, pending, steps.step); err != nil {
+		t.Fatalf("advanceLifecycle failed: %v", err)
+	}
+
+	steps.action = lifecycle.ActionConfirmPending
+	if err := d.advanceLifecycle(ctx, models.TxKindDeposit, imported, steps.step); err != nil {
+		t.Fatalf("advanceLifecycle failed: %v", err)
+	}
+	if got := fmt.Sprint(steps.calls); got != "[new->pending pending->credited]" {
+		t.Errorf("steps = %s", got)
+	}
+	if state := db.states["tx1"]; state.State != models.TxStateCredited || state.PrimeStatus != "TRANSACTION_IMPORTED" {
+		t.Errorf("Expected tx1 credited, got %+v", state)
+	}
+	if !d.isTransactionProcessed("tx1") {
+		t.Error("Expected a credited deposit to be marked processed")
+	}
+	history := db.history["tx1"]
+	if len(history) != 2 || history[1].Action != lifecycle.ActionConfirmPending || history[1].Outcome != models.TxOutcomeApplied {
+		t.Errorf("Unexpected history %+v", history)
+	}
+}
+
+func TestAdvanceLifecycle_QuarantinesRegression(t *testing.T) {
+	ctx := context.Background()
+	db := newTxStateStore()
+	db.states["tx1"] = &models.TxState{PrimeTxId: "tx1", Kind: models.TxKindWithdrawal, State: models.TxStateCompleted}
+	steps := &recordingStep{action: lifecycle.ActionRevert}
+
+	failed := models.PrimeTransaction{Id: "tx1", Type: "WITHDRAWAL", Status: "TRANSACTION_FAILED", Amount: "5", Symbol: "USDC"}
+	for i := 0; i < 2; i++ {
+		// A fresh listener, as after a restart with the transaction still in the lookback window
+		d := NewSendReceiveListener(SendReceiveListenerConfig{DbService: db, PortfolioId: "portfolio"})
+		if err := d.advanceLifecycle(ctx, models.TxKindWithdrawal, failed, steps.step); err != nil {
+			t.Fatalf("advanceLifecycle failed: %v", err)
+		}
+		if !d.isTransactionProcessed("tx1") {
+			t.Error("Expected a quarantined transaction to be marked processed")
+		}
+	}
+
+	if len(steps.calls) != 0 {
+		t.Errorf("Expected nothing booked for an illegal transition, got %v", steps.calls)
+	}
+	state := db.states["tx1"]
+	if state.State != models.TxStateCompleted || !state.Quarantined {
+		t.Errorf("Expected tx1 to stay completed and be quarantined, got %+v", state)
+	}
+	history := db.history["tx1"]
+	if len(history) != 1 || history[0].Outcome != models.TxOutcomeQuarantined || history[0].ToState != models.TxStateFailed {
+		t.Errorf("Expected one quarantined entry, got %+v", history)
+	}
+}
+
+func TestAdvanceLifecycle_SkipsAndFailures(t *testing.T) {
+	ctx := context.Background()
+	db := newTxStateStore()
+	d := NewSendReceiveListener(SendReceiveListenerConfig{DbService: db, PortfolioId: "portfolio"})
+
+	// A status still in flight is neither booked nor remembered
+	steps := &recordingStep{action: lifecycle.ActionDebitDirect}
+	created := models.PrimeTransaction{Id: "tx1", Type: "WITHDRAWAL", Status: "TRANSACTION_CREATED"}
+	if err := d.advanceLifecycle(ctx, models.TxKindWithdrawal, created, steps.step); err != nil {
+		t.Fatalf("advanceLifecycle failed: %v", err)
+	}
+	if len(steps.calls) != 0 || d.isStatusProcessed(created) {
+		t.Errorf("Expected an in-flight status to be skipped, got %v", steps.calls)
+	}
+
+	// A failed step leaves the state alone so the next poll retries it
+	done := models.PrimeTransaction{Id: "tx1", Type: "WITHDRAWAL", Status: "TRANSACTION_DONE"}
+	steps.err = errors.New("ledger unavailable")
+	if err := d.advanceLifecycle(ctx, models.TxKindWithdrawal, done, steps.step); err == nil {
+		t.Fatal("Expected the step's error")
+	}
+	if db.states["tx1"] != nil || d.isStatusProcessed(done) {
+		t.Error("Expected no state recorded after a failed step")
+	}
+
+	// A step that books nothing records no transition
+	steps.err, steps.action = nil, lifecycle.ActionNone
+	if err := d.advanceLifecycle(ctx, models.TxKindWithdrawal, done, steps.step); err != nil {
+		t.Fatalf("advanceLifecycle failed: %v", err)
+	}
+	if db.states["tx1"] != nil || !d.isTransactionProcessed("tx1") {
+		t.Error("Expected no state but a processed mark after a terminal status booked nothing")
+	}
+}
+
+// confirmedDepositStore is a two-phase backend whose confirmation was already booked
+type confirmedDepositStore struct {
+	*txStateStore
+}
+
+func (s confirmedDepositStore) ConfirmDeposit(_ context.Context, _, _ string, _ decimal.Decimal, transactionId string) error {
+	return fmt.Errorf("%w: %s-confirmed", store.ErrDuplicateTransaction, transactionId)
+}
+
+func TestProcessDeposit_RerunConfirmedPending(t *testing.T) {
+	ctx := context.Background()
+	db := confirmedDepositStore{newTxStateStore()}
+	db.states["tx1"] = &models.TxState{PrimeTxId: "tx1", Kind: models.TxKindDeposit, State: models.TxStatePending}
+	d := NewSendReceiveListener(SendReceiveListenerConfig{DbService: db, PortfolioId: "portfolio"})
+
+	// The confirmation posted but the state write was lost, so Prime's status is seen again
+	imported := models.PrimeTransaction{Id: "tx1", Type: "DEPOSIT", Status: "TRANSACTION_IMPORTED", Amount: "5", Symbol: "USDC",
+		TransferTo: models.PrimeTransferInfo{Address: "0xabc"}}
+	if err := d.processDeposit(ctx, imported, models.WalletInfo{Id: "w1"}); err != nil {
+		t.Fatalf("processDeposit failed: %v", err)
+	}
+	if state := db.states["tx1"]; state.State != models.TxStateCredited {
+		t.Errorf("Expected tx1 credited, got %+v", state)
+	}
+	history := db.history["tx1"]
+	if len(history) != 1 || history[0].Action != lifecycle.ActionAlreadyBooked {
+		t.Errorf("Expected one already_booked entry, got %+v", history)
+	}
+}
+
+func TestProcessDeposit_SQLiteBooksDirect(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewSQLiteStore(t)
+	if _, err := db.CreateUser(ctx, "user1", "Test User", "test@example.com"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := db.StoreAddress(ctx, store.StoreAddressParams{UserId: "user1", Asset: "USDC", Network: "base-mainnet", Address: "0xabc"}); err != nil {
+		t.Fatalf("StoreAddress failed: %v", err)
+	}
+	d := NewSendReceiveListener(SendReceiveListenerConfig{DbService: db, ApiService: api.NewLedgerService(db), PortfolioId: "portfolio"})
+
+	pending := models.PrimeTransaction{Id: "tx1", Type: "DEPOSIT", Status: "TRANSACTION_IMPORT_PENDING", Amount: "5", Symbol: "USDC", Network: "base-mainnet",
+		TransferTo: models.PrimeTransferInfo{Address: "0xabc"}}
+	if err := d.processDeposit(ctx, pending, models.WalletInfo{Id: "w1"}); err != nil {
+		t.Fatalf("processDeposit failed: %v", err)
+	}
+	if state, err := db.GetTxState(ctx, "tx1"); err != nil || state != nil {
+		t.Fatalf("Expected no pending state on SQLite, got %+v (%v)", state, err)
+	}
+
+	imported := pending
+	imported.Status = "TRANSACTION_IMPORTED"
+	if err := d.processDeposit(ctx, imported, models.WalletInfo{Id: "w1"}); err != nil {
+		t.Fatalf("processDeposit failed: %v", err)
+	}
+	history, err := db.GetTxHistory(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("GetTxHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].FromState != models.TxStateNew || history[0].Action != lifecycle.ActionCreditDirect {
+		t.Errorf("Expected one direct credit from new, got %+v", history)
+	}
+	balance, err := db.GetUserBalance(ctx, "user1", "USDC")
+	if err != nil {
+		t.Fatalf("GetUserBalance failed: %v", err)
+	}
+	if !balance.Equal(testutil.Dec("5")) {
+		t.Errorf("Expected balance 5, got %s", balance)
+	}
+}
@@ -0,0 +1,183 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package listener
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// wallets returns a snapshot of the monitored wallets that is safe to range
+// over while discovery changes the set.
+func (d *SendReceiveListener) wallets() []models.WalletInfo {
+	d.walletsMutex.RLock()
+	defer d.walletsMutex.RUnlock()
+
+	return append([]models.WalletInfo(nil), d.monitoredWallets...)
+}
+
+// setMonitoredWallets replaces the monitored wallet set
+func (d *SendReceiveListener) setMonitoredWallets(wallets []models.WalletInfo) {
+	d.walletsMutex.Lock()
+	defer d.walletsMutex.Unlock()
+
+	d.monitoredWallets = wallets
+}
+
+// diffWallets returns the discovered wallets missing from current and the
+// current wallets missing from discovered, each in their original order.
+func diffWallets(current, discovered []models.WalletInfo) (added, removed []models.WalletInfo) {
+	currentIds := make(map[string]bool, len(current))
+	for _, w := range current {
+		currentIds[w.Id] = true
+	}
+	discoveredIds := make(map[string]bool, len(discovered))
+	for _, w := range discovered {
+		discoveredIds[w.Id] = true
+		if !currentIds[w.Id] {
+			added = append(added, w)
+		}
+	}
+	for _, w := range current {
+		if !discoveredIds[w.Id] {
+			removed = append(removed, w)
+		}
+	}
+	return added, removed
+}
+
+// Rediscover re-runs wallet discovery and applies the difference to the
+// monitored set while polling continues. Added wallets get a recovery scan over
+// the lookback window before they join the polling loop; removed wallets are
+// dropped after any in-flight poll finishes. If Prime cannot be reached the
+// current set is kept unchanged.
+func (d *SendReceiveListener) Rediscover(ctx context.Context, reason string) error {
+	d.discoveryMutex.Lock()
+	defer d.discoveryMutex.Unlock()
+
+	discovered, err := d.discoverWallets(ctx, d.assetsFile, false)
+	if err != nil {
+		return err
+	}
+
+	added, removed := diffWallets(d.wallets(), discovered)
+	if len(added) == 0 && len(removed) == 0 {
+		zap.L().Debug("Wallet discovery found no changes",
+			zap.String("portfolio_id", d.portfolioId),
+			zap.String("reason", reason))
+		return nil
+	}
+
+	// A standby leaves the recovery scan to whichever instance leads
+	since := time.Now().UTC().Add(-d.lookbackWindow)
+	for _, w := range added {
+		if !d.active() {
+			break
+		}
+		recovered, err := d.recoverWalletTransactions(ctx, w, since)
+		if err != nil {
+			// The wallet is still added; the regular poll covers the same window
+			zap.L().Warn("Recovery scan failed for new wallet",
+				zap.String("wallet_id", w.Id),
+				zap.String("asset_symbol", w.AssetSymbol),
+				zap.Error(err))
+			continue
+		}
+		zap.L().Info("Recovery scan completed for new wallet",
+			zap.String("wallet_id", w.Id),
+			zap.String("asset_symbol", w.AssetSymbol),
+			zap.Int("transactions_recovered", recovered))
+	}
+
+	// Apply against the latest set so the update is a pure add/remove
+	d.walletsMutex.Lock()
+	removedIds := make(map[string]bool, len(removed))
+	for _, w := range removed {
+		removedIds[w.Id] = true
+	}
+	updated := make([]models.WalletInfo, 0, len(d.monitoredWallets)+len(added))
+	for _, w := range d.monitoredWallets {
+		if !removedIds[w.Id] {
+			updated = append(updated, w)
+		}
+	}
+	updated = append(updated, added...)
+	d.monitoredWallets = updated
+	d.walletsMutex.Unlock()
+
+	for _, w := range added {
+		d.emitWalletEvent(models.WalletEventAdded, w, reason)
+	}
+	for _, w := range removed {
+		d.emitWalletEvent(models.WalletEventRemoved, w, reason)
+	}
+
+	zap.L().Info("Monitored wallets updated",
+		zap.String("portfolio_id", d.portfolioId),
+		zap.String("reason", reason),
+		zap.Int("added", len(added)),
+		zap.Int("removed", len(removed)),
+		zap.Int("total", len(updated)))
+	return nil
+}
+
+// emitWalletEvent logs a membership change and passes it to the configured hook
+func (d *SendReceiveListener) emitWalletEvent(eventType string, wallet models.WalletInfo, reason string) {
+	event := models.WalletEvent{
+		Type:        eventType,
+		PortfolioId: d.portfolioId,
+		Wallet:      wallet,
+		Reason:      reason,
+		Time:        time.Now().UTC(),
+	}
+
+	zap.L().Info(fmt.Sprintf("Wallet %s", eventType),
+		zap.String("portfolio_id", event.PortfolioId),
+		zap.String("wallet_id", wallet.Id),
+		zap.String("asset_symbol", wallet.AssetSymbol),
+		zap.String("reason", reason))
+
+	if d.onWalletEvent != nil {
+		d.onWalletEvent(event)
+	}
+}
+
+// discoveryLoop periodically rediscovers wallets from Prime
+func (d *SendReceiveListener) discoveryLoop(ctx context.Context) {
+	ticker := time.NewTicker(d.discoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := d.Rediscover(ctx, "discovery"); err != nil {
+				zap.L().Warn("Periodic wallet discovery failed, keeping current wallets",
+					zap.String("portfolio_id", d.portfolioId),
+					zap.Error(err))
+			}
+		case <-d.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package listener
+
+import (
+	"testing"
+
+	"prime-send-receive-go/internal/models"
+)
+
+func walletIds(wallets []models.WalletInfo) []string {
+	ids := make([]string, len(wallets))
+	for i, w := range wallets {
+		ids[i] = w.Id
+	}
+	return ids
+}
+
+func TestDiffWallets(t *testing.T) {
+	current := []models.WalletInfo{{Id: "a", AssetSymbol: "BTC"}, {Id: "b", AssetSymbol: "ETH"}, {Id: "c", AssetSymbol: "USDC"}}
+	discovered := []models.WalletInfo{{Id: "c", AssetSymbol: "USDC"}, {Id: "d", AssetSymbol: "SOL"}, {Id: "a", AssetSymbol: "BTC"}, {Id: "e", AssetSymbol: "XRP"}}
+
+	added, removed := diffWallets(current, discovered)
+	if got := walletIds(added); len(got) != 2 || got[0] != "d" || got[1] != "e" {
+		t.Errorf("added = %v, want [d e]", got)
+	}
+	if got := walletIds(removed); len(got) != 1 || got[0] != "b" {
+		t.Errorf("removed = %v, want [b]", got)
+	}
+
+	added, removed = diffWallets(current, current)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("Expected no changes for an identical set, got added %v removed %v", added, removed)
+	}
+}
+
+func TestEmitWalletEvent(t *testing.T) {
+	var events []models.WalletEvent
+	d := NewSendReceiveListener(SendReceiveListenerConfig{
+		PortfolioId:   "portfolio",
+		OnWalletEvent: func(e models.WalletEvent) { events = append(events, e) },
+	})
+	d.setMonitoredWallets([]models.WalletInfo{{Id: "a", AssetSymbol: "BTC"}})
+
+	d.emitWalletEvent(models.WalletEventAdded, models.WalletInfo{Id: "b", AssetSymbol: "ETH"}, "test")
+	if len(events) != 1 || events[0].Type != models.WalletEventAdded || events[0].PortfolioId != "portfolio" || events[0].Wallet.Id != "b" {
+		t.Errorf("Unexpected events %+v", events)
+	}
+	if got := walletIds(d.wallets()); len(got) != 1 || got[0] != "a" {
+		t.Errorf("Expected emitting an event to leave the wallet set unchanged, got %v", got)
+	}
+}
@@ -0,0 +1,245 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// retryStats is the retry queue depth as of the last retry pass
+type retryStats struct {
+	queued int
+	dead   int
+}
+
+// loadRetryQueue hands every transaction already in the retry queue or the
+// dead-letter queue to the retry loop, so polling does not reprocess it at the
+// same status.
+func (d *SendReceiveListener) loadRetryQueue(ctx context.Context) error {
+	entries, err := d.dbService.GetFailedTxs(ctx, store.FailedTxQuery{PortfolioId: d.portfolioId})
+	if err != nil {
+		return err
+	}
+	d.retryMutex.Lock()
+	for _, e := range entries {
+		d.retrying[e.PrimeTxId] = true
+	}
+	d.retryMutex.Unlock()
+	for _, e := range entries {
+		d.markTransactionProcessed(statusKey(e.Transaction))
+	}
+	d.updateRetryStats(entries)
+
+	if len(entries) > 0 {
+		queued, dead := d.retryDepth()
+		zap.L().Info("Loaded retry queue",
+			zap.String("portfolio_id", d.portfolioId),
+			zap.Int("queued", queued),
+			zap.Int("dead_letters", dead))
+	}
+	return nil
+}
+
+// queueRetry records a failed attempt. Polling then leaves the transaction at
+// this status to the retry loop, which backs off between attempts and
+// dead-letters it once the policy is exhausted. Failures caused by losing
+// leadership are not the transaction's fault and are left to the new leader.
+func (d *SendReceiveListener) queueRetry(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo, cause error) {
+	if d.retryPolicy.BaseDelay <= 0 || errors.Is(cause, store.ErrLeaseLost) || errors.Is(cause, errNotLeader) {
+		return
+	}
+
+	entry, err := d.dbService.RecordTxFailure(ctx, store.RecordTxFailureParams{
+		PortfolioId: d.portfolioId,
+		Wallet:      wallet,
+		Transaction: tx,
+		Error:       cause.Error(),
+		Policy:      d.retryPolicy,
+	})
+	d.checkLeaseLost(err)
+	if err != nil {
+		zap.L().Error("Failed to queue transaction for retry",
+			zap.String("transaction_id", tx.Id),
+			zap.NamedError("cause", cause),
+			zap.Error(err))
+		return
+	}
+	d.retryMutex.Lock()
+	d.retrying[tx.Id] = true
+	d.retryMutex.Unlock()
+	d.markTransactionProcessed(statusKey(tx))
+
+	switch entry.State {
+	case models.RetryStateQueued:
+		fmt.Printf("  %s↻ %s retry %d/%d at %s%s\n", colorYellow, shortId(tx.Id),
+			entry.Attempts+1, d.retryPolicy.MaxAttempts, entry.NextAttemptAt.Local().Format(time.TimeOnly), colorReset)
+		zap.L().Warn("Transaction queued for retry",
+			zap.String("transaction_id", tx.Id),
+			zap.Int("attempts", entry.Attempts),
+			zap.Time("next_attempt_at", entry.NextAttemptAt))
+	case models.RetryStateDead:
+		fmt.Printf("  %s✗ %s moved to the dead-letter queue after %d attempts%s\n", colorRed, shortId(tx.Id), entry.Attempts, colorReset)
+		zap.L().Error("Transaction moved to the dead-letter queue",
+			zap.String("transaction_id", tx.Id),
+			zap.String("portfolio_id", d.portfolioId),
+			zap.Int("attempts", entry.Attempts),
+			zap.String("last_error", entry.LastError))
+		if entry.Attempts == d.retryPolicy.MaxAttempts {
+			d.alertDeadLetter(ctx, *entry)
+		}
+	}
+}
+
+// resolveRetry removes a transaction from the retry queue once it has been
+// processed, whether by the retry loop or by a poll seeing a later status.
+func (d *SendReceiveListener) resolveRetry(ctx context.Context, txId string) {
+	d.retryMutex.Lock()
+	queued := d.retrying[txId]
+	delete(d.retrying, txId)
+	d.retryMutex.Unlock()
+	if !queued {
+		return
+	}
+
+	err := d.dbService.ResolveFailedTx(ctx, txId)
+	d.checkLeaseLost(err)
+	if err != nil && !errors.Is(err, store.ErrFailedTxNotFound) {
+		zap.L().Error("Failed to remove transaction from the retry queue",
+			zap.String("transaction_id", txId),
+			zap.Error(err))
+		return
+	}
+	zap.L().Info("Transaction removed from the retry queue after processing",
+		zap.String("transaction_id", txId))
+}
+
+// alertDeadLetter reports a new dead letter once the queue has reached the alert threshold
+func (d *SendReceiveListener) alertDeadLetter(ctx context.Context, entry models.FailedTransaction) {
+	dead, err := d.dbService.GetFailedTxs(ctx, store.FailedTxQuery{PortfolioId: d.portfolioId, State: models.RetryStateDead})
+	if err != nil {
+		zap.L().Error("Failed to count dead letters", zap.Error(err))
+		return
+	}
+	if len(dead) < d.alertThreshold {
+		return
+	}
+	zap.L().Error("Dead-letter queue grew",
+		zap.String("portfolio_id", d.portfolioId),
+		zap.Int("depth", len(dead)),
+		zap.String("transaction_id", entry.PrimeTxId))
+	if d.onDeadLetter != nil {
+		d.onDeadLetter(models.DeadLetterAlert{
+			PortfolioId: d.portfolioId,
+			Depth:       len(dead),
+			Transaction: entry,
+			Time:        time.Now().UTC(),
+		})
+	}
+}
+
+// retryLoop retries due transactions from the queue every polling interval
+func (d *SendReceiveListener) retryLoop(ctx context.Context) {
+	ticker := time.NewTicker(d.pollingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if d.active() {
+				d.retryDue(ctx)
+			}
+		case <-d.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// retryDue replays each queued transaction whose next attempt is due from its
+// stored payload, so retries continue after Prime's lookback window has moved on.
+func (d *SendReceiveListener) retryDue(ctx context.Context) {
+	entries, err := d.dbService.GetFailedTxs(ctx, store.FailedTxQuery{PortfolioId: d.portfolioId})
+	if err != nil {
+		zap.L().Error("Failed to read retry queue", zap.Error(err))
+		return
+	}
+	d.updateRetryStats(entries)
+
+	now := time.Now().UTC()
+	for _, e := range entries {
+		if e.State != models.RetryStateQueued || e.NextAttemptAt.After(now) {
+			continue
+		}
+		d.retryMutex.Lock()
+		d.retrying[e.PrimeTxId] = true
+		d.retryMutex.Unlock()
+
+		retryCtx, err := d.leaderContext(ctx)
+		if err != nil {
+			return
+		}
+		err = d.routeTransaction(retryCtx, e.Transaction, e.Wallet)
+		d.checkLeaseLost(err)
+		if err != nil {
+			d.queueRetry(retryCtx, e.Transaction, e.Wallet, err)
+			continue
+		}
+		fmt.Printf("  %s↻ %s %s %s retried successfully after %d failed attempts%s\n",
+			colorGreen, shortId(e.PrimeTxId), e.Transaction.Type, e.Transaction.Status, e.Attempts, colorReset)
+		d.resolveRetry(retryCtx, e.PrimeTxId)
+	}
+}
+
+// updateRetryStats records the queue depth reported by Stats
+func (d *SendReceiveListener) updateRetryStats(entries []models.FailedTransaction) {
+	var stats retryStats
+	for _, e := range entries {
+		switch e.State {
+		case models.RetryStateQueued:
+			stats.queued++
+		case models.RetryStateDead:
+			stats.dead++
+		}
+	}
+	d.retryMutex.Lock()
+	d.retryStats = stats
+	d.retryMutex.Unlock()
+}
+
+// retryDepth returns the queued and dead-lettered counts as of the last retry pass
+func (d *SendReceiveListener) retryDepth() (int, int) {
+	d.retryMutex.Lock()
+	defer d.retryMutex.Unlock()
+	return d.retryStats.queued, d.retryStats.dead
+}
+
+// shortId abbreviates a transaction id for console output
+func shortId(id string) string {
+	if len(id) > 12 {
+		return id[:12] + "..."
+	}
+	return id
+}
@@ -0,0 +1,177 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+)
+
+// retryStore keeps the retry queue in memory and fails platform
+// transactions while err is set
+type retryStore struct {
+	store.LedgerStore
+	err    error
+	failed map[string]*models.FailedTransaction
+	booked []string
+}
+
+func newRetryStore() *retryStore {
+	return &retryStore{failed: map[string]*models.FailedTransaction{}}
+}
+
+func (s *retryStore) RecordPlatformTransaction(_ context.Context, p store.PlatformTransactionParams) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.booked = append(s.booked, p.TransactionId)
+	return nil
+}
+
+func (s *retryStore) RecordTxFailure(_ context.Context, p store.RecordTxFailureParams) (*models.FailedTransaction, error) {
+	failed := p.Apply(s.failed[p.Transaction.Id], time.Now().UTC())
+	s.failed[failed.PrimeTxId] = failed
+	return failed, nil
+}
+
+func (s *retryStore) GetFailedTxs(_ context.Context, q store.FailedTxQuery) ([]models.FailedTransaction, error) {
+	var entries []models.FailedTransaction
+	for _, f := range s.failed {
+		if (q.PortfolioId == "" || f.PortfolioId == q.PortfolioId) && (q.State == "" || f.State == q.State) {
+			entries = append(entries, *f)
+		}
+	}
+	return entries, nil
+}
+
+func (s *retryStore) ResolveFailedTx(_ context.Context, id string) error {
+	if s.failed[id] == nil {
+		return fmt.Errorf("%w: %s", store.ErrFailedTxNotFound, id)
+	}
+	delete(s.failed, id)
+	return nil
+}
+
+// makeDue moves every queued entry's next attempt into the past
+func (s *retryStore) makeDue() {
+	for _, f := range s.failed {
+		f.NextAttemptAt = time.Now().UTC().Add(-time.Second)
+	}
+}
+
+func TestRetryQueue_RetriesUntilSuccess(t *testing.T) {
+	ctx := context.Background()
+	db := newRetryStore()
+	db.err = errors.New("database is locked")
+	d := NewSendReceiveListener(SendReceiveListenerConfig{
+		DbService:   db,
+		PortfolioId: "portfolio",
+		RetryPolicy: models.RetryPolicy{BaseDelay: time.Minute, MaxAttempts: 5},
+	})
+
+	tx := models.PrimeTransaction{Id: "tx1", Type: "REWARD", Status: "TRANSACTION_DONE", Amount: "1", Symbol: "ETH"}
+	if err := d.processTransaction(ctx, tx, models.WalletInfo{Id: "w1"}); err == nil {
+		t.Fatal("Expected the first attempt to fail")
+	}
+	if f := db.failed["tx1"]; f == nil || f.State != models.RetryStateQueued || f.Attempts != 1 {
+		t.Fatalf("Expected a queued entry, got %+v", f)
+	}
+	// Polls leave the transaction to the retry loop
+	if !d.isStatusProcessed(tx) {
+		t.Error("Expected the failed status to be marked processed")
+	}
+
+	// Not due yet
+	db.err = nil
+	d.retryDue(ctx)
+	if len(db.booked) != 0 {
+		t.Fatalf("Expected no retry before the backoff passed, booked %v", db.booked)
+	}
+	if queued, _ := d.retryDepth(); queued != 1 {
+		t.Errorf("Expected 1 queued, got %d", queued)
+	}
+
+	db.makeDue()
+	d.retryDue(ctx)
+	if len(db.booked) != 1 || db.failed["tx1"] != nil {
+		t.Errorf("Expected the retry to book the transaction and resolve the entry, booked %v, entry %+v", db.booked, db.failed["tx1"])
+	}
+}
+
+func TestRetryQueue_DeadLetterAlert(t *testing.T) {
+	ctx := context.Background()
+	db := newRetryStore()
+	db.err = errors.New("unknown wallet")
+	var alerts []models.DeadLetterAlert
+	d := NewSendReceiveListener(SendReceiveListenerConfig{
+		DbService:                db,
+		PortfolioId:              "portfolio",
+		RetryPolicy:              models.RetryPolicy{BaseDelay: time.Minute, MaxAttempts: 2},
+		DeadLetterAlertThreshold: 2,
+		OnDeadLetter:             func(a models.DeadLetterAlert) { alerts = append(alerts, a) },
+	})
+
+	for _, id := range []string{"tx1", "tx2"} {
+		tx := models.PrimeTransaction{Id: id, Type: "REWARD", Status: "TRANSACTION_DONE"}
+		_ = d.processTransaction(ctx, tx, models.WalletInfo{Id: "w1"})
+		db.makeDue()
+		d.retryDue(ctx)
+		if db.failed[id].State != models.RetryStateDead {
+			t.Fatalf("Expected %s to be dead-lettered, got %+v", id, db.failed[id])
+		}
+	}
+
+	// Only the dead letter that reached the threshold alerts
+	if len(alerts) != 1 || alerts[0].Depth != 2 || alerts[0].Transaction.PrimeTxId != "tx2" {
+		t.Fatalf("Expected one alert at depth 2, got %+v", alerts)
+	}
+	if alerts[0].Transaction.LastError == "" {
+		t.Error("Expected the alert to carry the last error")
+	}
+
+	// Dead letters are not retried
+	db.err = nil
+	db.makeDue()
+	d.retryDue(ctx)
+	if len(db.booked) != 0 {
+		t.Errorf("Expected dead letters to stay put, booked %v", db.booked)
+	}
+	if _, dead := d.retryDepth(); dead != 2 {
+		t.Errorf("Expected 2 dead letters, got %d", dead)
+	}
+}
+
+func TestRetryQueue_SkipsLeadershipErrors(t *testing.T) {
+	ctx := context.Background()
+	db := newRetryStore()
+	d := NewSendReceiveListener(SendReceiveListenerConfig{
+		DbService:   db,
+		PortfolioId: "portfolio",
+		RetryPolicy: models.RetryPolicy{BaseDelay: time.Minute},
+	})
+
+	tx := models.PrimeTransaction{Id: "tx1", Type: "REWARD", Status: "TRANSACTION_DONE"}
+	d.queueRetry(ctx, tx, models.WalletInfo{}, fmt.Errorf("write fenced off: %w", store.ErrLeaseLost))
+	if len(db.failed) != 0 || d.isStatusProcessed(tx) {
+		t.Error("Expected a lost lease not to queue the transaction")
+	}
+}
+
+func TestRetryQueue_Disabled(t *testing.T) {
+	ctx := context.Background()
+	db := newRetryStore()
+	db.err = errors.New("boom")
+	d := NewSendReceiveListener(SendReceiveListenerConfig{DbService: db, PortfolioId: "portfolio"})
+
+	tx := models.PrimeTransaction{Id: "tx1", Type: "REWARD", Status: "TRANSACTION_DONE"}
+	if err := d.processTransaction(ctx, tx, models.WalletInfo{}); err == nil {
+		t.Fatal("Expected the attempt to fail")
+	}
+	if len(db.failed) != 0 || d.isStatusProcessed(tx) {
+		t.Error("Expected no retry queue without a retry policy, so polls keep retrying")
+	}
+}
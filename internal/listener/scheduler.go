@@ -0,0 +1,237 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package listener
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+)
+
+// Scheduler defaults
+const (
+	DefaultPollConcurrency = 8
+	maxSchedulerTick       = time.Second
+)
+
+// scheduleEntry is the polling state of one monitored wallet
+type scheduleEntry struct {
+	wallet       models.WalletInfo
+	interval     time.Duration
+	nextPoll     time.Time
+	lastPoll     time.Time
+	lastActivity time.Time
+	failures     int
+	inFlight     bool
+	// removed marks a wallet dropped by sync while its poll was in flight. The
+	// entry is kept until complete runs, so the wallet cannot be polled twice at
+	// once if it is rediscovered in the meantime.
+	removed bool
+}
+
+// walletScheduler decides when each wallet is polled. Wallets that saw new
+// transactions are polled every minInterval; idle or failing wallets double
+// their interval up to maxInterval. At most concurrency polls run at once and
+// due wallets beyond that wait in the queue, oldest due first.
+type walletScheduler struct {
+	mutex       sync.Mutex
+	entries     map[string]*scheduleEntry
+	minInterval time.Duration
+	maxInterval time.Duration
+	slots       chan struct{}
+	wake        chan struct{}
+	polls       int64
+	failures    int64
+}
+
+func newWalletScheduler(concurrency int, minInterval, maxInterval time.Duration) *walletScheduler {
+	if concurrency <= 0 {
+		concurrency = DefaultPollConcurrency
+	}
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+	return &walletScheduler{
+		entries:     make(map[string]*scheduleEntry),
+		minInterval: minInterval,
+		maxInterval: maxInterval,
+		slots:       make(chan struct{}, concurrency),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// tick is how often due wallets are checked for
+func (s *walletScheduler) tick() time.Duration {
+	if s.minInterval > 0 && s.minInterval < maxSchedulerTick {
+		return s.minInterval
+	}
+	return maxSchedulerTick
+}
+
+// sync makes the schedule match the monitored wallets. New wallets are due
+// immediately; removed wallets are forgotten, and an in-flight poll for one
+// completes without being rescheduled. A wallet rediscovered while its poll is
+// still in flight keeps that poll and is rescheduled when it completes.
+func (s *walletScheduler) sync(wallets []models.WalletInfo, now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	present := make(map[string]bool, len(wallets))
+	for _, w := range wallets {
+		present[w.Id] = true
+		if e, ok := s.entries[w.Id]; !ok {
+			s.entries[w.Id] = &scheduleEntry{wallet: w, interval: s.minInterval, nextPoll: now}
+		} else if e.removed {
+			e.wallet, e.removed = w, false
+		}
+	}
+	for id, e := range s.entries {
+		switch {
+		case present[id]:
+		case e.inFlight:
+			e.removed = true
+		default:
+			delete(s.entries, id)
+		}
+	}
+}
+
+// due returns the wallets whose next poll has passed and that are not being
+// polled, oldest due first.
+func (s *walletScheduler) due(now time.Time) []*scheduleEntry {
+	var due []*scheduleEntry
+	for _, e := range s.entries {
+		if !e.inFlight && !e.removed && !e.nextPoll.After(now) {
+			due = append(due, e)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].nextPoll.Equal(due[j].nextPoll) {
+			return due[i].wallet.Id < due[j].wallet.Id
+		}
+		return due[i].nextPoll.Before(due[j].nextPoll)
+	})
+	return due
+}
+
+// claim takes a free poll slot for each due wallet in order and marks it in
+// flight. Wallets left without a slot stay queued until one is released.
+func (s *walletScheduler) claim(now time.Time) []models.WalletInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var claimed []models.WalletInfo
+	for _, e := range s.due(now) {
+		select {
+		case s.slots <- struct{}{}:
+			e.inFlight = true
+			claimed = append(claimed, e.wallet)
+		default:
+			return claimed
+		}
+	}
+	return claimed
+}
+
+// complete records a finished poll, schedules the wallet's next poll and frees
+// its slot.
+func (s *walletScheduler) complete(walletId string, newTransactions int, pollErr error, now time.Time) {
+	s.mutex.Lock()
+	s.polls++
+	if pollErr != nil {
+		s.failures++
+	}
+	if e, ok := s.entries[walletId]; ok && e.removed {
+		delete(s.entries, walletId)
+	} else if ok {
+		e.inFlight = false
+		e.lastPoll = now
+		switch {
+		case pollErr != nil:
+			e.failures++
+			e.interval = s.backoff(e.interval)
+		case newTransactions > 0:
+			e.failures = 0
+			e.lastActivity = now
+			e.interval = s.minInterval
+		default:
+			e.failures = 0
+			e.interval = s.backoff(e.interval)
+		}
+		e.nextPoll = now.Add(e.interval)
+	}
+	s.mutex.Unlock()
+
+	<-s.slots
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *walletScheduler) backoff(interval time.Duration) time.Duration {
+	interval *= 2
+	if interval < s.minInterval {
+		interval = s.minInterval
+	}
+	if interval > s.maxInterval {
+		interval = s.maxInterval
+	}
+	return interval
+}
+
+// stats reports the schedule for monitoring. Lag is how long the oldest queued
+// wallet has been waiting past its due time.
+func (s *walletScheduler) stats(now time.Time) models.ListenerStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := models.ListenerStats{
+		Concurrency: cap(s.slots),
+		Polls:       s.polls,
+		Failures:    s.failures,
+		Wallets:     make([]models.WalletScheduleStats, 0, len(s.entries)),
+	}
+	for _, e := range s.due(now) {
+		stats.QueueDepth++
+		if lag := now.Sub(e.nextPoll).Seconds(); lag > stats.MaxLagSeconds {
+			stats.MaxLagSeconds = lag
+		}
+	}
+	for _, e := range s.entries {
+		if e.inFlight {
+			stats.InFlight++
+		}
+		if e.removed {
+			continue
+		}
+		stats.Wallets = append(stats.Wallets, models.WalletScheduleStats{
+			Id:                  e.wallet.Id,
+			AssetSymbol:         e.wallet.AssetSymbol,
+			IntervalSeconds:     e.interval.Seconds(),
+			NextPoll:            e.nextPoll,
+			LastPoll:            e.lastPoll,
+			LastActivity:        e.lastActivity,
+			ConsecutiveFailures: e.failures,
+			InFlight:            e.inFlight,
+		})
+	}
+	sort.Slice(stats.Wallets, func(i, j int) bool { return stats.Wallets[i].Id < stats.Wallets[j].Id })
+	return stats
+}
@@ -0,0 +1,171 @@
+package listener
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+)
+
+func scheduledWallets(ids ...string) []models.WalletInfo {
+	wallets := make([]models.WalletInfo, len(ids))
+	for i, id := range ids {
+		wallets[i] = models.WalletInfo{Id: id, AssetSymbol: "BTC"}
+	}
+	return wallets
+}
+
+func TestSchedulerConcurrencyAndQueue(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newWalletScheduler(2, 30*time.Second, 5*time.Minute)
+	s.sync(scheduledWallets("a", "b", "c"), now)
+
+	if got := walletIds(s.claim(now)); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("claim = %v, want [a b]", got)
+	}
+	if got := s.claim(now); len(got) != 0 {
+		t.Errorf("Expected no free slots, claimed %v", walletIds(got))
+	}
+
+	stats := s.stats(now.Add(10 * time.Second))
+	if stats.InFlight != 2 || stats.QueueDepth != 1 || stats.MaxLagSeconds != 10 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// A slow wallet keeps its slot; the other slot frees up for the queued wallet
+	s.complete("a", 0, nil, now.Add(10*time.Second))
+	if got := walletIds(s.claim(now.Add(10 * time.Second))); len(got) != 1 || got[0] != "c" {
+		t.Errorf("claim = %v, want [c]", got)
+	}
+	select {
+	case <-s.wake:
+	default:
+		t.Error("Expected complete to wake the dispatcher")
+	}
+}
+
+func TestSchedulerIntervals(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newWalletScheduler(1, 30*time.Second, 2*time.Minute)
+	s.sync(scheduledWallets("a"), now)
+
+	poll := func(newTransactions int, err error) time.Duration {
+		t.Helper()
+		if len(s.claim(now)) != 1 {
+			t.Fatal("Expected the wallet to be due")
+		}
+		s.complete("a", newTransactions, err, now)
+		e := s.entries["a"]
+		now = e.nextPoll
+		return e.interval
+	}
+
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 2 * time.Minute} {
+		if got := poll(0, nil); got != want {
+			t.Errorf("idle poll %d: interval %s, want %s", i, got, want)
+		}
+	}
+	if got := poll(3, nil); got != 30*time.Second {
+		t.Errorf("Expected activity to reset the interval, got %s", got)
+	}
+	if got := poll(0, errors.New("prime unavailable")); got != time.Minute {
+		t.Errorf("Expected a failure to back off, got %s", got)
+	}
+	if s.entries["a"].failures != 1 || s.failures != 1 || s.polls != 5 {
+		t.Errorf("Unexpected failure counts: wallet %d, total %d, polls %d", s.entries["a"].failures, s.failures, s.polls)
+	}
+	if due := s.claim(now.Add(-time.Second)); len(due) != 0 {
+		t.Error("Expected the wallet not to be due before its next poll")
+	}
+}
+
+func TestSchedulerSync(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newWalletScheduler(4, 30*time.Second, 5*time.Minute)
+	s.sync(scheduledWallets("a", "b"), now)
+	s.claim(now)
+
+	// b is removed while in flight; its completion must not reschedule it
+	s.sync(scheduledWallets("a", "c"), now)
+	s.complete("b", 1, nil, now)
+	if _, ok := s.entries["b"]; ok {
+		t.Error("Expected removed wallet to stay unscheduled")
+	}
+	if got := walletIds(s.claim(now)); len(got) != 1 || got[0] != "c" {
+		t.Errorf("claim = %v, want [c]", got)
+	}
+	if stats := s.stats(now); len(stats.Wallets) != 2 || stats.InFlight != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestSchedulerSync_RediscoveredWhileInFlight(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newWalletScheduler(4, 30*time.Second, 5*time.Minute)
+	s.sync(scheduledWallets("a"), now)
+	s.claim(now)
+
+	// a is dropped and rediscovered while its first poll is still running
+	s.sync(nil, now)
+	if got := s.claim(now); len(got) != 0 {
+		t.Fatalf("Expected a removed in-flight wallet not to be claimed, got %v", walletIds(got))
+	}
+	s.sync(scheduledWallets("a"), now)
+	if got := s.claim(now); len(got) != 0 {
+		t.Fatalf("Expected no second poll while the first is in flight, got %v", walletIds(got))
+	}
+	if stats := s.stats(now); stats.InFlight != 1 || len(stats.Wallets) != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// The first poll's completion reschedules a, and only one poll follows
+	s.complete("a", 0, nil, now)
+	if got := s.claim(now); len(got) != 0 {
+		t.Errorf("Expected a not to be due straight after its poll, got %v", walletIds(got))
+	}
+	next := s.entries["a"].nextPoll
+	if got := walletIds(s.claim(next)); len(got) != 1 || got[0] != "a" {
+		t.Errorf("claim = %v, want [a]", got)
+	}
+	if got := s.claim(next); len(got) != 0 {
+		t.Errorf("Expected a single poll in flight, got %v", walletIds(got))
+	}
+}
+
+func TestMaxPollIntervalClampedToLookback(t *testing.T) {
+	l := NewSendReceiveListener(SendReceiveListenerConfig{
+		LookbackWindow:  time.Hour,
+		PollingInterval: 30 * time.Second,
+		MaxPollInterval: 2 * time.Hour,
+	})
+	if l.scheduler.maxInterval != 30*time.Minute {
+		t.Errorf("Expected the max poll interval clamped to half the lookback window, got %s", l.scheduler.maxInterval)
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	l := NewSendReceiveListener(SendReceiveListenerConfig{
+		PortfolioId:     "portfolio",
+		PollingInterval: 30 * time.Second,
+		PollConcurrency: 3,
+	})
+	l.scheduler.sync(scheduledWallets("a"), time.Now().UTC())
+
+	rec := httptest.NewRecorder()
+	StatusHandler([]*SendReceiveListener{l}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	var stats []models.ListenerStats
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].PortfolioId != "portfolio" || stats[0].Concurrency != 3 || len(stats[0].Wallets) != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
@@ -19,7 +19,7 @@ package listener
 import (
 	"context"
 	"fmt"
-	"sync"
+	"strings"
 	"time"
 
 	"prime-send-receive-go/internal/models"
@@ -33,27 +33,44 @@ func (d *SendReceiveListener) Start(ctx context.Context, assetsFile string) erro
 	zap.L().Info("Starting deposit listener")
 
 	// Load monitored wallets
+	d.assetsFile = assetsFile
 	if err := d.LoadMonitoredWallets(ctx, assetsFile); err != nil {
 		return fmt.Errorf("failed to load monitored wallets: %w", err)
 	}
 
-	if len(d.monitoredWallets) == 0 {
+	if len(d.wallets()) == 0 {
 		zap.L().Warn("No wallets to monitor - make sure addresses have been created")
 		return fmt.Errorf("no wallets to monitor")
 	}
 
-	// Perform startup recovery to catch any missed transactions
-	if err := d.performStartupRecovery(ctx); err != nil {
-		zap.L().Error("Startup recovery failed", zap.Error(err))
-		return fmt.Errorf("startup recovery failed: %w", err)
+	// Perform startup recovery to catch any missed transactions. With leader
+	// election it runs whenever this instance takes over instead.
+	if d.elector == nil {
+		if err := d.performStartupRecovery(ctx); err != nil {
+			zap.L().Error("Startup recovery failed", zap.Error(err))
+			return fmt.Errorf("startup recovery failed: %w", err)
+		}
+	}
+
+	if d.retryPolicy.BaseDelay > 0 {
+		if err := d.loadRetryQueue(ctx); err != nil {
+			return fmt.Errorf("failed to load retry queue: %w", err)
+		}
+		go d.retryLoop(ctx)
 	}
 
 	go d.pollLoop(ctx)
 	go d.cleanupLoop(ctx)
+	if d.discoveryInterval > 0 {
+		go d.discoveryLoop(ctx)
+	}
 
 	zap.L().Info("Deposit listener started successfully",
 		zap.Duration("polling_interval", d.pollingInterval),
-		zap.Duration("lookback_window", d.lookbackWindow))
+		zap.Duration("lookback_window", d.lookbackWindow),
+		zap.Duration("discovery_interval", d.discoveryInterval),
+		zap.Duration("max_poll_interval", d.scheduler.maxInterval),
+		zap.Int("poll_concurrency", cap(d.scheduler.slots)))
 
 	return nil
 }
@@ -66,19 +83,31 @@ func (d *SendReceiveListener) Stop() {
 	zap.L().Info("Deposit listener stopped")
 }
 
-// pollLoop runs the main polling loop
+// pollLoop dispatches due wallets to the poll workers until stopped, then
+// waits for in-flight polls to finish
 func (d *SendReceiveListener) pollLoop(ctx context.Context) {
 	defer close(d.doneChan)
+	defer d.pollers.Wait()
 
-	ticker := time.NewTicker(d.pollingInterval)
+	ticker := time.NewTicker(d.scheduler.tick())
 	defer ticker.Stop()
 
+	var leadership <-chan bool
+	if d.elector != nil {
+		leadership = d.elector.Changes()
+	}
+
 	d.pollWallets(ctx)
 
 	for {
 		select {
 		case <-ticker.C:
 			d.pollWallets(ctx)
+		case <-d.scheduler.wake:
+			d.pollWallets(ctx)
+		case isLeader := <-leadership:
+			d.onLeadershipChange(ctx, isLeader)
+			d.pollWallets(ctx)
 		case <-d.stopChan:
 			return
 		case <-ctx.Done():
@@ -97,22 +126,46 @@ const (
 	colorGray   = "\033[90m"
 )
 
-// pollWallets polls all monitored wallets for new transactions
+// pollWallets starts a poll for every due wallet that gets a free slot. Each
+// poll runs on its own, so a slow wallet only holds its slot and never delays
+// the others; wallets without a slot wait for the next dispatch.
 func (d *SendReceiveListener) pollWallets(ctx context.Context) {
-	since := time.Now().UTC().Add(-d.lookbackWindow)
+	now := time.Now().UTC()
+	wallets := d.wallets()
+	d.scheduler.sync(wallets, now)
+	if !d.active() {
+		return
+	}
 
-	fmt.Printf("\n%s[%s] Polling %d wallets (lookback: %s)%s\n",
-		colorCyan, time.Now().Format("15:04:05"), len(d.monitoredWallets), d.lookbackWindow, colorReset)
+	claimed := d.scheduler.claim(now)
+	if len(claimed) == 0 {
+		return
+	}
 
-	var wg sync.WaitGroup
+	stats := d.scheduler.stats(now)
+	fmt.Printf("\n%s[%s] Polling %d of %d wallets (in flight: %d, queued: %d, lag: %s, lookback: %s)%s\n",
+		colorCyan, time.Now().Format("15:04:05"), len(claimed), len(wallets), stats.InFlight,
+		stats.QueueDepth, time.Duration(stats.MaxLagSeconds*float64(time.Second)).Round(time.Second),
+		d.lookbackWindow, colorReset)
+
+	if stats.QueueDepth > 0 && stats.MaxLagSeconds > d.pollingInterval.Seconds() {
+		zap.L().Warn("Wallet polls are falling behind schedule",
+			zap.String("portfolio_id", d.portfolioId),
+			zap.Int("queue_depth", stats.QueueDepth),
+			zap.Float64("max_lag_seconds", stats.MaxLagSeconds),
+			zap.Int("poll_concurrency", stats.Concurrency))
+	}
 
-	for _, wallet := range d.monitoredWallets {
-		wg.Add(1)
+	since := now.Add(-d.lookbackWindow)
+	for _, wallet := range claimed {
+		d.pollers.Add(1)
 
 		go func(w models.WalletInfo) {
-			defer wg.Done()
+			defer d.pollers.Done()
 
-			if err := d.pollWallet(ctx, w, since); err != nil {
+			newCount, err := d.pollWallet(ctx, w, since)
+			d.scheduler.complete(w.Id, newCount, err, time.Now().UTC())
+			if err != nil {
 				fmt.Printf("  %s✗ %s (%s): %s%s\n", colorRed, w.AssetSymbol, w.Id[:8], err, colorReset)
 				zap.L().Error("Failed to poll wallet",
 					zap.String("wallet_id", w.Id),
@@ -121,20 +174,28 @@ func (d *SendReceiveListener) pollWallets(ctx context.Context) {
 			}
 		}(wallet)
 	}
+}
 
-	wg.Wait()
+// Stats returns a snapshot of the wallet poll schedule for monitoring
+func (d *SendReceiveListener) Stats() models.ListenerStats {
+	stats := d.scheduler.stats(time.Now().UTC())
+	stats.PortfolioId = d.portfolioId
+	stats.Active = d.active()
+	stats.RetryQueued, stats.DeadLetters = d.retryDepth()
+	return stats
 }
 
-// pollWallet polls a specific wallet for new transactions
-func (d *SendReceiveListener) pollWallet(ctx context.Context, wallet models.WalletInfo, since time.Time) error {
+// pollWallet polls a specific wallet for new transactions and returns how many
+// it had not seen before
+func (d *SendReceiveListener) pollWallet(ctx context.Context, wallet models.WalletInfo, since time.Time) (int, error) {
 	transactions, err := d.fetchWalletTransactions(ctx, wallet.Id, since)
 	if err != nil {
-		return fmt.Errorf("failed to fetch transactions: %w", err)
+		return 0, fmt.Errorf("failed to fetch transactions: %w", err)
 	}
 
 	newCount := 0
 	for _, tx := range transactions {
-		if d.isTransactionProcessed(tx.Id) {
+		if d.isStatusProcessed(tx) {
 			continue
 		}
 		newCount++
@@ -172,15 +233,31 @@ func (d *SendReceiveListener) pollWallet(ctx context.Context, wallet models.Wall
 			zap.Int("total", len(transactions)))
 	}
 
-	return nil
+	return newCount, nil
 }
 
 // processTransaction processes a single Prime transaction
 func (d *SendReceiveListener) processTransaction(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) error {
-	if d.isTransactionProcessed(tx.Id) {
+	if d.isStatusProcessed(tx) {
 		return nil
 	}
 
+	ctx, err := d.leaderContext(ctx)
+	if err != nil {
+		return err
+	}
+	err = d.routeTransaction(ctx, tx, wallet)
+	d.checkLeaseLost(err)
+	if err != nil {
+		d.queueRetry(ctx, tx, wallet, err)
+		return err
+	}
+	d.resolveRetry(ctx, tx.Id)
+	return nil
+}
+
+// routeTransaction hands a transaction to the handler for its type
+func (d *SendReceiveListener) routeTransaction(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) error {
 	switch tx.Type {
 	case "DEPOSIT":
 		return d.processDeposit(ctx, tx, wallet)
@@ -203,8 +280,12 @@ func (d *SendReceiveListener) processTransaction(ctx context.Context, tx models.
 			WalletId:        wallet.Id,
 			TransactionTime: txTime,
 			Metadata: map[string]string{
-				"idempotency_key": tx.IdempotencyKey,
-				"transaction_id":  tx.TransactionId,
+				"idempotency_key":             tx.IdempotencyKey,
+				store.MetadataPrimeTxId:       tx.TransactionId,
+				store.MetadataLegacyPrimeTxId: tx.TransactionId, // still read by existing tooling
+				"blockchain_ids":              strings.Join(tx.BlockchainIds, ","),
+				"network_fees":                tx.NetworkFees,
+				"fees":                        tx.Fees,
 			},
 		})
 		if err != nil {
@@ -313,9 +394,10 @@ func (d *SendReceiveListener) performStartupRecovery(ctx context.Context) error
 		zap.Duration("lookback_window", d.lookbackWindow))
 
 	// Poll all wallets for transactions in the recovery window
+	wallets := d.wallets()
 	var totalRecovered int
 	var failedWallets []string
-	for _, wallet := range d.monitoredWallets {
+	for _, wallet := range wallets {
 		recovered, err := d.recoverWalletTransactions(ctx, wallet, recoveryStart)
 		if err != nil {
 			zap.L().Error("Failed to recover transactions for wallet",
@@ -333,19 +415,19 @@ func (d *SendReceiveListener) performStartupRecovery(ctx context.Context) error
 	if len(failedWallets) > 0 {
 		zap.L().Warn("Startup recovery completed with some failures",
 			zap.Int("total_transactions_recovered", totalRecovered),
-			zap.Int("total_wallets", len(d.monitoredWallets)),
+			zap.Int("total_wallets", len(wallets)),
 			zap.Int("failed_wallets", len(failedWallets)),
 			zap.Strings("failed_wallet_details", failedWallets))
 
 		// If more than half the wallets failed, consider this a critical issue
-		if len(failedWallets) > len(d.monitoredWallets)/2 {
+		if len(failedWallets) > len(wallets)/2 {
 			return fmt.Errorf("recovery failed for majority of wallets (%d/%d): %v",
-				len(failedWallets), len(d.monitoredWallets), failedWallets)
+				len(failedWallets), len(wallets), failedWallets)
 		}
 	} else {
 		zap.L().Info("Startup recovery completed successfully",
 			zap.Int("total_transactions_recovered", totalRecovered),
-			zap.Int("total_wallets", len(d.monitoredWallets)))
+			zap.Int("total_wallets", len(wallets)))
 	}
 
 	return nil
@@ -372,7 +454,7 @@ func (d *SendReceiveListener) recoverWalletTransactions(ctx context.Context, wal
 	var recovered int
 	for _, tx := range transactions {
 		// Skip if already processed
-		if d.isTransactionProcessed(tx.Id) {
+		if d.isStatusProcessed(tx) {
 			zap.L().Debug("Transaction already processed during recovery, skipping",
 				zap.String("transaction_id", tx.Id))
 			continue
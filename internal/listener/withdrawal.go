@@ -23,6 +23,8 @@ import (
 	"strings"
 	"time"
 
+	"prime-send-receive-go/internal/assets"
+	"prime-send-receive-go/internal/lifecycle"
 	"prime-send-receive-go/internal/models"
 	"prime-send-receive-go/internal/store"
 
@@ -30,54 +32,43 @@ import (
 	"go.uber.org/zap"
 )
 
-// processWithdrawal processes a withdrawal transaction
+// processWithdrawal processes a withdrawal transaction. Statuses still in
+// flight are skipped until Prime reports one the lifecycle acts on.
 func (d *SendReceiveListener) processWithdrawal(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) error {
-	// Terminal failure statuses that require balance credit-back
-	terminalFailures := map[string]bool{
-		"TRANSACTION_CANCELLED": true,
-		"TRANSACTION_REJECTED":  true,
-		"TRANSACTION_FAILED":    true,
-		"TRANSACTION_EXPIRED":   true,
-	}
-
-	// Check if this is a terminal failure status
-	if terminalFailures[tx.Status] {
-		zap.L().Warn("Withdrawal failed with terminal status - crediting back",
-			zap.String("transaction_id", tx.Id),
-			zap.String("status", tx.Status),
-			zap.String("symbol", tx.Symbol),
-			zap.String("amount", tx.Amount),
-			zap.Time("created_at", tx.CreatedAt))
-		return d.handleFailedWithdrawal(ctx, tx, wallet)
-	}
-
-	// OTHER_TRANSACTION_STATUS: treat as a pending withdrawal.
-	// Try to match to a user via idempotency key; if not, use the platform account.
-	// Funds move to the withdrawal pending account (same as WITHDRAWAL_INITIATED).
-	if tx.Status == "OTHER_TRANSACTION_STATUS" {
-		return d.handlePendingWithdrawal(ctx, tx, wallet)
-	}
-
-	if tx.Status != "TRANSACTION_DONE" {
-		zap.L().Debug("Skipping non-completed withdrawal - waiting for completion",
-			zap.String("transaction_id", tx.Id),
-			zap.String("status", tx.Status),
-			zap.String("symbol", tx.Symbol),
-			zap.String("amount", tx.Amount),
-			zap.Time("created_at", tx.CreatedAt))
-		return nil
-	}
+	return d.advanceLifecycle(ctx, models.TxKindWithdrawal, tx, func(ctx context.Context, from, to string) (string, error) {
+		switch to {
+		case models.TxStateFailed:
+			// Terminal failure statuses that require balance credit-back
+			zap.L().Warn("Withdrawal failed with terminal status - crediting back",
+				zap.String("transaction_id", tx.Id),
+				zap.String("status", tx.Status),
+				zap.String("symbol", tx.Symbol),
+				zap.String("amount", tx.Amount),
+				zap.Time("created_at", tx.CreatedAt))
+			return d.handleFailedWithdrawal(ctx, tx, wallet)
+		case models.TxStatePending:
+			// OTHER_TRANSACTION_STATUS: treat as a pending withdrawal.
+			// Try to match to a user via idempotency key; if not, use the platform account.
+			// Funds move to the withdrawal pending account (same as WITHDRAWAL_INITIATED).
+			return d.handlePendingWithdrawal(ctx, tx, wallet)
+		default:
+			return d.handleCompletedWithdrawal(ctx, tx, wallet, from == models.TxStatePending)
+		}
+	})
+}
 
+// handleCompletedWithdrawal settles a TRANSACTION_DONE withdrawal. fromPending
+// is set when the lifecycle shows the listener booked its pending step.
+func (d *SendReceiveListener) handleCompletedWithdrawal(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo, fromPending bool) (string, error) {
 	amount, err := decimal.NewFromString(tx.Amount)
 	if err != nil {
-		return fmt.Errorf("invalid amount: %w", err)
+		return "", fmt.Errorf("invalid amount: %w", err)
 	}
 	if amount.LessThan(decimal.Zero) {
 		amount = amount.Neg()
 	}
 	if amount.IsZero() {
-		d.markTransactionProcessed(tx.Id)
-		return nil
+		return lifecycle.ActionNone, nil
 	}
 
 	canonicalSymbol := normalizeSymbol(tx.Symbol)
@@ -94,7 +85,7 @@ func (d *SendReceiveListener) processWithdrawal(ctx context.Context, tx models.P
 	// Try to match user: 1) destination address, 2) idempotency key.
 	var userId string
 	if destAddr != "" {
-		user, _, findErr := d.dbService.FindUserByAddress(ctx, destAddr)
+		user, _, findErr := d.dbService.FindUserByAddress(ctx, destAddr, tx.TransferTo.Memo)
 		if findErr == nil && user != nil {
 			userId = user.Id
 		}
@@ -127,22 +118,40 @@ func (d *SendReceiveListener) processWithdrawal(ctx context.Context, tx models.P
 
 	// Check if a WITHDRAWAL_INITIATED exists for this withdrawal reference.
 	// If yes -> confirm from pending (3-phase). If no -> direct debit from user.
-	hasPending, _ := d.dbService.HasPendingWithdrawal(ctx, tx.IdempotencyKey)
+	// The lifecycle only knows about pending steps the listener booked, so
+	// withdrawals initiated through the CLI are still found by reference.
+	hasPending := fromPending
+	if !hasPending {
+		hasPending, _ = d.dbService.HasPendingWithdrawal(ctx, tx.IdempotencyKey)
+	}
 	if !hasPending {
 		// Also check by Prime transaction ID.
 		hasPending, _ = d.dbService.HasPendingWithdrawal(ctx, tx.Id)
 	}
 
+	action := lifecycle.ActionConfirmPending
 	if hasPending {
 		// Normal 3-phase: pending -> wallet.
 		zap.L().Info("Found pending transaction, confirming from pending",
 			zap.String("transaction_id", tx.Id))
 
-		err = d.apiService.ConfirmWithdrawal(ctx, userId, canonicalSymbol, amount, tx.IdempotencyKey, tx.Id)
-		if err != nil && !errors.Is(err, store.ErrDuplicateTransaction) {
-			return fmt.Errorf("failed to confirm withdrawal from pending: %w", err)
+		confirmCtx := models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{
+			TransactionId:   tx.TransactionId,
+			Network:         tx.Network,
+			NetworkFees:     tx.NetworkFees,
+			Fees:            tx.Fees,
+			PrimeApiSymbol:  tx.Symbol,
+			WalletId:        tx.WalletId,
+			TransactionTime: txTime,
+		})
+		err = d.apiService.ConfirmWithdrawal(confirmCtx, userId, canonicalSymbol, amount, tx.IdempotencyKey, tx.Id)
+		if errors.Is(err, store.ErrDuplicateTransaction) {
+			action = lifecycle.ActionAlreadyBooked
+		} else if err != nil {
+			return "", fmt.Errorf("failed to confirm withdrawal from pending: %w", err)
 		}
 	} else {
+		action = lifecycle.ActionDebitDirect
 		// No pending -- direct debit from user to wallet (with overdraft).
 		zap.L().Info("No pending transaction found, debiting user directly",
 			zap.String("transaction_id", tx.Id),
@@ -159,15 +168,15 @@ func (d *SendReceiveListener) processWithdrawal(ctx context.Context, tx models.P
 			Network:            tx.Network,
 			PrimeTxId:          tx.TransactionId,
 			IdempotencyKey:     tx.IdempotencyKey,
+			NetworkFees:        tx.NetworkFees,
+			Fees:               tx.Fees,
 			TransactionTime:    txTime,
 		})
 		if dErr != nil {
-			return fmt.Errorf("failed to record confirmed withdrawal: %w", dErr)
+			return "", fmt.Errorf("failed to record confirmed withdrawal: %w", dErr)
 		}
 	}
 
-	d.markTransactionProcessed(tx.Id)
-
 	zap.L().Info("Withdrawal confirmed successfully",
 		zap.String("transaction_id", tx.Id),
 		zap.String("user_id", userId),
@@ -176,24 +185,23 @@ func (d *SendReceiveListener) processWithdrawal(ctx context.Context, tx models.P
 		zap.String("destination", destAddr),
 		zap.Time("processed_at", time.Now()))
 
-	return nil
+	return action, nil
 }
 
 // handlePendingWithdrawal processes a withdrawal with OTHER_TRANSACTION_STATUS
 // as a pending withdrawal. Tries to match to a user via idempotency key;
 // falls back to the platform account. In both cases, funds move to the
 // portfolio's withdrawal pending account via ProcessWithdrawal.
-func (d *SendReceiveListener) handlePendingWithdrawal(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) error {
+func (d *SendReceiveListener) handlePendingWithdrawal(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) (string, error) {
 	amount, err := decimal.NewFromString(tx.Amount)
 	if err != nil {
-		return fmt.Errorf("invalid amount: %w", err)
+		return "", fmt.Errorf("invalid amount: %w", err)
 	}
 	if amount.LessThan(decimal.Zero) {
 		amount = amount.Neg()
 	}
 	if amount.IsZero() {
-		d.markTransactionProcessed(tx.Id)
-		return nil
+		return lifecycle.ActionNone, nil
 	}
 
 	canonicalSymbol := normalizeSymbol(tx.Symbol)
@@ -221,7 +229,7 @@ func (d *SendReceiveListener) handlePendingWithdrawal(ctx context.Context, tx mo
 
 	// 1. Check if the destination address belongs to a known user.
 	if destAddr != "" {
-		user, _, findErr := d.dbService.FindUserByAddress(ctx, destAddr)
+		user, _, findErr := d.dbService.FindUserByAddress(ctx, destAddr, tx.TransferTo.Memo)
 		if findErr == nil && user != nil {
 			userId = user.Id
 			zap.L().Info("Pending withdrawal -- matched user by destination address",
@@ -251,18 +259,31 @@ func (d *SendReceiveListener) handlePendingWithdrawal(ctx context.Context, tx mo
 			zap.String("amount", amount.String()),
 			zap.String("destination", destAddr))
 
-		err = d.dbService.ProcessWithdrawal(ctx, userId, canonicalSymbol, amount, tx.Id)
+		withdrawalCtx := models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{
+			TransactionId:   tx.TransactionId,
+			Network:         tx.Network,
+			NetworkFees:     tx.NetworkFees,
+			Fees:            tx.Fees,
+			PrimeApiSymbol:  tx.Symbol,
+			WalletId:        tx.WalletId,
+			TransactionTime: txTime,
+		})
+		err = d.dbService.ProcessWithdrawal(withdrawalCtx, userId, canonicalSymbol, amount, tx.Id)
 		if err != nil {
 			if errors.Is(err, store.ErrDuplicateTransaction) {
-				d.markTransactionProcessed(tx.Id)
-				return nil
+				return lifecycle.ActionAlreadyBooked, nil
+			}
+			if errors.Is(err, store.ErrUserFrozen) {
+				// Prime already executed it; record it against the wallet and leave the frozen balance untouched
+				zap.L().Warn("Withdrawal for frozen user, falling through to wallet debit",
+					zap.String("user_id", userId), zap.String("transaction_id", tx.Id))
+			} else {
+				// If user doesn't have funds (e.g. deposit not yet processed), fall through to wallet.
+				zap.L().Warn("User withdrawal failed (insufficient funds?), falling through to wallet debit",
+					zap.String("user_id", userId), zap.Error(err))
 			}
-			// If user doesn't have funds (e.g. deposit not yet processed), fall through to wallet.
-			zap.L().Warn("User withdrawal failed (insufficient funds?), falling through to wallet debit",
-				zap.String("user_id", userId), zap.Error(err))
 		} else {
-			d.markTransactionProcessed(tx.Id)
-			return nil
+			return lifecycle.ActionDebitPending, nil
 		}
 	}
 
@@ -283,20 +304,23 @@ func (d *SendReceiveListener) handlePendingWithdrawal(ctx context.Context, tx mo
 		WalletId:           wallet.Id,
 		DestinationAddress: destAddr,
 		IdempotencyKey:     tx.IdempotencyKey,
+		Network:            tx.Network,
+		PrimeTxId:          tx.TransactionId,
+		NetworkFees:        tx.NetworkFees,
+		Fees:               tx.Fees,
 		TransactionTime:    txTime,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to process pending withdrawal from wallet: %w", err)
+		return "", fmt.Errorf("failed to process pending withdrawal from wallet: %w", err)
 	}
-	d.markTransactionProcessed(tx.Id)
-	return nil
+	return lifecycle.ActionWalletPending, nil
 }
 
 // handleFailedWithdrawal credits back a withdrawal that failed on-chain
-func (d *SendReceiveListener) handleFailedWithdrawal(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) error {
+func (d *SendReceiveListener) handleFailedWithdrawal(ctx context.Context, tx models.PrimeTransaction, wallet models.WalletInfo) (string, error) {
 	amount, err := decimal.NewFromString(tx.Amount)
 	if err != nil {
-		return fmt.Errorf("invalid amount: %w", err)
+		return "", fmt.Errorf("invalid amount: %w", err)
 	}
 
 	if amount.LessThan(decimal.Zero) {
@@ -307,7 +331,7 @@ func (d *SendReceiveListener) handleFailedWithdrawal(ctx context.Context, tx mod
 		zap.L().Debug("Skipping zero amount failed withdrawal",
 			zap.String("transaction_id", tx.Id),
 			zap.String("amount", amount.String()))
-		return nil
+		return lifecycle.ActionNone, nil
 	}
 
 	userId, err := d.findUserByIdempotencyKeyPrefix(ctx, tx.IdempotencyKey)
@@ -334,22 +358,25 @@ func (d *SendReceiveListener) handleFailedWithdrawal(ctx context.Context, tx mod
 			WalletId:           wallet.Id,
 			DestinationAddress: destAddr,
 			IdempotencyKey:     tx.IdempotencyKey,
+			Network:            tx.Network,
+			PrimeTxId:          tx.TransactionId,
+			NetworkFees:        tx.NetworkFees,
+			Fees:               tx.Fees,
 			TransactionTime:    tx.CreatedAt,
 		}); pErr != nil {
 			zap.L().Error("Failed to record platform-level failed withdrawal",
 				zap.String("transaction_id", tx.Id),
 				zap.Error(pErr))
-			return fmt.Errorf("failed to record platform-level failed withdrawal: %w", pErr)
+			return "", fmt.Errorf("failed to record platform-level failed withdrawal: %w", pErr)
 		}
 
-		d.markTransactionProcessed(tx.Id)
 		zap.L().Info("Platform-level failed withdrawal recorded (initiation + reversal)",
 			zap.String("transaction_id", tx.Id),
 			zap.String("status", tx.Status),
 			zap.String("asset", normalizeSymbol(tx.Symbol)),
 			zap.String("amount", amount.String()),
 			zap.String("destination", destAddr))
-		return nil
+		return lifecycle.ActionRecordFailure, nil
 	}
 
 	// Normalize symbol: Prime API returns network-specific symbols like "BASEUSDC" or "USDC"
@@ -374,7 +401,6 @@ func (d *SendReceiveListener) handleFailedWithdrawal(ctx context.Context, tx mod
 		zap.L().Info("Failed withdrawal reverted via native RevertTransaction",
 			zap.String("transaction_id", tx.Id),
 			zap.String("idempotency_key", tx.IdempotencyKey))
-		d.markTransactionProcessed(tx.Id)
 
 		zap.L().Info("Failed withdrawal credited back successfully",
 			zap.String("transaction_id", tx.Id),
@@ -383,7 +409,7 @@ func (d *SendReceiveListener) handleFailedWithdrawal(ctx context.Context, tx mod
 			zap.String("amount", amount.String()),
 			zap.String("status", tx.Status),
 			zap.Time("processed_at", time.Now()))
-		return nil
+		return lifecycle.ActionRevert, nil
 	}
 
 	// For Formance: if revert failed because the transaction wasn't found or was
@@ -392,35 +418,44 @@ func (d *SendReceiveListener) handleFailedWithdrawal(ctx context.Context, tx mod
 		zap.L().Info("No pending withdrawal transaction found to revert -- skipping",
 			zap.String("transaction_id", tx.Id),
 			zap.String("idempotency_key", tx.IdempotencyKey))
-		d.markTransactionProcessed(tx.Id)
-		return nil
+		return lifecycle.ActionNone, nil
 	}
 
 	// Fall back to compensating transaction (SQLite only -- RevertTransaction
-	// returns "not supported" for SQLite, triggering this path).
+	// returns ErrNotSupported for SQLite, triggering this path).
 	zap.L().Debug("Native revert unavailable, using compensating transaction",
 		zap.Error(revertErr))
 
-	result, err := d.apiService.CreditBackFailedWithdrawal(ctx, userId, canonicalSymbol, amount, tx.IdempotencyKey)
+	txTime := tx.CompletedAt
+	if txTime.IsZero() {
+		txTime = tx.CreatedAt
+	}
+	reversalCtx := models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{
+		TransactionId:   tx.TransactionId,
+		Network:         tx.Network,
+		NetworkFees:     tx.NetworkFees,
+		Fees:            tx.Fees,
+		PrimeApiSymbol:  tx.Symbol,
+		WalletId:        tx.WalletId,
+		TransactionTime: txTime,
+	})
+	result, err := d.apiService.CreditBackFailedWithdrawal(reversalCtx, userId, canonicalSymbol, amount, tx.IdempotencyKey)
 	if err != nil {
-		return fmt.Errorf("failed to credit back failed withdrawal: %w", err)
+		return "", fmt.Errorf("failed to credit back failed withdrawal: %w", err)
 	}
 
 	if !result.Success {
 		if strings.Contains(result.Error, "duplicate transaction") {
 			zap.L().Info("Failed withdrawal reversal already processed - skipping",
 				zap.String("transaction_id", tx.Id))
-			d.markTransactionProcessed(tx.Id)
-			return nil
+			return lifecycle.ActionAlreadyBooked, nil
 		}
 		zap.L().Error("Failed withdrawal credit-back processing failed",
 			zap.String("transaction_id", tx.Id),
 			zap.String("error", result.Error))
-		return fmt.Errorf("failed withdrawal credit-back failed: %s", result.Error)
+		return "", fmt.Errorf("failed withdrawal credit-back failed: %s", result.Error)
 	}
 
-	d.markTransactionProcessed(tx.Id)
-
 	zap.L().Info("Failed withdrawal credited back successfully",
 		zap.String("transaction_id", tx.Id),
 		zap.String("user_id", result.UserId),
@@ -430,26 +465,11 @@ func (d *SendReceiveListener) handleFailedWithdrawal(ctx context.Context, tx mod
 		zap.String("status", tx.Status),
 		zap.Time("processed_at", time.Now()))
 
-	return nil
-}
-
-// symbolMapping maps Prime API's network-specific symbols to canonical symbols
-var symbolMapping = map[string]string{
-	// USDC variants (canonical + network-specific)
-	"USDC":     "USDC",
-	"SPLUSDC":  "USDC",
-	"AVAUSDC":  "USDC",
-	"ARBUSDC":  "USDC",
-	"BASEUSDC": "USDC",
-
-	// ETH variants
-	"ETH":     "ETH",
-	"BASEETH": "ETH",
+	return lifecycle.ActionCreditBack, nil
 }
 
+// normalizeSymbol maps Prime API's network-specific symbols (e.g. "BASEUSDC") to
+// canonical ones via the asset registry
 func normalizeSymbol(symbol string) string {
-	if canonical, ok := symbolMapping[symbol]; ok {
-		return canonical
-	}
-	return symbol
+	return assets.Current().Canonical(symbol)
 }
@@ -0,0 +1,76 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Allowlist audit log actions
+const (
+	AllowlistActionAdd    = "add"
+	AllowlistActionRemove = "remove"
+)
+
+// AllowlistEntry is a withdrawal destination approved for a user. Withdrawals to it
+// are refused until ActiveAt, the end of the cooldown that starts when it is added.
+// A non-empty Asset or Network limits the entry to withdrawals of that asset or on
+// that network; an empty one matches any. Tag is the destination tag or memo, which
+// must match exactly, so a shared exchange address is only allowlisted for one tag.
+type AllowlistEntry struct {
+	UserId           string     `json:"user_id"`
+	Address          string     `json:"address"`
+	Tag              string     `json:"tag,omitempty"`
+	Asset            string     `json:"asset,omitempty"`
+	Network          string     `json:"network,omitempty"`
+	AddressBookState string     `json:"address_book_state,omitempty"`
+	AddedBy          string     `json:"added_by"`
+	Note             string     `json:"note,omitempty"`
+	AddedAt          time.Time  `json:"added_at"`
+	ActiveAt         time.Time  `json:"active_at"`
+	RemovedAt        *time.Time `json:"removed_at,omitempty"`
+}
+
+// Usable reports whether the entry is live and past its cooldown at now
+func (e *AllowlistEntry) Usable(now time.Time) bool {
+	return e.RemovedAt == nil && !now.Before(e.ActiveAt)
+}
+
+// Covers reports whether the entry applies to withdrawals of asset on network
+func (e *AllowlistEntry) Covers(asset, network string) bool {
+	return (e.Asset == "" || strings.EqualFold(e.Asset, asset)) && (e.Network == "" || e.Network == network)
+}
+
+// AllowlistDestination formats an allowlisted address and its tag, if any
+func AllowlistDestination(address, tag string) string {
+	if tag == "" {
+		return address
+	}
+	return address + " (tag " + tag + ")"
+}
+
+// AllowlistEvent is one entry in a user's allowlist audit log
+type AllowlistEvent struct {
+	UserId    string    `json:"user_id"`
+	Address   string    `json:"address"`
+	Tag       string    `json:"tag,omitempty"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -24,19 +24,30 @@ import (
 
 // UserBalance represents a user's balance for a specific asset
 type UserBalance struct {
-	Asset   string          `json:"asset"`
-	Balance decimal.Decimal `json:"balance"`
+	Asset     string          `json:"asset"`
+	Balance   decimal.Decimal `json:"balance"` // total, including held funds
+	Held      decimal.Decimal `json:"held"`
+	Available decimal.Decimal `json:"available"`
 }
 
 // TransactionRecord represents a transaction in the user's history
 type TransactionRecord struct {
-	Id          string          `json:"id"`
-	Type        string          `json:"type"` // "deposit", "withdrawal"
-	Asset       string          `json:"asset"`
-	Amount      decimal.Decimal `json:"amount"`
-	Address     string          `json:"address,omitempty"`
-	Status      string          `json:"status"`
-	ProcessedAt time.Time       `json:"processed_at"`
+	Id          string            `json:"id"`
+	UserId      string            `json:"user_id,omitempty"`
+	Type        string            `json:"type"` // "deposit", "withdrawal"
+	Asset       string            `json:"asset"`
+	Amount      decimal.Decimal   `json:"amount"`
+	Address     string            `json:"address,omitempty"`
+	Status      string            `json:"status"`
+	ProcessedAt time.Time         `json:"processed_at"`
+	EffectiveAt time.Time         `json:"effective_at"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// TransactionSearchResult is one page of a transaction search
+type TransactionSearchResult struct {
+	Transactions []TransactionRecord `json:"transactions"`
+	NextCursor   string              `json:"next_cursor,omitempty"`
 }
 
 // DepositResult represents the result of processing a deposit
@@ -46,5 +57,9 @@ type DepositResult struct {
 	Asset      string          `json:"asset,omitempty"`
 	Amount     decimal.Decimal `json:"amount,omitempty"`
 	NewBalance decimal.Decimal `json:"new_balance,omitempty"`
-	Error      string          `json:"error,omitempty"`
+	// Quarantined is set when the user was frozen and the deposit went to quarantine
+	Quarantined bool `json:"quarantined,omitempty"`
+	// Suspense is set when the address was retired or its user inactive and the deposit went to suspense
+	Suspense bool   `json:"suspense,omitempty"`
+	Error    string `json:"error,omitempty"`
 }
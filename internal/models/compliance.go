@@ -0,0 +1,76 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import "time"
+
+// Freeze reason codes recorded with every freeze and unfreeze
+const (
+	FreezeReasonCompliance      = "compliance"
+	FreezeReasonFraud           = "fraud"
+	FreezeReasonLegalOrder      = "legal_order"
+	FreezeReasonCustomerRequest = "customer_request"
+	FreezeReasonReviewCleared   = "review_cleared"
+	FreezeReasonOther           = "other"
+)
+
+// FreezeReasons lists the accepted reason codes
+var FreezeReasons = []string{
+	FreezeReasonCompliance,
+	FreezeReasonFraud,
+	FreezeReasonLegalOrder,
+	FreezeReasonCustomerRequest,
+	FreezeReasonReviewCleared,
+	FreezeReasonOther,
+}
+
+// ValidFreezeReason reports whether code is one of FreezeReasons
+func ValidFreezeReason(code string) bool {
+	for _, r := range FreezeReasons {
+		if r == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Freeze audit log actions
+const (
+	FreezeActionFreeze   = "freeze"
+	FreezeActionUnfreeze = "unfreeze"
+)
+
+// FreezeStatus is a user's current freeze state. Reason, Actor and Note describe the
+// most recent freeze or unfreeze.
+type FreezeStatus struct {
+	UserId    string    `json:"user_id"`
+	Frozen    bool      `json:"frozen"`
+	Reason    string    `json:"reason,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// FreezeEvent is one entry in a user's freeze audit log
+type FreezeEvent struct {
+	UserId    string    `json:"user_id"`
+	Action    string    `json:"action"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -24,6 +24,10 @@ type Config struct {
 	Database    DatabaseConfig
 	Formance    FormanceConfig
 	Listener    ListenerConfig
+	Pricing     PricingConfig
+	CustodyFee  CustodyFeeConfig
+	Withdrawal  WithdrawalConfig
+	Assets      AssetsConfig
 }
 
 // FormanceConfig holds Formance Stack connection settings.
@@ -51,4 +55,48 @@ type ListenerConfig struct {
 	PollingInterval time.Duration
 	CleanupInterval time.Duration
 	AssetsFile      string
+
+	DiscoveryInterval    time.Duration // how often to rediscover wallets from Prime; 0 disables
+	AssetsReloadInterval time.Duration // how often to check the assets file for changes; 0 disables
+
+	PollConcurrency int           // maximum concurrent wallet polls per listener
+	MaxPollInterval time.Duration // longest an idle wallet goes between polls
+	StatusAddr      string        // address serving scheduler stats as JSON; empty disables
+
+	LeaderElection     bool          // run as leader or hot standby per portfolio
+	InstanceId         string        // lease holder identity; defaults to host name and pid
+	LeaseTTL           time.Duration // how long a leader's lease lasts without renewal
+	LeaseRenewInterval time.Duration // how often leaders renew and standbys retry
+
+	RetryPolicy              RetryPolicy // backoff for failed transactions before they are dead-lettered
+	DeadLetterAlertThreshold int         // dead-letter queue depth from which each new dead letter alerts
+	DeadLetterWebhook        string      // URL dead-letter alerts are POSTed to as JSON; empty only logs them
+}
+
+// PricingConfig selects the price source used for USD valuations
+type PricingConfig struct {
+	Source   string // "" (disabled), "static", "historical" or "http"
+	File     string // static YAML/CSV prices or historical daily closes CSV
+	URL      string // price service base URL for the http source
+	CacheTTL time.Duration
+}
+
+// CustodyFeeConfig sets the annual custody fee charged on held balances
+type CustodyFeeConfig struct {
+	AnnualBps int64 // basis points per year of the average daily balance
+	Precision int32 // decimals fees are rounded down to
+}
+
+// WithdrawalConfig controls which destinations withdrawals may be sent to
+type WithdrawalConfig struct {
+	EnforceAllowlist  bool          // refuse destinations not on the user's allowlist
+	AllowlistCooldown time.Duration // how long a newly allowlisted address stays locked
+	VerifyAddressBook bool          // also require a Prime address book entry in one of AddressBookStates
+	AddressBookStates []string
+}
+
+// AssetsConfig locates the asset registry
+type AssetsConfig struct {
+	File             string // assets.yaml extending the built-in registry
+	RefreshFromPrime bool   // update networks and tag requirements from Prime at startup
 }
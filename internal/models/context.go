@@ -20,6 +20,7 @@ type PrimeDepositContext struct {
 	Network          string    // raw network from Prime (e.g. "base-mainnet")
 	PrimeApiSymbol   string    // raw symbol before normalization (e.g. "BASEUSDC")
 	WalletId         string    // source Prime wallet ID
+	DestinationTag   string    // destination tag or memo on tag-based networks
 	CreatedAt        string    // Prime created_at as string (for metadata)
 	CompletedAt      string    // Prime completed_at as string (for metadata)
 	TransactionTime  time.Time // effective transaction time for the ledger entry
@@ -35,3 +36,12 @@ func GetPrimeDepositContext(ctx context.Context) *PrimeDepositContext {
 	pdc, _ := ctx.Value(primeContextKey{}).(*PrimeDepositContext)
 	return pdc
 }
+
+// DepositTag returns the destination tag of the deposit in ctx, or "" when the
+// deposit carries none.
+func DepositTag(ctx context.Context) string {
+	if pdc := GetPrimeDepositContext(ctx); pdc != nil {
+		return pdc.DestinationTag
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TaxLot is a quantity of an asset a user acquired in one ledger posting, with its USD cost
+type TaxLot struct {
+	Id            string          `json:"id"` // transaction id and posting sequence
+	UserId        string          `json:"user_id"`
+	Asset         string          `json:"asset"`
+	TransactionId string          `json:"transaction_id"`
+	AcquiredAt    time.Time       `json:"acquired_at"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	Remaining     decimal.Decimal `json:"remaining"`
+	UnitCost      decimal.Decimal `json:"unit_cost"`
+	Priced        bool            `json:"priced"` // false when no price existed at acquisition; cost is zero
+}
+
+// LotDisposal is the part of one lot consumed by a user debit and the gain realized on it.
+// Uncovered disposals exceed every recorded lot and carry zero cost basis.
+type LotDisposal struct {
+	UserId        string          `json:"user_id"`
+	Asset         string          `json:"asset"`
+	LotId         string          `json:"lot_id,omitempty"`
+	TransactionId string          `json:"transaction_id"`
+	AcquiredAt    time.Time       `json:"acquired_at"`
+	DisposedAt    time.Time       `json:"disposed_at"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	CostBasis     decimal.Decimal `json:"cost_basis"`
+	Proceeds      decimal.Decimal `json:"proceeds"`
+	Gain          decimal.Decimal `json:"gain"`
+	Term          string          `json:"term"` // "short" or "long"
+	Priced        bool            `json:"priced"`
+	Uncovered     bool            `json:"uncovered,omitempty"`
+}
+
+// RealizedGainsReport lists a tax year's disposals with per-user, per-asset totals
+type RealizedGainsReport struct {
+	Year      int                  `json:"year"`
+	Method    string               `json:"method"`
+	Disposals []LotDisposal        `json:"disposals"`
+	Totals    []RealizedGainsTotal `json:"totals"`
+}
+
+// RealizedGainsTotal sums one user's disposals of one asset in a tax year
+type RealizedGainsTotal struct {
+	UserId         string          `json:"user_id"`
+	Asset          string          `json:"asset"`
+	Quantity       decimal.Decimal `json:"quantity"`
+	CostBasis      decimal.Decimal `json:"cost_basis"`
+	Proceeds       decimal.Decimal `json:"proceeds"`
+	ShortTermGain  decimal.Decimal `json:"short_term_gain"`
+	LongTermGain   decimal.Decimal `json:"long_term_gain"`
+	Gain           decimal.Decimal `json:"gain"`
+	UnpricedItems  int             `json:"unpriced_items,omitempty"`
+	UncoveredItems int             `json:"uncovered_items,omitempty"`
+}
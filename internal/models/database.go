@@ -40,6 +40,7 @@ type Address struct {
 	Address           string    `db:"address"`
 	WalletId          string    `db:"wallet_id"`
 	AccountIdentifier string    `db:"account_identifier"`
+	Tag               string    `db:"tag"`
 	CreatedAt         time.Time `db:"created_at"`
 }
 
@@ -57,17 +58,37 @@ type AccountBalance struct {
 
 // Transaction represents immutable transaction history (cold data)
 type Transaction struct {
-	Id                    string          `db:"id"`
-	UserId                string          `db:"user_id"`
-	Asset                 string          `db:"asset"`
-	TransactionType       string          `db:"transaction_type"`
-	Amount                decimal.Decimal `db:"amount"`
-	BalanceBefore         decimal.Decimal `db:"balance_before"`
-	BalanceAfter          decimal.Decimal `db:"balance_after"`
-	ExternalTransactionId string          `db:"external_transaction_id"`
-	Address               string          `db:"address"`
-	Reference             string          `db:"reference"`
-	Status                string          `db:"status"`
-	CreatedAt             time.Time       `db:"created_at"`
-	ProcessedAt           time.Time       `db:"processed_at"`
+	Id                    string            `db:"id"`
+	UserId                string            `db:"user_id"`
+	Asset                 string            `db:"asset"`
+	TransactionType       string            `db:"transaction_type"`
+	Amount                decimal.Decimal   `db:"amount"`
+	BalanceBefore         decimal.Decimal   `db:"balance_before"`
+	BalanceAfter          decimal.Decimal   `db:"balance_after"`
+	ExternalTransactionId string            `db:"external_transaction_id"`
+	Address               string            `db:"address"`
+	Reference             string            `db:"reference"`
+	Status                string            `db:"status"`
+	CreatedAt             time.Time         `db:"created_at"`
+	ProcessedAt           time.Time         `db:"processed_at"`
+	EffectiveAt           time.Time         `db:"effective_at"` // when the money moved at Prime (completed_at, else created_at)
+	Network               string            `db:"network"`
+	SourceAddress         string            `db:"source_address"`
+	TxHash                string            `db:"tx_hash"` // on-chain transaction hash
+	NetworkFees           string            `db:"network_fees"`
+	Fees                  string            `db:"fees"`
+	Metadata              map[string]string `db:"-"` // Prime context (prime_tx_id, idempotency_key, ...); nil when none
+}
+
+// TrialBalanceLine is the total debits and credits posted to one ledger account in one asset
+type TrialBalanceLine struct {
+	Account string          `json:"account"`
+	Asset   string          `json:"asset"`
+	Debits  decimal.Decimal `json:"debits"`
+	Credits decimal.Decimal `json:"credits"`
+}
+
+// Net returns debits minus credits
+func (l TrialBalanceLine) Net() decimal.Decimal {
+	return l.Debits.Sub(l.Credits)
 }
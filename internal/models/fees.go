@@ -0,0 +1,67 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Custody fee line statuses
+const (
+	FeeStatusPreview             = "preview"
+	FeeStatusCharged             = "charged"
+	FeeStatusAlreadyCharged      = "already_charged"
+	FeeStatusInsufficientBalance = "insufficient_balance"
+	FeeStatusBelowMinimum        = "below_minimum"
+	FeeStatusFailed              = "failed"
+)
+
+// CustodyFeeReport lists the custody fee for every user and asset held during a
+// period: the average daily balance, the prorated fee, and what happened when it was
+// charged.
+type CustodyFeeReport struct {
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"` // exclusive
+	Days      int               `json:"days"`
+	AnnualBps int64             `json:"annual_bps"`
+	Precision int32             `json:"precision"`
+	Lines     []CustodyFeeLine  `json:"lines"`
+	Totals    []CustodyFeeTotal `json:"totals"`
+}
+
+// CustodyFeeLine is one user's custody fee in one asset. Description is the line item
+// shown on the user's statement.
+type CustodyFeeLine struct {
+	UserId              string          `json:"user_id"`
+	Asset               string          `json:"asset"`
+	AverageDailyBalance decimal.Decimal `json:"average_daily_balance"`
+	Fee                 decimal.Decimal `json:"fee"`
+	Reference           string          `json:"reference"`
+	Description         string          `json:"description"`
+	Status              string          `json:"status"`
+	Error               string          `json:"error,omitempty"`
+}
+
+// CustodyFeeTotal sums the fees of one asset, and the part actually charged
+type CustodyFeeTotal struct {
+	Asset   string          `json:"asset"`
+	Fees    decimal.Decimal `json:"fees"`
+	Charged decimal.Decimal `json:"charged"`
+	Users   int             `json:"users"`
+}
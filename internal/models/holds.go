@@ -0,0 +1,62 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Hold statuses
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+)
+
+// Hold reserves part of a user's balance: it reduces the available balance but not
+// the total until it is captured (the funds leave the user) or released or expired
+// (the funds become available again).
+type Hold struct {
+	Id        string          `json:"id"`
+	UserId    string          `json:"user_id"`
+	Asset     string          `json:"asset"`
+	Amount    decimal.Decimal `json:"amount"`
+	Captured  decimal.Decimal `json:"captured"`
+	Status    string          `json:"status"`
+	Reason    string          `json:"reason,omitempty"`
+	ExpiresAt time.Time       `json:"expires_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	SettledAt time.Time       `json:"settled_at,omitempty"` // when captured, released or expired
+}
+
+// Expired reports whether an active hold is past its expiry at now.
+func (h *Hold) Expired(now time.Time) bool {
+	return h.Status == HoldStatusActive && !h.ExpiresAt.IsZero() && !now.Before(h.ExpiresAt)
+}
+
+// BalanceDetail splits a user's balance in one asset into the part reserved by
+// active holds and the part available to spend. Total = Held + Available.
+type BalanceDetail struct {
+	UserId    string          `json:"user_id"`
+	Asset     string          `json:"asset"`
+	Total     decimal.Decimal `json:"total"`
+	Held      decimal.Decimal `json:"held"`
+	Available decimal.Decimal `json:"available"`
+}
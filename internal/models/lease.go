@@ -0,0 +1,65 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// Lease grants one holder exclusive use of a named resource, such as the
+// listener for a portfolio, until ExpiresAt. Token increases every time the
+// lease changes hands and fences writes made by earlier holders.
+type Lease struct {
+	Name       string    `json:"name"`
+	Holder     string    `json:"holder"`
+	Token      int64     `json:"token"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the lease has lapsed at now
+func (l *Lease) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// Fence returns the fencing token writes under this lease carry
+func (l *Lease) Fence() LeaseFence {
+	return LeaseFence{Name: l.Name, Holder: l.Holder, Token: l.Token}
+}
+
+// LeaseFence identifies the lease a write is made under. Backends refuse the
+// write unless the lease is still held by Holder with Token.
+type LeaseFence struct {
+	Name   string
+	Holder string
+	Token  int64
+}
+
+type leaseFenceKey struct{}
+
+// WithLeaseFence attaches a fencing token to a context.
+func WithLeaseFence(ctx context.Context, fence LeaseFence) context.Context {
+	return context.WithValue(ctx, leaseFenceKey{}, &fence)
+}
+
+// GetLeaseFence retrieves the fencing token from context, or nil if absent.
+func GetLeaseFence(ctx context.Context) *LeaseFence {
+	fence, _ := ctx.Value(leaseFenceKey{}).(*LeaseFence)
+	return fence
+}
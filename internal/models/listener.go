@@ -24,12 +24,57 @@ type WalletInfo struct {
 	AssetSymbol string `json:"asset_symbol"`
 }
 
+// Wallet membership event types
+const (
+	WalletEventAdded   = "added"
+	WalletEventRemoved = "removed"
+)
+
+// WalletEvent records a wallet joining or leaving the listener's monitored set
+type WalletEvent struct {
+	Type        string     `json:"type"`
+	PortfolioId string     `json:"portfolio_id"`
+	Wallet      WalletInfo `json:"wallet"`
+	Reason      string     `json:"reason"`
+	Time        time.Time  `json:"time"`
+}
+
+// ListenerStats is a snapshot of a listener's wallet poll scheduler
+type ListenerStats struct {
+	PortfolioId   string                `json:"portfolio_id"`
+	Active        bool                  `json:"active"` // false while another instance leads
+	Concurrency   int                   `json:"concurrency"`
+	InFlight      int                   `json:"in_flight"`
+	QueueDepth    int                   `json:"queue_depth"`     // due wallets waiting for a poll slot
+	MaxLagSeconds float64               `json:"max_lag_seconds"` // longest wait past a due time
+	Polls         int64                 `json:"polls"`
+	Failures      int64                 `json:"failures"`
+	RetryQueued   int                   `json:"retry_queued"` // failed transactions waiting to be retried
+	DeadLetters   int                   `json:"dead_letters"` // failed transactions out of attempts
+	Wallets       []WalletScheduleStats `json:"wallets"`
+}
+
+// WalletScheduleStats is the poll schedule of one monitored wallet
+type WalletScheduleStats struct {
+	Id                  string    `json:"id"`
+	AssetSymbol         string    `json:"asset_symbol"`
+	IntervalSeconds     float64   `json:"interval_seconds"`
+	NextPoll            time.Time `json:"next_poll"`
+	LastPoll            time.Time `json:"last_poll"`
+	LastActivity        time.Time `json:"last_activity"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	InFlight            bool      `json:"in_flight"`
+}
+
 // PrimeTransferInfo represents the actual transfer_to structure from Prime API
 type PrimeTransferInfo struct {
 	Type              string `json:"type"`
 	Value             string `json:"value"`
 	Address           string `json:"address"`
 	AccountIdentifier string `json:"account_identifier"`
+	// Memo is the destination tag or memo on networks that share one address
+	// between accounts (XRP, XLM, ATOM). Prime reports it as the account identifier.
+	Memo string `json:"memo"`
 }
 
 // PrimeTransaction represents a transaction from Prime API with complete fields
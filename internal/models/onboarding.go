@@ -0,0 +1,53 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import "time"
+
+// User import row statuses
+const (
+	ImportStatusCreated  = "created"  // the user was created by this run
+	ImportStatusExisting = "existing" // a user with this email already existed and was reused
+	ImportStatusSkipped  = "skipped"  // completed by an earlier run, per the progress file
+	ImportStatusFailed   = "failed"
+)
+
+// UserImportResult is the outcome of importing one input row. A row succeeded when
+// Error is empty; FailedAssets lists assets whose deposit address could not be provisioned.
+type UserImportResult struct {
+	Line                int      `json:"line"`
+	Name                string   `json:"name"`
+	Email               string   `json:"email"`
+	UserId              string   `json:"user_id,omitempty"`
+	Status              string   `json:"status"`
+	DepositAddresses    int      `json:"deposit_addresses"`
+	WithdrawalAddresses int      `json:"withdrawal_addresses"`
+	FailedAssets        []string `json:"failed_assets,omitempty"`
+	Error               string   `json:"error,omitempty"`
+}
+
+// UserImportReport summarises an import run, one result per input row in file order.
+type UserImportReport struct {
+	Source    string             `json:"source"`
+	StartedAt time.Time          `json:"started_at"`
+	EndedAt   time.Time          `json:"ended_at"`
+	Created   int                `json:"created"`
+	Existing  int                `json:"existing"`
+	Skipped   int                `json:"skipped"`
+	Failed    int                `json:"failed"`
+	Results   []UserImportResult `json:"results"`
+}
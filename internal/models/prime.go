@@ -18,8 +18,9 @@ package models
 
 // Portfolio represents a Prime portfolio
 type Portfolio struct {
-	Id   string
-	Name string
+	Id       string
+	Name     string
+	EntityId string
 }
 
 // Wallet represents a Prime wallet
@@ -36,6 +37,7 @@ type DepositAddress struct {
 	Address string
 	Network string
 	Asset   string
+	Tag     string // destination tag or memo on tag-based networks
 }
 
 // AddressBookEntry represents a Prime address book entry
@@ -55,3 +57,16 @@ type Withdrawal struct {
 	Destination    string
 	IdempotencyKey string
 }
+
+// PrimeAsset is an asset Prime supports for an entity, with its networks
+type PrimeAsset struct {
+	Symbol    string
+	Precision int
+	Networks  []PrimeAssetNetwork
+}
+
+// PrimeAssetNetwork is a network Prime supports for an asset
+type PrimeAssetNetwork struct {
+	Network     string // e.g. "base-mainnet"
+	TagRequired bool
+}
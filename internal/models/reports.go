@@ -0,0 +1,130 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TrialBalanceReport lists every account's debits and credits as of a point in time
+type TrialBalanceReport struct {
+	AsOf   time.Time           `json:"as_of"`
+	Lines  []TrialBalanceLine  `json:"lines"`
+	Totals []TrialBalanceTotal `json:"totals"`
+	// Liabilities values user account balances in USD when a price source is configured
+	Liabilities *Valuation `json:"liabilities,omitempty"`
+}
+
+// TrialBalanceTotal is the per-asset sum of a trial balance; Debits equal Credits when balanced
+type TrialBalanceTotal struct {
+	Asset    string          `json:"asset"`
+	Debits   decimal.Decimal `json:"debits"`
+	Credits  decimal.Decimal `json:"credits"`
+	Balanced bool            `json:"balanced"`
+}
+
+// GeneralLedgerReport holds one ledger section per account and asset for a period
+type GeneralLedgerReport struct {
+	From     time.Time              `json:"from"`
+	To       time.Time              `json:"to"`
+	Accounts []GeneralLedgerAccount `json:"accounts"`
+}
+
+// GeneralLedgerAccount is an account's opening balance, postings and closing balance for a period
+type GeneralLedgerAccount struct {
+	Account string               `json:"account"`
+	Asset   string               `json:"asset"`
+	Opening decimal.Decimal      `json:"opening"`
+	Debits  decimal.Decimal      `json:"debits"`
+	Credits decimal.Decimal      `json:"credits"`
+	Closing decimal.Decimal      `json:"closing"`
+	Entries []GeneralLedgerEntry `json:"entries"`
+}
+
+// GeneralLedgerEntry is one posting to an account with the running balance after it
+type GeneralLedgerEntry struct {
+	TransactionId string          `json:"transaction_id"`
+	EffectiveAt   time.Time       `json:"effective_at"`
+	Counterparty  string          `json:"counterparty"`
+	ExternalTxId  string          `json:"external_tx_id,omitempty"`
+	Debit         decimal.Decimal `json:"debit"`
+	Credit        decimal.Decimal `json:"credit"`
+	Balance       decimal.Decimal `json:"balance"`
+}
+
+// MovementSummaryReport totals money moved per account type and asset for a period
+type MovementSummaryReport struct {
+	From  time.Time      `json:"from"`
+	To    time.Time      `json:"to"`
+	Lines []MovementLine `json:"lines"`
+}
+
+// MovementLine is the inflow (debits) and outflow (credits) of one account type in one asset.
+// For the user account type, In is deposits and Out is withdrawals.
+type MovementLine struct {
+	AccountType string          `json:"account_type"`
+	Asset       string          `json:"asset"`
+	In          decimal.Decimal `json:"in"`
+	Out         decimal.Decimal `json:"out"`
+	Net         decimal.Decimal `json:"net"`
+	Postings    int             `json:"postings"`
+}
+
+// Valuation prices a set of asset amounts in USD at a point in time. Assets without a
+// price are listed in Unpriced and left out of TotalUSD.
+type Valuation struct {
+	AsOf     time.Time        `json:"as_of"`
+	Assets   []AssetValuation `json:"assets"`
+	TotalUSD decimal.Decimal  `json:"total_usd"`
+	Unpriced []string         `json:"unpriced,omitempty"`
+}
+
+// AssetValuation is one asset's amount, unit price and USD value
+type AssetValuation struct {
+	Asset    string          `json:"asset"`
+	Amount   decimal.Decimal `json:"amount"`
+	Price    decimal.Decimal `json:"price"`
+	USDValue decimal.Decimal `json:"usd_value"`
+	Priced   bool            `json:"priced"`
+}
+
+// SolvencyReport compares what the ledger owes users with what it holds in Prime
+// wallets, per asset and in USD at the report time
+type SolvencyReport struct {
+	AsOf                time.Time       `json:"as_of"`
+	Lines               []SolvencyLine  `json:"lines"`
+	TotalLiabilitiesUSD decimal.Decimal `json:"total_liabilities_usd"`
+	TotalCustodyUSD     decimal.Decimal `json:"total_custody_usd"`
+	SurplusUSD          decimal.Decimal `json:"surplus_usd"`
+	Unpriced            []string        `json:"unpriced,omitempty"`
+}
+
+// SolvencyLine is one asset's user liabilities against wallet custody. Custody is the
+// net amount the ledger has moved into Prime wallets; Surplus is custody minus liabilities.
+type SolvencyLine struct {
+	Asset          string          `json:"asset"`
+	Liabilities    decimal.Decimal `json:"liabilities"`
+	Custody        decimal.Decimal `json:"custody"`
+	Surplus        decimal.Decimal `json:"surplus"`
+	Price          decimal.Decimal `json:"price"`
+	LiabilitiesUSD decimal.Decimal `json:"liabilities_usd"`
+	CustodyUSD     decimal.Decimal `json:"custody_usd"`
+	SurplusUSD     decimal.Decimal `json:"surplus_usd"`
+	Priced         bool            `json:"priced"`
+}
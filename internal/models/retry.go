@@ -0,0 +1,78 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import "time"
+
+// Retry queue states
+const (
+	RetryStateQueued    = "queued"    // waiting for its next attempt
+	RetryStateDead      = "dead"      // out of attempts; in the dead-letter queue
+	RetryStateDiscarded = "discarded" // dropped from the dead-letter queue by an operator
+)
+
+// RetryPolicy decides when a failed listener transaction is tried again
+type RetryPolicy struct {
+	BaseDelay   time.Duration // wait after the first failure
+	MaxDelay    time.Duration // longest wait between attempts
+	MaxAttempts int           // attempts before dead-lettering; 0 retries forever
+}
+
+// Delay returns the wait after the given number of failed attempts: BaseDelay,
+// doubling with each further failure, capped at MaxDelay.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Exhausted reports whether a transaction that has failed attempts times
+// belongs in the dead-letter queue
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
+
+// FailedTransaction is a Prime transaction the listener could not process,
+// kept with its full payload so it can be replayed after Prime's lookback
+// window has moved on
+type FailedTransaction struct {
+	PrimeTxId     string           `json:"prime_tx_id"`
+	PortfolioId   string           `json:"portfolio_id"`
+	Wallet        WalletInfo       `json:"wallet"`
+	Transaction   PrimeTransaction `json:"transaction"`
+	State         string           `json:"state"`
+	Attempts      int              `json:"attempts"`
+	LastError     string           `json:"last_error"`
+	NextAttemptAt time.Time        `json:"next_attempt_at"`
+	FirstFailedAt time.Time        `json:"first_failed_at"`
+	LastFailedAt  time.Time        `json:"last_failed_at"`
+	UpdatedBy     string           `json:"updated_by,omitempty"` // operator who last retried or discarded it
+	Note          string           `json:"note,omitempty"`
+}
+
+// DeadLetterAlert reports a transaction moving to a portfolio's dead-letter queue
+type DeadLetterAlert struct {
+	PortfolioId string            `json:"portfolio_id"`
+	Depth       int               `json:"depth"` // dead letters for the portfolio, including this one
+	Transaction FailedTransaction `json:"transaction"`
+	Time        time.Time         `json:"time"`
+}
@@ -0,0 +1,55 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RewardDistributionReport records how a platform reward was split between users:
+// the inputs (reward, weighting, commission), every user's weight, share and amount,
+// and a hash of the allocations that is also written to the ledger posting.
+type RewardDistributionReport struct {
+	DistributionId string                 `json:"distribution_id"`
+	RewardTxId     string                 `json:"reward_tx_id"`
+	Asset          string                 `json:"asset"`
+	RewardAmount   decimal.Decimal        `json:"reward_amount"`
+	Method         string                 `json:"method"`
+	SnapshotAt     time.Time              `json:"snapshot_at,omitempty"`
+	From           time.Time              `json:"from,omitempty"`
+	To             time.Time              `json:"to,omitempty"`
+	CommissionBps  int64                  `json:"commission_bps"`
+	Commission     decimal.Decimal        `json:"commission"`
+	Distributed    decimal.Decimal        `json:"distributed"`
+	Precision      int32                  `json:"precision"`
+	TotalWeight    decimal.Decimal        `json:"total_weight"`
+	Allocations    []RewardAllocationLine `json:"allocations"`
+	Hash           string                 `json:"hash"`
+	Posted         bool                   `json:"posted"`
+	PostedAt       time.Time              `json:"posted_at,omitempty"`
+}
+
+// RewardAllocationLine is one user's weight (balance, or balance-seconds when time
+// weighted), fraction of the total weight, and allocated amount
+type RewardAllocationLine struct {
+	UserId string          `json:"user_id"`
+	Weight decimal.Decimal `json:"weight"`
+	Share  decimal.Decimal `json:"share"`
+	Amount decimal.Decimal `json:"amount"`
+}
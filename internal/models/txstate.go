@@ -0,0 +1,63 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import "time"
+
+// Prime transactions the listener books move through an explicit lifecycle.
+// Each kind has its own states; a transaction the ledger has not seen is in
+// TxStateNew, which is never persisted.
+const (
+	TxKindDeposit    = "deposit"
+	TxKindWithdrawal = "withdrawal"
+
+	TxStateNew       = "new"
+	TxStatePending   = "pending"   // deposit parked in pending, or withdrawal debited to pending
+	TxStateCredited  = "credited"  // deposit credited to the user (terminal)
+	TxStateCompleted = "completed" // withdrawal settled (terminal)
+	TxStateFailed    = "failed"    // withdrawal failed and reversed (terminal)
+)
+
+// Outcomes recorded in a transaction's history
+const (
+	TxOutcomeApplied     = "applied"     // the ledger action ran and the state moved
+	TxOutcomeQuarantined = "quarantined" // the Prime status would have been an illegal transition; nothing was booked
+)
+
+// TxState is the current lifecycle state of a Prime transaction
+type TxState struct {
+	PrimeTxId   string    `json:"prime_tx_id"`
+	Kind        string    `json:"kind"`
+	State       string    `json:"state"`
+	PrimeStatus string    `json:"prime_status"` // Prime status that produced State
+	Quarantined bool      `json:"quarantined"`  // an illegal transition has been seen
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TxTransition is one entry in a Prime transaction's lifecycle history
+type TxTransition struct {
+	PrimeTxId   string    `json:"prime_tx_id"`
+	Kind        string    `json:"kind"`
+	FromState   string    `json:"from_state"`
+	ToState     string    `json:"to_state"`
+	PrimeStatus string    `json:"prime_status"`
+	Action      string    `json:"action"` // ledger action taken, e.g. confirm_pending
+	Outcome     string    `json:"outcome"`
+	Detail      string    `json:"detail,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
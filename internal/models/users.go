@@ -0,0 +1,38 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// UserClosure is the outcome of closing a user account.
+type UserClosure struct {
+	UserId           string         `json:"user_id"`
+	Swept            []SweptBalance `json:"swept,omitempty"`
+	RetiredAddresses int            `json:"retired_addresses"`
+	ClosedAt         time.Time      `json:"closed_at"`
+}
+
+// SweptBalance is one balance moved out of a user account when it was closed.
+type SweptBalance struct {
+	Asset  string          `json:"asset"`
+	Amount decimal.Decimal `json:"amount"`
+	To     string          `json:"to"`
+}
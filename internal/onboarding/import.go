@@ -0,0 +1,317 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// allowlistActor is recorded in the allowlist audit log for addresses added by an import
+const allowlistActor = "import-users"
+
+// Provisioner finds and creates addresses at the custodian. Returned params carry
+// everything but the UserId, which the importer fills in.
+type Provisioner interface {
+	// FindDepositAddress locates an existing deposit address on the portfolio's wallets.
+	FindDepositAddress(ctx context.Context, address string) (store.StoreAddressParams, error)
+	// CreateDepositAddress creates a new deposit address for the asset.
+	CreateDepositAddress(ctx context.Context, asset common.AssetConfig) (store.StoreAddressParams, error)
+	// WithdrawalAsset returns the asset of an external address from the address book,
+	// or "WITHDRAWAL" when the address book does not know it.
+	WithdrawalAsset(ctx context.Context, address string) (string, error)
+}
+
+// ValidationError lists every invalid row of an import. Nothing is written when an
+// import fails validation.
+type ValidationError struct {
+	Errors []RowError
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d invalid rows, first: %v", len(e.Errors), e.Errors[0])
+}
+
+// Importer creates users and their addresses. Rows are processed by Concurrency
+// workers, which bounds the number of concurrent calls to the Provisioner.
+type Importer struct {
+	Store       store.LedgerStore
+	Provisioner Provisioner
+	// Assets get a fresh deposit address for every row that lists no deposit addresses
+	Assets      []common.AssetConfig
+	Concurrency int
+	// Progress, when set, skips rows finished by an earlier run and records each row's result
+	Progress *Progress
+	// AllowlistCooldown locks newly registered withdrawal addresses on the user's allowlist
+	AllowlistCooldown time.Duration
+}
+
+// Run validates all rows, then imports them. The report has one result per row in
+// input order; a row that fails does not stop the others.
+func (im *Importer) Run(ctx context.Context, source string, rows []Row) (*models.UserImportReport, error) {
+	if errs := Validate(rows); len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+
+	report := &models.UserImportReport{
+		Source:    source,
+		StartedAt: time.Now().UTC(),
+		Results:   make([]models.UserImportResult, len(rows)),
+	}
+	workers := im.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Results[i] = im.importRow(ctx, rows[i])
+			}
+		}()
+	}
+	for i := range rows {
+		select {
+		case jobs <- i:
+			continue
+		case <-ctx.Done():
+		}
+		// Rows never dispatched are left for the next run
+		for j := i; j < len(rows); j++ {
+			report.Results[j] = failedResult(rows[j], ctx.Err())
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, r := range report.Results {
+		switch r.Status {
+		case models.ImportStatusCreated:
+			report.Created++
+		case models.ImportStatusExisting:
+			report.Existing++
+		case models.ImportStatusSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+	}
+	report.EndedAt = time.Now().UTC()
+
+	zap.L().Info("User import finished",
+		zap.String("source", source),
+		zap.Int("rows", len(rows)),
+		zap.Int("created", report.Created),
+		zap.Int("existing", report.Existing),
+		zap.Int("skipped", report.Skipped),
+		zap.Int("failed", report.Failed))
+	return report, ctx.Err()
+}
+
+func failedResult(row Row, err error) models.UserImportResult {
+	return models.UserImportResult{
+		Line:   row.Line,
+		Name:   row.Name,
+		Email:  row.Email,
+		Status: models.ImportStatusFailed,
+		Error:  err.Error(),
+	}
+}
+
+// importRow creates or reuses the row's user, assigns the listed addresses and, when
+// no deposit addresses are listed, provisions one per configured asset.
+func (im *Importer) importRow(ctx context.Context, row Row) models.UserImportResult {
+	if im.Progress != nil {
+		if prev, ok := im.Progress.Completed(row.Email); ok {
+			prev.Line, prev.Status = row.Line, models.ImportStatusSkipped
+			return prev
+		}
+	}
+
+	result := im.provisionRow(ctx, row)
+	if result.Error != "" {
+		result.Status = models.ImportStatusFailed
+		zap.L().Warn("User import row failed",
+			zap.Int("line", row.Line),
+			zap.String("email", row.Email),
+			zap.String("error", result.Error))
+	}
+	if im.Progress != nil && ctx.Err() == nil {
+		if err := im.Progress.Record(result); err != nil {
+			zap.L().Error("Failed to record import progress", zap.String("email", row.Email), zap.Error(err))
+		}
+	}
+	return result
+}
+
+func (im *Importer) provisionRow(ctx context.Context, row Row) models.UserImportResult {
+	result := models.UserImportResult{Line: row.Line, Name: row.Name, Email: row.Email}
+
+	user, err := im.Store.GetUserByEmail(ctx, row.Email)
+	if err == nil && user != nil {
+		result.Status = models.ImportStatusExisting
+	} else {
+		if user, err = im.Store.CreateUser(ctx, uuid.New().String(), row.Name, row.Email); err != nil {
+			result.Error = fmt.Sprintf("failed to create user: %v", err)
+			return result
+		}
+		result.Status = models.ImportStatusCreated
+	}
+	result.UserId = user.Id
+
+	for _, addr := range row.DepositAddresses {
+		if err := im.assignDepositAddress(ctx, user, addr); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.DepositAddresses++
+	}
+	for _, addr := range row.WithdrawalAddresses {
+		if err := im.registerWithdrawalAddress(ctx, user, addr); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.WithdrawalAddresses++
+	}
+	if len(row.DepositAddresses) > 0 {
+		return result
+	}
+
+	for _, asset := range im.Assets {
+		if err := im.provisionAsset(ctx, user, asset); err != nil {
+			zap.L().Warn("Failed to provision deposit address",
+				zap.String("user_id", user.Id),
+				zap.String("asset", asset.Symbol),
+				zap.String("network", asset.Network),
+				zap.Error(err))
+			result.FailedAssets = append(result.FailedAssets, asset.Symbol+"-"+asset.Network)
+			continue
+		}
+		result.DepositAddresses++
+	}
+	if len(result.FailedAssets) > 0 {
+		result.Error = fmt.Sprintf("failed to provision %d assets: %s", len(result.FailedAssets), strings.Join(result.FailedAssets, ", "))
+	}
+	return result
+}
+
+// checkOwner returns done when the address already belongs to user, and an error
+// when it belongs to someone else.
+func (im *Importer) checkOwner(ctx context.Context, user *models.User, address string) (done bool, err error) {
+	owner, _, err := im.Store.FindUserByAddress(ctx, address, "")
+	if err != nil {
+		return false, fmt.Errorf("failed to look up address %s: %w", address, err)
+	}
+	if owner == nil {
+		return false, nil
+	}
+	if owner.Id != user.Id {
+		return false, fmt.Errorf("address %s already belongs to %s", address, owner.Email)
+	}
+	return true, nil
+}
+
+func (im *Importer) assignDepositAddress(ctx context.Context, user *models.User, address string) error {
+	if done, err := im.checkOwner(ctx, user, address); done || err != nil {
+		return err
+	}
+	params, err := im.Provisioner.FindDepositAddress(ctx, address)
+	if err != nil {
+		return fmt.Errorf("deposit address %s: %w", address, err)
+	}
+	params.UserId = user.Id
+	if _, err := im.Store.StoreAddress(ctx, params); err != nil {
+		return fmt.Errorf("failed to store deposit address %s: %w", address, err)
+	}
+	return nil
+}
+
+// registerWithdrawalAddress stores the address and puts it on the user's withdrawal
+// allowlist. A resumed row allowlists an address stored by the earlier run.
+func (im *Importer) registerWithdrawalAddress(ctx context.Context, user *models.User, address string) error {
+	done, err := im.checkOwner(ctx, user, address)
+	if err != nil {
+		return err
+	}
+	asset, err := im.Provisioner.WithdrawalAsset(ctx, address)
+	if err != nil {
+		return fmt.Errorf("withdrawal address %s: %w", address, err)
+	}
+	if !done {
+		_, err = im.Store.StoreAddress(ctx, store.StoreAddressParams{
+			UserId:  user.Id,
+			Asset:   asset,
+			Network: "external",
+			Address: address,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store withdrawal address %s: %w", address, err)
+		}
+	}
+	// The generic type is not an asset, so such an entry covers any asset
+	if asset == genericWithdrawalAsset {
+		asset = ""
+	}
+	_, err = im.Store.AddAllowlistAddress(ctx, store.AllowlistParams{
+		UserId:   user.Id,
+		Address:  address,
+		Asset:    asset,
+		Actor:    allowlistActor,
+		Cooldown: im.AllowlistCooldown,
+	})
+	if err != nil && !errors.Is(err, store.ErrAddressAllowlisted) {
+		return fmt.Errorf("failed to allowlist withdrawal address %s: %w", address, err)
+	}
+	return nil
+}
+
+// provisionAsset creates a deposit address for the asset unless the user has one,
+// so a resumed row does not get a second address.
+func (im *Importer) provisionAsset(ctx context.Context, user *models.User, asset common.AssetConfig) error {
+	existing, err := im.Store.GetAddresses(ctx, user.Id, asset.Symbol, asset.Network)
+	if err != nil {
+		return fmt.Errorf("failed to check existing addresses: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+	params, err := im.Provisioner.CreateDepositAddress(ctx, asset)
+	if err != nil {
+		return err
+	}
+	params.UserId = user.Id
+	if _, err := im.Store.StoreAddress(ctx, params); err != nil {
+		return fmt.Errorf("failed to store deposit address: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+	"prime-send-receive-go/internal/testutil"
+)
+
+// fakeProvisioner hands out sequential addresses and tracks peak concurrency.
+type fakeProvisioner struct {
+	created   atomic.Int64
+	inFlight  atomic.Int64
+	peak      atomic.Int64
+	failAsset string
+	known     map[string]store.StoreAddressParams
+}
+
+func (p *fakeProvisioner) FindDepositAddress(_ context.Context, address string) (store.StoreAddressParams, error) {
+	if params, ok := p.known[strings.ToLower(address)]; ok {
+		return params, nil
+	}
+	return store.StoreAddressParams{}, errors.New("not found on any Prime trading wallet")
+}
+
+func (p *fakeProvisioner) CreateDepositAddress(_ context.Context, asset common.AssetConfig) (store.StoreAddressParams, error) {
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	if asset.Symbol == p.failAsset {
+		return store.StoreAddressParams{}, errors.New("prime unavailable")
+	}
+	id := p.created.Add(1)
+	return store.StoreAddressParams{Asset: asset.Symbol, Network: asset.Network, Address: fmt.Sprintf("0xnew%d", id)}, nil
+}
+
+func (p *fakeProvisioner) WithdrawalAsset(_ context.Context, _ string) (string, error) {
+	return "USDC", nil
+}
+
+var testAssets = []common.AssetConfig{
+	{Symbol: "USDC", Network: "base-mainnet"},
+	{Symbol: "ETH", Network: "ethereum-mainnet"},
+}
+
+func TestParseRows_CSV(t *testing.T) {
+	input := "name,email,deposit_addresses,withdrawal_addresses\n" +
+		"Alice Johnson,alice@example.com,,\n" +
+		"Bob Smith, bob@example.com ,0xAAA;0xBBB,0xCCC\n"
+	rows, err := ParseRows(strings.NewReader(input), FormatCSV)
+	if err != nil {
+		t.Fatalf("ParseRows failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].Line != 2 || rows[1].Line != 3 {
+		t.Errorf("Expected lines 2 and 3, got %d and %d", rows[0].Line, rows[1].Line)
+	}
+	bob := rows[1]
+	if bob.Email != "bob@example.com" || len(bob.DepositAddresses) != 2 || bob.WithdrawalAddresses[0] != "0xCCC" {
+		t.Errorf("Unexpected row: %+v", bob)
+	}
+
+	if _, err := ParseRows(strings.NewReader("name,deposit_addresses\nAlice,\n"), FormatCSV); err == nil {
+		t.Error("Expected a missing email column to fail")
+	}
+}
+
+func TestParseRows_JSONL(t *testing.T) {
+	input := `{"name": "Alice Johnson", "email": "alice@example.com"}
+
+{"name": "Bob Smith", "email": "bob@example.com", "deposit_addresses": ["0xAAA"]}
+`
+	rows, err := ParseRows(strings.NewReader(input), FormatJSONL)
+	if err != nil {
+		t.Fatalf("ParseRows failed: %v", err)
+	}
+	if len(rows) != 2 || rows[1].Line != 3 || rows[1].DepositAddresses[0] != "0xAAA" {
+		t.Errorf("Unexpected rows: %+v", rows)
+	}
+
+	_, err = ParseRows(strings.NewReader(`{"name": "Alice", "mail": "a@example.com"}`), FormatJSONL)
+	var rowErr RowError
+	if !errors.As(err, &rowErr) || rowErr.Line != 1 {
+		t.Errorf("Expected a line 1 error for an unknown field, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	rows := []Row{
+		{Line: 2, Name: "Alice Johnson", Email: "alice@example.com", DepositAddresses: []string{"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"}},
+		{Line: 3, Name: "A", Email: "not-an-email"},
+		{Line: 4, Name: "Alice Again", Email: "ALICE@example.com"},
+		// The same EVM address in lower case, and a base58 address differing only in case
+		{Line: 5, Name: "Carol Williams", Email: "carol@example.com", WithdrawalAddresses: []string{"0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", "ePjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"}},
+		{Line: 6, Name: "Dan Brown", Email: "dan@example.com", WithdrawalAddresses: []string{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"}},
+	}
+	errs := Validate(rows)
+	lines := make([]int, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Line
+	}
+	if fmt.Sprint(lines) != "[3 3 4 5 6]" {
+		t.Errorf("Expected errors on lines [3 3 4 5 6], got %v", errs)
+	}
+}
+
+func TestImporter_Run(t *testing.T) {
+	fs := testutil.NewSQLiteStore(t)
+	existing, err := fs.CreateUser(context.Background(), "existing-id", "Bob Smith", "bob@example.com")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	prov := &fakeProvisioner{known: map[string]store.StoreAddressParams{
+		"0xaaa": {Asset: "USDC", Network: "base-mainnet", Address: "0xAAA", WalletId: "w1"},
+	}}
+
+	var rows []Row
+	for i := 0; i < 10; i++ {
+		rows = append(rows, Row{Line: i + 2, Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i)})
+	}
+	rows = append(rows,
+		Row{Line: 12, Name: "Bob Smith", Email: "bob@example.com", DepositAddresses: []string{"0xAAA"}, WithdrawalAddresses: []string{"0xEXT"}},
+		Row{Line: 13, Name: "Eve Adams", Email: "eve@example.com", DepositAddresses: []string{"0xUNKNOWN"}},
+	)
+
+	im := &Importer{Store: fs, Provisioner: prov, Assets: testAssets, Concurrency: 3}
+	report, err := im.Run(context.Background(), "users.csv", rows)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Created != 10 || report.Existing != 1 || report.Failed != 1 {
+		t.Errorf("Expected 10 created, 1 existing, 1 failed, got %+v", report)
+	}
+	if peak := prov.peak.Load(); peak > 3 {
+		t.Errorf("Expected at most 3 concurrent provisioning calls, got %d", peak)
+	}
+	if prov.created.Load() != 20 {
+		t.Errorf("Expected 20 deposit addresses created, got %d", prov.created.Load())
+	}
+
+	bob := report.Results[10]
+	if bob.Status != models.ImportStatusExisting || bob.UserId != existing.Id || bob.DepositAddresses != 1 || bob.WithdrawalAddresses != 1 {
+		t.Errorf("Unexpected result for existing user: %+v", bob)
+	}
+	eve := report.Results[11]
+	if eve.Status != models.ImportStatusFailed || eve.Line != 13 || !strings.Contains(eve.Error, "0xUNKNOWN") {
+		t.Errorf("Unexpected result for unknown address: %+v", eve)
+	}
+
+	if _, err := im.Run(context.Background(), "users.csv", []Row{{Line: 2, Name: "X", Email: "x"}}); err == nil {
+		t.Error("Expected invalid rows to fail validation")
+	} else {
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Errors) != 2 {
+			t.Errorf("Expected a ValidationError with 2 errors, got %v", err)
+		}
+	}
+}
+
+func TestImporter_ResumeFromProgress(t *testing.T) {
+	fs := testutil.NewSQLiteStore(t)
+	prov := &fakeProvisioner{failAsset: "ETH"}
+	path := filepath.Join(t.TempDir(), "progress.jsonl")
+	rows := []Row{
+		{Line: 2, Name: "Alice Johnson", Email: "alice@example.com", WithdrawalAddresses: []string{"0xEXT"}},
+		{Line: 3, Name: "Bob Smith", Email: "bob@example.com", DepositAddresses: []string{}},
+	}
+
+	run := func() *models.UserImportReport {
+		t.Helper()
+		progress, err := OpenProgress(path)
+		if err != nil {
+			t.Fatalf("OpenProgress failed: %v", err)
+		}
+		defer progress.Close()
+		im := &Importer{Store: fs, Provisioner: prov, Assets: testAssets, Concurrency: 2, Progress: progress, AllowlistCooldown: time.Hour}
+		report, err := im.Run(context.Background(), "users.jsonl", rows)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return report
+	}
+
+	// ETH provisioning fails, so both rows fail after creating their users and USDC addresses
+	first := run()
+	if first.Failed != 2 || len(first.Results[0].FailedAssets) != 1 {
+		t.Fatalf("Expected both rows to fail on ETH, got %+v", first)
+	}
+
+	prov.failAsset = ""
+	second := run()
+	if second.Existing != 2 || second.Failed != 0 {
+		t.Fatalf("Expected both rows to be retried against their existing users, got %+v", second)
+	}
+	if prov.created.Load() != 4 {
+		t.Errorf("Expected USDC addresses to be reused on retry (4 created), got %d", prov.created.Load())
+	}
+	alice := second.Results[0].UserId
+	allowlist, err := fs.GetAllowlist(context.Background(), alice)
+	if err != nil {
+		t.Fatalf("GetAllowlist failed: %v", err)
+	}
+	if len(allowlist) != 1 || allowlist[0].Address != "0xEXT" || allowlist[0].ActiveAt.Sub(allowlist[0].AddedAt) != time.Hour || allowlist[0].AddedBy != allowlistActor {
+		t.Errorf("Expected 0xEXT allowlisted once for alice with the importer's cooldown, got %+v", allowlist)
+	}
+
+	third := run()
+	if third.Skipped != 2 || third.Results[1].UserId == "" {
+		t.Errorf("Expected both rows to be skipped with their user ids, got %+v", third)
+	}
+}
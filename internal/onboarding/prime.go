@@ -0,0 +1,179 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package onboarding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"prime-send-receive-go/internal/assets"
+	"prime-send-receive-go/internal/common"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/prime"
+	"prime-send-receive-go/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// PrimeProvisioner provisions addresses on a Prime portfolio. Wallets are looked up
+// or created once per asset, and existing deposit addresses are indexed on first use
+// instead of scanning every wallet for each row.
+type PrimeProvisioner struct {
+	prime       *prime.Service
+	portfolioId string
+	networks    []string
+
+	walletsMu sync.Mutex
+	wallets   map[string]string // asset symbol -> trading wallet id
+
+	indexOnce sync.Once
+	index     map[string]store.StoreAddressParams // lower-cased address -> location
+	indexErr  error
+}
+
+// NewPrimeProvisioner searches the configured assets' networks as well as every
+// network in the asset registry for existing addresses.
+func NewPrimeProvisioner(primeService *prime.Service, portfolioId string, assetConfigs []common.AssetConfig) *PrimeProvisioner {
+	networks := assets.Current().Networks()
+	for _, a := range assetConfigs {
+		if !containsFold(networks, a.Network) {
+			networks = append(networks, a.Network)
+		}
+	}
+	return &PrimeProvisioner{
+		prime:       primeService,
+		portfolioId: portfolioId,
+		networks:    networks,
+		wallets:     make(map[string]string),
+	}
+}
+
+// FindDepositAddress looks the address up in the index of every trading wallet's addresses.
+func (p *PrimeProvisioner) FindDepositAddress(ctx context.Context, address string) (store.StoreAddressParams, error) {
+	p.indexOnce.Do(func() { p.index, p.indexErr = p.buildIndex(ctx) })
+	if p.indexErr != nil {
+		return store.StoreAddressParams{}, p.indexErr
+	}
+	params, ok := p.index[strings.ToLower(address)]
+	if !ok {
+		return store.StoreAddressParams{}, fmt.Errorf("not found on any Prime trading wallet")
+	}
+	return params, nil
+}
+
+func (p *PrimeProvisioner) buildIndex(ctx context.Context) (map[string]store.StoreAddressParams, error) {
+	wallets, err := p.prime.ListWallets(ctx, p.portfolioId, "TRADING", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Prime wallets: %w", err)
+	}
+
+	index := make(map[string]store.StoreAddressParams)
+	for _, w := range wallets {
+		for _, network := range p.networks {
+			addrs, err := p.prime.ListWalletAddresses(ctx, p.portfolioId, w.Id, network)
+			if err != nil {
+				continue
+			}
+			for _, a := range addrs {
+				index[strings.ToLower(a.Address)] = store.StoreAddressParams{
+					Asset:             w.Symbol,
+					Network:           network,
+					Address:           a.Address,
+					WalletId:          w.Id,
+					AccountIdentifier: a.Id,
+					Tag:               a.Tag,
+				}
+			}
+		}
+	}
+	zap.L().Info("Indexed Prime deposit addresses", zap.Int("wallets", len(wallets)), zap.Int("addresses", len(index)))
+	return index, nil
+}
+
+// CreateDepositAddress creates a deposit address on the asset's trading wallet.
+func (p *PrimeProvisioner) CreateDepositAddress(ctx context.Context, asset common.AssetConfig) (store.StoreAddressParams, error) {
+	walletId, err := p.wallet(ctx, asset.Symbol)
+	if err != nil {
+		return store.StoreAddressParams{}, err
+	}
+	addr, err := p.prime.CreateDepositAddress(ctx, p.portfolioId, walletId, asset.Symbol, asset.Network)
+	if err != nil {
+		return store.StoreAddressParams{}, fmt.Errorf("error creating deposit address: %w", err)
+	}
+	return store.StoreAddressParams{
+		Asset:             asset.Symbol,
+		Network:           asset.Network,
+		Address:           addr.Address,
+		WalletId:          walletId,
+		AccountIdentifier: addr.Id,
+		Tag:               addr.Tag,
+	}, nil
+}
+
+// wallet returns the asset's trading wallet, creating it on first use. The lock is
+// held across the create so concurrent rows never create two wallets.
+func (p *PrimeProvisioner) wallet(ctx context.Context, symbol string) (string, error) {
+	p.walletsMu.Lock()
+	defer p.walletsMu.Unlock()
+	if id, ok := p.wallets[symbol]; ok {
+		return id, nil
+	}
+
+	wallets, err := p.prime.ListWallets(ctx, p.portfolioId, "TRADING", []string{symbol})
+	if err != nil {
+		return "", fmt.Errorf("error listing wallets: %w", err)
+	}
+	var wallet *models.Wallet
+	if len(wallets) > 0 {
+		wallet = &wallets[0]
+	} else {
+		zap.L().Info("Creating new wallet", zap.String("asset", symbol))
+		if wallet, err = p.prime.CreateWallet(ctx, p.portfolioId, fmt.Sprintf("%s Trading Wallet", symbol), symbol, "TRADING"); err != nil {
+			return "", fmt.Errorf("error creating wallet: %w", err)
+		}
+	}
+	p.wallets[symbol] = wallet.Id
+	return wallet.Id, nil
+}
+
+// genericWithdrawalAsset is recorded for withdrawal addresses missing from the address book
+const genericWithdrawalAsset = "WITHDRAWAL"
+
+// WithdrawalAsset returns the address book symbol for the address, or "WITHDRAWAL".
+func (p *PrimeProvisioner) WithdrawalAsset(ctx context.Context, address string) (string, error) {
+	entry, err := p.prime.LookupAddressBook(ctx, p.portfolioId, address)
+	if err != nil {
+		zap.L().Debug("Address book lookup failed, using generic type",
+			zap.String("address", address), zap.Error(err))
+		return genericWithdrawalAsset, nil
+	}
+	if entry == nil {
+		return genericWithdrawalAsset, nil
+	}
+	return entry.Symbol, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
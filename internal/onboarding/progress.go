@@ -0,0 +1,112 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package onboarding
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"prime-send-receive-go/internal/models"
+)
+
+// Progress is an append-only JSONL file with one result per finished row. Rows whose
+// latest recorded result succeeded are skipped when an import is run again; failed
+// rows are retried.
+type Progress struct {
+	mu   sync.Mutex
+	file *os.File
+	done map[string]models.UserImportResult // by lower-cased email
+}
+
+// OpenProgress loads the results already recorded in path, creating the file if it
+// does not exist, and opens it for appending.
+func OpenProgress(path string) (*Progress, error) {
+	p := &Progress{done: make(map[string]models.UserImportResult)}
+
+	f, err := os.Open(path)
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var result models.UserImportResult
+			if err := json.Unmarshal([]byte(text), &result); err != nil {
+				// A run killed mid-write leaves a truncated last line; the row is simply redone
+				continue
+			}
+			key := strings.ToLower(result.Email)
+			if result.Error == "" {
+				p.done[key] = result
+			} else {
+				delete(p.done, key)
+			}
+		}
+		scanErr := scanner.Err()
+		f.Close()
+		if scanErr != nil {
+			return nil, fmt.Errorf("unable to read progress file %s: %w", path, scanErr)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to open progress file %s: %w", path, err)
+	}
+
+	if p.file, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600); err != nil {
+		return nil, fmt.Errorf("unable to open progress file %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Completed returns the successful result recorded for email by an earlier run.
+func (p *Progress) Completed(email string) (models.UserImportResult, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.done[strings.ToLower(email)]
+	return result, ok
+}
+
+// Record appends a row's result and syncs it to disk.
+func (p *Progress) Record(result models.UserImportResult) error {
+	line, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode progress: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write progress: %w", err)
+	}
+	if err := p.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync progress: %w", err)
+	}
+	if result.Error == "" {
+		p.done[strings.ToLower(result.Email)] = result
+	}
+	return nil
+}
+
+// Close closes the progress file.
+func (p *Progress) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,223 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package onboarding imports users in bulk from CSV or JSONL files: it validates
+// every row up front, creates the users, assigns or provisions their addresses with
+// bounded concurrency, and records progress so an interrupted run can resume.
+package onboarding
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"prime-send-receive-go/internal/addressing"
+	"prime-send-receive-go/internal/common"
+)
+
+// Input formats
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Row is one user to import. Line is the 1-based line of the row in its file.
+type Row struct {
+	Line                int      `json:"-"`
+	Name                string   `json:"name"`
+	Email               string   `json:"email"`
+	DepositAddresses    []string `json:"deposit_addresses,omitempty"`
+	WithdrawalAddresses []string `json:"withdrawal_addresses,omitempty"`
+}
+
+// RowError is a validation failure on one input line.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// FormatFromPath picks the input format from the file extension.
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("cannot tell the format of %s: use a .csv or .jsonl file, or --format", path)
+	}
+}
+
+// LoadRows reads every row of a CSV or JSONL file.
+func LoadRows(path, format string) ([]Row, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %w", path, err)
+	}
+	defer f.Close()
+	return ParseRows(f, format)
+}
+
+// ParseRows reads rows in the given format. CSV input needs a header with name and
+// email columns and optional deposit_addresses and withdrawal_addresses columns,
+// whose addresses are separated by semicolons or spaces. JSONL input is one object
+// per line with the same fields, addresses as arrays. Blank lines are ignored.
+func ParseRows(r io.Reader, format string) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatJSONL:
+		return parseJSONL(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected %s or %s", format, FormatCSV, FormatJSONL)
+	}
+}
+
+func parseCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %q column", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, Row{
+			Line:                line,
+			Name:                field(record, "name"),
+			Email:               field(record, "email"),
+			DepositAddresses:    splitAddresses(field(record, "deposit_addresses")),
+			WithdrawalAddresses: splitAddresses(field(record, "withdrawal_addresses")),
+		})
+	}
+	return rows, nil
+}
+
+func parseJSONL(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []Row
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var row Row
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			return nil, RowError{Line: line, Err: fmt.Errorf("invalid JSON: %w", err)}
+		}
+		row.Line = line
+		row.Name = strings.TrimSpace(row.Name)
+		row.Email = strings.TrimSpace(row.Email)
+		row.DepositAddresses = trimAddresses(row.DepositAddresses)
+		row.WithdrawalAddresses = trimAddresses(row.WithdrawalAddresses)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read JSONL: %w", err)
+	}
+	return rows, nil
+}
+
+func splitAddresses(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ' ' || r == '\t' })
+}
+
+func trimAddresses(addrs []string) []string {
+	var out []string
+	for _, a := range addrs {
+		if a = strings.TrimSpace(a); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Validate checks every row before anything is written: names and emails use the
+// same rules as adduser, addresses must be well formed, and no email or address may
+// appear on two rows. It returns all failures, in file order.
+func Validate(rows []Row) []RowError {
+	var errs []RowError
+	emails := make(map[string]int)
+	addresses := make(map[string]int)
+	for _, row := range rows {
+		if err := common.ValidateName(row.Name); err != nil {
+			errs = append(errs, RowError{Line: row.Line, Err: err})
+		}
+		if err := common.ValidateEmail(row.Email); err != nil {
+			errs = append(errs, RowError{Line: row.Line, Err: err})
+		} else if first, ok := emails[strings.ToLower(row.Email)]; ok {
+			errs = append(errs, RowError{Line: row.Line, Err: fmt.Errorf("email %s is also on line %d", row.Email, first)})
+		} else {
+			emails[strings.ToLower(row.Email)] = row.Line
+		}
+		for _, addr := range append(append([]string{}, row.DepositAddresses...), row.WithdrawalAddresses...) {
+			key, err := addressing.NormalizeAny(addr)
+			if err != nil {
+				errs = append(errs, RowError{Line: row.Line, Err: err})
+				continue
+			}
+			if first, ok := addresses[key]; ok {
+				errs = append(errs, RowError{Line: row.Line, Err: fmt.Errorf("address %s is also on line %d", addr, first)})
+				continue
+			}
+			addresses[key] = row.Line
+		}
+	}
+	return errs
+}
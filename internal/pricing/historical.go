@@ -0,0 +1,111 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const dateLayout = "2006-01-02"
+
+type dailyClose struct {
+	day   time.Time // midnight UTC
+	price decimal.Decimal
+}
+
+// HistoricalSource serves daily closing prices. A time is valued at the close of its
+// UTC day, or the most recent earlier close when that day is missing, so month-end
+// valuations use month-end prices. Times before the first close have no price.
+type HistoricalSource struct {
+	closes map[string][]dailyClose // sorted by day
+}
+
+// NewHistoricalSource returns a source over closes keyed by asset then YYYY-MM-DD date.
+func NewHistoricalSource(closes map[string]map[string]decimal.Decimal) (*HistoricalSource, error) {
+	s := &HistoricalSource{closes: make(map[string][]dailyClose)}
+	for asset, byDate := range closes {
+		key := normalizeAsset(asset)
+		for date, price := range byDate {
+			day, err := time.Parse(dateLayout, date)
+			if err != nil {
+				return nil, fmt.Errorf("invalid close date %q for %s: expected YYYY-MM-DD", date, asset)
+			}
+			s.closes[key] = append(s.closes[key], dailyClose{day: day, price: price})
+		}
+		sort.Slice(s.closes[key], func(i, j int) bool { return s.closes[key][i].day.Before(s.closes[key][j].day) })
+	}
+	return s, nil
+}
+
+// LoadHistorical reads daily closes from a CSV file of date,asset,close rows with an
+// optional header.
+func LoadHistorical(path string) (*HistoricalSource, error) {
+	if path == "" {
+		return nil, fmt.Errorf("PRICE_FILE is required for the historical price source")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	rows, err := readCSV(strings.NewReader(string(data)), 3)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	closes := make(map[string]map[string]decimal.Decimal)
+	for _, row := range rows {
+		date, asset := row[0], row[1]
+		price, err := parsePrice(asset, row[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if closes[asset] == nil {
+			closes[asset] = make(map[string]decimal.Decimal)
+		}
+		closes[asset][date] = price
+	}
+	if len(closes) == 0 {
+		return nil, fmt.Errorf("%s contains no prices", path)
+	}
+	return NewHistoricalSource(closes)
+}
+
+// Price returns the close for at's UTC day or the latest close before it. A zero at
+// returns the latest close.
+func (s *HistoricalSource) Price(_ context.Context, asset string, at time.Time) (decimal.Decimal, error) {
+	closes := s.closes[normalizeAsset(asset)]
+	if len(closes) == 0 {
+		return decimal.Zero, fmt.Errorf("%w for %s", ErrNoPrice, asset)
+	}
+	if at.IsZero() {
+		return closes[len(closes)-1].price, nil
+	}
+
+	day := at.UTC().Truncate(24 * time.Hour)
+	i := sort.Search(len(closes), func(i int) bool { return closes[i].day.After(day) })
+	if i == 0 {
+		return decimal.Zero, fmt.Errorf("%w for %s on %s", ErrNoPrice, asset, day.Format(dateLayout))
+	}
+	return closes[i-1].price, nil
+}
@@ -0,0 +1,174 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultCacheTTL    = time.Minute
+	defaultHTTPTimeout = 10 * time.Second
+)
+
+// priceResponse is the body of GET {base}/v1/prices/{asset}[?date=YYYY-MM-DD]. Without
+// a date the service returns the latest price, with one the close for that day.
+type priceResponse struct {
+	Asset string `json:"asset"`
+	Date  string `json:"date,omitempty"`
+	Price string `json:"price"`
+}
+
+type cachedPrice struct {
+	price   decimal.Decimal
+	expires time.Time // zero for daily closes, which never change
+}
+
+// HTTPSource fetches prices from a price service and caches them: latest prices for
+// the cache TTL, daily closes for the life of the process.
+type HTTPSource struct {
+	baseURL string
+	ttl     time.Duration
+	client  *http.Client
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedPrice
+}
+
+// NewHTTPSource returns a source for the price service at baseURL. A zero ttl uses the
+// default; a nil client uses one with a request timeout.
+func NewHTTPSource(baseURL string, ttl time.Duration, client *http.Client) *HTTPSource {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	return &HTTPSource{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		ttl:     ttl,
+		client:  client,
+		now:     time.Now,
+		cache:   make(map[string]cachedPrice),
+	}
+}
+
+// Price returns the latest price for a zero at, otherwise the close for at's UTC day.
+func (s *HTTPSource) Price(ctx context.Context, asset string, at time.Time) (decimal.Decimal, error) {
+	asset = normalizeAsset(asset)
+	var date string
+	if !at.IsZero() {
+		date = at.UTC().Format(dateLayout)
+	}
+	key := asset + "|" + date
+
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && (cached.expires.IsZero() || s.now().Before(cached.expires)) {
+		return cached.price, nil
+	}
+
+	price, err := s.fetch(ctx, asset, date)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	entry := cachedPrice{price: price}
+	if date == "" {
+		entry.expires = s.now().Add(s.ttl)
+	}
+	s.mu.Lock()
+	s.cache[key] = entry
+	s.mu.Unlock()
+	return price, nil
+}
+
+func (s *HTTPSource) fetch(ctx context.Context, asset, date string) (decimal.Decimal, error) {
+	endpoint := s.baseURL + "/v1/prices/" + url.PathEscape(asset)
+	if date != "" {
+		endpoint += "?date=" + url.QueryEscape(date)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to build price request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to fetch %s price: %w", asset, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return decimal.Zero, fmt.Errorf("%w for %s", ErrNoPrice, asset)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return decimal.Zero, fmt.Errorf("price service returned %s for %s", resp.Status, asset)
+	}
+
+	var body priceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to decode %s price: %w", asset, err)
+	}
+	return parsePrice(asset, body.Price)
+}
+
+// StandInHandler serves the HTTPSource protocol from any PriceSource. Tests and local
+// development point an HTTPSource at it instead of a real price service.
+func StandInHandler(source PriceSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asset := strings.TrimPrefix(r.URL.Path, "/v1/prices/")
+		if r.Method != http.MethodGet || asset == r.URL.Path || asset == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var at time.Time
+		date := r.URL.Query().Get("date")
+		if date != "" {
+			day, err := time.Parse(dateLayout, date)
+			if err != nil {
+				http.Error(w, "invalid date", http.StatusBadRequest)
+				return
+			}
+			at = day.Add(24*time.Hour - time.Nanosecond)
+		}
+
+		price, err := source.Price(r.Context(), asset, at)
+		if errors.Is(err, ErrNoPrice) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(priceResponse{Asset: asset, Date: date, Price: price.String()})
+	})
+}
@@ -0,0 +1,89 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package pricing values ledger balances in USD. A PriceSource answers "what was one
+// unit of this asset worth at this time"; implementations read a static file, daily
+// closes, or a cached HTTP price service.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Price source kinds selectable via PRICE_SOURCE
+const (
+	SourceNone       = ""
+	SourceStatic     = "static"
+	SourceHistorical = "historical"
+	SourceHTTP       = "http"
+)
+
+// ErrNoPrice is returned when a source has no price for an asset at the requested time
+var ErrNoPrice = errors.New("no price available")
+
+// PriceSource returns the USD price of one unit of an asset. A zero at asks for the
+// latest price; otherwise the price in effect at that time.
+type PriceSource interface {
+	Price(ctx context.Context, asset string, at time.Time) (decimal.Decimal, error)
+}
+
+// New builds the price source selected by cfg, or returns nil when pricing is disabled.
+func New(cfg models.PricingConfig) (PriceSource, error) {
+	switch cfg.Source {
+	case SourceNone:
+		return nil, nil
+	case SourceStatic:
+		return LoadStatic(cfg.File)
+	case SourceHistorical:
+		return LoadHistorical(cfg.File)
+	case SourceHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("PRICE_URL is required for the http price source")
+		}
+		return NewHTTPSource(cfg.URL, cfg.CacheTTL, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown price source %q: expected static, historical or http", cfg.Source)
+	}
+}
+
+// normalizeAsset maps ledger asset names to price keys: upper case, without any
+// -network suffix, so USDC and usdc-base-mainnet share a price.
+func normalizeAsset(asset string) string {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	if i := strings.Index(asset, "-"); i > 0 {
+		asset = asset[:i]
+	}
+	return asset
+}
+
+func parsePrice(asset, value string) (decimal.Decimal, error) {
+	price, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid price %q for %s: %w", value, asset, err)
+	}
+	if price.IsNegative() {
+		return decimal.Zero, fmt.Errorf("negative price %s for %s", price, asset)
+	}
+	return price, nil
+}
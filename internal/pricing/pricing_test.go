@@ -0,0 +1,156 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadStatic(t *testing.T) {
+	ctx := context.Background()
+	yamlPath := writeFile(t, "prices.yaml", "prices:\n  BTC: \"65000.5\"\n  usdc: \"1\"\n")
+	csvPath := writeFile(t, "prices.csv", "asset,price\nBTC,65000.5\nUSDC,1\n")
+
+	for _, path := range []string{yamlPath, csvPath} {
+		src, err := LoadStatic(path)
+		if err != nil {
+			t.Fatalf("LoadStatic(%s): %v", filepath.Base(path), err)
+		}
+		if p, err := src.Price(ctx, "btc", time.Now()); err != nil || !p.Equal(dec("65000.5")) {
+			t.Errorf("%s BTC = %s, %v; want 65000.5", filepath.Base(path), p, err)
+		}
+		if p, err := src.Price(ctx, "USDC-base-mainnet", time.Time{}); err != nil || !p.Equal(dec("1")) {
+			t.Errorf("%s USDC = %s, %v; want 1", filepath.Base(path), p, err)
+		}
+		if _, err := src.Price(ctx, "ETH", time.Time{}); !errors.Is(err, ErrNoPrice) {
+			t.Errorf("%s ETH error = %v, want ErrNoPrice", filepath.Base(path), err)
+		}
+	}
+
+	if _, err := LoadStatic(writeFile(t, "bad.csv", "BTC,-1\n")); err == nil {
+		t.Error("expected negative price to be rejected")
+	}
+}
+
+func TestHistoricalSource(t *testing.T) {
+	ctx := context.Background()
+	src, err := LoadHistorical(writeFile(t, "closes.csv",
+		"date,asset,close\n2025-03-28,BTC,80000\n2025-03-31,BTC,82000\n2025-04-01,BTC,85000\n"))
+	if err != nil {
+		t.Fatalf("LoadHistorical: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"end of day uses that close", time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC), "82000"},
+		{"missing day uses previous close", time.Date(2025, 3, 30, 12, 0, 0, 0, time.UTC), "80000"},
+		{"after last close", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), "85000"},
+		{"zero is latest", time.Time{}, "85000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := src.Price(ctx, "BTC", tt.at)
+			if err != nil || !p.Equal(dec(tt.want)) {
+				t.Errorf("Price = %s, %v; want %s", p, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := src.Price(ctx, "BTC", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoPrice) {
+		t.Errorf("before first close error = %v, want ErrNoPrice", err)
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	ctx := context.Background()
+	closes, err := NewHistoricalSource(map[string]map[string]decimal.Decimal{
+		"ETH": {"2025-03-31": dec("1800"), "2025-04-01": dec("1900")},
+	})
+	if err != nil {
+		t.Fatalf("NewHistoricalSource: %v", err)
+	}
+
+	var requests atomic.Int32
+	handler := StandInHandler(closes)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	src := NewHTTPSource(server.URL, time.Minute, server.Client())
+	now := time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)
+	src.now = func() time.Time { return now }
+
+	march := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if p, err := src.Price(ctx, "eth", march); err != nil || !p.Equal(dec("1800")) {
+			t.Fatalf("historical price = %s, %v; want 1800", p, err)
+		}
+	}
+	if p, err := src.Price(ctx, "ETH", time.Time{}); err != nil || !p.Equal(dec("1900")) {
+		t.Fatalf("latest price = %s, %v; want 1900", p, err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2 (repeat close served from cache)", got)
+	}
+
+	// Latest prices expire after the TTL; daily closes do not
+	now = now.Add(2 * time.Minute)
+	if _, err := src.Price(ctx, "ETH", time.Time{}); err != nil {
+		t.Fatalf("latest price after TTL: %v", err)
+	}
+	if _, err := src.Price(ctx, "ETH", march); err != nil {
+		t.Fatalf("historical price after TTL: %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+
+	if _, err := src.Price(ctx, "DOGE", time.Time{}); !errors.Is(err, ErrNoPrice) {
+		t.Errorf("unknown asset error = %v, want ErrNoPrice", err)
+	}
+}
+
+func TestValue(t *testing.T) {
+	src := NewStaticSource(map[string]decimal.Decimal{"BTC": dec("60000"), "USDC": dec("1")})
+	v, err := Value(context.Background(), src, time.Time{}, map[string]decimal.Decimal{
+		"BTC":  dec("0.123456"),
+		"USDC": dec("250.5"),
+		"XYZ":  dec("10"),
+	})
+	if err != nil {
+		t.Fatalf("Value: %v", err)
+	}
+	if !v.TotalUSD.Equal(dec("7657.86")) {
+		t.Errorf("total = %s, want 7657.86", v.TotalUSD)
+	}
+	if len(v.Unpriced) != 1 || v.Unpriced[0] != "XYZ" {
+		t.Errorf("unpriced = %v, want [XYZ]", v.Unpriced)
+	}
+	if v.Assets[0].Asset != "BTC" || !v.Assets[0].USDValue.Equal(dec("7407.36")) {
+		t.Errorf("BTC valuation = %+v", v.Assets[0])
+	}
+}
@@ -0,0 +1,119 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v2"
+)
+
+// StaticSource serves fixed prices regardless of the requested time.
+type StaticSource struct {
+	prices map[string]decimal.Decimal
+}
+
+type staticFile struct {
+	Prices map[string]string `yaml:"prices"`
+}
+
+// NewStaticSource returns a source serving the given asset prices.
+func NewStaticSource(prices map[string]decimal.Decimal) *StaticSource {
+	normalized := make(map[string]decimal.Decimal, len(prices))
+	for asset, price := range prices {
+		normalized[normalizeAsset(asset)] = price
+	}
+	return &StaticSource{prices: normalized}
+}
+
+// LoadStatic reads prices from a YAML file (a "prices" map of asset to price) or, for
+// .csv files, rows of asset,price with an optional header.
+func LoadStatic(path string) (*StaticSource, error) {
+	if path == "" {
+		return nil, fmt.Errorf("PRICE_FILE is required for the static price source")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+
+	prices := make(map[string]decimal.Decimal)
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		rows, err := readCSV(strings.NewReader(string(data)), 2)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+		}
+		for _, row := range rows {
+			price, err := parsePrice(row[0], row[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			prices[row[0]] = price
+		}
+	} else {
+		var file staticFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+		}
+		for asset, value := range file.Prices {
+			price, err := parsePrice(asset, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			prices[asset] = price
+		}
+	}
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("%s contains no prices", path)
+	}
+	return NewStaticSource(prices), nil
+}
+
+// Price returns the configured price for asset; at is ignored.
+func (s *StaticSource) Price(_ context.Context, asset string, _ time.Time) (decimal.Decimal, error) {
+	price, ok := s.prices[normalizeAsset(asset)]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w for %s", ErrNoPrice, asset)
+	}
+	return price, nil
+}
+
+// readCSV returns rows with exactly columns fields, skipping blank lines and a header
+// row whose last column is not a number.
+func readCSV(r io.Reader, columns int) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = columns
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 {
+		if _, err := decimal.NewFromString(rows[0][columns-1]); err != nil {
+			rows = rows[1:]
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,59 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Value prices each asset amount at time at and totals the USD values. Assets the
+// source has no price for are reported as unpriced rather than failing the valuation;
+// any other source error is returned.
+func Value(ctx context.Context, source PriceSource, at time.Time, amounts map[string]decimal.Decimal) (*models.Valuation, error) {
+	assets := make([]string, 0, len(amounts))
+	for asset := range amounts {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	valuation := &models.Valuation{AsOf: at, TotalUSD: decimal.Zero}
+	for _, asset := range assets {
+		line := models.AssetValuation{Asset: asset, Amount: amounts[asset]}
+		price, err := source.Price(ctx, asset, at)
+		switch {
+		case errors.Is(err, ErrNoPrice):
+			valuation.Unpriced = append(valuation.Unpriced, asset)
+		case err != nil:
+			return nil, fmt.Errorf("failed to price %s: %w", asset, err)
+		default:
+			line.Price = price
+			line.USDValue = line.Amount.Mul(price).Round(2)
+			line.Priced = true
+			valuation.TotalUSD = valuation.TotalUSD.Add(line.USDValue)
+		}
+		valuation.Assets = append(valuation.Assets, line)
+	}
+	return valuation, nil
+}
@@ -21,12 +21,15 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"prime-send-receive-go/internal/addressing"
 	"prime-send-receive-go/internal/models"
 
 	"github.com/coinbase-samples/prime-sdk-go/addressbook"
+	primeassets "github.com/coinbase-samples/prime-sdk-go/assets"
 	"github.com/coinbase-samples/prime-sdk-go/client"
 	"github.com/coinbase-samples/prime-sdk-go/credentials"
 	"github.com/coinbase-samples/prime-sdk-go/model"
@@ -44,6 +47,7 @@ type Service struct {
 	walletsSvc      wallets.WalletsService
 	transactionsSvc transactions.TransactionsService
 	addressBookSvc  addressbook.AddressBookService
+	assetsSvc       primeassets.AssetsService
 }
 
 func NewService(creds *credentials.Credentials) (*Service, error) {
@@ -60,6 +64,7 @@ func NewService(creds *credentials.Credentials) (*Service, error) {
 		walletsSvc:      wallets.NewWalletsService(restClient),
 		transactionsSvc: transactions.NewTransactionsService(restClient),
 		addressBookSvc:  addressbook.NewAddressBookService(restClient),
+		assetsSvc:       primeassets.NewAssetsService(restClient),
 	}, nil
 }
 
@@ -100,14 +105,49 @@ func (s *Service) ListPortfolios(ctx context.Context) ([]models.Portfolio, error
 	portfolioList := make([]models.Portfolio, len(response.Portfolios))
 	for i, p := range response.Portfolios {
 		portfolioList[i] = models.Portfolio{
-			Id:   p.Id,
-			Name: p.Name,
+			Id:       p.Id,
+			Name:     p.Name,
+			EntityId: p.EntityId,
 		}
 	}
 
 	return portfolioList, nil
 }
 
+// ListAssets returns the assets Prime supports for an entity, with their networks
+// and destination tag requirements.
+func (s *Service) ListAssets(ctx context.Context, entityId string) ([]models.PrimeAsset, error) {
+	response, err := s.assetsSvc.ListAssets(ctx, &primeassets.ListAssetsRequest{EntityId: entityId})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list assets: %w", err)
+	}
+
+	assetList := make([]models.PrimeAsset, 0, len(response.Assets))
+	for _, a := range response.Assets {
+		if a == nil {
+			continue
+		}
+		precision, _ := strconv.Atoi(a.DecimalPrecision)
+		asset := models.PrimeAsset{Symbol: a.Symbol, Precision: precision}
+		for _, n := range a.Networks {
+			if n == nil || n.Network == nil {
+				continue
+			}
+			asset.Networks = append(asset.Networks, models.PrimeAssetNetwork{
+				Network:     n.Network.Id + "-" + n.Network.Type,
+				TagRequired: n.DestinationTagRequired,
+			})
+		}
+		assetList = append(assetList, asset)
+	}
+
+	zap.L().Debug("Listed assets from Prime",
+		zap.String("entity_id", entityId),
+		zap.Int("count", len(assetList)))
+
+	return assetList, nil
+}
+
 func (s *Service) FindDefaultPortfolio(ctx context.Context) (*models.Portfolio, error) {
 	portfolioList, err := s.ListPortfolios(ctx)
 	if err != nil {
@@ -197,6 +237,7 @@ func (s *Service) ListWalletAddresses(ctx context.Context, portfolioId, walletId
 			Id:      addr.AccountIdentifier,
 			Address: addr.Address,
 			Network: network,
+			Tag:     depositTag(network, addr.AccountIdentifier),
 		})
 	}
 
@@ -225,9 +266,20 @@ func (s *Service) CreateDepositAddress(ctx context.Context, portfolioId, walletI
 		Address: response.Address,
 		Network: network,
 		Asset:   asset,
+		Tag:     depositTag(network, response.AccountIdentifier),
 	}, nil
 }
 
+// depositTag returns the destination tag of a Prime deposit address. On
+// tag-based networks Prime shares one address between wallets and reports the
+// wallet's tag as its account identifier.
+func depositTag(network, accountIdentifier string) string {
+	if !addressing.TagRequired(network) {
+		return ""
+	}
+	return accountIdentifier
+}
+
 func (s *Service) CreateWallet(ctx context.Context, portfolioId, name, symbol, walletType string) (*models.Wallet, error) {
 	request := &wallets.CreateWalletRequest{
 		PortfolioId:    portfolioId,
@@ -258,6 +310,9 @@ type CreateWithdrawalParams struct {
 	Amount             string
 	Asset              string
 	IdempotencyKey     string
+	// DestinationTag is the destination tag or memo identifying the receiving
+	// account on tag-based networks such as XRP, XLM and ATOM
+	DestinationTag string
 }
 
 // CreateWithdrawal creates a withdrawal from a wallet
@@ -267,7 +322,8 @@ func (s *Service) CreateWithdrawal(ctx context.Context, params CreateWithdrawalP
 		zap.String("wallet_id", params.WalletId),
 		zap.String("asset", params.Asset),
 		zap.String("amount", params.Amount),
-		zap.String("destination", params.DestinationAddress))
+		zap.String("destination", params.DestinationAddress),
+		zap.String("destination_tag", params.DestinationTag))
 
 	// Parse asset string: ETH-ethereum-mainnet --> ETH, ethereum, mainnet
 	// Or just: ETH --> ETH (defaults to ethereum-mainnet in Prime API)
@@ -275,13 +331,20 @@ func (s *Service) CreateWithdrawal(ctx context.Context, params CreateWithdrawalP
 	symbol := parts[0]
 
 	blockchainAddr := &model.BlockchainAddress{
-		Address: params.DestinationAddress,
+		Address:           params.DestinationAddress,
+		AccountIdentifier: params.DestinationTag,
 	}
 
 	// If network is specified, include it in the request
 	if len(parts) >= 3 {
 		networkId := parts[1]
 		networkType := parts[2]
+		// Withdrawals to a shared address without a tag cannot be credited by the receiver
+		tag, err := addressing.NormalizeTag(networkId+"-"+networkType, params.DestinationTag)
+		if err != nil {
+			return nil, err
+		}
+		blockchainAddr.AccountIdentifier = tag
 		blockchainAddr.Network = &model.NetworkDetails{
 			Id:   networkId,
 			Type: networkType,
@@ -0,0 +1,222 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package reports builds month-end accounting reports (trial balance, general ledger,
+// movement summary) from any store.LedgerStore, so both backends report identically.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+// Account types used by the movement summary
+const (
+	AccountTypeUser               = "user"
+	AccountTypeWallet             = "wallet"
+	AccountTypeDepositsPending    = "deposits_pending"
+	AccountTypeWithdrawalsPending = "withdrawals_pending"
+	AccountTypeConversion         = "conversion"
+	AccountTypeFees               = "fees"
+	AccountTypeRewards            = "rewards"
+	AccountTypeHolds              = "holds"
+	AccountTypeOther              = "other"
+)
+
+// AccountType classifies a ledger account path from either backend. Formance paths
+// carry a prime:portfolio:{id} prefix that SQLite paths omit.
+func AccountType(account string) string {
+	switch {
+	case strings.HasPrefix(account, "users:"):
+		return AccountTypeUser
+	case strings.HasPrefix(account, "fees:"):
+		return AccountTypeFees
+	case strings.Contains(account, ":wallets:"):
+		return AccountTypeWallet
+	case strings.HasSuffix(account, ":deposits:pending"):
+		return AccountTypeDepositsPending
+	case strings.HasSuffix(account, ":withdrawals:pending"):
+		return AccountTypeWithdrawalsPending
+	case strings.HasSuffix(account, ":conversions"):
+		return AccountTypeConversion
+	case strings.HasSuffix(account, ":rewards:distribution"):
+		return AccountTypeRewards
+	case strings.HasSuffix(account, ":holds:captured"):
+		return AccountTypeHolds
+	default:
+		return AccountTypeOther
+	}
+}
+
+// TrialBalance returns every account's debits and credits as of asOf with per-asset totals.
+func TrialBalance(ctx context.Context, db store.LedgerStore, asOf time.Time) (*models.TrialBalanceReport, error) {
+	lines, err := db.GetTrialBalance(ctx, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trial balance: %w", err)
+	}
+
+	report := &models.TrialBalanceReport{AsOf: asOf, Lines: lines}
+	totals := make(map[string]*models.TrialBalanceTotal)
+	var assets []string
+	for _, l := range lines {
+		t, ok := totals[l.Asset]
+		if !ok {
+			t = &models.TrialBalanceTotal{Asset: l.Asset}
+			totals[l.Asset] = t
+			assets = append(assets, l.Asset)
+		}
+		t.Debits = t.Debits.Add(l.Debits)
+		t.Credits = t.Credits.Add(l.Credits)
+	}
+	sort.Strings(assets)
+	for _, asset := range assets {
+		t := totals[asset]
+		t.Balanced = t.Debits.Equal(t.Credits)
+		report.Totals = append(report.Totals, *t)
+	}
+	return report, nil
+}
+
+// GeneralLedger returns, for every account matching accountPrefix (empty matches all),
+// the opening balance at from, each posting in [from, to) with a running balance,
+// and the closing balance. Balances are debits minus credits.
+func GeneralLedger(ctx context.Context, db store.LedgerStore, from, to time.Time, accountPrefix string) (*models.GeneralLedgerReport, error) {
+	type key struct{ account, asset string }
+	sections := make(map[key]*models.GeneralLedgerAccount)
+	section := func(p store.Posting) *models.GeneralLedgerAccount {
+		k := key{p.Account, p.Asset}
+		s, ok := sections[k]
+		if !ok {
+			s = &models.GeneralLedgerAccount{Account: p.Account, Asset: p.Asset}
+			sections[k] = s
+		}
+		return s
+	}
+
+	// One pass over history up to the end of the period: earlier postings build
+	// the opening balance, postings in the period become entries.
+	err := db.ExportPostings(ctx, store.ExportQuery{To: to}, func(postings []store.Posting) error {
+		for _, p := range postings {
+			if !strings.HasPrefix(p.Account, accountPrefix) {
+				continue
+			}
+			s := section(p)
+			signed := signedAmount(p)
+			if !from.IsZero() && p.EffectiveAt.Before(from) {
+				s.Opening = s.Opening.Add(signed)
+				continue
+			}
+			entry := models.GeneralLedgerEntry{
+				TransactionId: p.TransactionId,
+				EffectiveAt:   p.EffectiveAt,
+				Counterparty:  p.Counterparty,
+				ExternalTxId:  p.ExternalTxId,
+			}
+			if p.Direction == store.DirectionDebit {
+				entry.Debit = p.Amount
+				s.Debits = s.Debits.Add(p.Amount)
+			} else {
+				entry.Credit = p.Amount
+				s.Credits = s.Credits.Add(p.Amount)
+			}
+			s.Entries = append(s.Entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read postings: %w", err)
+	}
+
+	report := &models.GeneralLedgerReport{From: from, To: to}
+	for _, s := range sections {
+		// Backends stream in different orders; present each ledger chronologically
+		sort.SliceStable(s.Entries, func(i, j int) bool {
+			return s.Entries[i].EffectiveAt.Before(s.Entries[j].EffectiveAt)
+		})
+		running := s.Opening
+		for i := range s.Entries {
+			running = running.Add(s.Entries[i].Debit).Sub(s.Entries[i].Credit)
+			s.Entries[i].Balance = running
+		}
+		s.Closing = running
+		report.Accounts = append(report.Accounts, *s)
+	}
+	sort.Slice(report.Accounts, func(i, j int) bool {
+		a, b := report.Accounts[i], report.Accounts[j]
+		if a.Asset != b.Asset {
+			return a.Asset < b.Asset
+		}
+		return a.Account < b.Account
+	})
+	return report, nil
+}
+
+// MovementSummary totals inflows (debits) and outflows (credits) per account type and
+// asset for postings in [from, to).
+func MovementSummary(ctx context.Context, db store.LedgerStore, from, to time.Time) (*models.MovementSummaryReport, error) {
+	type key struct{ accountType, asset string }
+	lines := make(map[key]*models.MovementLine)
+
+	err := db.ExportPostings(ctx, store.ExportQuery{From: from, To: to}, func(postings []store.Posting) error {
+		for _, p := range postings {
+			k := key{AccountType(p.Account), p.Asset}
+			l, ok := lines[k]
+			if !ok {
+				l = &models.MovementLine{AccountType: k.accountType, Asset: k.asset}
+				lines[k] = l
+			}
+			if p.Direction == store.DirectionDebit {
+				l.In = l.In.Add(p.Amount)
+			} else {
+				l.Out = l.Out.Add(p.Amount)
+			}
+			l.Postings++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read postings: %w", err)
+	}
+
+	report := &models.MovementSummaryReport{From: from, To: to}
+	for _, l := range lines {
+		l.Net = l.In.Sub(l.Out)
+		report.Lines = append(report.Lines, *l)
+	}
+	sort.Slice(report.Lines, func(i, j int) bool {
+		a, b := report.Lines[i], report.Lines[j]
+		if a.Asset != b.Asset {
+			return a.Asset < b.Asset
+		}
+		return a.AccountType < b.AccountType
+	})
+	return report, nil
+}
+
+func signedAmount(p store.Posting) decimal.Decimal {
+	if p.Direction == store.DirectionDebit {
+		return p.Amount
+	}
+	return p.Amount.Neg()
+}
@@ -0,0 +1,153 @@
+package reports
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
+	"prime-send-receive-go/internal/store"
+	"prime-send-receive-go/internal/testutil"
+
+	"github.com/shopspring/decimal"
+)
+
+// postingsLedger streams testPostings in two chunks to exercise the chunked readers
+func postingsLedger() *testutil.Ledger {
+	db := testutil.NewLedger()
+	db.Postings = testPostings()
+	db.ExportChunk = 4
+	return db
+}
+
+func move(txId string, at time.Time, from, to, asset, amount string) []store.Posting {
+	amt := decimal.RequireFromString(amount)
+	return []store.Posting{
+		{TransactionId: txId, EffectiveAt: at, Account: to, Counterparty: from, Asset: asset, Amount: amt, Direction: store.DirectionDebit},
+		{TransactionId: txId, Sequence: 1, EffectiveAt: at, Account: from, Counterparty: to, Asset: asset, Amount: amt, Direction: store.DirectionCredit},
+	}
+}
+
+func testPostings() []store.Posting {
+	feb := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	var p []store.Posting
+	p = append(p, move("1", feb, "prime:wallets:w1", "users:u1", "USDC", "100")...)
+	p = append(p, move("2", mar.Add(time.Hour), "users:u1", "prime:wallets:w1", "USDC", "30")...)
+	p = append(p, move("3", mar, "prime:wallets:w1", "users:u1", "USDC", "5")...)
+	p = append(p, move("4", mar, "users:u1", "fees:custody", "USDC", "1")...)
+	return p
+}
+
+func TestAccountType(t *testing.T) {
+	tests := map[string]string{
+		"users:u1":                               AccountTypeUser,
+		"prime:wallets:w1":                       AccountTypeWallet,
+		"prime:portfolio:p1:wallets:w1":          AccountTypeWallet,
+		"prime:portfolio:p1:withdrawals:pending": AccountTypeWithdrawalsPending,
+		"prime:deposits:pending":                 AccountTypeDepositsPending,
+		"prime:conversions":                      AccountTypeConversion,
+		"fees:custody":                           AccountTypeFees,
+		"world":                                  AccountTypeOther,
+	}
+	for account, want := range tests {
+		if got := AccountType(account); got != want {
+			t.Errorf("AccountType(%q) = %q, want %q", account, got, want)
+		}
+	}
+}
+
+func TestTrialBalance_Totals(t *testing.T) {
+	db := &testutil.Ledger{Trial: []models.TrialBalanceLine{
+		{Account: "users:u1", Asset: "USDC", Debits: decimal.NewFromInt(10)},
+		{Account: "prime:wallets:w1", Asset: "USDC", Credits: decimal.NewFromInt(10)},
+		{Account: "users:u1", Asset: "ETH", Debits: decimal.NewFromInt(1)},
+	}}
+	report, err := TrialBalance(context.Background(), db, time.Time{})
+	if err != nil {
+		t.Fatalf("TrialBalance failed: %v", err)
+	}
+	if len(report.Totals) != 2 || report.Totals[0].Asset != "ETH" || report.Totals[0].Balanced || !report.Totals[1].Balanced {
+		t.Errorf("unexpected totals: %+v", report.Totals)
+	}
+}
+
+func TestGeneralLedger(t *testing.T) {
+	db := postingsLedger()
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := GeneralLedger(context.Background(), db, from, to, "users:")
+	if err != nil {
+		t.Fatalf("GeneralLedger failed: %v", err)
+	}
+	if len(report.Accounts) != 1 {
+		t.Fatalf("expected only users:u1, got %+v", report.Accounts)
+	}
+	u1 := report.Accounts[0]
+	if !u1.Opening.Equal(decimal.NewFromInt(100)) || !u1.Closing.Equal(decimal.NewFromInt(74)) {
+		t.Errorf("opening/closing = %s/%s, want 100/74", u1.Opening, u1.Closing)
+	}
+	if len(u1.Entries) != 3 {
+		t.Fatalf("expected 3 entries in March, got %d", len(u1.Entries))
+	}
+	// Chronological with running balance: +5, -1 at mar, then -30 an hour later
+	if !u1.Entries[2].Credit.Equal(decimal.NewFromInt(30)) || !u1.Entries[2].Balance.Equal(decimal.NewFromInt(74)) {
+		t.Errorf("last entry = %+v, want 30 credit leaving 74", u1.Entries[2])
+	}
+	if !u1.Debits.Equal(decimal.NewFromInt(5)) || !u1.Credits.Equal(decimal.NewFromInt(31)) {
+		t.Errorf("period debits/credits = %s/%s, want 5/31", u1.Debits, u1.Credits)
+	}
+}
+
+func TestMovementSummary(t *testing.T) {
+	db := postingsLedger()
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	report, err := MovementSummary(context.Background(), db, from, time.Time{})
+	if err != nil {
+		t.Fatalf("MovementSummary failed: %v", err)
+	}
+
+	byType := make(map[string]models.MovementLine)
+	for _, l := range report.Lines {
+		byType[l.AccountType] = l
+	}
+	user := byType[AccountTypeUser]
+	if !user.In.Equal(decimal.NewFromInt(5)) || !user.Out.Equal(decimal.NewFromInt(31)) || user.Postings != 3 {
+		t.Errorf("user movements = %+v, want in 5, out 31", user)
+	}
+	if !byType[AccountTypeFees].In.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("fees movements = %+v, want in 1", byType[AccountTypeFees])
+	}
+}
+
+func TestSolvency(t *testing.T) {
+	db := &testutil.Ledger{Trial: []models.TrialBalanceLine{
+		{Account: "users:u1", Asset: "BTC", Debits: testutil.Dec("1.5"), Credits: testutil.Dec("0.5")},
+		{Account: "users:u2", Asset: "BTC", Debits: testutil.Dec("0.25")},
+		{Account: "fees:withdrawal", Asset: "BTC", Debits: testutil.Dec("0.01")},
+		{Account: "prime:wallets:w1", Asset: "BTC", Debits: testutil.Dec("0.5"), Credits: testutil.Dec("1.76")},
+		{Account: "users:u1", Asset: "XYZ", Debits: testutil.Dec("3")},
+		{Account: "prime:wallets:w2", Asset: "XYZ", Credits: testutil.Dec("3")},
+	}}
+	prices := pricing.NewStaticSource(map[string]decimal.Decimal{"BTC": testutil.Dec("80000")})
+
+	report, err := Solvency(context.Background(), db, prices, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Solvency failed: %v", err)
+	}
+	if len(report.Lines) != 2 {
+		t.Fatalf("expected 2 assets, got %d", len(report.Lines))
+	}
+	btc := report.Lines[0]
+	if !btc.Liabilities.Equal(testutil.Dec("1.25")) || !btc.Custody.Equal(testutil.Dec("1.26")) || !btc.Surplus.Equal(testutil.Dec("0.01")) {
+		t.Errorf("BTC line = liabilities %s custody %s surplus %s, want 1.25 / 1.26 / 0.01", btc.Liabilities, btc.Custody, btc.Surplus)
+	}
+	if !btc.LiabilitiesUSD.Equal(testutil.Dec("100000")) || !report.SurplusUSD.Equal(testutil.Dec("800")) {
+		t.Errorf("BTC liabilities USD %s, surplus USD %s; want 100000 and 800", btc.LiabilitiesUSD, report.SurplusUSD)
+	}
+	if report.Lines[1].Priced || len(report.Unpriced) != 1 || report.Unpriced[0] != "XYZ" {
+		t.Errorf("expected XYZ to be unpriced, got %+v / %v", report.Lines[1], report.Unpriced)
+	}
+}
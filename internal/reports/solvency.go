@@ -0,0 +1,109 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/pricing"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+// UserLiabilities sums the user account balances of a trial balance per asset: what
+// the platform owes its users.
+func UserLiabilities(lines []models.TrialBalanceLine) map[string]decimal.Decimal {
+	amounts := make(map[string]decimal.Decimal)
+	for _, l := range lines {
+		if AccountType(l.Account) == AccountTypeUser {
+			amounts[l.Asset] = amounts[l.Asset].Add(l.Net())
+		}
+	}
+	return amounts
+}
+
+// Solvency compares user liabilities with wallet custody per asset as of asOf and
+// values both with prices in effect at asOf (latest prices for a zero asOf). With a
+// nil price source only the asset amounts are reported.
+func Solvency(ctx context.Context, db store.LedgerStore, prices pricing.PriceSource, asOf time.Time) (*models.SolvencyReport, error) {
+	lines, err := db.GetTrialBalance(ctx, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trial balance: %w", err)
+	}
+
+	liabilities := UserLiabilities(lines)
+	// Deposits credit the wallet they arrived in, so custody is the negated wallet net.
+	custody := make(map[string]decimal.Decimal)
+	for _, l := range lines {
+		if AccountType(l.Account) == AccountTypeWallet {
+			custody[l.Asset] = custody[l.Asset].Sub(l.Net())
+		}
+	}
+
+	seen := make(map[string]bool)
+	var assets []string
+	for _, m := range []map[string]decimal.Decimal{liabilities, custody} {
+		for asset := range m {
+			if !seen[asset] {
+				seen[asset] = true
+				assets = append(assets, asset)
+			}
+		}
+	}
+	sort.Strings(assets)
+
+	report := &models.SolvencyReport{AsOf: asOf}
+	for _, asset := range assets {
+		line := models.SolvencyLine{
+			Asset:       asset,
+			Liabilities: liabilities[asset],
+			Custody:     custody[asset],
+		}
+		line.Surplus = line.Custody.Sub(line.Liabilities)
+		report.Lines = append(report.Lines, line)
+	}
+	if prices == nil {
+		return report, nil
+	}
+
+	for i := range report.Lines {
+		line := &report.Lines[i]
+		price, err := prices.Price(ctx, line.Asset, asOf)
+		if errors.Is(err, pricing.ErrNoPrice) {
+			report.Unpriced = append(report.Unpriced, line.Asset)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to price %s: %w", line.Asset, err)
+		}
+		line.Priced = true
+		line.Price = price
+		line.LiabilitiesUSD = line.Liabilities.Mul(price).Round(2)
+		line.CustodyUSD = line.Custody.Mul(price).Round(2)
+		line.SurplusUSD = line.CustodyUSD.Sub(line.LiabilitiesUSD)
+		report.TotalLiabilitiesUSD = report.TotalLiabilitiesUSD.Add(line.LiabilitiesUSD)
+		report.TotalCustodyUSD = report.TotalCustodyUSD.Add(line.CustodyUSD)
+	}
+	report.SurplusUSD = report.TotalCustodyUSD.Sub(report.TotalLiabilitiesUSD)
+	return report, nil
+}
@@ -0,0 +1,358 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package rewards distributes platform reward income (staking and other REWARD
+// transactions booked to the platform user) to end users pro rata to their balances
+// in the reward asset, less a platform commission.
+package rewards
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Weighting methods
+const (
+	// MethodSnapshot weights users by their balance at one point in time
+	MethodSnapshot = "snapshot"
+	// MethodTimeWeighted weights users by their average balance over a period
+	MethodTimeWeighted = "time-weighted"
+)
+
+const (
+	maxCommissionBps   = 10000
+	platformUserPrefix = "prime-platform"
+	// shareScale is the extra precision kept when dividing before rounding allocations
+	shareScale = 18
+)
+
+// ErrAlreadyDistributed is returned when a reward has already been distributed
+var ErrAlreadyDistributed = errors.New("reward already distributed")
+
+// Reward is the platform reward transaction being distributed.
+type Reward struct {
+	TransactionId string // Prime transaction id
+	Asset         string
+	Amount        decimal.Decimal
+	EffectiveAt   time.Time
+}
+
+// Options controls how a reward is split.
+type Options struct {
+	Method        string    // MethodSnapshot (default) or MethodTimeWeighted
+	SnapshotAt    time.Time // snapshot: balance time; defaults to the reward's effective time
+	From          time.Time // time-weighted: period start (required)
+	To            time.Time // time-weighted: period end (exclusive); defaults to the reward's effective time
+	CommissionBps int64     // platform commission in basis points of the reward
+	// Precision is the number of decimals allocations are rounded to; zero uses the
+	// reward amount's own decimals. It must not exceed the backend's asset precision.
+	Precision int32
+}
+
+// DistributionId is the idempotency key for distributing a reward: one distribution
+// per Prime reward transaction.
+func DistributionId(rewardTxId string) string {
+	return "reward-distribution-" + rewardTxId
+}
+
+// Plan computes a distribution without posting it.
+func Plan(ctx context.Context, db store.LedgerStore, reward Reward, opts Options) (*models.RewardDistributionReport, error) {
+	if reward.TransactionId == "" || reward.Asset == "" {
+		return nil, fmt.Errorf("reward transaction id and asset are required")
+	}
+	if !reward.Amount.IsPositive() {
+		return nil, fmt.Errorf("reward amount must be positive, got %s", reward.Amount)
+	}
+	if opts.CommissionBps < 0 || opts.CommissionBps > maxCommissionBps {
+		return nil, fmt.Errorf("commission must be between 0 and %d bps, got %d", maxCommissionBps, opts.CommissionBps)
+	}
+	precision := opts.Precision
+	if precision == 0 && reward.Amount.Exponent() < 0 {
+		precision = -reward.Amount.Exponent()
+	}
+
+	report := &models.RewardDistributionReport{
+		DistributionId: DistributionId(reward.TransactionId),
+		RewardTxId:     reward.TransactionId,
+		Asset:          reward.Asset,
+		RewardAmount:   reward.Amount,
+		Method:         opts.Method,
+		CommissionBps:  opts.CommissionBps,
+		Precision:      precision,
+	}
+
+	var weights map[string]decimal.Decimal
+	var err error
+	switch opts.Method {
+	case "", MethodSnapshot:
+		report.Method = MethodSnapshot
+		report.SnapshotAt = opts.SnapshotAt
+		if report.SnapshotAt.IsZero() {
+			report.SnapshotAt = reward.EffectiveAt
+		}
+		if report.SnapshotAt.IsZero() {
+			return nil, fmt.Errorf("snapshot time is required when the reward has no effective time")
+		}
+		weights, err = snapshotWeights(ctx, db, reward.Asset, report.SnapshotAt)
+	case MethodTimeWeighted:
+		report.From, report.To = opts.From, opts.To
+		if report.To.IsZero() {
+			report.To = reward.EffectiveAt
+		}
+		if report.From.IsZero() || report.To.IsZero() || !report.From.Before(report.To) {
+			return nil, fmt.Errorf("time-weighted distribution needs a period with from before to")
+		}
+		weights, err = timeWeightedWeights(ctx, db, reward.Asset, report.From, report.To)
+	default:
+		return nil, fmt.Errorf("unknown distribution method %q: expected snapshot or time-weighted", opts.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	distributable := reward.Amount.Mul(decimal.NewFromInt(maxCommissionBps - opts.CommissionBps)).
+		Div(decimal.NewFromInt(maxCommissionBps)).RoundFloor(precision)
+	report.Allocations, report.TotalWeight = allocate(distributable, weights, precision)
+	if report.TotalWeight.IsZero() {
+		return nil, fmt.Errorf("no user holds %s at the distribution time", reward.Asset)
+	}
+	for _, a := range report.Allocations {
+		report.Distributed = report.Distributed.Add(a.Amount)
+	}
+	report.Commission = reward.Amount.Sub(report.Distributed)
+	report.Hash = reportHash(report)
+	return report, nil
+}
+
+// Distribute plans a distribution and posts it atomically. Distributing a reward a
+// second time returns ErrAlreadyDistributed without posting anything.
+func Distribute(ctx context.Context, db store.LedgerStore, reward Reward, opts Options) (*models.RewardDistributionReport, error) {
+	exists, err := db.HasRewardDistribution(ctx, DistributionId(reward.TransactionId))
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyDistributed, reward.TransactionId)
+	}
+
+	report, err := Plan(ctx, db, reward, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	params := store.RewardDistributionParams{
+		DistributionId: report.DistributionId,
+		RewardTxId:     report.RewardTxId,
+		Asset:          report.Asset,
+		Metadata: map[string]string{
+			"distribution_method": report.Method,
+			"commission_bps":      strconv.FormatInt(report.CommissionBps, 10),
+			"commission":          report.Commission.String(),
+			"distribution_hash":   report.Hash,
+		},
+	}
+	for _, a := range report.Allocations {
+		if a.Amount.IsPositive() {
+			params.Allocations = append(params.Allocations, store.RewardAllocation{UserId: a.UserId, Amount: a.Amount})
+		}
+	}
+	if len(params.Allocations) == 0 {
+		return nil, fmt.Errorf("reward %s is too small to allocate at %d decimals", reward.TransactionId, report.Precision)
+	}
+
+	if err := db.DistributeReward(ctx, params); err != nil {
+		if errors.Is(err, store.ErrDuplicateTransaction) {
+			return nil, fmt.Errorf("%w: %s", ErrAlreadyDistributed, reward.TransactionId)
+		}
+		return nil, err
+	}
+
+	report.Posted = true
+	report.PostedAt = time.Now().UTC()
+	zap.L().Info("Reward distribution posted",
+		zap.String("distribution_id", report.DistributionId),
+		zap.String("distributed", report.Distributed.String()),
+		zap.String("commission", report.Commission.String()),
+		zap.String("hash", report.Hash))
+	return report, nil
+}
+
+// snapshotWeights returns each end user's positive balance at the given time.
+func snapshotWeights(ctx context.Context, db store.LedgerStore, asset string, at time.Time) (map[string]decimal.Decimal, error) {
+	users, err := db.GetOpenUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	weights := make(map[string]decimal.Decimal)
+	for _, u := range users {
+		if strings.HasPrefix(u.Id, platformUserPrefix) {
+			continue
+		}
+		bal, err := db.GetUserBalanceAt(ctx, u.Id, asset, at)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s balance for user %s: %w", asset, u.Id, err)
+		}
+		if bal.IsPositive() {
+			weights[u.Id] = bal
+		}
+	}
+	return weights, nil
+}
+
+// timeWeightedWeights returns each end user's balance integrated over [from, to), in
+// balance-seconds. Negative balances count as zero.
+func timeWeightedWeights(ctx context.Context, db store.LedgerStore, asset string, from, to time.Time) (map[string]decimal.Decimal, error) {
+	users, err := db.GetOpenUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	balances := make(map[string]decimal.Decimal)
+	for _, u := range users {
+		if strings.HasPrefix(u.Id, platformUserPrefix) {
+			continue
+		}
+		// Postings at exactly from are replayed below, so start just before it
+		bal, err := db.GetUserBalanceAt(ctx, u.Id, asset, from.Add(-time.Nanosecond))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s balance for user %s: %w", asset, u.Id, err)
+		}
+		balances[u.Id] = bal
+	}
+
+	var changes []store.Posting
+	err = db.ExportPostings(ctx, store.ExportQuery{From: from, To: to}, func(postings []store.Posting) error {
+		for _, p := range postings {
+			if p.Asset != asset {
+				continue
+			}
+			if userId, ok := store.UserIdFromAccount(p.Account); ok {
+				if _, ok := balances[userId]; ok {
+					changes = append(changes, p)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s postings: %w", asset, err)
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].EffectiveAt.Before(changes[j].EffectiveAt) })
+
+	weights := make(map[string]decimal.Decimal)
+	last := make(map[string]time.Time)
+	accrue := func(userId string, until time.Time) {
+		since, ok := last[userId]
+		if !ok {
+			since = from
+		}
+		if bal := balances[userId]; bal.IsPositive() && until.After(since) {
+			weights[userId] = weights[userId].Add(bal.Mul(seconds(until.Sub(since))))
+		}
+		last[userId] = until
+	}
+	for _, p := range changes {
+		userId, _ := store.UserIdFromAccount(p.Account)
+		accrue(userId, p.EffectiveAt)
+		if p.Direction == store.DirectionDebit {
+			balances[userId] = balances[userId].Add(p.Amount)
+		} else {
+			balances[userId] = balances[userId].Sub(p.Amount)
+		}
+	}
+	for userId := range balances {
+		accrue(userId, to)
+	}
+	for userId, w := range weights {
+		if !w.IsPositive() {
+			delete(weights, userId)
+		}
+	}
+	return weights, nil
+}
+
+func seconds(d time.Duration) decimal.Decimal {
+	return decimal.NewFromInt(d.Milliseconds()).Shift(-3)
+}
+
+// allocate splits amount by weight, rounding every allocation down to precision and
+// handing the leftover smallest units to the largest remainders (ties by user id), so
+// allocations always sum to amount and the result is deterministic.
+func allocate(amount decimal.Decimal, weights map[string]decimal.Decimal, precision int32) ([]models.RewardAllocationLine, decimal.Decimal) {
+	total := decimal.Zero
+	userIds := make([]string, 0, len(weights))
+	for userId, w := range weights {
+		total = total.Add(w)
+		userIds = append(userIds, userId)
+	}
+	sort.Strings(userIds)
+	if total.IsZero() {
+		return nil, total
+	}
+
+	lines := make([]models.RewardAllocationLine, len(userIds))
+	remainders := make([]decimal.Decimal, len(userIds))
+	allocated := decimal.Zero
+	for i, userId := range userIds {
+		w := weights[userId]
+		exact := amount.Mul(w).DivRound(total, precision+shareScale)
+		lines[i] = models.RewardAllocationLine{
+			UserId: userId,
+			Weight: w,
+			Share:  w.DivRound(total, shareScale),
+			Amount: exact.RoundFloor(precision),
+		}
+		remainders[i] = exact.Sub(lines[i].Amount)
+		allocated = allocated.Add(lines[i].Amount)
+	}
+
+	unit := decimal.New(1, -precision)
+	leftover := amount.Sub(allocated).Div(unit).IntPart()
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]].GreaterThan(remainders[order[b]]) })
+	for i := int64(0); i < leftover && i < int64(len(order)); i++ {
+		lines[order[i]].Amount = lines[order[i]].Amount.Add(unit)
+	}
+	return lines, total
+}
+
+// reportHash commits to the distribution's identity and every allocation, so a saved
+// report can be matched to the ledger posting that carries the same hash.
+func reportHash(r *models.RewardDistributionReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%s|%s|%d|%s\n", r.DistributionId, r.Asset, r.RewardAmount, r.Method, r.CommissionBps, r.Distributed)
+	for _, a := range r.Allocations {
+		fmt.Fprintf(&b, "%s|%s\n", a.UserId, a.Amount)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
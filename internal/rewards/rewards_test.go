@@ -0,0 +1,186 @@
+package rewards
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+	"prime-send-receive-go/internal/testutil"
+
+	"github.com/shopspring/decimal"
+)
+
+var rewardAt = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func testStore() *testutil.Ledger {
+	db := testutil.NewLedger()
+	db.Users = []models.User{{Id: "u1"}, {Id: "u2"}, {Id: "u3"}, {Id: "prime-platform"}}
+	db.Balances = map[string]decimal.Decimal{
+		"u1:ETH":             testutil.Dec("1"),
+		"u2:ETH":             testutil.Dec("1"),
+		"u3:ETH":             testutil.Dec("1"),
+		"prime-platform:ETH": testutil.Dec("100"),
+	}
+	return db
+}
+
+func byUser(r *models.RewardDistributionReport) map[string]decimal.Decimal {
+	out := make(map[string]decimal.Decimal)
+	for _, a := range r.Allocations {
+		out[a.UserId] = a.Amount
+	}
+	return out
+}
+
+func TestPlan_LargestRemainderSumsToDistributable(t *testing.T) {
+	db := testStore()
+	reward := Reward{TransactionId: "tx1", Asset: "ETH", Amount: testutil.Dec("1.00"), EffectiveAt: rewardAt}
+
+	report, err := Plan(context.Background(), db, reward, Options{})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if _, ok := byUser(report)["prime-platform"]; ok {
+		t.Error("Expected the platform user to be excluded")
+	}
+	if !report.Distributed.Equal(testutil.Dec("1")) || !report.Commission.IsZero() {
+		t.Errorf("Expected 1 distributed and no commission, got %s and %s", report.Distributed, report.Commission)
+	}
+	// 1.00 / 3 = 0.33 each with one cent left over, which goes to the first user by id
+	alloc := byUser(report)
+	if !alloc["u1"].Equal(testutil.Dec("0.34")) || !alloc["u2"].Equal(testutil.Dec("0.33")) || !alloc["u3"].Equal(testutil.Dec("0.33")) {
+		t.Errorf("Unexpected allocations: %v", alloc)
+	}
+}
+
+func TestPlan_Commission(t *testing.T) {
+	db := testStore()
+	db.Balances["u1:ETH"] = testutil.Dec("3")
+	reward := Reward{TransactionId: "tx1", Asset: "ETH", Amount: testutil.Dec("10"), EffectiveAt: rewardAt}
+
+	report, err := Plan(context.Background(), db, reward, Options{CommissionBps: 1500, Precision: 4})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if !report.Distributed.Equal(testutil.Dec("8.5")) || !report.Commission.Equal(testutil.Dec("1.5")) {
+		t.Errorf("Expected 8.5 distributed and 1.5 commission, got %s and %s", report.Distributed, report.Commission)
+	}
+	alloc := byUser(report)
+	if !alloc["u1"].Equal(testutil.Dec("5.1")) || !alloc["u2"].Equal(testutil.Dec("1.7")) {
+		t.Errorf("Unexpected allocations: %v", alloc)
+	}
+
+	if _, err := Plan(context.Background(), db, reward, Options{CommissionBps: 10001}); err == nil {
+		t.Error("Expected a commission over 100% to be rejected")
+	}
+}
+
+func TestPlan_TimeWeighted(t *testing.T) {
+	db := testStore()
+	from := rewardAt.Add(-10 * time.Second)
+	db.Balances = map[string]decimal.Decimal{"u1:ETH": testutil.Dec("1")}
+	// u2 deposits 1 halfway through the period, so it earns a third of u1's share
+	db.Postings = []store.Posting{
+		{Account: "users:u2", EffectiveAt: from.Add(5 * time.Second), Asset: "ETH", Amount: testutil.Dec("1"), Direction: store.DirectionDebit},
+	}
+	reward := Reward{TransactionId: "tx1", Asset: "ETH", Amount: testutil.Dec("1.5"), EffectiveAt: rewardAt}
+
+	report, err := Plan(context.Background(), db, reward, Options{Method: MethodTimeWeighted, From: from})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if !report.TotalWeight.Equal(testutil.Dec("15")) {
+		t.Errorf("Expected 15 balance-seconds, got %s", report.TotalWeight)
+	}
+	alloc := byUser(report)
+	if !alloc["u1"].Equal(testutil.Dec("1")) || !alloc["u2"].Equal(testutil.Dec("0.5")) {
+		t.Errorf("Unexpected allocations: %v", alloc)
+	}
+	if _, ok := alloc["u3"]; ok {
+		t.Error("Expected a user with no balance to receive nothing")
+	}
+}
+
+func TestDistribute_IsIdempotent(t *testing.T) {
+	db := testStore()
+	reward := Reward{TransactionId: "tx1", Asset: "ETH", Amount: testutil.Dec("0.3"), EffectiveAt: rewardAt}
+
+	report, err := Distribute(context.Background(), db, reward, Options{})
+	if err != nil {
+		t.Fatalf("Distribute failed: %v", err)
+	}
+	if !report.Posted {
+		t.Error("Expected the report to be marked posted")
+	}
+	posted, ok := db.Rewards[DistributionId("tx1")]
+	if !ok || len(posted.Allocations) != 3 {
+		t.Fatalf("Expected three allocations to be posted, got %+v", posted)
+	}
+	if posted.Metadata["distribution_hash"] != report.Hash {
+		t.Error("Expected the posting to carry the report hash")
+	}
+
+	if _, err := Distribute(context.Background(), db, reward, Options{}); !errors.Is(err, ErrAlreadyDistributed) {
+		t.Errorf("Expected ErrAlreadyDistributed on re-run, got %v", err)
+	}
+}
+
+func TestDistribute_PostsToSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewSQLiteStore(t)
+	testutil.Deposit(t, db, "u1", "ETH", testutil.Dec("1"), "dep-1", rewardAt.Add(-time.Hour))
+	testutil.Deposit(t, db, "u2", "ETH", testutil.Dec("3"), "dep-2", rewardAt.Add(-time.Hour))
+	if err := db.RecordPlatformTransaction(ctx, store.PlatformTransactionParams{
+		TransactionId: "tx1", Type: "REWARD", Status: "TRANSACTION_DONE", Symbol: "ETH", Amount: "1", WalletId: "w-eth",
+	}); err != nil {
+		t.Fatalf("RecordPlatformTransaction failed: %v", err)
+	}
+
+	reward := Reward{TransactionId: "tx1", Asset: "ETH", Amount: testutil.Dec("1.00"), EffectiveAt: rewardAt}
+	if _, err := Distribute(ctx, db, reward, Options{}); err != nil {
+		t.Fatalf("Distribute failed: %v", err)
+	}
+	for user, want := range map[string]string{"u1": "1.25", "u2": "3.75", "prime-platform": "0"} {
+		balance, err := db.GetUserBalance(ctx, user, "ETH")
+		if err != nil {
+			t.Fatalf("GetUserBalance failed: %v", err)
+		}
+		if !balance.Equal(testutil.Dec(want)) {
+			t.Errorf("Expected %s to hold %s ETH, got %s", user, want, balance)
+		}
+	}
+	lines, err := db.GetTrialBalance(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("GetTrialBalance failed: %v", err)
+	}
+	testutil.AssertBalanced(t, lines)
+
+	if _, err := Distribute(ctx, db, reward, Options{}); !errors.Is(err, ErrAlreadyDistributed) {
+		t.Errorf("Expected ErrAlreadyDistributed on re-run, got %v", err)
+	}
+}
+
+func TestPlan_WeighsDeactivatedUsers(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewSQLiteStore(t)
+	testutil.Deposit(t, db, "u1", "ETH", testutil.Dec("1"), "dep-1", rewardAt.Add(-2*time.Hour))
+	testutil.Deposit(t, db, "u2", "ETH", testutil.Dec("3"), "dep-2", rewardAt.Add(-2*time.Hour))
+	if err := db.DeactivateUser(ctx, "u2"); err != nil {
+		t.Fatalf("DeactivateUser failed: %v", err)
+	}
+
+	reward := Reward{TransactionId: "tx1", Asset: "ETH", Amount: testutil.Dec("1.00"), EffectiveAt: rewardAt}
+	for _, opts := range []Options{{}, {Method: MethodTimeWeighted, From: rewardAt.Add(-time.Hour)}} {
+		report, err := Plan(ctx, db, reward, opts)
+		if err != nil {
+			t.Fatalf("Plan failed: %v", err)
+		}
+		got := byUser(report)
+		if !got["u1"].Equal(testutil.Dec("0.25")) || !got["u2"].Equal(testutil.Dec("0.75")) {
+			t.Errorf("%q allocations = %v, want u1 0.25 and u2 0.75", opts.Method, got)
+		}
+	}
+}
@@ -3,8 +3,11 @@ package store
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"prime-send-receive-go/internal/addressing"
 	"prime-send-receive-go/internal/models"
 
 	"github.com/shopspring/decimal"
@@ -15,6 +18,20 @@ var (
 	ErrDuplicateTransaction   = errors.New("duplicate transaction")
 	ErrConcurrentModification = errors.New("concurrent modification detected")
 	ErrUserNotFound           = errors.New("no user found for address")
+	ErrHoldNotFound           = errors.New("hold not found")
+	ErrHoldNotActive          = errors.New("hold is not active")
+	ErrInsufficientAvailable  = errors.New("insufficient available balance")
+	ErrUserFrozen             = errors.New("user is frozen")
+	ErrEmailTaken             = errors.New("email already in use")
+	ErrNonZeroBalance         = errors.New("user has a non-zero balance")
+	ErrAddressAllowlisted     = errors.New("address is already allowlisted")
+	ErrAddressNotAllowlisted  = errors.New("address is not on the user's withdrawal allowlist")
+	ErrAddressCoolingDown     = errors.New("allowlisted address is still in its cooldown period")
+	ErrLeaseHeld              = errors.New("lease is held by another holder")
+	ErrLeaseLost              = errors.New("lease is no longer held")
+	ErrTxStateConflict        = errors.New("transaction state changed concurrently")
+	ErrFailedTxNotFound       = errors.New("transaction is not in the retry queue")
+	ErrNotSupported           = errors.New("not supported by this backend")
 )
 
 // StoreAddressParams contains the parameters for storing a deposit address.
@@ -25,6 +42,32 @@ type StoreAddressParams struct {
 	Address           string
 	WalletId          string
 	AccountIdentifier string
+	// Tag is the destination tag or memo that, together with Address, identifies
+	// the user on tag-based networks where one address is shared between users
+	Tag string
+}
+
+// Normalize checks Address against Network's format and rewrites it in canonical
+// form. Withdrawal addresses (Network "external") are checked by shape alone.
+// Addresses on tag-based networks must carry a valid Tag.
+func (p *StoreAddressParams) Normalize() error {
+	var address, tag string
+	var err error
+	if p.Network == "external" {
+		address, err = addressing.NormalizeAny(p.Address)
+		tag = strings.TrimSpace(p.Tag)
+	} else {
+		address, err = addressing.Normalize(p.Network, p.Address)
+		if err == nil {
+			tag, err = addressing.NormalizeTag(p.Network, p.Tag)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	p.Address = address
+	p.Tag = tag
+	return nil
 }
 
 // PlatformTransactionParams captures any Prime transaction type for recording
@@ -68,6 +111,10 @@ type WithdrawalFromWalletParams struct {
 	WalletId           string
 	DestinationAddress string
 	IdempotencyKey     string
+	Network            string // raw Prime network (e.g. "base-mainnet")
+	PrimeTxId          string // on-chain transaction hash
+	NetworkFees        string
+	Fees               string
 	TransactionTime    time.Time
 }
 
@@ -83,6 +130,10 @@ type FailedWithdrawalPlatformParams struct {
 	WalletId           string
 	DestinationAddress string
 	IdempotencyKey     string
+	Network            string // raw Prime network (e.g. "base-mainnet")
+	PrimeTxId          string // on-chain transaction hash
+	NetworkFees        string
+	Fees               string
 	TransactionTime    time.Time
 }
 
@@ -99,28 +150,467 @@ type WithdrawalConfirmDirectParams struct {
 	Network            string
 	PrimeTxId          string
 	IdempotencyKey     string
+	NetworkFees        string
+	Fees               string
 	TransactionTime    time.Time
 }
 
+// RewardDistributionParams moves a platform reward to users in one atomic posting.
+// DistributionId is the idempotency key: posting it a second time returns
+// ErrDuplicateTransaction and changes nothing.
+type RewardDistributionParams struct {
+	DistributionId string
+	RewardTxId     string // Prime transaction id of the reward being distributed
+	Asset          string
+	Allocations    []RewardAllocation
+	Metadata       map[string]string // audit context (method, commission, report hash)
+}
+
+// RewardAllocation is one user's share of a reward distribution.
+type RewardAllocation struct {
+	UserId string
+	Amount decimal.Decimal
+}
+
+// FeeChargeParams moves a fee from a user to the fees:{Kind} account. Reference is
+// the idempotency key: charging it a second time returns ErrDuplicateTransaction.
+type FeeChargeParams struct {
+	Reference   string
+	UserId      string
+	Asset       string
+	Amount      decimal.Decimal // positive amount taken from the user
+	Kind        string          // fee account suffix, e.g. "custody"
+	Description string          // shown on the user's transaction history
+	Metadata    map[string]string
+}
+
+// PlaceHoldParams reserves part of a user's available balance. HoldId is chosen by the
+// caller and is the idempotency key for the hold and its capture or release.
+type PlaceHoldParams struct {
+	HoldId    string
+	UserId    string
+	Asset     string
+	Amount    decimal.Decimal
+	ExpiresAt time.Time // zero means the hold never expires
+	Reason    string
+}
+
+// HoldsAccountSuffix names the sub-account that holds a user's reserved funds in
+// backends that move held funds (Formance: users:{id}:holds).
+const HoldsAccountSuffix = ":holds"
+
+// UserIdFromAccount returns the user owning a ledger account: users:{id} and its
+// holds sub-account users:{id}:holds both belong to {id}.
+func UserIdFromAccount(account string) (string, bool) {
+	userId, ok := strings.CutPrefix(account, "users:")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSuffix(userId, HoldsAccountSuffix), true
+}
+
+// Metadata keys for a transaction's on-chain hash. Platform transactions
+// recorded before the key was renamed carry it as transaction_id only.
+const (
+	MetadataPrimeTxId       = "prime_tx_id"
+	MetadataLegacyPrimeTxId = "transaction_id"
+)
+
+// PrimeTxIdFromMetadata returns the on-chain hash from transaction metadata,
+// falling back to the legacy key.
+func PrimeTxIdFromMetadata(meta map[string]string) string {
+	if id := meta[MetadataPrimeTxId]; id != "" {
+		return id
+	}
+	return meta[MetadataLegacyPrimeTxId]
+}
+
+// FreezeParams describes a freeze or unfreeze of a user. Reason must be one of
+// models.FreezeReasons and Actor names the operator making the change.
+type FreezeParams struct {
+	UserId string
+	Reason string
+	Actor  string
+	Note   string
+}
+
+// Validate rejects a freeze without a user or actor, or with a reason outside models.FreezeReasons.
+func (p FreezeParams) Validate() error {
+	if p.UserId == "" {
+		return errors.New("user id is required")
+	}
+	if !models.ValidFreezeReason(p.Reason) {
+		return fmt.Errorf("invalid reason %q, expected one of %s", p.Reason, strings.Join(models.FreezeReasons, ", "))
+	}
+	if p.Actor == "" {
+		return errors.New("actor is required")
+	}
+	return nil
+}
+
+// QuarantineUserId is the platform user that receives deposits to frozen users.
+// Backends that keep one platform user per portfolio pass the portfolio id.
+func QuarantineUserId(portfolioId string) string {
+	if portfolioId == "" {
+		return "prime-platform-quarantine"
+	}
+	return "prime-platform-quarantine-" + portfolioId
+}
+
+// AllowlistParams adds or removes a withdrawal destination on a user's allowlist.
+// An entry is identified by Address and Tag, the destination tag or memo, which is
+// required on tag-based networks. Asset, Network, AddressBookState and Cooldown are
+// only used when adding; Asset and Network limit the entry to that asset and network,
+// and the address cannot be used for withdrawals until Cooldown has passed.
+type AllowlistParams struct {
+	UserId           string
+	Address          string
+	Tag              string
+	Asset            string
+	Network          string
+	AddressBookState string
+	Actor            string
+	Note             string
+	Cooldown         time.Duration
+}
+
+// Validate rejects an entry without a user, address or actor, one without a tag on a
+// tag-based network, or one with a negative cooldown.
+func (p AllowlistParams) Validate() error {
+	if p.UserId == "" {
+		return errors.New("user id is required")
+	}
+	if strings.TrimSpace(p.Address) == "" {
+		return errors.New("address is required")
+	}
+	if addressing.TagRequired(p.Network) && strings.TrimSpace(p.Tag) == "" {
+		return fmt.Errorf("%w for %s", addressing.ErrTagRequired, p.Network)
+	}
+	if p.Actor == "" {
+		return errors.New("actor is required")
+	}
+	if p.Cooldown < 0 {
+		return errors.New("cooldown cannot be negative")
+	}
+	return nil
+}
+
+// UpdateUserParams changes a user's name and/or email. Empty fields are left unchanged.
+// The new email must not belong to any other user, active or not.
+type UpdateUserParams struct {
+	UserId string
+	Name   string
+	Email  string
+}
+
+// Validate rejects an update without a user, or one that changes neither name nor email.
+func (p UpdateUserParams) Validate() error {
+	if p.UserId == "" {
+		return errors.New("user id is required")
+	}
+	if p.Name == "" && p.Email == "" {
+		return errors.New("nothing to update: name or email is required")
+	}
+	return nil
+}
+
+// CloseUserParams closes a user account for good. Without Force every balance must
+// be zero; with Force each positive balance is swept to the SweepTo account path
+// (e.g. "users:{id}" or "fees:closure") before the account is closed.
+type CloseUserParams struct {
+	UserId  string
+	SweepTo string
+	Force   bool
+}
+
+// Validate rejects a close without a user, a forced close without a sweep account, a sweep
+// without force, and a sweep back into the account being closed.
+func (p CloseUserParams) Validate() error {
+	if p.UserId == "" {
+		return errors.New("user id is required")
+	}
+	if p.Force && p.SweepTo == "" {
+		return errors.New("a sweep account is required to force a close")
+	}
+	if !p.Force && p.SweepTo != "" {
+		return errors.New("sweeping balances requires force")
+	}
+	if p.SweepTo == "users:"+p.UserId || strings.HasPrefix(p.SweepTo, "users:"+p.UserId+":") {
+		return errors.New("cannot sweep balances to the account being closed")
+	}
+	return nil
+}
+
+// SuspenseUserId is the platform user that receives deposits to retired addresses
+// and to addresses of deactivated or closed users.
+// Backends that keep one platform user per portfolio pass the portfolio id.
+func SuspenseUserId(portfolioId string) string {
+	if portfolioId == "" {
+		return "prime-platform-suspense"
+	}
+	return "prime-platform-suspense-" + portfolioId
+}
+
+// TransactionQuery filters SearchTransactions. Zero values mean "no filter";
+// all set filters must match.
+type TransactionQuery struct {
+	UserId       string
+	Asset        string
+	Network      string           // raw Prime network (e.g. "base-mainnet")
+	Type         string           // SQLite transaction_type (deposit, withdrawal, conversion-in, ...); Formance event_type
+	Status       string           // SQLite status; Formance prime_status
+	From         time.Time        // effective time, inclusive
+	To           time.Time        // effective time, exclusive
+	MinAmount    *decimal.Decimal // on the absolute amount, inclusive
+	MaxAmount    *decimal.Decimal // on the absolute amount, inclusive
+	ExternalTxId string           // Prime transaction id
+	TxHash       string           // on-chain transaction hash
+	Address      string           // deposit, source or destination address
+	Limit        int
+	Cursor       string // opaque; NextCursor of a previous page
+}
+
+// TransactionPage is one page of SearchTransactions results, newest effective time first.
+type TransactionPage struct {
+	Transactions []models.Transaction
+	NextCursor   string // empty when there are no more results
+}
+
+// Posting directions. Every posting produces one debit row on the receiving account
+// and one credit row on the sending account.
+const (
+	DirectionDebit  = "debit"
+	DirectionCredit = "credit"
+)
+
+// ExportQuery selects the postings streamed by ExportPostings.
+type ExportQuery struct {
+	From, To  time.Time // effective time; From inclusive, To exclusive; zero means unbounded
+	ChunkSize int       // transactions per backend round trip; <= 0 uses the backend default
+}
+
+// Posting is one side of a ledger movement, flattened for export.
+type Posting struct {
+	TransactionId string          // backend transaction id
+	Sequence      int             // position of this row within its transaction
+	EffectiveAt   time.Time
+	Account       string
+	Counterparty  string
+	Asset         string
+	Amount        decimal.Decimal // always positive; see Direction
+	Direction     string          // DirectionDebit or DirectionCredit
+	ExternalTxId  string
+	Metadata      map[string]string
+}
+
+// PostingHandler receives export rows one chunk at a time. Returning an error stops the export.
+type PostingHandler func(postings []Posting) error
+
+// AcquireLeaseParams acquires or renews a named lease for Holder. A live lease
+// held by someone else fails with ErrLeaseHeld; an expired one is taken over
+// with the next fencing token.
+type AcquireLeaseParams struct {
+	Name   string
+	Holder string
+	TTL    time.Duration
+}
+
+// Validate rejects a lease without a name or holder, or with a TTL ≤ 0.
+func (p AcquireLeaseParams) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("lease name is required")
+	}
+	if strings.TrimSpace(p.Holder) == "" {
+		return errors.New("lease holder is required")
+	}
+	if p.TTL <= 0 {
+		return errors.New("lease ttl must be positive")
+	}
+	return nil
+}
+
+// TxTransitionParams records a Prime transaction moving from FromState to
+// ToState. Backends apply it only if the transaction is still in FromState
+// (models.TxStateNew for one they have not seen), failing with
+// ErrTxStateConflict otherwise.
+type TxTransitionParams struct {
+	PrimeTxId   string
+	Kind        string
+	FromState   string
+	ToState     string
+	PrimeStatus string
+	Action      string
+	Detail      string
+}
+
+// Validate rejects a transition without a Prime transaction id or action, of a kind other
+// than deposit or withdrawal, or with an empty state or a target state of new.
+func (p TxTransitionParams) Validate() error {
+	if strings.TrimSpace(p.PrimeTxId) == "" {
+		return errors.New("prime transaction id is required")
+	}
+	if p.Kind != models.TxKindDeposit && p.Kind != models.TxKindWithdrawal {
+		return fmt.Errorf("invalid transaction kind %q", p.Kind)
+	}
+	if p.FromState == "" || p.ToState == "" || p.ToState == models.TxStateNew {
+		return fmt.Errorf("invalid transition %q -> %q", p.FromState, p.ToState)
+	}
+	if p.Action == "" {
+		return errors.New("transition action is required")
+	}
+	return nil
+}
+
+// RecordTxFailureParams records a failed attempt at processing a Prime
+// transaction. The first failure queues it; each further one counts an attempt,
+// and the one that exhausts Policy moves it to the dead-letter queue.
+type RecordTxFailureParams struct {
+	PortfolioId string
+	Wallet      models.WalletInfo
+	Transaction models.PrimeTransaction
+	Error       string
+	Policy      models.RetryPolicy
+}
+
+// Validate rejects a failure without a Prime transaction id or portfolio, or with a
+// retry base delay ≤ 0.
+func (p RecordTxFailureParams) Validate() error {
+	if strings.TrimSpace(p.Transaction.Id) == "" {
+		return errors.New("prime transaction id is required")
+	}
+	if strings.TrimSpace(p.PortfolioId) == "" {
+		return errors.New("portfolio id is required")
+	}
+	if p.Policy.BaseDelay <= 0 {
+		return errors.New("retry base delay must be positive")
+	}
+	return nil
+}
+
+// Apply returns the retry queue entry after this failure, given the existing
+// entry or nil. A discarded transaction stays discarded.
+func (p RecordTxFailureParams) Apply(existing *models.FailedTransaction, now time.Time) *models.FailedTransaction {
+	f := &models.FailedTransaction{
+		PrimeTxId:     p.Transaction.Id,
+		PortfolioId:   p.PortfolioId,
+		Wallet:        p.Wallet,
+		Transaction:   p.Transaction,
+		State:         models.RetryStateQueued,
+		FirstFailedAt: now,
+	}
+	if existing != nil {
+		f.Attempts = existing.Attempts
+		f.FirstFailedAt = existing.FirstFailedAt
+		f.UpdatedBy, f.Note = existing.UpdatedBy, existing.Note
+		if existing.State == models.RetryStateDiscarded {
+			f.State = models.RetryStateDiscarded
+		}
+	}
+	f.Attempts++
+	f.LastError = p.Error
+	f.LastFailedAt = now
+	f.NextAttemptAt = now.Add(p.Policy.Delay(f.Attempts))
+	if f.State == models.RetryStateQueued && p.Policy.Exhausted(f.Attempts) {
+		f.State = models.RetryStateDead
+	}
+	return f
+}
+
+// FailedTxQuery filters the retry queue. Zero values match everything.
+type FailedTxQuery struct {
+	PortfolioId string
+	State       string    // one of models.RetryState*
+	DueBy       time.Time // only transactions whose next attempt is due by then
+}
+
+// UpdateFailedTxParams identifies a retry or discard made by an operator
+type UpdateFailedTxParams struct {
+	PrimeTxId string
+	Actor     string
+	Note      string
+}
+
 // LedgerStore defines the contract that every backend (SQLite, Formance, ...) must satisfy.
 type LedgerStore interface {
 	// --- Users ---
 	GetUsers(ctx context.Context) ([]models.User, error)
+	// GetOpenUsers lists every user that has not been closed, active or deactivated.
+	// Anything that must cover every balance (liabilities, rewards) enumerates these.
+	GetOpenUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, userId string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	CreateUser(ctx context.Context, userId, name, email string) (*models.User, error)
+	UpdateUser(ctx context.Context, params UpdateUserParams) (*models.User, error)
+	DeactivateUser(ctx context.Context, userId string) error
+	CloseUser(ctx context.Context, params CloseUserParams) (*models.UserClosure, error)
+
+	// --- Compliance ---
+	FreezeUser(ctx context.Context, params FreezeParams) (*models.FreezeStatus, error)
+	UnfreezeUser(ctx context.Context, params FreezeParams) (*models.FreezeStatus, error)
+	GetFreezeStatus(ctx context.Context, userId string) (*models.FreezeStatus, error)
+	GetFreezeEvents(ctx context.Context, userId string) ([]models.FreezeEvent, error)
+
+	// --- Withdrawal allowlist ---
+	AddAllowlistAddress(ctx context.Context, params AllowlistParams) (*models.AllowlistEntry, error)
+	RemoveAllowlistAddress(ctx context.Context, params AllowlistParams) error
+	GetAllowlist(ctx context.Context, userId string) ([]models.AllowlistEntry, error)
+	GetAllowlistEvents(ctx context.Context, userId string) ([]models.AllowlistEvent, error)
+
+	// --- Leases ---
+	// Ledger writes made with a models.LeaseFence in their context fail with
+	// ErrLeaseLost once the lease has changed hands.
+	AcquireLease(ctx context.Context, params AcquireLeaseParams) (*models.Lease, error)
+	ReleaseLease(ctx context.Context, fence models.LeaseFence) error
+	GetLease(ctx context.Context, name string) (*models.Lease, error)
+	CheckLease(ctx context.Context, fence models.LeaseFence) error
+
+	// --- Transaction lifecycle ---
+	// RecordTxTransition moves a transaction to its next state and appends the
+	// history entry; QuarantineTx only appends the rejected transition and flags
+	// the transaction. GetTxState returns nil for a transaction never recorded.
+	GetTxState(ctx context.Context, primeTxId string) (*models.TxState, error)
+	RecordTxTransition(ctx context.Context, params TxTransitionParams) (*models.TxState, error)
+	QuarantineTx(ctx context.Context, params TxTransitionParams) error
+	GetTxHistory(ctx context.Context, primeTxId string) ([]models.TxTransition, error)
+	GetQuarantinedTxs(ctx context.Context) ([]models.TxState, error)
+
+	// --- Retry queue ---
+	// Transactions the listener failed to process. ResolveFailedTx removes one
+	// once it succeeds; RequeueFailedTx moves one back from the dead-letter
+	// queue for an immediate attempt. Both fail with ErrFailedTxNotFound for an
+	// unknown id, and GetFailedTx returns nil.
+	RecordTxFailure(ctx context.Context, params RecordTxFailureParams) (*models.FailedTransaction, error)
+	GetFailedTx(ctx context.Context, primeTxId string) (*models.FailedTransaction, error)
+	GetFailedTxs(ctx context.Context, query FailedTxQuery) ([]models.FailedTransaction, error)
+	ResolveFailedTx(ctx context.Context, primeTxId string) error
+	RequeueFailedTx(ctx context.Context, params UpdateFailedTxParams) (*models.FailedTransaction, error)
+	DiscardFailedTx(ctx context.Context, params UpdateFailedTxParams) error
 
 	// --- Addresses ---
 	StoreAddress(ctx context.Context, params StoreAddressParams) (*models.Address, error)
 	GetAddresses(ctx context.Context, userId, asset, network string) ([]models.Address, error)
 	GetAllUserAddresses(ctx context.Context, userId string) ([]models.Address, error)
-	FindUserByAddress(ctx context.Context, address string) (*models.User, *models.Address, error)
+	// FindUserByAddress resolves the active owner of an (address, tag) pair. Pass
+	// an empty tag for networks that give every user their own address.
+	FindUserByAddress(ctx context.Context, address, tag string) (*models.User, *models.Address, error)
 
 	// --- Balances ---
 	GetUserBalance(ctx context.Context, userId, asset string) (decimal.Decimal, error)
+	GetUserBalanceAt(ctx context.Context, userId, asset string, at time.Time) (decimal.Decimal, error)
 	GetAllUserBalances(ctx context.Context, userId string) ([]models.AccountBalance, error)
+	GetUserBalanceDetail(ctx context.Context, userId, asset string) (*models.BalanceDetail, error)
+
+	// --- Holds ---
+	PlaceHold(ctx context.Context, params PlaceHoldParams) (*models.Hold, error)
+	CaptureHold(ctx context.Context, holdId string, amount decimal.Decimal) (*models.Hold, error)
+	ReleaseHold(ctx context.Context, holdId string) (*models.Hold, error)
+	GetHold(ctx context.Context, holdId string) (*models.Hold, error)
+	GetActiveHolds(ctx context.Context, userId, asset string) ([]models.Hold, error)
+	ExpireHolds(ctx context.Context, now time.Time) ([]models.Hold, error)
 
 	// --- Transactions ---
+	// ProcessDepositPending returns ErrNotSupported on backends without a
+	// pending phase; the deposit is then booked directly on confirmation.
 	ProcessDepositPending(ctx context.Context, asset, walletId string, amount decimal.Decimal, transactionId, depositAddress string) error
 	ConfirmDeposit(ctx context.Context, address, asset string, amount decimal.Decimal, transactionId string) error
 	ProcessDeposit(ctx context.Context, address, asset string, amount decimal.Decimal, transactionId string) error
@@ -134,7 +624,17 @@ type LedgerStore interface {
 	RecordFailedWithdrawalPlatform(ctx context.Context, params FailedWithdrawalPlatformParams) error
 	RecordPlatformTransaction(ctx context.Context, params PlatformTransactionParams) error
 	RecordConversion(ctx context.Context, params ConversionParams) error
+	DistributeReward(ctx context.Context, params RewardDistributionParams) error
+	HasRewardDistribution(ctx context.Context, distributionId string) (bool, error)
+	ChargeFee(ctx context.Context, params FeeChargeParams) error
+	HasFeeCharge(ctx context.Context, reference string) (bool, error)
 	GetTransactionHistory(ctx context.Context, userId, asset string, limit, offset int) ([]models.Transaction, error)
+	FindTransactionsByMetadata(ctx context.Context, filter map[string]string, limit int) ([]models.Transaction, error)
+	SearchTransactions(ctx context.Context, query TransactionQuery) (*TransactionPage, error)
+	ExportPostings(ctx context.Context, query ExportQuery, handle PostingHandler) error
+
+	// --- Accounting ---
+	GetTrialBalance(ctx context.Context, asOf time.Time) ([]models.TrialBalanceLine, error)
 	GetMostRecentTransactionTime(ctx context.Context) (time.Time, error)
 	ReconcileUserBalance(ctx context.Context, userId, asset string) error
 
@@ -1,7 +1,12 @@
 package store
 
 import (
+	"errors"
 	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/addressing"
+	"prime-send-receive-go/internal/models"
 )
 
 // Compile-time checks that the interface is importable and usable.
@@ -16,3 +21,171 @@ func TestLedgerStoreInterfaceExists(t *testing.T) {
 	// Ensure the interface is non-nil type.
 	var _ LedgerStore
 }
+
+func TestUserIdFromAccount(t *testing.T) {
+	tests := []struct {
+		account string
+		want    string
+		ok      bool
+	}{
+		{"users:u1", "u1", true},
+		{"users:u1:holds", "u1", true},
+		{"prime:wallets:w1", "", false},
+		{"fees:custody", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := UserIdFromAccount(tt.account)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("UserIdFromAccount(%q) = %q, %v, want %q, %v", tt.account, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPrimeTxIdFromMetadata(t *testing.T) {
+	if got := PrimeTxIdFromMetadata(map[string]string{MetadataPrimeTxId: "0xnew", MetadataLegacyPrimeTxId: "0xold"}); got != "0xnew" {
+		t.Errorf("Expected the current key to win, got %q", got)
+	}
+	if got := PrimeTxIdFromMetadata(map[string]string{MetadataLegacyPrimeTxId: "0xold"}); got != "0xold" {
+		t.Errorf("Expected the legacy key as a fallback, got %q", got)
+	}
+}
+
+func TestFreezeParamsValidate(t *testing.T) {
+	tests := []struct {
+		params FreezeParams
+		ok     bool
+	}{
+		{FreezeParams{UserId: "u1", Reason: "fraud", Actor: "ops"}, true},
+		{FreezeParams{UserId: "u1", Reason: "fraud"}, false},
+		{FreezeParams{UserId: "u1", Reason: "bored", Actor: "ops"}, false},
+		{FreezeParams{Reason: "fraud", Actor: "ops"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.params.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok=%v", tt.params, err, tt.ok)
+		}
+	}
+}
+
+func TestCloseUserParamsValidate(t *testing.T) {
+	tests := []struct {
+		params CloseUserParams
+		ok     bool
+	}{
+		{CloseUserParams{UserId: "u1"}, true},
+		{CloseUserParams{UserId: "u1", Force: true, SweepTo: "users:u2"}, true},
+		{CloseUserParams{UserId: "u1", Force: true}, false},
+		{CloseUserParams{UserId: "u1", SweepTo: "users:u2"}, false},
+		{CloseUserParams{UserId: "u1", Force: true, SweepTo: "users:u1"}, false},
+		{CloseUserParams{UserId: "u1", Force: true, SweepTo: "users:u1:holds"}, false},
+		{CloseUserParams{Force: true, SweepTo: "users:u2"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.params.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok=%v", tt.params, err, tt.ok)
+		}
+	}
+}
+
+func TestAllowlistParamsValidate(t *testing.T) {
+	tests := []struct {
+		params AllowlistParams
+		ok     bool
+	}{
+		{AllowlistParams{UserId: "u1", Address: "0xabc", Actor: "ops"}, true},
+		{AllowlistParams{UserId: "u1", Address: "0xabc", Actor: "ops", Cooldown: time.Hour}, true},
+		{AllowlistParams{UserId: "u1", Address: " ", Actor: "ops"}, false},
+		{AllowlistParams{UserId: "u1", Address: "0xabc"}, false},
+		{AllowlistParams{Address: "0xabc", Actor: "ops"}, false},
+		{AllowlistParams{UserId: "u1", Address: "0xabc", Actor: "ops", Cooldown: -time.Minute}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.params.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok=%v", tt.params, err, tt.ok)
+		}
+	}
+}
+
+func TestStoreAddressParamsNormalize(t *testing.T) {
+	p := StoreAddressParams{Network: "base-mainnet", Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+	if err := p.Normalize(); err != nil || p.Address != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+		t.Errorf("Normalize() = %v, address %s", err, p.Address)
+	}
+	p = StoreAddressParams{Network: "external", Address: " BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4 "}
+	if err := p.Normalize(); err != nil || p.Address != "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4" {
+		t.Errorf("Normalize() = %v, address %s", err, p.Address)
+	}
+	p = StoreAddressParams{Network: "bitcoin-mainnet", Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+	if err := p.Normalize(); err == nil {
+		t.Error("Expected an EVM address to be rejected on bitcoin-mainnet")
+	}
+	p = StoreAddressParams{Network: "xrp-mainnet", Address: "rPEPPER7kfTD9w2To4CQk6UCfuHM9c6GDY", Tag: " 1042 "}
+	if err := p.Normalize(); err != nil || p.Tag != "1042" {
+		t.Errorf("Normalize() = %v, tag %q", err, p.Tag)
+	}
+	p = StoreAddressParams{Network: "xrp-mainnet", Address: "rPEPPER7kfTD9w2To4CQk6UCfuHM9c6GDY"}
+	if err := p.Normalize(); !errors.Is(err, addressing.ErrTagRequired) {
+		t.Errorf("Expected ErrTagRequired for an untagged XRP address, got %v", err)
+	}
+}
+
+func TestRecordTxFailureParamsApply(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	params := RecordTxFailureParams{
+		PortfolioId: "p1",
+		Transaction: models.PrimeTransaction{Id: "tx1", Type: "DEPOSIT", Status: "TRANSACTION_IMPORTED"},
+		Error:       "no user for address",
+		Policy:      models.RetryPolicy{BaseDelay: time.Minute, MaxDelay: 3 * time.Minute, MaxAttempts: 3},
+	}
+	if err := params.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	first := params.Apply(nil, now)
+	if first.State != models.RetryStateQueued || first.Attempts != 1 || !first.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Unexpected first failure %+v", first)
+	}
+	second := params.Apply(first, now.Add(time.Minute))
+	if second.Attempts != 2 || !second.NextAttemptAt.Equal(now.Add(3*time.Minute)) || !second.FirstFailedAt.Equal(now) {
+		t.Errorf("Expected the delay to double and the first failure to be kept, got %+v", second)
+	}
+	third := params.Apply(second, now.Add(3*time.Minute))
+	if third.State != models.RetryStateDead || third.Attempts != 3 {
+		t.Errorf("Expected dead letter after 3 attempts, got %+v", third)
+	}
+
+	// An operator's discard sticks even if the transaction fails again at a later status
+	third.State = models.RetryStateDiscarded
+	if again := params.Apply(third, now.Add(time.Hour)); again.State != models.RetryStateDiscarded {
+		t.Errorf("Expected discarded entry to stay discarded, got %s", again.State)
+	}
+
+	if err := (RecordTxFailureParams{PortfolioId: "p1", Transaction: params.Transaction}).Validate(); err == nil {
+		t.Error("Expected an error for a policy without a base delay")
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := models.RetryPolicy{BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute, MaxAttempts: 10}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{5, 5 * time.Minute},
+		{50, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Delay(tt.attempts); got != tt.want {
+			t.Errorf("Delay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+	if policy.Exhausted(9) || !policy.Exhausted(10) {
+		t.Error("Expected the policy to be exhausted at exactly MaxAttempts")
+	}
+	if (models.RetryPolicy{BaseDelay: time.Second}).Exhausted(1000) {
+		t.Error("Expected a policy without MaxAttempts to retry forever")
+	}
+}
@@ -0,0 +1,164 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package testutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+// Ledger is an in-memory LedgerStore built from fixed users, opening balances, postings
+// and a trial balance. It implements the read paths used by reports, snapshots and
+// accruals plus the idempotent reward and fee postings; any other method panics
+// through the nil embedded interface, so a test that strays past them fails loudly.
+// Reward and fee postings are only recorded, not applied to balances; tests of what
+// they post run against NewSQLiteStore.
+type Ledger struct {
+	store.LedgerStore
+
+	mu       sync.Mutex
+	Users    []models.User
+	Balances map[string]decimal.Decimal // BalanceKey -> balance before any Postings
+	Postings []store.Posting
+	Trial    []models.TrialBalanceLine
+
+	// ExportChunk splits ExportPostings into batches of this size; zero sends one batch
+	ExportChunk int
+
+	Rewards map[string]store.RewardDistributionParams // by distribution id
+	Fees    map[string]store.FeeChargeParams          // by reference
+}
+
+// NewLedger returns an empty Ledger ready for postings
+func NewLedger() *Ledger {
+	return &Ledger{
+		Balances: make(map[string]decimal.Decimal),
+		Rewards:  make(map[string]store.RewardDistributionParams),
+		Fees:     make(map[string]store.FeeChargeParams),
+	}
+}
+
+// BalanceKey indexes Ledger.Balances
+func BalanceKey(userId, asset string) string { return userId + ":" + asset }
+
+func (l *Ledger) GetUsers(context.Context) ([]models.User, error) {
+	return l.Users, nil
+}
+
+func (l *Ledger) GetOpenUsers(context.Context) ([]models.User, error) {
+	return l.Users, nil
+}
+
+// GetUserBalance is the opening balance plus every user posting
+func (l *Ledger) GetUserBalance(_ context.Context, userId, asset string) (decimal.Decimal, error) {
+	return l.balance(userId, asset, nil), nil
+}
+
+// GetUserBalanceAt is the opening balance plus user postings effective at or before at
+func (l *Ledger) GetUserBalanceAt(_ context.Context, userId, asset string, at time.Time) (decimal.Decimal, error) {
+	return l.balance(userId, asset, &at), nil
+}
+
+func (l *Ledger) balance(userId, asset string, at *time.Time) decimal.Decimal {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bal := l.Balances[BalanceKey(userId, asset)]
+	for _, p := range l.Postings {
+		if p.Account != "users:"+userId || p.Asset != asset || (at != nil && p.EffectiveAt.After(*at)) {
+			continue
+		}
+		if p.Direction == store.DirectionDebit {
+			bal = bal.Add(p.Amount)
+		} else {
+			bal = bal.Sub(p.Amount)
+		}
+	}
+	return bal
+}
+
+// ExportPostings streams postings in [From, To) in slice order, as an export by
+// transaction id would; a zero bound is open
+func (l *Ledger) ExportPostings(_ context.Context, q store.ExportQuery, handle store.PostingHandler) error {
+	l.mu.Lock()
+	var out []store.Posting
+	for _, p := range l.Postings {
+		if !q.From.IsZero() && p.EffectiveAt.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && !p.EffectiveAt.Before(q.To) {
+			continue
+		}
+		out = append(out, p)
+	}
+	l.mu.Unlock()
+
+	size := l.ExportChunk
+	if size <= 0 || size > len(out) {
+		return handle(out)
+	}
+	for start := 0; start < len(out); start += size {
+		if err := handle(out[start:min(start+size, len(out))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTrialBalance returns the fixed trial balance whatever the as-of time
+func (l *Ledger) GetTrialBalance(context.Context, time.Time) ([]models.TrialBalanceLine, error) {
+	return l.Trial, nil
+}
+
+func (l *Ledger) HasRewardDistribution(_ context.Context, distributionId string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.Rewards[distributionId]
+	return ok, nil
+}
+
+func (l *Ledger) DistributeReward(_ context.Context, params store.RewardDistributionParams) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.Rewards[params.DistributionId]; ok {
+		return store.ErrDuplicateTransaction
+	}
+	l.Rewards[params.DistributionId] = params
+	return nil
+}
+
+func (l *Ledger) HasFeeCharge(_ context.Context, reference string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.Fees[reference]
+	return ok, nil
+}
+
+func (l *Ledger) ChargeFee(_ context.Context, params store.FeeChargeParams) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.Fees[params.Reference]; ok {
+		return store.ErrDuplicateTransaction
+	}
+	l.Fees[params.Reference] = params
+	return nil
+}
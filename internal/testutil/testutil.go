@@ -0,0 +1,91 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package testutil holds fixtures shared by package tests: an in-memory LedgerStore
+// for report-style code that only reads balances and postings, and a throwaway
+// SQLite store for code that needs the real one.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"prime-send-receive-go/internal/database"
+	"prime-send-receive-go/internal/models"
+	"prime-send-receive-go/internal/store"
+
+	"github.com/shopspring/decimal"
+)
+
+// Dec parses a decimal literal, panicking on a typo in the test
+func Dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+// NewSQLiteStore opens a fresh SQLite store in the test's temp dir and closes it on cleanup
+func NewSQLiteStore(t *testing.T) *database.Service {
+	t.Helper()
+	db, err := database.NewService(context.Background(), models.DatabaseConfig{
+		Path:         filepath.Join(t.TempDir(), "ledger.db"),
+		MaxOpenConns: 4,
+		MaxIdleConns: 4,
+		PingTimeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open SQLite store: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// Deposit credits amount of asset to userId through the SQLite deposit path, effective
+// at at. The user and a deposit address for the asset are created on first use.
+func Deposit(t *testing.T, db *database.Service, userId, asset string, amount decimal.Decimal, txId string, at time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	address := fmt.Sprintf("deposit-%s-%s", userId, asset)
+	user, _, err := db.FindUserByAddress(ctx, address, "")
+	if err != nil {
+		t.Fatalf("FindUserByAddress failed: %v", err)
+	}
+	if user == nil {
+		if _, err := db.CreateUser(ctx, userId, userId, userId+"@example.com"); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		if _, err := db.StoreAddress(ctx, store.StoreAddressParams{UserId: userId, Asset: asset, Network: "test", Address: address}); err != nil {
+			t.Fatalf("StoreAddress failed: %v", err)
+		}
+	}
+	ctx = models.WithPrimeDepositContext(ctx, &models.PrimeDepositContext{TransactionId: txId, TransactionTime: at})
+	if err := db.ProcessDeposit(ctx, address, asset, amount, txId); err != nil {
+		t.Fatalf("ProcessDeposit failed: %v", err)
+	}
+}
+
+// AssertBalanced fails the test unless debits equal credits for every asset
+func AssertBalanced(t *testing.T, lines []models.TrialBalanceLine) {
+	t.Helper()
+	net := make(map[string]decimal.Decimal)
+	for _, l := range lines {
+		net[l.Asset] = net[l.Asset].Add(l.Debits).Sub(l.Credits)
+	}
+	for asset, n := range net {
+		if !n.IsZero() {
+			t.Errorf("Expected %s debits to equal credits, off by %s", asset, n)
+		}
+	}
+}
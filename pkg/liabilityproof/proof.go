@@ -0,0 +1,155 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package liabilityproof defines the proof-of-liabilities Merkle sum tree format and
+// verifies inclusion proofs. It depends only on the standard library and decimal so
+// customers can vendor it to check their own proof against the published root.
+//
+// Every node carries a hash and the per-asset sum of the balances below it. A leaf
+// commits to a user id, a per-snapshot nonce and that user's balances; an inner node
+// commits to both children's hashes and sums, so no balance can be left out or
+// counted twice without changing the root.
+package liabilityproof
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	leafPrefix = "pol-leaf"
+	nodePrefix = "pol-node"
+)
+
+// Sides of a sibling in a proof path
+const (
+	SideLeft  = "left"
+	SideRight = "right"
+)
+
+// Node is a hash and the per-asset sums it commits to, in Proof.Assets order.
+type Node struct {
+	Hash string            `json:"hash"`
+	Sums []decimal.Decimal `json:"sums"`
+}
+
+// Step is a sibling on the path from a leaf to the root.
+type Step struct {
+	Side string `json:"side"` // which side the sibling is on
+	Node
+}
+
+// Proof shows that one user's balances are included in a published root.
+type Proof struct {
+	Assets   []string          `json:"assets"` // sorted; every sum and balance follows this order
+	UserId   string            `json:"user_id"`
+	Nonce    string            `json:"nonce"`
+	Balances []decimal.Decimal `json:"balances"`
+	Path     []Step            `json:"path"`
+	Root     Node              `json:"root"`
+}
+
+func encodeSums(sums []decimal.Decimal) string {
+	parts := make([]string, len(sums))
+	for i, s := range sums {
+		parts[i] = s.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func digest(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:])
+}
+
+// LeafHash commits to a user's id, nonce and balances.
+func LeafHash(userId, nonce string, balances []decimal.Decimal) string {
+	return digest(leafPrefix, userId, nonce, encodeSums(balances))
+}
+
+// Leaf builds the tree node for one user.
+func Leaf(userId, nonce string, balances []decimal.Decimal) Node {
+	return Node{Hash: LeafHash(userId, nonce, balances), Sums: balances}
+}
+
+// Parent combines two child nodes.
+func Parent(left, right Node) Node {
+	sums := make([]decimal.Decimal, len(left.Sums))
+	for i := range sums {
+		sums[i] = left.Sums[i].Add(right.Sums[i])
+	}
+	return Node{
+		Hash: digest(nodePrefix, left.Hash, encodeSums(left.Sums), right.Hash, encodeSums(right.Sums)),
+		Sums: sums,
+	}
+}
+
+// Verify recomputes the root from the proof's leaf and path and checks it matches
+// the proof's root, and that every balance and sum is non-negative.
+func Verify(p Proof) error {
+	n := len(p.Assets)
+	if len(p.Balances) != n {
+		return fmt.Errorf("proof has %d balances for %d assets", len(p.Balances), n)
+	}
+	if err := checkSums(p.Balances, n); err != nil {
+		return fmt.Errorf("leaf: %w", err)
+	}
+
+	node := Leaf(p.UserId, p.Nonce, p.Balances)
+	for i, step := range p.Path {
+		if err := checkSums(step.Sums, n); err != nil {
+			return fmt.Errorf("path step %d: %w", i, err)
+		}
+		switch step.Side {
+		case SideLeft:
+			node = Parent(step.Node, node)
+		case SideRight:
+			node = Parent(node, step.Node)
+		default:
+			return fmt.Errorf("path step %d: invalid side %q", i, step.Side)
+		}
+	}
+
+	if len(p.Root.Sums) != n {
+		return fmt.Errorf("root has %d sums for %d assets", len(p.Root.Sums), n)
+	}
+	if node.Hash != p.Root.Hash {
+		return errors.New("computed root hash does not match published root")
+	}
+	for i := range node.Sums {
+		if !node.Sums[i].Equal(p.Root.Sums[i]) {
+			return fmt.Errorf("computed %s total %s does not match published total %s", p.Assets[i], node.Sums[i], p.Root.Sums[i])
+		}
+	}
+	return nil
+}
+
+func checkSums(sums []decimal.Decimal, n int) error {
+	if len(sums) != n {
+		return fmt.Errorf("expected %d sums, got %d", n, len(sums))
+	}
+	for _, s := range sums {
+		if s.IsNegative() {
+			return fmt.Errorf("negative sum %s", s)
+		}
+	}
+	return nil
+}
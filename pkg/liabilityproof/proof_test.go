@@ -0,0 +1,58 @@
+package liabilityproof
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+// twoLeafProof returns a proof for the left leaf of a two-leaf tree
+func twoLeafProof() Proof {
+	a := Leaf("u1", "n1", []decimal.Decimal{dec("1.5"), dec("0")})
+	b := Leaf("u2", "n2", []decimal.Decimal{dec("2"), dec("10")})
+	return Proof{
+		Assets:   []string{"BTC", "USDC"},
+		UserId:   "u1",
+		Nonce:    "n1",
+		Balances: []decimal.Decimal{dec("1.5"), dec("0")},
+		Path:     []Step{{Side: SideRight, Node: b}},
+		Root:     Parent(a, b),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	p := twoLeafProof()
+	if err := Verify(p); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got := p.Root.Sums[0]; !got.Equal(dec("3.5")) {
+		t.Errorf("root BTC sum = %s, want 3.5", got)
+	}
+}
+
+func TestVerify_RejectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(p *Proof)
+	}{
+		{"balance", func(p *Proof) { p.Balances[0] = dec("1") }},
+		{"user", func(p *Proof) { p.UserId = "u3" }},
+		{"nonce", func(p *Proof) { p.Nonce = "other" }},
+		{"sibling sum", func(p *Proof) { p.Path[0].Sums[1] = dec("9") }},
+		{"negative sibling", func(p *Proof) { p.Path[0].Sums[0] = dec("-1") }},
+		{"side", func(p *Proof) { p.Path[0].Side = SideLeft }},
+		{"root sum", func(p *Proof) { p.Root.Sums[0] = dec("4") }},
+		{"asset count", func(p *Proof) { p.Assets = p.Assets[:1] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := twoLeafProof()
+			tt.tamper(&p)
+			if err := Verify(p); err == nil {
+				t.Error("expected tampered proof to fail verification")
+			}
+		})
+	}
+}